@@ -23,7 +23,11 @@ func TestGetRecentEvents_ReturnsEmptyWhenCollectorMissing(t *testing.T) {
 
 func TestGetRecentEvents_ReturnsLatestN(t *testing.T) {
 	socketPath := filepath.Join(t.TempDir(), "collector.sock")
-	appServices := services.NewAppServicesWithOptions(services.Options{SocketPath: socketPath})
+	appServices := services.NewAppServicesWithOptions(services.Options{
+		SocketPath: socketPath,
+		PayloadDir: filepath.Join(t.TempDir(), "payloads"),
+		StoreDir:   filepath.Join(t.TempDir(), "sessions"),
+	})
 	if err := appServices.Lifecycle.ServiceStartup(context.Background(), application.ServiceOptions{}); err != nil {
 		t.Fatalf("Lifecycle.ServiceStartup() error = %v", err)
 	}
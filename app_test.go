@@ -19,7 +19,7 @@ func TestDecodeDumpEventNDJSONLine_EmptyLineIsIgnored(t *testing.T) {
 
 func TestDecodeDumpEventNDJSONLine_ValidHTTPEvent(t *testing.T) {
 	dumpService := services.NewAppServices().Dump
-	line := `{"schemaVersion":1,"id":"01JNFKEC8Q4Y8S97R2M5W12Q9H","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"http","projectRoot":"/home/ronald/code/example-app","phpSapi":"fpm-fcgi","requestId":"f2a1a3d2-2087-4dc4-9fc4-3f8e75ae3202","http":{"method":"GET","scheme":"https","host":"example.test","path":"/users/42"},"isDd":false,"payloadFormat":"json","payload":{"user":{"id":42}},"trace":[],"host":{"hostname":"ronald-linux","pid":48211}}`
+	line := `{"schemaVersion":1,"id":"01KJHZN2B34Y8S97R2M5W12Q9H","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"http","projectRoot":"/home/ronald/code/example-app","phpSapi":"fpm-fcgi","requestId":"f2a1a3d2-2087-4dc4-9fc4-3f8e75ae3202","http":{"method":"GET","scheme":"https","host":"example.test","path":"/users/42"},"isDd":false,"payloadFormat":"json","payload":{"user":{"id":42}},"trace":[],"host":{"hostname":"ronald-linux","pid":48211}}`
 
 	event, err := dumpService.DecodeDumpEventNDJSONLine(line)
 	if err != nil {
@@ -32,7 +32,7 @@ func TestDecodeDumpEventNDJSONLine_ValidHTTPEvent(t *testing.T) {
 
 func TestDecodeDumpEventNDJSONLine_ValidCLIEventWithNullRequestID(t *testing.T) {
 	dumpService := services.NewAppServices().Dump
-	line := `{"schemaVersion":1,"id":"01JNFKEPA3A4CNV3K2E12YVYTG","timestamp":"2026-02-28T11:21:18.011Z","sourceType":"cli","projectRoot":"/home/ronald/code/example-app","phpSapi":"cli","requestId":null,"command":{"name":"artisan","args":["queue:work"]},"isDd":false,"payloadFormat":"json","payload":{"ok":true},"trace":[],"host":{"hostname":"ronald-linux","pid":49302}}`
+	line := `{"schemaVersion":1,"id":"01KJHZPFXVA4CNV3K2E12YVYTG","timestamp":"2026-02-28T11:21:18.011Z","sourceType":"cli","projectRoot":"/home/ronald/code/example-app","phpSapi":"cli","requestId":null,"command":{"name":"artisan","args":["queue:work"]},"isDd":false,"payloadFormat":"json","payload":{"ok":true},"trace":[],"host":{"hostname":"ronald-linux","pid":49302}}`
 
 	event, err := dumpService.DecodeDumpEventNDJSONLine(line)
 	if err != nil {
@@ -57,13 +57,13 @@ func TestDecodeDumpEventNDJSONLine_InvalidCases(t *testing.T) {
 		},
 		{
 			name:    "unsupported schema version",
-			line:    `{"schemaVersion":2,"id":"1","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"http","projectRoot":"/x","phpSapi":"fpm-fcgi","requestId":"a","http":{"method":"GET","scheme":"https","host":"example.test","path":"/"},"isDd":false,"payloadFormat":"json","payload":{"k":"v"},"trace":[],"host":{"hostname":"h","pid":1}}`,
+			line:    `{"schemaVersion":3,"id":"1","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"http","projectRoot":"/x","phpSapi":"fpm-fcgi","requestId":"a","http":{"method":"GET","scheme":"https","host":"example.test","path":"/"},"isDd":false,"payloadFormat":"json","payload":{"k":"v"},"trace":[],"host":{"hostname":"h","pid":1}}`,
 			wantErr: services.ErrUnsupportedSchemaVersion.Error(),
 		},
 		{
 			name:    "invalid sourceType",
 			line:    `{"schemaVersion":1,"id":"1","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"job","projectRoot":"/x","phpSapi":"cli","requestId":null,"command":{"name":"artisan"},"isDd":false,"payloadFormat":"json","payload":{"k":"v"},"trace":[],"host":{"hostname":"h","pid":1}}`,
-			wantErr: "sourceType must be one of: http, cli, worker, cron",
+			wantErr: "sourceType must be one of: http, cli, worker, cron, log",
 		},
 		{
 			name:    "requestId wrong type",
@@ -81,9 +81,9 @@ func TestDecodeDumpEventNDJSONLine_InvalidCases(t *testing.T) {
 			wantErr: "trace must be an array",
 		},
 		{
-			name:    "payloadFormat not json",
-			line:    `{"schemaVersion":1,"id":"1","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"cli","projectRoot":"/x","phpSapi":"cli","requestId":null,"command":{"name":"artisan"},"isDd":false,"payloadFormat":"text","payload":{"k":"v"},"trace":[],"host":{"hostname":"h","pid":1}}`,
-			wantErr: "payloadFormat must be json for schemaVersion 1",
+			name:    "payloadFormat unknown",
+			line:    `{"schemaVersion":1,"id":"1","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"cli","projectRoot":"/x","phpSapi":"cli","requestId":null,"command":{"name":"artisan"},"isDd":false,"payloadFormat":"xml","payload":{"k":"v"},"trace":[],"host":{"hostname":"h","pid":1}}`,
+			wantErr: "payloadFormat must be one of: json, text, html",
 		},
 		{
 			name:    "http source missing http meta",
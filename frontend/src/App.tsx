@@ -124,13 +124,15 @@ function App() {
         let disposed = false;
         let unsubscribe: (() => void) | null = null;
 
-        const appendEvent = (event: DumpEvent) => {
+        const appendEvents = (batch: DumpEvent[]) => {
             setEvents((previousEvents) => {
-                if (previousEvents.some((existing) => existing.id === event.id)) {
+                const seen = new Set(previousEvents.map((existing) => existing.id));
+                const fresh = batch.filter((event) => !seen.has(event.id));
+                if (fresh.length === 0) {
                     return previousEvents;
                 }
 
-                const next = [...previousEvents, event];
+                const next = [...previousEvents, ...fresh];
                 if (next.length <= MAX_RENDERED_EVENTS) {
                     return next;
                 }
@@ -155,7 +157,8 @@ function App() {
             setEvents(recentEvents);
 
             unsubscribe = Events.On(resolvedChannel, (event) => {
-                appendEvent(event.data as DumpEvent);
+                const data = event.data as DumpEvent | DumpEvent[];
+                appendEvents(Array.isArray(data) ? data : [data]);
             });
         };
 
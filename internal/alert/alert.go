@@ -0,0 +1,291 @@
+// Package alert keeps user-defined alert rules, evaluates them against
+// incoming events, and records the alerts they raise. Carrying out an
+// alert's actions is left to the caller.
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+const (
+	FileName = "alerts.json"
+
+	MaxNameLength    = 100
+	MaxActions       = 8
+	MaxPatternLength = 1000
+	MaxThreshold     = 100000
+	MaxWindow        = time.Hour
+
+	DefaultWindow      = "10s"
+	DefaultHistorySize = 200
+)
+
+// Condition kinds.
+const (
+	// ConditionMatch raises an alert for every matching event.
+	ConditionMatch = "match"
+	// ConditionRate raises one when more than Threshold matching events
+	// arrive within Window, counted per GroupBy value.
+	ConditionRate = "rate"
+	// ConditionSlowQuery raises one for every matching event that reached
+	// the slow query threshold.
+	ConditionSlowQuery = "slowQuery"
+)
+
+// Rate groups, the event fields a rate condition counts separately.
+const (
+	GroupNone       = ""
+	GroupOrigin     = "origin"
+	GroupProject    = "project"
+	GroupHost       = "host"
+	GroupSourceType = "sourceType"
+)
+
+// Action kinds.
+const (
+	// ActionNotify shows a desktop notification.
+	ActionNotify = "notify"
+	// ActionWebhook posts the alert as JSON to URL.
+	ActionWebhook = "webhook"
+	// ActionTag adds Tag to the event that raised the alert.
+	ActionTag = "tag"
+)
+
+// Action outcomes.
+const (
+	StatusDone   = "done"
+	StatusFailed = "failed"
+)
+
+var (
+	ErrRuleNotFound = errors.New("alert rule not found")
+	ErrEmptyName    = errors.New("alert rule name must not be empty")
+	ErrNoActions    = errors.New("alert rule must have at least one action")
+)
+
+var groups = []string{GroupNone, GroupOrigin, GroupProject, GroupHost, GroupSourceType}
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:/-]{0,63}$`)
+
+// Condition selects the events a rule reacts to. Filter and Pattern, a
+// regular expression over the payload text, narrow every kind; Threshold,
+// Window, and GroupBy only apply to rate conditions.
+type Condition struct {
+	Kind      string       `json:"kind"`
+	Filter    query.Filter `json:"filter"`
+	Pattern   string       `json:"pattern,omitempty"`
+	Threshold int          `json:"threshold,omitempty"`
+	Window    string       `json:"window,omitempty"`
+	GroupBy   string       `json:"groupBy,omitempty"`
+}
+
+// Action is what to do when a rule raises an alert.
+type Action struct {
+	Kind string `json:"kind"`
+	URL  string `json:"url,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+// Rule raises an alert when its condition is met, at most once per
+// Cooldown for each group of a rate condition, or for the rule otherwise.
+type Rule struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Enabled   bool      `json:"enabled"`
+	Condition Condition `json:"condition"`
+	Actions   []Action  `json:"actions"`
+	Cooldown  string    `json:"cooldown,omitempty"`
+	UpdatedAt string    `json:"updatedAt"`
+}
+
+func (r Rule) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+// Alert is one firing of a rule, raised by EventID. Count is how many
+// events the rate condition counted, and 1 otherwise.
+type Alert struct {
+	ID       string         `json:"id"`
+	RuleID   string         `json:"ruleId"`
+	RuleName string         `json:"ruleName"`
+	EventID  string         `json:"eventId"`
+	Group    string         `json:"group,omitempty"`
+	Count    int            `json:"count"`
+	Message  string         `json:"message"`
+	At       string         `json:"at"`
+	Actions  []ActionResult `json:"actions"`
+}
+
+// ActionResult is the outcome of one action of an alert. Target is the
+// webhook URL or the tag.
+type ActionResult struct {
+	Kind   string `json:"kind"`
+	Target string `json:"target,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type compiledRule struct {
+	Rule
+	matcher  query.Matcher
+	pattern  *regexp.Regexp
+	window   time.Duration
+	cooldown time.Duration
+}
+
+func (r Rule) compile() (compiledRule, error) {
+	if strings.TrimSpace(r.Name) == "" {
+		return compiledRule{}, ErrEmptyName
+	}
+	if len(r.Name) > MaxNameLength {
+		return compiledRule{}, fmt.Errorf("alert rule name must be at most %d characters", MaxNameLength)
+	}
+
+	compiled := compiledRule{Rule: r}
+	condition := r.Condition
+	switch condition.Kind {
+	case ConditionMatch, ConditionSlowQuery:
+	case ConditionRate:
+		if condition.Threshold < 1 || condition.Threshold > MaxThreshold {
+			return compiledRule{}, fmt.Errorf("threshold must be between 1 and %d", MaxThreshold)
+		}
+		if !slices.Contains(groups, condition.GroupBy) {
+			return compiledRule{}, errors.New("groupBy must be empty or one of: origin, project, host, sourceType")
+		}
+		window, err := parseDuration("window", condition.Window, DefaultWindow)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		compiled.window = window
+	default:
+		return compiledRule{}, errors.New("condition kind must be one of: match, rate, slowQuery")
+	}
+
+	matcher, err := condition.Filter.Compile()
+	if err != nil {
+		return compiledRule{}, err
+	}
+	compiled.matcher = matcher
+
+	if condition.Pattern != "" {
+		if len(condition.Pattern) > MaxPatternLength {
+			return compiledRule{}, fmt.Errorf("pattern must be at most %d characters", MaxPatternLength)
+		}
+		if compiled.pattern, err = regexp.Compile(condition.Pattern); err != nil {
+			return compiledRule{}, fmt.Errorf("pattern: %w", err)
+		}
+	}
+
+	if r.Cooldown != "" {
+		if compiled.cooldown, err = parseDuration("cooldown", r.Cooldown, ""); err != nil {
+			return compiledRule{}, err
+		}
+	}
+
+	if len(r.Actions) == 0 {
+		return compiledRule{}, ErrNoActions
+	}
+	if len(r.Actions) > MaxActions {
+		return compiledRule{}, fmt.Errorf("alert rule must have at most %d actions", MaxActions)
+	}
+	for i, action := range r.Actions {
+		if err := action.validate(); err != nil {
+			return compiledRule{}, fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
+	return compiled, nil
+}
+
+func (a Action) validate() error {
+	switch a.Kind {
+	case ActionNotify:
+	case ActionWebhook:
+		target, err := url.Parse(a.URL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return errors.New("url must be an absolute http or https URL")
+		}
+	case ActionTag:
+		if !tagPattern.MatchString(a.Tag) {
+			return fmt.Errorf("tag %q must be a letter or digit followed by letters, digits, or _ . : / - (at most 64)", a.Tag)
+		}
+	default:
+		return errors.New("action kind must be one of: notify, webhook, tag")
+	}
+	return nil
+}
+
+func (a Action) target() string {
+	if a.Kind == ActionTag {
+		return a.Tag
+	}
+	return a.URL
+}
+
+func parseDuration(name string, text string, fallback string) (time.Duration, error) {
+	if text == "" {
+		text = fallback
+	}
+	duration, err := query.ParseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if duration <= 0 || duration > MaxWindow {
+		return 0, fmt.Errorf("%s must be positive and at most 1h", name)
+	}
+	return duration, nil
+}
+
+// matches reports whether event meets the rule's condition, rates aside.
+func (c compiledRule) matches(event dump.Event) bool {
+	if c.Condition.Kind == ConditionSlowQuery && !event.SlowQuery {
+		return false
+	}
+	if !c.matcher.Match(event) {
+		return false
+	}
+	return c.pattern == nil || c.pattern.MatchString(event.PayloadString())
+}
+
+// group is the value a rate condition counts event under.
+func (c compiledRule) group(event dump.Event) string {
+	switch c.Condition.GroupBy {
+	case GroupOrigin:
+		return query.Origin(event)
+	case GroupProject:
+		return event.ProjectRoot
+	case GroupHost:
+		return event.Host.Hostname
+	case GroupSourceType:
+		return event.SourceType
+	}
+	return ""
+}
+
+func (c compiledRule) message(event dump.Event, group string, count int) string {
+	switch c.Condition.Kind {
+	case ConditionRate:
+		if group == "" {
+			return fmt.Sprintf("%d events within %s", count, c.window)
+		}
+		return fmt.Sprintf("%d events from %s within %s", count, group, c.window)
+	case ConditionSlowQuery:
+		if event.DurationMs != nil {
+			return fmt.Sprintf("slow query took %.0f ms", *event.DurationMs)
+		}
+		return "slow query"
+	}
+	if event.Label != "" {
+		return fmt.Sprintf("%s event %q matched", event.SourceType, event.Label)
+	}
+	return fmt.Sprintf("%s event matched", event.SourceType)
+}
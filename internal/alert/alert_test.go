@@ -0,0 +1,169 @@
+package alert
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+func durationMs(ms float64) *float64 {
+	return &ms
+}
+
+func openEngine(t *testing.T, rules ...Rule) *Engine {
+	t.Helper()
+	engine, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := engine.Merge(rules); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	return engine
+}
+
+func TestEvaluate_RateCountsPerGroupWithinWindow(t *testing.T) {
+	engine := openEngine(t, Rule{
+		Name:      "noisy origin",
+		Enabled:   true,
+		Condition: Condition{Kind: ConditionRate, Threshold: 2, Window: "10s", GroupBy: GroupOrigin},
+		Actions:   []Action{{Kind: ActionNotify}},
+	})
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(file string) dump.Event {
+		return dump.Event{ID: file, SourceType: "cli", Trace: []dump.TraceFrame{{File: file, Line: 1}}}
+	}
+
+	var fired []Alert
+	for i, file := range []string{"/a.php", "/a.php", "/b.php", "/a.php", "/a.php"} {
+		fired = append(fired, engine.Evaluate(at(file), start.Add(time.Duration(i)*time.Second))...)
+	}
+	if len(fired) != 1 || fired[0].Group != "/a.php:1" || fired[0].Count != 3 {
+		t.Fatalf("Evaluate() alerts = %+v, want one for /a.php:1 counting 3", fired)
+	}
+	if fired[0].Message != "3 events from /a.php:1 within 10s" {
+		t.Fatalf("Evaluate() message = %q", fired[0].Message)
+	}
+
+	// The burst was reset and the earlier events left the window.
+	if alerts := engine.Evaluate(at("/a.php"), start.Add(20*time.Second)); len(alerts) != 0 {
+		t.Fatalf("Evaluate(after window) alerts = %+v, want none", alerts)
+	}
+}
+
+func TestEvaluate_MatchesPatternsAndSlowQueriesWithCooldown(t *testing.T) {
+	engine := openEngine(t,
+		Rule{
+			Name:      "payment failed",
+			Enabled:   true,
+			Condition: Condition{Kind: ConditionMatch, Filter: query.Filter{ProjectRoot: "/app"}, Pattern: `payment (declined|failed)`},
+			Actions:   []Action{{Kind: ActionTag, Tag: "payments"}, {Kind: ActionWebhook, URL: "https://hooks.example.test/alert"}},
+			Cooldown:  "1m",
+		},
+		Rule{
+			Name:      "slow queries",
+			Enabled:   true,
+			Condition: Condition{Kind: ConditionSlowQuery},
+			Actions:   []Action{{Kind: ActionNotify}},
+		},
+		Rule{
+			Name:      "disabled",
+			Condition: Condition{Kind: ConditionMatch},
+			Actions:   []Action{{Kind: ActionNotify}},
+		},
+	)
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	failed := dump.Event{ID: "1", SourceType: "http", ProjectRoot: "/app", PayloadFormat: dump.PayloadFormatJSON, Payload: []byte(`{"status":"payment declined"}`)}
+
+	alerts := engine.Evaluate(failed, now)
+	if len(alerts) != 1 || alerts[0].RuleName != "payment failed" || alerts[0].EventID != "1" {
+		t.Fatalf("Evaluate(declined) = %+v, want the payment rule", alerts)
+	}
+	want := []ActionResult{{Kind: ActionTag, Target: "payments"}, {Kind: ActionWebhook, Target: "https://hooks.example.test/alert"}}
+	if len(alerts[0].Actions) != 2 || alerts[0].Actions[0] != want[0] || alerts[0].Actions[1] != want[1] {
+		t.Fatalf("Evaluate(declined) actions = %+v, want %+v", alerts[0].Actions, want)
+	}
+	if alerts := engine.Evaluate(failed, now.Add(30*time.Second)); len(alerts) != 0 {
+		t.Fatalf("Evaluate(within cooldown) = %+v, want none", alerts)
+	}
+	if alerts := engine.Evaluate(failed, now.Add(time.Minute)); len(alerts) != 1 {
+		t.Fatalf("Evaluate(after cooldown) = %+v, want one", alerts)
+	}
+
+	slow := dump.Event{ID: "2", SourceType: "http", ProjectRoot: "/other", SlowQuery: true, DurationMs: durationMs(1250)}
+	alerts = engine.Evaluate(slow, now)
+	if len(alerts) != 1 || alerts[0].Message != "slow query took 1250 ms" {
+		t.Fatalf("Evaluate(slow query) = %+v, want the slow query rule", alerts)
+	}
+}
+
+func TestEngine_PersistsRulesAndKeepsHistory(t *testing.T) {
+	dir := t.TempDir()
+	engine, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	saved, err := engine.Merge([]Rule{{Name: "errors", Enabled: true, Condition: Condition{Kind: ConditionMatch}, Actions: []Action{{Kind: ActionNotify}}}})
+	if err != nil || saved[0].ID == "" || saved[0].UpdatedAt == "" {
+		t.Fatalf("Merge() = %+v, %v, want an ID and update time assigned", saved, err)
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open(reopened) error = %v", err)
+	}
+	if rules := reopened.Rules(); len(rules) != 1 || rules[0].ID != saved[0].ID {
+		t.Fatalf("Rules() = %+v, want the saved rule", rules)
+	}
+
+	for i := range DefaultHistorySize + 1 {
+		reopened.Record(Alert{ID: strings.Repeat("x", i+1), RuleID: saved[0].ID})
+	}
+	history := reopened.History(saved[0].ID)
+	if len(history) != DefaultHistorySize || len(history[0].ID) != DefaultHistorySize+1 {
+		t.Fatalf("History() = %d alerts, newest %q, want %d, newest first", len(history), history[0].ID, DefaultHistorySize)
+	}
+	if other := reopened.History("other"); len(other) != 0 {
+		t.Fatalf("History(other) = %d alerts, want none", len(other))
+	}
+
+	if err := reopened.Delete(saved[0].ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := reopened.Delete(saved[0].ID); !errors.Is(err, ErrRuleNotFound) {
+		t.Fatalf("Delete(again) error = %v, want %v", err, ErrRuleNotFound)
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	valid := Rule{Name: "r", Condition: Condition{Kind: ConditionMatch}, Actions: []Action{{Kind: ActionNotify}}}
+	tests := map[string]struct {
+		change func(*Rule)
+		want   string
+	}{
+		"name":      {func(r *Rule) { r.Name = " " }, "name must not be empty"},
+		"kind":      {func(r *Rule) { r.Condition.Kind = "sometimes" }, "condition kind"},
+		"threshold": {func(r *Rule) { r.Condition.Kind = ConditionRate }, "threshold"},
+		"window":    {func(r *Rule) { r.Condition = Condition{Kind: ConditionRate, Threshold: 5, Window: "2h"} }, "window"},
+		"group":     {func(r *Rule) { r.Condition = Condition{Kind: ConditionRate, Threshold: 5, GroupBy: "user"} }, "groupBy"},
+		"pattern":   {func(r *Rule) { r.Condition.Pattern = "(" }, "pattern"},
+		"actions":   {func(r *Rule) { r.Actions = nil }, "at least one action"},
+		"webhook":   {func(r *Rule) { r.Actions = []Action{{Kind: ActionWebhook, URL: "ftp://x"}} }, "url"},
+		"tag":       {func(r *Rule) { r.Actions = []Action{{Kind: ActionTag, Tag: "Bad Tag"}} }, "tag"},
+	}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for name, test := range tests {
+		rule := valid
+		test.change(&rule)
+		if err := rule.Validate(); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("Validate(%s) error = %v, want %q", name, err, test.want)
+		}
+	}
+}
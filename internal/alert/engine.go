@@ -0,0 +1,329 @@
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// maxGroups bounds the rate windows kept; past it, windows whose events
+// have all expired are dropped.
+const maxGroups = 10000
+
+type groupKey struct {
+	ruleID string
+	group  string
+}
+
+// Engine holds every rule in memory and rewrites its file after each
+// change. Rate windows and the alert history are kept in memory only.
+type Engine struct {
+	path string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	rules   []compiledRule
+	windows map[groupKey][]time.Time
+	fired   map[groupKey]time.Time
+
+	historyMu sync.Mutex
+	history   []Alert
+	next      int
+	size      int
+}
+
+// Open loads the rules stored in dir, if any.
+func Open(dir string) (*Engine, error) {
+	engine := &Engine{
+		path:    filepath.Join(dir, FileName),
+		now:     time.Now,
+		windows: map[groupKey][]time.Time{},
+		fired:   map[groupKey]time.Time{},
+		size:    DefaultHistorySize,
+	}
+
+	data, err := os.ReadFile(engine.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return engine, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", engine.path, err)
+	}
+	for _, rule := range rules {
+		compiled, err := rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("%s: rule %q: %w", engine.path, rule.Name, err)
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+	return engine, nil
+}
+
+// Rules returns the rules sorted by name, case-insensitively.
+func (e *Engine) Rules() []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := make([]Rule, len(e.rules))
+	for i, rule := range e.rules {
+		rules[i] = rule.Rule
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return strings.ToLower(rules[i].Name) < strings.ToLower(rules[j].Name)
+	})
+	return rules
+}
+
+// Merge saves several rules at once, replacing those with the same ID and
+// assigning one to rules without. Nothing is saved if any rule is invalid.
+func (e *Engine) Merge(rules []Rule) ([]Rule, error) {
+	updatedAt := e.now().UTC().Format(time.RFC3339Nano)
+	compiled := make([]compiledRule, len(rules))
+	saved := make([]Rule, len(rules))
+	for i, rule := range rules {
+		rule.Name = strings.TrimSpace(rule.Name)
+		if rule.ID == "" {
+			rule.ID = newID()
+		}
+		rule.UpdatedAt = updatedAt
+		c, err := rule.compile()
+		if err != nil {
+			return nil, err
+		}
+		compiled[i] = c
+		saved[i] = rule
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	previous := e.rules
+	updated := append([]compiledRule{}, e.rules...)
+	for _, rule := range compiled {
+		index := e.indexLocked(updated, rule.ID)
+		if index < 0 {
+			updated = append(updated, rule)
+			continue
+		}
+		updated[index] = rule
+		e.resetLocked(rule.ID)
+	}
+	e.rules = updated
+	if err := e.saveLocked(); err != nil {
+		e.rules = previous
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (e *Engine) Delete(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	index := e.indexLocked(e.rules, id)
+	if index < 0 {
+		return ErrRuleNotFound
+	}
+	previous := e.rules
+	e.rules = append(append([]compiledRule{}, e.rules[:index]...), e.rules[index+1:]...)
+	if err := e.saveLocked(); err != nil {
+		e.rules = previous
+		return err
+	}
+	e.resetLocked(id)
+	return nil
+}
+
+// Evaluate checks event against every enabled rule at now, when the event
+// arrived, and returns the alerts it raised. Their actions are listed with
+// an empty status for the caller to carry out before calling Record.
+func (e *Engine) Evaluate(event dump.Event, now time.Time) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var alerts []Alert
+	for _, rule := range e.rules {
+		if !rule.Enabled || !rule.matches(event) {
+			continue
+		}
+
+		key := groupKey{ruleID: rule.ID}
+		count := 1
+		if rule.Condition.Kind == ConditionRate {
+			key.group = rule.group(event)
+			count = e.countLocked(key, rule.window, now)
+			if count <= rule.Condition.Threshold {
+				continue
+			}
+		}
+		if last, ok := e.fired[key]; ok && now.Sub(last) < rule.cooldown {
+			continue
+		}
+		// A rate fires once per burst: the next alert needs Threshold more
+		// events.
+		delete(e.windows, key)
+		e.fired[key] = now
+
+		alerts = append(alerts, newAlert(rule, event, key.group, count, now))
+	}
+	return alerts
+}
+
+// countLocked adds an event at now to key's window and returns how many
+// events it holds.
+func (e *Engine) countLocked(key groupKey, window time.Duration, now time.Time) int {
+	if len(e.windows) >= maxGroups {
+		e.sweepLocked(now)
+	}
+
+	times := e.windows[key]
+	start := 0
+	for start < len(times) && now.Sub(times[start]) >= window {
+		start++
+	}
+	times = append(times[start:], now)
+	e.windows[key] = times
+	return len(times)
+}
+
+func (e *Engine) sweepLocked(now time.Time) {
+	for key, times := range e.windows {
+		if now.Sub(times[len(times)-1]) >= MaxWindow {
+			delete(e.windows, key)
+		}
+	}
+	for key, at := range e.fired {
+		if now.Sub(at) >= MaxWindow {
+			delete(e.fired, key)
+		}
+	}
+}
+
+func (e *Engine) resetLocked(ruleID string) {
+	for key := range e.windows {
+		if key.ruleID == ruleID {
+			delete(e.windows, key)
+		}
+	}
+	for key := range e.fired {
+		if key.ruleID == ruleID {
+			delete(e.fired, key)
+		}
+	}
+}
+
+func (e *Engine) indexLocked(rules []compiledRule, id string) int {
+	for i, rule := range rules {
+		if rule.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func newAlert(rule compiledRule, event dump.Event, group string, count int, now time.Time) Alert {
+	alert := Alert{
+		ID:       newID(),
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		EventID:  event.ID,
+		Group:    group,
+		Count:    count,
+		Message:  rule.message(event, group, count),
+		At:       now.UTC().Format(time.RFC3339Nano),
+		Actions:  make([]ActionResult, len(rule.Actions)),
+	}
+	for i, action := range rule.Actions {
+		alert.Actions[i] = ActionResult{Kind: action.Kind, Target: action.target()}
+	}
+	return alert
+}
+
+// Record adds an alert whose actions were carried out to the history,
+// which keeps the latest DefaultHistorySize.
+func (e *Engine) Record(alert Alert) {
+	e.historyMu.Lock()
+	defer e.historyMu.Unlock()
+
+	if len(e.history) < e.size {
+		e.history = append(e.history, alert)
+		e.next = len(e.history) % e.size
+		return
+	}
+	e.history[e.next] = alert
+	e.next = (e.next + 1) % e.size
+}
+
+// History returns recorded alerts, newest first, narrowed to one rule when
+// ruleID is set.
+func (e *Engine) History(ruleID string) []Alert {
+	e.historyMu.Lock()
+	defer e.historyMu.Unlock()
+
+	alerts := make([]Alert, 0, len(e.history))
+	for i := 1; i <= len(e.history); i++ {
+		alert := e.history[(e.next-i+len(e.history))%len(e.history)]
+		if ruleID == "" || alert.RuleID == ruleID {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+func (e *Engine) ClearHistory() {
+	e.historyMu.Lock()
+	defer e.historyMu.Unlock()
+
+	e.history = nil
+	e.next = 0
+}
+
+func (e *Engine) saveLocked() error {
+	rules := make([]Rule, len(e.rules))
+	for i, rule := range e.rules {
+		rules[i] = rule.Rule
+	}
+
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(e.path), FileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), e.path)
+}
+
+func newID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
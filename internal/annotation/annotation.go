@@ -0,0 +1,276 @@
+// Package annotation keeps user annotations on events (pins, notes, and
+// tags) in a small JSON file next to the session store.
+package annotation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FileName = "annotations.json"
+
+	MaxNoteLength = 10000
+	MaxTags       = 32
+)
+
+var ErrEmptyEventID = errors.New("event id must not be empty")
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:/-]{0,63}$`)
+
+type Annotation struct {
+	EventID   string   `json:"eventId"`
+	Pinned    bool     `json:"pinned,omitempty"`
+	Note      string   `json:"note,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	UpdatedAt string   `json:"updatedAt"`
+}
+
+func (a Annotation) empty() bool {
+	return !a.Pinned && a.Note == "" && len(a.Tags) == 0
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// Set holds every annotation in memory and rewrites its file after each
+// change. An annotation left with no pin, note, or tags is removed.
+type Set struct {
+	path string
+	now  func() time.Time
+
+	mu   sync.RWMutex
+	byID map[string]Annotation
+}
+
+// Open loads the annotations stored in dir, if any.
+func Open(dir string) (*Set, error) {
+	set := &Set{path: filepath.Join(dir, FileName), now: time.Now, byID: map[string]Annotation{}}
+
+	data, err := os.ReadFile(set.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return set, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var annotations []Annotation
+	if err := json.Unmarshal(data, &annotations); err != nil {
+		return nil, fmt.Errorf("%s: %w", set.path, err)
+	}
+	for _, annotation := range annotations {
+		set.byID[annotation.EventID] = annotation
+	}
+	return set, nil
+}
+
+func (s *Set) Get(eventID string) (Annotation, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	annotation, ok := s.byID[eventID]
+	return annotation, ok
+}
+
+// All lists annotations, most recently updated first.
+func (s *Set) All() []Annotation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	annotations := make([]Annotation, 0, len(s.byID))
+	for _, annotation := range s.byID {
+		annotations = append(annotations, annotation)
+	}
+	sort.Slice(annotations, func(i, j int) bool {
+		if annotations[i].UpdatedAt != annotations[j].UpdatedAt {
+			return annotations[i].UpdatedAt > annotations[j].UpdatedAt
+		}
+		return annotations[i].EventID < annotations[j].EventID
+	})
+	return annotations
+}
+
+func (s *Set) Pin(eventID string, pinned bool) (Annotation, error) {
+	return s.update(eventID, func(a *Annotation) error {
+		a.Pinned = pinned
+		return nil
+	})
+}
+
+func (s *Set) SetNote(eventID string, note string) (Annotation, error) {
+	return s.update(eventID, func(a *Annotation) error {
+		return a.setNote(note)
+	})
+}
+
+// SetTags replaces an event's tags. Tags are lowercased, deduplicated, and
+// sorted; each is a letter or digit followed by up to 63 of letters,
+// digits, and _ . : / -.
+func (s *Set) SetTags(eventID string, tags []string) (Annotation, error) {
+	return s.update(eventID, func(a *Annotation) error {
+		return a.setTags(tags)
+	})
+}
+
+// Put replaces an event's pin, note, and tags with those of annotation at
+// once, as importing a session export does.
+func (s *Set) Put(annotation Annotation) (Annotation, error) {
+	return s.update(annotation.EventID, func(a *Annotation) error {
+		a.Pinned = annotation.Pinned
+		if err := a.setNote(annotation.Note); err != nil {
+			return err
+		}
+		return a.setTags(annotation.Tags)
+	})
+}
+
+func (a *Annotation) setNote(note string) error {
+	note = strings.TrimSpace(note)
+	if len(note) > MaxNoteLength {
+		return fmt.Errorf("note must be at most %d characters", MaxNoteLength)
+	}
+	a.Note = note
+	return nil
+}
+
+func (a *Annotation) setTags(tags []string) error {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return fmt.Errorf("tag %q must be a letter or digit followed by letters, digits, or _ . : / - (at most 64)", tag)
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	if len(normalized) > MaxTags {
+		return fmt.Errorf("an event can have at most %d tags", MaxTags)
+	}
+	if len(normalized) == 0 {
+		normalized = nil
+	}
+	a.Tags = normalized
+	return nil
+}
+
+func (s *Set) Delete(eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byID[eventID]; !ok {
+		return nil
+	}
+	delete(s.byID, eventID)
+	return s.saveLocked()
+}
+
+func (s *Set) Pinned(eventID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.byID[eventID].Pinned
+}
+
+func (s *Set) HasTag(eventID string, tag string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, found := slices.BinarySearch(s.byID[eventID].Tags, strings.ToLower(tag))
+	return found
+}
+
+// Tags counts how many events carry each tag, most used first.
+func (s *Set) Tags() []TagCount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, annotation := range s.byID {
+		for _, tag := range annotation.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags
+}
+
+func (s *Set) update(eventID string, change func(*Annotation) error) (Annotation, error) {
+	if eventID == "" {
+		return Annotation{}, ErrEmptyEventID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	annotation := s.byID[eventID]
+	annotation.EventID = eventID
+	if err := change(&annotation); err != nil {
+		return Annotation{}, err
+	}
+	annotation.UpdatedAt = s.now().UTC().Format(time.RFC3339Nano)
+
+	previous, existed := s.byID[eventID]
+	if annotation.empty() {
+		delete(s.byID, eventID)
+	} else {
+		s.byID[eventID] = annotation
+	}
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.byID[eventID] = previous
+		} else {
+			delete(s.byID, eventID)
+		}
+		return Annotation{}, err
+	}
+	return annotation, nil
+}
+
+func (s *Set) saveLocked() error {
+	annotations := make([]Annotation, 0, len(s.byID))
+	for _, annotation := range s.byID {
+		annotations = append(annotations, annotation)
+	}
+	sort.Slice(annotations, func(i, j int) bool { return annotations[i].EventID < annotations[j].EventID })
+
+	data, err := json.MarshalIndent(annotations, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), FileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
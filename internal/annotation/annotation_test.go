@@ -0,0 +1,60 @@
+package annotation
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSet_PersistsPinsNotesAndTags(t *testing.T) {
+	dir := t.TempDir()
+	set, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if _, err := set.Pin("evt-1", true); err != nil {
+		t.Fatalf("Pin() error = %v", err)
+	}
+	if _, err := set.SetNote("evt-1", "  cart total is off by one  "); err != nil {
+		t.Fatalf("SetNote() error = %v", err)
+	}
+	annotation, err := set.SetTags("evt-2", []string{"Checkout", "bug", "checkout"})
+	if err != nil {
+		t.Fatalf("SetTags() error = %v", err)
+	}
+	if !slices.Equal(annotation.Tags, []string{"bug", "checkout"}) {
+		t.Fatalf("SetTags() tags = %v, want normalized", annotation.Tags)
+	}
+	set.SetTags("evt-1", []string{"bug"})
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open(again) error = %v", err)
+	}
+	if got, _ := reopened.Get("evt-1"); !got.Pinned || got.Note != "cart total is off by one" {
+		t.Fatalf("Get(evt-1) = %+v, want pinned with trimmed note", got)
+	}
+	if !reopened.HasTag("evt-2", "CHECKOUT") || reopened.HasTag("evt-1", "checkout") || !reopened.Pinned("evt-1") {
+		t.Fatalf("HasTag/Pinned after reopen do not match the saved annotations")
+	}
+	if want := []TagCount{{"bug", 2}, {"checkout", 1}}; !slices.Equal(reopened.Tags(), want) {
+		t.Fatalf("Tags() = %v, want %v", reopened.Tags(), want)
+	}
+}
+
+func TestSet_DropsEmptyAnnotationsAndRejectsBadTags(t *testing.T) {
+	set, _ := Open(t.TempDir())
+
+	set.Pin("evt-1", true)
+	set.Pin("evt-1", false)
+	if _, ok := set.Get("evt-1"); ok || len(set.All()) != 0 {
+		t.Fatalf("Get(unpinned) found an annotation, want it removed")
+	}
+
+	if _, err := set.SetTags("evt-1", []string{"has space"}); err == nil {
+		t.Fatalf("SetTags(has space) error = nil, want invalid tag")
+	}
+	if _, err := set.Pin("", true); err != ErrEmptyEventID {
+		t.Fatalf("Pin(empty id) error = %v, want ErrEmptyEventID", err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package archive
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile falls back to reading the segment into memory where mmap is not
+// available through the syscall package.
+func mapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,237 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// Reader serves a segment from a read-only memory mapping, so searching a
+// cold archive only decompresses the blocks it visits.
+type Reader struct {
+	data  []byte
+	unmap func() error
+	index Index
+}
+
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	data, unmap, err := mapFile(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &Reader{data: data, unmap: unmap}
+	if err := reader.readIndex(); err != nil {
+		_ = unmap()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return reader, nil
+}
+
+func (r *Reader) Close() error {
+	if r.unmap == nil {
+		return nil
+	}
+	err := r.unmap()
+	r.unmap = nil
+	r.data = nil
+	return err
+}
+
+func (r *Reader) Index() Index {
+	return r.index
+}
+
+func (r *Reader) readIndex() error {
+	size := int64(len(r.data))
+	if size < int64(len(segmentMagic))+footerTrailerSize ||
+		!bytes.Equal(r.data[:len(segmentMagic)], segmentMagic) ||
+		!bytes.Equal(r.data[size-int64(len(segmentMagic)):], segmentMagic) {
+		return ErrInvalidSegment
+	}
+
+	lengthAt := size - footerTrailerSize
+	footerLength := int64(binary.LittleEndian.Uint64(r.data[lengthAt : lengthAt+8]))
+	footerAt := lengthAt - footerLength
+	if footerLength <= 0 || footerAt < int64(len(segmentMagic)) {
+		return ErrInvalidSegment
+	}
+
+	if err := json.Unmarshal(r.data[footerAt:lengthAt], &r.index); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSegment, err)
+	}
+	for _, block := range r.index.Blocks {
+		if block.Offset < int64(len(segmentMagic)) || block.Length <= 0 || block.Offset+block.Length > footerAt {
+			return ErrInvalidSegment
+		}
+	}
+	return nil
+}
+
+// Block decodes the events of a single block.
+func (r *Reader) Block(i int) ([]dump.Event, error) {
+	events := []dump.Event{}
+	err := r.scanBlock(i, func(line []byte) (bool, error) {
+		var event dump.Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return false, err
+		}
+		events = append(events, event)
+		return true, nil
+	})
+	return events, err
+}
+
+// Search returns up to limit events whose encoded form contains query, in
+// archive order. Lines are matched before they are decoded, and blocks whose
+// time range falls outside [from, to] are skipped; empty bounds are open.
+// Bounds are RFC 3339 times; with either set, events whose timestamp does
+// not parse are left out.
+func (r *Reader) Search(query string, from string, to string, limit int) ([]dump.Event, error) {
+	window, err := parseWindow(from, to)
+	if err != nil {
+		return nil, err
+	}
+	needle := []byte(query)
+	matches := []dump.Event{}
+
+	for i, block := range r.index.Blocks {
+		if !window.overlaps(block.FirstTimestamp, block.LastTimestamp) {
+			continue
+		}
+
+		err := r.scanBlock(i, func(line []byte) (bool, error) {
+			if !bytes.Contains(line, needle) {
+				return true, nil
+			}
+
+			var event dump.Event
+			if err := json.Unmarshal(line, &event); err != nil {
+				return false, err
+			}
+			if !window.contains(event.Timestamp) {
+				return true, nil
+			}
+
+			matches = append(matches, event)
+			return limit <= 0 || len(matches) < limit, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if limit > 0 && len(matches) >= limit {
+			break
+		}
+	}
+
+	return matches, nil
+}
+
+// window is a search time range; zero bounds are open.
+type window struct {
+	from time.Time
+	to   time.Time
+}
+
+func parseWindow(from string, to string) (window, error) {
+	var w window
+	var err error
+	if from != "" {
+		if w.from, err = time.Parse(time.RFC3339Nano, from); err != nil {
+			return window{}, fmt.Errorf("invalid search start %q: %w", from, err)
+		}
+	}
+	if to != "" {
+		if w.to, err = time.Parse(time.RFC3339Nano, to); err != nil {
+			return window{}, fmt.Errorf("invalid search end %q: %w", to, err)
+		}
+	}
+	return w, nil
+}
+
+func (w window) open() bool {
+	return w.from.IsZero() && w.to.IsZero()
+}
+
+func (w window) contains(timestamp string) bool {
+	if w.open() {
+		return true
+	}
+	at, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return false
+	}
+	return (w.from.IsZero() || !at.Before(w.from)) && (w.to.IsZero() || !at.After(w.to))
+}
+
+// overlaps reports whether a block's time range may hold events in the
+// window. A block without a usable range is always visited.
+func (w window) overlaps(first string, last string) bool {
+	if w.open() {
+		return true
+	}
+	firstAt, err := time.Parse(time.RFC3339Nano, first)
+	if err != nil {
+		return true
+	}
+	lastAt, err := time.Parse(time.RFC3339Nano, last)
+	if err != nil {
+		return true
+	}
+	return (w.from.IsZero() || !lastAt.Before(w.from)) && (w.to.IsZero() || !firstAt.After(w.to))
+}
+
+func (r *Reader) scanBlock(i int, visit func(line []byte) (bool, error)) error {
+	if r.data == nil {
+		return os.ErrClosed
+	}
+	if i < 0 || i >= len(r.index.Blocks) {
+		return fmt.Errorf("archive block %d out of range", i)
+	}
+
+	block := r.index.Blocks[i]
+	gz, err := gzip.NewReader(bytes.NewReader(r.data[block.Offset : block.Offset+block.Length]))
+	if err != nil {
+		return fmt.Errorf("%w: block %d: %v", ErrInvalidSegment, i, err)
+	}
+	defer gz.Close()
+
+	reader := bufio.NewReader(gz)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			more, visitErr := visit(line)
+			if visitErr != nil {
+				return visitErr
+			}
+			if !more {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// Segment layout:
+//
+//	magic | block... | index JSON | index length (uint64 LE) | magic
+//
+// Each block is a gzip member holding NDJSON events. The index footer lets a
+// reader locate and decompress single blocks without touching the rest.
+var segmentMagic = []byte("PHNTSEG1")
+
+const (
+	DefaultBlockEvents = 256
+	footerTrailerSize  = 8 + 8
+)
+
+var ErrInvalidSegment = errors.New("invalid archive segment")
+
+type BlockIndex struct {
+	Offset         int64  `json:"offset"`
+	Length         int64  `json:"length"`
+	Events         int    `json:"events"`
+	FirstTimestamp string `json:"firstTimestamp"`
+	LastTimestamp  string `json:"lastTimestamp"`
+}
+
+type Index struct {
+	Version int          `json:"version"`
+	Events  int          `json:"events"`
+	Blocks  []BlockIndex `json:"blocks"`
+}
+
+// WriteSegment writes events, oldest first, as a compressed segment. The file
+// is written to a temporary name and renamed so readers never see a partial
+// segment.
+func WriteSegment(path string, events []dump.Event, blockEvents int) (Index, error) {
+	writer, err := CreateSegment(path, blockEvents)
+	if err != nil {
+		return Index{}, err
+	}
+	for _, event := range events {
+		if err := writer.Write(event); err != nil {
+			writer.Abort()
+			return Index{}, err
+		}
+	}
+	return writer.Close()
+}
+
+// SegmentWriter writes a segment one event at a time, holding only the
+// current block in memory, so a session log of any size can be archived.
+type SegmentWriter struct {
+	path        string
+	tmp         *os.File
+	blockEvents int
+	offset      int64
+	block       []dump.Event
+	index       Index
+}
+
+// CreateSegment starts a segment at path. Nothing appears there until
+// Close renames the finished file into place.
+func CreateSegment(path string, blockEvents int) (*SegmentWriter, error) {
+	if blockEvents <= 0 {
+		blockEvents = DefaultBlockEvents
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.OpenFile(path+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Write(segmentMagic); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return &SegmentWriter{
+		path:        path,
+		tmp:         tmp,
+		blockEvents: blockEvents,
+		offset:      int64(len(segmentMagic)),
+		block:       make([]dump.Event, 0, blockEvents),
+		index:       Index{Version: 1, Blocks: []BlockIndex{}},
+	}, nil
+}
+
+func (w *SegmentWriter) Write(event dump.Event) error {
+	w.block = append(w.block, event)
+	w.index.Events++
+	if len(w.block) < w.blockEvents {
+		return nil
+	}
+	return w.flushBlock()
+}
+
+func (w *SegmentWriter) flushBlock() error {
+	if len(w.block) == 0 {
+		return nil
+	}
+
+	var out bytes.Buffer
+	if err := writeBlock(&out, w.block); err != nil {
+		return err
+	}
+	if _, err := w.tmp.Write(out.Bytes()); err != nil {
+		return err
+	}
+
+	first, last := timeRange(w.block)
+	w.index.Blocks = append(w.index.Blocks, BlockIndex{
+		Offset:         w.offset,
+		Length:         int64(out.Len()),
+		Events:         len(w.block),
+		FirstTimestamp: first,
+		LastTimestamp:  last,
+	})
+	w.offset += int64(out.Len())
+	w.block = w.block[:0]
+	return nil
+}
+
+// Close writes the last block and the index footer and renames the segment
+// into place.
+func (w *SegmentWriter) Close() (Index, error) {
+	if err := w.flushBlock(); err != nil {
+		w.Abort()
+		return Index{}, err
+	}
+
+	footer, err := json.Marshal(w.index)
+	if err != nil {
+		w.Abort()
+		return Index{}, err
+	}
+	footer = append(footer, binary.LittleEndian.AppendUint64(nil, uint64(len(footer)))...)
+	footer = append(footer, segmentMagic...)
+	if _, err := w.tmp.Write(footer); err != nil {
+		w.Abort()
+		return Index{}, err
+	}
+	if err := w.tmp.Close(); err != nil {
+		os.Remove(w.tmp.Name())
+		return Index{}, err
+	}
+	if err := os.Rename(w.tmp.Name(), w.path); err != nil {
+		_ = os.Remove(w.tmp.Name())
+		return Index{}, err
+	}
+	return w.index, nil
+}
+
+// Abort discards an unfinished segment.
+func (w *SegmentWriter) Abort() {
+	w.tmp.Close()
+	os.Remove(w.tmp.Name())
+}
+
+// timeRange finds the earliest and latest timestamps of events, as they
+// were written. Events arrive roughly but not strictly in time order, and
+// RFC 3339 strings of different precision do not sort as text, so they
+// are compared parsed; timestamps that do not parse are left out.
+func timeRange(events []dump.Event) (string, string) {
+	var first, last string
+	var firstAt, lastAt time.Time
+	for _, event := range events {
+		at, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+		if err != nil {
+			continue
+		}
+		if first == "" || at.Before(firstAt) {
+			first, firstAt = event.Timestamp, at
+		}
+		if last == "" || at.After(lastAt) {
+			last, lastAt = event.Timestamp, at
+		}
+	}
+	return first, last
+}
+
+func writeBlock(out *bytes.Buffer, events []dump.Event) error {
+	writer := gzip.NewWriter(out)
+	encoder := json.NewEncoder(writer)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("encode archived event %s: %w", event.ID, err)
+		}
+	}
+	return writer.Close()
+}
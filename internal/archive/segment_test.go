@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func archivedEvents(n int) []dump.Event {
+	events := make([]dump.Event, n)
+	for i := range events {
+		events[i] = dump.Event{
+			SchemaVersion: dump.SchemaVersion,
+			ID:            fmt.Sprintf("evt-%03d", i),
+			Timestamp:     fmt.Sprintf("2026-03-01T10:00:%02dZ", i),
+			PayloadFormat: dump.PayloadFormatJSON,
+			Payload:       json.RawMessage(fmt.Sprintf(`{"n":%d}`, i)),
+		}
+	}
+	return events
+}
+
+func TestSegment_RoundTripByBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seg", "0001.phseg")
+
+	index, err := WriteSegment(path, archivedEvents(10), 4)
+	if err != nil {
+		t.Fatalf("WriteSegment() error = %v", err)
+	}
+	if len(index.Blocks) != 3 || index.Events != 10 {
+		t.Fatalf("WriteSegment() index = %+v, want 3 blocks and 10 events", index)
+	}
+
+	reader, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer reader.Close()
+
+	block, err := reader.Block(2)
+	if err != nil {
+		t.Fatalf("Block(2) error = %v", err)
+	}
+	if len(block) != 2 || block[0].ID != "evt-008" || string(block[1].Payload) != `{"n":9}` {
+		t.Fatalf("Block(2) = %+v, want events 8 and 9", block)
+	}
+}
+
+func TestReader_SearchUsesTimeRangeAndLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "0001.phseg")
+	if _, err := WriteSegment(path, archivedEvents(20), 5); err != nil {
+		t.Fatalf("WriteSegment() error = %v", err)
+	}
+
+	reader, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer reader.Close()
+
+	matches, err := reader.Search(`"n":1`, "", "", 0)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(matches) != 11 {
+		t.Fatalf("Search() len = %d, want 11 (1 and 10-19)", len(matches))
+	}
+
+	matches, err = reader.Search("evt-", "2026-03-01T10:00:07Z", "2026-03-01T10:00:12Z", 3)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(matches) != 3 || matches[0].ID != "evt-007" || matches[2].ID != "evt-009" {
+		t.Fatalf("Search() = %+v, want evt-007..evt-009", matches)
+	}
+}
+
+func TestOpen_RejectsCorruptSegment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.phseg")
+	if err := os.WriteFile(path, []byte("not a segment at all"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := Open(path); !errors.Is(err, ErrInvalidSegment) {
+		t.Fatalf("Open() error = %v, want ErrInvalidSegment", err)
+	}
+}
+
+func TestReader_SearchComparesParsedTimes(t *testing.T) {
+	events := archivedEvents(3)
+	// Fractional seconds sort before the whole second as text.
+	events[0].Timestamp = "2026-03-01T10:00:00.5Z"
+	events[1].Timestamp = "2026-03-01T10:00:00Z"
+	events[2].Timestamp = "2026-03-01T10:00:01Z"
+
+	path := filepath.Join(t.TempDir(), "0001.phseg")
+	index, err := WriteSegment(path, events, 2)
+	if err != nil {
+		t.Fatalf("WriteSegment() error = %v", err)
+	}
+	if block := index.Blocks[0]; block.FirstTimestamp != events[1].Timestamp || block.LastTimestamp != events[0].Timestamp {
+		t.Fatalf("block range = %s..%s, want %s..%s", block.FirstTimestamp, block.LastTimestamp, events[1].Timestamp, events[0].Timestamp)
+	}
+
+	reader, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer reader.Close()
+
+	matches, err := reader.Search("evt-", "2026-03-01T10:00:00Z", "2026-03-01T10:00:00.9Z", 0)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(matches) != 2 || matches[0].ID != "evt-000" || matches[1].ID != "evt-001" {
+		t.Fatalf("Search() = %+v, want evt-000 and evt-001", matches)
+	}
+	if _, err := reader.Search("evt-", "yesterday", "", 0); err == nil {
+		t.Fatalf("Search() with an invalid bound error = nil")
+	}
+}
@@ -0,0 +1,406 @@
+// Package capture records the raw bytes the ingestion listeners receive
+// into a bounded ring of files on disk and reads them back for replay, so
+// a protocol bug reported against a client library can be reproduced byte
+// for byte.
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxBytes = 64 * 1024 * 1024
+	MinMaxBytes     = 1024 * 1024
+
+	// segments is how many files the ring is split into; the oldest file
+	// is deleted whole once the ring is full.
+	segments      = 8
+	segmentPrefix = "capture-"
+	segmentSuffix = ".ndjson"
+
+	// maxRecordLine bounds a record when loading; reads are at most a few
+	// tens of KiB, base64 inflates them by a third.
+	maxRecordLine = 16 * 1024 * 1024
+)
+
+// Listener names, as recorded with each connection.
+const (
+	ListenerCollector = "collector"
+	ListenerVarDumper = "varDumper"
+	ListenerRay       = "ray"
+	ListenerMonolog   = "monolog"
+)
+
+// Record is one line of a capture: a connection being accepted (Open) or
+// bytes read from it. Data is what the listener read after TLS, so a
+// capture never holds ciphertext.
+type Record struct {
+	Listener string `json:"listener"`
+	Conn     string `json:"conn"`
+	At       string `json:"at"`
+	Open     bool   `json:"open,omitempty"`
+	Remote   string `json:"remote,omitempty"`
+	Data     []byte `json:"data,omitempty"`
+}
+
+type Stats struct {
+	Dir         string `json:"dir"`
+	MaxBytes    int64  `json:"maxBytes"`
+	Bytes       int64  `json:"bytes"`
+	Connections int    `json:"connections"`
+	LastError   string `json:"lastError,omitempty"`
+}
+
+// Recorder appends records to the newest segment file in its directory.
+// Records are written as reads happen, so nothing is lost if phant exits
+// without closing it.
+type Recorder struct {
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+	session      string
+	now          func() time.Time
+
+	mu          sync.Mutex
+	file        *os.File
+	size        int64
+	files       []string
+	total       int64
+	connections int
+	lastErr     error
+	closed      bool
+}
+
+// Open starts recording into dir, keeping at most about maxBytes of
+// records there, older captures included.
+func Open(dir string, maxBytes int64) (*Recorder, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	if maxBytes < MinMaxBytes {
+		return nil, fmt.Errorf("capture size must be at least %d bytes", MinMaxBytes)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	recorder := &Recorder{
+		dir:          dir,
+		maxBytes:     maxBytes,
+		segmentBytes: maxBytes / segments,
+		session:      fmt.Sprintf("%x", now.UnixNano()),
+		now:          time.Now,
+	}
+	files, err := segmentFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range files {
+		if info, err := os.Stat(name); err == nil {
+			recorder.files = append(recorder.files, name)
+			recorder.total += info.Size()
+		}
+	}
+	if err := recorder.rotateLocked(); err != nil {
+		return nil, err
+	}
+	return recorder, nil
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *Recorder) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := Stats{Dir: r.dir, MaxBytes: r.maxBytes, Bytes: r.total, Connections: r.connections}
+	if r.lastErr != nil {
+		stats.LastError = r.lastErr.Error()
+	}
+	return stats
+}
+
+// Tap wraps a listener so the connections it accepts are recorded under
+// name while recorder returns one. Connections accepted while it returns
+// nil are never recorded, even if capture starts later.
+func Tap(listener net.Listener, name string, recorder func() *Recorder) net.Listener {
+	return &tappedListener{Listener: listener, name: name, recorder: recorder}
+}
+
+type tappedListener struct {
+	net.Listener
+	name     string
+	recorder func() *Recorder
+}
+
+func (l *tappedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return conn, err
+	}
+	recorder := l.recorder()
+	if recorder == nil {
+		return conn, nil
+	}
+	return recorder.open(l.name, conn), nil
+}
+
+type tappedConn struct {
+	net.Conn
+	recorder *Recorder
+	listener string
+	id       string
+}
+
+func (c *tappedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.recorder.write(Record{Listener: c.listener, Conn: c.id, Data: p[:n]})
+	}
+	return n, err
+}
+
+func (r *Recorder) open(listener string, conn net.Conn) net.Conn {
+	r.mu.Lock()
+	r.connections++
+	id := fmt.Sprintf("%s-%d", r.session, r.connections)
+	r.mu.Unlock()
+
+	remote := ""
+	if addr := conn.RemoteAddr(); addr != nil {
+		remote = addr.String()
+	}
+	r.write(Record{Listener: listener, Conn: id, Open: true, Remote: remote})
+	return &tappedConn{Conn: conn, recorder: r, listener: listener, id: id}
+}
+
+// write appends a record; a failing disk is reported in Stats and never
+// disturbs the connection being recorded.
+func (r *Recorder) write(record Record) {
+	record.At = r.now().UTC().Format(time.RFC3339Nano)
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	if r.size > 0 && r.size+int64(len(line)) > r.segmentBytes {
+		if err := r.rotateLocked(); err != nil {
+			r.lastErr = err
+			return
+		}
+	}
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	r.total += int64(n)
+	if err != nil {
+		r.lastErr = err
+	}
+}
+
+// rotateLocked starts a new segment, then deletes the oldest ones until
+// the ring fits maxBytes again.
+func (r *Recorder) rotateLocked() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+	}
+	name := filepath.Join(r.dir, fmt.Sprintf("%s%016x%s", segmentPrefix, r.now().UnixNano(), segmentSuffix))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		r.file = nil
+		return err
+	}
+	r.file = file
+	r.size = 0
+	r.files = append(r.files, name)
+
+	for len(r.files) > 1 && r.total > r.maxBytes-r.segmentBytes {
+		oldest := r.files[0]
+		if info, err := os.Stat(oldest); err == nil {
+			r.total -= info.Size()
+		}
+		if err := os.Remove(oldest); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		r.files = r.files[1:]
+	}
+	return nil
+}
+
+func segmentFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, segmentPrefix) && strings.HasSuffix(name, segmentSuffix) {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Connection is everything one connection sent. Partial is set when the
+// ring no longer holds its start, so replaying it begins mid-stream.
+type Connection struct {
+	Listener  string `json:"listener"`
+	ID        string `json:"id"`
+	Remote    string `json:"remote,omitempty"`
+	StartedAt string `json:"startedAt"`
+	Bytes     int    `json:"bytes"`
+	Partial   bool   `json:"partial,omitempty"`
+	Data      []byte `json:"-"`
+}
+
+// Load reads a capture: a directory of segments, as a Recorder writes, or
+// a single file such as Export writes. Connections are returned in the
+// order they were accepted.
+func Load(path string) ([]Connection, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = segmentFiles(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var connections []*Connection
+	byID := map[string]*Connection{}
+	for _, name := range files {
+		err := readRecords(name, func(record Record) {
+			connection := byID[record.Conn]
+			if connection == nil {
+				connection = &Connection{Listener: record.Listener, ID: record.Conn, StartedAt: record.At, Partial: !record.Open}
+				byID[record.Conn] = connection
+				connections = append(connections, connection)
+			}
+			if record.Open {
+				connection.Remote = record.Remote
+			}
+			connection.Data = append(connection.Data, record.Data...)
+			connection.Bytes = len(connection.Data)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	loaded := make([]Connection, len(connections))
+	for i, connection := range connections {
+		loaded[i] = *connection
+	}
+	return loaded, nil
+}
+
+func readRecords(name string, handle func(Record)) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// The last record of a segment may be torn by a crash.
+			continue
+		}
+		if record.Conn == "" {
+			return fmt.Errorf("%s:%d: record without connection", name, line)
+		}
+		handle(record)
+	}
+	return scanner.Err()
+}
+
+// Export copies the capture in dir into a single file that Load reads, for
+// attaching to a bug report.
+func Export(dir string, path string) error {
+	files, err := segmentFiles(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		in, err := os.Open(name)
+		if err != nil {
+			out.Close()
+			return err
+		}
+		_, err = io.Copy(out, in)
+		in.Close()
+		if err != nil {
+			out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// Replay hands data to serve as if a client had connected and sent it,
+// then hung up. Anything serve writes back, such as dd() releases, is
+// discarded. It returns once serve does.
+func Replay(data []byte, serve func(net.Conn)) {
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer server.Close()
+		serve(server)
+	}()
+	go func() {
+		_, _ = io.Copy(io.Discard, client)
+	}()
+
+	// A server that hangs up early, such as on a failed handshake, leaves
+	// the rest unread; the write then fails and the replay ends there.
+	_, _ = client.Write(data)
+	client.Close()
+	<-done
+}
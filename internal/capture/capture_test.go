@@ -0,0 +1,112 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTap_RecordsConnectionsForReplay(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := Open(dir, MinMaxBytes)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := Tap(raw, ListenerMonolog, func() *Recorder { return recorder })
+	defer listener.Close()
+
+	sent := []byte("{\"message\":\"one\"}\n{\"message\":\"two\"}\n")
+	go func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return
+		}
+		conn.Write(sent[:10])
+		conn.Write(sent[10:])
+		conn.Close()
+	}()
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	received, _ := io.ReadAll(conn)
+	conn.Close()
+	if !bytes.Equal(received, sent) {
+		t.Fatalf("read through tap = %q, want %q", received, sent)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	connections, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(connections) != 1 {
+		t.Fatalf("Load() = %d connections, want 1", len(connections))
+	}
+	got := connections[0]
+	if got.Listener != ListenerMonolog || got.Partial || got.Remote == "" || !bytes.Equal(got.Data, sent) {
+		t.Fatalf("Load()[0] = %+v with %q, want the whole monolog connection", got, got.Data)
+	}
+
+	exported := filepath.Join(t.TempDir(), "capture.ndjson")
+	if err := Export(dir, exported); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	fromFile, err := Load(exported)
+	if err != nil || len(fromFile) != 1 || !bytes.Equal(fromFile[0].Data, sent) {
+		t.Fatalf("Load(exported) = %v, %v, want the same connection", fromFile, err)
+	}
+
+	var replayed []byte
+	Replay(got.Data, func(conn net.Conn) {
+		replayed, _ = io.ReadAll(conn)
+	})
+	if !bytes.Equal(replayed, sent) {
+		t.Fatalf("Replay() served %q, want %q", replayed, sent)
+	}
+}
+
+func TestRecorder_DropsOldestSegmentsWhenFull(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := Open(dir, MinMaxBytes)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	chunk := []byte(strings.Repeat("x", 16*1024))
+	for i := 0; i < 200; i++ {
+		recorder.write(Record{Listener: ListenerCollector, Conn: "c", Data: chunk})
+	}
+	recorder.Close()
+
+	files, _ := segmentFiles(dir)
+	var total int64
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += info.Size()
+	}
+	if total > MinMaxBytes {
+		t.Fatalf("capture holds %d bytes in %d files, want at most %d", total, len(files), MinMaxBytes)
+	}
+	if stats := recorder.Stats(); stats.Bytes != total {
+		t.Fatalf("Stats().Bytes = %d, want %d on disk", stats.Bytes, total)
+	}
+
+	connections, err := Load(dir)
+	if err != nil || len(connections) != 1 || !connections[0].Partial {
+		t.Fatalf("Load() = %v, %v, want one partial connection", connections, err)
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/ray"
+	"phant/internal/soak"
+)
+
+func cliEvent() dump.Event {
+	return dump.Event{
+		ID:          "evt-1",
+		Timestamp:   "2026-03-02T12:00:01.5Z",
+		SourceType:  "http",
+		Level:       "error",
+		Label:       "cart",
+		HTTP:        &dump.HTTPMeta{Method: "POST", Path: "/checkout"},
+		Trace:       []dump.TraceFrame{{File: "app/Cart.php", Line: 12}},
+		Payload:     json.RawMessage(`{"items":[1,2]}`),
+		ProjectRoot: "/srv/app",
+	}
+}
+
+func TestPrinter_PrettyPrintsHeaderAndIndentedPayload(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewPrinter(&out, FormatPretty).Print(cliEvent()); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	if !strings.HasSuffix(lines[0], ` ERROR [http] POST /checkout "cart" app/Cart.php:12`) {
+		t.Fatalf("Print() header = %q, want level, source, request, label and frame", lines[0])
+	}
+	if lines[1] != "  {" || lines[2] != `    "items": [` {
+		t.Fatalf("Print() payload = %q, want indented JSON", lines[1:])
+	}
+}
+
+func TestPrinter_NDJSONWritesOneEventPerLine(t *testing.T) {
+	var out bytes.Buffer
+	printer := NewPrinter(&out, FormatNDJSON)
+	printer.Print(cliEvent())
+	printer.Print(cliEvent())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Print() lines = %d, want 2", len(lines))
+	}
+	var event dump.Event
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil || event.ID != "evt-1" {
+		t.Fatalf("Print() line = %q, want the event as JSON", lines[0])
+	}
+}
+
+func TestParseTailArgs(t *testing.T) {
+	options, err := ParseTailArgs([]string{"--listen", ":23517", "--format", "ndjson"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseTailArgs() error = %v", err)
+	}
+	if options.Listen != ":23517" || options.Format != FormatNDJSON {
+		t.Fatalf("ParseTailArgs() = %+v, want listen :23517 and ndjson", options)
+	}
+
+	defaults, _ := ParseTailArgs(nil, io.Discard)
+	if defaults.Listen != ray.DefaultAddress || defaults.Format != FormatPretty {
+		t.Fatalf("ParseTailArgs(nil) = %+v, want Ray default address and pretty", defaults)
+	}
+
+	if _, err := ParseTailArgs([]string{"--format", "xml"}, io.Discard); err == nil {
+		t.Fatalf("ParseTailArgs(xml) error = nil, want error")
+	}
+}
+
+func TestParseSoakArgs(t *testing.T) {
+	options, err := ParseSoakArgs([]string{"--duration", "10m", "--sample", "1m", "--rate", "50"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseSoakArgs() error = %v", err)
+	}
+	if options.Soak.Duration != 10*time.Minute || options.Soak.Rate != 50 || options.Soak.Thresholds.Windows != soak.DefaultWindows {
+		t.Fatalf("ParseSoakArgs() = %+v, want 10m at 50/s with default windows", options)
+	}
+
+	if _, err := ParseSoakArgs([]string{"--duration", "2m", "--sample", "1m"}, io.Discard); err == nil {
+		t.Fatalf("ParseSoakArgs(too few samples) error = nil, want error")
+	}
+}
+
+func TestParseSchemaCheckArgs(t *testing.T) {
+	options, err := ParseSchemaCheckArgs([]string{"--version", "1", "sessions"}, io.Discard)
+	if err != nil {
+		t.Fatalf("ParseSchemaCheckArgs() error = %v", err)
+	}
+	if options.Version != 1 || options.Path != "sessions" {
+		t.Fatalf("ParseSchemaCheckArgs() = %+v, want version 1 for sessions", options)
+	}
+
+	if _, err := ParseSchemaCheckArgs(nil, io.Discard); err == nil {
+		t.Fatalf("ParseSchemaCheckArgs(no path) error = nil, want error")
+	}
+}
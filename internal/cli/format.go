@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+type Format string
+
+const (
+	// FormatPretty prints a header line per event followed by its indented
+	// payload.
+	FormatPretty Format = "pretty"
+	// FormatNDJSON prints each event as one JSON line, as stored.
+	FormatNDJSON Format = "ndjson"
+)
+
+// Printer writes events to a terminal or pipe. It is not safe for
+// concurrent use.
+type Printer struct {
+	w       io.Writer
+	format  Format
+	encoder *json.Encoder
+}
+
+func NewPrinter(w io.Writer, format Format) *Printer {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &Printer{w: w, format: format, encoder: encoder}
+}
+
+func (p *Printer) Print(event dump.Event) error {
+	if p.format == FormatNDJSON {
+		return p.encoder.Encode(event)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(header(event))
+	out.WriteByte('\n')
+
+	var payload bytes.Buffer
+	if err := json.Indent(&payload, event.Payload, "  ", "  "); err != nil {
+		payload.Reset()
+		payload.Write(event.Payload)
+	}
+	out.WriteString("  ")
+	out.Write(payload.Bytes())
+	out.WriteByte('\n')
+
+	_, err := p.w.Write(out.Bytes())
+	return err
+}
+
+func header(event dump.Event) string {
+	parts := []string{clock(event.Timestamp)}
+	if event.Level != "" {
+		parts = append(parts, strings.ToUpper(event.Level))
+	}
+	parts = append(parts, "["+event.SourceType+"]")
+
+	switch {
+	case event.HTTP != nil:
+		parts = append(parts, event.HTTP.Method+" "+event.HTTP.Path)
+	case event.Command != nil:
+		parts = append(parts, event.Command.Name)
+	case event.Log != nil:
+		parts = append(parts, event.Log.Channel+": "+event.Log.Message)
+	}
+	if event.Exception != nil {
+		parts = append(parts, event.Exception.Class+": "+event.Exception.Message)
+	}
+	if event.Label != "" {
+		parts = append(parts, fmt.Sprintf("%q", event.Label))
+	}
+	if len(event.Trace) > 0 && event.Trace[0].File != "" {
+		parts = append(parts, event.Trace[0].File+":"+strconv.Itoa(event.Trace[0].Line))
+	}
+	return strings.Join(parts, " ")
+}
+
+// clock shortens timestamps to local time of day; the date rarely matters
+// while tailing.
+func clock(timestamp string) string {
+	parsed, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return parsed.Local().Format("15:04:05.000")
+}
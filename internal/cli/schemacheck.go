@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"phant/internal/dump"
+)
+
+// SchemaCheckOptions configures `phant schema-check`, a dry run of an
+// existing corpus against a schema version.
+type SchemaCheckOptions struct {
+	Version int
+	// Path is an NDJSON file or a store directory.
+	Path string
+	JSON bool
+}
+
+func ParseSchemaCheckArgs(args []string, stderr io.Writer) (SchemaCheckOptions, error) {
+	var options SchemaCheckOptions
+
+	flags := flag.NewFlagSet("phant schema-check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: phant schema-check [flags] <file.ndjson|store-dir>")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Re-validates every event as if it declared the given schemaVersion and exits 1")
+		fmt.Fprintln(stderr, "if any event that is valid today would be rejected.")
+		fmt.Fprintln(stderr, "")
+		flags.PrintDefaults()
+	}
+	flags.IntVar(&options.Version, "version", dump.SchemaVersion, "schema `version` to validate against")
+	flags.BoolVar(&options.JSON, "json", false, "print the full report as JSON")
+
+	if err := flags.Parse(args); err != nil {
+		return SchemaCheckOptions{}, err
+	}
+	fail := func(err error) (SchemaCheckOptions, error) {
+		fmt.Fprintln(stderr, err)
+		flags.Usage()
+		return SchemaCheckOptions{}, err
+	}
+	if flags.NArg() != 1 {
+		return fail(errors.New("expected exactly one file or directory"))
+	}
+	options.Path = flags.Arg(0)
+	return options, nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"phant/internal/soak"
+)
+
+// SoakOptions configures `phant soak`, which runs the headless pipeline
+// against synthetic events for hours and fails on steady memory or
+// goroutine growth.
+type SoakOptions struct {
+	Soak soak.Options
+	// KeepData leaves the temporary store and payload directories behind
+	// for inspection.
+	KeepData bool
+}
+
+func ParseSoakArgs(args []string, stderr io.Writer) (SoakOptions, error) {
+	var options SoakOptions
+
+	flags := flag.NewFlagSet("phant soak", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: phant soak [flags]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Ingests synthetic events through the headless pipeline and exits 1 if heap or")
+		fmt.Fprintln(stderr, "goroutine counts grow monotonically. Samples are printed to stderr.")
+		fmt.Fprintln(stderr, "")
+		flags.PrintDefaults()
+	}
+	flags.DurationVar(&options.Soak.Duration, "duration", 2*time.Hour, "how long to ingest after warmup")
+	flags.DurationVar(&options.Soak.Warmup, "warmup", soak.DefaultWarmup, "ingest time before sampling starts")
+	flags.IntVar(&options.Soak.Rate, "rate", soak.DefaultRate, "events per second")
+	flags.DurationVar(&options.Soak.SampleInterval, "sample", soak.DefaultSampleInterval, "time between heap and goroutine samples")
+	flags.IntVar(&options.Soak.Thresholds.Windows, "windows", soak.DefaultWindows, "windows whose floors must all rise to report a leak")
+	flags.BoolVar(&options.KeepData, "keep-data", false, "keep the temporary session and payload directories")
+
+	if err := flags.Parse(args); err != nil {
+		return SoakOptions{}, err
+	}
+	fail := func(err error) (SoakOptions, error) {
+		fmt.Fprintln(stderr, err)
+		flags.Usage()
+		return SoakOptions{}, err
+	}
+	if flags.NArg() > 0 {
+		return fail(fmt.Errorf("unexpected argument %q", flags.Arg(0)))
+	}
+	if options.Soak.Duration <= 0 || options.Soak.Rate <= 0 || options.Soak.SampleInterval <= 0 {
+		return fail(errors.New("duration, rate, and sample must be positive"))
+	}
+	if samples := options.Soak.Duration / options.Soak.SampleInterval; int(samples) < options.Soak.Thresholds.Windows {
+		return fail(fmt.Errorf("duration allows %d samples; need at least %d (one per window)", samples, options.Soak.Thresholds.Windows))
+	}
+	return options, nil
+}
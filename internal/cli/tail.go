@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"phant/internal/ray"
+)
+
+// TailOptions configures `phant tail`, which runs the collector and ingest
+// listeners without the desktop window and prints events to stdout.
+type TailOptions struct {
+	// Socket overrides the collector socket path; empty uses the default.
+	Socket string
+	// Listen is the Ray-compatible HTTP address; empty disables it.
+	Listen    string
+	VarDumper string
+	Monolog   string
+	Format    Format
+}
+
+func ParseTailArgs(args []string, stderr io.Writer) (TailOptions, error) {
+	var options TailOptions
+	var format string
+
+	flags := flag.NewFlagSet("phant tail", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: phant tail [flags]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Runs the collector without the desktop window and prints events to stdout.")
+		fmt.Fprintln(stderr, "")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&options.Socket, "socket", "", "collector socket `path` (default: the desktop app's socket)")
+	flags.StringVar(&options.Listen, "listen", ray.DefaultAddress, "Ray HTTP listener `address`; empty disables it")
+	flags.StringVar(&options.VarDumper, "var-dumper", "", "symfony/var-dumper server `address`; empty disables it")
+	flags.StringVar(&options.Monolog, "monolog", "", "Monolog JSON socket `address`; empty disables it")
+	flags.StringVar(&format, "format", string(FormatPretty), "output `format`: pretty or ndjson")
+
+	if err := flags.Parse(args); err != nil {
+		return TailOptions{}, err
+	}
+	// Mirror flag's own error reporting for the checks it cannot do.
+	fail := func(err error) (TailOptions, error) {
+		fmt.Fprintln(stderr, err)
+		flags.Usage()
+		return TailOptions{}, err
+	}
+	if flags.NArg() > 0 {
+		return fail(fmt.Errorf("unexpected argument %q", flags.Arg(0)))
+	}
+
+	switch Format(format) {
+	case FormatPretty, FormatNDJSON:
+		options.Format = Format(format)
+	default:
+		return fail(errors.New("format must be one of: pretty, ndjson"))
+	}
+	return options, nil
+}
@@ -1,6 +1,12 @@
 package collector
 
-import "sync"
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
 
 type RingBuffer struct {
 	mu      sync.RWMutex
@@ -8,6 +14,11 @@ type RingBuffer struct {
 	start   int
 	size    int
 	dropped uint64
+
+	// byRequest maps a requestId to the slots holding its events, oldest
+	// first, so a request's events can be gathered without a full scan.
+	byRequest map[string][]int
+	payloads  *payloadInterner
 }
 
 func NewRingBuffer(capacity int) *RingBuffer {
@@ -16,26 +27,113 @@ func NewRingBuffer(capacity int) *RingBuffer {
 	}
 
 	return &RingBuffer{
-		events: make([]Event, capacity),
+		events:    make([]Event, capacity),
+		byRequest: make(map[string][]int),
+		payloads:  newPayloadInterner(),
 	}
 }
 
 func (b *RingBuffer) Add(event Event) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.addLocked(event)
+}
+
+// AddCollapsing adds event unless it repeats the newest buffered event
+// within window, in which case that event's repeat count is bumped in place
+// and the updated event is returned with collapsed set. A zero window
+// never collapses.
+func (b *RingBuffer) AddCollapsing(event Event, window time.Duration) (Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if window > 0 && b.size > 0 {
+		last := &b.events[(b.start+b.size-1)%len(b.events)]
+		if isRepeat(*last, event, window) {
+			last.RepeatCount = max(last.RepeatCount, 1) + 1
+			last.LastRepeatAt = event.Timestamp
+			return *last, true
+		}
+	}
+
+	b.addLocked(event)
+	return event, false
+}
+
+func (b *RingBuffer) addLocked(event Event) {
+	event.Payload = b.payloads.intern(event.Payload)
 
 	if b.size < len(b.events) {
 		idx := (b.start + b.size) % len(b.events)
 		b.events[idx] = event
+		b.indexSlot(idx)
 		b.size++
 		return
 	}
 
+	b.unindexSlot(b.start)
+	b.payloads.release(b.events[b.start].Payload)
 	b.events[b.start] = event
+	b.indexSlot(b.start)
 	b.start = (b.start + 1) % len(b.events)
 	b.dropped++
 }
 
+func (b *RingBuffer) indexSlot(idx int) {
+	if requestID := b.events[idx].RequestID; requestID != nil && *requestID != "" {
+		b.byRequest[*requestID] = append(b.byRequest[*requestID], idx)
+	}
+}
+
+// unindexSlot drops the oldest slot of the evicted event's request, which is
+// always the slot being overwritten.
+func (b *RingBuffer) unindexSlot(idx int) {
+	requestID := b.events[idx].RequestID
+	if requestID == nil || *requestID == "" {
+		return
+	}
+
+	slots := b.byRequest[*requestID]
+	if len(slots) > 0 && slots[0] == idx {
+		slots = slots[1:]
+	}
+	if len(slots) == 0 {
+		delete(b.byRequest, *requestID)
+		return
+	}
+	b.byRequest[*requestID] = slots
+}
+
+// ByRequest returns the events of one request ordered by timestamp, falling
+// back to arrival order for equal or unparsable timestamps.
+func (b *RingBuffer) ByRequest(requestID string) []Event {
+	b.mu.RLock()
+	slots := b.byRequest[requestID]
+	result := make([]Event, len(slots))
+	for i, idx := range slots {
+		result[i] = b.events[idx]
+	}
+	b.mu.RUnlock()
+
+	times := make([]time.Time, len(result))
+	for i, event := range result {
+		times[i], _ = time.Parse(time.RFC3339Nano, event.Timestamp)
+	}
+	order := make([]int, len(result))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return times[order[i]].Before(times[order[j]])
+	})
+
+	sorted := make([]Event, len(result))
+	for i, idx := range order {
+		sorted[i] = result[idx]
+	}
+	return sorted
+}
+
 func (b *RingBuffer) Snapshot() []Event {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -48,8 +146,159 @@ func (b *RingBuffer) Snapshot() []Event {
 	return result
 }
 
+// Select returns the events accepted by match, oldest first, evaluating the
+// predicate in place so non-matching events are never copied.
+func (b *RingBuffer) Select(match func(Event) bool) []Event {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	result := []Event{}
+	for i := 0; i < b.size; i++ {
+		event := &b.events[(b.start+i)%len(b.events)]
+		if match(*event) {
+			result = append(result, *event)
+		}
+	}
+	return result
+}
+
+func (b *RingBuffer) InternStats() InternStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.payloads.stats()
+}
+
 func (b *RingBuffer) DroppedCount() uint64 {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	return b.dropped
 }
+
+// Replace swaps buffered events for the given ones, matched by ID, keeping
+// their positions. It returns how many slots were replaced.
+func (b *RingBuffer) Replace(events map[string]Event) int {
+	if len(events) == 0 {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replaced := 0
+	for i := 0; i < b.size; i++ {
+		idx := (b.start + i) % len(b.events)
+		event, ok := events[b.events[idx].ID]
+		if !ok {
+			continue
+		}
+		b.payloads.release(b.events[idx].Payload)
+		event.Payload = b.payloads.intern(event.Payload)
+		b.events[idx] = event
+		replaced++
+	}
+
+	if replaced > 0 {
+		b.byRequest = make(map[string][]int)
+		for i := 0; i < b.size; i++ {
+			b.indexSlot((b.start + i) % len(b.events))
+		}
+	}
+	return replaced
+}
+
+// Remove deletes the events whose IDs are in ids, keeping the remaining
+// events in order, and returns how many were removed.
+func (b *RingBuffer) Remove(ids map[string]struct{}) int {
+	if len(ids) == 0 {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	kept := make([]Event, len(b.events))
+	size := 0
+	for i := 0; i < b.size; i++ {
+		event := b.events[(b.start+i)%len(b.events)]
+		if _, ok := ids[event.ID]; ok {
+			b.payloads.release(event.Payload)
+			continue
+		}
+		kept[size] = event
+		size++
+	}
+
+	removed := b.size - size
+	b.events = kept
+	b.start = 0
+	b.size = size
+
+	b.byRequest = make(map[string][]int)
+	for idx := 0; idx < size; idx++ {
+		b.indexSlot(idx)
+	}
+	return removed
+}
+
+// Restore puts removed events back, each before the first buffered event
+// received after it, so a cleared range reappears where it was. Events
+// already buffered are skipped; if the buffer overflows, the oldest events
+// are dropped as usual. It returns how many events were restored.
+func (b *RingBuffer) Restore(events []Event) int {
+	if len(events) == 0 {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	present := make(map[string]struct{}, b.size)
+	current := make([]Event, b.size)
+	for i := 0; i < b.size; i++ {
+		current[i] = b.events[(b.start+i)%len(b.events)]
+		present[current[i].ID] = struct{}{}
+	}
+
+	merged := make([]Event, 0, len(current)+len(events))
+	restored := 0
+	next := 0
+	for _, event := range events {
+		if _, ok := present[event.ID]; ok {
+			continue
+		}
+		at := receivedAt(event)
+		for next < len(current) && !receivedAt(current[next]).After(at) {
+			merged = append(merged, current[next])
+			next++
+		}
+		event.Payload = b.payloads.intern(event.Payload)
+		merged = append(merged, event)
+		present[event.ID] = struct{}{}
+		restored++
+	}
+	merged = append(merged, current[next:]...)
+
+	if overflow := len(merged) - len(b.events); overflow > 0 {
+		for _, event := range merged[:overflow] {
+			b.payloads.release(event.Payload)
+		}
+		merged = merged[overflow:]
+		b.dropped += uint64(overflow)
+	}
+
+	b.events = append(merged, make([]Event, len(b.events)-len(merged))...)
+	b.start = 0
+	b.size = len(merged)
+	b.byRequest = make(map[string][]int)
+	for idx := 0; idx < b.size; idx++ {
+		b.indexSlot(idx)
+	}
+	return restored
+}
+
+// receivedAt is when an event arrived, or when it was sent if the receive
+// clock was off; unparsable times sort first.
+func receivedAt(event Event) time.Time {
+	at, _ := time.Parse(time.RFC3339Nano, event.Time(dump.TimeReceived))
+	return at
+}
@@ -1,6 +1,14 @@
 package collector
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/store"
+)
 
 func TestRingBuffer_DropsOldestWhenFull(t *testing.T) {
 	buffer := NewRingBuffer(2)
@@ -22,3 +30,194 @@ func TestRingBuffer_DropsOldestWhenFull(t *testing.T) {
 		t.Fatalf("buffer.DroppedCount() = %d, want %d", got, 1)
 	}
 }
+
+func TestRingBuffer_RemoveKeepsOrderAndCapacity(t *testing.T) {
+	buffer := NewRingBuffer(3)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		buffer.Add(Event{ID: id})
+	}
+
+	if got := buffer.Remove(map[string]struct{}{"3": {}, "missing": {}}); got != 1 {
+		t.Fatalf("buffer.Remove() = %d, want %d", got, 1)
+	}
+
+	buffer.Add(Event{ID: "5"})
+	events := buffer.Snapshot()
+	if len(events) != 3 || events[0].ID != "2" || events[1].ID != "4" || events[2].ID != "5" {
+		t.Fatalf("buffer.Snapshot() = %v, want IDs [2 4 5]", events)
+	}
+}
+
+func TestRingBuffer_RestorePutsEventsBackInTimeOrder(t *testing.T) {
+	request := "req"
+	buffer := NewRingBuffer(4)
+	for i, id := range []string{"1", "2", "3", "4"} {
+		buffer.Add(Event{ID: id, Timestamp: fmt.Sprintf("2024-01-01T00:00:0%dZ", i), RequestID: &request})
+	}
+	removed := buffer.Select(func(event Event) bool { return event.ID == "2" || event.ID == "3" })
+	buffer.Remove(map[string]struct{}{"2": {}, "3": {}})
+	buffer.Add(Event{ID: "5", Timestamp: "2024-01-01T00:00:05Z"})
+
+	if got := buffer.Restore(append(removed, Event{ID: "4"})); got != 2 {
+		t.Fatalf("buffer.Restore() = %d, want %d", got, 2)
+	}
+
+	events := buffer.Snapshot()
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	if got := strings.Join(ids, " "); got != "2 3 4 5" {
+		t.Fatalf("buffer.Snapshot() IDs = [%s], want [2 3 4 5]", got)
+	}
+	if got := buffer.DroppedCount(); got != 1 {
+		t.Fatalf("buffer.DroppedCount() = %d, want %d", got, 1)
+	}
+	if got := len(buffer.ByRequest(request)); got != 3 {
+		t.Fatalf("len(buffer.ByRequest()) = %d, want %d", got, 3)
+	}
+}
+
+func TestRingBuffer_ReplaceKeepsPositionAndReindexes(t *testing.T) {
+	request := "req"
+	buffer := NewRingBuffer(3)
+	for _, id := range []string{"1", "2", "3"} {
+		buffer.Add(Event{ID: id})
+	}
+
+	if got := buffer.Replace(map[string]Event{"2": {ID: "2", RequestID: &request}, "missing": {ID: "missing"}}); got != 1 {
+		t.Fatalf("buffer.Replace() = %d, want %d", got, 1)
+	}
+
+	events := buffer.Snapshot()
+	if len(events) != 3 || events[1].ID != "2" || events[1].RequestID == nil {
+		t.Fatalf("buffer.Snapshot() = %v, want event 2 replaced in place", events)
+	}
+	if got := buffer.ByRequest(request); len(got) != 1 || got[0].ID != "2" {
+		t.Fatalf("buffer.ByRequest() = %v, want the replaced event", got)
+	}
+}
+
+func TestRingBuffer_ByRequestOrdersByTimestampAndFollowsEviction(t *testing.T) {
+	requestA, requestB := "a", "b"
+	buffer := NewRingBuffer(4)
+	buffer.Add(Event{ID: "1", RequestID: &requestA, Timestamp: "2026-03-01T10:00:00.5Z"})
+	buffer.Add(Event{ID: "2", RequestID: &requestB, Timestamp: "2026-03-01T10:00:00Z"})
+	buffer.Add(Event{ID: "3", RequestID: &requestA, Timestamp: "2026-03-01T10:00:00.15Z"})
+	buffer.Add(Event{ID: "4"})
+
+	events := buffer.ByRequest("a")
+	if len(events) != 2 || events[0].ID != "3" || events[1].ID != "1" {
+		t.Fatalf("buffer.ByRequest(a) = %v, want IDs [3 1]", events)
+	}
+
+	buffer.Add(Event{ID: "5", RequestID: &requestA, Timestamp: "2026-03-01T10:00:01Z"})
+	if events := buffer.ByRequest("a"); len(events) != 2 || events[0].ID != "3" || events[1].ID != "5" {
+		t.Fatalf("buffer.ByRequest(a) after eviction = %v, want IDs [3 5]", events)
+	}
+
+	buffer.Remove(map[string]struct{}{"2": {}})
+	if events := buffer.ByRequest("b"); len(events) != 0 {
+		t.Fatalf("buffer.ByRequest(b) after Remove = %v, want empty", events)
+	}
+	if events := buffer.ByRequest("a"); len(events) != 2 {
+		t.Fatalf("buffer.ByRequest(a) after Remove = %v, want 2 events", events)
+	}
+}
+
+func TestRingBuffer_SelectKeepsOrder(t *testing.T) {
+	buffer := NewRingBuffer(3)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		buffer.Add(Event{ID: id, IsDD: id != "3"})
+	}
+
+	events := buffer.Select(func(event Event) bool { return event.IsDD })
+	if len(events) != 2 || events[0].ID != "2" || events[1].ID != "4" {
+		t.Fatalf("buffer.Select() = %v, want IDs [2 4]", events)
+	}
+}
+
+func TestRingBuffer_InternsIdenticalPayloads(t *testing.T) {
+	buffer := NewRingBuffer(3)
+	buffer.Add(Event{ID: "1", Payload: []byte(`{"same":true}`)})
+	buffer.Add(Event{ID: "2", Payload: []byte(`{"same":true}`)})
+	buffer.Add(Event{ID: "3", Payload: []byte(`{"other":1}`)})
+
+	events := buffer.Snapshot()
+	if &events[0].Payload[0] != &events[1].Payload[0] {
+		t.Fatalf("identical payloads do not share storage")
+	}
+	if string(events[1].Payload) != `{"same":true}` {
+		t.Fatalf("events[1].Payload = %s, want original body", events[1].Payload)
+	}
+	if got := buffer.InternStats(); got.Unique != 2 || got.References != 3 || got.SavedBytes != 13 {
+		t.Fatalf("buffer.InternStats() = %+v, want 2 unique, 3 refs, 13 saved bytes", got)
+	}
+
+	buffer.Add(Event{ID: "4", Payload: []byte(`{"x":1}`)})
+	buffer.Remove(map[string]struct{}{"2": {}})
+	if got := buffer.InternStats(); got.Unique != 2 || got.References != 2 || got.SavedBytes != 0 {
+		t.Fatalf("buffer.InternStats() after eviction = %+v, want 2 unique, 2 refs, 0 saved", got)
+	}
+}
+
+func TestRingBuffer_AddCollapsingFoldsRepeatsWithinWindow(t *testing.T) {
+	dumpAt := func(id string, timestamp string, payload string) Event {
+		return Event{
+			ID: id, Timestamp: timestamp, ProjectRoot: "/app", SourceType: "cli", PayloadFormat: "json",
+			Payload: []byte(payload), Trace: []dump.TraceFrame{{File: "/app/loop.php", Line: 4}},
+		}
+	}
+	buffer := NewRingBuffer(10)
+
+	buffer.AddCollapsing(dumpAt("1", "2026-03-02T12:00:00Z", `{"i":1}`), time.Second)
+	updated, collapsed := buffer.AddCollapsing(dumpAt("2", "2026-03-02T12:00:00.5Z", `{"i":1}`), time.Second)
+	if !collapsed || updated.ID != "1" || updated.RepeatCount != 2 || updated.LastRepeatAt != "2026-03-02T12:00:00.5Z" {
+		t.Fatalf("AddCollapsing(repeat) = %+v %v, want event 1 with two occurrences", updated, collapsed)
+	}
+	// The window runs from the latest repeat, so a steady loop keeps folding.
+	buffer.AddCollapsing(dumpAt("3", "2026-03-02T12:00:01.2Z", `{"i":1}`), time.Second)
+
+	for _, event := range []Event{
+		dumpAt("4", "2026-03-02T12:00:01.3Z", `{"i":2}`),
+		dumpAt("5", "2026-03-02T12:00:09Z", `{"i":2}`),
+	} {
+		if _, collapsed := buffer.AddCollapsing(event, time.Second); collapsed {
+			t.Fatalf("AddCollapsing(%s) collapsed, want a new event", event.ID)
+		}
+	}
+	if _, collapsed := buffer.AddCollapsing(dumpAt("6", "2026-03-02T12:00:09.1Z", `{"i":2}`), 0); collapsed {
+		t.Fatalf("AddCollapsing(window 0) collapsed, want dedup disabled")
+	}
+
+	events := buffer.Snapshot()
+	if len(events) != 4 || events[0].RepeatCount != 3 || events[1].ID != "4" || events[3].ID != "6" {
+		t.Fatalf("buffer.Snapshot() = %+v, want 1 (x3), 4, 5, 6", events)
+	}
+}
+
+func TestRingBuffer_AddCollapsingComparesOffloadedPayloadsInFull(t *testing.T) {
+	offloaded := func(id string, tail string) Event {
+		event := Event{
+			ID: id, Timestamp: "2026-03-02T12:00:00Z", ProjectRoot: "/app", SourceType: "cli", PayloadFormat: "json",
+			Trace: []dump.TraceFrame{{File: "/app/loop.php", Line: 4}},
+			Payload: []byte(`{"rows":"` + strings.Repeat("x", 4096) + `","tail":"` + tail + `"}`),
+		}
+		original, _ := event.OffloadPayload(1024, 128)
+		event.PayloadRef = store.PayloadRef(original)
+		return event
+	}
+	buffer := NewRingBuffer(10)
+
+	first, second := offloaded("1", "a"), offloaded("2", "b")
+	if string(first.Payload) != string(second.Payload) || first.OriginalBytes != second.OriginalBytes {
+		t.Fatalf("previews differ, want payloads that only differ past them")
+	}
+	buffer.AddCollapsing(first, time.Second)
+	if _, collapsed := buffer.AddCollapsing(second, time.Second); collapsed {
+		t.Fatalf("AddCollapsing(different tail) collapsed, want a new event")
+	}
+	if _, collapsed := buffer.AddCollapsing(offloaded("3", "b"), time.Second); !collapsed {
+		t.Fatalf("AddCollapsing(same payload) did not collapse")
+	}
+}
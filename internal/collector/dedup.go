@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"bytes"
+	"time"
+)
+
+// isRepeat reports whether event is the same dump as last, made from the
+// same place within window of last's latest occurrence. Events without a
+// callsite are never treated as repeats. An offloaded payload is compared by
+// its stored content's reference, since its preview only holds the start.
+func isRepeat(last Event, event Event, window time.Duration) bool {
+	if last.ProjectRoot != event.ProjectRoot || last.SourceType != event.SourceType ||
+		last.Label != event.Label || last.Level != event.Level || last.PayloadFormat != event.PayloadFormat ||
+		last.OriginalBytes != event.OriginalBytes || last.PayloadRef != event.PayloadRef {
+		return false
+	}
+
+	lastFile, lastLine, ok := callsite(last)
+	file, line, eventOK := callsite(event)
+	if !ok || !eventOK || lastFile != file || lastLine != line {
+		return false
+	}
+	if !bytes.Equal(last.Payload, event.Payload) {
+		return false
+	}
+
+	seen := last.Timestamp
+	if last.LastRepeatAt != "" {
+		seen = last.LastRepeatAt
+	}
+	previous, err := time.Parse(time.RFC3339Nano, seen)
+	if err != nil {
+		return false
+	}
+	current, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+	if err != nil {
+		return false
+	}
+	gap := current.Sub(previous)
+	return gap >= -window && gap <= window
+}
+
+// callsite is the application frame that made the dump, falling back to
+// the top frame for events without classified frames.
+func callsite(event Event) (string, int, bool) {
+	if event.Origin != nil {
+		return event.Origin.File, event.Origin.Line, event.Origin.File != ""
+	}
+	if len(event.Trace) > 0 && event.Trace[0].File != "" {
+		return event.Trace[0].File, event.Trace[0].Line, true
+	}
+	return "", 0, false
+}
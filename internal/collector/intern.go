@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+)
+
+type InternStats struct {
+	Unique     int   `json:"unique"`
+	References int   `json:"references"`
+	SavedBytes int64 `json:"savedBytes"`
+}
+
+type internEntry struct {
+	payload json.RawMessage
+	refs    int
+}
+
+// payloadInterner stores each distinct payload body once. Events keep a
+// slice of the shared bytes, so readers see ordinary payloads while loops
+// dumping the same structure cost one copy. Shared bytes must not be
+// mutated; replacing an event's Payload is fine.
+type payloadInterner struct {
+	entries    map[[sha256.Size]byte]*internEntry
+	references int
+	savedBytes int64
+}
+
+func newPayloadInterner() *payloadInterner {
+	return &payloadInterner{entries: make(map[[sha256.Size]byte]*internEntry)}
+}
+
+func (p *payloadInterner) intern(payload json.RawMessage) json.RawMessage {
+	if len(payload) == 0 {
+		return payload
+	}
+
+	key := sha256.Sum256(payload)
+	p.references++
+	if entry, ok := p.entries[key]; ok {
+		entry.refs++
+		p.savedBytes += int64(len(payload))
+		return entry.payload
+	}
+
+	p.entries[key] = &internEntry{payload: payload, refs: 1}
+	return payload
+}
+
+func (p *payloadInterner) release(payload json.RawMessage) {
+	if len(payload) == 0 {
+		return
+	}
+
+	key := sha256.Sum256(payload)
+	entry, ok := p.entries[key]
+	if !ok {
+		return
+	}
+
+	p.references--
+	entry.refs--
+	if entry.refs == 0 {
+		delete(p.entries, key)
+		return
+	}
+	p.savedBytes -= int64(len(payload))
+}
+
+func (p *payloadInterner) stats() InternStats {
+	return InternStats{
+		Unique:     len(p.entries),
+		References: p.references,
+		SavedBytes: p.savedBytes,
+	}
+}
@@ -1,24 +1,53 @@
 package collector
 
 import (
-	"bufio"
+	"encoding/json"
 	"errors"
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"phant/internal/capture"
+	"phant/internal/ddgate"
 	"phant/internal/dump"
+	"phant/internal/netline"
+	"phant/internal/pipeline"
 )
 
+// releaseWriteTimeout bounds writing a release line to a held producer.
+const releaseWriteTimeout = time.Second
+
 type Server struct {
 	socketPath string
 	buffer     *RingBuffer
 	decode     Decoder
 
-	mu          sync.RWMutex
-	subscribers map[int]chan Event
-	nextSubID   int
+	hub    *pipeline.Hub
+	shards *pipeline.Shards
+
+	// publishMu is held shared while publishing and exclusively while taking
+	// a snapshot, so a snapshot never splits an event between the buffer and
+	// the feed.
+	publishMu sync.RWMutex
+	published atomic.Uint64
+	// dedupWindow is the time.Duration within which consecutive identical
+	// dumps are collapsed; zero disables collapsing.
+	dedupWindow atomic.Int64
+	collapsed   atomic.Uint64
+	// gates parks producers that wait at a dd() call; without it they are
+	// released as soon as their event arrives.
+	gates atomic.Pointer[ddgate.Queue]
+	// receiveClock stamps ingested events with their receive time;
+	// skewWarning is the time.Duration of clock skew that adds a warning.
+	receiveClock atomic.Bool
+	skewWarning  atomic.Int64
+
+	capture func() *capture.Recorder
+	journal func(Event)
 
 	listener net.Listener
 	stopOnce sync.Once
@@ -26,18 +55,63 @@ type Server struct {
 	wg       sync.WaitGroup
 }
 
+// ServerOptions sizes the ring buffer and ingest shards; zero fields use
+// the defaults.
+type ServerOptions struct {
+	BufferSize int
+	Shards     int
+	ShardDepth int
+	// Overflow decides what happens when a shard queue is full; empty
+	// blocks the producing connection.
+	Overflow pipeline.OverflowPolicy
+}
+
 func NewServer(socketPath string, bufferSize int) *Server {
-	if bufferSize <= 0 {
-		bufferSize = DefaultBufferSize
+	return NewServerWithOptions(socketPath, ServerOptions{BufferSize: bufferSize})
+}
+
+func NewServerWithOptions(socketPath string, options ServerOptions) *Server {
+	if options.BufferSize <= 0 {
+		options.BufferSize = DefaultBufferSize
+	}
+	if options.Shards <= 0 {
+		options.Shards = pipeline.DefaultShardCount()
+	}
+	if options.ShardDepth <= 0 {
+		options.ShardDepth = pipeline.DefaultShardDepth
 	}
 
-	return &Server{
-		socketPath:  socketPath,
-		buffer:      NewRingBuffer(bufferSize),
-		decode:      dump.DecodeNDJSONLine,
-		subscribers: make(map[int]chan Event),
-		stopped:     make(chan struct{}),
+	server := &Server{
+		socketPath: socketPath,
+		buffer:     NewRingBuffer(options.BufferSize),
+		decode:     dump.DecodeNDJSONLine,
+		hub:        pipeline.NewHub(),
+		stopped:    make(chan struct{}),
 	}
+	server.shards = pipeline.NewShards(options.Shards, options.ShardDepth, server.Publish)
+	server.shards.SetOverflow(options.Overflow)
+	return server
+}
+
+// SetDecoder replaces the line decoder. It must be called before Start.
+func (s *Server) SetDecoder(decode Decoder) {
+	if decode == nil {
+		decode = dump.DecodeNDJSONLine
+	}
+	s.decode = decode
+}
+
+// SetCapture records connections while recorder returns a recorder. It
+// must be called before Start.
+func (s *Server) SetCapture(recorder func() *capture.Recorder) {
+	s.capture = recorder
+}
+
+// SetJournal has journal called with every ingested event before it is
+// queued, so it can be written ahead of the pipeline. It must be called
+// before Start.
+func (s *Server) SetJournal(journal func(Event)) {
+	s.journal = journal
 }
 
 func (s *Server) Start() error {
@@ -53,8 +127,12 @@ func (s *Server) Start() error {
 	if err != nil {
 		return err
 	}
+	if s.capture != nil {
+		listener = capture.Tap(listener, capture.ListenerCollector, s.capture)
+	}
 
 	s.listener = listener
+	s.shards.Start()
 	s.wg.Add(1)
 	go s.acceptLoop()
 
@@ -70,13 +148,8 @@ func (s *Server) Stop() error {
 		}
 		close(s.stopped)
 		s.wg.Wait()
-
-		s.mu.Lock()
-		for id, ch := range s.subscribers {
-			close(ch)
-			delete(s.subscribers, id)
-		}
-		s.mu.Unlock()
+		s.shards.Stop()
+		s.hub.Close()
 	})
 
 	if errors.Is(closeErr, net.ErrClosed) {
@@ -93,37 +166,59 @@ func (s *Server) Events() []Event {
 	return s.buffer.Snapshot()
 }
 
+func (s *Server) Select(match func(Event) bool) []Event {
+	return s.buffer.Select(match)
+}
+
+func (s *Server) RequestEvents(requestID string) []Event {
+	return s.buffer.ByRequest(requestID)
+}
+
+func (s *Server) Remove(ids map[string]struct{}) int {
+	return s.buffer.Remove(ids)
+}
+
+// Restore puts events removed earlier back into the buffer; see
+// RingBuffer.Restore.
+func (s *Server) Restore(events []Event) int {
+	return s.buffer.Restore(events)
+}
+
+func (s *Server) Replace(events map[string]Event) int {
+	return s.buffer.Replace(events)
+}
+
+func (s *Server) InternStats() InternStats {
+	return s.buffer.InternStats()
+}
+
 func (s *Server) DroppedCount() uint64 {
 	return s.buffer.DroppedCount()
 }
 
 func (s *Server) Subscribe(channelSize int) (int, <-chan Event) {
-	if channelSize < 1 {
-		channelSize = 1
-	}
-
-	ch := make(chan Event, channelSize)
-
-	s.mu.Lock()
-	id := s.nextSubID
-	s.nextSubID++
-	s.subscribers[id] = ch
-	s.mu.Unlock()
+	return s.hub.Subscribe(channelSize)
+}
 
-	return id, ch
+// SubscribeBlocking subscribes without dropping events, for the session
+// log: a full channel holds back publishing instead.
+func (s *Server) SubscribeBlocking(channelSize int) (int, <-chan Event) {
+	return s.hub.SubscribeBlocking(channelSize)
 }
 
 func (s *Server) Unsubscribe(id int) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.hub.Unsubscribe(id)
+}
 
-	ch, ok := s.subscribers[id]
-	if !ok {
-		return
-	}
+func (s *Server) SubscriberStats(id int) (pipeline.SubscriberStats, bool) {
+	return s.hub.Stats(id)
+}
 
-	close(ch)
-	delete(s.subscribers, id)
+// ServeConn handles conn as if the listener had accepted it, returning
+// once it ends. Replayed captures arrive this way.
+func (s *Server) ServeConn(conn net.Conn) {
+	s.wg.Add(1)
+	s.handleConn(conn)
 }
 
 func (s *Server) acceptLoop() {
@@ -152,28 +247,181 @@ func (s *Server) handleConn(conn net.Conn) {
 	defer s.wg.Done()
 	defer conn.Close()
 
-	scanner := bufio.NewScanner(conn)
-	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	// Producers may hold their socket open; Stop must not wait for them.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-s.stopped:
+			conn.Close()
+		case <-done:
+		}
+	}()
 
-	for scanner.Scan() {
-		event, err := s.decode(scanner.Text())
+	var held []string
+	send := releaser(conn)
+	netline.Read(conn, netline.Options{}, func(line []byte) {
+		event, err := s.decode(string(line))
 		if err != nil || event == nil {
-			continue
+			return
 		}
 
-		s.buffer.Add(*event)
-		s.broadcast(*event)
+		s.Ingest(*event)
+		if event.Gate && event.IsDD {
+			if s.hold(*event, send) {
+				held = append(held, event.ID)
+			}
+		}
+	})
+
+	if gates := s.gates.Load(); gates != nil && len(held) > 0 {
+		gates.Forget(held...)
 	}
 }
 
-func (s *Server) broadcast(event Event) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// hold parks a gated dd event's producer, or releases it at once when no
+// gate queue is set. It reports whether the producer is now waiting.
+func (s *Server) hold(event Event, send func(ddgate.Release) error) bool {
+	gates := s.gates.Load()
+	if gates == nil {
+		send(ddgate.Release{Type: "release", ID: event.ID, Action: ddgate.ActionExit, Reason: ddgate.ReasonDisabled})
+		return false
+	}
+	_, err := gates.Hold(event, send)
+	return err == nil
+}
 
-	for _, ch := range s.subscribers {
-		select {
-		case ch <- event:
-		default:
+// releaser writes release lines to a held producer. Writes are bounded so a
+// producer that stopped reading cannot stall a release batch.
+func releaser(conn net.Conn) func(ddgate.Release) error {
+	var mu sync.Mutex
+	return func(release ddgate.Release) error {
+		line, err := json.Marshal(release)
+		if err != nil {
+			return err
 		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(releaseWriteTimeout))
+		_, err = conn.Write(append(line, '\n'))
+		return err
+	}
+}
+
+// SetGates enables dd gates; nil releases gated producers immediately.
+func (s *Server) SetGates(gates *ddgate.Queue) {
+	s.gates.Store(gates)
+}
+
+// Ingest routes an event through the ingest shards. Events sharing a request
+// (or, without one, a producing process) are published in arrival order.
+func (s *Server) Ingest(event Event) {
+	if s.receiveClock.Load() {
+		dump.StampReceived(&event, time.Now(), time.Duration(s.skewWarning.Load()))
+	}
+	if s.journal != nil {
+		s.journal(event)
+	}
+	s.shards.Submit(shardKey(event), event)
+}
+
+// SetReceiveClock turns recording of receive times on ingested events on or
+// off. Events whose timestamp is more than warnAfter from their receive
+// time get a warning; zero never warns. Bulk imports are not stamped.
+func (s *Server) SetReceiveClock(record bool, warnAfter time.Duration) {
+	s.skewWarning.Store(int64(max(warnAfter, 0)))
+	s.receiveClock.Store(record)
+}
+
+func (s *Server) IngestStats() []pipeline.ShardStats {
+	return s.shards.Stats()
+}
+
+// SetDedupWindow enables collapsing of consecutive identical dumps made
+// within window of each other; zero turns it off.
+func (s *Server) SetDedupWindow(window time.Duration) {
+	s.dedupWindow.Store(int64(max(window, 0)))
+}
+
+// CollapsedCount is how many events were folded into an earlier one.
+func (s *Server) CollapsedCount() uint64 {
+	return s.collapsed.Load()
+}
+
+func (s *Server) SetOverflowPolicy(policy pipeline.OverflowPolicy) error {
+	return s.shards.SetOverflow(policy)
+}
+
+func (s *Server) OverflowPolicy() pipeline.OverflowPolicy {
+	return s.shards.Overflow()
+}
+
+func shardKey(event Event) string {
+	if event.RequestID != nil && *event.RequestID != "" {
+		return *event.RequestID
+	}
+	return event.Host.Hostname + ":" + strconv.Itoa(event.Host.PID)
+}
+
+// Publish stores and fans out an event immediately, bypassing the ingest
+// shards; bulk imports use it directly. A collapsed repeat is fanned out as
+// the updated original event, under its ID, so subscribers replace it.
+func (s *Server) Publish(event Event) {
+	s.publishMu.RLock()
+	defer s.publishMu.RUnlock()
+
+	event, collapsed := s.buffer.AddCollapsing(event, time.Duration(s.dedupWindow.Load()))
+	if collapsed {
+		s.collapsed.Add(1)
+	}
+	s.hub.Publish(event)
+	s.published.Add(1)
+}
+
+// PublishBatch publishes events in order as Publish does, and returns them
+// as they were fanned out, collapsed repeats included. Subscriber skip does
+// not receive them: bulk imports write each batch to the session log
+// themselves, so a full store subscription cannot drop any.
+func (s *Server) PublishBatch(events []Event, skip int) []Event {
+	s.publishMu.RLock()
+	defer s.publishMu.RUnlock()
+
+	published := make([]Event, 0, len(events))
+	window := time.Duration(s.dedupWindow.Load())
+	for _, event := range events {
+		event, collapsed := s.buffer.AddCollapsing(event, window)
+		if collapsed {
+			s.collapsed.Add(1)
+		}
+		s.hub.PublishExcept(event, skip)
+		s.published.Add(1)
+		published = append(published, event)
+	}
+	return published
+}
+
+// Snapshot is a consistent view for a new viewer: Events holds the latest
+// buffered matches and the subscription receives exactly the events
+// published after them. Cursor counts every event published before it.
+type Snapshot struct {
+	Events         []Event
+	Cursor         uint64
+	SubscriptionID int
+}
+
+// SubscribeSnapshot atomically snapshots up to limit buffered events
+// matching match (all of them when limit is not positive) and subscribes to
+// everything published afterwards, so listing and listening leave neither a
+// gap nor duplicates. The feed is not filtered.
+func (s *Server) SubscribeSnapshot(match func(Event) bool, limit int, channelSize int) (Snapshot, <-chan Event) {
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+
+	events := s.buffer.Select(match)
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
 	}
+	id, ch := s.hub.Subscribe(channelSize)
+	return Snapshot{Events: events, Cursor: s.published.Load(), SubscriptionID: id}, ch
 }
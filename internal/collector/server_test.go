@@ -1,11 +1,20 @@
 package collector
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"net"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"phant/internal/ddgate"
+	"phant/internal/dump"
+	"phant/internal/store"
+	"phant/internal/testsupport"
 )
 
 func TestServer_IngestsAndBroadcastsEvents(t *testing.T) {
@@ -49,6 +58,58 @@ func TestServer_IngestsAndBroadcastsEvents(t *testing.T) {
 	}
 }
 
+func TestServer_SubscribeSnapshotSplitsEventsWithoutGapOrOverlap(t *testing.T) {
+	server := NewServer(filepath.Join(t.TempDir(), "collector.sock"), 1000)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 500 {
+			server.Publish(Event{ID: fmt.Sprintf("evt-%d", i)})
+		}
+	}()
+
+	time.Sleep(time.Millisecond)
+	snapshot, ch := server.SubscribeSnapshot(func(Event) bool { return true }, 0, 1000)
+	wg.Wait()
+	server.Unsubscribe(snapshot.SubscriptionID)
+
+	if uint64(len(snapshot.Events)) != snapshot.Cursor {
+		t.Fatalf("snapshot len = %d, cursor = %d, want equal", len(snapshot.Events), snapshot.Cursor)
+	}
+	seen := map[string]bool{}
+	for _, event := range snapshot.Events {
+		seen[event.ID] = true
+	}
+	for event := range ch {
+		if seen[event.ID] {
+			t.Fatalf("feed repeated snapshot event %q", event.ID)
+		}
+		seen[event.ID] = true
+	}
+	if len(seen) != 500 {
+		t.Fatalf("snapshot + feed = %d events, want 500", len(seen))
+	}
+}
+
+func TestServer_PublishBatchSkipsOneSubscriber(t *testing.T) {
+	server := NewServer(filepath.Join(t.TempDir(), "collector.sock"), 10)
+	skipped, skippedCh := server.Subscribe(10)
+	_, ch := server.Subscribe(10)
+
+	published := server.PublishBatch([]Event{{ID: "a"}, {ID: "b"}}, skipped)
+	if len(published) != 2 || published[0].ID != "a" || published[1].ID != "b" {
+		t.Fatalf("PublishBatch() = %+v, want a and b in order", published)
+	}
+	if got := len(server.Events()); got != 2 {
+		t.Fatalf("buffered events = %d, want 2", got)
+	}
+	if len(ch) != 2 || len(skippedCh) != 0 {
+		t.Fatalf("subscriber got %d events, skipped one %d, want 2 and 0", len(ch), len(skippedCh))
+	}
+}
+
 func TestServer_RingBufferTracksDropped(t *testing.T) {
 	socketPath := filepath.Join(t.TempDir(), "collector.sock")
 	server := NewServer(socketPath, 2)
@@ -123,3 +184,243 @@ func TestServer_IgnoresInvalidLines(t *testing.T) {
 func validCLIEventLine(id string) string {
 	return fmt.Sprintf(`{"schemaVersion":1,"id":"%s","timestamp":"2026-03-02T12:00:00Z","sourceType":"cli","projectRoot":"/tmp/app","phpSapi":"cli","requestId":null,"command":{"name":"artisan"},"isDd":false,"payloadFormat":"json","payload":{"ok":true},"trace":[],"host":{"hostname":"test-host","pid":1234}}`, id)
 }
+
+func startChaosServer(t *testing.T) (*Server, string) {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "collector.sock")
+	server := NewServer(socketPath, 100)
+	if err := server.Start(); err != nil {
+		t.Fatalf("server.Start() error = %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+	return server, socketPath
+}
+
+func TestServer_SurvivesSlowInterleavedPartialWrites(t *testing.T) {
+	server, socketPath := startChaosServer(t)
+	producer := testsupport.NewProducer("/srv/app")
+	script := producer.CLI("artisan")
+	for i := range 12 {
+		script.Dump(fmt.Sprintf("dump-%d", i), i)
+	}
+
+	delivered, err := testsupport.SendChaos("unix", socketPath, script.Events(), testsupport.Chaos{
+		ChunkBytes:  7,
+		Delay:       100 * time.Microsecond,
+		Connections: 3,
+	})
+	if err != nil {
+		t.Fatalf("SendChaos() error = %v", err)
+	}
+
+	events := testsupport.WaitForEvents(t, server.Events, len(delivered))
+	testsupport.RequireIDs(t, events, testsupport.IDs(delivered)...)
+}
+
+func TestServer_DropsHalfWrittenLineOnDisconnect(t *testing.T) {
+	server, socketPath := startChaosServer(t)
+	events := testsupport.NewProducer("/srv/app").CLI("artisan").Dump("a", 1).Dump("b", 2).Events()
+	firstLine := len(testsupport.NDJSON(events[:1]))
+
+	delivered, err := testsupport.SendChaos("unix", socketPath, events, testsupport.Chaos{CutAfter: firstLine + 40})
+	if err != nil {
+		t.Fatalf("SendChaos() error = %v", err)
+	}
+	testsupport.RequireIDs(t, delivered, "evt-1")
+
+	// A second client still gets through after the torn connection.
+	if err := testsupport.Send("unix", socketPath, []dump.Event{events[1]}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	testsupport.RequireIDs(t, testsupport.WaitForEvents(t, server.Events, 2), "evt-1", "evt-2")
+}
+
+func TestServer_StopDisconnectsIdleClients(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "collector.sock")
+	server := NewServer(socketPath, 10)
+	if err := server.Start(); err != nil {
+		t.Fatalf("server.Start() error = %v", err)
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("net.Dial(unix, %q) error = %v", socketPath, err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(`{"schemaVersion":`))
+
+	stopped := make(chan struct{})
+	go func() {
+		server.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("server.Stop() blocked on an idle client")
+	}
+}
+
+func gatedDDLine(id string) string {
+	line := strings.Replace(validCLIEventLine(id), `"schemaVersion":1`, `"schemaVersion":2`, 1)
+	return strings.Replace(line, `"isDd":false`, `"isDd":true,"gate":true`, 1)
+}
+
+func readRelease(t *testing.T, reader *bufio.Reader) ddgate.Release {
+	t.Helper()
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("read release line error = %v", err)
+	}
+	var release ddgate.Release
+	if err := json.Unmarshal(line, &release); err != nil {
+		t.Fatalf("release line %q error = %v", line, err)
+	}
+	return release
+}
+
+func TestServer_HoldsGatedDumpsUntilReleased(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "collector.sock")
+	server := NewServer(socketPath, 10)
+	gates := ddgate.NewQueue(ddgate.DefaultSettings(), nil)
+	server.SetGates(gates)
+	if err := server.Start(); err != nil {
+		t.Fatalf("server.Start() error = %v", err)
+	}
+	defer server.Stop()
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("net.Dial(unix, %q) error = %v", socketPath, err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	fmt.Fprintln(conn, gatedDDLine("evt-1"))
+	testsupport.Eventually(t, "held gate", func() bool { return len(gates.Pending()) == 1 })
+
+	if released, err := gates.Release([]string{"evt-1"}, ddgate.ActionContinue); err != nil || released != 1 {
+		t.Fatalf("gates.Release() = %d, %v, want 1", released, err)
+	}
+	if release := readRelease(t, reader); release.ID != "evt-1" || release.Action != ddgate.ActionContinue {
+		t.Fatalf("release = %+v, want evt-1 continued", release)
+	}
+
+	fmt.Fprintln(conn, gatedDDLine("evt-2"))
+	testsupport.Eventually(t, "second gate", func() bool { return len(gates.Pending()) == 1 })
+	conn.Close()
+	testsupport.Eventually(t, "forgotten gate", func() bool { return len(gates.Pending()) == 0 })
+}
+
+func TestServer_ReleasesGatedDumpsWithoutQueue(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "collector.sock")
+	server := NewServer(socketPath, 10)
+	if err := server.Start(); err != nil {
+		t.Fatalf("server.Start() error = %v", err)
+	}
+	defer server.Stop()
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("net.Dial(unix, %q) error = %v", socketPath, err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintln(conn, gatedDDLine("evt-1"))
+	if release := readRelease(t, bufio.NewReader(conn)); release.Reason != ddgate.ReasonDisabled || release.Action != ddgate.ActionExit {
+		t.Fatalf("release = %+v, want immediate exit", release)
+	}
+}
+
+func TestServer_StampsReceiveTimeWhenEnabled(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "collector.sock")
+	server := NewServer(socketPath, 10)
+	server.SetReceiveClock(true, time.Minute)
+	if err := server.Start(); err != nil {
+		t.Fatalf("server.Start() error = %v", err)
+	}
+	defer server.Stop()
+
+	subID, ch := server.Subscribe(2)
+	defer server.Unsubscribe(subID)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("net.Dial(unix, %q) error = %v", socketPath, err)
+	}
+	defer conn.Close()
+
+	before := time.Now()
+	fmt.Fprintln(conn, validCLIEventLine("evt-1"))
+
+	select {
+	case got := <-ch:
+		received, err := time.Parse(time.RFC3339Nano, got.ReceivedAt)
+		if err != nil || received.Before(before.Add(-time.Millisecond)) {
+			t.Fatalf("event.ReceivedAt = %q, want time of receipt", got.ReceivedAt)
+		}
+		if n := len(got.Warnings); n == 0 || got.Warnings[n-1].Field != "timestamp" || !strings.Contains(got.Warnings[n-1].Message, "behind receive time") {
+			t.Fatalf("event.Warnings = %+v, want clock skew warning", got.Warnings)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for broadcast event")
+	}
+}
+
+func TestServer_BlockingSubscriberCommitsEventsPastAFullQueue(t *testing.T) {
+	dir := t.TempDir()
+	journal, _, err := store.OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	defer journal.Close()
+	log, err := store.Open(dir, store.Options{BatchSize: 8, OnCommit: func(batch []dump.Event) { _ = journal.Done(batch) }})
+	if err != nil {
+		t.Fatalf("store.Open() error = %v", err)
+	}
+
+	server := NewServer(filepath.Join(dir, "collector.sock"), 1000)
+	server.SetJournal(func(event Event) { _ = journal.Append(event) })
+	storeID, storeCh := server.SubscribeBlocking(1)
+	lossyID, _ := server.Subscribe(1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for event := range storeCh {
+			time.Sleep(100 * time.Microsecond)
+			_ = log.Append(event)
+		}
+	}()
+	if err := server.Start(); err != nil {
+		t.Fatalf("server.Start() error = %v", err)
+	}
+
+	const total = 200
+	for i := range total {
+		server.Ingest(dump.Event{ID: fmt.Sprintf("evt-%d", i), Payload: json.RawMessage(fmt.Sprintf(`{"n":%d}`, i))})
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.Events()) < total && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if stats, _ := server.SubscriberStats(lossyID); stats.Dropped == 0 {
+		t.Fatalf("lossy subscriber dropped nothing; the queue never filled")
+	}
+	server.Unsubscribe(storeID)
+	wg.Wait()
+	_ = server.Stop()
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	committed := 0
+	if err := store.ReadSession(log.Path(), func(dump.Event) error { committed++; return nil }); err != nil {
+		t.Fatalf("ReadSession() error = %v", err)
+	}
+	if committed != total || journal.Outstanding() != 0 {
+		t.Fatalf("committed %d events, %d left in the journal, want all %d committed", committed, journal.Outstanding(), total)
+	}
+}
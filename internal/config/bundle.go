@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const BundleVersion = 1
+
+var ErrUnsupportedBundleVersion = errors.New("unsupported config bundle version")
+
+// Bundle is the portable document written by Export and read by Import.
+// Each subsystem owns one named section so teams can share a phant setup
+// without the bundle format knowing every rule type up front.
+type Bundle struct {
+	Version    int                        `json:"version"`
+	ExportedAt string                     `json:"exportedAt"`
+	Sections   map[string]json.RawMessage `json:"sections"`
+}
+
+type Section struct {
+	Name   string
+	Export func() (any, error)
+	Import func(raw json.RawMessage) error
+}
+
+type ImportResult struct {
+	Imported []string `json:"imported"`
+	Skipped  []string `json:"skipped"`
+	Errors   []string `json:"errors"`
+}
+
+type Registry struct {
+	now func() time.Time
+
+	mu       sync.RWMutex
+	sections map[string]Section
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		now:      time.Now,
+		sections: make(map[string]Section),
+	}
+}
+
+func (r *Registry) Register(section Section) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sections[section.Name] = section
+}
+
+func (r *Registry) Build() (Bundle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bundle := Bundle{
+		Version:    BundleVersion,
+		ExportedAt: r.now().UTC().Format(time.RFC3339),
+		Sections:   make(map[string]json.RawMessage, len(r.sections)),
+	}
+
+	for name, section := range r.sections {
+		if section.Export == nil {
+			continue
+		}
+
+		value, err := section.Export()
+		if err != nil {
+			return Bundle{}, fmt.Errorf("export config section %s: %w", name, err)
+		}
+
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return Bundle{}, fmt.Errorf("encode config section %s: %w", name, err)
+		}
+		bundle.Sections[name] = raw
+	}
+
+	return bundle, nil
+}
+
+// Apply imports every known section of the bundle. A failing section does
+// not stop the others; its error is reported in the result instead.
+func (r *Registry) Apply(bundle Bundle) (ImportResult, error) {
+	if bundle.Version < 1 || bundle.Version > BundleVersion {
+		return ImportResult{}, fmt.Errorf("%w: %d", ErrUnsupportedBundleVersion, bundle.Version)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(bundle.Sections))
+	for name := range bundle.Sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := ImportResult{
+		Imported: []string{},
+		Skipped:  []string{},
+		Errors:   []string{},
+	}
+	for _, name := range names {
+		section, ok := r.sections[name]
+		if !ok || section.Import == nil {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+
+		if err := section.Import(bundle.Sections[name]); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		result.Imported = append(result.Imported, name)
+	}
+
+	return result, nil
+}
+
+func (r *Registry) Export(path string) error {
+	bundle, err := r.Build()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (r *Registry) Import(path string) (ImportResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return ImportResult{}, fmt.Errorf("decode config bundle: %w", err)
+	}
+
+	return r.Apply(bundle)
+}
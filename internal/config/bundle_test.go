@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry_ExportImportRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "phant-config.json")
+
+	source := NewRegistry()
+	source.Register(Section{
+		Name:   "pathMappings",
+		Export: func() (any, error) { return map[string]string{"/var/www/html": "/home/ada/app"}, nil },
+	})
+	if err := source.Export(path); err != nil {
+		t.Fatalf("source.Export() error = %v", err)
+	}
+
+	var imported map[string]string
+	target := NewRegistry()
+	target.Register(Section{
+		Name: "pathMappings",
+		Import: func(raw json.RawMessage) error {
+			return json.Unmarshal(raw, &imported)
+		},
+	})
+
+	result, err := target.Import(path)
+	if err != nil {
+		t.Fatalf("target.Import() error = %v", err)
+	}
+	if !reflect.DeepEqual(result.Imported, []string{"pathMappings"}) {
+		t.Fatalf("result.Imported = %v, want [pathMappings]", result.Imported)
+	}
+	if imported["/var/www/html"] != "/home/ada/app" {
+		t.Fatalf("imported mappings = %v, want /var/www/html mapping", imported)
+	}
+}
+
+func TestRegistry_ApplyReportsSkippedAndFailedSections(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Section{
+		Name:   "broken",
+		Import: func(json.RawMessage) error { return errors.New("boom") },
+	})
+
+	result, err := registry.Apply(Bundle{
+		Version: BundleVersion,
+		Sections: map[string]json.RawMessage{
+			"broken":  json.RawMessage(`{}`),
+			"unknown": json.RawMessage(`{}`),
+		},
+	})
+	if err != nil {
+		t.Fatalf("registry.Apply() error = %v", err)
+	}
+	if !reflect.DeepEqual(result.Skipped, []string{"unknown"}) {
+		t.Fatalf("result.Skipped = %v, want [unknown]", result.Skipped)
+	}
+	if !reflect.DeepEqual(result.Errors, []string{"broken: boom"}) {
+		t.Fatalf("result.Errors = %v, want [broken: boom]", result.Errors)
+	}
+}
+
+func TestRegistry_ApplyRejectsFutureVersion(t *testing.T) {
+	_, err := NewRegistry().Apply(Bundle{Version: BundleVersion + 1})
+	if !errors.Is(err, ErrUnsupportedBundleVersion) {
+		t.Fatalf("registry.Apply() error = %v, want ErrUnsupportedBundleVersion", err)
+	}
+}
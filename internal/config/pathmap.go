@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ValidatePathMappings checks mappings set from the UI. Unlike .phant.toml
+// there is no project directory to resolve relative paths against, so local
+// prefixes must be absolute.
+func ValidatePathMappings(mappings []PathMapping) error {
+	for i, mapping := range mappings {
+		if mapping.Remote == "" || mapping.Local == "" {
+			return fmt.Errorf("path mapping %d requires remote and local", i+1)
+		}
+		if !filepath.IsAbs(mapping.Local) {
+			return fmt.Errorf("path mapping %d: local path %q must be absolute", i+1, mapping.Local)
+		}
+	}
+	return nil
+}
+
+// MapPath rewrites a path reported by a producer (typically inside a
+// container) to the matching host path. The mapping with the longest remote
+// prefix wins, and prefixes only match on whole path segments, so
+// /var/www/html never maps /var/www/html2.
+func MapPath(mappings []PathMapping, path string) (string, bool) {
+	best := -1
+	bestLen := 0
+	for i, mapping := range mappings {
+		remote := strings.TrimRight(mapping.Remote, "/\\")
+		if remote == "" || len(remote) <= bestLen || !hasPathPrefix(path, remote) {
+			continue
+		}
+		best, bestLen = i, len(remote)
+	}
+	if best < 0 {
+		return path, false
+	}
+
+	rest := strings.TrimLeft(path[bestLen:], "/\\")
+	if rest == "" {
+		return filepath.Clean(mappings[best].Local), true
+	}
+	return filepath.Join(mappings[best].Local, filepath.FromSlash(rest)), true
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '/' || path[len(prefix)] == '\\'
+}
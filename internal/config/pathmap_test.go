@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMapPath_LongestSegmentPrefixWins(t *testing.T) {
+	mappings := []PathMapping{
+		{Remote: "/var/www/html/", Local: "/home/ada/app"},
+		{Remote: "/var/www/html/vendor", Local: "/home/ada/vendor-cache"},
+	}
+
+	tests := []struct {
+		path   string
+		want   string
+		mapped bool
+	}{
+		{"/var/www/html/app/Cart.php", "/home/ada/app/app/Cart.php", true},
+		{"/var/www/html", "/home/ada/app", true},
+		{"/var/www/html/vendor/laravel/Router.php", "/home/ada/vendor-cache/laravel/Router.php", true},
+		{"/var/www/html2/index.php", "/var/www/html2/index.php", false},
+		{"/srv/other.php", "/srv/other.php", false},
+	}
+	for _, test := range tests {
+		got, mapped := MapPath(mappings, test.path)
+		if got != test.want || mapped != test.mapped {
+			t.Fatalf("MapPath(%q) = %q, %v, want %q, %v", test.path, got, mapped, test.want, test.mapped)
+		}
+	}
+}
+
+func TestValidatePathMappings(t *testing.T) {
+	if err := ValidatePathMappings([]PathMapping{{Remote: "/var/www/html", Local: "/home/ada/app"}}); err != nil {
+		t.Fatalf("ValidatePathMappings() error = %v", err)
+	}
+	for _, mapping := range []PathMapping{{Remote: "/var/www"}, {Remote: "/var/www", Local: "app"}} {
+		if err := ValidatePathMappings([]PathMapping{mapping}); err == nil {
+			t.Fatalf("ValidatePathMappings(%+v) error = nil, want error", mapping)
+		}
+	}
+}
+
+func TestProjects_ResolveReadsProjectFileThroughMapping(t *testing.T) {
+	local := t.TempDir()
+	if err := os.WriteFile(filepath.Join(local, ProjectFileName), []byte(sampleProjectFile), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	projects := NewProjects()
+	projects.SetOverrides("/var/www/html", ProjectConfig{PathMappings: []PathMapping{{Remote: "/var/www/html", Local: local}}})
+
+	resolved := projects.Resolve("/var/www/html")
+	if resolved.LocalRoot != local || !resolved.Detected {
+		t.Fatalf("Resolve() localRoot = %q detected = %v, want %q detected", resolved.LocalRoot, resolved.Detected, local)
+	}
+	if got, _ := projects.MapPath("/var/www/html", "/var/www/html/app/Cart.php"); got != filepath.Join(local, "app", "Cart.php") {
+		t.Fatalf("MapPath() = %q, want file under %q", got, local)
+	}
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const ProjectFileName = ".phant.toml"
+
+// ProjectConfig holds the per-project settings a team can commit in
+// .phant.toml and a user can override locally.
+type ProjectConfig struct {
+	Editor       EditorConfig    `json:"editor"`
+	PathMappings []PathMapping   `json:"pathMappings"`
+	Redaction    []RedactionRule `json:"redaction"`
+	Channels     []string        `json:"channels"`
+}
+
+type EditorConfig struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+type PathMapping struct {
+	Remote string `json:"remote"`
+	Local  string `json:"local"`
+}
+
+type RedactionRule struct {
+	Key     string `json:"key"`
+	Pattern string `json:"pattern"`
+}
+
+type ResolvedProject struct {
+	ProjectRoot string `json:"projectRoot"`
+	// LocalRoot is where the project lives on this machine: ProjectRoot
+	// rewritten by the local path mappings, or ProjectRoot itself.
+	LocalRoot  string        `json:"localRoot"`
+	ConfigFile string        `json:"configFile"`
+	Detected   bool          `json:"detected"`
+	Config     ProjectConfig `json:"config"`
+	LastError  string        `json:"lastError"`
+}
+
+// LoadProjectFile reads <projectRoot>/.phant.toml. A missing file is not an
+// error; detected reports whether one was found.
+func LoadProjectFile(projectRoot string) (ProjectConfig, bool, error) {
+	data, err := os.ReadFile(filepath.Join(projectRoot, ProjectFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return ProjectConfig{}, false, nil
+	}
+	if err != nil {
+		return ProjectConfig{}, false, err
+	}
+
+	project, err := ParseProjectFile(data, projectRoot)
+	return project, true, err
+}
+
+func ParseProjectFile(data []byte, projectRoot string) (ProjectConfig, error) {
+	document, err := parseTOML(data)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+
+	var project ProjectConfig
+	if err := json.Unmarshal(encoded, &project); err != nil {
+		return ProjectConfig{}, fmt.Errorf("invalid %s: %w", ProjectFileName, err)
+	}
+	// The file comes with the repository, so it may only pick a preset: a
+	// command it named would run as soon as a trace frame was clicked.
+	if project.Editor.Command != "" {
+		return ProjectConfig{}, fmt.Errorf("invalid %s: editor.command is only accepted in user settings; set editor.name to a preset", ProjectFileName)
+	}
+
+	for i, mapping := range project.PathMappings {
+		if mapping.Remote == "" || mapping.Local == "" {
+			return ProjectConfig{}, fmt.Errorf("invalid %s: path mapping %d requires remote and local", ProjectFileName, i+1)
+		}
+		if !filepath.IsAbs(mapping.Local) {
+			project.PathMappings[i].Local = filepath.Join(projectRoot, mapping.Local)
+		}
+	}
+	for i, rule := range project.Redaction {
+		if rule.Key == "" && rule.Pattern == "" {
+			return ProjectConfig{}, fmt.Errorf("invalid %s: redaction rule %d requires key or pattern", ProjectFileName, i+1)
+		}
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return ProjectConfig{}, fmt.Errorf("invalid %s: redaction rule %d: %w", ProjectFileName, i+1, err)
+			}
+		}
+	}
+
+	return project, nil
+}
+
+// Merge layers overlay on top of base: scalar fields set in overlay win,
+// lists are combined with overlay entries first.
+func Merge(base ProjectConfig, overlay ProjectConfig) ProjectConfig {
+	merged := base
+
+	if overlay.Editor.Name != "" {
+		merged.Editor.Name = overlay.Editor.Name
+	}
+	if overlay.Editor.Command != "" {
+		merged.Editor.Command = overlay.Editor.Command
+	}
+
+	merged.PathMappings = nil
+	seenRemote := map[string]bool{}
+	for _, mapping := range append(append([]PathMapping{}, overlay.PathMappings...), base.PathMappings...) {
+		if seenRemote[mapping.Remote] {
+			continue
+		}
+		seenRemote[mapping.Remote] = true
+		merged.PathMappings = append(merged.PathMappings, mapping)
+	}
+
+	merged.Redaction = nil
+	seenRule := map[RedactionRule]bool{}
+	for _, rule := range append(append([]RedactionRule{}, overlay.Redaction...), base.Redaction...) {
+		if seenRule[rule] {
+			continue
+		}
+		seenRule[rule] = true
+		merged.Redaction = append(merged.Redaction, rule)
+	}
+
+	merged.Channels = nil
+	seenChannel := map[string]bool{}
+	for _, channel := range append(append([]string{}, overlay.Channels...), base.Channels...) {
+		if seenChannel[channel] {
+			continue
+		}
+		seenChannel[channel] = true
+		merged.Channels = append(merged.Channels, channel)
+	}
+
+	return merged
+}
+
+type projectEntry struct {
+	modTime  time.Time
+	detected bool
+	file     ProjectConfig
+	err      string
+}
+
+// Projects tracks project roots seen in incoming events and resolves their
+// effective configuration: committed .phant.toml settings with local
+// overrides applied on top. Files are re-read when their mtime changes.
+type Projects struct {
+	mu        sync.Mutex
+	entries   map[string]projectEntry
+	overrides map[string]ProjectConfig
+}
+
+func NewProjects() *Projects {
+	return &Projects{
+		entries:   make(map[string]projectEntry),
+		overrides: make(map[string]ProjectConfig),
+	}
+}
+
+// Observe registers a project root the first time it is seen.
+func (p *Projects) Observe(projectRoot string) {
+	if projectRoot == "" {
+		return
+	}
+
+	p.mu.Lock()
+	_, known := p.entries[projectRoot]
+	p.mu.Unlock()
+
+	if !known {
+		p.Resolve(projectRoot)
+	}
+}
+
+// Resolve returns the effective configuration for projectRoot. Roots
+// reported from containers are mapped through the local path mappings first,
+// so the committed .phant.toml is read from the host checkout.
+func (p *Projects) Resolve(projectRoot string) ResolvedProject {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	localRoot, _ := MapPath(p.overrides[projectRoot].PathMappings, projectRoot)
+	path := filepath.Join(localRoot, ProjectFileName)
+
+	entry := p.entries[projectRoot]
+	info, statErr := os.Stat(path)
+	switch {
+	case statErr != nil:
+		entry = projectEntry{}
+	case !entry.detected || !info.ModTime().Equal(entry.modTime):
+		file, detected, err := LoadProjectFile(localRoot)
+		entry = projectEntry{modTime: info.ModTime(), detected: detected, file: file}
+		if err != nil {
+			entry.err = err.Error()
+		}
+	}
+	p.entries[projectRoot] = entry
+
+	resolved := ResolvedProject{
+		ProjectRoot: projectRoot,
+		LocalRoot:   localRoot,
+		Detected:    entry.detected,
+		Config:      Merge(entry.file, p.overrides[projectRoot]),
+		LastError:   entry.err,
+	}
+	if entry.detected {
+		resolved.ConfigFile = path
+	}
+	return resolved
+}
+
+// ConfigPath is where the .phant.toml of projectRoot is read from, whether
+// or not it exists.
+func (p *Projects) ConfigPath(projectRoot string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	localRoot, _ := MapPath(p.overrides[projectRoot].PathMappings, projectRoot)
+	return filepath.Join(localRoot, ProjectFileName)
+}
+
+// MapPath rewrites a path reported for projectRoot with the project's
+// effective path mappings.
+func (p *Projects) MapPath(projectRoot string, path string) (string, bool) {
+	return MapPath(p.Resolve(projectRoot).Config.PathMappings, path)
+}
+
+func (p *Projects) SetOverrides(projectRoot string, overrides ProjectConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.overrides[projectRoot] = overrides
+	if _, known := p.entries[projectRoot]; !known {
+		p.entries[projectRoot] = projectEntry{}
+	}
+}
+
+func (p *Projects) Overrides() map[string]ProjectConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	overrides := make(map[string]ProjectConfig, len(p.overrides))
+	for root, project := range p.overrides {
+		overrides[root] = project
+	}
+	return overrides
+}
+
+func (p *Projects) Roots() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	roots := make([]string, 0, len(p.entries))
+	for root := range p.entries {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	return roots
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const sampleProjectFile = `# committed by the team
+channels = ["payments", "auth"]
+
+[editor]
+name = "phpstorm"
+
+[[pathMappings]]
+remote = "/var/www/html"
+local = "."
+
+[[redaction]]
+key = "password"
+
+[[redaction]]
+pattern = '\d{16}'
+`
+
+func TestParseProjectFile(t *testing.T) {
+	root := "/home/ada/app"
+	got, err := ParseProjectFile([]byte(sampleProjectFile), root)
+	if err != nil {
+		t.Fatalf("ParseProjectFile() error = %v", err)
+	}
+
+	want := ProjectConfig{
+		Editor:       EditorConfig{Name: "phpstorm"},
+		PathMappings: []PathMapping{{Remote: "/var/www/html", Local: root}},
+		Redaction:    []RedactionRule{{Key: "password"}, {Pattern: `\d{16}`}},
+		Channels:     []string{"payments", "auth"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseProjectFile() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseProjectFile_RejectsInvalidDocuments(t *testing.T) {
+	for name, doc := range map[string]string{
+		"unterminated string": `channels = ["a`,
+		"duplicate key":       "channels = []\nchannels = []",
+		"incomplete mapping":  "[[pathMappings]]\nremote = \"/var/www\"",
+		"inline table":        `editor = { name = "code" }`,
+		"invalid pattern":     "[[redaction]]\npattern = '('",
+		"editor command":      "[editor]\ncommand = \"sh -c {file}\"",
+	} {
+		if _, err := ParseProjectFile([]byte(doc), "/app"); err == nil {
+			t.Fatalf("ParseProjectFile(%s) error = nil, want error", name)
+		}
+	}
+}
+
+func TestProjects_ResolveMergesOverridesAndReloads(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ProjectFileName)
+	if err := os.WriteFile(path, []byte(sampleProjectFile), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	projects := NewProjects()
+	projects.Observe(root)
+	projects.SetOverrides(root, ProjectConfig{Editor: EditorConfig{Name: "code"}})
+
+	resolved := projects.Resolve(root)
+	if !resolved.Detected || resolved.ConfigFile != path {
+		t.Fatalf("Resolve() detected = %v file = %q, want detected %q", resolved.Detected, resolved.ConfigFile, path)
+	}
+	if resolved.Config.Editor.Name != "code" {
+		t.Fatalf("Resolve().Config.Editor.Name = %q, want local override %q", resolved.Config.Editor.Name, "code")
+	}
+	if len(resolved.Config.Redaction) != 2 {
+		t.Fatalf("Resolve().Config.Redaction len = %d, want 2", len(resolved.Config.Redaction))
+	}
+
+	if err := os.WriteFile(path, []byte("channels = [\"billing\"]\n"), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("os.Chtimes() error = %v", err)
+	}
+
+	resolved = projects.Resolve(root)
+	if !reflect.DeepEqual(resolved.Config.Channels, []string{"billing"}) {
+		t.Fatalf("Resolve().Config.Channels = %v, want reloaded [billing]", resolved.Config.Channels)
+	}
+}
+
+func TestProjects_ResolveWithoutProjectFile(t *testing.T) {
+	resolved := NewProjects().Resolve(t.TempDir())
+	if resolved.Detected || resolved.LastError != "" {
+		t.Fatalf("Resolve() = %+v, want undetected without error", resolved)
+	}
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"phant/internal/ingest"
+	"phant/internal/retention"
+	"phant/internal/trash"
+)
+
+const (
+	SettingsFileName = "settings.json"
+	// SettingsVersion is the version Save writes; Load migrates files of
+	// older versions up to it.
+	SettingsVersion = 1
+
+	ThemeSystem = "system"
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+
+	// DefaultSlowQueryMs matches the slow query threshold of Laravel
+	// Telescope.
+	DefaultSlowQueryMs = 100
+
+	// legacyTrayFileName held the tray settings before settings.json.
+	legacyTrayFileName = "tray.json"
+)
+
+var ErrUnsupportedSettingsVersion = errors.New("unsupported settings version")
+
+// Listeners are the addresses of the network listeners, such as
+// "127.0.0.1:9912"; an empty address disables a listener.
+type Listeners struct {
+	VarDumper string `json:"varDumper"`
+	Ray       string `json:"ray"`
+	Monolog   string `json:"monolog"`
+	// API serves the read-only REST API rather than ingesting events.
+	API string `json:"api"`
+	// Extra are the listeners added next to the collector socket, each with
+	// its own kind and address.
+	Extra []ingest.Spec `json:"extra,omitempty"`
+}
+
+// Settings are the app settings that outlive a restart. Unlike the config
+// bundle, which copies a setup between machines, they belong to this
+// install: Load reads them at startup and Save writes them back.
+type Settings struct {
+	Version        int              `json:"version"`
+	Listeners      Listeners        `json:"listeners"`
+	Retention      retention.Policy `json:"retention"`
+	Editor         EditorConfig     `json:"editor"`
+	Theme          string           `json:"theme"`
+	RedactionRules []RedactionRule  `json:"redactionRules"`
+	// StartHidden opens phant with its window hidden, reached from the
+	// system tray.
+	StartHidden bool `json:"startHidden"`
+	// UndoWindowMs is how long cleared events can be restored before they
+	// are deleted; zero makes clearing permanent at once.
+	UndoWindowMs int `json:"undoWindowMs"`
+	// SlowQueryMs flags events with an sql block that took at least this
+	// long; zero flags none.
+	SlowQueryMs int `json:"slowQueryMs"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Version:        SettingsVersion,
+		Theme:          ThemeSystem,
+		RedactionRules: []RedactionRule{},
+		UndoWindowMs:   int(trash.DefaultWindow / time.Millisecond),
+		SlowQueryMs:    DefaultSlowQueryMs,
+	}
+}
+
+// Validate checks what this package can; the editor and redaction rules
+// are validated by the packages that use them.
+func (s Settings) Validate() error {
+	for name, address := range map[string]string{
+		"varDumper": s.Listeners.VarDumper,
+		"ray":       s.Listeners.Ray,
+		"monolog":   s.Listeners.Monolog,
+		"api":       s.Listeners.API,
+	} {
+		if address == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return fmt.Errorf("listeners.%s: %w", name, err)
+		}
+	}
+	seen := map[string]bool{}
+	for _, spec := range s.Listeners.Extra {
+		if err := spec.Validate(); err != nil {
+			return fmt.Errorf("listeners.extra %s: %w", spec.ID(), err)
+		}
+		if seen[spec.ID()] {
+			return fmt.Errorf("listeners.extra %s is listed twice", spec.ID())
+		}
+		seen[spec.ID()] = true
+	}
+	if err := s.Retention.Validate(); err != nil {
+		return err
+	}
+	switch s.Theme {
+	case ThemeSystem, ThemeLight, ThemeDark:
+	default:
+		return errors.New("theme must be one of: system, light, dark")
+	}
+	if window := time.Duration(s.UndoWindowMs) * time.Millisecond; window < 0 || window > trash.MaxWindow {
+		return fmt.Errorf("undoWindowMs: %w", trash.ErrWindowRange)
+	}
+	if s.SlowQueryMs < 0 {
+		return errors.New("slowQueryMs must not be negative")
+	}
+	return nil
+}
+
+// migrations upgrade a settings document from the version it is keyed by
+// to the next one. Version 0 is an install without settings.json.
+var migrations = map[int]func(dir string, document map[string]json.RawMessage) error{
+	0: migrateTrayFile,
+}
+
+// migrateTrayFile takes startHidden from tray.json, where the tray kept it
+// before settings.json existed. SaveSettings removes the file.
+func migrateTrayFile(dir string, document map[string]json.RawMessage) error {
+	path := filepath.Join(dir, legacyTrayFileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var tray struct {
+		StartHidden json.RawMessage `json:"startHidden"`
+	}
+	if err := json.Unmarshal(data, &tray); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if tray.StartHidden != nil {
+		document["startHidden"] = tray.StartHidden
+	}
+	return nil
+}
+
+// LoadSettings reads the settings in dir, migrating them to
+// SettingsVersion; missing fields keep their defaults. Without a settings
+// file it returns the defaults, plus whatever older files held.
+func LoadSettings(dir string) (Settings, error) {
+	path := filepath.Join(dir, SettingsFileName)
+	document := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		document["version"] = json.RawMessage("0")
+	case err != nil:
+		return Settings{}, err
+	default:
+		if err := json.Unmarshal(data, &document); err != nil {
+			return Settings{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	var version int
+	if raw, ok := document["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return Settings{}, fmt.Errorf("%s: version: %w", path, err)
+		}
+	}
+	if version < 0 || version > SettingsVersion {
+		return Settings{}, fmt.Errorf("%w: %d", ErrUnsupportedSettingsVersion, version)
+	}
+	for ; version < SettingsVersion; version++ {
+		if err := migrations[version](dir, document); err != nil {
+			return Settings{}, fmt.Errorf("migrate settings from version %d: %w", version, err)
+		}
+	}
+	delete(document, "version")
+
+	migrated, err := json.Marshal(document)
+	if err != nil {
+		return Settings{}, err
+	}
+	settings := DefaultSettings()
+	if err := json.Unmarshal(migrated, &settings); err != nil {
+		return Settings{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if settings.RedactionRules == nil {
+		settings.RedactionRules = []RedactionRule{}
+	}
+	if err := settings.Validate(); err != nil {
+		return Settings{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// SaveSettings writes settings to dir at SettingsVersion, replacing the
+// file atomically, and drops files that older versions read instead.
+func SaveSettings(dir string, settings Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	settings.Version = SettingsVersion
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, SettingsFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, SettingsFileName)); err != nil {
+		return err
+	}
+	_ = os.Remove(filepath.Join(dir, legacyTrayFileName))
+	return nil
+}
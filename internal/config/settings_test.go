@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"phant/internal/retention"
+)
+
+func TestSettings_SaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	loaded, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings(empty) error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, DefaultSettings()) {
+		t.Fatalf("LoadSettings(empty) = %+v, want defaults", loaded)
+	}
+
+	saved := DefaultSettings()
+	saved.Listeners = Listeners{Ray: "127.0.0.1:23517"}
+	saved.Retention = retention.Policy{MaxEvents: 5000}
+	saved.Editor = EditorConfig{Name: "phpstorm"}
+	saved.Theme = ThemeDark
+	saved.RedactionRules = []RedactionRule{{Key: "token"}}
+	saved.UndoWindowMs = 0
+	if err := SaveSettings(dir, saved); err != nil {
+		t.Fatalf("SaveSettings() error = %v", err)
+	}
+
+	loaded, err = LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, saved) {
+		t.Fatalf("LoadSettings() = %+v, want %+v", loaded, saved)
+	}
+
+	saved.Theme = "sepia"
+	if err := SaveSettings(dir, saved); err == nil {
+		t.Fatalf("SaveSettings(theme sepia) error = nil, want error")
+	}
+	saved.Theme = ThemeLight
+	saved.Listeners.Monolog = "9913"
+	if err := SaveSettings(dir, saved); err == nil {
+		t.Fatalf("SaveSettings(monolog 9913) error = nil, want error")
+	}
+	saved.Listeners.Monolog = ""
+	saved.UndoWindowMs = -1
+	if err := SaveSettings(dir, saved); err == nil {
+		t.Fatalf("SaveSettings(undoWindowMs -1) error = nil, want error")
+	}
+	saved.UndoWindowMs = 0
+	saved.SlowQueryMs = -1
+	if err := SaveSettings(dir, saved); err == nil {
+		t.Fatalf("SaveSettings(slowQueryMs -1) error = nil, want error")
+	}
+}
+
+func TestLoadSettings_MigratesOlderInstalls(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, legacyTrayFileName), []byte(`{"startHidden":true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if !settings.StartHidden || settings.Theme != ThemeSystem {
+		t.Fatalf("LoadSettings() = %+v, want startHidden from tray.json and defaults otherwise", settings)
+	}
+
+	if err := SaveSettings(dir, settings); err != nil {
+		t.Fatalf("SaveSettings() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, legacyTrayFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("tray.json after SaveSettings() stat error = %v, want removed", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, SettingsFileName), []byte(`{"version":99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSettings(dir); !errors.Is(err, ErrUnsupportedSettingsVersion) {
+		t.Fatalf("LoadSettings(version 99) error = %v, want ErrUnsupportedSettingsVersion", err)
+	}
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML decodes the subset of TOML used by project files: tables,
+// arrays of tables, and key/value pairs holding strings, integers, floats,
+// booleans, or single-line arrays of those.
+func parseTOML(data []byte) (map[string]any, error) {
+	root := map[string]any{}
+	current := root
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		var err error
+		switch {
+		case strings.HasPrefix(line, "[["):
+			if !strings.HasSuffix(line, "]]") {
+				return nil, tomlError(lineNo, "unterminated array table header")
+			}
+			current, err = openArrayTable(root, strings.TrimSpace(line[2:len(line)-2]))
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, tomlError(lineNo, "unterminated table header")
+			}
+			current, err = openTable(root, strings.TrimSpace(line[1:len(line)-1]))
+		default:
+			err = parseKeyValue(current, line)
+		}
+		if err != nil {
+			return nil, tomlError(lineNo, err.Error())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+func tomlError(line int, message string) error {
+	return fmt.Errorf("toml line %d: %s", line, message)
+}
+
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func splitTOMLKey(key string) ([]string, error) {
+	if key == "" {
+		return nil, errors.New("empty key")
+	}
+
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(part) >= 2 && (part[0] == '"' || part[0] == '\'') && part[len(part)-1] == part[0] {
+			part = part[1 : len(part)-1]
+		}
+		if part == "" {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+		parts[i] = part
+	}
+	return parts, nil
+}
+
+func descend(root map[string]any, parts []string) (map[string]any, error) {
+	current := root
+	for _, part := range parts {
+		switch next := current[part].(type) {
+		case nil:
+			table := map[string]any{}
+			current[part] = table
+			current = table
+		case map[string]any:
+			current = next
+		case []map[string]any:
+			current = next[len(next)-1]
+		default:
+			return nil, fmt.Errorf("key %q is not a table", part)
+		}
+	}
+	return current, nil
+}
+
+func openTable(root map[string]any, header string) (map[string]any, error) {
+	parts, err := splitTOMLKey(header)
+	if err != nil {
+		return nil, err
+	}
+	return descend(root, parts)
+}
+
+func openArrayTable(root map[string]any, header string) (map[string]any, error) {
+	parts, err := splitTOMLKey(header)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := descend(root, parts[:len(parts)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	name := parts[len(parts)-1]
+	table := map[string]any{}
+	switch existing := parent[name].(type) {
+	case nil:
+		parent[name] = []map[string]any{table}
+	case []map[string]any:
+		parent[name] = append(existing, table)
+	default:
+		return nil, fmt.Errorf("key %q is not an array of tables", name)
+	}
+	return table, nil
+}
+
+func parseKeyValue(table map[string]any, line string) error {
+	idx := strings.Index(line, "=")
+	if idx < 0 {
+		return errors.New("expected key = value")
+	}
+
+	parts, err := splitTOMLKey(strings.TrimSpace(line[:idx]))
+	if err != nil {
+		return err
+	}
+
+	value, rest, err := parseTOMLValue(strings.TrimSpace(line[idx+1:]))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return fmt.Errorf("unexpected trailing content %q", rest)
+	}
+
+	parent, err := descend(table, parts[:len(parts)-1])
+	if err != nil {
+		return err
+	}
+
+	name := parts[len(parts)-1]
+	if _, exists := parent[name]; exists {
+		return fmt.Errorf("duplicate key %q", name)
+	}
+	parent[name] = value
+	return nil
+}
+
+func parseTOMLValue(input string) (any, string, error) {
+	if input == "" {
+		return nil, "", errors.New("missing value")
+	}
+
+	switch input[0] {
+	case '"':
+		return parseBasicString(input)
+	case '\'':
+		end := strings.IndexByte(input[1:], '\'')
+		if end < 0 {
+			return nil, "", errors.New("unterminated literal string")
+		}
+		return input[1 : end+1], input[end+2:], nil
+	case '[':
+		return parseTOMLArray(input)
+	case '{':
+		return nil, "", errors.New("inline tables are not supported")
+	}
+
+	end := strings.IndexAny(input, ",]")
+	if end < 0 {
+		end = len(input)
+	}
+	token := strings.TrimSpace(input[:end])
+	rest := input[end:]
+
+	switch token {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+
+	cleaned := strings.ReplaceAll(token, "_", "")
+	if number, err := strconv.ParseInt(cleaned, 10, 64); err == nil {
+		return number, rest, nil
+	}
+	if number, err := strconv.ParseFloat(cleaned, 64); err == nil {
+		return number, rest, nil
+	}
+
+	return nil, "", fmt.Errorf("unsupported value %q", token)
+}
+
+func parseBasicString(input string) (any, string, error) {
+	var builder strings.Builder
+	for i := 1; i < len(input); i++ {
+		c := input[i]
+		switch c {
+		case '"':
+			return builder.String(), input[i+1:], nil
+		case '\\':
+			if i+1 >= len(input) {
+				return nil, "", errors.New("unterminated escape sequence")
+			}
+			i++
+			switch input[i] {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			case 'r':
+				builder.WriteByte('\r')
+			case '"', '\\':
+				builder.WriteByte(input[i])
+			default:
+				return nil, "", fmt.Errorf("unsupported escape sequence \\%c", input[i])
+			}
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return nil, "", errors.New("unterminated string")
+}
+
+func parseTOMLArray(input string) (any, string, error) {
+	values := []any{}
+	rest := strings.TrimSpace(input[1:])
+
+	for {
+		if strings.HasPrefix(rest, "]") {
+			return values, rest[1:], nil
+		}
+
+		value, next, err := parseTOMLValue(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		values = append(values, value)
+
+		rest = strings.TrimSpace(next)
+		switch {
+		case strings.HasPrefix(rest, ","):
+			rest = strings.TrimSpace(rest[1:])
+		case strings.HasPrefix(rest, "]"):
+		default:
+			return nil, "", errors.New("unterminated array")
+		}
+	}
+}
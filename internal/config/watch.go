@@ -0,0 +1,121 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is how often a Watcher polls its files.
+const DefaultWatchInterval = time.Second
+
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func (s fileStamp) equal(other fileStamp) bool {
+	return s.exists == other.exists && s.modTime.Equal(other.modTime) && s.size == other.size
+}
+
+// Watcher polls config files and reports the ones that changed, were
+// created, or were removed since the previous poll. It stats files rather
+// than subscribing to the OS, so it works alike on every platform and on
+// project checkouts mounted from elsewhere.
+type Watcher struct {
+	paths    func() []string
+	onChange func(paths []string)
+
+	mu     sync.Mutex
+	stamps map[string]fileStamp
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewWatcher watches the files paths returns, asked again on every poll so
+// files can be added as they become known. onChange gets the changed files
+// in the order paths returned them.
+func NewWatcher(paths func() []string, onChange func(paths []string)) *Watcher {
+	return &Watcher{
+		paths:    paths,
+		onChange: onChange,
+		stamps:   map[string]fileStamp{},
+		stopped:  make(chan struct{}),
+	}
+}
+
+func (w *Watcher) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w.Poll()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stopped:
+				return
+			case <-ticker.C:
+				w.Poll()
+			}
+		}
+	}()
+}
+
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopped)
+		w.wg.Wait()
+	})
+}
+
+// Poll stats every file once and calls onChange if any changed. A file
+// seen for the first time is only recorded: whoever added it has read it
+// already.
+func (w *Watcher) Poll() []string {
+	w.mu.Lock()
+	var changed []string
+	for _, path := range w.paths() {
+		stamp := fileStamp{}
+		if info, err := os.Stat(path); err == nil {
+			stamp = fileStamp{exists: true, modTime: info.ModTime(), size: info.Size()}
+		}
+		previous, known := w.stamps[path]
+		w.stamps[path] = stamp
+		if known && !previous.equal(stamp) {
+			changed = append(changed, path)
+		}
+	}
+	w.mu.Unlock()
+
+	if len(changed) > 0 && w.onChange != nil {
+		w.onChange(changed)
+	}
+	return changed
+}
+
+// FileVersion identifies the contents of a config file: the first 12 hex
+// digits of their SHA-256, or "" for a file that does not exist.
+func FileVersion(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12], nil
+}
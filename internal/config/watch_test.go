@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcher_ReportsChangedCreatedAndRemovedFiles(t *testing.T) {
+	dir := t.TempDir()
+	settings := filepath.Join(dir, SettingsFileName)
+	project := filepath.Join(dir, ProjectFileName)
+	if err := os.WriteFile(settings, []byte(`{"version":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var reported [][]string
+	watcher := NewWatcher(func() []string { return []string{settings, project} }, func(paths []string) {
+		reported = append(reported, paths)
+	})
+
+	if changed := watcher.Poll(); changed != nil {
+		t.Fatalf("first Poll() = %v, want nothing", changed)
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(settings, []byte(`{"version":1,"theme":"dark"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(settings, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(project, []byte(`channels = []`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed := watcher.Poll(); !reflect.DeepEqual(changed, []string{settings, project}) {
+		t.Fatalf("Poll() after edits = %v, want both files", changed)
+	}
+	if changed := watcher.Poll(); changed != nil {
+		t.Fatalf("Poll() without edits = %v, want nothing", changed)
+	}
+
+	if err := os.Remove(project); err != nil {
+		t.Fatal(err)
+	}
+	if changed := watcher.Poll(); !reflect.DeepEqual(changed, []string{project}) {
+		t.Fatalf("Poll() after removal = %v, want %v", changed, []string{project})
+	}
+	if len(reported) != 2 {
+		t.Fatalf("onChange calls = %d, want 2", len(reported))
+	}
+}
+
+func TestFileVersion(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, SettingsFileName)
+	if version, err := FileVersion(path); err != nil || version != "" {
+		t.Fatalf("FileVersion(missing) = %q, %v, want empty", version, err)
+	}
+
+	if err := os.WriteFile(path, []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	first, err := FileVersion(path)
+	if err != nil || len(first) != 12 {
+		t.Fatalf("FileVersion() = %q, %v, want 12 hex digits", first, err)
+	}
+	if err := os.WriteFile(path, []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := FileVersion(path); second == first {
+		t.Fatalf("FileVersion() = %q after an edit, want a new version", second)
+	}
+}
@@ -0,0 +1,126 @@
+// Package conversation groups dumps from interactive REPL sessions, such as
+// artisan tinker or psysh, into conversations of input and output turns.
+package conversation
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// DefaultGap is the longest pause between two dumps of one REPL process
+// that still continues the same conversation.
+const DefaultGap = 5 * time.Minute
+
+// Turn is one evaluated input and the dumps it produced. Expression is
+// empty when the producer did not echo the input.
+type Turn struct {
+	Expression string       `json:"expression,omitempty"`
+	Events     []dump.Event `json:"events"`
+}
+
+type Conversation struct {
+	// ID is the ID of the conversation's first event.
+	ID          string `json:"id"`
+	ProjectRoot string `json:"projectRoot"`
+	Hostname    string `json:"hostname"`
+	PID         int    `json:"pid"`
+	Command     string `json:"command"`
+	StartedAt   string `json:"startedAt"`
+	EndedAt     string `json:"endedAt"`
+	Turns       []Turn `json:"turns"`
+}
+
+var replCommands = []string{"tinker", "psysh", "boris"}
+
+// IsREPL reports whether an event was dumped from an interactive session:
+// a cli event that echoes its input or comes from tinker, psysh, or php -a.
+func IsREPL(event dump.Event) bool {
+	if event.SourceType != "cli" {
+		return false
+	}
+	if event.Expression != "" {
+		return true
+	}
+	if event.Command == nil {
+		return false
+	}
+
+	words := append([]string{path.Base(event.Command.Name)}, event.Command.Args...)
+	for _, word := range words {
+		if slices.Contains(replCommands, word) {
+			return true
+		}
+	}
+	return path.Base(event.Command.Name) == "php" && slices.Contains(event.Command.Args, "-a")
+}
+
+type process struct {
+	hostname string
+	pid      int
+	project  string
+}
+
+// Group collects REPL events, expected oldest first, into conversations per
+// process. A pause longer than gap starts a new conversation; consecutive
+// dumps of the same expression form one turn. Conversations are returned
+// newest first.
+func Group(events []dump.Event, gap time.Duration) []Conversation {
+	if gap <= 0 {
+		gap = DefaultGap
+	}
+
+	var conversations []*Conversation
+	open := map[process]*Conversation{}
+	last := map[process]time.Time{}
+
+	for _, event := range events {
+		if !IsREPL(event) {
+			continue
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+		if err != nil {
+			continue
+		}
+
+		key := process{hostname: event.Host.Hostname, pid: event.Host.PID, project: event.ProjectRoot}
+		current := open[key]
+		if current == nil || timestamp.Sub(last[key]) > gap {
+			current = &Conversation{
+				ID:          event.ID,
+				ProjectRoot: event.ProjectRoot,
+				Hostname:    event.Host.Hostname,
+				PID:         event.Host.PID,
+				Command:     commandLine(event),
+				StartedAt:   event.Timestamp,
+			}
+			open[key] = current
+			conversations = append(conversations, current)
+		}
+		last[key] = timestamp
+		current.EndedAt = event.Timestamp
+
+		turns := current.Turns
+		if n := len(turns); n > 0 && event.Expression != "" && turns[n-1].Expression == event.Expression {
+			turns[n-1].Events = append(turns[n-1].Events, event)
+			continue
+		}
+		current.Turns = append(turns, Turn{Expression: event.Expression, Events: []dump.Event{event}})
+	}
+
+	grouped := make([]Conversation, len(conversations))
+	for i, conversation := range conversations {
+		grouped[len(conversations)-1-i] = *conversation
+	}
+	return grouped
+}
+
+func commandLine(event dump.Event) string {
+	if event.Command == nil {
+		return ""
+	}
+	return strings.TrimSpace(event.Command.Name + " " + strings.Join(event.Command.Args, " "))
+}
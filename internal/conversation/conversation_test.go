@@ -0,0 +1,65 @@
+package conversation
+
+import (
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func replEvent(id string, pid int, at string, expression string) dump.Event {
+	return dump.Event{
+		ID: id, SourceType: "cli", ProjectRoot: "/app", Timestamp: at, Expression: expression,
+		Command: &dump.CommandMeta{Name: "artisan", Args: []string{"tinker"}},
+		Host:    dump.HostMeta{Hostname: "h", PID: pid},
+	}
+}
+
+func TestIsREPL(t *testing.T) {
+	tests := []struct {
+		name  string
+		event dump.Event
+		want  bool
+	}{
+		{"tinker", dump.Event{SourceType: "cli", Command: &dump.CommandMeta{Name: "artisan", Args: []string{"tinker"}}}, true},
+		{"psysh path", dump.Event{SourceType: "cli", Command: &dump.CommandMeta{Name: "/usr/local/bin/psysh"}}, true},
+		{"php -a", dump.Event{SourceType: "cli", Command: &dump.CommandMeta{Name: "php", Args: []string{"-a"}}}, true},
+		{"echoed input", dump.Event{SourceType: "cli", Expression: "User::count()"}, true},
+		{"queue worker", dump.Event{SourceType: "cli", Command: &dump.CommandMeta{Name: "artisan", Args: []string{"queue:work"}}}, false},
+		{"http", dump.Event{SourceType: "http", Expression: "x"}, false},
+	}
+	for _, test := range tests {
+		if got := IsREPL(test.event); got != test.want {
+			t.Fatalf("IsREPL(%s) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGroup_SplitsByProcessAndPause(t *testing.T) {
+	events := []dump.Event{
+		replEvent("a1", 10, "2026-03-01T10:00:00Z", "$user = User::first()"),
+		replEvent("a2", 10, "2026-03-01T10:00:10Z", "$user->posts"),
+		replEvent("a3", 10, "2026-03-01T10:00:10.5Z", "$user->posts"),
+		replEvent("b1", 11, "2026-03-01T10:00:20Z", ""),
+		{ID: "w1", SourceType: "cli", Timestamp: "2026-03-01T10:00:30Z", Command: &dump.CommandMeta{Name: "artisan", Args: []string{"queue:work"}}, Host: dump.HostMeta{Hostname: "h", PID: 10}},
+		replEvent("a4", 10, "2026-03-01T10:30:00Z", "User::count()"),
+	}
+
+	conversations := Group(events, time.Minute)
+	if len(conversations) != 3 {
+		t.Fatalf("Group() = %d conversations, want 3", len(conversations))
+	}
+	if got := conversations[0]; got.ID != "a4" || len(got.Turns) != 1 {
+		t.Fatalf("Group()[0] = %+v, want the conversation after the pause first", got)
+	}
+	first := conversations[2]
+	if first.ID != "a1" || first.Command != "artisan tinker" || first.EndedAt != "2026-03-01T10:00:10.5Z" {
+		t.Fatalf("Group()[2] = %+v, want a1 to a3 from artisan tinker", first)
+	}
+	if len(first.Turns) != 2 || first.Turns[1].Expression != "$user->posts" || len(first.Turns[1].Events) != 2 {
+		t.Fatalf("Group()[2] turns = %+v, want repeated expression in one turn", first.Turns)
+	}
+	if other := conversations[1]; other.PID != 11 || other.Turns[0].Expression != "" {
+		t.Fatalf("Group()[1] = %+v, want the second process without echoed input", other)
+	}
+}
@@ -0,0 +1,317 @@
+// Package dashboard keeps named dashboards, sets of aggregation, histogram,
+// and watch panels over the buffered events, and evaluates them on demand.
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"phant/internal/jsonpath"
+	"phant/internal/query"
+)
+
+const (
+	FileName = "dashboards.json"
+
+	MaxNameLength = 100
+	MaxPanels     = 24
+
+	DefaultTop        = 10
+	MaxTop            = 100
+	DefaultInterval   = "1m"
+	MinInterval       = time.Second
+	DefaultWatchLimit = 10
+	MaxWatchLimit     = 100
+)
+
+// Panel kinds.
+const (
+	// KindAggregate ranks groups of events, such as the routes with the
+	// most errors or the slowest queries.
+	KindAggregate = "aggregate"
+	// KindHistogram counts events per time bucket.
+	KindHistogram = "histogram"
+	// KindWatch shows the latest values of a JSONPath into the payload.
+	KindWatch = "watch"
+)
+
+// Aggregation groups, the event fields a panel can group by.
+const (
+	GroupSourceType = "sourceType"
+	GroupProject    = "project"
+	GroupRoute      = "route"
+	GroupOrigin     = "origin"
+	GroupLevel      = "level"
+	GroupChannel    = "channel"
+	GroupException  = "exception"
+	GroupHost       = "host"
+	GroupLabel      = "label"
+	GroupCommand    = "command"
+)
+
+// Aggregation metrics. Groups are ranked by count unless a duration metric
+// is chosen, which only considers events with a durationMs.
+const (
+	MetricCount       = "count"
+	MetricAvgDuration = "avgDuration"
+	MetricMaxDuration = "maxDuration"
+)
+
+var (
+	ErrEmptyName   = errors.New("dashboard name must not be empty")
+	ErrUnknownKind = errors.New("panel kind must be one of: aggregate, histogram, watch")
+)
+
+var groups = []string{
+	GroupSourceType, GroupProject, GroupRoute, GroupOrigin, GroupLevel,
+	GroupChannel, GroupException, GroupHost, GroupLabel, GroupCommand,
+}
+
+// Panel is one chart or table of a dashboard. Filter narrows the events
+// the dashboard filter already selected; the remaining fields apply to
+// the kinds named in their comments.
+type Panel struct {
+	Title  string       `json:"title"`
+	Kind   string       `json:"kind"`
+	Filter query.Filter `json:"filter"`
+
+	// Aggregate: the field to group by, the metric to rank by (count by
+	// default), and how many groups to keep.
+	GroupBy string `json:"groupBy,omitempty"`
+	Metric  string `json:"metric,omitempty"`
+	Top     int    `json:"top,omitempty"`
+
+	// Histogram: the bucket width, such as "1m" or "1h".
+	Interval string `json:"interval,omitempty"`
+
+	// Watch: a JSONPath into JSON payloads and how many recent values to
+	// keep.
+	Path  string `json:"path,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+func (p Panel) Validate() error {
+	if _, err := p.Filter.Compile(); err != nil {
+		return err
+	}
+
+	switch p.Kind {
+	case KindAggregate:
+		if !slices.Contains(groups, p.GroupBy) {
+			return fmt.Errorf("groupBy must be one of: %s", strings.Join(groups, ", "))
+		}
+		switch p.Metric {
+		case "", MetricCount, MetricAvgDuration, MetricMaxDuration:
+		default:
+			return errors.New("metric must be one of: count, avgDuration, maxDuration")
+		}
+		if p.Top < 0 || p.Top > MaxTop {
+			return fmt.Errorf("top must be between 0 and %d", MaxTop)
+		}
+	case KindHistogram:
+		if _, err := p.interval(); err != nil {
+			return err
+		}
+	case KindWatch:
+		if _, err := jsonpath.Compile(p.Path); err != nil {
+			return fmt.Errorf("path: %w", err)
+		}
+		if p.Limit < 0 || p.Limit > MaxWatchLimit {
+			return fmt.Errorf("limit must be between 0 and %d", MaxWatchLimit)
+		}
+	default:
+		return ErrUnknownKind
+	}
+	return nil
+}
+
+func (p Panel) interval() (time.Duration, error) {
+	text := p.Interval
+	if text == "" {
+		text = DefaultInterval
+	}
+	interval, err := query.ParseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("interval: %w", err)
+	}
+	if interval < MinInterval {
+		return 0, fmt.Errorf("interval must be at least %s", MinInterval)
+	}
+	return interval, nil
+}
+
+// Dashboard is a named set of panels. Filter applies to every panel, so a
+// per-project dashboard sets its projectRoot there once.
+type Dashboard struct {
+	Name      string       `json:"name"`
+	Filter    query.Filter `json:"filter"`
+	Panels    []Panel      `json:"panels"`
+	UpdatedAt string       `json:"updatedAt"`
+}
+
+func (d Dashboard) Validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return ErrEmptyName
+	}
+	if len(d.Name) > MaxNameLength {
+		return fmt.Errorf("dashboard name must be at most %d characters", MaxNameLength)
+	}
+	if len(d.Panels) > MaxPanels {
+		return fmt.Errorf("dashboard must have at most %d panels", MaxPanels)
+	}
+	if _, err := d.Filter.Compile(); err != nil {
+		return fmt.Errorf("dashboard %s: %w", d.Name, err)
+	}
+	for i, panel := range d.Panels {
+		if err := panel.Validate(); err != nil {
+			return fmt.Errorf("dashboard %s: panel %d: %w", d.Name, i+1, err)
+		}
+	}
+	return nil
+}
+
+// Store holds every dashboard in memory and rewrites its file after each
+// change. Names are unique; saving an existing name replaces it.
+type Store struct {
+	path string
+	now  func() time.Time
+
+	mu     sync.RWMutex
+	byName map[string]Dashboard
+}
+
+// Open loads the dashboards stored in dir, if any.
+func Open(dir string) (*Store, error) {
+	store := &Store{path: filepath.Join(dir, FileName), now: time.Now, byName: map[string]Dashboard{}}
+
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var dashboards []Dashboard
+	if err := json.Unmarshal(data, &dashboards); err != nil {
+		return nil, fmt.Errorf("%s: %w", store.path, err)
+	}
+	for _, dashboard := range dashboards {
+		store.byName[dashboard.Name] = dashboard
+	}
+	return store, nil
+}
+
+func (s *Store) Get(name string) (Dashboard, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	dashboard, ok := s.byName[name]
+	return dashboard, ok
+}
+
+// List returns dashboards sorted by name, case-insensitively.
+func (s *Store) List() []Dashboard {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dashboards := make([]Dashboard, 0, len(s.byName))
+	for _, dashboard := range s.byName {
+		dashboards = append(dashboards, dashboard)
+	}
+	sort.Slice(dashboards, func(i, j int) bool {
+		a, b := strings.ToLower(dashboards[i].Name), strings.ToLower(dashboards[j].Name)
+		if a != b {
+			return a < b
+		}
+		return dashboards[i].Name < dashboards[j].Name
+	})
+	return dashboards
+}
+
+// Merge saves several dashboards at once, replacing those with the same
+// name. Nothing is saved if any dashboard is invalid.
+func (s *Store) Merge(dashboards []Dashboard) ([]Dashboard, error) {
+	updatedAt := s.now().UTC().Format(time.RFC3339Nano)
+	saved := make([]Dashboard, len(dashboards))
+	for i, dashboard := range dashboards {
+		dashboard.Name = strings.TrimSpace(dashboard.Name)
+		if dashboard.Panels == nil {
+			dashboard.Panels = []Panel{}
+		}
+		if err := dashboard.Validate(); err != nil {
+			return nil, err
+		}
+		dashboard.UpdatedAt = updatedAt
+		saved[i] = dashboard
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := make(map[string]Dashboard, len(s.byName))
+	for name, dashboard := range s.byName {
+		previous[name] = dashboard
+	}
+	for _, dashboard := range saved {
+		s.byName[dashboard.Name] = dashboard
+	}
+	if err := s.saveLocked(); err != nil {
+		s.byName = previous
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dashboard, ok := s.byName[name]
+	if !ok {
+		return nil
+	}
+	delete(s.byName, name)
+	if err := s.saveLocked(); err != nil {
+		s.byName[name] = dashboard
+		return err
+	}
+	return nil
+}
+
+func (s *Store) saveLocked() error {
+	dashboards := make([]Dashboard, 0, len(s.byName))
+	for _, dashboard := range s.byName {
+		dashboards = append(dashboards, dashboard)
+	}
+	sort.Slice(dashboards, func(i, j int) bool { return dashboards[i].Name < dashboards[j].Name })
+
+	data, err := json.MarshalIndent(dashboards, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), FileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
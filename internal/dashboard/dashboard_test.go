@@ -0,0 +1,141 @@
+package dashboard
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+func durationMs(ms float64) *float64 {
+	return &ms
+}
+
+func compile(filter query.Filter) (query.Matcher, error) {
+	return filter.Compile()
+}
+
+func TestEvaluate_ComputesEveryPanelKind(t *testing.T) {
+	isError := true
+	events := []dump.Event{
+		{ID: "1", SourceType: "http", ProjectRoot: "/app", Timestamp: "2026-03-01T10:00:05Z", IsError: true, HTTP: &dump.HTTPMeta{Method: "GET", Path: "/users/42"}},
+		{ID: "2", SourceType: "http", ProjectRoot: "/app", Timestamp: "2026-03-01T10:02:10Z", IsError: true, HTTP: &dump.HTTPMeta{Method: "GET", Path: "/users/7"}},
+		{ID: "3", SourceType: "http", ProjectRoot: "/app", Timestamp: "2026-03-01T10:02:20Z", Label: "select users", DurationMs: durationMs(120)},
+		{ID: "4", SourceType: "http", ProjectRoot: "/app", Timestamp: "2026-03-01T10:02:30Z", Label: "select users", DurationMs: durationMs(80),
+			PayloadFormat: dump.PayloadFormatJSON, Payload: []byte(`{"queue":{"size":3}}`)},
+		{ID: "5", SourceType: "http", ProjectRoot: "/app", Timestamp: "2026-03-01T10:02:40Z", Label: "insert log", DurationMs: durationMs(300),
+			PayloadFormat: dump.PayloadFormatJSON, Payload: []byte(`{"queue":{"size":5}}`)},
+		{ID: "6", SourceType: "http", ProjectRoot: "/other", Timestamp: "2026-03-01T10:03:00Z", IsError: true, HTTP: &dump.HTTPMeta{Method: "GET", Path: "/users/1"}},
+	}
+	dashboard := Dashboard{
+		Name:   "app",
+		Filter: query.Filter{ProjectRoot: "/app"},
+		Panels: []Panel{
+			{Title: "Errors over time", Kind: KindHistogram, Filter: query.Filter{IsError: &isError}},
+			{Title: "Top error routes", Kind: KindAggregate, Filter: query.Filter{IsError: &isError}, GroupBy: GroupRoute},
+			{Title: "Slowest queries", Kind: KindAggregate, GroupBy: GroupLabel, Metric: MetricMaxDuration},
+			{Title: "Queue size", Kind: KindWatch, Path: "$.queue.size", Limit: 1},
+		},
+	}
+
+	result, err := Evaluate(dashboard, events, compile, time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if len(result.Panels) != 4 {
+		t.Fatalf("Evaluate() panels = %d, want 4", len(result.Panels))
+	}
+
+	overTime := result.Panels[0]
+	wantBuckets := []Bucket{{"2026-03-01T10:00:00Z", 1}, {"2026-03-01T10:01:00Z", 0}, {"2026-03-01T10:02:00Z", 1}}
+	if overTime.Matched != 2 || !slices.Equal(overTime.Buckets, wantBuckets) {
+		t.Fatalf("histogram = %d matched, %v, want 2 matched, %v", overTime.Matched, overTime.Buckets, wantBuckets)
+	}
+
+	routes := result.Panels[1].Groups
+	if len(routes) != 1 || routes[0].Key != "GET /users/{id}" || routes[0].Count != 2 {
+		t.Fatalf("routes = %+v, want GET /users/{id} twice", routes)
+	}
+
+	slowest := result.Panels[2].Groups
+	if len(slowest) != 2 || slowest[0].Key != "insert log" || *slowest[0].DurationMs != 300 || slowest[1].Key != "select users" || *slowest[1].DurationMs != 120 {
+		t.Fatalf("slowest = %+v, want insert log 300 then select users 120", slowest)
+	}
+
+	values := result.Panels[3].Values
+	if len(values) != 1 || values[0].EventID != "5" || string(values[0].Value) != "5" {
+		t.Fatalf("watch = %+v, want the latest size 5 from event 5", values)
+	}
+}
+
+func TestHistogram_CapsBuckets(t *testing.T) {
+	events := []dump.Event{{Timestamp: "2026-03-01T00:00:00Z"}, {Timestamp: "2026-03-05T00:00:00Z"}}
+	buckets := histogram(events, time.Minute, dump.TimeSent)
+	if len(buckets) != MaxBuckets || buckets[0].Start != "2026-03-04T00:01:00Z" {
+		t.Fatalf("histogram() = %d buckets from %v, want the last %d", len(buckets), buckets[0], MaxBuckets)
+	}
+}
+
+func TestDashboard_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		panel Panel
+		want  bool
+	}{
+		{"aggregate", Panel{Kind: KindAggregate, GroupBy: GroupRoute}, true},
+		{"unknown group", Panel{Kind: KindAggregate, GroupBy: "color"}, false},
+		{"unknown metric", Panel{Kind: KindAggregate, GroupBy: GroupLabel, Metric: "p99"}, false},
+		{"histogram default interval", Panel{Kind: KindHistogram}, true},
+		{"histogram short interval", Panel{Kind: KindHistogram, Interval: "10ms"}, false},
+		{"watch", Panel{Kind: KindWatch, Path: "$.a"}, true},
+		{"watch bad path", Panel{Kind: KindWatch, Path: "a["}, false},
+		{"bad filter", Panel{Kind: KindHistogram, Filter: query.Filter{Since: "soon"}}, false},
+		{"unknown kind", Panel{Kind: "pie"}, false},
+	}
+	for _, tt := range tests {
+		err := Dashboard{Name: "d", Panels: []Panel{tt.panel}}.Validate()
+		if (err == nil) != tt.want {
+			t.Fatalf("Validate(%s) error = %v, want valid %v", tt.name, err, tt.want)
+		}
+	}
+
+	if err := (Dashboard{Name: " "}).Validate(); !errors.Is(err, ErrEmptyName) {
+		t.Fatalf("Validate(blank name) error = %v, want %v", err, ErrEmptyName)
+	}
+}
+
+func TestStore_PersistsDashboards(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := store.Merge([]Dashboard{{Name: " api ", Panels: []Panel{{Kind: KindHistogram}}}, {Name: "Bad", Panels: []Panel{{Kind: "pie"}}}}); err == nil {
+		t.Fatalf("Merge() with an invalid dashboard succeeded")
+	}
+	if len(store.List()) != 0 {
+		t.Fatalf("List() after failed Merge() = %v, want none", store.List())
+	}
+	if _, err := store.Merge([]Dashboard{{Name: " api ", Panels: []Panel{{Kind: KindHistogram}}}, {Name: "App"}}); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	list := reopened.List()
+	if len(list) != 2 || list[0].Name != "api" || list[1].Name != "App" || len(list[0].Panels) != 1 || list[1].Panels == nil {
+		t.Fatalf("List() = %+v, want api then App", list)
+	}
+
+	if err := reopened.Delete("api"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := reopened.Get("api"); ok {
+		t.Fatalf("Get(api) after Delete() found it")
+	}
+}
@@ -0,0 +1,297 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/jsonpath"
+	"phant/internal/query"
+)
+
+// MaxBuckets caps a histogram series; longer spans keep their most recent
+// buckets.
+const MaxBuckets = 1440
+
+// Result is a dashboard evaluated against a set of events.
+type Result struct {
+	Name        string        `json:"name"`
+	EvaluatedAt string        `json:"evaluatedAt"`
+	Panels      []PanelResult `json:"panels"`
+}
+
+// PanelResult holds Groups, Buckets, or Values depending on Kind. Matched
+// counts the events the panel's filters selected.
+type PanelResult struct {
+	Title   string       `json:"title"`
+	Kind    string       `json:"kind"`
+	Matched int          `json:"matched"`
+	Groups  []Group      `json:"groups,omitempty"`
+	Buckets []Bucket     `json:"buckets,omitempty"`
+	Values  []WatchValue `json:"values,omitempty"`
+}
+
+// Group is one row of an aggregation. DurationMs is the group's average or
+// maximum duration for duration metrics.
+type Group struct {
+	Key        string   `json:"key"`
+	Count      int      `json:"count"`
+	DurationMs *float64 `json:"durationMs,omitempty"`
+}
+
+type Bucket struct {
+	Start string `json:"start"`
+	Count int    `json:"count"`
+}
+
+// WatchValue is the value a watch path selected in one event.
+type WatchValue struct {
+	EventID   string          `json:"eventId"`
+	Timestamp string          `json:"timestamp"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// Compiler turns a filter into a matcher. Callers attach annotations here
+// so tag and pinned filters work in panels.
+type Compiler func(query.Filter) (query.Matcher, error)
+
+// Evaluate computes every panel of a dashboard over events.
+func Evaluate(dashboard Dashboard, events []dump.Event, compile Compiler, now time.Time) (Result, error) {
+	base, err := compile(dashboard.Filter)
+	if err != nil {
+		return Result{}, fmt.Errorf("dashboard %s: %w", dashboard.Name, err)
+	}
+	var selected []dump.Event
+	for _, event := range events {
+		if base.Match(event) {
+			selected = append(selected, event)
+		}
+	}
+
+	result := Result{
+		Name:        dashboard.Name,
+		EvaluatedAt: now.UTC().Format(time.RFC3339),
+		Panels:      make([]PanelResult, 0, len(dashboard.Panels)),
+	}
+	for i, panel := range dashboard.Panels {
+		panelResult, err := evaluatePanel(panel, dashboard.Filter.TimeBasis, selected, compile)
+		if err != nil {
+			return Result{}, fmt.Errorf("dashboard %s: panel %d: %w", dashboard.Name, i+1, err)
+		}
+		result.Panels = append(result.Panels, panelResult)
+	}
+	return result, nil
+}
+
+func evaluatePanel(panel Panel, basis string, events []dump.Event, compile Compiler) (PanelResult, error) {
+	matcher, err := compile(panel.Filter)
+	if err != nil {
+		return PanelResult{}, err
+	}
+	var matches []dump.Event
+	for _, event := range events {
+		if matcher.Match(event) {
+			matches = append(matches, event)
+		}
+	}
+	if panel.Filter.TimeBasis != "" {
+		basis = panel.Filter.TimeBasis
+	}
+
+	result := PanelResult{Title: panel.Title, Kind: panel.Kind, Matched: len(matches)}
+	switch panel.Kind {
+	case KindAggregate:
+		result.Groups = aggregate(panel, matches)
+	case KindHistogram:
+		interval, err := panel.interval()
+		if err != nil {
+			return PanelResult{}, err
+		}
+		result.Buckets = histogram(matches, interval, basis)
+	case KindWatch:
+		path, err := jsonpath.Compile(panel.Path)
+		if err != nil {
+			return PanelResult{}, fmt.Errorf("path: %w", err)
+		}
+		limit := panel.Limit
+		if limit <= 0 {
+			limit = DefaultWatchLimit
+		}
+		result.Values = watch(path, matches, basis, limit)
+	default:
+		return PanelResult{}, ErrUnknownKind
+	}
+	return result, nil
+}
+
+// aggregate ranks groups by count, or by duration for duration metrics,
+// highest first with ties by key. Events without the grouped field are
+// left out.
+func aggregate(panel Panel, events []dump.Event) []Group {
+	type tally struct {
+		count     int
+		timed     int
+		totalMs   float64
+		slowestMs float64
+	}
+	byDuration := panel.Metric == MetricAvgDuration || panel.Metric == MetricMaxDuration
+
+	tallies := map[string]*tally{}
+	for _, event := range events {
+		key := groupKey(panel.GroupBy, event)
+		if key == "" || byDuration && event.DurationMs == nil {
+			continue
+		}
+		t := tallies[key]
+		if t == nil {
+			t = &tally{}
+			tallies[key] = t
+		}
+		t.count++
+		if event.DurationMs != nil {
+			t.timed++
+			t.totalMs += *event.DurationMs
+			t.slowestMs = max(t.slowestMs, *event.DurationMs)
+		}
+	}
+
+	groups := make([]Group, 0, len(tallies))
+	for key, t := range tallies {
+		group := Group{Key: key, Count: t.count}
+		switch panel.Metric {
+		case MetricAvgDuration:
+			average := t.totalMs / float64(t.timed)
+			group.DurationMs = &average
+		case MetricMaxDuration:
+			slowest := t.slowestMs
+			group.DurationMs = &slowest
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if byDuration && *a.DurationMs != *b.DurationMs {
+			return *a.DurationMs > *b.DurationMs
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Key < b.Key
+	})
+
+	top := panel.Top
+	if top <= 0 {
+		top = DefaultTop
+	}
+	if len(groups) > top {
+		groups = groups[:top]
+	}
+	return groups
+}
+
+func groupKey(groupBy string, event dump.Event) string {
+	switch groupBy {
+	case GroupSourceType:
+		return event.SourceType
+	case GroupProject:
+		return event.ProjectRoot
+	case GroupRoute:
+		if event.HTTP != nil {
+			return query.Route(event.HTTP.Method, event.HTTP.Path)
+		}
+	case GroupOrigin:
+		return query.Origin(event)
+	case GroupLevel:
+		return event.Level
+	case GroupChannel:
+		if event.Log != nil {
+			return event.Log.Channel
+		}
+	case GroupException:
+		if event.Exception != nil {
+			return event.Exception.Class
+		}
+	case GroupHost:
+		return event.Host.Hostname
+	case GroupLabel:
+		return event.Label
+	case GroupCommand:
+		if event.Command != nil {
+			return event.Command.Name
+		}
+	}
+	return ""
+}
+
+// histogram counts events per interval, aligned to UTC, from the first
+// event's bucket to the last one's; empty buckets in between count zero.
+func histogram(events []dump.Event, interval time.Duration, basis string) []Bucket {
+	counts := map[time.Time]int{}
+	var first, last time.Time
+	for _, event := range events {
+		timestamp, err := time.Parse(time.RFC3339Nano, event.Time(basis))
+		if err != nil {
+			continue
+		}
+		start := timestamp.UTC().Truncate(interval)
+		counts[start]++
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+
+	buckets := []Bucket{}
+	if first.IsZero() {
+		return buckets
+	}
+	if span := int(last.Sub(first) / interval); span >= MaxBuckets {
+		first = last.Add(-(MaxBuckets - 1) * interval)
+	}
+	for start := first; !start.After(last); start = start.Add(interval) {
+		buckets = append(buckets, Bucket{Start: start.Format(time.RFC3339), Count: counts[start]})
+	}
+	return buckets
+}
+
+// watch returns the path's value in the most recent JSON payloads it
+// selects anything from, newest first. Paths that are not definite yield
+// an array of their matches.
+func watch(path jsonpath.Path, events []dump.Event, basis string, limit int) []WatchValue {
+	events = slices.Clone(events)
+	dump.SortEventsBy(events, basis)
+
+	values := []WatchValue{}
+	for i := len(events) - 1; i >= 0 && len(values) < limit; i-- {
+		event := events[i]
+		if event.PayloadFormat != dump.PayloadFormatJSON {
+			continue
+		}
+		matches, err := path.Evaluate(event.Payload)
+		if err != nil || len(matches) == 0 {
+			continue
+		}
+
+		value := matches[0]
+		if !path.Definite() {
+			var array bytes.Buffer
+			array.WriteByte('[')
+			for j, match := range matches {
+				if j > 0 {
+					array.WriteByte(',')
+				}
+				array.Write(match)
+			}
+			array.WriteByte(']')
+			value = array.Bytes()
+		}
+		values = append(values, WatchValue{EventID: event.ID, Timestamp: event.Time(basis), Value: value})
+	}
+	return values
+}
@@ -0,0 +1,348 @@
+// Package ddgate keeps PHP processes parked at a dd() call until the
+// developer releases them. A producer opts in by sending a dd event with
+// gate set and then waiting on its connection for a release line.
+package ddgate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	// ActionExit ends the held process as dd() would; ActionContinue lets
+	// it run on past the call.
+	ActionExit     = "exit"
+	ActionContinue = "continue"
+
+	// DefaultMaxHeld bounds how many producer connections are kept open.
+	DefaultMaxHeld = 32
+)
+
+// Reasons a gate was released.
+const (
+	ReasonManual  = "manual"
+	ReasonTimeout = "timeout"
+	ReasonLimit   = "limit"
+	// ReasonDisabled releases producers at once when the collector does
+	// not hold gates.
+	ReasonDisabled = "disabled"
+)
+
+var (
+	ErrTooManyHeld   = errors.New("too many processes are held at dd gates")
+	ErrUnknownAction = errors.New("gate action must be exit or continue")
+)
+
+// Release is the line written back to a held producer.
+type Release struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// Policy auto-releases gates after TimeoutMs; zero holds them until they
+// are released by hand.
+type Policy struct {
+	TimeoutMs int    `json:"timeoutMs"`
+	Action    string `json:"action"`
+}
+
+type Settings struct {
+	MaxHeld  int               `json:"maxHeld"`
+	Default  Policy            `json:"default"`
+	Projects map[string]Policy `json:"projects,omitempty"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{MaxHeld: DefaultMaxHeld, Default: Policy{Action: ActionExit}}
+}
+
+func (p Policy) Validate() error {
+	if p.TimeoutMs < 0 {
+		return errors.New("gate timeout must not be negative")
+	}
+	return validAction(p.Action)
+}
+
+func (s Settings) Validate() error {
+	if s.MaxHeld < 0 {
+		return errors.New("gate limit must not be negative")
+	}
+	if err := s.Default.Validate(); err != nil {
+		return err
+	}
+	for root, policy := range s.Projects {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", root, err)
+		}
+	}
+	return nil
+}
+
+func validAction(action string) error {
+	switch action {
+	case "", ActionExit, ActionContinue:
+		return nil
+	}
+	return ErrUnknownAction
+}
+
+// Gate is a process waiting at dd(). ReleaseAt is when its policy releases
+// it, empty when it waits indefinitely.
+type Gate struct {
+	ID          string           `json:"id"`
+	ProjectRoot string           `json:"projectRoot"`
+	SourceType  string           `json:"sourceType"`
+	Hostname    string           `json:"hostname"`
+	PID         int              `json:"pid"`
+	RequestID   string           `json:"requestId,omitempty"`
+	Label       string           `json:"label,omitempty"`
+	Origin      *dump.TraceFrame `json:"origin,omitempty"`
+	HeldAt      string           `json:"heldAt"`
+	ReleaseAt   string           `json:"releaseAt,omitempty"`
+}
+
+type held struct {
+	gate   Gate
+	heldAt time.Time
+	send   func(Release) error
+	timer  *time.Timer
+	// action is what the policy does when the timer fires.
+	action string
+}
+
+// Queue tracks held gates. Changes are reported to onChange, outside the
+// queue's lock, with the pending gates oldest first.
+type Queue struct {
+	onChange func([]Gate)
+	now      func() time.Time
+
+	mu       sync.Mutex
+	settings Settings
+	pending  map[string]*held
+}
+
+func NewQueue(settings Settings, onChange func([]Gate)) *Queue {
+	if settings.MaxHeld <= 0 {
+		settings.MaxHeld = DefaultMaxHeld
+	}
+	return &Queue{
+		onChange: onChange,
+		now:      time.Now,
+		settings: settings,
+		pending:  make(map[string]*held),
+	}
+}
+
+// Settings returns the current limit and policies.
+func (q *Queue) Settings() Settings {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.settings
+}
+
+// SetSettings changes the limit and policies for gates held from now on;
+// gates already waiting keep their deadline.
+func (q *Queue) SetSettings(settings Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if settings.MaxHeld == 0 {
+		settings.MaxHeld = DefaultMaxHeld
+	}
+
+	q.mu.Lock()
+	q.settings = settings
+	q.mu.Unlock()
+	return nil
+}
+
+// Hold parks the producer of event until its gate is released through
+// send. When the queue is full the producer is released at once with its
+// policy's action and ErrTooManyHeld is returned.
+func (q *Queue) Hold(event dump.Event, send func(Release) error) (Gate, error) {
+	q.mu.Lock()
+	policy := q.policyLocked(event.ProjectRoot)
+
+	if len(q.pending) >= q.settings.MaxHeld {
+		q.mu.Unlock()
+		send(Release{Type: "release", ID: event.ID, Action: policy.Action, Reason: ReasonLimit})
+		return Gate{}, ErrTooManyHeld
+	}
+	if existing, ok := q.pending[event.ID]; ok {
+		q.mu.Unlock()
+		return existing.gate, nil
+	}
+
+	heldAt := q.now()
+	entry := &held{
+		gate: Gate{
+			ID:          event.ID,
+			ProjectRoot: event.ProjectRoot,
+			SourceType:  event.SourceType,
+			Hostname:    event.Host.Hostname,
+			PID:         event.Host.PID,
+			Label:       event.Label,
+			Origin:      event.Origin,
+			HeldAt:      heldAt.UTC().Format(time.RFC3339Nano),
+		},
+		heldAt: heldAt,
+		send:   send,
+		action: policy.Action,
+	}
+	if event.RequestID != nil {
+		entry.gate.RequestID = *event.RequestID
+	}
+	if policy.TimeoutMs > 0 {
+		timeout := time.Duration(policy.TimeoutMs) * time.Millisecond
+		entry.gate.ReleaseAt = heldAt.Add(timeout).UTC().Format(time.RFC3339Nano)
+		entry.timer = time.AfterFunc(timeout, func() { q.expire(event.ID) })
+	}
+	q.pending[event.ID] = entry
+	pending := q.pendingLocked()
+	q.mu.Unlock()
+
+	q.changed(pending)
+	return entry.gate, nil
+}
+
+// Release lets the given gates go with action, or with each gate's policy
+// action when it is empty. Unknown IDs are skipped; the number released is
+// returned.
+func (q *Queue) Release(ids []string, action string) (int, error) {
+	if err := validAction(action); err != nil {
+		return 0, err
+	}
+
+	q.mu.Lock()
+	var released []*held
+	for _, id := range ids {
+		if entry, ok := q.pending[id]; ok {
+			delete(q.pending, id)
+			released = append(released, entry)
+		}
+	}
+	return q.finish(released, action, ReasonManual), nil
+}
+
+// ReleaseProject releases every gate of a project, or every gate when root
+// is empty.
+func (q *Queue) ReleaseProject(root string, action string) (int, error) {
+	if err := validAction(action); err != nil {
+		return 0, err
+	}
+
+	q.mu.Lock()
+	var released []*held
+	for id, entry := range q.pending {
+		if root == "" || entry.gate.ProjectRoot == root {
+			delete(q.pending, id)
+			released = append(released, entry)
+		}
+	}
+	return q.finish(released, action, ReasonManual), nil
+}
+
+// Forget drops gates whose producer hung up without being released.
+func (q *Queue) Forget(ids ...string) {
+	q.mu.Lock()
+	changed := false
+	for _, id := range ids {
+		if entry, ok := q.pending[id]; ok {
+			entry.stop()
+			delete(q.pending, id)
+			changed = true
+		}
+	}
+	pending := q.pendingLocked()
+	q.mu.Unlock()
+
+	if changed {
+		q.changed(pending)
+	}
+}
+
+func (q *Queue) Pending() []Gate {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pendingLocked()
+}
+
+func (q *Queue) expire(id string) {
+	q.mu.Lock()
+	entry, ok := q.pending[id]
+	if !ok {
+		q.mu.Unlock()
+		return
+	}
+	delete(q.pending, id)
+	q.finish([]*held{entry}, "", ReasonTimeout)
+}
+
+// finish is called with q.mu held and unlocks it before writing to the
+// released producers.
+func (q *Queue) finish(released []*held, action string, reason string) int {
+	pending := q.pendingLocked()
+	q.mu.Unlock()
+
+	for _, entry := range released {
+		entry.stop()
+		release := Release{Type: "release", ID: entry.gate.ID, Action: action, Reason: reason}
+		if release.Action == "" {
+			release.Action = entry.action
+		}
+		entry.send(release)
+	}
+	if len(released) > 0 {
+		q.changed(pending)
+	}
+	return len(released)
+}
+
+func (q *Queue) policyLocked(root string) Policy {
+	policy, ok := q.settings.Projects[root]
+	if !ok {
+		policy = q.settings.Default
+	}
+	if policy.Action == "" {
+		policy.Action = ActionExit
+	}
+	return policy
+}
+
+func (q *Queue) pendingLocked() []Gate {
+	entries := make([]*held, 0, len(q.pending))
+	for _, entry := range q.pending {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].heldAt.Equal(entries[j].heldAt) {
+			return entries[i].heldAt.Before(entries[j].heldAt)
+		}
+		return entries[i].gate.ID < entries[j].gate.ID
+	})
+
+	gates := make([]Gate, len(entries))
+	for i, entry := range entries {
+		gates[i] = entry.gate
+	}
+	return gates
+}
+
+func (q *Queue) changed(pending []Gate) {
+	if q.onChange != nil {
+		q.onChange(pending)
+	}
+}
+
+func (h *held) stop() {
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+}
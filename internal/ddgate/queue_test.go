@@ -0,0 +1,106 @@
+package ddgate
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"phant/internal/dump"
+	"phant/internal/testsupport"
+)
+
+type recorder struct {
+	mu       sync.Mutex
+	releases []Release
+}
+
+func (r *recorder) send(release Release) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.releases = append(r.releases, release)
+	return nil
+}
+
+func (r *recorder) all() []Release {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Release(nil), r.releases...)
+}
+
+func gateEvent(id string, root string) dump.Event {
+	return dump.Event{ID: id, ProjectRoot: root, SourceType: "http", IsDD: true, Host: dump.HostMeta{Hostname: "h", PID: 7}}
+}
+
+func TestQueue_HoldsAndReleasesInBatches(t *testing.T) {
+	var changes int
+	queue := NewQueue(DefaultSettings(), func([]Gate) { changes++ })
+	var sent recorder
+
+	for _, event := range []dump.Event{gateEvent("a", "/app"), gateEvent("b", "/app"), gateEvent("c", "/api")} {
+		if _, err := queue.Hold(event, sent.send); err != nil {
+			t.Fatalf("Hold(%s) error = %v", event.ID, err)
+		}
+	}
+	if pending := queue.Pending(); len(pending) != 3 || pending[0].ID != "a" || pending[0].PID != 7 {
+		t.Fatalf("Pending() = %+v, want three gates oldest first", pending)
+	}
+
+	if released, err := queue.ReleaseProject("/app", ActionContinue); err != nil || released != 2 {
+		t.Fatalf("ReleaseProject(/app) = %d, %v, want 2", released, err)
+	}
+	if released, err := queue.Release([]string{"c", "missing"}, ""); err != nil || released != 1 {
+		t.Fatalf("Release(c, missing) = %d, %v, want 1", released, err)
+	}
+	if _, err := queue.Release([]string{"c"}, "pause"); !errors.Is(err, ErrUnknownAction) {
+		t.Fatalf("Release(pause) error = %v, want ErrUnknownAction", err)
+	}
+
+	releases := sent.all()
+	if len(releases) != 3 || releases[0].Action != ActionContinue || releases[2] != (Release{Type: "release", ID: "c", Action: ActionExit, Reason: ReasonManual}) {
+		t.Fatalf("releases = %+v, want two continues then c exiting by policy", releases)
+	}
+	if len(queue.Pending()) != 0 || changes != 5 {
+		t.Fatalf("Pending() = %v after %d changes, want empty after 5", queue.Pending(), changes)
+	}
+}
+
+func TestQueue_ProtectsConnectionLimit(t *testing.T) {
+	queue := NewQueue(Settings{MaxHeld: 1}, nil)
+	var sent recorder
+
+	queue.Hold(gateEvent("a", "/app"), sent.send)
+	if _, err := queue.Hold(gateEvent("b", "/app"), sent.send); !errors.Is(err, ErrTooManyHeld) {
+		t.Fatalf("Hold(over limit) error = %v, want ErrTooManyHeld", err)
+	}
+	if releases := sent.all(); len(releases) != 1 || releases[0].ID != "b" || releases[0].Reason != ReasonLimit {
+		t.Fatalf("releases = %+v, want b released at once", releases)
+	}
+
+	queue.Forget("a")
+	if _, err := queue.Hold(gateEvent("b", "/app"), sent.send); err != nil {
+		t.Fatalf("Hold(after forget) error = %v", err)
+	}
+}
+
+func TestQueue_AutoReleasesByProjectPolicy(t *testing.T) {
+	queue := NewQueue(DefaultSettings(), nil)
+	if err := queue.SetSettings(Settings{Projects: map[string]Policy{"/app": {TimeoutMs: 10, Action: ActionContinue}}}); err != nil {
+		t.Fatalf("SetSettings() error = %v", err)
+	}
+	var sent recorder
+
+	gate, _ := queue.Hold(gateEvent("a", "/app"), sent.send)
+	queue.Hold(gateEvent("b", "/api"), sent.send)
+	if gate.ReleaseAt == "" {
+		t.Fatalf("Hold() gate = %+v, want a release deadline", gate)
+	}
+
+	testsupport.Eventually(t, "timeout release", func() bool { return len(sent.all()) > 0 })
+	releases := sent.all()
+	if len(releases) != 1 || releases[0] != (Release{Type: "release", ID: "a", Action: ActionContinue, Reason: ReasonTimeout}) {
+		t.Fatalf("releases = %+v, want a continued on timeout", releases)
+	}
+	if pending := queue.Pending(); len(pending) != 1 || pending[0].ID != "b" {
+		t.Fatalf("Pending() = %+v, want b still held", pending)
+	}
+}
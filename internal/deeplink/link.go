@@ -0,0 +1,151 @@
+package deeplink
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const Scheme = "phant"
+
+const (
+	KindEvent   = "event"
+	KindRequest = "request"
+	KindFilter  = "filter"
+)
+
+var ErrInvalidLink = errors.New("invalid phant link")
+
+// Link points at something another phant instance attached to the same
+// collector can open: an event, a request timeline, or a filtered view.
+type Link struct {
+	Kind   string            `json:"kind"`
+	Target string            `json:"target"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+func ForEvent(id string) Link {
+	return Link{Kind: KindEvent, Target: id}
+}
+
+func ForRequest(requestID string) Link {
+	return Link{Kind: KindRequest, Target: requestID}
+}
+
+func ForFilter(params map[string]string) Link {
+	return Link{Kind: KindFilter, Params: params}
+}
+
+func (l Link) Validate() error {
+	switch l.Kind {
+	case KindEvent, KindRequest:
+		if l.Target == "" {
+			return fmt.Errorf("%w: %s link requires a target", ErrInvalidLink, l.Kind)
+		}
+	case KindFilter:
+		if l.Target != "" {
+			return fmt.Errorf("%w: filter link does not take a target", ErrInvalidLink)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidLink, l.Kind)
+	}
+	return nil
+}
+
+// URL renders the link as a phant:// URL for OS-level protocol handling.
+func (l Link) URL() string {
+	u := url.URL{
+		Scheme:   Scheme,
+		Host:     l.Kind,
+		RawQuery: l.encodeParams(),
+	}
+	if l.Target != "" {
+		u.Path = "/" + l.Target
+	}
+	return u.String()
+}
+
+// HTTPURL renders the link against the base URL of a local phant API.
+func (l Link) HTTPURL(baseURL string) (string, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return "", fmt.Errorf("%w: base URL must be http or https", ErrInvalidLink)
+	}
+
+	prefix := strings.TrimSuffix(base.Path, "/") + "/open/" + l.Kind
+	base.Path = prefix
+	base.RawPath = ""
+	if l.Target != "" {
+		base.Path = prefix + "/" + l.Target
+		base.RawPath = prefix + "/" + url.PathEscape(l.Target)
+	}
+	base.RawQuery = l.encodeParams()
+	base.Fragment = ""
+
+	return base.String(), nil
+}
+
+// Parse accepts either a phant:// URL or an HTTP URL produced by HTTPURL.
+func Parse(raw string) (Link, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return Link{}, fmt.Errorf("%w: %v", ErrInvalidLink, err)
+	}
+
+	var link Link
+	switch u.Scheme {
+	case Scheme:
+		link.Kind = u.Host
+		link.Target = strings.Trim(u.Path, "/")
+	case "http", "https":
+		segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+		idx := -1
+		for i, segment := range segments {
+			if segment == "open" {
+				idx = i
+			}
+		}
+		if idx < 0 || idx+1 >= len(segments) {
+			return Link{}, fmt.Errorf("%w: missing /open/<kind> path", ErrInvalidLink)
+		}
+		link.Kind = segments[idx+1]
+		if idx+2 < len(segments) {
+			target, unescapeErr := url.PathUnescape(strings.Join(segments[idx+2:], "/"))
+			if unescapeErr != nil {
+				return Link{}, fmt.Errorf("%w: %v", ErrInvalidLink, unescapeErr)
+			}
+			link.Target = target
+		}
+	default:
+		return Link{}, fmt.Errorf("%w: unsupported scheme %q", ErrInvalidLink, u.Scheme)
+	}
+
+	query := u.Query()
+	if len(query) > 0 {
+		link.Params = make(map[string]string, len(query))
+		for key := range query {
+			link.Params[key] = query.Get(key)
+		}
+	}
+
+	if err := link.Validate(); err != nil {
+		return Link{}, err
+	}
+	return link, nil
+}
+
+func (l Link) encodeParams() string {
+	if len(l.Params) == 0 {
+		return ""
+	}
+
+	values := url.Values{}
+	for key, value := range l.Params {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
@@ -0,0 +1,68 @@
+package deeplink
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLink_URLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		link Link
+		want string
+	}{
+		{name: "event", link: ForEvent("01JNFKEC8Q4Y8S97R2M5W12Q9H"), want: "phant://event/01JNFKEC8Q4Y8S97R2M5W12Q9H"},
+		{name: "request", link: ForRequest("f2a1a3d2"), want: "phant://request/f2a1a3d2"},
+		{name: "filter", link: ForFilter(map[string]string{"sourceType": "worker", "isDd": "true"}), want: "phant://filter?isDd=true&sourceType=worker"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.link.URL()
+			if got != test.want {
+				t.Fatalf("link.URL() = %q, want %q", got, test.want)
+			}
+
+			parsed, err := Parse(got)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", got, err)
+			}
+			if parsed.URL() != got {
+				t.Fatalf("Parse(%q).URL() = %q, want round trip", got, parsed.URL())
+			}
+		})
+	}
+}
+
+func TestLink_HTTPURLRoundTrip(t *testing.T) {
+	link := ForEvent("evt/1")
+
+	got, err := link.HTTPURL("http://127.0.0.1:23519/")
+	if err != nil {
+		t.Fatalf("link.HTTPURL() error = %v", err)
+	}
+	if want := "http://127.0.0.1:23519/open/event/evt%2F1"; got != want {
+		t.Fatalf("link.HTTPURL() = %q, want %q", got, want)
+	}
+
+	parsed, err := Parse(got)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", got, err)
+	}
+	if parsed.Kind != KindEvent || parsed.Target != "evt/1" {
+		t.Fatalf("Parse(%q) = %+v, want event evt/1", got, parsed)
+	}
+}
+
+func TestParse_RejectsInvalidLinks(t *testing.T) {
+	for _, raw := range []string{
+		"https://example.test/",
+		"phant://event",
+		"phant://unknown/1",
+		"ftp://event/1",
+	} {
+		if _, err := Parse(raw); !errors.Is(err, ErrInvalidLink) {
+			t.Fatalf("Parse(%q) error = %v, want ErrInvalidLink", raw, err)
+		}
+	}
+}
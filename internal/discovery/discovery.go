@@ -0,0 +1,307 @@
+// Package discovery advertises the network ingestion listeners over
+// multicast DNS (DNS-SD type _phant._tcp), so PHP clients on the same
+// network can find a running phant instead of hardcoding its address.
+package discovery
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	ServiceType = "_phant._tcp"
+	domain      = "local."
+
+	// recordTTL is how long, in seconds, resolvers may cache answers; RFC
+	// 6762 suggests 120 for records naming a host.
+	recordTTL = 120
+
+	maxPacketBytes = 9000
+	// cacheFlush marks records this responder alone owns.
+	cacheFlush = 1 << 15
+	// unicastResponse is the question bit asking for a direct reply.
+	unicastResponse = 1 << 15
+)
+
+var (
+	groupAddr    = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+	servicesName = dnsmessage.MustNewName("_services._dns-sd._udp." + domain)
+	typeName     = dnsmessage.MustNewName(ServiceType + "." + domain)
+)
+
+// Service is one advertised endpoint. TXT entries are published as
+// key=value strings.
+type Service struct {
+	Instance string            `json:"instance"`
+	Port     int               `json:"port"`
+	TXT      map[string]string `json:"txt"`
+}
+
+// Advertiser answers mDNS queries for its services over IPv4 and announces
+// them on start. It must not be restarted once stopped.
+type Advertiser struct {
+	host     dnsmessage.Name
+	services []Service
+	addrs    func() []net.IP
+
+	conn     *net.UDPConn
+	stopOnce sync.Once
+	stopped  chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New prepares an advertiser for services on this machine, published under
+// hostname.local.
+func New(hostname string, services []Service) (*Advertiser, error) {
+	host, err := dnsmessage.NewName(label(hostname) + "." + domain)
+	if err != nil {
+		return nil, err
+	}
+	return &Advertiser{host: host, services: services, addrs: interfaceAddrs, stopped: make(chan struct{})}, nil
+}
+
+// Start joins the mDNS group and announces the services.
+func (a *Advertiser) Start() error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, groupAddr)
+	if err != nil {
+		return err
+	}
+	a.conn = conn
+
+	a.wg.Add(1)
+	go a.serve()
+	// RFC 6762 asks for at least two announcements, a second apart.
+	if err := a.announce(recordTTL); err != nil {
+		return errors.Join(err, a.Stop())
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		select {
+		case <-time.After(time.Second):
+			_ = a.announce(recordTTL)
+		case <-a.stopped:
+		}
+	}()
+	return nil
+}
+
+// Stop sends goodbye packets, so resolvers drop the records at once, and
+// closes the socket.
+func (a *Advertiser) Stop() error {
+	var err error
+	a.stopOnce.Do(func() {
+		close(a.stopped)
+		if a.conn == nil {
+			return
+		}
+		_ = a.announce(0)
+		err = a.conn.Close()
+		a.wg.Wait()
+	})
+	return err
+}
+
+func (a *Advertiser) serve() {
+	defer a.wg.Done()
+
+	buf := make([]byte, maxPacketBytes)
+	for {
+		n, from, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		var query dnsmessage.Message
+		if err := query.Unpack(buf[:n]); err != nil || query.Header.Response {
+			continue
+		}
+		response, ok := a.answer(query, from.Port != groupAddr.Port)
+		if !ok {
+			continue
+		}
+		packet, err := response.Pack()
+		if err != nil {
+			continue
+		}
+
+		to := groupAddr
+		if from.Port != groupAddr.Port || wantsUnicast(query) {
+			to = from
+		}
+		_, _ = a.conn.WriteToUDP(packet, to)
+	}
+}
+
+func wantsUnicast(query dnsmessage.Message) bool {
+	for _, question := range query.Questions {
+		if question.Class&unicastResponse == 0 {
+			return false
+		}
+	}
+	return len(query.Questions) > 0
+}
+
+// answer builds the response to a query, if any question is about these
+// services. Legacy resolvers, which query from a port other than 5353, get
+// the query ID and questions echoed as in unicast DNS.
+func (a *Advertiser) answer(query dnsmessage.Message, legacy bool) (dnsmessage.Message, bool) {
+	response := dnsmessage.Message{Header: dnsmessage.Header{Response: true, Authoritative: true}}
+	if legacy {
+		response.Header.ID = query.Header.ID
+		response.Questions = query.Questions
+	}
+
+	seen := map[string]bool{}
+	add := func(section *[]dnsmessage.Resource, records []dnsmessage.Resource) {
+		for _, record := range records {
+			key := record.Header.Name.String() + "/" + record.Header.Type.String() + "/" + record.Body.GoString()
+			if !seen[key] {
+				seen[key] = true
+				*section = append(*section, record)
+			}
+		}
+	}
+
+	for _, question := range query.Questions {
+		name := strings.ToLower(question.Name.String())
+		wants := func(recordType dnsmessage.Type) bool {
+			return question.Type == recordType || question.Type == dnsmessage.TypeALL
+		}
+
+		switch {
+		case name == strings.ToLower(servicesName.String()) && wants(dnsmessage.TypePTR):
+			add(&response.Answers, []dnsmessage.Resource{a.ptr(servicesName, typeName, recordTTL)})
+		case name == strings.ToLower(typeName.String()) && wants(dnsmessage.TypePTR):
+			for _, service := range a.services {
+				add(&response.Answers, []dnsmessage.Resource{a.ptr(typeName, a.instanceName(service), recordTTL)})
+				add(&response.Additionals, a.serviceRecords(service, recordTTL))
+			}
+			add(&response.Additionals, a.hostRecords(recordTTL))
+		case name == strings.ToLower(a.host.String()) && wants(dnsmessage.TypeA):
+			add(&response.Answers, a.hostRecords(recordTTL))
+		default:
+			for _, service := range a.services {
+				if name != strings.ToLower(a.instanceName(service).String()) {
+					continue
+				}
+				for _, record := range a.serviceRecords(service, recordTTL) {
+					if wants(record.Header.Type) {
+						add(&response.Answers, []dnsmessage.Resource{record})
+					}
+				}
+				add(&response.Additionals, a.hostRecords(recordTTL))
+			}
+		}
+	}
+	return response, len(response.Answers) > 0
+}
+
+// announce multicasts every record unprompted; a zero ttl says goodbye.
+func (a *Advertiser) announce(ttl uint32) error {
+	announcement := dnsmessage.Message{Header: dnsmessage.Header{Response: true, Authoritative: true}}
+	for _, service := range a.services {
+		announcement.Answers = append(announcement.Answers, a.ptr(typeName, a.instanceName(service), ttl))
+		announcement.Answers = append(announcement.Answers, a.serviceRecords(service, ttl)...)
+	}
+	announcement.Answers = append(announcement.Answers, a.hostRecords(ttl)...)
+
+	packet, err := announcement.Pack()
+	if err != nil {
+		return err
+	}
+	_, err = a.conn.WriteToUDP(packet, groupAddr)
+	return err
+}
+
+func (a *Advertiser) ptr(name dnsmessage.Name, target dnsmessage.Name, ttl uint32) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: ttl},
+		Body:   &dnsmessage.PTRResource{PTR: target},
+	}
+}
+
+// serviceRecords are the SRV and TXT records of a service instance.
+func (a *Advertiser) serviceRecords(service Service, ttl uint32) []dnsmessage.Resource {
+	name := a.instanceName(service)
+	header := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET | cacheFlush, TTL: ttl}
+
+	keys := make([]string, 0, len(service.TXT))
+	for key := range service.TXT {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	txt := make([]string, 0, len(keys))
+	for _, key := range keys {
+		txt = append(txt, key+"="+service.TXT[key])
+	}
+	if len(txt) == 0 {
+		// A TXT record must hold at least one string.
+		txt = append(txt, "")
+	}
+
+	return []dnsmessage.Resource{
+		{Header: header, Body: &dnsmessage.SRVResource{Target: a.host, Port: uint16(service.Port)}},
+		{Header: header, Body: &dnsmessage.TXTResource{TXT: txt}},
+	}
+}
+
+func (a *Advertiser) hostRecords(ttl uint32) []dnsmessage.Resource {
+	var records []dnsmessage.Resource
+	for _, ip := range a.addrs() {
+		var address [4]byte
+		copy(address[:], ip.To4())
+		records = append(records, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: a.host, Class: dnsmessage.ClassINET | cacheFlush, TTL: ttl},
+			Body:   &dnsmessage.AResource{A: address},
+		})
+	}
+	return records
+}
+
+func (a *Advertiser) instanceName(service Service) dnsmessage.Name {
+	name, err := dnsmessage.NewName(label(service.Instance) + "." + ServiceType + "." + domain)
+	if err != nil {
+		return typeName
+	}
+	return name
+}
+
+// label makes text usable as a single DNS label: dots would split it and
+// labels are capped at 63 bytes.
+func label(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), ".", "-")
+	if text == "" {
+		text = "phant"
+	}
+	if len(text) > 63 {
+		text = text[:63]
+	}
+	return text
+}
+
+// interfaceAddrs lists the IPv4 addresses LAN clients can reach.
+func interfaceAddrs() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		network, ok := addr.(*net.IPNet)
+		if !ok || network.IP.IsLoopback() || network.IP.To4() == nil {
+			continue
+		}
+		ips = append(ips, network.IP.To4())
+	}
+	return ips
+}
@@ -0,0 +1,83 @@
+package discovery
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func testAdvertiser(t *testing.T) *Advertiser {
+	t.Helper()
+	advertiser, err := New("dev.box", []Service{{
+		Instance: "phant ray on dev",
+		Port:     23517,
+		TXT:      map[string]string{"schema": "2", "auth": "token"},
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	advertiser.addrs = func() []net.IP { return []net.IP{net.IPv4(192, 168, 1, 20)} }
+	return advertiser
+}
+
+func question(name string, recordType dnsmessage.Type) dnsmessage.Message {
+	return dnsmessage.Message{Questions: []dnsmessage.Question{{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  recordType,
+		Class: dnsmessage.ClassINET,
+	}}}
+}
+
+func TestAnswer_BrowseReturnsInstanceWithRecords(t *testing.T) {
+	response, ok := testAdvertiser(t).answer(question("_phant._tcp.local.", dnsmessage.TypePTR), false)
+	if !ok || len(response.Answers) != 1 {
+		t.Fatalf("answer() = %+v, %v, want one PTR answer", response.Answers, ok)
+	}
+	ptr := response.Answers[0].Body.(*dnsmessage.PTRResource)
+	if got, want := ptr.PTR.String(), "phant ray on dev._phant._tcp.local."; got != want {
+		t.Fatalf("PTR = %q, want %q", got, want)
+	}
+
+	var srv *dnsmessage.SRVResource
+	var txt *dnsmessage.TXTResource
+	var a *dnsmessage.AResource
+	for _, record := range response.Additionals {
+		switch body := record.Body.(type) {
+		case *dnsmessage.SRVResource:
+			srv = body
+		case *dnsmessage.TXTResource:
+			txt = body
+		case *dnsmessage.AResource:
+			a = body
+		}
+	}
+	if srv == nil || srv.Port != 23517 || srv.Target.String() != "dev-box.local." {
+		t.Fatalf("SRV = %+v, want port 23517 on dev-box.local.", srv)
+	}
+	if txt == nil || len(txt.TXT) != 2 || txt.TXT[0] != "auth=token" || txt.TXT[1] != "schema=2" {
+		t.Fatalf("TXT = %+v, want [auth=token schema=2]", txt)
+	}
+	if a == nil || a.A != [4]byte{192, 168, 1, 20} {
+		t.Fatalf("A = %+v, want 192.168.1.20", a)
+	}
+
+	if _, err := response.Pack(); err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+}
+
+func TestAnswer_IgnoresOtherNames(t *testing.T) {
+	if response, ok := testAdvertiser(t).answer(question("_http._tcp.local.", dnsmessage.TypePTR), false); ok {
+		t.Fatalf("answer() = %+v, want no answer", response)
+	}
+}
+
+func TestAnswer_LegacyQueryEchoesID(t *testing.T) {
+	query := question("dev-box.local.", dnsmessage.TypeA)
+	query.Header.ID = 42
+	response, ok := testAdvertiser(t).answer(query, true)
+	if !ok || response.Header.ID != 42 || len(response.Questions) != 1 {
+		t.Fatalf("answer() header = %+v, questions %d, want ID 42 and the question echoed", response.Header, len(response.Questions))
+	}
+}
@@ -0,0 +1,243 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// DefaultBatchLines is how many lines a decode worker takes at a time, and
+// so the most events a Batch holds.
+const DefaultBatchLines = 512
+
+type BatchOptions struct {
+	// MaxLineBytes, MaxErrors, and Decode behave as in StreamOptions.
+	MaxLineBytes int
+	MaxErrors    int
+	Decode       DecodeOptions
+	// Workers is how many lines are decoded at once; zero uses one worker
+	// per CPU.
+	Workers int
+	// BatchLines is how many lines make up a batch; zero uses
+	// DefaultBatchLines.
+	BatchLines int
+}
+
+// Batch holds what consecutive lines decoded to. Lines and Bytes count the
+// input read up to the end of the batch, for progress reports.
+type Batch struct {
+	Events []Event
+	Errors []LineError
+	Lines  int
+	Bytes  int64
+}
+
+type batchLine struct {
+	data    []byte
+	tooLong bool
+}
+
+type batchJob struct {
+	first int
+	lines []batchLine
+	bytes int64
+	err   error
+	done  chan batchResult
+}
+
+type batchResult struct {
+	events     []Event
+	eventLines []int
+	errors     []LineError
+	controls   []int
+}
+
+// DecodeNDJSONBatches decodes r like DecodeNDJSONStream, but spreads the
+// lines over a pool of workers and hands the events to emit batch by batch,
+// in line order, instead of collecting them. It stops when ctx is cancelled
+// or emit fails, returning that error; batches already emitted stay
+// emitted. The result counts lines, controls, and errors as
+// DecodeNDJSONStream does but holds no events.
+func DecodeNDJSONBatches(ctx context.Context, r io.Reader, opts BatchOptions, emit func(Batch) error) (StreamResult, error) {
+	if opts.MaxLineBytes <= 0 {
+		opts.MaxLineBytes = DefaultMaxLineBytes
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	if opts.BatchLines <= 0 {
+		opts.BatchLines = DefaultBatchLines
+	}
+
+	result := StreamResult{
+		Events: []Event{},
+		Errors: []LineError{},
+	}
+
+	// Jobs are queued for the workers and, in the same order, for this
+	// goroutine, which waits for each in turn; the queue bounds how far
+	// decoding runs ahead of emit.
+	jobs := make(chan *batchJob)
+	ordered := make(chan *batchJob, opts.Workers*2)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(stop)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		defer close(ordered)
+		readBatches(r, opts, jobs, ordered, stop)
+	}()
+	for range opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.done <- decodeBatch(job, opts)
+			}
+		}()
+	}
+
+	for job := range ordered {
+		var decoded batchResult
+		select {
+		case decoded = <-job.done:
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+
+		lines := job.first + len(job.lines) - 1
+		if opts.MaxErrors > 0 && len(result.Errors)+len(decoded.errors) >= opts.MaxErrors {
+			lines = decoded.errors[opts.MaxErrors-len(result.Errors)-1].Line
+			decoded = decoded.through(lines)
+			result.Truncated = true
+		}
+		result.Lines = lines
+		result.Errors = append(result.Errors, decoded.errors...)
+		result.Controls += len(decoded.controls)
+
+		if len(job.lines) > 0 {
+			batch := Batch{Events: decoded.events, Errors: decoded.errors, Lines: lines, Bytes: job.bytes}
+			if err := emit(batch); err != nil {
+				return result, err
+			}
+		}
+		if result.Truncated {
+			return result, nil
+		}
+		if job.err != nil {
+			return result, job.err
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// readBatches splits r into jobs of opts.BatchLines lines until it ends,
+// fails, or stop is closed.
+func readBatches(r io.Reader, opts BatchOptions, jobs, ordered chan<- *batchJob, stop <-chan struct{}) {
+	counter := &countingReader{r: r}
+	reader := bufio.NewReaderSize(counter, 64*1024)
+	number := 0
+
+	for {
+		job := &batchJob{first: number + 1, done: make(chan batchResult, 1)}
+		var err error
+		for len(job.lines) < opts.BatchLines && err == nil {
+			var line []byte
+			var tooLong bool
+			line, tooLong, err = readLine(reader, opts.MaxLineBytes)
+			if len(line) > 0 || tooLong || err == nil {
+				job.lines = append(job.lines, batchLine{data: line, tooLong: tooLong})
+				number++
+			}
+		}
+		job.bytes = counter.n - int64(reader.Buffered())
+		if !errors.Is(err, io.EOF) {
+			job.err = err
+		}
+
+		select {
+		case ordered <- job:
+		case <-stop:
+			return
+		}
+		select {
+		case jobs <- job:
+		case <-stop:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func decodeBatch(job *batchJob, opts BatchOptions) batchResult {
+	var decoded batchResult
+	for i, line := range job.lines {
+		number := job.first + i
+		switch {
+		case line.tooLong:
+			decoded.errors = append(decoded.errors, LineError{
+				Line:    number,
+				Message: fmt.Sprintf("line exceeds %d bytes", opts.MaxLineBytes),
+			})
+		case len(line.data) == 0:
+		case recordKind(line.data) == RecordKindControl:
+			decoded.controls = append(decoded.controls, number)
+		default:
+			event, err := decodeLine(bytes.TrimSpace(line.data), opts.Decode)
+			if err != nil {
+				decoded.errors = append(decoded.errors, LineError{Line: number, Message: err.Error()})
+			} else if event != nil {
+				decoded.events = append(decoded.events, *event)
+				decoded.eventLines = append(decoded.eventLines, number)
+			}
+		}
+	}
+	return decoded
+}
+
+// through drops what came from lines after line.
+func (b batchResult) through(line int) batchResult {
+	kept := batchResult{}
+	for i, event := range b.events {
+		if b.eventLines[i] <= line {
+			kept.events = append(kept.events, event)
+			kept.eventLines = append(kept.eventLines, b.eventLines[i])
+		}
+	}
+	for _, lineErr := range b.errors {
+		if lineErr.Line <= line {
+			kept.errors = append(kept.errors, lineErr)
+		}
+	}
+	for _, control := range b.controls {
+		if control <= line {
+			kept.controls = append(kept.controls, control)
+		}
+	}
+	return kept
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,114 @@
+package dump
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func collectBatches(t *testing.T, input string, opts BatchOptions) (StreamResult, []Batch) {
+	t.Helper()
+	var batches []Batch
+	result, err := DecodeNDJSONBatches(context.Background(), strings.NewReader(input), opts, func(batch Batch) error {
+		batches = append(batches, batch)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeNDJSONBatches() error = %v", err)
+	}
+	return result, batches
+}
+
+func TestDecodeNDJSONBatches_MatchesSequentialDecoding(t *testing.T) {
+	var lines []string
+	for i := range 40 {
+		switch i % 5 {
+		case 1:
+			lines = append(lines, "{")
+		case 2:
+			lines = append(lines, "")
+		case 3:
+			lines = append(lines, `{"kind":"control","type":"clear-screen"}`)
+		default:
+			lines = append(lines, strings.Replace(validCLILine, "01KJHZPFXVA4CNV3K2E12YVYTG", fmt.Sprintf("event-%d", i), 1))
+		}
+	}
+	lines = append(lines, strings.Repeat("x", 2*len(validCLILine)))
+	input := strings.Join(lines, "\n")
+
+	for _, maxErrors := range []int{0, 5} {
+		want, err := DecodeNDJSONStream(strings.NewReader(input), StreamOptions{MaxErrors: maxErrors, MaxLineBytes: len(validCLILine) + 8})
+		if err != nil {
+			t.Fatalf("DecodeNDJSONStream() error = %v", err)
+		}
+
+		result, batches := collectBatches(t, input, BatchOptions{MaxErrors: maxErrors, MaxLineBytes: len(validCLILine) + 8, Workers: 3, BatchLines: 4})
+		var events []Event
+		for _, batch := range batches {
+			if len(batch.Events) > 4 || batch.Lines > result.Lines || batch.Bytes > int64(len(input)) {
+				t.Fatalf("batch = %+v, want at most 4 events within the input", batch)
+			}
+			events = append(events, batch.Events...)
+		}
+		if !reflect.DeepEqual(events, want.Events) {
+			t.Fatalf("maxErrors %d: batched events = %v, want %v", maxErrors, eventIDs(events), eventIDs(want.Events))
+		}
+		want.Events = []Event{}
+		if !reflect.DeepEqual(result, want) {
+			t.Fatalf("maxErrors %d: DecodeNDJSONBatches() = %+v, want %+v", maxErrors, result, want)
+		}
+	}
+}
+
+func TestDecodeNDJSONBatches_StopsWhenEmitFailsOrContextEnds(t *testing.T) {
+	input := strings.Repeat(validCLILine+"\n", 20)
+	emitErr := errors.New("store full")
+	calls := 0
+	_, err := DecodeNDJSONBatches(context.Background(), strings.NewReader(input), BatchOptions{BatchLines: 2}, func(Batch) error {
+		calls++
+		return emitErr
+	})
+	if !errors.Is(err, emitErr) || calls != 1 {
+		t.Fatalf("DecodeNDJSONBatches() error = %v after %d batches, want %v after 1", err, calls, emitErr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	_, err = DecodeNDJSONBatches(ctx, strings.NewReader(input), BatchOptions{BatchLines: 2}, func(Batch) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Fatalf("DecodeNDJSONBatches() error = %v after %d batches, want %v after 1", err, calls, context.Canceled)
+	}
+}
+
+func eventIDs(events []Event) []string {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func BenchmarkDecodeNDJSONBatches(b *testing.B) {
+	const lines = 100_000
+	input := strings.Repeat(benchmarkHTTPLine+"\n", lines)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		decoded := 0
+		_, err := DecodeNDJSONBatches(context.Background(), strings.NewReader(input), BatchOptions{}, func(batch Batch) error {
+			decoded += len(batch.Events)
+			return nil
+		})
+		if err != nil || decoded != lines {
+			b.Fatalf("DecodeNDJSONBatches() = %d events, %v", decoded, err)
+		}
+	}
+}
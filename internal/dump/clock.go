@@ -0,0 +1,44 @@
+package dump
+
+import (
+	"time"
+)
+
+// Time bases an event can be ordered and filtered by: the producer's
+// timestamp or, when recorded, the time the collector received it.
+const (
+	TimeSent     = "sent"
+	TimeReceived = "received"
+)
+
+// StampReceived records when the collector received the event and warns
+// when the producer's timestamp is more than warnAfter away from it, which
+// usually means the producing host's clock is skewed. A zero warnAfter
+// never warns.
+func StampReceived(event *Event, at time.Time, warnAfter time.Duration) {
+	event.ReceivedAt = at.UTC().Format(time.RFC3339Nano)
+	if warnAfter <= 0 {
+		return
+	}
+
+	sent, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+	if err != nil {
+		return
+	}
+	skew := at.Sub(sent)
+	switch {
+	case skew > warnAfter:
+		event.Warnings = append(event.Warnings, Warning{Field: "timestamp", Message: "timestamp is " + skew.Round(time.Millisecond).String() + " behind receive time; the host clock may be skewed"})
+	case -skew > warnAfter:
+		event.Warnings = append(event.Warnings, Warning{Field: "timestamp", Message: "timestamp is " + (-skew).Round(time.Millisecond).String() + " ahead of receive time; the host clock may be skewed"})
+	}
+}
+
+// Time returns the event's time on basis: ReceivedAt for TimeReceived when
+// it was recorded, the producer's Timestamp otherwise.
+func (e Event) Time(basis string) string {
+	if basis == TimeReceived && e.ReceivedAt != "" {
+		return e.ReceivedAt
+	}
+	return e.Timestamp
+}
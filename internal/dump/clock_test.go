@@ -0,0 +1,46 @@
+package dump
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStampReceived_WarnsAboutSkewedClocks(t *testing.T) {
+	received := time.Date(2026, 2, 28, 11, 21, 18, 0, time.UTC)
+	tests := []struct {
+		timestamp string
+		want      string
+	}{
+		{"2026-02-28T11:21:17.5Z", ""},
+		{"2026-02-28T11:21:08Z", "timestamp is 10s behind receive time"},
+		{"2026-02-28T11:22:18Z", "timestamp is 1m0s ahead of receive time"},
+	}
+
+	for _, test := range tests {
+		event := Event{Timestamp: test.timestamp}
+		StampReceived(&event, received, 5*time.Second)
+		if event.ReceivedAt != "2026-02-28T11:21:18Z" {
+			t.Fatalf("StampReceived() receivedAt = %q, want receive time", event.ReceivedAt)
+		}
+		switch {
+		case test.want == "" && len(event.Warnings) != 0:
+			t.Fatalf("StampReceived(%s) warnings = %+v, want none", test.timestamp, event.Warnings)
+		case test.want != "" && (len(event.Warnings) != 1 || !strings.HasPrefix(event.Warnings[0].Message, test.want)):
+			t.Fatalf("StampReceived(%s) warnings = %+v, want %q", test.timestamp, event.Warnings, test.want)
+		}
+	}
+}
+
+func TestSortEventsBy_ReceiveTime(t *testing.T) {
+	events := []Event{
+		{ID: "skewed", Timestamp: "2026-02-28T11:00:00Z", ReceivedAt: "2026-02-28T11:21:20Z"},
+		{ID: "unstamped", Timestamp: "2026-02-28T11:21:19Z"},
+		{ID: "accurate", Timestamp: "2026-02-28T11:21:18Z", ReceivedAt: "2026-02-28T11:21:18Z"},
+	}
+
+	SortEventsBy(events, TimeReceived)
+	if events[0].ID != "accurate" || events[1].ID != "unstamped" || events[2].ID != "skewed" {
+		t.Fatalf("SortEventsBy(received) = %s %s %s, want accurate unstamped skewed", events[0].ID, events[1].ID, events[2].ID)
+	}
+}
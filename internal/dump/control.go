@@ -0,0 +1,125 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Record kinds. A line without a kind is an event.
+const (
+	RecordKindEvent   = "event"
+	RecordKindControl = "control"
+)
+
+// Control actions.
+const (
+	// ControlClearScreen clears the events of the control's project, or all
+	// events without one.
+	ControlClearScreen = "clear-screen"
+	// ControlNewSection starts a titled section of the event stream.
+	ControlNewSection = "new-section"
+	// ControlSetLabel labels the later events of the control's request that
+	// carry no label of their own.
+	ControlSetLabel = "set-label"
+	// ControlPause pauses the live stream, or resumes it with paused false.
+	ControlPause = "pause"
+)
+
+// MinControlSchemaVersion is the first schemaVersion with control records.
+const MinControlSchemaVersion = 2
+
+const (
+	maxControlTitleLength = 200
+	maxControlLabelLength = 200
+)
+
+// Control is a command from a PHP client, such as clearing the screen. It
+// shares the event transport but is never stored as a dump.
+type Control struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Action        string `json:"action"`
+	Timestamp     string `json:"timestamp"`
+	ProjectRoot   string `json:"projectRoot,omitempty"`
+	RequestID     string `json:"requestId,omitempty"`
+	Title         string `json:"title,omitempty"`
+	Label         string `json:"label,omitempty"`
+	Paused        *bool  `json:"paused,omitempty"`
+}
+
+// IsControlLine reports whether a line is a control record rather than an
+// event.
+func IsControlLine(line string) bool {
+	return RecordKind(line) == RecordKindControl
+}
+
+// RecordKind returns the kind a line declares, or RecordKindEvent when it
+// declares none. Lines that mention no kind are not decoded twice.
+func RecordKind(line string) string {
+	if !strings.Contains(line, `"kind"`) {
+		return RecordKindEvent
+	}
+	return recordKind([]byte(strings.TrimSpace(line)))
+}
+
+func recordKind(line []byte) string {
+	if !bytes.Contains(line, []byte(`"kind"`)) {
+		return RecordKindEvent
+	}
+	var record struct {
+		Kind string `json:"kind"`
+	}
+	if json.Unmarshal(bytes.TrimSpace(line), &record) != nil || record.Kind == "" {
+		return RecordKindEvent
+	}
+	return record.Kind
+}
+
+// DecodeControlLine decodes and validates a control line. It returns every
+// issue found; the control is nil when any of them is an error. Unknown
+// fields are ignored, as they are for events.
+func DecodeControlLine(line string) (*Control, []ValidationIssue) {
+	var issues issueList
+	var control Control
+	if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &control); err != nil {
+		issues.fail("", err)
+		return nil, issues
+	}
+
+	if control.SchemaVersion < MinControlSchemaVersion || control.SchemaVersion > SchemaVersion {
+		issues.fail("schemaVersion", fmt.Errorf("%w: control records need %d to %d", ErrUnsupportedSchemaVersion, MinControlSchemaVersion, SchemaVersion))
+	}
+	if timestamp, err := time.Parse(time.RFC3339Nano, control.Timestamp); err != nil {
+		issues.fail("timestamp", errors.New("timestamp must be RFC3339Nano"))
+	} else if timestamp.UTC().Format(time.RFC3339Nano) != control.Timestamp {
+		issues.fail("timestamp", errors.New("timestamp must be UTC (Z)"))
+	}
+
+	switch control.Action {
+	case ControlClearScreen, ControlPause:
+	case ControlNewSection:
+		if len(control.Title) > maxControlTitleLength {
+			issues.fail("title", fmt.Errorf("title must be at most %d bytes", maxControlTitleLength))
+		}
+	case ControlSetLabel:
+		if control.RequestID == "" {
+			issues.fail("requestId", errors.New("set-label needs the requestId it labels"))
+		}
+		if control.Label == "" || len(control.Label) > maxControlLabelLength {
+			issues.fail("label", fmt.Errorf("label must be 1 to %d bytes", maxControlLabelLength))
+		}
+	default:
+		issues.fail("action", errors.New("action must be one of: clear-screen, new-section, set-label, pause"))
+	}
+	if control.Paused != nil && control.Action != ControlPause {
+		issues.warn("paused", "paused only applies to the pause action")
+	}
+
+	if issues.firstError() != nil {
+		return nil, issues
+	}
+	return &control, issues
+}
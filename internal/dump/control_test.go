@@ -0,0 +1,56 @@
+package dump
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIsControlLine(t *testing.T) {
+	cases := map[string]bool{
+		`{"kind":"control","schemaVersion":2,"action":"pause"}`: true,
+		`{"kind":"event","schemaVersion":2}`:                    false,
+		`{"label":"kind"}`:                                      false,
+		validCLILine:                                            false,
+	}
+	for line, want := range cases {
+		if got := IsControlLine(line); got != want {
+			t.Fatalf("IsControlLine(%s) = %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestDecodeControlLine_ValidatesByAction(t *testing.T) {
+	control, issues := DecodeControlLine(`{"kind":"control","schemaVersion":2,"action":"set-label","timestamp":"2026-02-28T11:20:31.331Z","requestId":"req-1","label":"checkout"}`)
+	if control == nil || control.Label != "checkout" || control.RequestID != "req-1" {
+		t.Fatalf("DecodeControlLine(set-label) = %+v, %v, want the label for req-1", control, issues)
+	}
+
+	control, issues = DecodeControlLine(`{"kind":"control","schemaVersion":2,"action":"set-label","timestamp":"2026-02-28T11:20:31.331Z","label":"checkout"}`)
+	if control != nil || len(issues) != 1 || issues[0].Field != "requestId" {
+		t.Fatalf("DecodeControlLine(set-label without requestId) = %+v, %v, want a requestId error", control, issues)
+	}
+
+	control, issues = DecodeControlLine(`{"kind":"control","schemaVersion":1,"action":"reboot","timestamp":"2026-02-28T11:20:31+02:00"}`)
+	if control != nil || len(issues) != 3 {
+		t.Fatalf("DecodeControlLine(invalid) = %+v, %v, want schemaVersion, timestamp, and action errors", control, issues)
+	}
+	if !errors.Is(issues[0].err, ErrUnsupportedSchemaVersion) {
+		t.Fatalf("DecodeControlLine(schemaVersion 1) error = %v, want %v", issues[0].err, ErrUnsupportedSchemaVersion)
+	}
+}
+
+func TestDecodeNDJSONStream_SkipsControlLines(t *testing.T) {
+	input := strings.Join([]string{
+		`{"kind":"control","schemaVersion":2,"action":"clear-screen","timestamp":"2026-02-28T11:20:31.331Z"}`,
+		validCLILine,
+	}, "\n")
+
+	result, err := DecodeNDJSONStream(strings.NewReader(input), StreamOptions{})
+	if err != nil {
+		t.Fatalf("DecodeNDJSONStream() error = %v", err)
+	}
+	if len(result.Events) != 1 || len(result.Errors) != 0 || result.Controls != 1 {
+		t.Fatalf("DecodeNDJSONStream() = %+v, want 1 event and 1 control", result)
+	}
+}
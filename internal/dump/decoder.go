@@ -10,6 +10,13 @@ import (
 
 var ErrUnsupportedSchemaVersion = errors.New("unsupported schemaVersion")
 
+var (
+	errPayloadFormat    = errors.New("payloadFormat must be one of: json, text, html, binary")
+	errPayloadNotString = errors.New("payload must be a JSON string when payloadFormat is text or html")
+	errPayloadNotBase64 = errors.New("payload must be a base64 JSON string when payloadFormat is binary")
+	errBinaryTooLarge   = fmt.Errorf("binary payload must be at most %d bytes", MaxBinaryBytes)
+)
+
 var requiredEventKeys = []string{
 	"schemaVersion",
 	"id",
@@ -25,117 +32,204 @@ var requiredEventKeys = []string{
 	"host",
 }
 
+// DecodeOptions relaxes decoding rules that are strict by default.
+type DecodeOptions struct {
+	// LenientTimestamps accepts RFC3339 timestamps with a UTC offset and
+	// normalizes them to UTC instead of rejecting them.
+	LenientTimestamps bool `json:"lenientTimestamps"`
+	// MaxTraceFrames caps the stored trace depth; zero keeps every frame.
+	MaxTraceFrames int `json:"maxTraceFrames"`
+	// MaxPayloadBytes truncates larger payloads; zero keeps them verbatim.
+	MaxPayloadBytes int `json:"maxPayloadBytes"`
+	// RetainRawLines keeps the original line on each event (Event.Raw) so it
+	// can be re-decoded later; it roughly doubles the memory per event.
+	RetainRawLines bool `json:"retainRawLines"`
+}
+
 func DecodeNDJSONLine(line string) (*Event, error) {
-	trimmed := strings.TrimSpace(line)
-	if trimmed == "" {
+	return DecodeNDJSONLineWithOptions(line, DecodeOptions{})
+}
+
+func DecodeNDJSONLineWithOptions(line string, opts DecodeOptions) (*Event, error) {
+	return decodeLine([]byte(strings.TrimSpace(line)), opts)
+}
+
+// decodeLine decodes a trimmed line. The event keeps line: its payload and
+// raw line are slices of it, so the caller must not reuse it.
+func decodeLine(line []byte, opts DecodeOptions) (*Event, error) {
+	if len(line) == 0 {
 		return nil, nil
 	}
 
-	var raw map[string]json.RawMessage
-	if err := json.Unmarshal([]byte(trimmed), &raw); err != nil {
+	var issues issueList
+	event, err := decodeEvent(line, &issues)
+	if err != nil {
 		return nil, err
 	}
-
-	if err := validateRequiredKeys(raw); err != nil {
+	if err := issues.firstError(); err != nil {
 		return nil, err
 	}
 
-	var event Event
-	if err := json.Unmarshal([]byte(trimmed), &event); err != nil {
+	if err := upgradeEvent(&event); err != nil {
 		return nil, err
 	}
 
-	if err := validateEvent(event); err != nil {
+	if opts.LenientTimestamps {
+		normalizeTimestamp(&event, &issues)
+	}
+	liftBuiltinMeta(&event, &issues)
+
+	inspectEvent(event, &issues)
+	if err := issues.firstError(); err != nil {
 		return nil, err
 	}
+	event.Warnings = issues.warnings()
+	markExceptionEvent(&event)
+
+	ClassifyTrace(&event)
+	truncateEventTrace(&event, opts.MaxTraceFrames)
+	event.LimitPayload(opts.MaxPayloadBytes)
+	if opts.RetainRawLines {
+		event.Raw = json.RawMessage(line)
+	}
 
 	return &event, nil
 }
 
-func validateRequiredKeys(raw map[string]json.RawMessage) error {
-	for _, key := range requiredEventKeys {
-		if _, ok := raw[key]; !ok {
-			return fmt.Errorf("missing required dump event field: %s", key)
-		}
+// decodeEvent decodes a well-formed line in a single pass, and any other
+// through wireEvent, which records every missing key and invalid type in
+// issues.
+func decodeEvent(line []byte, issues *issueList) (Event, error) {
+	if event, ok := scanEvent(line); ok {
+		return event, nil
 	}
 
-	requestIDRaw := raw["requestId"]
-	if string(requestIDRaw) != "null" {
-		var requestID string
-		if err := json.Unmarshal(requestIDRaw, &requestID); err != nil {
-			return errors.New("requestId must be null or string")
-		}
+	var wire wireEvent
+	if err := json.Unmarshal(line, &wire); err != nil {
+		return Event{}, err
+	}
+	wire.inspect(issues)
+	return wire.event(), nil
+}
+
+func DecodeNDJSONLineLenient(line string) (*Event, []ValidationIssue) {
+	return DecodeNDJSONLineLenientWithOptions(line, DecodeOptions{})
+}
+
+// DecodeNDJSONLineLenientWithOptions never rejects an event that parses as a
+// JSON object. It returns whatever could be decoded together with every
+// validation issue found, so malformed events can be quarantined and shown
+// instead of dropped. The event is nil only for blank or non-object lines.
+func DecodeNDJSONLineLenientWithOptions(line string, opts DecodeOptions) (*Event, []ValidationIssue) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	var issues issueList
+	event, err := decodeEvent([]byte(trimmed), &issues)
+	if err != nil {
+		issues.fail("", err)
+		return nil, issues
 	}
 
-	isDDRaw := raw["isDd"]
-	var isDD bool
-	if err := json.Unmarshal(isDDRaw, &isDD); err != nil {
-		return errors.New("isDd must be a boolean")
+	if err := upgradeEvent(&event); err != nil {
+		issues.fail("schemaVersion", err)
 	}
 
-	traceRaw := raw["trace"]
-	if string(traceRaw) == "null" {
-		return errors.New("trace must be an array")
+	if opts.LenientTimestamps {
+		normalizeTimestamp(&event, &issues)
 	}
+	liftBuiltinMeta(&event, &issues)
+
+	inspectEvent(event, &issues)
+	event.Warnings = issues.warnings()
+	markExceptionEvent(&event)
 
-	var trace []json.RawMessage
-	if err := json.Unmarshal(traceRaw, &trace); err != nil {
-		return errors.New("trace must be an array")
+	ClassifyTrace(&event)
+	truncateEventTrace(&event, opts.MaxTraceFrames)
+	event.LimitPayload(opts.MaxPayloadBytes)
+
+	return &event, issues
+}
+
+func truncateEventTrace(event *Event, limit int) {
+	if limit <= 0 || len(event.Trace) <= limit {
+		return
 	}
 
-	return nil
+	original := len(event.Trace)
+	event.Trace = TruncateTrace(event.Trace, limit)
+	event.Warnings = append(event.Warnings, Warning{
+		Field:   "trace",
+		Message: fmt.Sprintf("trace truncated from %d to %d frames", original, limit),
+	})
 }
 
-func validateEvent(event Event) error {
-	if event.SchemaVersion != SchemaVersion {
-		return ErrUnsupportedSchemaVersion
+func normalizeTimestamp(event *Event, issues *issueList) {
+	timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+	if err != nil {
+		return
+	}
+
+	normalized := timestamp.UTC().Format(time.RFC3339Nano)
+	if normalized == event.Timestamp {
+		return
 	}
 
+	original := event.Timestamp
+	event.Timestamp = normalized
+	issues.warn("timestamp", fmt.Sprintf("timestamp normalized to UTC from %s", original))
+}
+
+// inspectEvent records errors for events that cannot be displayed
+// meaningfully and warnings for borderline ones that are still worth keeping.
+func inspectEvent(event Event, issues *issueList) {
 	if event.ID == "" || event.Timestamp == "" || event.SourceType == "" || event.ProjectRoot == "" || event.PHPSAPI == "" || event.PayloadFormat == "" || len(event.Payload) == 0 {
-		return errors.New("missing required dump event fields")
+		issues.fail("", errors.New("missing required dump event fields"))
 	}
+	inspectID(event, issues)
 
 	if event.Host.Hostname == "" || event.Host.PID <= 0 {
-		return errors.New("invalid host metadata")
+		issues.fail("host", errors.New("invalid host metadata"))
 	}
 
-	timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
-	if err != nil {
-		return errors.New("timestamp must be RFC3339Nano")
+	if event.Timestamp != "" {
+		timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+		if err != nil {
+			issues.fail("timestamp", errors.New("timestamp must be RFC3339Nano"))
+		} else if timestamp.UTC().Format(time.RFC3339Nano) != event.Timestamp {
+			issues.fail("timestamp", errors.New("timestamp must be UTC (Z)"))
+		}
 	}
-	if timestamp.UTC().Format(time.RFC3339Nano) != event.Timestamp {
-		return errors.New("timestamp must be UTC (Z)")
+
+	source, knownSource := LookupSourceType(event.SourceType)
+	if !knownSource {
+		issues.fail("sourceType", InvalidSourceTypeError())
 	}
 
-	switch event.SourceType {
-	case "http", "cli", "worker", "cron":
+	validFormat := true
+	switch event.PayloadFormat {
+	case PayloadFormatJSON, PayloadFormatText, PayloadFormatHTML, PayloadFormatBinary:
 	default:
-		return errors.New("sourceType must be one of: http, cli, worker, cron")
+		validFormat = false
+		issues.fail("payloadFormat", errPayloadFormat)
 	}
 
-	if event.PayloadFormat != "json" {
-		return errors.New("payloadFormat must be json for schemaVersion 1")
+	inspectV2Fields(event, issues)
+
+	if knownSource {
+		inspectSourceMetadata(source, event, issues)
 	}
 
-	if event.SourceType == "http" {
-		if event.HTTP == nil {
-			return errors.New("http metadata is required when sourceType is http")
-		}
-		if event.HTTP.Method == "" || event.HTTP.Scheme == "" || event.HTTP.Host == "" || event.HTTP.Path == "" {
-			return errors.New("http metadata is missing required fields")
-		}
-	} else {
-		if event.Command == nil {
-			return errors.New("command metadata is required when sourceType is cli, worker, or cron")
-		}
-		if event.Command.Name == "" {
-			return errors.New("command metadata is missing required field: name")
+	if validFormat && len(event.Payload) > 0 {
+		if err := validatePayload(event); err != nil {
+			issues.fail("payload", err)
 		}
+		validateMimeType(event, issues)
 	}
 
-	if !json.Valid(event.Payload) {
-		return errors.New("payload must be valid JSON")
+	if len(event.Trace) == 0 {
+		issues.warn("trace", "trace is empty; the dump callsite is unknown")
 	}
-
-	return nil
 }
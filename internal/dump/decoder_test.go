@@ -0,0 +1,433 @@
+package dump
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+const validCLILine = `{"schemaVersion":1,"id":"01KJHZPFXVA4CNV3K2E12YVYTG","timestamp":"2026-02-28T11:21:18.011Z","sourceType":"cli","projectRoot":"/app","phpSapi":"cli","requestId":null,"command":{"name":"artisan"},"isDd":false,"payloadFormat":"json","payload":{"ok":true},"trace":[{"file":"/app/routes/console.php","line":12}],"host":{"hostname":"h","pid":1}}`
+
+func TestDecodeNDJSONLine_AttachesWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		fields []string
+	}{
+		{
+			name: "complete event has no warnings",
+			line: validCLILine,
+		},
+		{
+			name:   "empty trace",
+			line:   strings.Replace(validCLILine, `"trace":[{"file":"/app/routes/console.php","line":12}]`, `"trace":[]`, 1),
+			fields: []string{"trace"},
+		},
+		{
+			name:   "http event without scheme and path",
+			line:   `{"schemaVersion":1,"id":"1","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"http","projectRoot":"/x","phpSapi":"fpm-fcgi","requestId":"a","http":{"method":"GET","host":"example.test"},"isDd":false,"payloadFormat":"json","payload":{},"trace":[{"file":"/x/index.php","line":3}],"host":{"hostname":"h","pid":1}}`,
+			fields: []string{"id", "http.scheme", "http.path"},
+		},
+		{
+			name: "producer supplied warnings are discarded",
+			line: strings.Replace(validCLILine, `"isDd":false`, `"isDd":false,"warnings":[{"field":"fake","message":"x"}]`, 1),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, err := DecodeNDJSONLine(test.line)
+			if err != nil {
+				t.Fatalf("DecodeNDJSONLine() error = %v", err)
+			}
+
+			if len(event.Warnings) != len(test.fields) {
+				t.Fatalf("event.Warnings = %+v, want fields %v", event.Warnings, test.fields)
+			}
+			for i, field := range test.fields {
+				if event.Warnings[i].Field != field {
+					t.Fatalf("event.Warnings[%d].Field = %q, want %q", i, event.Warnings[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeNDJSONLine_MissingHTTPMethodIsFatal(t *testing.T) {
+	line := `{"schemaVersion":1,"id":"1","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"http","projectRoot":"/x","phpSapi":"fpm-fcgi","requestId":"a","http":{"host":"example.test","path":"/"},"isDd":false,"payloadFormat":"json","payload":{},"trace":[],"host":{"hostname":"h","pid":1}}`
+
+	if _, err := DecodeNDJSONLine(line); err == nil || err.Error() != "http metadata is missing required fields" {
+		t.Fatalf("DecodeNDJSONLine() error = %v, want missing required fields", err)
+	}
+}
+
+func TestDecodeNDJSONLineWithOptions_LenientTimestamps(t *testing.T) {
+	line := strings.Replace(validCLILine, `"2026-02-28T11:21:18.011Z"`, `"2026-02-28T13:21:18.011+02:00"`, 1)
+
+	if _, err := DecodeNDJSONLine(line); err == nil || err.Error() != "timestamp must be UTC (Z)" {
+		t.Fatalf("DecodeNDJSONLine() error = %v, want strict UTC rejection", err)
+	}
+
+	event, err := DecodeNDJSONLineWithOptions(line, DecodeOptions{LenientTimestamps: true})
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLineWithOptions() error = %v", err)
+	}
+	if event.Timestamp != "2026-02-28T11:21:18.011Z" {
+		t.Fatalf("event.Timestamp = %q, want normalized UTC", event.Timestamp)
+	}
+	if len(event.Warnings) != 1 || event.Warnings[0].Field != "timestamp" {
+		t.Fatalf("event.Warnings = %+v, want timestamp normalization warning", event.Warnings)
+	}
+}
+
+func TestDecodeNDJSONLineWithOptions_RetainRawLines(t *testing.T) {
+	event, err := DecodeNDJSONLine(validCLILine)
+	if err != nil || event.Raw != nil {
+		t.Fatalf("DecodeNDJSONLine() raw = %q, err = %v, want no raw line by default", event.Raw, err)
+	}
+
+	event, err = DecodeNDJSONLineWithOptions("  "+validCLILine+"\n", DecodeOptions{RetainRawLines: true})
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLineWithOptions() error = %v", err)
+	}
+	if string(event.Raw) != validCLILine {
+		t.Fatalf("event.Raw = %q, want the trimmed original line", event.Raw)
+	}
+}
+
+func TestDecodeNDJSONLine_UpgradesV1Events(t *testing.T) {
+	line := strings.Replace(validCLILine, `"isDd":false`, `"isDd":false,"label":"ignored in v1"`, 1)
+
+	event, err := DecodeNDJSONLine(line)
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine() error = %v", err)
+	}
+	if event.SchemaVersion != SchemaVersion || event.OriginalSchemaVersion != 1 {
+		t.Fatalf("event versions = %d (original %d), want %d (original 1)", event.SchemaVersion, event.OriginalSchemaVersion, SchemaVersion)
+	}
+	if event.Label != "" {
+		t.Fatalf("event.Label = %q, want v1 extra field dropped", event.Label)
+	}
+}
+
+func TestDecodeNDJSONLine_V2Fields(t *testing.T) {
+	v2 := strings.Replace(validCLILine, `"schemaVersion":1`, `"schemaVersion":2`, 1)
+
+	event, err := DecodeNDJSONLine(strings.Replace(v2, `"isDd":false`, `"isDd":false,"label":"checkout","color":"#ff8800","level":"warning","durationMs":12.5,"ttlSeconds":30,"expression":"User::first()","test":{"framework":"pest","name":"it charges","dataset":"visa"},"measure":{"id":"m1","phase":"stop","memoryBytes":2048},"sql":{"query":"select * from users where id = ?","bindings":[7],"connection":"mysql"}`, 1))
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine() error = %v", err)
+	}
+	if event.OriginalSchemaVersion != 2 || event.Label != "checkout" || event.Color != "#ff8800" || event.Level != "warning" {
+		t.Fatalf("event = %+v, want v2 presentation fields", event)
+	}
+	if event.DurationMs == nil || *event.DurationMs != 12.5 {
+		t.Fatalf("event.DurationMs = %v, want 12.5", event.DurationMs)
+	}
+	if event.TTLSeconds != 30 {
+		t.Fatalf("event.TTLSeconds = %d, want 30", event.TTLSeconds)
+	}
+	if event.Expression != "User::first()" {
+		t.Fatalf("event.Expression = %q, want echoed input", event.Expression)
+	}
+	if event.Test == nil || event.Test.Name != "it charges" || event.Test.Dataset != "visa" {
+		t.Fatalf("event.Test = %+v, want test context", event.Test)
+	}
+	if event.Measure == nil || event.Measure.ID != "m1" || event.Measure.Phase != MeasureStop || event.Measure.MemoryBytes == nil || *event.Measure.MemoryBytes != 2048 {
+		t.Fatalf("event.Measure = %+v, want measure stop", event.Measure)
+	}
+	if event.SQL == nil || event.SQL.Connection != "mysql" || string(event.SQL.Bindings) != "[7]" {
+		t.Fatalf("event.SQL = %+v, want query with bindings", event.SQL)
+	}
+
+	captured := `"http":{"method":"POST","host":"app.test","headers":{"Content-Type":"application/json"},"body":"{\"id\":7}"}`
+	for version, want := range map[string]string{`"schemaVersion":2`: `{"id":7}`, `"schemaVersion":1`: ""} {
+		line := strings.Replace(strings.Replace(v2, `"schemaVersion":2`, version, 1), `"isDd":false`, `"isDd":false,`+captured, 1)
+		event, err := DecodeNDJSONLine(line)
+		if err != nil {
+			t.Fatalf("DecodeNDJSONLine(%s http capture) error = %v", version, err)
+		}
+		if event.HTTP == nil || event.HTTP.Body != want || (want != "") != (event.HTTP.Headers["Content-Type"] == "application/json") {
+			t.Fatalf("DecodeNDJSONLine(%s).HTTP = %+v, want body %q", version, event.HTTP, want)
+		}
+	}
+
+	withParent := func(ppid string) string {
+		return strings.Replace(v2, `"pid":1}`, `"pid":1,"ppid":`+ppid+`}`, 1)
+	}
+	if event, err := DecodeNDJSONLine(withParent("7")); err != nil || event.Host.PPID != 7 {
+		t.Fatalf("DecodeNDJSONLine(ppid) = %v, %v, want parent pid 7", event, err)
+	}
+	if _, err := DecodeNDJSONLine(withParent("-1")); err == nil || !strings.Contains(err.Error(), "host ppid must not be negative") {
+		t.Fatalf("DecodeNDJSONLine(negative ppid) error = %v, want ppid error", err)
+	}
+	withSDK := func(sdk string) string {
+		return strings.Replace(v2, `"pid":1}`, `"pid":1,"sdk":"`+sdk+`"}`, 1)
+	}
+	if event, err := DecodeNDJSONLine(withSDK("phant-laravel/1.4.2")); err != nil || event.Host.SDK != "phant-laravel/1.4.2" {
+		t.Fatalf("DecodeNDJSONLine(sdk) = %v, %v, want sdk", event, err)
+	}
+	if _, err := DecodeNDJSONLine(withSDK(strings.Repeat("x", 101))); err == nil || !strings.Contains(err.Error(), "host sdk must be at most 100 characters") {
+		t.Fatalf("DecodeNDJSONLine(long sdk) error = %v, want sdk error", err)
+	}
+
+	for name, extra := range map[string]string{
+		"level must be one of":              `"level":"loud"`,
+		"color must be":                     `"color":"#12"`,
+		"durationMs must not be":            `"durationMs":-1`,
+		"ttlSeconds must not be":            `"ttlSeconds":-5`,
+		"ttlSeconds has an invalid type":    `"ttlSeconds":"30s"`,
+		"label must be at most":             `"label":"` + strings.Repeat("x", 201) + `"`,
+		"expression must be at most":        `"expression":"` + strings.Repeat("x", 10001) + `"`,
+		"test metadata is missing":          `"test":{"class":"CheckoutTest"}`,
+		"test framework must be":            `"test":{"name":"x","framework":"codeception"}`,
+		"measure id must be":                `"measure":{"phase":"start"}`,
+		"measure phase must be":             `"measure":{"id":"m1","phase":"lap"}`,
+		"measure memoryBytes must not be":   `"measure":{"id":"m1","phase":"start","memoryBytes":-1}`,
+		"sql metadata is missing":           `"sql":{"query":" "}`,
+		"sql bindings must be":              `"sql":{"query":"select 1","bindings":"7"}`,
+		"http header names must be":         `"http":{"method":"GET","host":"x","headers":{"X Bad":"1"}}`,
+		"http body must be at most":         `"http":{"method":"POST","host":"x","body":"` + strings.Repeat("x", 1<<20+1) + `"}`,
+		ErrUnsupportedSchemaVersion.Error(): `"schemaVersion":3`,
+	} {
+		line := strings.Replace(v2, `"isDd":false`, `"isDd":false,`+extra, 1)
+		if strings.HasPrefix(extra, `"schemaVersion"`) {
+			line = strings.Replace(v2, `"schemaVersion":2`, extra, 1)
+		}
+		if _, err := DecodeNDJSONLine(line); err == nil || !strings.Contains(err.Error(), name) {
+			t.Fatalf("DecodeNDJSONLine(%s) error = %v, want %q", extra, err, name)
+		}
+	}
+}
+
+func TestDecodeNDJSONLine_LogSourceType(t *testing.T) {
+	v2 := strings.Replace(validCLILine, `"schemaVersion":1`, `"schemaVersion":2`, 1)
+	v2 = strings.Replace(v2, `"sourceType":"cli"`, `"sourceType":"log"`, 1)
+
+	event, err := DecodeNDJSONLine(strings.Replace(v2, `"isDd":false`, `"isDd":false,"level":"error","log":{"channel":"app","message":"payment failed"}`, 1))
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine() error = %v", err)
+	}
+	if event.Log == nil || event.Log.Channel != "app" || event.Log.Message != "payment failed" {
+		t.Fatalf("event.Log = %+v, want app channel record", event.Log)
+	}
+
+	if _, err := DecodeNDJSONLine(v2); err == nil || !strings.Contains(err.Error(), "log metadata is required") {
+		t.Fatalf("DecodeNDJSONLine() without log error = %v, want log metadata error", err)
+	}
+
+	v1 := strings.Replace(validCLILine, `"sourceType":"cli"`, `"sourceType":"log"`, 1)
+	if _, err := DecodeNDJSONLine(strings.Replace(v1, `"isDd":false`, `"isDd":false,"log":{"channel":"app","message":"x"}`, 1)); err == nil {
+		t.Fatalf("DecodeNDJSONLine() v1 log event error = nil, want log metadata dropped on upgrade")
+	}
+}
+
+func TestDecodeNDJSONLine_ExceptionBlock(t *testing.T) {
+	v2 := strings.Replace(validCLILine, `"schemaVersion":1`, `"schemaVersion":2`, 1)
+	exception := `"exception":{"class":"PDOException","message":"gone away","code":"HY000","file":"/app/Db.php","line":12,"previous":{"class":"RuntimeException","message":"retry","code":3}}`
+
+	event, err := DecodeNDJSONLine(strings.Replace(v2, `"isDd":false`, `"isDd":false,`+exception, 1))
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine() error = %v", err)
+	}
+	if !event.IsError || event.Level != "error" {
+		t.Fatalf("event isError = %v, level = %q, want flagged error", event.IsError, event.Level)
+	}
+	if event.Exception == nil || event.Exception.Code != "HY000" || event.Exception.Previous == nil || event.Exception.Previous.Code != "3" {
+		t.Fatalf("event.Exception = %+v, want PDOException chain with codes", event.Exception)
+	}
+
+	for name, extra := range map[string]string{
+		"missing required field: class":  `"exception":{"message":"no class"}`,
+		"exception has an invalid type":  `"exception":{"class":"E","code":1.5}`,
+		"previous chain must be at most": `"exception":` + strings.Repeat(`{"class":"E","previous":`, 33) + `null` + strings.Repeat(`}`, 33),
+	} {
+		line := strings.Replace(v2, `"isDd":false`, `"isDd":false,`+extra, 1)
+		if _, err := DecodeNDJSONLine(line); err == nil || !strings.Contains(err.Error(), name) {
+			t.Fatalf("DecodeNDJSONLine(%s) error = %v, want %q", name, err, name)
+		}
+	}
+}
+
+func TestDecodeNDJSONLine_ContextBlocks(t *testing.T) {
+	v2 := strings.Replace(validCLILine, `"schemaVersion":1`, `"schemaVersion":2`, 1)
+	worker := strings.Replace(v2, `"sourceType":"cli"`, `"sourceType":"worker"`, 1)
+	cron := strings.Replace(v2, `"sourceType":"cli"`, `"sourceType":"cron"`, 1)
+
+	event, err := DecodeNDJSONLine(strings.Replace(worker, `"isDd":false`, `"isDd":false,"user":{"id":"42","name":"ada"},"job":{"name":"App\\Jobs\\SendInvoice","queue":"mail","attempt":2}`, 1))
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine() error = %v", err)
+	}
+	if event.User == nil || event.User.ID != "42" || event.User.Name != "ada" {
+		t.Fatalf("event.User = %+v, want user 42", event.User)
+	}
+	if event.Job == nil || event.Job.Name != `App\Jobs\SendInvoice` || event.Job.Queue != "mail" || event.Job.Attempt != 2 {
+		t.Fatalf("event.Job = %+v, want SendInvoice on mail", event.Job)
+	}
+
+	event, err = DecodeNDJSONLine(strings.Replace(cron, `"isDd":false`, `"isDd":false,"schedule":{"expression":"*/5 * * * *","description":"prune"}`, 1))
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine() error = %v", err)
+	}
+	if event.Schedule == nil || event.Schedule.Expression != "*/5 * * * *" {
+		t.Fatalf("event.Schedule = %+v, want every five minutes", event.Schedule)
+	}
+	if _, err := DecodeNDJSONLine(strings.Replace(cron, `"isDd":false`, `"isDd":false,"schedule":{"expression":"@daily"}`, 1)); err != nil {
+		t.Fatalf("DecodeNDJSONLine() @daily error = %v", err)
+	}
+
+	for name, line := range map[string]string{
+		"user metadata is missing":           strings.Replace(v2, `"isDd":false`, `"isDd":false,"user":{"name":"ada"}`, 1),
+		"user has an invalid type":           strings.Replace(v2, `"isDd":false`, `"isDd":false,"user":{"id":42}`, 1),
+		"job metadata is only accepted":      strings.Replace(v2, `"isDd":false`, `"isDd":false,"job":{"name":"x"}`, 1),
+		"job metadata is missing":            strings.Replace(worker, `"isDd":false`, `"isDd":false,"job":{"queue":"mail"}`, 1),
+		"job attempt must not be":            strings.Replace(worker, `"isDd":false`, `"isDd":false,"job":{"name":"x","attempt":-1}`, 1),
+		"schedule metadata is only accepted": strings.Replace(worker, `"isDd":false`, `"isDd":false,"schedule":{"expression":"@daily"}`, 1),
+		"schedule expression must be":        strings.Replace(cron, `"isDd":false`, `"isDd":false,"schedule":{"expression":"every day"}`, 1),
+	} {
+		if _, err := DecodeNDJSONLine(line); err == nil || !strings.Contains(err.Error(), name) {
+			t.Fatalf("DecodeNDJSONLine(%s) error = %v, want %q", name, err, name)
+		}
+	}
+
+	v1 := strings.Replace(validCLILine, `"sourceType":"cli"`, `"sourceType":"worker"`, 1)
+	event, err = DecodeNDJSONLine(strings.Replace(v1, `"isDd":false`, `"isDd":false,"job":{"name":"x"}`, 1))
+	if err != nil || event.Job != nil {
+		t.Fatalf("DecodeNDJSONLine() v1 job = %+v, %v, want it dropped on upgrade", event.Job, err)
+	}
+}
+func TestDecodeNDJSONLine_TextAndHTMLPayloads(t *testing.T) {
+	for _, format := range []string{PayloadFormatText, PayloadFormatHTML} {
+		line := strings.Replace(validCLILine, `"payloadFormat":"json","payload":{"ok":true}`, `"payloadFormat":"`+format+`","payload":"<pre>array:1 [\n  0 => 1\n]</pre>"`, 1)
+
+		event, err := DecodeNDJSONLine(line)
+		if err != nil {
+			t.Fatalf("DecodeNDJSONLine(%s) error = %v", format, err)
+		}
+		if got, want := event.PayloadString(), "<pre>array:1 [\n  0 => 1\n]</pre>"; got != want {
+			t.Fatalf("PayloadString() = %q, want %q", got, want)
+		}
+
+		notString := strings.Replace(validCLILine, `"payloadFormat":"json"`, `"payloadFormat":"`+format+`"`, 1)
+		if _, err := DecodeNDJSONLine(notString); err == nil || !strings.Contains(err.Error(), "payload must be a JSON string") {
+			t.Fatalf("DecodeNDJSONLine(%s object payload) error = %v, want JSON string error", format, err)
+		}
+	}
+
+	event, err := DecodeNDJSONLine(validCLILine)
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine() error = %v", err)
+	}
+	if got := event.PayloadString(); got != `{"ok":true}` {
+		t.Fatalf("PayloadString() = %q, want raw JSON", got)
+	}
+}
+
+func TestDecodeNDJSONLine_BinaryPayloads(t *testing.T) {
+	binary := func(mimeType string, payload string) string {
+		return strings.NewReplacer(
+			`"schemaVersion":1`, `"schemaVersion":2`,
+			`"payloadFormat":"json","payload":{"ok":true}`, `"payloadFormat":"binary","mimeType":"`+mimeType+`","payload":`+payload,
+		).Replace(validCLILine)
+	}
+
+	event, err := DecodeNDJSONLine(binary("image/png", `"iVBORw0K\/w=="`))
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine(binary) error = %v", err)
+	}
+	data, err := event.BinaryPayload()
+	if err != nil || string(data) != "\x89PNG\r\n\xff" || event.MimeType != "image/png" {
+		t.Fatalf("BinaryPayload() = %q, %v with mimeType %q, want PNG header", data, err, event.MimeType)
+	}
+
+	oversized := `"` + strings.Repeat("A", base64.StdEncoding.EncodedLen(MaxBinaryBytes+1)) + `"`
+	for name, tc := range map[string]struct {
+		line string
+		want string
+	}{
+		"not base64":       {binary("image/png", `"not base64!"`), "base64"},
+		"object":           {binary("image/png", `{"ok":true}`), "base64"},
+		"missing mimeType": {binary("", `"AA=="`), "mimeType is required"},
+		"bad mimeType":     {binary("png", `"AA=="`), "mimeType must be a media type"},
+		"too large":        {binary("application/pdf", oversized), "at most"},
+	} {
+		if _, err := DecodeNDJSONLine(tc.line); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("DecodeNDJSONLine(%s) error = %v, want %q", name, err, tc.want)
+		}
+	}
+}
+
+func TestDecodeNDJSONLineLenient_ReturnsAllIssues(t *testing.T) {
+	line := strings.NewReplacer(
+		`"isDd":false`, `"isDd":"no"`,
+		`"sourceType":"cli"`, `"sourceType":"daemon"`,
+		`"trace":[{"file":"/app/routes/console.php","line":12}]`, `"trace":[]`,
+	).Replace(validCLILine)
+
+	if _, err := DecodeNDJSONLine(line); err == nil {
+		t.Fatalf("DecodeNDJSONLine() error = nil, want strict failure")
+	}
+
+	event, issues := DecodeNDJSONLineLenient(line)
+	if event == nil {
+		t.Fatalf("DecodeNDJSONLineLenient() event = nil, want best-effort event")
+	}
+	if event.ID != "01KJHZPFXVA4CNV3K2E12YVYTG" || event.Command == nil || event.Command.Name != "artisan" {
+		t.Fatalf("DecodeNDJSONLineLenient() event = %+v, want well-typed fields kept", event)
+	}
+
+	want := map[string]string{
+		"isDd":       SeverityError,
+		"sourceType": SeverityError,
+		"trace":      SeverityWarning,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("DecodeNDJSONLineLenient() issues = %+v, want %d issues", issues, len(want))
+	}
+	for _, issue := range issues {
+		if want[issue.Field] != issue.Severity {
+			t.Fatalf("issue %+v, want severity %q", issue, want[issue.Field])
+		}
+	}
+}
+
+func TestDecodeNDJSONLineLenient_NotJSON(t *testing.T) {
+	event, issues := DecodeNDJSONLineLenient("not json")
+	if event != nil || len(issues) != 1 || issues[0].Severity != SeverityError {
+		t.Fatalf("DecodeNDJSONLineLenient() = %+v, %+v, want nil event and one error", event, issues)
+	}
+}
+
+func TestDecodeNDJSONLine_FieldTypeErrors(t *testing.T) {
+	tests := []struct {
+		replace string
+		with    string
+		wantErr string
+	}{
+		{replace: `"trace":[{"file":"/app/routes/console.php","line":12}]`, with: `"trace":{}`, wantErr: "trace must be an array"},
+		{replace: `"trace":[{"file":"/app/routes/console.php","line":12}]`, with: `"trace":[1]`, wantErr: "trace frames are invalid"},
+		{replace: `"id":"01KJHZPFXVA4CNV3K2E12YVYTG"`, with: `"id":42`, wantErr: "id has an invalid type"},
+		{replace: `"host":{"hostname":"h","pid":1}`, with: `"host":"h"`, wantErr: "host has an invalid type"},
+		{replace: `"payload":{"ok":true}`, with: `"payload":null`, wantErr: ""},
+	}
+
+	for _, test := range tests {
+		_, err := DecodeNDJSONLine(strings.Replace(validCLILine, test.replace, test.with, 1))
+		if test.wantErr == "" {
+			if err != nil {
+				t.Fatalf("DecodeNDJSONLine(%s) error = %v, want nil", test.with, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Fatalf("DecodeNDJSONLine(%s) error = %v, want %q", test.with, err, test.wantErr)
+		}
+	}
+}
+
+func BenchmarkDecodeNDJSONLine(b *testing.B) {
+	for b.Loop() {
+		if _, err := DecodeNDJSONLine(validCLILine); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package dump
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ValidateAs validates line as if its producer had declared schemaVersion
+// version, and returns every issue found. It lets maintainers see what an
+// existing corpus would break on before producers move to a new version;
+// version must be one this build can decode.
+func ValidateAs(line string, version int) ([]ValidationIssue, error) {
+	if version < MinSchemaVersion || version > SchemaVersion {
+		return nil, ErrUnsupportedSchemaVersion
+	}
+
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(trimmed), &object); err != nil {
+		var issues issueList
+		issues.fail("", err)
+		return issues, nil
+	}
+	object["schemaVersion"], _ = json.Marshal(version)
+
+	rewritten, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	_, issues := DecodeNDJSONLineLenient(string(rewritten))
+	return issues, nil
+}
@@ -0,0 +1,63 @@
+package dump
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// maxExceptionChain bounds Throwable::getPrevious() links; PHP itself
+// prevents cycles, so longer chains indicate a broken producer.
+const maxExceptionChain = 32
+
+// ExceptionCode is Throwable::getCode(): an integer for most exceptions, but
+// a string such as "HY000" for PDOException. Both decode to their text form.
+type ExceptionCode string
+
+func (c *ExceptionCode) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = ExceptionCode(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return errors.New("exception code must be a string or integer")
+	}
+	if _, err := strconv.ParseInt(number.String(), 10, 64); err != nil {
+		return errors.New("exception code must be a string or integer")
+	}
+	*c = ExceptionCode(number.String())
+	return nil
+}
+
+// markExceptionEvent flags events carrying an exception block as errors and
+// gives them a level when the producer sent none.
+func markExceptionEvent(event *Event) {
+	if event.Exception == nil {
+		return
+	}
+	event.IsError = true
+	if event.Level == "" {
+		event.Level = "error"
+	}
+}
+
+func inspectException(exception *ExceptionMeta, issues *issueList) {
+	depth := 0
+	for current := exception; current != nil; current = current.Previous {
+		depth++
+		if depth > maxExceptionChain {
+			issues.fail("exception.previous", errors.New("exception previous chain must be at most 32 levels deep"))
+			return
+		}
+
+		if current.Class == "" {
+			issues.fail("exception.class", errors.New("exception metadata is missing required field: class"))
+		}
+		if current.Line < 0 {
+			issues.fail("exception.line", errors.New("exception line must not be negative"))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package dump
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationIssue is one problem found while validating an event. Strict
+// decoding fails on the first error-severity issue; lenient decoding returns
+// them all alongside a best-effort event.
+type ValidationIssue struct {
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+
+	err error
+}
+
+type issueList []ValidationIssue
+
+func (l *issueList) fail(field string, err error) {
+	*l = append(*l, ValidationIssue{Field: field, Message: err.Error(), Severity: SeverityError, err: err})
+}
+
+func (l *issueList) warn(field string, message string) {
+	*l = append(*l, ValidationIssue{Field: field, Message: message, Severity: SeverityWarning})
+}
+
+func (l issueList) firstError() error {
+	for _, issue := range l {
+		if issue.Severity == SeverityError {
+			return issue.err
+		}
+	}
+	return nil
+}
+
+func (l issueList) warnings() []Warning {
+	var warnings []Warning
+	for _, issue := range l {
+		if issue.Severity == SeverityWarning {
+			warnings = append(warnings, Warning{Field: issue.Field, Message: issue.Message})
+		}
+	}
+	return warnings
+}
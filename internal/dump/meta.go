@@ -0,0 +1,156 @@
+package dump
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Types a MetaField can declare.
+const (
+	MetaString  = "string"
+	MetaNumber  = "number"
+	MetaBoolean = "boolean"
+	MetaObject  = "object"
+	MetaArray   = "array"
+)
+
+var ErrNoMeta = errors.New("event has no metadata for its source type")
+
+// MetaField declares one key of a custom source type's metadata block, so
+// types registered from configuration get checked without Go code.
+type MetaField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+}
+
+func validateMetaFields(fields []MetaField) error {
+	seen := map[string]bool{}
+	for _, field := range fields {
+		if field.Name == "" {
+			return errors.New("metadata field name must not be empty")
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("metadata field %q is declared twice", field.Name)
+		}
+		seen[field.Name] = true
+		switch field.Type {
+		case MetaString, MetaNumber, MetaBoolean, MetaObject, MetaArray:
+		default:
+			return fmt.Errorf("metadata field %q type must be one of: string, number, boolean, object, array", field.Name)
+		}
+	}
+	return nil
+}
+
+// UnmarshalMeta decodes the event's block under meta.<sourceType> into
+// target.
+func (e Event) UnmarshalMeta(target any) error {
+	raw, ok := e.Meta[e.SourceType]
+	if !ok {
+		return ErrNoMeta
+	}
+	return json.Unmarshal(raw, target)
+}
+
+// liftBuiltinMeta moves http, command, and log blocks sent inside meta to
+// their own fields, so consumers see one shape whichever way a producer
+// sent them. The top-level field wins when both are present.
+func liftBuiltinMeta(event *Event, issues *issueList) {
+	lift := func(key string, set bool, target any) bool {
+		raw, ok := event.Meta[key]
+		if !ok {
+			return false
+		}
+		delete(event.Meta, key)
+		at := "meta." + key
+
+		if set {
+			issues.warn(at, fmt.Sprintf("%s ignored; %s is also set", at, key))
+			return false
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			issues.fail(at, fmt.Errorf("%s has an invalid type", at))
+			return false
+		}
+		return true
+	}
+
+	if http := new(HTTPMeta); lift(MetadataHTTP, event.HTTP != nil, http) {
+		event.HTTP = http
+	}
+	if command := new(CommandMeta); lift(MetadataCommand, event.Command != nil, command) {
+		event.Command = command
+	}
+	if log := new(LogMeta); lift(MetadataLog, event.Log != nil, log) {
+		event.Log = log
+	}
+	if len(event.Meta) == 0 {
+		event.Meta = nil
+	}
+}
+
+// inspectMeta checks the event's own block against its source type and
+// warns about blocks no registered type would read.
+func inspectMeta(source SourceType, event Event, issues *issueList) {
+	for key := range event.Meta {
+		if key == source.Name {
+			continue
+		}
+		if _, ok := LookupSourceType(key); !ok {
+			issues.warn("meta."+key, fmt.Sprintf("meta.%s is not a registered source type", key))
+		}
+	}
+
+	raw, ok := event.Meta[source.Name]
+	if !ok {
+		return
+	}
+	at := "meta." + source.Name
+
+	if len(source.Fields) > 0 {
+		var block map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &block); err != nil || block == nil {
+			issues.fail(at, fmt.Errorf("%s must be an object", at))
+			return
+		}
+		for _, field := range source.Fields {
+			value, present := block[field.Name]
+			switch {
+			case !present || string(value) == "null":
+				if field.Required {
+					issues.fail(at+"."+field.Name, fmt.Errorf("%s is missing required field: %s", at, field.Name))
+				}
+			case !metaValueIs(value, field.Type):
+				issues.fail(at+"."+field.Name, fmt.Errorf("%s.%s must be a %s", at, field.Name, field.Type))
+			}
+		}
+	}
+
+	if source.DecodeMeta != nil {
+		if err := source.DecodeMeta(raw); err != nil {
+			issues.fail(at, fmt.Errorf("%s is invalid: %w", at, err))
+		}
+	}
+}
+
+func metaValueIs(value json.RawMessage, kind string) bool {
+	var decoded any
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return false
+	}
+	switch decoded.(type) {
+	case string:
+		return kind == MetaString
+	case float64:
+		return kind == MetaNumber
+	case bool:
+		return kind == MetaBoolean
+	case map[string]any:
+		return kind == MetaObject
+	case []any:
+		return kind == MetaArray
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package dump
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var eventLevels = map[string]bool{
+	"debug":     true,
+	"info":      true,
+	"notice":    true,
+	"warning":   true,
+	"error":     true,
+	"critical":  true,
+	"alert":     true,
+	"emergency": true,
+}
+
+var testFrameworks = map[string]bool{
+	"pest":    true,
+	"phpunit": true,
+}
+
+var namedColors = map[string]bool{
+	"gray":   true,
+	"red":    true,
+	"orange": true,
+	"yellow": true,
+	"green":  true,
+	"blue":   true,
+	"purple": true,
+}
+
+var hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// cronField is one field of a cron expression, such as "*/5", "1-5", or
+// "MON,FRI".
+var cronField = regexp.MustCompile(`^[0-9A-Za-z*?/,#-]+$`)
+
+var cronMacros = map[string]bool{
+	"@yearly":   true,
+	"@annually": true,
+	"@monthly":  true,
+	"@weekly":   true,
+	"@daily":    true,
+	"@midnight": true,
+	"@hourly":   true,
+	"@reboot":   true,
+}
+
+const (
+	maxLabelLength      = 200
+	maxExpressionLength = 10000
+	maxSDKLength        = 100
+	maxMeasureIDLength  = 200
+	maxHTTPBodyLength   = 1 << 20
+	maxMimeTypeLength   = 255
+)
+
+// ValidColor reports whether color is one of the named colors or a #rgb or
+// #rrggbb hex value.
+func ValidColor(color string) bool {
+	return namedColors[color] || hexColorPattern.MatchString(color)
+}
+
+// upgradeEvent brings a decoded event to the current SchemaVersion and
+// records the version the producer sent.
+func upgradeEvent(event *Event) error {
+	event.OriginalSchemaVersion = event.SchemaVersion
+
+	switch event.SchemaVersion {
+	case 1:
+		upgradeV1ToV2(event)
+	case 2:
+	default:
+		return ErrUnsupportedSchemaVersion
+	}
+
+	return nil
+}
+
+// upgradeV1ToV2 drops fields v1 producers could only have sent as unknown
+// extras, so they are not mistaken for v2 presentation hints.
+func upgradeV1ToV2(event *Event) {
+	event.Label = ""
+	event.Color = ""
+	event.Level = ""
+	event.DurationMs = nil
+	event.TTLSeconds = 0
+	event.Expression = ""
+	event.Log = nil
+	event.Meta = nil
+	event.IsError = false
+	event.Gate = false
+	event.Host.PPID = 0
+	event.Host.SDK = ""
+	event.Exception = nil
+	event.Test = nil
+	event.Measure = nil
+	event.SQL = nil
+	event.User = nil
+	event.Job = nil
+	event.Schedule = nil
+	event.MimeType = ""
+	if event.HTTP != nil {
+		event.HTTP.Headers = nil
+		event.HTTP.Body = ""
+	}
+	event.SchemaVersion = 2
+}
+
+func inspectV2Fields(event Event, issues *issueList) {
+	if len(event.Label) > maxLabelLength {
+		issues.fail("label", errors.New("label must be at most 200 characters"))
+	}
+
+	if len(event.Expression) > maxExpressionLength {
+		issues.fail("expression", errors.New("expression must be at most 10000 characters"))
+	}
+
+	if event.Color != "" && !ValidColor(event.Color) {
+		issues.fail("color", errors.New("color must be a named color or #rgb/#rrggbb hex value"))
+	}
+
+	if event.Level != "" && !eventLevels[event.Level] {
+		issues.fail("level", errors.New("level must be one of: debug, info, notice, warning, error, critical, alert, emergency"))
+	}
+
+	if event.Host.PPID < 0 {
+		issues.fail("host.ppid", errors.New("host ppid must not be negative"))
+	}
+	if len(event.Host.SDK) > maxSDKLength {
+		issues.fail("host.sdk", errors.New("host sdk must be at most 100 characters"))
+	}
+
+	if event.Gate && !event.IsDD {
+		issues.warn("gate", "gate is ignored unless isDd is true")
+	}
+
+	if event.DurationMs != nil && *event.DurationMs < 0 {
+		issues.fail("durationMs", errors.New("durationMs must not be negative"))
+	}
+	if event.TTLSeconds < 0 {
+		issues.fail("ttlSeconds", errors.New("ttlSeconds must not be negative"))
+	}
+
+	if event.Exception != nil {
+		inspectException(event.Exception, issues)
+	}
+
+	if event.Test != nil {
+		inspectTest(event.Test, issues)
+	}
+
+	if event.Measure != nil {
+		inspectMeasure(event.Measure, issues)
+	}
+
+	if event.SQL != nil {
+		inspectSQL(event.SQL, issues)
+	}
+
+	if event.User != nil && event.User.ID == "" {
+		issues.fail("user.id", errors.New("user metadata is missing required field: id"))
+	}
+	inspectJob(event, issues)
+	inspectSchedule(event, issues)
+
+	if event.HTTP != nil {
+		inspectHTTPCapture(event.HTTP, issues)
+	}
+}
+
+func inspectTest(test *TestMeta, issues *issueList) {
+	if test.Name == "" {
+		issues.fail("test.name", errors.New("test metadata is missing required field: name"))
+	}
+	if test.Framework != "" && !testFrameworks[test.Framework] {
+		issues.fail("test.framework", errors.New("test framework must be one of: pest, phpunit"))
+	}
+}
+
+// inspectJob checks the job block, which only worker events carry.
+func inspectJob(event Event, issues *issueList) {
+	job := event.Job
+	if job == nil {
+		return
+	}
+	if event.SourceType != "worker" {
+		issues.fail("job", errors.New("job metadata is only accepted when sourceType is worker"))
+	}
+	if job.Name == "" {
+		issues.fail("job.name", errors.New("job metadata is missing required field: name"))
+	}
+	if job.Attempt < 0 {
+		issues.fail("job.attempt", errors.New("job attempt must not be negative"))
+	}
+}
+
+// inspectSchedule checks the schedule block, which only cron events carry.
+func inspectSchedule(event Event, issues *issueList) {
+	schedule := event.Schedule
+	if schedule == nil {
+		return
+	}
+	if event.SourceType != "cron" {
+		issues.fail("schedule", errors.New("schedule metadata is only accepted when sourceType is cron"))
+	}
+	if !validCronExpression(schedule.Expression) {
+		issues.fail("schedule.expression", errors.New("schedule expression must be a cron expression of 5 or 6 fields or a macro such as @daily"))
+	}
+}
+
+func validCronExpression(expression string) bool {
+	if cronMacros[expression] {
+		return true
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 && len(fields) != 6 {
+		return false
+	}
+	for _, field := range fields {
+		if !cronField.MatchString(field) {
+			return false
+		}
+	}
+	return true
+}
+
+func inspectMeasure(measure *MeasureMeta, issues *issueList) {
+	if measure.ID == "" || len(measure.ID) > maxMeasureIDLength {
+		issues.fail("measure.id", errors.New("measure id must be 1 to 200 characters"))
+	}
+	if measure.Phase != MeasureStart && measure.Phase != MeasureStop {
+		issues.fail("measure.phase", errors.New("measure phase must be one of: start, stop"))
+	}
+	if len(measure.Name) > maxLabelLength {
+		issues.fail("measure.name", errors.New("measure name must be at most 200 characters"))
+	}
+	if measure.MemoryBytes != nil && *measure.MemoryBytes < 0 {
+		issues.fail("measure.memoryBytes", errors.New("measure memoryBytes must not be negative"))
+	}
+}
+
+func inspectSQL(sql *SQLMeta, issues *issueList) {
+	if strings.TrimSpace(sql.Query) == "" {
+		issues.fail("sql.query", errors.New("sql metadata is missing required field: query"))
+	}
+	if bindings := bytes.TrimSpace(sql.Bindings); len(bindings) > 0 && bindings[0] != '[' && bindings[0] != '{' && string(bindings) != "null" {
+		issues.fail("sql.bindings", errors.New("sql bindings must be an array or an object"))
+	}
+}
+
+func inspectHTTPCapture(http *HTTPMeta, issues *issueList) {
+	for name := range http.Headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			issues.fail("http.headers", errors.New("http header names must be non-empty tokens"))
+			break
+		}
+	}
+	if len(http.Body) > maxHTTPBodyLength {
+		issues.fail("http.body", errors.New("http body must be at most 1 MiB"))
+	}
+}
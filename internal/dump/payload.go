@@ -0,0 +1,89 @@
+package dump
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"mime"
+	"strings"
+)
+
+const (
+	PayloadFormatJSON   = "json"
+	PayloadFormatText   = "text"
+	PayloadFormatHTML   = "html"
+	PayloadFormatBinary = "binary"
+)
+
+// MaxBinaryBytes caps a binary payload once decoded, so its base64 form
+// fits in a line of DefaultMaxLineBytes with room for the rest of the event.
+const MaxBinaryBytes = 3 * 1024 * 1024
+
+// PayloadString returns the payload as display text: text and html payloads
+// are unwrapped from their JSON string, json payloads are returned verbatim.
+func (e Event) PayloadString() string {
+	if e.PayloadFormat == PayloadFormatText || e.PayloadFormat == PayloadFormatHTML {
+		var text string
+		if err := json.Unmarshal(e.Payload, &text); err == nil {
+			return text
+		}
+	}
+	return string(e.Payload)
+}
+
+// BinaryPayload decodes the base64 data of a binary payload, which is at
+// most MaxBinaryBytes.
+func (e Event) BinaryPayload() ([]byte, error) {
+	var encoded string
+	if err := json.Unmarshal(e.Payload, &encoded); err != nil {
+		return nil, errPayloadNotBase64
+	}
+	// Checked before decoding, so an oversized payload costs no copy.
+	if len(encoded) > base64.StdEncoding.EncodedLen(MaxBinaryBytes) {
+		return nil, errBinaryTooLarge
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errPayloadNotBase64
+	}
+	return data, nil
+}
+
+// validatePayload checks the kind of a decoded payload. It was a value of a
+// line that parsed, so it is valid JSON, and a string when it starts with a
+// quote; null unmarshals into a string as well.
+func validatePayload(event Event) error {
+	switch event.PayloadFormat {
+	case PayloadFormatText, PayloadFormatHTML:
+		if event.Payload[0] != '"' && string(event.Payload) != "null" {
+			return errPayloadNotString
+		}
+	case PayloadFormatBinary:
+		if event.Payload[0] != '"' {
+			return errPayloadNotBase64
+		}
+		if _, err := event.BinaryPayload(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateMimeType checks the media type of a binary payload; it is
+// required for one and ignored for every other format.
+func validateMimeType(event Event, issues *issueList) {
+	if event.PayloadFormat != PayloadFormatBinary {
+		if event.MimeType != "" {
+			issues.warn("mimeType", "mimeType is ignored unless payloadFormat is binary")
+		}
+		return
+	}
+	if event.MimeType == "" {
+		issues.fail("mimeType", errors.New("mimeType is required when payloadFormat is binary"))
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(event.MimeType)
+	if err != nil || !strings.Contains(mediaType, "/") || len(event.MimeType) > maxMimeTypeLength {
+		issues.fail("mimeType", errors.New("mimeType must be a media type such as image/png"))
+	}
+}
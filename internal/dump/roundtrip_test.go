@@ -0,0 +1,301 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// The property tests below pin the wire contract: anything phant accepts
+// and re-encodes must decode to the same event again, so stored sessions,
+// exports, and forwarded events never drift from what producers sent.
+
+var roundTripConfig = &quick.Config{MaxCount: 500, Rand: rand.New(rand.NewSource(1))}
+
+// textRunes mixes ASCII with multi-byte runes, JSON and HTML metacharacters,
+// and control characters that need escaping.
+var textRunes = []rune("abcXYZ019 _-/.:\"\\<>&'\n\t\u0000\u001féüñßπЖ中文日本語한국어👍🐘\u2028\u2029")
+
+func randomText(r *rand.Rand, maxLen int) string {
+	n := r.Intn(maxLen + 1)
+	var b strings.Builder
+	for range n {
+		b.WriteRune(textRunes[r.Intn(len(textRunes))])
+	}
+	return b.String()
+}
+
+func randomName(r *rand.Rand) string {
+	return fmt.Sprintf("%s%d", []string{"app", "job", "Cart", "user"}[r.Intn(4)], r.Intn(1000))
+}
+
+// randomULID mints a ULID for timestamp with random entropy.
+func randomULID(r *rand.Rand, timestamp time.Time) string {
+	id := make([]byte, 26)
+	ms := timestamp.UnixMilli()
+	for i := 9; i >= 0; i-- {
+		id[i] = crockfordAlphabet[ms&31]
+		ms >>= 5
+	}
+	for i := 10; i < len(id); i++ {
+		id[i] = crockfordAlphabet[r.Intn(32)]
+	}
+	return string(id)
+}
+
+func pick[T any](r *rand.Rand, values ...T) T {
+	return values[r.Intn(len(values))]
+}
+
+func randomJSONValue(r *rand.Rand, depth int) any {
+	kind := r.Intn(7)
+	if depth <= 0 {
+		kind = r.Intn(5)
+	}
+	switch kind {
+	case 0:
+		return nil
+	case 1:
+		return r.Intn(2) == 0
+	case 2:
+		return r.NormFloat64() * 1e6
+	case 3:
+		return r.Int63n(1<<53) - 1<<52
+	case 4:
+		return randomText(r, 24)
+	case 5:
+		values := make([]any, r.Intn(4))
+		for i := range values {
+			values[i] = randomJSONValue(r, depth-1)
+		}
+		return values
+	default:
+		object := map[string]any{}
+		for range r.Intn(4) {
+			object[randomText(r, 8)] = randomJSONValue(r, depth-1)
+		}
+		return object
+	}
+}
+
+func randomPayload(r *rand.Rand) (string, json.RawMessage) {
+	format := pick(r, PayloadFormatJSON, PayloadFormatText, PayloadFormatHTML)
+	var value any = randomText(r, 64)
+	if format == PayloadFormatJSON {
+		value = randomJSONValue(r, 3)
+	}
+	payload, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return format, payload
+}
+
+func randomTrace(r *rand.Rand, projectRoot string) []TraceFrame {
+	frames := make([]TraceFrame, 1+r.Intn(4))
+	for i := range frames {
+		dir := pick(r, "app", "src", "vendor/laravel/framework/src")
+		frames[i] = TraceFrame{File: projectRoot + "/" + dir + "/" + randomName(r) + ".php", Line: 1 + r.Intn(500)}
+		if r.Intn(2) == 0 {
+			frames[i].Func = randomName(r) + "->handle"
+		}
+	}
+	return frames
+}
+
+func randomException(r *rand.Rand, projectRoot string, depth int) *ExceptionMeta {
+	exception := &ExceptionMeta{
+		Class:   pick(r, "RuntimeException", `App\Exceptions\PaymentFailed`, "TypeError"),
+		Message: randomText(r, 40),
+	}
+	if r.Intn(2) == 0 {
+		exception.Code = ExceptionCode(pick(r, "0", "42", "HY000"))
+	}
+	if r.Intn(2) == 0 {
+		exception.File = projectRoot + "/app/" + randomName(r) + ".php"
+		exception.Line = 1 + r.Intn(500)
+	}
+	if r.Intn(2) == 0 {
+		exception.Trace = randomTrace(r, projectRoot)
+	}
+	if depth > 0 && r.Intn(3) == 0 {
+		exception.Previous = randomException(r, projectRoot, depth-1)
+	}
+	return exception
+}
+
+// canonicalEvent is a valid v2 event in the form the decoder produces: no
+// warnings, truncation fields unset, frames classified, and exception
+// events already marked as errors.
+type canonicalEvent struct{ Event }
+
+func (canonicalEvent) Generate(r *rand.Rand, _ int) reflect.Value {
+	projectRoot := pick(r, "/srv/app", "/var/www/html", "/home/ada/Projects/shop")
+	timestamp := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(r.Int63n(int64(365 * 24 * time.Hour))))
+	format, payload := randomPayload(r)
+
+	event := Event{
+		SchemaVersion:         SchemaVersion,
+		OriginalSchemaVersion: SchemaVersion,
+		ID:                    randomULID(r, timestamp),
+		Timestamp:             timestamp.Format(time.RFC3339Nano),
+		SourceType:            pick(r, "http", "cli", "worker", "cron", "log"),
+		ProjectRoot:           projectRoot,
+		IsDD:                  r.Intn(5) == 0,
+		PayloadFormat:         format,
+		Payload:               payload,
+		Trace:                 randomTrace(r, projectRoot),
+		Host:                  HostMeta{Hostname: randomName(r), PID: 1 + r.Intn(1<<20)},
+	}
+
+	switch event.SourceType {
+	case "http":
+		event.PHPSAPI = "fpm-fcgi"
+		event.HTTP = &HTTPMeta{
+			Method: pick(r, "GET", "POST", "DELETE"),
+			Scheme: pick(r, "http", "https"),
+			Host:   "app.test",
+			Path:   "/" + randomText(r, 16),
+		}
+		if r.Intn(2) == 0 {
+			status := pick(r, 200, 302, 404, 500)
+			event.HTTP.StatusCode = &status
+			event.HTTP.Query = "q=" + randomText(r, 8)
+		}
+	case "log":
+		event.PHPSAPI = pick(r, "cli", "fpm-fcgi")
+		event.Log = &LogMeta{Channel: pick(r, "app", "payments"), Message: randomText(r, 40)}
+		event.Level = pick(r, "debug", "info", "warning", "error")
+	default:
+		event.PHPSAPI = "cli"
+		event.Command = &CommandMeta{Name: pick(r, "artisan", "bin/console", "worker.php")}
+		if r.Intn(2) == 0 {
+			event.Command.Args = []string{randomText(r, 8), "--force"}
+			event.Command.Cwd = projectRoot
+		}
+	}
+
+	if r.Intn(2) == 0 {
+		requestID := randomName(r)
+		event.RequestID = &requestID
+	}
+	if r.Intn(2) == 0 {
+		event.Label = randomText(r, 40)
+	}
+	if r.Intn(3) == 0 {
+		event.Color = pick(r, "red", "green", "#abc", "#A0B1C2")
+	}
+	if r.Intn(3) == 0 && event.Level == "" {
+		event.Level = pick(r, "notice", "critical", "emergency")
+	}
+	if r.Intn(3) == 0 {
+		duration := r.ExpFloat64() * 100
+		event.DurationMs = &duration
+	}
+	if r.Intn(4) == 0 {
+		event.Exception = randomException(r, projectRoot, 3)
+		markExceptionEvent(&event)
+	}
+	ClassifyTrace(&event)
+
+	return reflect.ValueOf(canonicalEvent{event})
+}
+
+// sloppyEvent is a valid event that the decoder still rewrites: it earns
+// warnings, carries producer-set consumer fields, or leaves exception
+// events unmarked.
+type sloppyEvent struct{ Event }
+
+func (sloppyEvent) Generate(r *rand.Rand, size int) reflect.Value {
+	event := canonicalEvent{}.Generate(r, size).Interface().(canonicalEvent).Event
+	if r.Intn(2) == 0 {
+		event.Trace = []TraceFrame{}
+	}
+	if event.HTTP != nil && r.Intn(2) == 0 {
+		event.HTTP.Scheme = ""
+		event.HTTP.Path = ""
+	}
+	if event.Exception != nil && r.Intn(2) == 0 {
+		event.IsError = false
+		event.Level = ""
+	}
+	for i := range event.Trace {
+		event.Trace[i].Kind = pick(r, "", FrameApp, FrameVendor, "bogus")
+	}
+	event.Warnings = []Warning{{Field: "fake", Message: randomText(r, 8)}}
+	event.Truncated = r.Intn(2) == 0
+	event.OriginalBytes = r.Intn(1000)
+	return reflect.ValueOf(sloppyEvent{event})
+}
+
+func encodeEvent(t *testing.T, event Event) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return data
+}
+
+func TestRoundTrip_CanonicalEventsAreAcceptedUnchanged(t *testing.T) {
+	property := func(generated canonicalEvent) bool {
+		first := encodeEvent(t, generated.Event)
+
+		decoded, err := DecodeNDJSONLine(string(first))
+		if err != nil {
+			t.Logf("DecodeNDJSONLine(%s) error = %v", first, err)
+			return false
+		}
+		if len(decoded.Warnings) > 0 {
+			t.Logf("DecodeNDJSONLine(%s) warnings = %v, want none", first, decoded.Warnings)
+			return false
+		}
+		if _, issues := DecodeNDJSONLineLenient(string(first)); len(issues) > 0 {
+			t.Logf("DecodeNDJSONLineLenient(%s) issues = %v, want none", first, issues)
+			return false
+		}
+
+		if second := encodeEvent(t, *decoded); !bytes.Equal(first, second) {
+			t.Logf("encode→decode→encode changed the event:\n first = %s\nsecond = %s", first, second)
+			return false
+		}
+		return true
+	}
+
+	if err := quick.Check(property, roundTripConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip_DecodingIsIdempotent(t *testing.T) {
+	property := func(generated sloppyEvent) bool {
+		decoded, err := DecodeNDJSONLine(string(encodeEvent(t, generated.Event)))
+		if err != nil {
+			t.Logf("DecodeNDJSONLine() error = %v", err)
+			return false
+		}
+		once := encodeEvent(t, *decoded)
+
+		again, err := DecodeNDJSONLine(string(once))
+		if err != nil {
+			t.Logf("DecodeNDJSONLine(%s) error = %v", once, err)
+			return false
+		}
+		if twice := encodeEvent(t, *again); !bytes.Equal(once, twice) {
+			t.Logf("re-decoding changed the event:\n once = %s\ntwice = %s", once, twice)
+			return false
+		}
+		return true
+	}
+
+	if err := quick.Check(property, roundTripConfig); err != nil {
+		t.Fatal(err)
+	}
+}
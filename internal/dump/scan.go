@@ -0,0 +1,545 @@
+package dump
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxScanDepth bounds the nesting scanEvent skips through; deeper values are
+// left to encoding/json.
+const maxScanDepth = 512
+
+// Keys scanEvent decodes itself. encoding/json matches keys without regard
+// to case, so an unknown key that folds to one of these is left to it.
+var scanKeys = []string{
+	"schemaVersion", "id", "timestamp", "sourceType", "projectRoot", "phpSapi", "requestId", "http", "command", "log",
+	"meta", "isDd", "gate", "isError", "exception", "test", "measure", "sql", "user", "job", "schedule", "payloadFormat",
+	"payload", "trace", "host", "mimeType", "label", "color", "level", "durationMs", "expression", "ttlSeconds",
+}
+
+// requiredScanKeys is the bit set of requiredEventKeys in scanKeys order.
+var requiredScanKeys = func() uint64 {
+	var bits uint64
+	for _, key := range requiredEventKeys {
+		bits |= keyBit(scanKeys, key)
+	}
+	return bits
+}()
+
+var (
+	frameKeys = []string{"file", "line", "func", "kind", "elided"}
+	hostKeys  = []string{"hostname", "pid", "ppid", "sdk"}
+)
+
+func keyBit(keys []string, key string) uint64 {
+	for i, known := range keys {
+		if known == key {
+			return 1 << i
+		}
+	}
+	return 0
+}
+
+// foldsToKey reports whether key differs from every known key but matches
+// one of them without regard to case.
+func foldsToKey(keys []string, key string) bool {
+	for _, known := range keys {
+		if strings.EqualFold(known, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanEvent decodes a line holding the common shape of an event in a single
+// pass over its bytes, without reflection. It gives up, returning false, on
+// anything it does not decode exactly as encoding/json would into a
+// wireEvent: a missing, null, or mistyped required key, a repeated key, or
+// a string it would have to repair. decodeEvent then decodes the line
+// through wireEvent, which also reports what is wrong with it.
+//
+// The payload is a slice of line, not a copy.
+func scanEvent(line []byte) (Event, bool) {
+	s := scanner{data: line}
+	var event Event
+	var seen uint64
+
+	ok := s.object(func(key []byte) bool {
+		bit := keyBit(scanKeys, string(key))
+		if bit == 0 {
+			return !foldsToKey(scanKeys, string(key)) && s.skip(0)
+		}
+		if seen&bit != 0 {
+			return false
+		}
+		seen |= bit
+
+		switch string(key) {
+		case "schemaVersion":
+			return s.int(&event.SchemaVersion)
+		case "id":
+			return s.string(&event.ID)
+		case "timestamp":
+			return s.string(&event.Timestamp)
+		case "sourceType":
+			return s.string(&event.SourceType)
+		case "projectRoot":
+			return s.string(&event.ProjectRoot)
+		case "phpSapi":
+			return s.string(&event.PHPSAPI)
+		case "requestId":
+			if s.null() {
+				return true
+			}
+			event.RequestID = new(string)
+			return s.string(event.RequestID)
+		case "http":
+			return scanBlock(&s, &event.HTTP)
+		case "command":
+			return scanBlock(&s, &event.Command)
+		case "log":
+			return scanBlock(&s, &event.Log)
+		case "exception":
+			return scanBlock(&s, &event.Exception)
+		case "test":
+			return scanBlock(&s, &event.Test)
+		case "measure":
+			return scanBlock(&s, &event.Measure)
+		case "sql":
+			return scanBlock(&s, &event.SQL)
+		case "user":
+			return scanBlock(&s, &event.User)
+		case "job":
+			return scanBlock(&s, &event.Job)
+		case "schedule":
+			return scanBlock(&s, &event.Schedule)
+		case "meta":
+			start := s.pos
+			if !s.skip(0) || json.Unmarshal(line[start:s.pos], &event.Meta) != nil {
+				return false
+			}
+			if len(event.Meta) == 0 {
+				event.Meta = nil
+			}
+			return true
+		case "isDd":
+			return s.bool(&event.IsDD)
+		case "gate":
+			return s.bool(&event.Gate)
+		case "isError":
+			return s.bool(&event.IsError)
+		case "payloadFormat":
+			return s.string(&event.PayloadFormat)
+		case "payload":
+			start := s.pos
+			if !s.skip(0) {
+				return false
+			}
+			event.Payload = json.RawMessage(line[start:s.pos])
+			return true
+		case "mimeType":
+			return s.string(&event.MimeType)
+		case "trace":
+			return s.trace(&event.Trace)
+		case "host":
+			return s.host(&event.Host)
+		case "label":
+			return s.string(&event.Label)
+		case "color":
+			return s.string(&event.Color)
+		case "level":
+			return s.string(&event.Level)
+		case "durationMs":
+			event.DurationMs = new(float64)
+			return s.float(event.DurationMs)
+		case "expression":
+			return s.string(&event.Expression)
+		case "ttlSeconds":
+			var ttl int
+			ok := s.int(&ttl)
+			event.TTLSeconds = int64(ttl)
+			return ok
+		}
+		return false
+	})
+
+	s.space()
+	if !ok || s.pos != len(line) || seen&requiredScanKeys != requiredScanKeys {
+		return Event{}, false
+	}
+	return event, true
+}
+
+// scanBlock decodes an optional metadata block with encoding/json; blocks
+// are small and rare next to the payload and trace.
+func scanBlock[T any](s *scanner, target **T) bool {
+	if s.null() {
+		*target = nil
+		return true
+	}
+	start := s.pos
+	if !s.skip(0) {
+		return false
+	}
+	value := new(T)
+	if json.Unmarshal(s.data[start:s.pos], value) != nil {
+		return false
+	}
+	*target = value
+	return true
+}
+
+type scanner struct {
+	data    []byte
+	pos     int
+	scratch []byte
+}
+
+func (s *scanner) space() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *scanner) consume(c byte) bool {
+	s.space()
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+func (s *scanner) literal(text string) bool {
+	s.space()
+	if len(s.data)-s.pos < len(text) || string(s.data[s.pos:s.pos+len(text)]) != text {
+		return false
+	}
+	s.pos += len(text)
+	return true
+}
+
+func (s *scanner) null() bool {
+	return s.literal("null")
+}
+
+// object calls member for each key of an object, with the scanner at the
+// member's value.
+func (s *scanner) object(member func(key []byte) bool) bool {
+	if !s.consume('{') {
+		return false
+	}
+	if s.consume('}') {
+		return true
+	}
+	for {
+		key, ok := s.quoted()
+		if !ok || !s.consume(':') || !member(key) {
+			return false
+		}
+		if s.consume('}') {
+			return true
+		}
+		if !s.consume(',') {
+			return false
+		}
+	}
+}
+
+func (s *scanner) trace(frames *[]TraceFrame) bool {
+	if !s.consume('[') {
+		return false
+	}
+	*frames = []TraceFrame{}
+	if s.consume(']') {
+		return true
+	}
+	for {
+		var frame TraceFrame
+		var seen uint64
+		ok := s.object(func(key []byte) bool {
+			bit := keyBit(frameKeys, string(key))
+			if bit == 0 {
+				return !foldsToKey(frameKeys, string(key)) && s.skip(1)
+			}
+			if seen&bit != 0 {
+				return false
+			}
+			seen |= bit
+			switch string(key) {
+			case "file":
+				return s.string(&frame.File)
+			case "line":
+				return s.int(&frame.Line)
+			case "func":
+				return s.string(&frame.Func)
+			case "kind":
+				return s.string(&frame.Kind)
+			case "elided":
+				return s.int(&frame.Elided)
+			}
+			return false
+		})
+		if !ok {
+			return false
+		}
+		*frames = append(*frames, frame)
+		if s.consume(']') {
+			return true
+		}
+		if !s.consume(',') {
+			return false
+		}
+	}
+}
+
+func (s *scanner) host(host *HostMeta) bool {
+	var seen uint64
+	return s.object(func(key []byte) bool {
+		bit := keyBit(hostKeys, string(key))
+		if bit == 0 {
+			return !foldsToKey(hostKeys, string(key)) && s.skip(1)
+		}
+		if seen&bit != 0 {
+			return false
+		}
+		seen |= bit
+		switch string(key) {
+		case "hostname":
+			return s.string(&host.Hostname)
+		case "pid":
+			return s.int(&host.PID)
+		case "ppid":
+			return s.int(&host.PPID)
+		case "sdk":
+			return s.string(&host.SDK)
+		}
+		return false
+	})
+}
+
+func (s *scanner) bool(target *bool) bool {
+	switch {
+	case s.literal("true"):
+		*target = true
+	case s.literal("false"):
+		*target = false
+	default:
+		return false
+	}
+	return true
+}
+
+// number returns the JSON number at the scanner and whether it is an
+// integer.
+func (s *scanner) number() ([]byte, bool, bool) {
+	s.space()
+	start := s.pos
+	if s.pos < len(s.data) && s.data[s.pos] == '-' {
+		s.pos++
+	}
+	switch {
+	case s.pos < len(s.data) && s.data[s.pos] == '0':
+		s.pos++
+	case s.pos < len(s.data) && s.data[s.pos] >= '1' && s.data[s.pos] <= '9':
+		s.digits()
+	default:
+		return nil, false, false
+	}
+	integer := true
+	if s.pos < len(s.data) && s.data[s.pos] == '.' {
+		s.pos++
+		integer = false
+		if !s.digits() {
+			return nil, false, false
+		}
+	}
+	if s.pos < len(s.data) && (s.data[s.pos] == 'e' || s.data[s.pos] == 'E') {
+		s.pos++
+		integer = false
+		if s.pos < len(s.data) && (s.data[s.pos] == '+' || s.data[s.pos] == '-') {
+			s.pos++
+		}
+		if !s.digits() {
+			return nil, false, false
+		}
+	}
+	return s.data[start:s.pos], integer, true
+}
+
+func (s *scanner) digits() bool {
+	start := s.pos
+	for s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '9' {
+		s.pos++
+	}
+	return s.pos > start
+}
+
+func (s *scanner) int(target *int) bool {
+	text, integer, ok := s.number()
+	if !ok || !integer {
+		return false
+	}
+	value, err := strconv.Atoi(string(text))
+	*target = value
+	return err == nil
+}
+
+func (s *scanner) float(target *float64) bool {
+	text, _, ok := s.number()
+	if !ok {
+		return false
+	}
+	value, err := strconv.ParseFloat(string(text), 64)
+	*target = value
+	return err == nil
+}
+
+// quoted returns the contents of the string at the scanner, unescaped
+// into the scanner's scratch buffer if it holds escapes. Strings with
+// invalid UTF-8 or lone surrogates are refused rather than repaired.
+func (s *scanner) quoted() ([]byte, bool) {
+	if !s.consume('"') {
+		return nil, false
+	}
+	start := s.pos
+	plain := true
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case c == '"':
+			raw := s.data[start:s.pos]
+			s.pos++
+			if !utf8.Valid(raw) {
+				return nil, false
+			}
+			if plain {
+				return raw, true
+			}
+			var ok bool
+			s.scratch, ok = unescape(s.scratch[:0], raw)
+			return s.scratch, ok
+		case c == '\\':
+			plain = false
+			s.pos += 2
+		case c < 0x20:
+			return nil, false
+		default:
+			s.pos++
+		}
+	}
+	return nil, false
+}
+
+func (s *scanner) string(target *string) bool {
+	text, ok := s.quoted()
+	*target = string(text)
+	return ok
+}
+
+func unescape(out, raw []byte) ([]byte, bool) {
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c != '\\' {
+			out = append(out, c)
+			continue
+		}
+		i++
+		if i >= len(raw) {
+			return out, false
+		}
+		switch raw[i] {
+		case '"', '\\', '/':
+			out = append(out, raw[i])
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'u':
+			r, ok := hex4(raw, i+1)
+			if !ok {
+				return out, false
+			}
+			i += 4
+			if utf16.IsSurrogate(r) {
+				low, ok := rune(0), i+6 < len(raw) && raw[i+1] == '\\' && raw[i+2] == 'u'
+				if ok {
+					low, ok = hex4(raw, i+3)
+				}
+				if r = utf16.DecodeRune(r, low); !ok || r == utf8.RuneError {
+					return out, false
+				}
+				i += 6
+			}
+			out = utf8.AppendRune(out, r)
+		default:
+			return out, false
+		}
+	}
+	return out, true
+}
+
+func hex4(raw []byte, at int) (rune, bool) {
+	if at+4 > len(raw) {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(string(raw[at:at+4]), 16, 16)
+	return rune(value), err == nil
+}
+
+// skip moves past one JSON value, checking its syntax as it goes.
+func (s *scanner) skip(depth int) bool {
+	if depth > maxScanDepth {
+		return false
+	}
+	s.space()
+	if s.pos >= len(s.data) {
+		return false
+	}
+	switch c := s.data[s.pos]; {
+	case c == '{':
+		return s.object(func([]byte) bool { return s.skip(depth + 1) })
+	case c == '[':
+		s.pos++
+		if s.consume(']') {
+			return true
+		}
+		for {
+			if !s.skip(depth + 1) {
+				return false
+			}
+			if s.consume(']') {
+				return true
+			}
+			if !s.consume(',') {
+				return false
+			}
+		}
+	case c == '"':
+		_, ok := s.quoted()
+		return ok
+	case c == 't':
+		return s.literal("true")
+	case c == 'f':
+		return s.literal("false")
+	case c == 'n':
+		return s.null()
+	default:
+		_, _, ok := s.number()
+		return ok
+	}
+}
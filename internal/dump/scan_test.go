@@ -0,0 +1,70 @@
+package dump
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanEvent_MatchesWireDecoding(t *testing.T) {
+	lines := []string{
+		validCLILine,
+		benchmarkHTTPLine,
+		` { "schemaVersion" : 2 , "id":"a\u00e9\ud83d\ude00\n","timestamp":"t","sourceType":"cli","projectRoot":"C:\\app","phpSapi":"cli","requestId":"r","isDd":true,"payloadFormat":"text","payload":"line\nnext","trace":[],"host":{"hostname":"h","pid":-1,"ppid":0,"sdk":"php/1.2","extra":[1,{"a":null}]}} `,
+		`{"schemaVersion":2,"id":"x","timestamp":"t","sourceType":"log","projectRoot":"/app","phpSapi":"cli","requestId":null,"log":{"channel":"app","level":"error"},"meta":{"k":[1,2]},"isDd":false,"gate":true,"isError":true,"durationMs":1.5e2,"ttlSeconds":60,"label":"l","color":"red","level":"warn","expression":"$x","payloadFormat":"json","payload":null,"trace":[{"file":"/a.php","line":1,"func":"f","kind":"vendor","elided":3,"args":{}}],"host":{"hostname":"h","pid":1},"unknown":"\/"}`,
+		`{"schemaVersion":2,"id":"x","timestamp":"t","sourceType":"cli","projectRoot":"/app","phpSapi":"cli","requestId":null,"http":null,"meta":{},"isDd":false,"payloadFormat":"json","payload":[true,false,-0.5e-3,"é"],"trace":[],"host":{"hostname":"h","pid":1}}`,
+		`{"schemaVersion":2,"id":"x","timestamp":"t","sourceType":"cli","projectRoot":"/app","phpSapi":"cli","requestId":null,"isDd":false,"payloadFormat":"binary","mimeType":"image/png","payload":"iVBORw0K\/w==","trace":[],"host":{"hostname":"h","pid":1}}`,
+	}
+
+	for _, line := range lines {
+		scanned, ok := scanEvent([]byte(line))
+		if !ok {
+			t.Fatalf("scanEvent(%s) declined a well-formed line", line)
+		}
+		var wire wireEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &wire); err != nil {
+			t.Fatalf("json.Unmarshal(%s) error = %v", line, err)
+		}
+		want := wire.event()
+		if !reflect.DeepEqual(scanned, want) {
+			t.Fatalf("scanEvent(%s) =\n%+v\nwant\n%+v", line, scanned, want)
+		}
+	}
+}
+
+func TestScanEvent_DefersToWireDecoding(t *testing.T) {
+	replace := func(old, new string) string {
+		if !strings.Contains(validCLILine, old) {
+			t.Fatalf("validCLILine has no %q", old)
+		}
+		return strings.Replace(validCLILine, old, new, 1)
+	}
+	lines := map[string]string{
+		"missing key":    replace(`"phpSapi":"cli",`, ""),
+		"null required":  replace(`"id":"01KJHZPFXVA4CNV3K2E12YVYTG"`, `"id":null`),
+		"wrong type":     replace(`"schemaVersion":1`, `"schemaVersion":"1"`),
+		"float for int":  replace(`"line":12`, `"line":12.0`),
+		"repeated key":   replace(`"isDd":false`, `"isDd":false,"isDd":true`),
+		"folded key":     replace(`"isDd":false`, `"isDd":false,"ISDD":true`),
+		"lone surrogate": replace(`"projectRoot":"/app"`, `"projectRoot":"\ud800"`),
+		"invalid utf-8":  replace(`"projectRoot":"/app"`, "\"projectRoot\":\"\xff\""),
+		"control char":   replace(`"projectRoot":"/app"`, "\"projectRoot\":\"a\tb\""),
+		"bad escape":     replace(`"projectRoot":"/app"`, `"projectRoot":"\x"`),
+		"null frame":     replace(`[{"file"`, `[null,{"file"`),
+		"trailing data":  validCLILine + `{}`,
+		"truncated":      validCLILine[:len(validCLILine)-1],
+		"bad payload":    replace(`{"ok":true}`, `{"ok":tru}`),
+		"int overflow":   replace(`"pid":1`, `"pid":99999999999999999999`),
+		"leading zero":   replace(`"pid":1`, `"pid":01`),
+		"not an object":  `[1]`,
+		"bad block":      replace(`{"name":"artisan"}`, `{"name":1}`),
+		"null optional":  replace(`"isDd":false`, `"isDd":false,"label":null`),
+	}
+
+	for name, line := range lines {
+		if event, ok := scanEvent([]byte(line)); ok {
+			t.Fatalf("scanEvent(%s) = %+v, want it to defer to wireEvent", name, event)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package dump
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Metadata blocks a source type can require.
+const (
+	MetadataNone    = ""
+	MetadataHTTP    = "http"
+	MetadataCommand = "command"
+	MetadataLog     = "log"
+	// MetadataMeta requires a block under meta.<source type name>.
+	MetadataMeta = "meta"
+)
+
+var (
+	ErrSourceTypeExists  = errors.New("source type is already registered")
+	ErrSourceTypeBuiltin = errors.New("built-in source types cannot be changed")
+	ErrSourceTypeUnknown = errors.New("source type is not registered")
+)
+
+var sourceTypeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// SourceType describes one accepted value of Event.SourceType: the metadata
+// block its events must carry and how the UI should present it.
+type SourceType struct {
+	Name string `json:"name"`
+	// Metadata is the block required on events of this type: http,
+	// command, log, meta, or empty for none.
+	Metadata string `json:"metadata"`
+	// Fields describes the block under meta.<name>; it is checked whenever
+	// the block is present.
+	Fields  []MetaField `json:"fields,omitempty"`
+	Label   string      `json:"label"`
+	Icon    string      `json:"icon,omitempty"`
+	Color   string      `json:"color,omitempty"`
+	Builtin bool        `json:"builtin"`
+
+	// DecodeMeta decodes the block under meta.<name> for integrations
+	// registered from Go; an error rejects the event.
+	DecodeMeta func(json.RawMessage) error `json:"-"`
+	// Validate runs after the metadata check for integrations registered
+	// from Go. Issues without a severity are errors.
+	Validate func(Event) []ValidationIssue `json:"-"`
+}
+
+func (s SourceType) validateDefinition() error {
+	if !sourceTypeNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("source type name %q must be lowercase letters, digits, - or _ (at most 32)", s.Name)
+	}
+	switch s.Metadata {
+	case MetadataNone, MetadataHTTP, MetadataCommand, MetadataLog, MetadataMeta:
+	default:
+		return fmt.Errorf("source type metadata must be one of: http, command, log, meta, or empty")
+	}
+	if err := validateMetaFields(s.Fields); err != nil {
+		return err
+	}
+	if s.Color != "" && !ValidColor(s.Color) {
+		return errors.New("source type color must be a named color or #rgb/#rrggbb hex value")
+	}
+	return nil
+}
+
+// sourceTypes is the process-wide registry consulted by the decoder and by
+// query filters. Registration order is kept for messages and listings.
+var sourceTypes = struct {
+	sync.RWMutex
+	order  []string
+	byName map[string]SourceType
+}{byName: map[string]SourceType{}}
+
+func init() {
+	for _, source := range []SourceType{
+		{Name: "http", Metadata: MetadataHTTP, Label: "HTTP", Icon: "globe"},
+		{Name: "cli", Metadata: MetadataCommand, Label: "CLI", Icon: "terminal"},
+		{Name: "worker", Metadata: MetadataCommand, Label: "Worker", Icon: "cog"},
+		{Name: "cron", Metadata: MetadataCommand, Label: "Cron", Icon: "clock"},
+		{Name: "log", Metadata: MetadataLog, Label: "Log", Icon: "list"},
+	} {
+		source.Builtin = true
+		sourceTypes.order = append(sourceTypes.order, source.Name)
+		sourceTypes.byName[source.Name] = source
+	}
+}
+
+// RegisterSourceType adds a custom source type, or replaces a custom type
+// registered earlier under the same name when replace is set.
+func RegisterSourceType(source SourceType, replace bool) error {
+	if err := source.validateDefinition(); err != nil {
+		return err
+	}
+	source.Builtin = false
+	if source.Label == "" {
+		source.Label = source.Name
+	}
+
+	sourceTypes.Lock()
+	defer sourceTypes.Unlock()
+
+	existing, ok := sourceTypes.byName[source.Name]
+	switch {
+	case ok && existing.Builtin:
+		return fmt.Errorf("%w: %s", ErrSourceTypeBuiltin, source.Name)
+	case ok && !replace:
+		return fmt.Errorf("%w: %s", ErrSourceTypeExists, source.Name)
+	case !ok:
+		sourceTypes.order = append(sourceTypes.order, source.Name)
+	}
+	sourceTypes.byName[source.Name] = source
+	return nil
+}
+
+func UnregisterSourceType(name string) error {
+	sourceTypes.Lock()
+	defer sourceTypes.Unlock()
+
+	existing, ok := sourceTypes.byName[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSourceTypeUnknown, name)
+	}
+	if existing.Builtin {
+		return fmt.Errorf("%w: %s", ErrSourceTypeBuiltin, name)
+	}
+	delete(sourceTypes.byName, name)
+	for i, candidate := range sourceTypes.order {
+		if candidate == name {
+			sourceTypes.order = append(sourceTypes.order[:i:i], sourceTypes.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// SourceTypes lists built-in types first, then custom ones in registration
+// order.
+func SourceTypes() []SourceType {
+	sourceTypes.RLock()
+	defer sourceTypes.RUnlock()
+
+	sources := make([]SourceType, len(sourceTypes.order))
+	for i, name := range sourceTypes.order {
+		sources[i] = sourceTypes.byName[name]
+	}
+	return sources
+}
+
+func LookupSourceType(name string) (SourceType, bool) {
+	sourceTypes.RLock()
+	defer sourceTypes.RUnlock()
+
+	source, ok := sourceTypes.byName[name]
+	return source, ok
+}
+
+// InvalidSourceTypeError is the error for a sourceType nobody registered; it
+// lists what is currently accepted.
+func InvalidSourceTypeError() error {
+	sourceTypes.RLock()
+	defer sourceTypes.RUnlock()
+	return fmt.Errorf("sourceType must be one of: %s", strings.Join(sourceTypes.order, ", "))
+}
+
+// sourceTypesRequiring names the registered types that need metadata, for
+// messages such as "required when sourceType is cli, worker, or cron".
+func sourceTypesRequiring(metadata string) string {
+	sourceTypes.RLock()
+	defer sourceTypes.RUnlock()
+
+	var names []string
+	for _, name := range sourceTypes.order {
+		if sourceTypes.byName[name].Metadata == metadata {
+			names = append(names, name)
+		}
+	}
+	switch len(names) {
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " or " + names[1]
+	default:
+		return strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1]
+	}
+}
+
+func inspectSourceMetadata(source SourceType, event Event, issues *issueList) {
+	switch source.Metadata {
+	case MetadataHTTP:
+		switch {
+		case event.HTTP == nil:
+			issues.fail("http", fmt.Errorf("http metadata is required when sourceType is %s", sourceTypesRequiring(MetadataHTTP)))
+		case event.HTTP.Method == "" || event.HTTP.Host == "":
+			issues.fail("http", errors.New("http metadata is missing required fields"))
+		}
+		if event.HTTP != nil && event.HTTP.Scheme == "" {
+			issues.warn("http.scheme", "http scheme is missing")
+		}
+		if event.HTTP != nil && event.HTTP.Path == "" {
+			issues.warn("http.path", "http path is missing")
+		}
+	case MetadataLog:
+		switch {
+		case event.Log == nil:
+			issues.fail("log", fmt.Errorf("log metadata is required when sourceType is %s", sourceTypesRequiring(MetadataLog)))
+		case event.Log.Channel == "":
+			issues.fail("log.channel", errors.New("log metadata is missing required field: channel"))
+		}
+	case MetadataCommand:
+		switch {
+		case event.Command == nil:
+			issues.fail("command", fmt.Errorf("command metadata is required when sourceType is %s", sourceTypesRequiring(MetadataCommand)))
+		case event.Command.Name == "":
+			issues.fail("command.name", errors.New("command metadata is missing required field: name"))
+		}
+	case MetadataMeta:
+		if _, ok := event.Meta[source.Name]; !ok {
+			issues.fail("meta."+source.Name, fmt.Errorf("meta.%s is required when sourceType is %s", source.Name, source.Name))
+		}
+	}
+	inspectMeta(source, event, issues)
+
+	if source.Validate == nil {
+		return
+	}
+	for _, issue := range source.Validate(event) {
+		if issue.Severity == SeverityWarning {
+			issues.warn(issue.Field, issue.Message)
+			continue
+		}
+		issues.fail(issue.Field, errors.New(issue.Message))
+	}
+}
@@ -0,0 +1,165 @@
+package dump
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegisterSourceType_AcceptsCustomSources(t *testing.T) {
+	websocket := SourceType{Name: "websocket", Metadata: MetadataCommand, Color: "purple"}
+	lambda := SourceType{Name: "lambda", Validate: func(event Event) []ValidationIssue {
+		if event.Label == "" {
+			return []ValidationIssue{{Field: "label", Message: "lambda events need the function name as label"}}
+		}
+		return nil
+	}}
+	for _, source := range []SourceType{websocket, lambda} {
+		if err := RegisterSourceType(source, false); err != nil {
+			t.Fatalf("RegisterSourceType(%s) error = %v", source.Name, err)
+		}
+		t.Cleanup(func() { UnregisterSourceType(source.Name) })
+	}
+
+	v2 := strings.Replace(validCLILine, `"schemaVersion":1`, `"schemaVersion":2`, 1)
+	ws := strings.Replace(v2, `"sourceType":"cli"`, `"sourceType":"websocket"`, 1)
+	if event, err := DecodeNDJSONLine(ws); err != nil || event.SourceType != "websocket" {
+		t.Fatalf("DecodeNDJSONLine(websocket) = %v, %v, want accepted", event, err)
+	}
+	noCommand := strings.Replace(ws, `"command":{"name":"artisan"},`, "", 1)
+	if _, err := DecodeNDJSONLine(noCommand); err == nil || err.Error() != "command metadata is required when sourceType is cli, worker, cron, or websocket" {
+		t.Fatalf("DecodeNDJSONLine(websocket without command) error = %v, want command required", err)
+	}
+
+	fn := strings.Replace(v2, `"sourceType":"cli"`, `"sourceType":"lambda"`, 1)
+	if _, err := DecodeNDJSONLine(fn); err == nil || err.Error() != "lambda events need the function name as label" {
+		t.Fatalf("DecodeNDJSONLine(lambda) error = %v, want custom validator error", err)
+	}
+	if _, err := DecodeNDJSONLine(strings.Replace(fn, `"isDd":false`, `"isDd":false,"label":"resize"`, 1)); err != nil {
+		t.Fatalf("DecodeNDJSONLine(lambda with label) error = %v", err)
+	}
+
+	if got := InvalidSourceTypeError().Error(); got != "sourceType must be one of: http, cli, worker, cron, log, websocket, lambda" {
+		t.Fatalf("InvalidSourceTypeError() = %q, want custom types listed", got)
+	}
+}
+
+func TestRegisterSourceType_ProtectsBuiltinsAndDuplicates(t *testing.T) {
+	if err := RegisterSourceType(SourceType{Name: "http"}, true); !errors.Is(err, ErrSourceTypeBuiltin) {
+		t.Fatalf("RegisterSourceType(http) error = %v, want ErrSourceTypeBuiltin", err)
+	}
+	if err := UnregisterSourceType("cli"); !errors.Is(err, ErrSourceTypeBuiltin) {
+		t.Fatalf("UnregisterSourceType(cli) error = %v, want ErrSourceTypeBuiltin", err)
+	}
+	if err := RegisterSourceType(SourceType{Name: "Test Runner"}, false); err == nil {
+		t.Fatalf("RegisterSourceType(invalid name) error = nil, want error")
+	}
+
+	if err := RegisterSourceType(SourceType{Name: "test"}, false); err != nil {
+		t.Fatalf("RegisterSourceType(test) error = %v", err)
+	}
+	t.Cleanup(func() { UnregisterSourceType("test") })
+	if err := RegisterSourceType(SourceType{Name: "test"}, false); !errors.Is(err, ErrSourceTypeExists) {
+		t.Fatalf("RegisterSourceType(test) again error = %v, want ErrSourceTypeExists", err)
+	}
+	if err := RegisterSourceType(SourceType{Name: "test", Label: "Tests"}, true); err != nil {
+		t.Fatalf("RegisterSourceType(test, replace) error = %v", err)
+	}
+	if source, _ := LookupSourceType("test"); source.Label != "Tests" {
+		t.Fatalf("LookupSourceType(test) = %+v, want replaced label", source)
+	}
+}
+
+func TestDecodeNDJSONLine_ValidatesCustomMetaBlocks(t *testing.T) {
+	type websocketMeta struct {
+		Channel      string `json:"channel"`
+		ConnectionID string `json:"connectionId"`
+	}
+	websocket := SourceType{
+		Name:     "websocket",
+		Metadata: MetadataMeta,
+		Fields:   []MetaField{{Name: "channel", Type: MetaString, Required: true}, {Name: "clients", Type: MetaNumber}},
+		DecodeMeta: func(raw json.RawMessage) error {
+			var meta websocketMeta
+			if err := json.Unmarshal(raw, &meta); err != nil {
+				return err
+			}
+			if meta.ConnectionID == "" {
+				return errors.New("connectionId is empty")
+			}
+			return nil
+		},
+	}
+	if err := RegisterSourceType(websocket, false); err != nil {
+		t.Fatalf("RegisterSourceType(websocket) error = %v", err)
+	}
+	t.Cleanup(func() { UnregisterSourceType("websocket") })
+
+	v2 := strings.Replace(validCLILine, `"schemaVersion":1`, `"schemaVersion":2`, 1)
+	base := strings.Replace(strings.Replace(v2, `"sourceType":"cli"`, `"sourceType":"websocket"`, 1), `"command":{"name":"artisan"},`, "", 1)
+	withMeta := func(meta string) string {
+		return strings.Replace(base, `"isDd":false`, `"isDd":false,"meta":`+meta, 1)
+	}
+
+	event, err := DecodeNDJSONLine(withMeta(`{"websocket":{"channel":"chat","connectionId":"c1","clients":3}}`))
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine(websocket) error = %v", err)
+	}
+	var meta websocketMeta
+	if err := event.UnmarshalMeta(&meta); err != nil || meta.Channel != "chat" || meta.ConnectionID != "c1" {
+		t.Fatalf("UnmarshalMeta() = %+v, %v, want channel chat on c1", meta, err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		line string
+		want string
+	}{
+		{"missing block", base, "meta.websocket is required when sourceType is websocket"},
+		{"missing field", withMeta(`{"websocket":{"connectionId":"c1"}}`), "meta.websocket is missing required field: channel"},
+		{"wrong type", withMeta(`{"websocket":{"channel":"chat","connectionId":"c1","clients":"3"}}`), "meta.websocket.clients must be a number"},
+		{"decoder", withMeta(`{"websocket":{"channel":"chat"}}`), "meta.websocket is invalid: connectionId is empty"},
+		{"not an object", withMeta(`[]`), "meta has an invalid type"},
+	} {
+		if _, err := DecodeNDJSONLine(tt.line); err == nil || err.Error() != tt.want {
+			t.Fatalf("DecodeNDJSONLine(%s) error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeNDJSONLine_LiftsBuiltinMetaBlocks(t *testing.T) {
+	v2 := strings.Replace(validCLILine, `"schemaVersion":1`, `"schemaVersion":2`, 1)
+	enveloped := strings.Replace(v2, `"command":{"name":"artisan"},`, `"meta":{"cli":{"name":"queue:work"},"command":{"name":"artisan","args":["migrate"]},"billing":{"plan":"pro"}},`, 1)
+
+	event, issues := DecodeNDJSONLineLenient(enveloped)
+	if event.Command == nil || event.Command.Name != "artisan" || len(event.Command.Args) != 1 {
+		t.Fatalf("DecodeNDJSONLineLenient() command = %+v, want lifted from meta", event.Command)
+	}
+	if _, ok := event.Meta["command"]; ok {
+		t.Fatalf("DecodeNDJSONLineLenient() meta = %v, want command block moved out", event.Meta)
+	}
+	if !hasIssue(issues, "meta.billing", SeverityWarning) || hasIssue(issues, "meta.cli", SeverityWarning) {
+		t.Fatalf("DecodeNDJSONLineLenient() issues = %v, want a warning for billing only", issues)
+	}
+
+	both := strings.Replace(v2, `"isDd":false`, `"isDd":false,"meta":{"command":{"name":"other"}}`, 1)
+	event, issues = DecodeNDJSONLineLenient(both)
+	if event.Command.Name != "artisan" || !hasIssue(issues, "meta.command", SeverityWarning) {
+		t.Fatalf("DecodeNDJSONLineLenient(both) command = %+v, issues = %v, want top-level kept", event.Command, issues)
+	}
+
+	v1 := strings.Replace(validCLILine, `"isDd":false`, `"isDd":false,"meta":{"billing":{"plan":"pro"}}`, 1)
+	if event, err := DecodeNDJSONLine(v1); err != nil || event.Meta != nil {
+		t.Fatalf("DecodeNDJSONLine(v1 with meta) = %v, %v, want meta dropped", event, err)
+	}
+}
+
+func hasIssue(issues []ValidationIssue, field string, severity string) bool {
+	for _, issue := range issues {
+		if issue.Field == field && issue.Severity == severity {
+			return true
+		}
+	}
+	return false
+}
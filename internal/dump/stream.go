@@ -0,0 +1,144 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const DefaultMaxLineBytes = 4 * 1024 * 1024
+
+type StreamOptions struct {
+	// MaxLineBytes caps a single line; longer lines are skipped and reported.
+	MaxLineBytes int
+	// MaxErrors stops decoding after this many line errors; zero means no limit.
+	MaxErrors int
+	Decode    DecodeOptions
+	// Records receives lines of a kind other than event or control, such as
+	// the notes in a session export, instead of decoding them as events.
+	// Without it those lines are decoded as events and usually fail.
+	Records func(kind string, line []byte) error
+}
+
+type LineError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+type StreamResult struct {
+	Events    []Event     `json:"events"`
+	Errors    []LineError `json:"errors"`
+	Lines     int         `json:"lines"`
+	Truncated bool        `json:"truncated"`
+	// Controls counts control records, which are skipped: they steered a
+	// live session and mean nothing once replayed from a file.
+	Controls int `json:"controls"`
+}
+
+// DecodeNDJSONStream decodes every line of r in order. Invalid lines are
+// collected with their 1-based line numbers instead of aborting; the
+// returned error is reserved for read failures.
+func DecodeNDJSONStream(r io.Reader, opts StreamOptions) (StreamResult, error) {
+	if opts.MaxLineBytes <= 0 {
+		opts.MaxLineBytes = DefaultMaxLineBytes
+	}
+
+	result := StreamResult{
+		Events: []Event{},
+		Errors: []LineError{},
+	}
+
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, tooLong, err := readLine(reader, opts.MaxLineBytes)
+		if len(line) > 0 || tooLong || err == nil {
+			result.Lines++
+		}
+
+		switch {
+		case tooLong:
+			result.Errors = append(result.Errors, LineError{
+				Line:    result.Lines,
+				Message: fmt.Sprintf("line exceeds %d bytes", opts.MaxLineBytes),
+			})
+		case len(line) > 0:
+			kind := recordKind(line)
+			if kind == RecordKindControl {
+				result.Controls++
+				break
+			}
+			if kind != RecordKindEvent && opts.Records != nil {
+				if recordErr := opts.Records(kind, line); recordErr != nil {
+					result.Errors = append(result.Errors, LineError{Line: result.Lines, Message: recordErr.Error()})
+				}
+				break
+			}
+			event, decodeErr := decodeLine(bytes.TrimSpace(line), opts.Decode)
+			if decodeErr != nil {
+				result.Errors = append(result.Errors, LineError{Line: result.Lines, Message: decodeErr.Error()})
+			} else if event != nil {
+				result.Events = append(result.Events, *event)
+			}
+		}
+
+		if opts.MaxErrors > 0 && len(result.Errors) >= opts.MaxErrors {
+			result.Truncated = true
+			return result, nil
+		}
+
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+}
+
+// linePool holds the buffers lines longer than the reader's buffer are
+// assembled in.
+var linePool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// readLine returns the next line without its terminator, in a slice of its
+// own that decoded events keep. Lines longer than limit are consumed and
+// discarded, reporting tooLong.
+func readLine(reader *bufio.Reader, limit int) ([]byte, bool, error) {
+	chunk, err := reader.ReadSlice('\n')
+	if !errors.Is(err, bufio.ErrBufferFull) {
+		if len(chunk) > limit+1 {
+			return nil, true, err
+		}
+		return bytes.Clone(bytes.TrimRight(chunk, "\r\n")), false, err
+	}
+
+	buffer := linePool.Get().(*bytes.Buffer)
+	defer linePool.Put(buffer)
+	buffer.Reset()
+	tooLong := false
+
+	for {
+		if !tooLong {
+			if buffer.Len()+len(chunk) > limit+1 {
+				tooLong = true
+			} else {
+				buffer.Write(chunk)
+			}
+		}
+
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			break
+		}
+		chunk, err = reader.ReadSlice('\n')
+	}
+	if tooLong {
+		return nil, true, err
+	}
+	return bytes.Clone(bytes.TrimRight(buffer.Bytes(), "\r\n")), false, err
+}
@@ -0,0 +1,93 @@
+package dump
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodeNDJSONStream_CollectsEventsAndLineErrors(t *testing.T) {
+	input := strings.Join([]string{
+		validCLILine,
+		"",
+		"{",
+		strings.Replace(validCLILine, "01KJHZPFXVA4CNV3K2E12YVYTG", "second", 1),
+	}, "\n")
+
+	result, err := DecodeNDJSONStream(strings.NewReader(input), StreamOptions{})
+	if err != nil {
+		t.Fatalf("DecodeNDJSONStream() error = %v", err)
+	}
+
+	if len(result.Events) != 2 || result.Events[1].ID != "second" {
+		t.Fatalf("result.Events = %+v, want 2 events in order", result.Events)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Fatalf("result.Errors = %+v, want one error on line 3", result.Errors)
+	}
+	if result.Lines != 4 {
+		t.Fatalf("result.Lines = %d, want 4", result.Lines)
+	}
+}
+
+func TestDecodeNDJSONStream_SkipsOversizedLines(t *testing.T) {
+	input := strings.Repeat("x", 200) + "\n" + validCLILine + "\n"
+
+	result, err := DecodeNDJSONStream(strings.NewReader(input), StreamOptions{MaxLineBytes: len(validCLILine)})
+	if err != nil {
+		t.Fatalf("DecodeNDJSONStream() error = %v", err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 1 {
+		t.Fatalf("result.Errors = %+v, want oversized line 1", result.Errors)
+	}
+	if len(result.Events) != 1 {
+		t.Fatalf("result.Events len = %d, want 1", len(result.Events))
+	}
+}
+
+func TestDecodeNDJSONStream_StopsAtMaxErrors(t *testing.T) {
+	result, err := DecodeNDJSONStream(strings.NewReader("{\n{\n{\n"), StreamOptions{MaxErrors: 2})
+	if err != nil {
+		t.Fatalf("DecodeNDJSONStream() error = %v", err)
+	}
+	if !result.Truncated || len(result.Errors) != 2 {
+		t.Fatalf("result = %+v, want truncated after 2 errors", result)
+	}
+}
+
+func TestDecodeNDJSONStream_ReturnsReadErrors(t *testing.T) {
+	readErr := errors.New("disk gone")
+	_, err := DecodeNDJSONStream(iotest.ErrReader(readErr), StreamOptions{})
+	if !errors.Is(err, readErr) {
+		t.Fatalf("DecodeNDJSONStream() error = %v, want %v", err, readErr)
+	}
+}
+
+// benchmarkHTTPLine is a typical framework dump: request metadata, a JSON
+// payload of about 1 KiB, and a ten-frame trace.
+var benchmarkHTTPLine = func() string {
+	var items, frames []string
+	for i := range 10 {
+		items = append(items, fmt.Sprintf(`{"id":%d,"sku":"SKU-%04d","name":"Item \"%d\"","price":%d.99,"tags":["sale","new"]}`, i, i, i, i))
+		frames = append(frames, fmt.Sprintf(`{"file":"/var/www/html/vendor/laravel/framework/src/Illuminate/Pipeline/Pipeline.php","line":%d,"func":"Illuminate\\Pipeline\\Pipeline->handle"}`, 100+i))
+	}
+	return `{"schemaVersion":2,"id":"01KJHZN2B34Y8S97R2M5W12Q9H","timestamp":"2026-02-28T11:20:31.331Z","sourceType":"http","projectRoot":"/home/ada/app","phpSapi":"fpm-fcgi","requestId":"f2a1a3d2-2087-4dc4-9fc4-3f8e75ae3202",` +
+		`"http":{"method":"GET","scheme":"https","host":"app.test","path":"/orders/42","query":"include=items","statusCode":200},"isDd":false,"label":"order","payloadFormat":"json",` +
+		`"payload":{"order":{"id":42,"items":[` + strings.Join(items, ",") + `]}},"trace":[` + strings.Join(frames, ",") + `],"host":{"hostname":"ada-laptop","pid":48211}}`
+}()
+
+func BenchmarkDecodeNDJSONStream(b *testing.B) {
+	const lines = 100_000
+	input := strings.Repeat(benchmarkHTTPLine+"\n", lines)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		result, err := DecodeNDJSONStream(strings.NewReader(input), StreamOptions{})
+		if err != nil || len(result.Events) != lines {
+			b.Fatalf("DecodeNDJSONStream() = %d events, %v", len(result.Events), err)
+		}
+	}
+}
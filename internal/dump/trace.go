@@ -0,0 +1,115 @@
+package dump
+
+import "strings"
+
+const DefaultMaxTraceFrames = 50
+
+// Frame kinds set on TraceFrame.Kind by the consumer.
+const (
+	// FrameApp is code in the project itself.
+	FrameApp = "app"
+	// FrameVendor is code installed by Composer under /vendor/.
+	FrameVendor = "vendor"
+	// FrameInternal is a call into PHP itself, such as array_map, which has
+	// no file location.
+	FrameInternal = "internal"
+)
+
+// ClassifyFrame returns the kind of a real frame; elision markers have none.
+func ClassifyFrame(frame TraceFrame) string {
+	switch {
+	case frame.Elided > 0:
+		return ""
+	case frame.File == "":
+		return FrameInternal
+	case isVendorFrame(frame):
+		return FrameVendor
+	default:
+		return FrameApp
+	}
+}
+
+// ClassifyTrace sets Kind on every frame of the event's trace and exception
+// traces, and records the first application frame as the event's Origin so
+// the UI can collapse vendor frames without losing the callsite. Any kinds
+// the producer sent are overwritten.
+func ClassifyTrace(event *Event) {
+	event.Origin = nil
+	for i := range event.Trace {
+		event.Trace[i].Kind = ClassifyFrame(event.Trace[i])
+		if event.Origin == nil && event.Trace[i].Kind == FrameApp {
+			origin := event.Trace[i]
+			event.Origin = &origin
+		}
+	}
+
+	for exception := event.Exception; exception != nil; exception = exception.Previous {
+		for i := range exception.Trace {
+			exception.Trace[i].Kind = ClassifyFrame(exception.Trace[i])
+		}
+	}
+}
+
+func isVendorFrame(frame TraceFrame) bool {
+	return strings.Contains(frame.File, "/vendor/")
+}
+
+// TruncateTrace keeps at most limit real frames. Application frames are
+// preferred from the top of the stack; the first and last vendor frames are
+// always kept so the framework entry and exit points stay visible. Each run
+// of removed frames is replaced by one marker frame carrying its size.
+func TruncateTrace(trace []TraceFrame, limit int) []TraceFrame {
+	if limit <= 0 || len(trace) <= limit {
+		return trace
+	}
+
+	keep := make([]bool, len(trace))
+	kept := 0
+	mark := func(i int) {
+		if i >= 0 && !keep[i] && kept < limit {
+			keep[i] = true
+			kept++
+		}
+	}
+
+	firstVendor, lastVendor := -1, -1
+	for i, frame := range trace {
+		if isVendorFrame(frame) {
+			if firstVendor < 0 {
+				firstVendor = i
+			}
+			lastVendor = i
+		}
+	}
+
+	mark(0)
+	mark(firstVendor)
+	mark(lastVendor)
+	for i, frame := range trace {
+		if !isVendorFrame(frame) {
+			mark(i)
+		}
+	}
+	for i := range trace {
+		mark(i)
+	}
+
+	result := make([]TraceFrame, 0, limit+1)
+	elided := 0
+	for i, frame := range trace {
+		if !keep[i] {
+			elided++
+			continue
+		}
+		if elided > 0 {
+			result = append(result, TraceFrame{Elided: elided})
+			elided = 0
+		}
+		result = append(result, frame)
+	}
+	if elided > 0 {
+		result = append(result, TraceFrame{Elided: elided})
+	}
+
+	return result
+}
@@ -0,0 +1,98 @@
+package dump
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTruncateTrace_KeepsAppFramesAndVendorBoundaries(t *testing.T) {
+	trace := []TraceFrame{{File: "/app/app/Http/Controllers/UserController.php", Line: 10}}
+	for i := 0; i < 6; i++ {
+		trace = append(trace, TraceFrame{File: fmt.Sprintf("/app/vendor/laravel/framework/src/F%d.php", i), Line: i})
+	}
+	trace = append(trace, TraceFrame{File: "/app/routes/web.php", Line: 5})
+	trace = append(trace, TraceFrame{File: "/app/vendor/laravel/framework/src/Last.php", Line: 99})
+	trace = append(trace, TraceFrame{File: "/app/public/index.php", Line: 1})
+
+	got := TruncateTrace(trace, 5)
+
+	want := []TraceFrame{
+		trace[0],
+		trace[1],
+		{Elided: 5},
+		trace[7],
+		trace[8],
+		trace[9],
+	}
+	if len(got) != len(want) {
+		t.Fatalf("TruncateTrace() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("TruncateTrace()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTruncateTrace_ShortTraceUnchanged(t *testing.T) {
+	trace := []TraceFrame{{File: "/app/a.php"}, {File: "/app/b.php"}}
+	if got := TruncateTrace(trace, 2); len(got) != 2 {
+		t.Fatalf("TruncateTrace() len = %d, want 2", len(got))
+	}
+}
+
+func TestTruncateTrace_FillsBudgetWithVendorFramesFromTop(t *testing.T) {
+	var trace []TraceFrame
+	for i := 0; i < 10; i++ {
+		trace = append(trace, TraceFrame{File: fmt.Sprintf("/app/vendor/pkg/F%d.php", i)})
+	}
+
+	got := TruncateTrace(trace, 4)
+
+	frames := 0
+	for _, frame := range got {
+		if frame.Elided == 0 {
+			frames++
+		}
+	}
+	if frames != 4 {
+		t.Fatalf("TruncateTrace() kept %d real frames, want 4: %+v", frames, got)
+	}
+	if got[len(got)-1] != trace[9] {
+		t.Fatalf("TruncateTrace() last frame = %+v, want last vendor frame", got[len(got)-1])
+	}
+}
+
+func TestClassifyTrace_SetsKindsAndOrigin(t *testing.T) {
+	event := Event{
+		Trace: []TraceFrame{
+			{Func: "array_map"},
+			{File: "/app/vendor/laravel/framework/src/Collection.php", Line: 7, Kind: FrameApp},
+			{File: "/app/app/Cart.php", Line: 12},
+			{Elided: 3},
+			{File: "/app/public/index.php", Line: 1},
+		},
+		Exception: &ExceptionMeta{Class: "E", Trace: []TraceFrame{{File: "/app/vendor/x.php"}}},
+	}
+
+	ClassifyTrace(&event)
+
+	want := []string{FrameInternal, FrameVendor, FrameApp, "", FrameApp}
+	for i, kind := range want {
+		if event.Trace[i].Kind != kind {
+			t.Fatalf("ClassifyTrace() frame %d kind = %q, want %q", i, event.Trace[i].Kind, kind)
+		}
+	}
+	if event.Origin == nil || event.Origin.File != "/app/app/Cart.php" {
+		t.Fatalf("ClassifyTrace() origin = %+v, want /app/app/Cart.php", event.Origin)
+	}
+	if event.Exception.Trace[0].Kind != FrameVendor {
+		t.Fatalf("ClassifyTrace() exception frame kind = %q, want vendor", event.Exception.Trace[0].Kind)
+	}
+
+	vendorOnly := Event{Trace: []TraceFrame{{File: "/app/vendor/x.php"}}}
+	ClassifyTrace(&vendorOnly)
+	if vendorOnly.Origin != nil {
+		t.Fatalf("ClassifyTrace() origin = %+v, want nil without application frames", vendorOnly.Origin)
+	}
+}
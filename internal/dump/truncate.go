@@ -0,0 +1,214 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// minStringBudget is the smallest room worth spending on a cut-down string;
+// below it the value is dropped instead.
+const minStringBudget = 16
+
+const truncationEllipsis = "…"
+
+// LimitPayload truncates the payload to at most limit bytes of valid JSON and
+// marks the event. It returns the original payload when it was cut so the
+// caller can keep a full copy elsewhere.
+func (e *Event) LimitPayload(limit int) (json.RawMessage, bool) {
+	if limit <= 0 || len(e.Payload) <= limit {
+		return nil, false
+	}
+
+	truncated, ok := TruncatePayload(e.Payload, limit)
+	if !ok {
+		return nil, false
+	}
+
+	original := e.Payload
+	e.Payload = truncated
+	e.Truncated = true
+	e.OriginalBytes = len(original)
+	e.Warnings = append(e.Warnings, Warning{
+		Field:   "payload",
+		Message: fmt.Sprintf("payload truncated from %d to %d bytes", len(original), len(truncated)),
+	})
+	return original, true
+}
+
+// OffloadPayload replaces a payload larger than limit with a preview of at
+// most preview bytes, for a caller that keeps the payload elsewhere, and
+// returns the payload it replaced. Unlike LimitPayload it adds no warning:
+// nothing is lost.
+func (e *Event) OffloadPayload(limit int, preview int) (json.RawMessage, bool) {
+	if limit <= 0 || len(e.Payload) <= limit {
+		return nil, false
+	}
+
+	shown, ok := TruncatePayload(e.Payload, preview)
+	if !ok {
+		return nil, false
+	}
+
+	original := e.Payload
+	e.Payload = shown
+	e.Offloaded = true
+	if e.OriginalBytes == 0 {
+		e.OriginalBytes = len(original)
+	}
+	return original, true
+}
+
+type truncFrame struct {
+	object  bool
+	items   int
+	wantKey bool
+	keyMark int
+}
+
+// TruncatePayload re-encodes payload compactly, keeping leading members of
+// objects and arrays and cutting long strings, until limit bytes are used.
+// Open containers are closed so the result is always valid JSON.
+func TruncatePayload(payload json.RawMessage, limit int) (json.RawMessage, bool) {
+	if len(payload) <= limit {
+		return payload, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var out bytes.Buffer
+	var stack []truncFrame
+
+	room := func(extra int) bool {
+		return out.Len()+extra+len(stack) <= limit
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+
+		var top *truncFrame
+		if len(stack) > 0 {
+			top = &stack[len(stack)-1]
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			out.WriteByte(byte(delim))
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				stack[len(stack)-1].items++
+			}
+			continue
+		}
+
+		separator := ""
+		if top != nil && (!top.object || top.wantKey) && top.items > 0 {
+			separator = ","
+		}
+
+		if top != nil && top.object && top.wantKey {
+			key, _ := json.Marshal(token)
+			if !room(len(separator) + len(key) + 1) {
+				break
+			}
+			top.keyMark = out.Len()
+			out.WriteString(separator)
+			out.Write(key)
+			out.WriteByte(':')
+			top.wantKey = false
+			continue
+		}
+
+		if delim, ok := token.(json.Delim); ok {
+			if !room(len(separator) + 2) {
+				break
+			}
+			out.WriteString(separator)
+			out.WriteByte(byte(delim))
+			if top != nil && top.object {
+				top.wantKey = true
+			}
+			stack = append(stack, truncFrame{object: delim == '{', wantKey: delim == '{', keyMark: -1})
+			continue
+		}
+
+		encoded, _ := json.Marshal(token)
+		if !room(len(separator) + len(encoded)) {
+			if text, ok := token.(string); ok {
+				budget := limit - out.Len() - len(separator) - len(stack)
+				if cut, ok := cutString(text, budget); ok {
+					out.WriteString(separator)
+					out.Write(cut)
+					if top != nil && top.object {
+						top.keyMark = -1
+					}
+				}
+			}
+			break
+		}
+
+		out.WriteString(separator)
+		out.Write(encoded)
+		if top != nil {
+			top.items++
+			if top.object {
+				top.wantKey = true
+				top.keyMark = -1
+			}
+		}
+	}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if top.object && !top.wantKey && top.keyMark >= 0 {
+			out.Truncate(top.keyMark)
+		}
+		if top.object {
+			out.WriteByte('}')
+		} else {
+			out.WriteByte(']')
+		}
+		stack = stack[:len(stack)-1]
+		if len(stack) > 0 {
+			stack[len(stack)-1].items++
+			if stack[len(stack)-1].object {
+				stack[len(stack)-1].wantKey = true
+				stack[len(stack)-1].keyMark = -1
+			}
+		}
+	}
+
+	if out.Len() == 0 {
+		return json.RawMessage(`null`), true
+	}
+	return json.RawMessage(out.Bytes()), true
+}
+
+// cutString encodes the longest rune-aligned prefix of text, followed by an
+// ellipsis, that fits in budget bytes.
+func cutString(text string, budget int) ([]byte, bool) {
+	if budget < minStringBudget {
+		return nil, false
+	}
+
+	end := min(len(text), budget)
+	for end > 0 {
+		for end > 0 && end < len(text) && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		encoded, _ := json.Marshal(text[:end] + truncationEllipsis)
+		if len(encoded) <= budget {
+			return encoded, true
+		}
+		end -= max(1, len(encoded)-budget)
+	}
+	return nil, false
+}
@@ -0,0 +1,75 @@
+package dump
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTruncatePayload_StaysValidJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		limit   int
+		want    string
+	}{
+		{name: "fits", payload: `{"a":1}`, limit: 10, want: `{"a":1}`},
+		{name: "drops trailing array items", payload: `[1,2,3,4,5,6,7,8,9]`, limit: 8, want: `[1,2,3]`},
+		{name: "drops key without value", payload: `{"a":1,"b":123456789}`, limit: 15, want: `{"a":1}`},
+		{name: "keeps key with partial container", payload: `{"a":1,"bbbbbbbb":[1,2,3]}`, limit: 22, want: `{"a":1,"bbbbbbbb":[1]}`},
+		{name: "closes nested containers", payload: `{"user":{"roles":["admin","editor","viewer"]}}`, limit: 30, want: `{"user":{"roles":["admin"]}}`},
+		{name: "cuts long string", payload: `"` + strings.Repeat("x", 100) + `"`, limit: 24, want: `"` + strings.Repeat("x", 19) + `…"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, _ := TruncatePayload(json.RawMessage(test.payload), test.limit)
+			if string(got) != test.want {
+				t.Fatalf("TruncatePayload() = %s, want %s", got, test.want)
+			}
+			if !json.Valid(got) || len(got) > max(test.limit, len(test.payload)) {
+				t.Fatalf("TruncatePayload() = %s, want valid JSON within %d bytes", got, test.limit)
+			}
+		})
+	}
+}
+
+func TestDecodeNDJSONLineWithOptions_MaxPayloadBytes(t *testing.T) {
+	line := strings.Replace(validCLILine, `"payload":{"ok":true}`, `"payload":{"items":[`+strings.Repeat(`"item",`, 200)+`"last"]}`, 1)
+
+	event, err := DecodeNDJSONLineWithOptions(line, DecodeOptions{MaxPayloadBytes: 64})
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLineWithOptions() error = %v", err)
+	}
+	if !event.Truncated || event.OriginalBytes <= 64 || len(event.Payload) > 64 || !json.Valid(event.Payload) {
+		t.Fatalf("event truncated=%v originalBytes=%d payload=%s, want valid payload within 64 bytes", event.Truncated, event.OriginalBytes, event.Payload)
+	}
+
+	spoofed := strings.Replace(validCLILine, `"isDd":false`, `"isDd":false,"truncated":true,"originalBytes":9`, 1)
+	event, err = DecodeNDJSONLine(spoofed)
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine() error = %v", err)
+	}
+	if event.Truncated || event.OriginalBytes != 0 {
+		t.Fatalf("event truncated=%v originalBytes=%d, want producer values discarded", event.Truncated, event.OriginalBytes)
+	}
+}
+
+func TestEvent_OffloadPayloadKeepsPreviewWithoutWarning(t *testing.T) {
+	payload := json.RawMessage(`{"items":[` + strings.Repeat(`"item",`, 200) + `"last"]}`)
+	event := Event{Payload: payload}
+
+	if _, ok := event.OffloadPayload(len(payload), 32); ok {
+		t.Fatalf("OffloadPayload(limit = size) offloaded, want payload kept")
+	}
+	original, ok := event.OffloadPayload(64, 32)
+	if !ok || string(original) != string(payload) {
+		t.Fatalf("OffloadPayload() = %s, %v, want the original payload", original, ok)
+	}
+	if !event.Offloaded || event.Truncated || event.OriginalBytes != len(payload) || len(event.Payload) > 32 || !json.Valid(event.Payload) {
+		t.Fatalf("event = %+v, want an offloaded preview within 32 bytes", event)
+	}
+	if len(event.Warnings) != 0 {
+		t.Fatalf("event.Warnings = %v, want none", event.Warnings)
+	}
+}
@@ -2,23 +2,118 @@ package dump
 
 import "encoding/json"
 
-const SchemaVersion = 1
+const SchemaVersion = 2
+
+// MinSchemaVersion is the oldest producer version still accepted; older
+// events are upgraded to SchemaVersion in memory.
+const MinSchemaVersion = 1
 
 type Event struct {
-	SchemaVersion int             `json:"schemaVersion"`
-	ID            string          `json:"id"`
-	Timestamp     string          `json:"timestamp"`
-	SourceType    string          `json:"sourceType"`
-	ProjectRoot   string          `json:"projectRoot"`
-	PHPSAPI       string          `json:"phpSapi"`
-	RequestID     *string         `json:"requestId"`
-	HTTP          *HTTPMeta       `json:"http,omitempty"`
-	Command       *CommandMeta    `json:"command,omitempty"`
-	IsDD          bool            `json:"isDd"`
+	SchemaVersion int          `json:"schemaVersion"`
+	ID            string       `json:"id"`
+	Timestamp     string       `json:"timestamp"`
+	SourceType    string       `json:"sourceType"`
+	ProjectRoot   string       `json:"projectRoot"`
+	PHPSAPI       string       `json:"phpSapi"`
+	RequestID     *string      `json:"requestId"`
+	HTTP          *HTTPMeta    `json:"http,omitempty"`
+	Command       *CommandMeta `json:"command,omitempty"`
+	Log           *LogMeta     `json:"log,omitempty"`
+	// Meta carries structured metadata for custom source types, keyed by
+	// source type name. Built-in blocks sent here are moved to their fields.
+	Meta map[string]json.RawMessage `json:"meta,omitempty"`
+	IsDD bool                       `json:"isDd"`
+	// Gate marks a dd() whose process waits on its connection to be
+	// released; see package ddgate.
+	Gate          bool            `json:"gate,omitempty"`
+	IsError       bool            `json:"isError,omitempty"`
+	Exception     *ExceptionMeta  `json:"exception,omitempty"`
+	Test          *TestMeta       `json:"test,omitempty"`
+	Measure       *MeasureMeta    `json:"measure,omitempty"`
+	SQL           *SQLMeta        `json:"sql,omitempty"`
+	User          *UserMeta       `json:"user,omitempty"`
+	Job           *JobMeta        `json:"job,omitempty"`
+	Schedule      *ScheduleMeta   `json:"schedule,omitempty"`
 	PayloadFormat string          `json:"payloadFormat"`
 	Payload       json.RawMessage `json:"payload"`
-	Trace         []TraceFrame    `json:"trace"`
-	Host          HostMeta        `json:"host"`
+	// MimeType is the media type of a binary payload (v2), such as
+	// image/png or application/pdf.
+	MimeType   string       `json:"mimeType,omitempty"`
+	Trace      []TraceFrame `json:"trace"`
+	Host       HostMeta     `json:"host"`
+	Label      string       `json:"label,omitempty"`
+	Color      string       `json:"color,omitempty"`
+	Level      string       `json:"level,omitempty"`
+	DurationMs *float64     `json:"durationMs,omitempty"`
+	// TTLSeconds asks the consumer to discard the event that long after its
+	// timestamp, for dumps such as heartbeats that only matter briefly. It
+	// also overrides the retention age limit, so an event may outlive it.
+	TTLSeconds int64 `json:"ttlSeconds,omitempty"`
+	// Expression is the REPL input that produced the dump, echoed above it
+	// in conversation views.
+	Expression string    `json:"expression,omitempty"`
+	Warnings   []Warning `json:"warnings,omitempty"`
+
+	// SlowQuery is set on events with an sql block whose durationMs reached
+	// the slow query threshold when they arrived.
+	SlowQuery bool `json:"slowQuery,omitempty"`
+
+	// Truncated is set when the payload was cut to the configured size limit;
+	// OriginalBytes is the size the producer sent, or for a binary payload the
+	// size of its decoded data.
+	Truncated     bool `json:"truncated,omitempty"`
+	OriginalBytes int  `json:"originalBytes,omitempty"`
+	// Offloaded is set when the payload was moved to compressed storage to
+	// save memory; Payload holds a preview and the consumer loads the rest
+	// on demand.
+	Offloaded bool `json:"offloaded,omitempty"`
+	// PayloadRef names the full payload kept on disk for a truncated or
+	// offloaded event. It is a hash of the payload's content, so session
+	// logs and the ingest journal can still resolve it after a restart.
+	PayloadRef string `json:"payloadRef,omitempty"`
+
+	// OriginalSchemaVersion is the version the producer sent before any
+	// in-memory upgrade.
+	OriginalSchemaVersion int `json:"originalSchemaVersion"`
+
+	// Redacted lists the locations masked by redaction rules, such as
+	// payload.user.password or label. It is empty when nothing was masked.
+	Redacted []string `json:"redacted,omitempty"`
+
+	// RepeatCount is set when consecutive identical dumps were collapsed
+	// into this event, counting the event itself; LastRepeatAt is the
+	// timestamp of the latest repeat.
+	RepeatCount  int    `json:"repeatCount,omitempty"`
+	LastRepeatAt string `json:"lastRepeatAt,omitempty"`
+
+	// Origin is the first application frame of Trace, set by the consumer.
+	// It is nil when every frame is vendor or internal code.
+	Origin *TraceFrame `json:"origin,omitempty"`
+
+	// ReceivedAt is when the collector received the event, recorded only
+	// when the receive clock is enabled. Comparing it with Timestamp shows
+	// how skewed the producing host's clock is.
+	ReceivedAt string `json:"receivedAt,omitempty"`
+
+	// IngestSource names the listener or file the event arrived through,
+	// such as "socket", "tcp:127.0.0.1:23517", or "file:/var/log/app.ndjson".
+	// The consumer sets it; imported events keep the one they were saved
+	// with.
+	IngestSource string `json:"ingestSource,omitempty"`
+
+	// Preview is a one-line summary of the payload for list rows. The
+	// consumer sets it only on events sent to the UI.
+	Preview string `json:"preview,omitempty"`
+
+	// Raw is the producer's original NDJSON line, kept only when
+	// DecodeOptions.RetainRawLines is set so the event can be re-decoded.
+	Raw json.RawMessage `json:"raw,omitempty"`
+}
+
+// Warning is a non-fatal validation finding attached to an accepted event.
+type Warning struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type HTTPMeta struct {
@@ -30,6 +125,10 @@ type HTTPMeta struct {
 	StatusCode *int   `json:"statusCode,omitempty"`
 	ClientIP   string `json:"clientIp,omitempty"`
 	UserAgent  string `json:"userAgent,omitempty"`
+	// Headers and Body (v2) are the request as received, for replaying it.
+	// Producers only send them when asked to capture requests.
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
 }
 
 type CommandMeta struct {
@@ -38,13 +137,100 @@ type CommandMeta struct {
 	Cwd  string   `json:"cwd,omitempty"`
 }
 
+// LogMeta describes a PSR-3 log record; it is required when sourceType is
+// log and the record's level is carried in Event.Level.
+type LogMeta struct {
+	Channel string `json:"channel"`
+	Message string `json:"message"`
+}
+
+// TestMeta identifies the Pest or PHPUnit test that was running when the
+// event was dumped. Dataset names the data set of a parameterized test.
+type TestMeta struct {
+	Framework string `json:"framework,omitempty"`
+	Class     string `json:"class,omitempty"`
+	Name      string `json:"name"`
+	Dataset   string `json:"dataset,omitempty"`
+	File      string `json:"file,omitempty"`
+}
+
+// Measure phases.
+const (
+	MeasureStart = "start"
+	MeasureStop  = "stop"
+)
+
+// MeasureMeta marks an event as the start or stop of a timed block, as
+// ray()->measure() does. Events sharing an ID pair up; MemoryBytes is
+// memory_get_usage() when the event was sent.
+type MeasureMeta struct {
+	ID          string `json:"id"`
+	Phase       string `json:"phase"`
+	Name        string `json:"name,omitempty"`
+	MemoryBytes *int64 `json:"memoryBytes,omitempty"`
+}
+
+// SQLMeta describes a database query, as a framework's query listener
+// reports it; the time it took goes in Event.DurationMs. Bindings is a
+// JSON array for ? placeholders or an object for :name ones.
+type SQLMeta struct {
+	Query      string          `json:"query"`
+	Bindings   json.RawMessage `json:"bindings,omitempty"`
+	Connection string          `json:"connection,omitempty"`
+}
+
+// UserMeta identifies the authenticated user the event was made for.
+type UserMeta struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// JobMeta identifies the queued job a worker event was made by. Attempt
+// counts from 1; zero means the producer did not report it.
+type JobMeta struct {
+	Name    string `json:"name"`
+	Queue   string `json:"queue,omitempty"`
+	Attempt int    `json:"attempt,omitempty"`
+}
+
+// ScheduleMeta identifies the scheduled task a cron event was made by.
+type ScheduleMeta struct {
+	Expression  string `json:"expression"`
+	Description string `json:"description,omitempty"`
+}
+
+// ExceptionMeta describes a reported Throwable. Previous follows
+// Throwable::getPrevious() and is nil at the end of the chain.
+type ExceptionMeta struct {
+	Class    string         `json:"class"`
+	Message  string         `json:"message"`
+	Code     ExceptionCode  `json:"code,omitempty"`
+	File     string         `json:"file,omitempty"`
+	Line     int            `json:"line,omitempty"`
+	Trace    []TraceFrame   `json:"trace,omitempty"`
+	Previous *ExceptionMeta `json:"previous,omitempty"`
+}
+
 type TraceFrame struct {
 	File string `json:"file,omitempty"`
 	Line int    `json:"line,omitempty"`
 	Func string `json:"func,omitempty"`
+
+	// Kind is FrameApp, FrameVendor, or FrameInternal, set by the consumer.
+	Kind string `json:"kind,omitempty"`
+
+	// Elided is set on marker frames inserted by trace truncation and counts
+	// the consecutive frames that were removed at that position.
+	Elided int `json:"elided,omitempty"`
 }
 
 type HostMeta struct {
 	Hostname string `json:"hostname"`
 	PID      int    `json:"pid"`
+	// PPID is the parent process, sent by v2 producers so spawned commands
+	// can be correlated with the process that started them.
+	PPID int `json:"ppid,omitempty"`
+	// SDK names the producer library and its version, such as
+	// phant-laravel/1.4.2.
+	SDK string `json:"sdk,omitempty"`
 }
@@ -0,0 +1,120 @@
+package dump
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ulidSkewTolerance is how far the time embedded in an event ID may be from
+// the event timestamp before the decoder warns about it.
+const ulidSkewTolerance = time.Minute
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	errULIDLength = errors.New("must be 26 characters")
+	errULIDDigit  = errors.New("must use Crockford base32 characters")
+	errULIDRange  = errors.New("timestamp overflows 48 bits")
+)
+
+// ParseULIDTime validates id as a ULID, case-insensitively, and returns the
+// millisecond timestamp in its first ten characters.
+func ParseULIDTime(id string) (time.Time, error) {
+	if len(id) != 26 {
+		return time.Time{}, errULIDLength
+	}
+	for i := 0; i < len(id); i++ {
+		if crockfordValue(id[i]) < 0 {
+			return time.Time{}, errULIDDigit
+		}
+	}
+	// 26 characters hold 130 bits; the leading two must be zero.
+	if crockfordValue(id[0]) > 7 {
+		return time.Time{}, errULIDRange
+	}
+
+	var ms int64
+	for i := 0; i < 10; i++ {
+		ms = ms<<5 | int64(crockfordValue(id[i]))
+	}
+	return time.UnixMilli(ms).UTC(), nil
+}
+
+func crockfordValue(c byte) int {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	return strings.IndexByte(crockfordAlphabet, c)
+}
+
+// inspectID warns about IDs that are not ULIDs, whose ordering among events
+// sharing a timestamp is then only their arrival order, and about ULIDs
+// minted far from the event timestamp.
+func inspectID(event Event, issues *issueList) {
+	if event.ID == "" {
+		return
+	}
+
+	minted, err := ParseULIDTime(event.ID)
+	if err != nil {
+		issues.warn("id", "id is not a ULID: "+err.Error())
+		return
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+	if err != nil {
+		return
+	}
+	if skew := minted.Sub(timestamp).Abs(); skew > ulidSkewTolerance {
+		issues.warn("id", "id timestamp differs from timestamp by "+skew.Round(time.Second).String())
+	}
+}
+
+type orderKey struct {
+	timestamp time.Time
+	ulid      string
+}
+
+type eventOrder struct {
+	events []Event
+	keys   []orderKey
+}
+
+func (o eventOrder) Len() int { return len(o.events) }
+
+func (o eventOrder) Swap(i, j int) {
+	o.events[i], o.events[j] = o.events[j], o.events[i]
+	o.keys[i], o.keys[j] = o.keys[j], o.keys[i]
+}
+
+func (o eventOrder) Less(i, j int) bool {
+	a, b := o.keys[i], o.keys[j]
+	if !a.timestamp.Equal(b.timestamp) {
+		return a.timestamp.Before(b.timestamp)
+	}
+	if (a.ulid == "") != (b.ulid == "") {
+		return a.ulid != ""
+	}
+	return a.ulid < b.ulid
+}
+
+// SortEvents orders events oldest first by timestamp, then by ULID, which
+// is creation order within a producer. At the same timestamp events without
+// a ULID follow those with one and, like events whose timestamp does not
+// parse (sorted first), keep their relative order.
+func SortEvents(events []Event) {
+	SortEventsBy(events, TimeSent)
+}
+
+// SortEventsBy is SortEvents on the given time basis; see Event.Time.
+func SortEventsBy(events []Event, basis string) {
+	keys := make([]orderKey, len(events))
+	for i, event := range events {
+		keys[i].timestamp, _ = time.Parse(time.RFC3339Nano, event.Time(basis))
+		if _, err := ParseULIDTime(event.ID); err == nil {
+			keys[i].ulid = strings.ToUpper(event.ID)
+		}
+	}
+	sort.Stable(eventOrder{events: events, keys: keys})
+}
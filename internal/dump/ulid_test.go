@@ -0,0 +1,57 @@
+package dump
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseULIDTime(t *testing.T) {
+	minted, err := ParseULIDTime("01kjhzpfxva4cnv3k2e12yvytg")
+	if err != nil {
+		t.Fatalf("ParseULIDTime() error = %v", err)
+	}
+	if want := time.Date(2026, 2, 28, 11, 21, 18, 11_000_000, time.UTC); !minted.Equal(want) {
+		t.Fatalf("ParseULIDTime() = %v, want %v", minted, want)
+	}
+
+	for id, want := range map[string]error{
+		"01KJHZPFXV":                 errULIDLength,
+		"01KJHZPFXVA4CNV3K2E12YVYTU": errULIDDigit,
+		"81KJHZPFXVA4CNV3K2E12YVYTG": errULIDRange,
+	} {
+		if _, err := ParseULIDTime(id); err != want {
+			t.Fatalf("ParseULIDTime(%s) error = %v, want %v", id, err, want)
+		}
+	}
+}
+
+func TestDecodeNDJSONLine_WarnsWhenIDTimeIsFarFromTimestamp(t *testing.T) {
+	line := strings.Replace(validCLILine, `"timestamp":"2026-02-28T11:21:18.011Z"`, `"timestamp":"2026-02-28T11:25:18.011Z"`, 1)
+
+	event, err := DecodeNDJSONLine(line)
+	if err != nil {
+		t.Fatalf("DecodeNDJSONLine() error = %v", err)
+	}
+	if len(event.Warnings) != 1 || event.Warnings[0].Message != "id timestamp differs from timestamp by 4m0s" {
+		t.Fatalf("event.Warnings = %+v, want id timestamp warning", event.Warnings)
+	}
+}
+
+func TestSortEvents_BreaksTimestampTiesByULID(t *testing.T) {
+	events := []Event{
+		{ID: "01KJHZPFXVA4CNV3K2E12YVYTH", Timestamp: "2026-02-28T11:21:18.011Z"},
+		{ID: "plain", Timestamp: "2026-02-28T11:21:18.011Z"},
+		{ID: "01KJHZPFXVA4CNV3K2E12YVYTG", Timestamp: "2026-02-28T11:21:18.011Z"},
+		{ID: "01KJHZN2B34Y8S97R2M5W12Q9H", Timestamp: "2026-02-28T11:20:31.331Z"},
+	}
+
+	SortEvents(events)
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	if got, want := strings.Join(ids, " "), "01KJHZN2B34Y8S97R2M5W12Q9H 01KJHZPFXVA4CNV3K2E12YVYTG 01KJHZPFXVA4CNV3K2E12YVYTH plain"; got != want {
+		t.Fatalf("SortEvents() = %s, want %s", got, want)
+	}
+}
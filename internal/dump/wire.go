@@ -0,0 +1,255 @@
+package dump
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// field remembers whether its key was present, whether it was null, and
+// whether its value had the wrong type, so required keys and types can be
+// checked from a single unmarshal of the line.
+type field[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+	Err   error
+}
+
+func (f *field[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	*f = field[T]{Value: zero, Set: true}
+
+	if string(data) == "null" {
+		f.Null = true
+		return nil
+	}
+	if err := json.Unmarshal(data, &f.Value); err != nil {
+		f.Err = err
+	}
+	return nil
+}
+
+// rawValue is a JSON value kept as the bytes it was decoded from. Unlike
+// json.RawMessage it does not copy them: a decoded line belongs to the
+// event made from it, so the payload is a slice of the line.
+type rawValue []byte
+
+func (r *rawValue) UnmarshalJSON(data []byte) error {
+	*r = data
+	return nil
+}
+
+// wireEvent is the producer-facing shape of an event. Consumer-owned fields
+// such as warnings or truncation markers are deliberately absent, so values
+// sent by producers are dropped while decoding.
+type wireEvent struct {
+	SchemaVersion field[int]                        `json:"schemaVersion"`
+	ID            field[string]                     `json:"id"`
+	Timestamp     field[string]                     `json:"timestamp"`
+	SourceType    field[string]                     `json:"sourceType"`
+	ProjectRoot   field[string]                     `json:"projectRoot"`
+	PHPSAPI       field[string]                     `json:"phpSapi"`
+	RequestID     field[string]                     `json:"requestId"`
+	HTTP          field[HTTPMeta]                   `json:"http"`
+	Command       field[CommandMeta]                `json:"command"`
+	Log           field[LogMeta]                    `json:"log"`
+	Meta          field[map[string]json.RawMessage] `json:"meta"`
+	IsDD          field[bool]                       `json:"isDd"`
+	Gate          field[bool]                       `json:"gate"`
+	IsError       field[bool]                       `json:"isError"`
+	Exception     field[ExceptionMeta]              `json:"exception"`
+	Test          field[TestMeta]                   `json:"test"`
+	Measure       field[MeasureMeta]                `json:"measure"`
+	SQL           field[SQLMeta]                    `json:"sql"`
+	User          field[UserMeta]                   `json:"user"`
+	Job           field[JobMeta]                    `json:"job"`
+	Schedule      field[ScheduleMeta]               `json:"schedule"`
+	PayloadFormat field[string]                     `json:"payloadFormat"`
+	Payload       rawValue                          `json:"payload"`
+	MimeType      field[string]                     `json:"mimeType"`
+	Trace         field[[]TraceFrame]               `json:"trace"`
+	Host          field[HostMeta]                   `json:"host"`
+	Label         field[string]                     `json:"label"`
+	Color         field[string]                     `json:"color"`
+	Level         field[string]                     `json:"level"`
+	DurationMs    field[float64]                    `json:"durationMs"`
+	Expression    field[string]                     `json:"expression"`
+	TTLSeconds    field[int64]                      `json:"ttlSeconds"`
+}
+
+func (w *wireEvent) has(key string) bool {
+	switch key {
+	case "schemaVersion":
+		return w.SchemaVersion.Set
+	case "id":
+		return w.ID.Set
+	case "timestamp":
+		return w.Timestamp.Set
+	case "sourceType":
+		return w.SourceType.Set
+	case "projectRoot":
+		return w.ProjectRoot.Set
+	case "phpSapi":
+		return w.PHPSAPI.Set
+	case "requestId":
+		return w.RequestID.Set
+	case "isDd":
+		return w.IsDD.Set
+	case "payloadFormat":
+		return w.PayloadFormat.Set
+	case "payload":
+		return w.Payload != nil
+	case "trace":
+		return w.Trace.Set
+	case "host":
+		return w.Host.Set
+	}
+	return false
+}
+
+// inspect records missing required keys first, then values of the wrong
+// type, in a fixed order so strict decoding reports a stable first error.
+func (w *wireEvent) inspect(issues *issueList) {
+	for _, key := range requiredEventKeys {
+		if !w.has(key) {
+			issues.fail(key, fmt.Errorf("missing required dump event field: %s", key))
+		}
+	}
+
+	if w.RequestID.Err != nil {
+		issues.fail("requestId", errors.New("requestId must be null or string"))
+	}
+	if w.IsDD.Err != nil {
+		issues.fail("isDd", errors.New("isDd must be a boolean"))
+	}
+	if w.Trace.Set && w.Trace.Null {
+		issues.fail("trace", errors.New("trace must be an array"))
+	}
+	if err := w.Trace.Err; err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Type == reflect.TypeOf([]TraceFrame(nil)) {
+			issues.fail("trace", errors.New("trace must be an array"))
+		} else {
+			issues.fail("trace", fmt.Errorf("trace frames are invalid: %w", err))
+		}
+	}
+
+	for _, typed := range []struct {
+		key string
+		err error
+	}{
+		{"schemaVersion", w.SchemaVersion.Err},
+		{"id", w.ID.Err},
+		{"timestamp", w.Timestamp.Err},
+		{"sourceType", w.SourceType.Err},
+		{"projectRoot", w.ProjectRoot.Err},
+		{"phpSapi", w.PHPSAPI.Err},
+		{"http", w.HTTP.Err},
+		{"command", w.Command.Err},
+		{"log", w.Log.Err},
+		{"meta", w.Meta.Err},
+		{"gate", w.Gate.Err},
+		{"isError", w.IsError.Err},
+		{"exception", w.Exception.Err},
+		{"test", w.Test.Err},
+		{"measure", w.Measure.Err},
+		{"sql", w.SQL.Err},
+		{"user", w.User.Err},
+		{"job", w.Job.Err},
+		{"schedule", w.Schedule.Err},
+		{"payloadFormat", w.PayloadFormat.Err},
+		{"mimeType", w.MimeType.Err},
+		{"host", w.Host.Err},
+		{"label", w.Label.Err},
+		{"color", w.Color.Err},
+		{"level", w.Level.Err},
+		{"durationMs", w.DurationMs.Err},
+		{"expression", w.Expression.Err},
+		{"ttlSeconds", w.TTLSeconds.Err},
+	} {
+		if typed.err != nil {
+			issues.fail(typed.key, fmt.Errorf("%s has an invalid type", typed.key))
+		}
+	}
+}
+
+func (w *wireEvent) event() Event {
+	event := Event{
+		SchemaVersion: w.SchemaVersion.Value,
+		ID:            w.ID.Value,
+		Timestamp:     w.Timestamp.Value,
+		SourceType:    w.SourceType.Value,
+		ProjectRoot:   w.ProjectRoot.Value,
+		PHPSAPI:       w.PHPSAPI.Value,
+		IsDD:          w.IsDD.Value,
+		Gate:          w.Gate.Value,
+		IsError:       w.IsError.Value,
+		PayloadFormat: w.PayloadFormat.Value,
+		Payload:       json.RawMessage(w.Payload),
+		MimeType:      w.MimeType.Value,
+		Trace:         w.Trace.Value,
+		Host:          w.Host.Value,
+		Label:         w.Label.Value,
+		Color:         w.Color.Value,
+		Level:         w.Level.Value,
+		Expression:    w.Expression.Value,
+		TTLSeconds:    w.TTLSeconds.Value,
+	}
+
+	if w.Meta.Err == nil && len(w.Meta.Value) > 0 {
+		event.Meta = w.Meta.Value
+	}
+
+	if w.RequestID.Set && !w.RequestID.Null && w.RequestID.Err == nil {
+		requestID := w.RequestID.Value
+		event.RequestID = &requestID
+	}
+	if w.HTTP.Set && !w.HTTP.Null && w.HTTP.Err == nil {
+		http := w.HTTP.Value
+		event.HTTP = &http
+	}
+	if w.Command.Set && !w.Command.Null && w.Command.Err == nil {
+		command := w.Command.Value
+		event.Command = &command
+	}
+	if w.Log.Set && !w.Log.Null && w.Log.Err == nil {
+		log := w.Log.Value
+		event.Log = &log
+	}
+	if w.Exception.Set && !w.Exception.Null && w.Exception.Err == nil {
+		exception := w.Exception.Value
+		event.Exception = &exception
+	}
+	if w.Test.Set && !w.Test.Null && w.Test.Err == nil {
+		test := w.Test.Value
+		event.Test = &test
+	}
+	if w.Measure.Set && !w.Measure.Null && w.Measure.Err == nil {
+		measure := w.Measure.Value
+		event.Measure = &measure
+	}
+	if w.SQL.Set && !w.SQL.Null && w.SQL.Err == nil {
+		sql := w.SQL.Value
+		event.SQL = &sql
+	}
+	if w.User.Set && !w.User.Null && w.User.Err == nil {
+		user := w.User.Value
+		event.User = &user
+	}
+	if w.Job.Set && !w.Job.Null && w.Job.Err == nil {
+		job := w.Job.Value
+		event.Job = &job
+	}
+	if w.Schedule.Set && !w.Schedule.Null && w.Schedule.Err == nil {
+		schedule := w.Schedule.Value
+		event.Schedule = &schedule
+	}
+	if w.DurationMs.Set && !w.DurationMs.Null && w.DurationMs.Err == nil {
+		duration := w.DurationMs.Value
+		event.DurationMs = &duration
+	}
+
+	return event
+}
@@ -0,0 +1,140 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"phant/internal/config"
+)
+
+var (
+	ErrNoEditor       = errors.New("no editor is configured")
+	ErrUnknownEditor  = errors.New("unknown editor")
+	ErrOptionLikeFile = errors.New("file must not start with -")
+)
+
+// presets are command templates for editors that can jump to a line from
+// the command line. {file} and {line} are replaced per argument.
+var presets = map[string]string{
+	"vscode":   "code --goto {file}:{line}",
+	"cursor":   "cursor --goto {file}:{line}",
+	"phpstorm": "phpstorm --line {line} {file}",
+	"sublime":  "subl {file}:{line}",
+	"zed":      "zed {file}:{line}",
+}
+
+// Presets lists the editor names that work without a custom command.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that the editor can produce a command. An empty config is
+// valid and means "not configured".
+func Validate(editor config.EditorConfig) error {
+	if editor.Name == "" && editor.Command == "" {
+		return nil
+	}
+	_, err := Command(editor, "file.php", 1)
+	return err
+}
+
+// Command builds the argv that opens file at line. A custom command wins
+// over the named preset. Arguments are never passed through a shell, and a
+// file starting with - is refused so the editor cannot take it for an
+// option.
+func Command(editor config.EditorConfig, file string, line int) ([]string, error) {
+	if strings.HasPrefix(file, "-") {
+		return nil, fmt.Errorf("%w: %s", ErrOptionLikeFile, file)
+	}
+	template := editor.Command
+	if template == "" {
+		if editor.Name == "" {
+			return nil, ErrNoEditor
+		}
+		preset, ok := presets[editor.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownEditor, editor.Name)
+		}
+		template = preset
+	}
+
+	fields, err := splitFields(template)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("editor command is empty")
+	}
+	if !strings.Contains(template, "{file}") {
+		return nil, errors.New("editor command must contain {file}")
+	}
+
+	line = max(line, 1)
+	replacer := strings.NewReplacer("{file}", file, "{line}", strconv.Itoa(line))
+	argv := make([]string, len(fields))
+	for i, field := range fields {
+		argv[i] = replacer.Replace(field)
+	}
+	return argv, nil
+}
+
+// Open launches the editor without waiting for it to exit.
+func Open(editor config.EditorConfig, file string, line int) error {
+	argv, err := Command(editor, file, line)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// splitFields splits a command template on whitespace, honouring single
+// and double quotes so paths with spaces can be configured.
+func splitFields(template string) ([]string, error) {
+	var fields []string
+	var current strings.Builder
+	var quote rune
+	inField := false
+
+	for _, r := range template {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("editor command has an unterminated quote")
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
@@ -0,0 +1,47 @@
+package editor
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"phant/internal/config"
+)
+
+func TestCommand_UsesPresetOrCustomTemplate(t *testing.T) {
+	tests := []struct {
+		editor config.EditorConfig
+		want   []string
+	}{
+		{config.EditorConfig{Name: "vscode"}, []string{"code", "--goto", "/srv/app/My File.php:12"}},
+		{config.EditorConfig{Name: "phpstorm"}, []string{"phpstorm", "--line", "12", "/srv/app/My File.php"}},
+		{config.EditorConfig{Name: "vscode", Command: `"/opt/My Editor/bin/edit" +{line} {file}`}, []string{"/opt/My Editor/bin/edit", "+12", "/srv/app/My File.php"}},
+	}
+
+	for _, test := range tests {
+		got, err := Command(test.editor, "/srv/app/My File.php", 12)
+		if err != nil {
+			t.Fatalf("Command(%+v) error = %v", test.editor, err)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Fatalf("Command(%+v) = %q, want %q", test.editor, got, test.want)
+		}
+	}
+}
+
+func TestCommand_RejectsUnusableConfigs(t *testing.T) {
+	if _, err := Command(config.EditorConfig{}, "a.php", 1); !errors.Is(err, ErrNoEditor) {
+		t.Fatalf("Command(empty) error = %v, want ErrNoEditor", err)
+	}
+	if _, err := Command(config.EditorConfig{Name: "notepad"}, "a.php", 1); !errors.Is(err, ErrUnknownEditor) {
+		t.Fatalf("Command(notepad) error = %v, want ErrUnknownEditor", err)
+	}
+	if _, err := Command(config.EditorConfig{Name: "vscode"}, "--install-extension=evil", 1); !errors.Is(err, ErrOptionLikeFile) {
+		t.Fatalf("Command(option-like file) error = %v, want ErrOptionLikeFile", err)
+	}
+	for _, command := range []string{"edit --line {line}", `edit "{file}`} {
+		if err := Validate(config.EditorConfig{Command: command}); err == nil {
+			t.Fatalf("Validate(%q) error = nil, want error", command)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package expand finds string values in a payload that hold a JSON document,
+// such as an API response stored in a field, and replaces them with the
+// document itself so viewers can render it as a tree.
+package expand
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"phant/internal/jsonpath"
+)
+
+const (
+	// MaxLength bounds the strings worth parsing; longer ones stay strings
+	// to keep the payload API fast.
+	MaxLength = 1 << 20
+	// MaxDepth caps how many times a document encoded inside another one is
+	// expanded in turn.
+	MaxDepth = 4
+	// MaxExpansions caps the strings expanded in one payload.
+	MaxExpansions = 100
+)
+
+// Expansion is a string that held JSON and was replaced by its value. Path
+// is a definite JSONPath to the value in the expanded payload, as
+// jsonpath.Query accepts. Depth is 1 for a string in the payload itself and
+// one more for each document it was encoded in. Bytes is the length of the
+// string it replaced.
+type Expansion struct {
+	Path  string `json:"path"`
+	Kind  string `json:"kind"`
+	Depth int    `json:"depth"`
+	Bytes int    `json:"bytes"`
+}
+
+// Kinds of expanded values. Strings holding a bare number, boolean, or
+// string are left alone: they are far more often plain text than JSON.
+const (
+	KindObject = "object"
+	KindArray  = "array"
+)
+
+// Payload returns payload with every string holding a JSON object or array
+// replaced by it, in document order and up to MaxExpansions, with the
+// points where that happened. Object keys are never expanded. The payload is
+// returned unchanged, byte for byte, when nothing was expanded.
+func Payload(payload json.RawMessage) (json.RawMessage, []Expansion, error) {
+	w := walker{expansions: []Expansion{}}
+	if len(payload) == 0 {
+		return payload, w.expansions, nil
+	}
+	if err := w.document(payload, "$", 0); err != nil {
+		return nil, nil, err
+	}
+	if len(w.expansions) == 0 {
+		return payload, w.expansions, nil
+	}
+	return json.RawMessage(w.out.Bytes()), w.expansions, nil
+}
+
+type walker struct {
+	out        bytes.Buffer
+	expansions []Expansion
+}
+
+func (w *walker) document(data []byte, at string, depth int) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := w.value(decoder, at, depth); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("trailing data after JSON value")
+	}
+	return nil
+}
+
+func (w *walker) value(decoder *json.Decoder, at string, depth int) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		if value == '{' {
+			return w.object(decoder, at, depth)
+		}
+		return w.array(decoder, at, depth)
+	case string:
+		if kind := documentKind(value); kind != "" && depth < MaxDepth && len(w.expansions) < MaxExpansions {
+			w.expansions = append(w.expansions, Expansion{Path: at, Kind: kind, Depth: depth + 1, Bytes: len(value)})
+			return w.document([]byte(value), at, depth+1)
+		}
+		return w.encode(value)
+	case json.Number:
+		w.out.WriteString(value.String())
+	case nil:
+		w.out.WriteString("null")
+	default:
+		return w.encode(value)
+	}
+	return nil
+}
+
+func (w *walker) object(decoder *json.Decoder, at string, depth int) error {
+	w.out.WriteByte('{')
+	for first := true; decoder.More(); first = false {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		if !first {
+			w.out.WriteByte(',')
+		}
+		if err := w.encode(key); err != nil {
+			return err
+		}
+		w.out.WriteByte(':')
+		if err := w.value(decoder, at+jsonpath.MemberPath(key), depth); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	w.out.WriteByte('}')
+	return nil
+}
+
+func (w *walker) array(decoder *json.Decoder, at string, depth int) error {
+	w.out.WriteByte('[')
+	for i := 0; decoder.More(); i++ {
+		if i > 0 {
+			w.out.WriteByte(',')
+		}
+		if err := w.value(decoder, at+"["+strconv.Itoa(i)+"]", depth); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	w.out.WriteByte(']')
+	return nil
+}
+
+func (w *walker) encode(value any) error {
+	encoder := json.NewEncoder(&w.out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	// Encode terminates every value with a newline.
+	w.out.Truncate(w.out.Len() - 1)
+	return nil
+}
+
+// documentKind reports whether text is a JSON object or array, and which.
+func documentKind(text string) string {
+	if len(text) < 2 || len(text) > MaxLength {
+		return ""
+	}
+	trimmed := strings.TrimSpace(text)
+	var kind string
+	switch {
+	case strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}"):
+		kind = KindObject
+	case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+		kind = KindArray
+	default:
+		return ""
+	}
+	if !json.Valid([]byte(trimmed)) {
+		return ""
+	}
+	return kind
+}
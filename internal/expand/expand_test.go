@@ -0,0 +1,61 @@
+package expand
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"phant/internal/jsonpath"
+)
+
+func TestPayload_ExpandsEncodedDocuments(t *testing.T) {
+	inner := `{"status":200,"body":"{\"ids\":[1,2]}"}`
+	payload, _ := json.Marshal(map[string]any{
+		"response": inner,
+		"plain":    "not {json}",
+		"number":   "42",
+		"list":     []string{"[true]", "<b>"},
+	})
+
+	expanded, expansions, err := Payload(payload)
+	if err != nil {
+		t.Fatalf("Payload() error = %v", err)
+	}
+	want := `{"list":[[true],"<b>"],"number":"42","plain":"not {json}","response":{"status":200,"body":{"ids":[1,2]}}}`
+	if string(expanded) != want {
+		t.Fatalf("Payload() = %s, want %s", expanded, want)
+	}
+	wantExpansions := []Expansion{
+		{Path: "$.list[0]", Kind: KindArray, Depth: 1, Bytes: 6},
+		{Path: "$.response", Kind: KindObject, Depth: 1, Bytes: len(inner)},
+		{Path: "$.response.body", Kind: KindObject, Depth: 2, Bytes: len(`{"ids":[1,2]}`)},
+	}
+	if !reflect.DeepEqual(expansions, wantExpansions) {
+		t.Fatalf("Payload() expansions = %+v, want %+v", expansions, wantExpansions)
+	}
+	if ids, err := jsonpath.Query(expanded, "$.response.body.ids"); err != nil || string(ids) != "[1,2]" {
+		t.Fatalf("jsonpath.Query(expansion path) = %s, %v, want [1,2]", ids, err)
+	}
+}
+
+func TestPayload_KeepsPayloadWithoutDocuments(t *testing.T) {
+	payload := json.RawMessage(`{ "a": "b",  "n": 1.50 }`)
+	expanded, expansions, err := Payload(payload)
+	if err != nil || string(expanded) != string(payload) || len(expansions) != 0 {
+		t.Fatalf("Payload() = %s, %v, %v, want the payload unchanged", expanded, expansions, err)
+	}
+}
+
+func TestPayload_StopsAtMaxDepth(t *testing.T) {
+	value := any(map[string]any{"leaf": true})
+	for range MaxDepth + 1 {
+		encoded, _ := json.Marshal(value)
+		value = map[string]any{"inner": string(encoded)}
+	}
+	payload, _ := json.Marshal(value)
+
+	_, expansions, err := Payload(payload)
+	if err != nil || len(expansions) != MaxDepth {
+		t.Fatalf("Payload() = %d expansions, %v, want %d", len(expansions), err, MaxDepth)
+	}
+}
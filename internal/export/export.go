@@ -0,0 +1,255 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"phant/internal/annotation"
+	"phant/internal/dump"
+)
+
+// Format identifies a phant session export in its manifest line.
+const Format = "phant-session"
+
+var (
+	ErrNotExport          = errors.New("file is not a phant session export")
+	ErrUnsupportedVersion = errors.New("session export schema version is not supported")
+)
+
+// Record kinds of the lines written between events.
+const (
+	RecordKindNote   = "note"
+	RecordKindMarker = "marker"
+)
+
+var errNoteEventID = errors.New("note record needs an eventId")
+
+// Manifest is the first line of an export. Events follow it, one per line,
+// in the order they were received, with the notes and markers of the
+// timeline written among them.
+type Manifest struct {
+	Format         string `json:"format"`
+	SchemaVersion  int    `json:"schemaVersion"`
+	Events         int    `json:"events"`
+	Notes          int    `json:"notes,omitempty"`
+	Markers        int    `json:"markers,omitempty"`
+	FirstTimestamp string `json:"firstTimestamp,omitempty"`
+	LastTimestamp  string `json:"lastTimestamp,omitempty"`
+	ExportedAt     string `json:"exportedAt"`
+}
+
+// Marker is a named point in the session, such as a section a PHP client
+// started, written before the first event at or after it.
+type Marker struct {
+	Title       string `json:"title"`
+	ProjectRoot string `json:"projectRoot,omitempty"`
+	At          string `json:"at"`
+}
+
+// Timeline is what the user added to a session around its events: notes,
+// pins, and tags on events, and markers between them. Each note is written
+// right after its event, so the export reads in order.
+type Timeline struct {
+	Notes   []annotation.Annotation `json:"notes"`
+	Markers []Marker                `json:"markers"`
+}
+
+type noteRecord struct {
+	Kind string `json:"kind"`
+	annotation.Annotation
+}
+
+type markerRecord struct {
+	Kind string `json:"kind"`
+	Marker
+}
+
+// Result is an export as read back: its events, and the notes and markers
+// written among them.
+type Result struct {
+	dump.StreamResult
+	Timeline
+}
+
+// NewManifest describes events as they would be written at exportedAt.
+func NewManifest(events []dump.Event, exportedAt time.Time) Manifest {
+	manifest := Manifest{
+		Format:        Format,
+		SchemaVersion: dump.SchemaVersion,
+		Events:        len(events),
+		ExportedAt:    exportedAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	var first, last time.Time
+	for _, event := range events {
+		timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+		if err != nil {
+			continue
+		}
+		if first.IsZero() || timestamp.Before(first) {
+			first = timestamp
+			manifest.FirstTimestamp = event.Timestamp
+		}
+		if last.IsZero() || timestamp.After(last) {
+			last = timestamp
+			manifest.LastTimestamp = event.Timestamp
+		}
+	}
+	return manifest
+}
+
+// Write gzip-compresses the manifest followed by events as NDJSON, with the
+// timeline's markers and notes in chronological position. Notes on events
+// that are not exported are left out.
+func Write(w io.Writer, events []dump.Event, timeline Timeline, exportedAt time.Time) (Manifest, error) {
+	manifest := NewManifest(events, exportedAt)
+
+	notes := make(map[string]annotation.Annotation, len(timeline.Notes))
+	for _, note := range timeline.Notes {
+		notes[note.EventID] = note
+	}
+	for _, event := range events {
+		if _, ok := notes[event.ID]; ok {
+			manifest.Notes++
+		}
+	}
+	markers := append([]Marker{}, timeline.Markers...)
+	sort.SliceStable(markers, func(i, j int) bool { return parseTime(markers[i].At).Before(parseTime(markers[j].At)) })
+	manifest.Markers = len(markers)
+
+	compressed := gzip.NewWriter(w)
+	encoder := json.NewEncoder(compressed)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(manifest); err != nil {
+		return Manifest{}, err
+	}
+	for _, event := range events {
+		at := parseTime(event.Timestamp)
+		for len(markers) > 0 && !parseTime(markers[0].At).After(at) {
+			if err := encoder.Encode(markerRecord{Kind: RecordKindMarker, Marker: markers[0]}); err != nil {
+				return Manifest{}, err
+			}
+			markers = markers[1:]
+		}
+
+		// Adapter events may carry no trace, which the decoder would
+		// reject as null on import.
+		if event.Trace == nil {
+			event.Trace = []dump.TraceFrame{}
+		}
+		if err := encoder.Encode(event); err != nil {
+			return Manifest{}, err
+		}
+
+		if note, ok := notes[event.ID]; ok {
+			if err := encoder.Encode(noteRecord{Kind: RecordKindNote, Annotation: note}); err != nil {
+				return Manifest{}, err
+			}
+		}
+	}
+	for _, marker := range markers {
+		if err := encoder.Encode(markerRecord{Kind: RecordKindMarker, Marker: marker}); err != nil {
+			return Manifest{}, err
+		}
+	}
+	if err := compressed.Close(); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, nil
+}
+
+// WriteFile writes an export to path; the file only appears once complete.
+func WriteFile(path string, events []dump.Event, timeline Timeline, exportedAt time.Time) (Manifest, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	manifest, err := Write(tmp, events, timeline, exportedAt)
+	if err != nil {
+		tmp.Close()
+		return Manifest{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, os.Rename(tmp.Name(), path)
+}
+
+// Read checks the manifest and decodes the events through the regular
+// validating decoder, collecting notes and markers on the way. Line numbers
+// in the result count the lines after the manifest.
+func Read(r io.Reader, opts dump.StreamOptions) (Manifest, Result, error) {
+	compressed, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, Result{}, ErrNotExport
+	}
+	defer compressed.Close()
+
+	reader := bufio.NewReaderSize(compressed, 64*1024)
+	header, err := reader.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Manifest{}, Result{}, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(header, &manifest); err != nil || manifest.Format != Format {
+		return Manifest{}, Result{}, ErrNotExport
+	}
+	if manifest.SchemaVersion < dump.MinSchemaVersion || manifest.SchemaVersion > dump.SchemaVersion {
+		return manifest, Result{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, manifest.SchemaVersion)
+	}
+
+	result := Result{Timeline: Timeline{Notes: []annotation.Annotation{}, Markers: []Marker{}}}
+	opts.Records = func(kind string, line []byte) error {
+		switch kind {
+		case RecordKindNote:
+			var record noteRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return err
+			}
+			if record.EventID == "" {
+				return errNoteEventID
+			}
+			result.Notes = append(result.Notes, record.Annotation)
+		case RecordKindMarker:
+			var record markerRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return err
+			}
+			result.Markers = append(result.Markers, record.Marker)
+		default:
+			return fmt.Errorf("unknown record kind %q", kind)
+		}
+		return nil
+	}
+
+	result.StreamResult, err = dump.DecodeNDJSONStream(reader, opts)
+	return manifest, result, err
+}
+
+func ReadFile(path string, opts dump.StreamOptions) (Manifest, Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Manifest{}, Result{}, err
+	}
+	defer file.Close()
+
+	return Read(file, opts)
+}
+
+// parseTime parses an RFC3339Nano timestamp; unparsable ones sort first.
+func parseTime(timestamp string) time.Time {
+	parsed, _ := time.Parse(time.RFC3339Nano, timestamp)
+	return parsed
+}
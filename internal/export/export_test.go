@@ -0,0 +1,152 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"phant/internal/annotation"
+	"phant/internal/dump"
+)
+
+func exportEvent(id int, timestamp string) dump.Event {
+	return dump.Event{
+		SchemaVersion: dump.SchemaVersion,
+		ID:            fmt.Sprintf("evt-%d", id),
+		Timestamp:     timestamp,
+		SourceType:    "cli",
+		ProjectRoot:   "/srv/app",
+		PHPSAPI:       "cli",
+		PayloadFormat: "json",
+		Payload:       []byte(`{"html":"<b>"}`),
+		Command:       &dump.CommandMeta{Name: "artisan"},
+		Host:          dump.HostMeta{Hostname: "dev", PID: 42},
+	}
+}
+
+func TestWriteFile_RoundTripsEventsAndManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.ndjson.gz")
+	events := []dump.Event{
+		exportEvent(1, "2026-01-01T10:00:02Z"),
+		exportEvent(2, "2026-01-01T10:00:01Z"),
+		exportEvent(3, "2026-01-01T10:00:03.5Z"),
+	}
+
+	written, err := WriteFile(path, events, Timeline{}, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if written.Events != 3 || written.FirstTimestamp != "2026-01-01T10:00:01Z" || written.LastTimestamp != "2026-01-01T10:00:03.5Z" {
+		t.Fatalf("WriteFile() manifest = %+v, want 3 events from 10:00:01 to 10:00:03.5", written)
+	}
+
+	manifest, result, err := ReadFile(path, dump.StreamOptions{})
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if manifest != written {
+		t.Fatalf("ReadFile() manifest = %+v, want %+v", manifest, written)
+	}
+	if len(result.Errors) != 0 || len(result.Events) != 3 {
+		t.Fatalf("ReadFile() = %d events, errors %v, want 3 events", len(result.Events), result.Errors)
+	}
+	if result.Events[0].ID != "evt-1" || string(result.Events[2].Payload) != `{"html":"<b>"}` {
+		t.Fatalf("ReadFile() events = %+v, want original order and payloads", result.Events)
+	}
+}
+
+func TestWrite_PlacesNotesAndMarkersInTimelineOrder(t *testing.T) {
+	events := []dump.Event{
+		exportEvent(1, "2026-01-01T10:00:01Z"),
+		exportEvent(2, "2026-01-01T10:00:03Z"),
+	}
+	timeline := Timeline{
+		Notes: []annotation.Annotation{
+			{EventID: "evt-1", Note: "cart is empty here", Pinned: true},
+			{EventID: "evt-9", Note: "not exported"},
+		},
+		Markers: []Marker{
+			{Title: "after", At: "2026-01-01T10:00:05Z"},
+			{Title: "checkout", At: "2026-01-01T10:00:02Z"},
+		},
+	}
+
+	var buf bytes.Buffer
+	written, err := Write(&buf, events, timeline, time.Now())
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if written.Notes != 1 || written.Markers != 2 {
+		t.Fatalf("Write() manifest = %+v, want 1 note and 2 markers", written)
+	}
+
+	raw := bytes.NewReader(buf.Bytes())
+	compressed, err := gzip.NewReader(raw)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	lines, err := io.ReadAll(compressed)
+	if err != nil {
+		t.Fatalf("io.ReadAll() error = %v", err)
+	}
+	var kinds []string
+	for _, line := range bytes.Split(bytes.TrimSpace(lines), []byte("\n"))[1:] {
+		kinds = append(kinds, dump.RecordKind(string(line)))
+	}
+	want := []string{"event", "note", "marker", "event", "marker"}
+	if fmt.Sprint(kinds) != fmt.Sprint(want) {
+		t.Fatalf("Write() lines = %v, want %v", kinds, want)
+	}
+
+	_, result, err := Read(&buf, dump.StreamOptions{})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(result.Errors) != 0 || len(result.Events) != 2 {
+		t.Fatalf("Read() = %d events, errors %v, want 2 events", len(result.Events), result.Errors)
+	}
+	if len(result.Notes) != 1 || result.Notes[0].Note != "cart is empty here" || !result.Notes[0].Pinned {
+		t.Fatalf("Read() notes = %+v, want the pinned note", result.Notes)
+	}
+	if len(result.Markers) != 2 || result.Markers[0].Title != "checkout" {
+		t.Fatalf("Read() markers = %+v, want checkout first", result.Markers)
+	}
+}
+
+func TestRead_ValidatesEvents(t *testing.T) {
+	invalid := exportEvent(2, "2026-01-01T10:00:00Z")
+	invalid.Host.PID = 0
+
+	var buf bytes.Buffer
+	if _, err := Write(&buf, []dump.Event{exportEvent(1, "2026-01-01T10:00:00Z"), invalid}, Timeline{}, time.Now()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	_, result, err := Read(&buf, dump.StreamOptions{})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(result.Events) != 1 || len(result.Errors) != 1 || result.Errors[0].Line != 2 {
+		t.Fatalf("Read() = %d events, errors %v, want 1 event and an error on line 2", len(result.Events), result.Errors)
+	}
+}
+
+func TestRead_RejectsOtherFiles(t *testing.T) {
+	if _, _, err := Read(bytes.NewReader([]byte("{}\n")), dump.StreamOptions{}); !errors.Is(err, ErrNotExport) {
+		t.Fatalf("Read(plain) error = %v, want ErrNotExport", err)
+	}
+
+	var buf bytes.Buffer
+	compressed := gzip.NewWriter(&buf)
+	compressed.Write([]byte(`{"format":"phant-session","schemaVersion":99}` + "\n"))
+	compressed.Close()
+
+	if _, _, err := Read(&buf, dump.StreamOptions{}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("Read(future) error = %v, want ErrUnsupportedVersion", err)
+	}
+}
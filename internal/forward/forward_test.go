@@ -0,0 +1,154 @@
+package forward
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+func forwardEvent(id string, label string) dump.Event {
+	return dump.Event{
+		ID:          id,
+		SourceType:  "http",
+		ProjectRoot: "/srv/app",
+		Label:       label,
+		Payload:     []byte(`{"user":1}`),
+	}
+}
+
+func startForwarder(t *testing.T) *Forwarder {
+	t.Helper()
+
+	f := NewForwarder(Options{InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, MaxAttempts: 3})
+	f.Start()
+	t.Cleanup(f.Stop)
+	return f
+}
+
+func waitForDeliveries(t *testing.T, f *Forwarder, count int) []Delivery {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if deliveries := f.Deliveries(); len(deliveries) >= count {
+			return deliveries
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Deliveries() = %v, want %d entries", f.Deliveries(), count)
+	return nil
+}
+
+func TestForwarder_PostsRenderedSlackMessage(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]string
+		_ = json.Unmarshal(data, &body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	f := startForwarder(t)
+	rule, err := f.Save(Rule{Name: "checkout", Enabled: true, Kind: KindSlack, URL: server.URL, Filter: query.Filter{SourceType: "http"}})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if rule.ID == "" {
+		t.Fatalf("Save() rule ID is empty")
+	}
+
+	f.Handle(forwardEvent("evt-1", "cart"))
+	deliveries := waitForDeliveries(t, f, 1)
+
+	if deliveries[0].Status != StatusDelivered || deliveries[0].EventID != "evt-1" || deliveries[0].Attempts != 1 {
+		t.Fatalf("Deliveries() = %+v, want evt-1 delivered on the first attempt", deliveries)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := `/srv/app http [cart]: {"user":1}`; len(bodies) != 1 || bodies[0]["text"] != want {
+		t.Fatalf("posted bodies = %v, want text %q", bodies, want)
+	}
+}
+
+func TestForwarder_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	f := startForwarder(t)
+	if _, err := f.Save(Rule{Enabled: true, Kind: KindGeneric, URL: server.URL}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	f.Handle(forwardEvent("evt-1", ""))
+	deliveries := waitForDeliveries(t, f, 1)
+
+	if deliveries[0].Status != StatusDelivered || deliveries[0].Attempts != 3 {
+		t.Fatalf("Deliveries() = %+v, want delivered after 3 attempts", deliveries)
+	}
+}
+
+func TestForwarder_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	f := startForwarder(t)
+	if _, err := f.Save(Rule{Enabled: true, Kind: KindDiscord, URL: server.URL}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	f.Handle(forwardEvent("evt-1", ""))
+	deliveries := waitForDeliveries(t, f, 1)
+
+	if deliveries[0].Status != StatusFailed || deliveries[0].StatusCode != http.StatusNotFound || calls.Load() != 1 {
+		t.Fatalf("Deliveries() = %+v after %d calls, want one failed attempt", deliveries, calls.Load())
+	}
+}
+
+func TestForwarder_SkipsDisabledAndUnmatchedRules(t *testing.T) {
+	f := NewForwarder(Options{})
+	_ = f.SetRules([]Rule{
+		{Enabled: false, Kind: KindGeneric, URL: "http://127.0.0.1:1/"},
+		{Enabled: true, Kind: KindGeneric, URL: "http://127.0.0.1:1/", Filter: query.Filter{SourceType: "cli"}},
+	})
+
+	f.Handle(forwardEvent("evt-1", ""))
+
+	if len(f.queue) != 0 {
+		t.Fatalf("queued deliveries = %d, want 0", len(f.queue))
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	tests := []Rule{
+		{Kind: "email", URL: "https://example.test/hook"},
+		{Kind: KindSlack, URL: "example.test/hook"},
+		{Kind: KindSlack, URL: "https://example.test/hook", Template: "{{.Label"},
+		{Kind: KindSlack, URL: "https://example.test/hook", Filter: query.Filter{SourceType: "mail"}},
+	}
+	for _, rule := range tests {
+		if err := rule.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) error = nil, want error", rule)
+		}
+	}
+}
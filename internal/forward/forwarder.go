@@ -0,0 +1,341 @@
+package forward
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	DefaultMaxAttempts    = 5
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultQueueDepth     = 256
+	DefaultTimeout        = 10 * time.Second
+	DefaultLogSize        = 200
+)
+
+const (
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+	// StatusDropped means the delivery queue was full.
+	StatusDropped = "dropped"
+)
+
+type Options struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	QueueDepth     int
+	Timeout        time.Duration
+	LogSize        int
+}
+
+// Delivery is the final outcome of forwarding one event to one rule.
+type Delivery struct {
+	RuleID     string `json:"ruleId"`
+	RuleName   string `json:"ruleName"`
+	EventID    string `json:"eventId"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	Attempts   int    `json:"attempts"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	At         string `json:"at"`
+}
+
+type job struct {
+	rule     compiledRule
+	eventID  string
+	body     []byte
+	attempts int
+}
+
+// Forwarder posts matching events to webhooks off the ingest path. Failed
+// attempts are retried with exponential backoff without holding up other
+// deliveries.
+type Forwarder struct {
+	client *http.Client
+	opts   Options
+
+	mu    sync.RWMutex
+	rules []compiledRule
+
+	logMu sync.Mutex
+	log   []Delivery
+	next  int
+
+	queue   chan job
+	runMu   sync.Mutex
+	stopped chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewForwarder(opts Options) *Forwarder {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = DefaultInitialBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	if opts.QueueDepth <= 0 {
+		opts.QueueDepth = DefaultQueueDepth
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.LogSize <= 0 {
+		opts.LogSize = DefaultLogSize
+	}
+
+	return &Forwarder{
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+		queue:  make(chan job, opts.QueueDepth),
+	}
+}
+
+// Start runs the delivery worker. Rules and the delivery log outlive it, so
+// the forwarder can be stopped and started again with the collector.
+func (f *Forwarder) Start() {
+	f.runMu.Lock()
+	defer f.runMu.Unlock()
+
+	if f.stopped != nil {
+		return
+	}
+	stopped := make(chan struct{})
+	f.stopped = stopped
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for {
+			select {
+			case <-stopped:
+				return
+			case j := <-f.queue:
+				f.deliver(j, stopped)
+			}
+		}
+	}()
+}
+
+// Stop waits for an in-flight attempt; queued deliveries wait for the next
+// Start and pending retries are abandoned.
+func (f *Forwarder) Stop() {
+	f.runMu.Lock()
+	defer f.runMu.Unlock()
+
+	if f.stopped == nil {
+		return
+	}
+	close(f.stopped)
+	f.wg.Wait()
+	f.stopped = nil
+}
+
+func (f *Forwarder) Rules() []Rule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	rules := make([]Rule, len(f.rules))
+	for i, rule := range f.rules {
+		rules[i] = rule.Rule
+	}
+	return rules
+}
+
+// SetRules replaces every rule; nothing changes if any rule is invalid.
+func (f *Forwarder) SetRules(rules []Rule) error {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.ID == "" {
+			rule.ID = newRuleID()
+		}
+		c, err := rule.compile()
+		if err != nil {
+			return fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		compiled = append(compiled, c)
+	}
+
+	f.mu.Lock()
+	f.rules = compiled
+	f.mu.Unlock()
+	return nil
+}
+
+// Save adds a rule, or replaces the one with the same ID. Rules without an
+// ID are assigned one.
+func (f *Forwarder) Save(rule Rule) (Rule, error) {
+	if rule.ID == "" {
+		rule.ID = newRuleID()
+	}
+	compiled, err := rule.compile()
+	if err != nil {
+		return Rule{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.rules {
+		if f.rules[i].ID == rule.ID {
+			f.rules[i] = compiled
+			return rule, nil
+		}
+	}
+	f.rules = append(f.rules, compiled)
+	return rule, nil
+}
+
+func (f *Forwarder) Delete(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.rules {
+		if f.rules[i].ID == id {
+			f.rules = append(f.rules[:i], f.rules[i+1:]...)
+			return nil
+		}
+	}
+	return ErrRuleNotFound
+}
+
+// Handle queues a delivery for every enabled rule matching event. It never
+// blocks: when the queue is full the delivery is logged as dropped.
+func (f *Forwarder) Handle(event dump.Event) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, rule := range f.rules {
+		if !rule.Enabled || !rule.matcher.Match(event) {
+			continue
+		}
+
+		body, err := rule.body(event)
+		if err != nil {
+			f.record(job{rule: rule, eventID: event.ID}, StatusFailed, 0, err)
+			continue
+		}
+		f.enqueue(job{rule: rule, eventID: event.ID, body: body})
+	}
+}
+
+// Deliveries returns the delivery log, newest first.
+func (f *Forwarder) Deliveries() []Delivery {
+	f.logMu.Lock()
+	defer f.logMu.Unlock()
+
+	deliveries := make([]Delivery, 0, len(f.log))
+	for i := 1; i <= len(f.log); i++ {
+		deliveries = append(deliveries, f.log[(f.next-i+len(f.log))%len(f.log)])
+	}
+	return deliveries
+}
+
+func (f *Forwarder) enqueue(j job) {
+	select {
+	case f.queue <- j:
+	default:
+		f.record(j, StatusDropped, 0, nil)
+	}
+}
+
+func (f *Forwarder) deliver(j job, stopped <-chan struct{}) {
+	j.attempts++
+
+	code, err := f.post(j)
+	switch {
+	case err == nil && code >= 200 && code < 300:
+		f.record(j, StatusDelivered, code, nil)
+		return
+	case err == nil && code != http.StatusTooManyRequests && code < 500:
+		f.record(j, StatusFailed, code, fmt.Errorf("webhook returned %d", code))
+		return
+	case j.attempts >= f.opts.MaxAttempts:
+		if err == nil {
+			err = fmt.Errorf("webhook returned %d", code)
+		}
+		f.record(j, StatusFailed, code, err)
+		return
+	}
+
+	time.AfterFunc(f.backoff(j.attempts), func() {
+		select {
+		case <-stopped:
+		default:
+			f.enqueue(j)
+		}
+	})
+}
+
+func (f *Forwarder) post(j job) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, j.rule.URL, bytes.NewReader(j.body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "phant")
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	return response.StatusCode, nil
+}
+
+func (f *Forwarder) backoff(attempts int) time.Duration {
+	delay := f.opts.InitialBackoff << (attempts - 1)
+	if delay <= 0 || delay > f.opts.MaxBackoff {
+		return f.opts.MaxBackoff
+	}
+	return delay
+}
+
+func (f *Forwarder) record(j job, status string, code int, err error) {
+	delivery := Delivery{
+		RuleID:     j.rule.ID,
+		RuleName:   j.rule.Name,
+		EventID:    j.eventID,
+		URL:        j.rule.URL,
+		Status:     status,
+		Attempts:   j.attempts,
+		StatusCode: code,
+		At:         time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	f.logMu.Lock()
+	defer f.logMu.Unlock()
+
+	if len(f.log) < f.opts.LogSize {
+		f.log = append(f.log, delivery)
+		f.next = len(f.log) % f.opts.LogSize
+		return
+	}
+	f.log[f.next] = delivery
+	f.next = (f.next + 1) % f.opts.LogSize
+}
+
+func newRuleID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,119 @@
+package forward
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"text/template"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+type Kind string
+
+const (
+	// KindGeneric posts the event JSON, or the rendered template as-is.
+	KindGeneric Kind = "generic"
+	// KindSlack posts {"text": ...} for Slack incoming webhooks.
+	KindSlack Kind = "slack"
+	// KindDiscord posts {"content": ...} for Discord webhooks.
+	KindDiscord Kind = "discord"
+)
+
+// DefaultTemplate is used for chat webhooks when a rule has no template.
+const DefaultTemplate = `{{.ProjectRoot}} {{.SourceType}}{{with .Label}} [{{.}}]{{end}}: {{truncate 1000 (printf "%s" .Payload)}}`
+
+var ErrRuleNotFound = errors.New("forwarding rule not found")
+
+// Rule forwards every event matching Filter to URL. Template is a Go
+// text/template rendered with the event.
+type Rule struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	Enabled  bool         `json:"enabled"`
+	Kind     Kind         `json:"kind"`
+	URL      string       `json:"url"`
+	Filter   query.Filter `json:"filter"`
+	Template string       `json:"template"`
+}
+
+type compiledRule struct {
+	Rule
+	matcher  query.Matcher
+	template *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+	"truncate": func(limit int, s string) string {
+		runes := []rune(s)
+		if len(runes) <= limit {
+			return s
+		}
+		return string(runes[:limit]) + "…"
+	},
+}
+
+func (r Rule) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+func (r Rule) compile() (compiledRule, error) {
+	switch r.Kind {
+	case KindGeneric, KindSlack, KindDiscord:
+	default:
+		return compiledRule{}, errors.New("kind must be one of: generic, slack, discord")
+	}
+
+	target, err := url.Parse(r.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return compiledRule{}, errors.New("url must be an absolute http or https URL")
+	}
+
+	matcher, err := r.Filter.Compile()
+	if err != nil {
+		return compiledRule{}, err
+	}
+
+	compiled := compiledRule{Rule: r, matcher: matcher}
+
+	text := r.Template
+	if text == "" && r.Kind != KindGeneric {
+		text = DefaultTemplate
+	}
+	if text != "" {
+		compiled.template, err = template.New(r.ID).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("template: %w", err)
+		}
+	}
+	return compiled, nil
+}
+
+// body renders the request body for event.
+func (c compiledRule) body(event dump.Event) ([]byte, error) {
+	if c.template == nil {
+		return json.Marshal(event)
+	}
+
+	var rendered bytes.Buffer
+	if err := c.template.Execute(&rendered, event); err != nil {
+		return nil, err
+	}
+
+	switch c.Kind {
+	case KindSlack:
+		return json.Marshal(map[string]string{"text": rendered.String()})
+	case KindDiscord:
+		return json.Marshal(map[string]string{"content": rendered.String()})
+	default:
+		return rendered.Bytes(), nil
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package health
+
+import "time"
+
+// processCPUTime is not available here; only memory pressure is monitored.
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
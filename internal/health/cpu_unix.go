@@ -0,0 +1,16 @@
+//go:build unix
+
+package health
+
+import (
+	"syscall"
+	"time"
+)
+
+func processCPUTime() (time.Duration, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
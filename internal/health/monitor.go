@@ -0,0 +1,236 @@
+package health
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+type Level string
+
+const (
+	LevelNormal   Level = "normal"
+	LevelElevated Level = "elevated"
+	LevelCritical Level = "critical"
+)
+
+const (
+	DefaultInterval = 2 * time.Second
+
+	// sustainSamples is how many consecutive samples must agree before the
+	// level changes, so a single GC spike or burst does not flap it.
+	sustainSamples = 3
+	// recoveryRatio is how far below a threshold usage must fall before the
+	// level it triggered is left.
+	recoveryRatio = 0.8
+)
+
+// Thresholds are in percent of one CPU core and bytes of memory obtained from
+// the OS. Zero disables a check.
+type Thresholds struct {
+	ElevatedCPUPercent  float64 `json:"elevatedCpuPercent"`
+	CriticalCPUPercent  float64 `json:"criticalCpuPercent"`
+	ElevatedMemoryBytes uint64  `json:"elevatedMemoryBytes"`
+	CriticalMemoryBytes uint64  `json:"criticalMemoryBytes"`
+}
+
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		ElevatedCPUPercent:  50,
+		CriticalCPUPercent:  90,
+		ElevatedMemoryBytes: 512 << 20,
+		CriticalMemoryBytes: 1 << 30,
+	}
+}
+
+type Sample struct {
+	At          string  `json:"at"`
+	CPUPercent  float64 `json:"cpuPercent"`
+	CPUKnown    bool    `json:"cpuKnown"`
+	MemoryBytes uint64  `json:"memoryBytes"`
+	HeapBytes   uint64  `json:"heapBytes"`
+	Goroutines  int     `json:"goroutines"`
+}
+
+type State struct {
+	Level   Level    `json:"level"`
+	Reasons []string `json:"reasons"`
+	Since   string   `json:"since"`
+	Sample  Sample   `json:"sample"`
+}
+
+// Monitor samples the process's own CPU and memory use and reports a
+// pressure level, calling onChange whenever the level changes.
+type Monitor struct {
+	thresholds Thresholds
+	onChange   func(State)
+
+	mu        sync.Mutex
+	state     State
+	candidate Level
+	streak    int
+	lastCPU   time.Duration
+	lastWall  time.Time
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewMonitor(thresholds Thresholds, onChange func(State)) *Monitor {
+	return &Monitor{
+		thresholds: thresholds,
+		onChange:   onChange,
+		state:      State{Level: LevelNormal, Reasons: []string{}, Since: now()},
+		candidate:  LevelNormal,
+		stopped:    make(chan struct{}),
+	}
+}
+
+func (m *Monitor) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.stopped:
+				return
+			case <-ticker.C:
+				m.Observe(m.sample())
+			}
+		}
+	}()
+}
+
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopped)
+		m.wg.Wait()
+	})
+}
+
+func (m *Monitor) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+func (m *Monitor) SetThresholds(thresholds Thresholds) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.thresholds = thresholds
+}
+
+// Observe feeds one sample into the level state machine.
+func (m *Monitor) Observe(sample Sample) {
+	m.mu.Lock()
+	level, reasons := m.classify(sample)
+	m.state.Sample = sample
+
+	if level == m.state.Level {
+		m.candidate = level
+		m.streak = 0
+		m.state.Reasons = reasons
+		m.mu.Unlock()
+		return
+	}
+
+	if level != m.candidate {
+		m.candidate = level
+		m.streak = 0
+	}
+	m.streak++
+	if m.streak < sustainSamples {
+		m.mu.Unlock()
+		return
+	}
+
+	m.state = State{Level: level, Reasons: reasons, Since: sample.At, Sample: sample}
+	m.streak = 0
+	state := m.state
+	m.mu.Unlock()
+
+	if m.onChange != nil {
+		m.onChange(state)
+	}
+}
+
+// classify returns the level a sample calls for. Leaving a level requires
+// usage to drop below recoveryRatio of the threshold that raised it.
+func (m *Monitor) classify(sample Sample) (Level, []string) {
+	t := m.thresholds
+	current := m.state.Level
+	reasons := []string{}
+
+	above := func(value, threshold float64, held bool) bool {
+		if threshold <= 0 {
+			return false
+		}
+		if held {
+			return value >= threshold*recoveryRatio
+		}
+		return value >= threshold
+	}
+
+	critical := false
+	if sample.CPUKnown && above(sample.CPUPercent, t.CriticalCPUPercent, current == LevelCritical) {
+		critical = true
+		reasons = append(reasons, fmt.Sprintf("cpu %.0f%% of a core", sample.CPUPercent))
+	}
+	if above(float64(sample.MemoryBytes), float64(t.CriticalMemoryBytes), current == LevelCritical) {
+		critical = true
+		reasons = append(reasons, fmt.Sprintf("memory %d MiB", sample.MemoryBytes>>20))
+	}
+	if critical {
+		return LevelCritical, reasons
+	}
+
+	held := current != LevelNormal
+	if sample.CPUKnown && above(sample.CPUPercent, t.ElevatedCPUPercent, held) {
+		reasons = append(reasons, fmt.Sprintf("cpu %.0f%% of a core", sample.CPUPercent))
+	}
+	if above(float64(sample.MemoryBytes), float64(t.ElevatedMemoryBytes), held) {
+		reasons = append(reasons, fmt.Sprintf("memory %d MiB", sample.MemoryBytes>>20))
+	}
+	if len(reasons) > 0 {
+		return LevelElevated, reasons
+	}
+	return LevelNormal, reasons
+}
+
+func (m *Monitor) sample() Sample {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+
+	sample := Sample{
+		At:          now(),
+		MemoryBytes: memory.Sys - memory.HeapReleased,
+		HeapBytes:   memory.HeapAlloc,
+		Goroutines:  runtime.NumGoroutine(),
+	}
+
+	cpu, ok := processCPUTime()
+	wall := time.Now()
+	if ok && !m.lastWall.IsZero() {
+		if elapsed := wall.Sub(m.lastWall); elapsed > 0 {
+			sample.CPUPercent = float64(cpu-m.lastCPU) / float64(elapsed) * 100
+			sample.CPUKnown = true
+		}
+	}
+	m.lastCPU, m.lastWall = cpu, wall
+
+	return sample
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,68 @@
+package health
+
+import "testing"
+
+func memorySample(mib uint64) Sample {
+	return Sample{MemoryBytes: mib << 20}
+}
+
+func TestMonitor_RaisesLevelOnlyAfterSustainedPressure(t *testing.T) {
+	var changes []State
+	monitor := NewMonitor(Thresholds{ElevatedMemoryBytes: 100 << 20, CriticalMemoryBytes: 200 << 20}, func(state State) {
+		changes = append(changes, state)
+	})
+
+	monitor.Observe(memorySample(150))
+	monitor.Observe(memorySample(50))
+	monitor.Observe(memorySample(150))
+	monitor.Observe(memorySample(150))
+	if got := monitor.State().Level; got != LevelNormal {
+		t.Fatalf("State().Level after a broken streak = %q, want %q", got, LevelNormal)
+	}
+
+	monitor.Observe(memorySample(150))
+	if got := monitor.State().Level; got != LevelElevated {
+		t.Fatalf("State().Level = %q, want %q", got, LevelElevated)
+	}
+	if len(changes) != 1 || len(changes[0].Reasons) != 1 {
+		t.Fatalf("onChange calls = %+v, want one with a memory reason", changes)
+	}
+}
+
+func TestMonitor_RecoversBelowHysteresisBand(t *testing.T) {
+	monitor := NewMonitor(Thresholds{ElevatedMemoryBytes: 100 << 20}, nil)
+	for i := 0; i < sustainSamples; i++ {
+		monitor.Observe(memorySample(120))
+	}
+
+	for i := 0; i < sustainSamples; i++ {
+		monitor.Observe(memorySample(90))
+	}
+	if got := monitor.State().Level; got != LevelElevated {
+		t.Fatalf("State().Level inside the recovery band = %q, want %q", got, LevelElevated)
+	}
+
+	for i := 0; i < sustainSamples; i++ {
+		monitor.Observe(memorySample(70))
+	}
+	if got := monitor.State().Level; got != LevelNormal {
+		t.Fatalf("State().Level after recovery = %q, want %q", got, LevelNormal)
+	}
+}
+
+func TestMonitor_CriticalOnCPU(t *testing.T) {
+	monitor := NewMonitor(DefaultThresholds(), nil)
+	for i := 0; i < sustainSamples; i++ {
+		monitor.Observe(Sample{CPUKnown: true, CPUPercent: 120})
+	}
+	if got := monitor.State().Level; got != LevelCritical {
+		t.Fatalf("State().Level = %q, want %q", got, LevelCritical)
+	}
+
+	for i := 0; i < sustainSamples; i++ {
+		monitor.Observe(Sample{CPUPercent: 120})
+	}
+	if got := monitor.State().Level; got != LevelNormal {
+		t.Fatalf("State().Level with unknown CPU = %q, want %q", got, LevelNormal)
+	}
+}
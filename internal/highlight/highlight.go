@@ -0,0 +1,226 @@
+// Package highlight finds code in the string values of a payload, such as
+// a SQL query or an HTML fragment, and splits it into token ranges the
+// frontend colors, so the UI needs no language detection or grammars of its
+// own.
+package highlight
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"phant/internal/jsonpath"
+)
+
+// Languages Detect recognizes.
+const (
+	LanguageSQL      = "sql"
+	LanguageHTML     = "html"
+	LanguageXML      = "xml"
+	LanguagePHP      = "php"
+	LanguageMarkdown = "markdown"
+)
+
+// Token kinds.
+const (
+	KindKeyword   = "keyword"
+	KindString    = "string"
+	KindNumber    = "number"
+	KindComment   = "comment"
+	KindVariable  = "variable"
+	KindTag       = "tag"
+	KindAttribute = "attribute"
+	KindHeading   = "heading"
+	KindCode      = "code"
+	KindEmphasis  = "emphasis"
+	KindLink      = "link"
+)
+
+const (
+	// MinLength and MaxLength bound the strings worth detecting: shorter
+	// ones are rarely code, longer ones are left plain to keep the payload
+	// API fast.
+	MinLength = 12
+	MaxLength = 64 << 10
+	// MaxHints caps the strings highlighted in one payload.
+	MaxHints = 50
+)
+
+// Token is a range of a string to color. Start and End count UTF-16 code
+// units, as JavaScript indexes strings, and End is exclusive.
+type Token struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Kind  string `json:"kind"`
+}
+
+// Hint is a string value holding code. Path is a definite JSONPath to it,
+// as jsonpath.Query accepts.
+type Hint struct {
+	Path     string  `json:"path"`
+	Language string  `json:"language"`
+	Tokens   []Token `json:"tokens"`
+}
+
+var (
+	sqlPattern = regexp.MustCompile(`(?is)^\s*(select\s.+\sfrom\s|select\s+\d|insert\s+into\s|update\s+\S+\s+set\s|delete\s+from\s|` +
+		`create\s+(table|index|view|unique|temporary)\s|alter\s+table\s|drop\s+(table|index|view)\s|with\s+\w+\s+as\s*\()`)
+	htmlPattern  = regexp.MustCompile(`(?i)<(html|head|body|div|span|p|a|ul|ol|li|table|tr|td|th|form|input|script|style|section|article|nav|header|footer|main|h[1-6]|br|img|button|label|select|option|strong|em|pre|code)\b[^>]*>`)
+	closePattern = regexp.MustCompile(`</[A-Za-z][\w:.-]*\s*>`)
+
+	markdownPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^#{1,6}\s+\S`),
+		regexp.MustCompile("(?m)^\\s*```"),
+		regexp.MustCompile(`(?m)^\s*([-*+]|\d+\.)\s+\S`),
+		regexp.MustCompile(`\[[^\]\n]+\]\([^)\s]+\)`),
+		regexp.MustCompile(`\*\*[^*\n]+\*\*|__[^_\n]+__`),
+		regexp.MustCompile("`[^`\n]+`"),
+	}
+)
+
+// Detect returns the language text is written in, or "" for text that does
+// not look like code in a language this package knows.
+func Detect(text string) string {
+	if len(text) < MinLength || len(text) > MaxLength {
+		return ""
+	}
+	trimmed := strings.TrimSpace(text)
+	lower := strings.ToLower(trimmed)
+
+	switch {
+	case strings.Contains(lower, "<?php") || strings.HasPrefix(lower, "<?="):
+		return LanguagePHP
+	case strings.HasPrefix(lower, "<?xml"):
+		return LanguageXML
+	case strings.HasPrefix(lower, "<!doctype html"):
+		return LanguageHTML
+	case strings.HasPrefix(trimmed, "<") && strings.HasSuffix(trimmed, ">"):
+		if htmlPattern.MatchString(trimmed) {
+			return LanguageHTML
+		}
+		if closePattern.MatchString(trimmed) {
+			return LanguageXML
+		}
+	case sqlPattern.MatchString(trimmed):
+		return LanguageSQL
+	}
+
+	// Markdown reads as prose, so it takes two different constructs over
+	// more than one line before a string counts as markdown.
+	if !strings.Contains(trimmed, "\n") {
+		return ""
+	}
+	signals := 0
+	for _, pattern := range markdownPatterns {
+		if pattern.MatchString(trimmed) {
+			signals++
+		}
+	}
+	if signals >= 2 {
+		return LanguageMarkdown
+	}
+	return ""
+}
+
+// Tokenize splits text in language into tokens, in order and without
+// overlaps. Text between tokens is plain.
+func Tokenize(language string, text string) []Token {
+	var tokens []Token
+	switch language {
+	case LanguageSQL:
+		tokens = lexCode(sqlGrammar, text)
+	case LanguagePHP:
+		tokens = lexCode(phpGrammar, text)
+	case LanguageHTML:
+		tokens = lexMarkup(text, true)
+	case LanguageXML:
+		tokens = lexMarkup(text, false)
+	case LanguageMarkdown:
+		tokens = lexMarkdown(text)
+	}
+	if tokens == nil {
+		tokens = []Token{}
+	}
+	toUTF16(text, tokens)
+	return tokens
+}
+
+// Scan returns a hint for every string value in a JSON payload that holds
+// code, in document order, up to MaxHints. Object keys are never
+// highlighted.
+func Scan(payload json.RawMessage) ([]Hint, error) {
+	hints := []Hint{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	if err := scanValue(decoder, "$", &hints); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return hints, nil
+}
+
+func scanValue(decoder *json.Decoder, at string, hints *[]Hint) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		switch value {
+		case '{':
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				name, _ := key.(string)
+				if err := scanValue(decoder, at+jsonpath.MemberPath(name), hints); err != nil {
+					return err
+				}
+			}
+		case '[':
+			for i := 0; decoder.More(); i++ {
+				if err := scanValue(decoder, at+"["+strconv.Itoa(i)+"]", hints); err != nil {
+					return err
+				}
+			}
+		}
+		// The closing delimiter.
+		_, err := decoder.Token()
+		return err
+	case string:
+		if len(*hints) >= MaxHints {
+			return nil
+		}
+		if language := Detect(value); language != "" {
+			*hints = append(*hints, Hint{Path: at, Language: language, Tokens: Tokenize(language, value)})
+		}
+	}
+	return nil
+}
+
+// toUTF16 rewrites token offsets from bytes to UTF-16 code units. Tokens
+// must be ordered and must not overlap, so one pass over text converts
+// them all.
+func toUTF16(text string, tokens []Token) {
+	offset, units := 0, 0
+	advance := func(to int) int {
+		for offset < to && offset < len(text) {
+			r, size := utf8.DecodeRuneInString(text[offset:])
+			units++
+			if r >= 0x10000 {
+				units++
+			}
+			offset += size
+		}
+		return units
+	}
+	for i := range tokens {
+		tokens[i].Start = advance(tokens[i].Start)
+		tokens[i].End = advance(tokens[i].End)
+	}
+}
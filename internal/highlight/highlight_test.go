@@ -0,0 +1,91 @@
+package highlight
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDetect_RecognizesLanguages(t *testing.T) {
+	cases := map[string]string{
+		"select id, name from users where id = ?":                LanguageSQL,
+		"UPDATE orders SET status = 'paid' WHERE id = 4":         LanguageSQL,
+		"<?php echo $user->name; ?>":                             LanguagePHP,
+		`<?xml version="1.0"?><feed><entry/></feed>`:             LanguageXML,
+		"<invoice><total>12.50</total></invoice>":                LanguageXML,
+		`<div class="alert"><p>Saved</p></div>`:                  LanguageHTML,
+		"# Release notes\n\n- fixed **login** for `admin` users": LanguageMarkdown,
+		"Selected from the list of users":                        "",
+		"a plain sentence\n- with one list item":                 "",
+		"short":                                                  "",
+	}
+	for text, want := range cases {
+		if got := Detect(text); got != want {
+			t.Fatalf("Detect(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestTokenize_SQL(t *testing.T) {
+	text := "SELECT * FROM t WHERE a = 'it''s' AND b > 10 -- done"
+	want := []Token{
+		{0, 6, KindKeyword},
+		{9, 13, KindKeyword},
+		{16, 21, KindKeyword},
+		{26, 33, KindString},
+		{34, 37, KindKeyword},
+		{42, 44, KindNumber},
+		{45, 52, KindComment},
+	}
+	if got := Tokenize(LanguageSQL, text); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Tokenize(sql) = %v, want %v", got, want)
+	}
+}
+
+func TestTokenize_MarkupTagsAttributesAndValues(t *testing.T) {
+	text := `<a href="/x">é</a><!-- c -->`
+	want := []Token{
+		{0, 2, KindTag},
+		{3, 7, KindAttribute},
+		{8, 12, KindString},
+		{12, 13, KindTag},
+		{14, 17, KindTag},
+		{17, 18, KindTag},
+		{18, 28, KindComment},
+	}
+	if got := Tokenize(LanguageHTML, text); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Tokenize(html) = %v, want %v", got, want)
+	}
+}
+
+func TestTokenize_CountsUTF16Units(t *testing.T) {
+	text := "<?php $a = '😀'; // ok"
+	want := []Token{
+		{0, 5, KindTag},
+		{6, 8, KindVariable},
+		{11, 15, KindString},
+		{17, 22, KindComment},
+	}
+	if got := Tokenize(LanguagePHP, text); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Tokenize(php) = %v, want %v", got, want)
+	}
+}
+
+func TestScan_FindsCodeInStringValues(t *testing.T) {
+	payload := json.RawMessage(`{"query": {"sql": "select * from users where id = 1", "time": 3.2},
+		"items": ["plain text", "<ul><li>one</li></ul>"], "select * from users where id = 1": true}`)
+
+	hints, err := Scan(payload)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(hints) != 2 {
+		t.Fatalf("Scan() = %+v, want 2 hints", hints)
+	}
+	if hints[0].Path != "$.query.sql" || hints[0].Language != LanguageSQL {
+		t.Fatalf("Scan()[0] = %s %s, want $.query.sql sql", hints[0].Path, hints[0].Language)
+	}
+	if hints[1].Path != "$.items[1]" || hints[1].Language != LanguageHTML {
+		t.Fatalf("Scan()[1] = %s %s, want $.items[1] html", hints[1].Path, hints[1].Language)
+	}
+}
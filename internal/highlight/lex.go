@@ -0,0 +1,338 @@
+package highlight
+
+import (
+	"regexp"
+	"strings"
+)
+
+// grammar describes a C-like language closely enough to color it: its
+// comments, string quotes, keywords, and variable or placeholder sigils.
+type grammar struct {
+	tags          []string
+	lineComments  []string
+	blockComment  [2]string
+	quotes        string
+	doubledQuotes bool
+	keywords      map[string]bool
+	foldCase      bool
+	sigils        string
+}
+
+var sqlGrammar = grammar{
+	lineComments:  []string{"--", "#"},
+	blockComment:  [2]string{"/*", "*/"},
+	quotes:        `'"`,
+	doubledQuotes: true,
+	keywords: words(`select from where and or not in is null like ilike between join inner left right outer full cross on
+		as group by order having limit offset union all distinct insert into values update set delete create table index
+		view alter add column drop primary key foreign references default unique case when then else end exists asc desc
+		with returning count sum avg min max coalesce true false cascade constraint if begin commit rollback transaction
+		for share nowait skip locked lateral using`),
+	foldCase: true,
+	sigils:   ":@$?",
+}
+
+var phpGrammar = grammar{
+	tags:         []string{"<?php", "<?=", "?>"},
+	lineComments: []string{"//", "#"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       `'"`,
+	keywords: words(`abstract and array as break callable case catch class clone const continue declare default do echo
+		else elseif empty enddeclare endfor endforeach endif endswitch endwhile enum extends final finally fn for foreach
+		function global goto if implements include include_once instanceof insteadof interface isset list match namespace
+		new or print private protected public readonly require require_once return static switch throw trait try unset
+		use var while xor yield true false null self parent`),
+	foldCase: true,
+	sigils:   "$",
+}
+
+func words(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(text) {
+		set[word] = true
+	}
+	return set
+}
+
+// lexCode tokenizes text by grammar, with byte offsets.
+func lexCode(g grammar, text string) []Token {
+	var tokens []Token
+	emit := func(start int, end int, kind string) {
+		tokens = append(tokens, Token{Start: start, End: min(end, len(text)), Kind: kind})
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		rest := text[i:]
+
+		if tag := prefix(rest, g.tags); tag != "" {
+			emit(i, i+len(tag), KindTag)
+			i += len(tag)
+			continue
+		}
+		// PHP 8 attributes start with #[ and are not comments.
+		if comment := prefix(rest, g.lineComments); comment != "" && !strings.HasPrefix(rest, "#[") {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			emit(i, i+end, KindComment)
+			i += end
+			continue
+		}
+		if g.blockComment[0] != "" && strings.HasPrefix(rest, g.blockComment[0]) {
+			end := len(rest)
+			if close := strings.Index(rest[len(g.blockComment[0]):], g.blockComment[1]); close >= 0 {
+				end = len(g.blockComment[0]) + close + len(g.blockComment[1])
+			}
+			emit(i, i+end, KindComment)
+			i += end
+			continue
+		}
+		if strings.IndexByte(g.quotes, c) >= 0 {
+			end := quoted(text, i, g.doubledQuotes)
+			emit(i, end, KindString)
+			i = end
+			continue
+		}
+		if isDigit(c) && (i == 0 || !isWord(text[i-1])) {
+			end := i + 1
+			for end < len(text) && (isWord(text[end]) || text[end] == '.') {
+				end++
+			}
+			emit(i, end, KindNumber)
+			i = end
+			continue
+		}
+		if strings.IndexByte(g.sigils, c) >= 0 && (i == 0 || text[i-1] != c) {
+			end := i + 1
+			for end < len(text) && isWord(text[end]) {
+				end++
+			}
+			// A bare ? is a positional placeholder; other sigils need a name.
+			if end > i+1 || c == '?' {
+				emit(i, end, KindVariable)
+				i = end
+				continue
+			}
+		}
+		if isWordStart(c) {
+			end := i + 1
+			for end < len(text) && isWord(text[end]) {
+				end++
+			}
+			word := text[i:end]
+			if g.foldCase {
+				word = strings.ToLower(word)
+			}
+			if g.keywords[word] {
+				emit(i, end, KindKeyword)
+			}
+			i = end
+			continue
+		}
+		i++
+	}
+	return tokens
+}
+
+// quoted returns the end of the string literal starting at start. Quotes
+// are escaped by doubling them or with a backslash.
+func quoted(text string, start int, doubled bool) int {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && !doubled:
+			i++
+		case text[i] == quote:
+			if doubled && i+1 < len(text) && text[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+func prefix(text string, candidates []string) string {
+	for _, candidate := range candidates {
+		if strings.HasPrefix(text, candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isWord(c byte) bool {
+	return isWordStart(c) || isDigit(c)
+}
+
+// lexMarkup tokenizes HTML or XML: tags, attributes and their values,
+// comments, and declarations. HTML script and style bodies are left plain.
+func lexMarkup(text string, html bool) []Token {
+	var tokens []Token
+	emit := func(start int, end int, kind string) {
+		tokens = append(tokens, Token{Start: start, End: min(end, len(text)), Kind: kind})
+	}
+	through := func(from int, close string) int {
+		if end := strings.Index(text[from:], close); end >= 0 {
+			return from + end + len(close)
+		}
+		return len(text)
+	}
+
+	for i := 0; i < len(text); {
+		open := strings.IndexByte(text[i:], '<')
+		if open < 0 {
+			break
+		}
+		i += open
+		rest := text[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := through(i+4, "-->")
+			emit(i, end, KindComment)
+			i = end
+		case strings.HasPrefix(rest, "<![CDATA["):
+			end := through(i+9, "]]>")
+			emit(i, end, KindString)
+			i = end
+		case strings.HasPrefix(rest, "<?"), strings.HasPrefix(rest, "<!"):
+			end := through(i+2, ">")
+			emit(i, end, KindKeyword)
+			i = end
+		case len(rest) > 1 && (rest[1] == '/' || isWordStart(rest[1])):
+			name := tagName(rest)
+			i = lexTag(text, i, emit)
+			if html && (name == "script" || name == "style") && rest[1] != '/' {
+				if end := strings.Index(strings.ToLower(text[i:]), "</"+name); end >= 0 {
+					i += end
+				} else {
+					i = len(text)
+				}
+			}
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// lexTag emits the tokens of the tag starting at start and returns its end.
+func lexTag(text string, start int, emit func(int, int, string)) int {
+	i := start + 1
+	if i < len(text) && text[i] == '/' {
+		i++
+	}
+	for i < len(text) && isName(text[i]) {
+		i++
+	}
+	emit(start, i, KindTag)
+
+	for i < len(text) {
+		switch c := text[i]; {
+		case c == '>':
+			emit(i, i+1, KindTag)
+			return i + 1
+		case c == '/' && i+1 < len(text) && text[i+1] == '>':
+			emit(i, i+2, KindTag)
+			return i + 2
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(text[i+1:], c)
+			if end < 0 {
+				emit(i, len(text), KindString)
+				return len(text)
+			}
+			emit(i, i+end+2, KindString)
+			i += end + 2
+		case isName(c):
+			end := i + 1
+			for end < len(text) && isName(text[end]) {
+				end++
+			}
+			emit(i, end, KindAttribute)
+			i = end
+		default:
+			i++
+		}
+	}
+	return i
+}
+
+var tagNamePattern = regexp.MustCompile(`^</?([A-Za-z][\w:.-]*)`)
+
+// tagName returns the lowercased name of the tag text starts with.
+func tagName(text string) string {
+	if match := tagNamePattern.FindStringSubmatch(text); match != nil {
+		return strings.ToLower(match[1])
+	}
+	return ""
+}
+
+func isName(c byte) bool {
+	return isWord(c) || c == '-' || c == ':' || c == '.'
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^#{1,6}\s`)
+	quotePattern   = regexp.MustCompile(`^\s*>`)
+	listPattern    = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s`)
+	inlinePattern  = regexp.MustCompile("(`[^`]+`)|(\\*\\*[^*]+\\*\\*|__[^_]+__)|(\\[[^\\]]+\\]\\([^)\\s]+\\))")
+)
+
+// lexMarkdown tokenizes markdown a line at a time: headings, quotes, and
+// fenced code blocks color whole lines; list markers, code spans, strong
+// emphasis, and links color their own ranges.
+func lexMarkdown(text string) []Token {
+	var tokens []Token
+	fenced := false
+	for start := 0; start < len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		line := text[start:end]
+
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(line), "```"):
+			fenced = !fenced
+			tokens = append(tokens, Token{Start: start, End: end, Kind: KindCode})
+		case fenced:
+			tokens = append(tokens, Token{Start: start, End: end, Kind: KindCode})
+		case headingPattern.MatchString(line):
+			tokens = append(tokens, Token{Start: start, End: end, Kind: KindHeading})
+		case quotePattern.MatchString(line):
+			tokens = append(tokens, Token{Start: start, End: end, Kind: KindComment})
+		default:
+			from := 0
+			if marker := listPattern.FindStringIndex(line); marker != nil {
+				tokens = append(tokens, Token{Start: start, End: start + marker[1], Kind: KindKeyword})
+				from = marker[1]
+			}
+			for _, match := range inlinePattern.FindAllStringSubmatchIndex(line[from:], -1) {
+				kind := KindLink
+				switch {
+				case match[2] >= 0:
+					kind = KindCode
+				case match[4] >= 0:
+					kind = KindEmphasis
+				}
+				tokens = append(tokens, Token{Start: start + from + match[0], End: start + from + match[1], Kind: kind})
+			}
+		}
+		start = end + 1
+	}
+	return tokens
+}
@@ -0,0 +1,142 @@
+package hook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+func hookEvent(id string) dump.Event {
+	return dump.Event{ID: id, SourceType: "http", ProjectRoot: "/srv/app", Payload: []byte(`{"user":1}`)}
+}
+
+func startRunner(t *testing.T, opts Options, rules ...Rule) *Runner {
+	t.Helper()
+
+	r := NewRunner(opts)
+	if err := r.SetRules(rules); err != nil {
+		t.Fatalf("SetRules() error = %v", err)
+	}
+	r.Start()
+	t.Cleanup(r.Stop)
+	return r
+}
+
+func waitForRuns(t *testing.T, r *Runner, count int) []Run {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if runs := r.Runs(); len(runs) >= count {
+			return runs
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Runs() = %v, want %d entries", r.Runs(), count)
+	return nil
+}
+
+func TestRunner_WritesMatchingEventsToStdin(t *testing.T) {
+	r := startRunner(t, Options{}, Rule{
+		Name:    "echo",
+		Enabled: true,
+		Filter:  query.Filter{SourceType: "http"},
+		Command: []string{"sh", "-c", `cat; echo "$PHANT_HOOK $PHANT_EVENT_ID" >&2`},
+	})
+
+	r.Handle(hookEvent("evt-1"))
+	r.Handle(dump.Event{ID: "evt-2", SourceType: "cli"})
+
+	runs := waitForRuns(t, r, 1)
+	run := runs[0]
+	if run.Status != StatusSucceeded || run.EventID != "evt-1" || run.ExitCode != 0 {
+		t.Fatalf("Runs()[0] = %+v, want a successful run for evt-1", run)
+	}
+
+	line, trailer, _ := strings.Cut(run.Output, "\n")
+	var event dump.Event
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.ID != "evt-1" {
+		t.Fatalf("stdin = %q, want the event JSON line", line)
+	}
+	if trailer != "echo evt-1\n" {
+		t.Fatalf("stderr = %q, want the hook name and event ID from the environment", trailer)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if runs := r.Runs(); len(runs) != 1 {
+		t.Fatalf("Runs() = %+v, want the cli event skipped", runs)
+	}
+}
+
+func TestRunner_ReportsFailuresTimeoutsAndTruncatedOutput(t *testing.T) {
+	r := startRunner(t, Options{OutputBytes: 8},
+		Rule{Name: "fails", Enabled: true, Command: []string{"sh", "-c", "echo 0123456789; exit 3"}},
+		Rule{Name: "hangs", Enabled: true, Command: []string{"sleep", "10"}, Timeout: "100ms"},
+	)
+
+	r.Handle(hookEvent("evt-1"))
+
+	runs := waitForRuns(t, r, 2)
+	byName := map[string]Run{}
+	for _, run := range runs {
+		byName[run.RuleName] = run
+	}
+
+	if run := byName["fails"]; run.Status != StatusFailed || run.ExitCode != 3 || run.Output != "01234567" || !run.Truncated {
+		t.Fatalf("fails = %+v, want exit status 3 with 8 bytes of output", run)
+	}
+	if run := byName["hangs"]; run.Status != StatusTimedOut || run.DurationMs >= 5000 {
+		t.Fatalf("hangs = %+v, want it killed at its timeout", run)
+	}
+}
+
+func TestRunner_DropsRunsWhenTheQueueIsFull(t *testing.T) {
+	r := NewRunner(Options{QueueDepth: 1})
+	if _, err := r.Save(Rule{Name: "cat", Enabled: true, Command: []string{"cat"}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	r.Handle(hookEvent("evt-1"))
+	r.Handle(hookEvent("evt-2"))
+
+	runs := r.Runs()
+	if len(runs) != 1 || runs[0].Status != StatusDropped || runs[0].EventID != "evt-2" {
+		t.Fatalf("Runs() = %+v, want evt-2 dropped", runs)
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	tests := map[string]struct {
+		rule Rule
+		want string
+	}{
+		"command": {Rule{Name: "x"}, "command"},
+		"dir":     {Rule{Name: "x", Command: []string{"cat"}, Dir: "relative"}, "dir"},
+		"timeout": {Rule{Name: "x", Command: []string{"cat"}, Timeout: "1h"}, "timeout"},
+	}
+	for name, test := range tests {
+		if err := test.rule.Validate(); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("Validate(%s) error = %v, want %q", name, err, test.want)
+		}
+	}
+}
+
+func TestSave_RoundTripsThroughLoad(t *testing.T) {
+	dir := t.TempDir()
+	if rules, err := Load(dir); err != nil || rules != nil {
+		t.Fatalf("Load(empty) = %v, %v, want no hooks", rules, err)
+	}
+
+	saved := []Rule{{ID: "a", Name: "jq", Enabled: true, Command: []string{"sh", "-c", "jq .payload"}, Timeout: "30s"}}
+	if err := Save(dir, saved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil || len(loaded) != 1 || loaded[0].Name != "jq" || strings.Join(loaded[0].Command, " ") != "sh -c jq .payload" {
+		t.Fatalf("Load() = %+v, %v, want the saved hook", loaded, err)
+	}
+}
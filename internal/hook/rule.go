@@ -0,0 +1,72 @@
+// Package hook pipes events matching a filter to external commands, one
+// run per event with the event JSON on stdin, so jq pipelines and custom
+// notifiers need no plugin interface.
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"phant/internal/query"
+)
+
+const (
+	DefaultTimeout = 10 * time.Second
+	MaxTimeout     = 5 * time.Minute
+)
+
+var ErrRuleNotFound = errors.New("command hook not found")
+
+// Rule runs Command for every event matching Filter. Command is the
+// program and its arguments and runs without a shell, so a pipeline is
+// written as ["sh", "-c", "jq .payload | ..."]. Dir is the working
+// directory, and Timeout, such as "30s", bounds each run.
+type Rule struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	Enabled bool         `json:"enabled"`
+	Filter  query.Filter `json:"filter"`
+	Command []string     `json:"command"`
+	Dir     string       `json:"dir,omitempty"`
+	Timeout string       `json:"timeout,omitempty"`
+}
+
+type compiledRule struct {
+	Rule
+	matcher query.Matcher
+	timeout time.Duration
+}
+
+func (r Rule) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+func (r Rule) compile() (compiledRule, error) {
+	if len(r.Command) == 0 || r.Command[0] == "" {
+		return compiledRule{}, errors.New("command must name a program")
+	}
+	if r.Dir != "" && !filepath.IsAbs(r.Dir) {
+		return compiledRule{}, errors.New("dir must be an absolute path")
+	}
+
+	matcher, err := r.Filter.Compile()
+	if err != nil {
+		return compiledRule{}, err
+	}
+
+	compiled := compiledRule{Rule: r, matcher: matcher, timeout: DefaultTimeout}
+	if r.Timeout != "" {
+		timeout, err := query.ParseDuration(r.Timeout)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("timeout: %w", err)
+		}
+		if timeout <= 0 || timeout > MaxTimeout {
+			return compiledRule{}, fmt.Errorf("timeout must be positive and at most %s", MaxTimeout)
+		}
+		compiled.timeout = timeout
+	}
+	return compiled, nil
+}
@@ -0,0 +1,348 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	DefaultConcurrency = 2
+	DefaultQueueDepth  = 256
+	DefaultOutputBytes = 16 * 1024
+	DefaultLogSize     = 200
+)
+
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusTimedOut  = "timedOut"
+	// StatusDropped means every runner was busy and the queue was full.
+	StatusDropped = "dropped"
+)
+
+// waitDelay bounds how long a finished or killed command's children may
+// keep its output open, as sh -c pipelines do.
+const waitDelay = time.Second
+
+type Options struct {
+	Concurrency int
+	QueueDepth  int
+	OutputBytes int
+	LogSize     int
+}
+
+// Run is the outcome of running one rule's command for one event. Output
+// is what the command wrote to stdout and stderr, cut to OutputBytes.
+type Run struct {
+	RuleID     string  `json:"ruleId"`
+	RuleName   string  `json:"ruleName"`
+	EventID    string  `json:"eventId"`
+	Status     string  `json:"status"`
+	ExitCode   int     `json:"exitCode"`
+	DurationMs float64 `json:"durationMs"`
+	Output     string  `json:"output,omitempty"`
+	Truncated  bool    `json:"truncated,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	At         string  `json:"at"`
+}
+
+type job struct {
+	rule    compiledRule
+	eventID string
+	input   []byte
+}
+
+// Runner runs hook commands off the ingest path, at most Concurrency at a
+// time. Each gets one event as a JSON line on stdin and PHANT_EVENT_ID and
+// PHANT_HOOK in its environment.
+type Runner struct {
+	opts Options
+
+	mu     sync.RWMutex
+	rules  []compiledRule
+	loader func(dump.Event) dump.Event
+
+	logMu sync.Mutex
+	log   []Run
+	next  int
+
+	queue  chan job
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRunner(opts Options) *Runner {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.QueueDepth <= 0 {
+		opts.QueueDepth = DefaultQueueDepth
+	}
+	if opts.OutputBytes <= 0 {
+		opts.OutputBytes = DefaultOutputBytes
+	}
+	if opts.LogSize <= 0 {
+		opts.LogSize = DefaultLogSize
+	}
+	return &Runner{opts: opts, queue: make(chan job, opts.QueueDepth)}
+}
+
+// SetLoader sets a function that completes an event before it is written
+// to a command, such as one loading a payload kept out of memory.
+func (r *Runner) SetLoader(loader func(dump.Event) dump.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loader = loader
+}
+
+// Start runs the workers. Rules and the run log outlive them, so the
+// runner can be stopped and started again with the collector.
+func (r *Runner) Start() {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	if r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	for range r.opts.Concurrency {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-r.queue:
+					r.run(ctx, j)
+				}
+			}
+		}()
+	}
+}
+
+// Stop kills running commands and waits for them; queued runs wait for the
+// next Start.
+func (r *Runner) Stop() {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+	r.cancel = nil
+}
+
+func (r *Runner) Rules() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]Rule, len(r.rules))
+	for i, rule := range r.rules {
+		rules[i] = rule.Rule
+	}
+	return rules
+}
+
+// SetRules replaces every rule; nothing changes if any rule is invalid.
+func (r *Runner) SetRules(rules []Rule) error {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.ID == "" {
+			rule.ID = newRuleID()
+		}
+		c, err := rule.compile()
+		if err != nil {
+			return fmt.Errorf("hook %q: %w", rule.Name, err)
+		}
+		compiled = append(compiled, c)
+	}
+
+	r.mu.Lock()
+	r.rules = compiled
+	r.mu.Unlock()
+	return nil
+}
+
+// Save adds a rule, or replaces the one with the same ID. Rules without an
+// ID are assigned one.
+func (r *Runner) Save(rule Rule) (Rule, error) {
+	if rule.ID == "" {
+		rule.ID = newRuleID()
+	}
+	compiled, err := rule.compile()
+	if err != nil {
+		return Rule{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.rules {
+		if r.rules[i].ID == rule.ID {
+			r.rules[i] = compiled
+			return rule, nil
+		}
+	}
+	r.rules = append(r.rules, compiled)
+	return rule, nil
+}
+
+func (r *Runner) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.rules {
+		if r.rules[i].ID == id {
+			r.rules = append(r.rules[:i], r.rules[i+1:]...)
+			return nil
+		}
+	}
+	return ErrRuleNotFound
+}
+
+// Handle queues a run for every enabled rule matching event. It never
+// blocks: when the queue is full the run is logged as dropped.
+func (r *Runner) Handle(event dump.Event) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var input []byte
+	for _, rule := range r.rules {
+		if !rule.Enabled || !rule.matcher.Match(event) {
+			continue
+		}
+
+		if input == nil {
+			if r.loader != nil {
+				event = r.loader(event)
+			}
+			encoded, err := json.Marshal(event)
+			if err != nil {
+				r.record(job{rule: rule, eventID: event.ID}, Run{Status: StatusFailed, Error: err.Error()})
+				return
+			}
+			input = append(encoded, '\n')
+		}
+
+		j := job{rule: rule, eventID: event.ID, input: input}
+		select {
+		case r.queue <- j:
+		default:
+			r.record(j, Run{Status: StatusDropped})
+		}
+	}
+}
+
+// Runs returns the run log, newest first.
+func (r *Runner) Runs() []Run {
+	r.logMu.Lock()
+	defer r.logMu.Unlock()
+
+	runs := make([]Run, 0, len(r.log))
+	for i := 1; i <= len(r.log); i++ {
+		runs = append(runs, r.log[(r.next-i+len(r.log))%len(r.log)])
+	}
+	return runs
+}
+
+func (r *Runner) run(ctx context.Context, j job) {
+	ctx, cancel := context.WithTimeout(ctx, j.rule.timeout)
+	defer cancel()
+
+	output := &limitedBuffer{limit: r.opts.OutputBytes}
+	cmd := exec.CommandContext(ctx, j.rule.Command[0], j.rule.Command[1:]...)
+	cmd.Dir = j.rule.Dir
+	cmd.Env = append(os.Environ(), "PHANT_EVENT_ID="+j.eventID, "PHANT_HOOK="+j.rule.Name)
+	cmd.Stdin = bytes.NewReader(j.input)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = waitDelay
+
+	started := time.Now()
+	err := cmd.Run()
+	result := Run{
+		Status:     StatusSucceeded,
+		ExitCode:   cmd.ProcessState.ExitCode(),
+		DurationMs: float64(time.Since(started).Microseconds()) / 1000,
+		Output:     output.String(),
+		Truncated:  output.truncated,
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Status = StatusTimedOut
+		result.Error = fmt.Sprintf("killed after %s", j.rule.timeout)
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.Status = StatusFailed
+		result.Error = fmt.Sprintf("exited with status %d", result.ExitCode)
+	default:
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	r.record(j, result)
+}
+
+func (r *Runner) record(j job, run Run) {
+	run.RuleID = j.rule.ID
+	run.RuleName = j.rule.Name
+	run.EventID = j.eventID
+	run.At = time.Now().UTC().Format(time.RFC3339Nano)
+
+	r.logMu.Lock()
+	defer r.logMu.Unlock()
+
+	if len(r.log) < r.opts.LogSize {
+		r.log = append(r.log, run)
+		r.next = len(r.log) % r.opts.LogSize
+		return
+	}
+	r.log[r.next] = run
+	r.next = (r.next + 1) % r.opts.LogSize
+}
+
+// limitedBuffer keeps the first limit bytes written to it. exec writes to
+// it from one goroutine at a time when it is both stdout and stderr. The
+// buffer is not embedded: io.Copy would use its ReadFrom and skip Write.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+func newRuleID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,233 @@
+// Package hosts tracks the machines and containers sending dumps, with
+// per-host activity and the display settings the user gives each one.
+package hosts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// rateSeconds is the trailing window, in seconds, EventsPerMinute is
+// counted over.
+const rateSeconds = 60
+
+var ErrEmptyHostname = errors.New("hostname is required")
+
+// Settings are what the user controls per host. Muted hosts keep being
+// collected and stored but are left out of the live feed.
+type Settings struct {
+	Color string `json:"color"`
+	Muted bool   `json:"muted"`
+}
+
+func (s Settings) Validate() error {
+	if s.Color != "" && !dump.ValidColor(s.Color) {
+		return errors.New("host color must be a named color or #rgb/#rrggbb hex value")
+	}
+	return nil
+}
+
+func (s Settings) isZero() bool {
+	return s.Color == "" && !s.Muted
+}
+
+// Host is a hostname seen in incoming events or configured by the user.
+// ClockSkewMs is how far the host's clock ran behind the collector's for
+// its latest event, negative when it runs ahead; it includes delivery
+// latency, which is small on a local socket.
+type Host struct {
+	Hostname string `json:"hostname"`
+	Settings
+	FirstSeen       string   `json:"firstSeen"`
+	LastSeen        string   `json:"lastSeen"`
+	Events          int      `json:"events"`
+	EventsPerMinute int      `json:"eventsPerMinute"`
+	Projects        []string `json:"projects"`
+	SDK             string   `json:"sdk,omitempty"`
+	ClockSkewMs     int64    `json:"clockSkewMs"`
+}
+
+type host struct {
+	Host
+	projects map[string]bool
+	// seconds and counts bucket recent arrivals by Unix second.
+	seconds [rateSeconds]int64
+	counts  [rateSeconds]int
+}
+
+// Registry holds every host. Settings are kept for hostnames that have not
+// been seen yet so an imported configuration applies on arrival.
+type Registry struct {
+	now func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+func New() *Registry {
+	return &Registry{now: time.Now, hosts: make(map[string]*host)}
+}
+
+// Observe records an event received at receivedAt, or at its ReceivedAt
+// stamp when the collector recorded one, registering its host if needed. It
+// reports whether the host is new.
+func (r *Registry) Observe(event dump.Event, receivedAt time.Time) bool {
+	hostname := event.Host.Hostname
+	if hostname == "" {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, known := r.hosts[hostname]
+	if !known {
+		entry = r.register(hostname)
+	}
+	if entry.FirstSeen == "" {
+		entry.FirstSeen = event.Timestamp
+	}
+	entry.LastSeen = event.Timestamp
+	entry.Events++
+	if event.ProjectRoot != "" {
+		entry.projects[event.ProjectRoot] = true
+	}
+	if event.Host.SDK != "" {
+		entry.SDK = event.Host.SDK
+	}
+	if stamped, err := time.Parse(time.RFC3339Nano, event.ReceivedAt); err == nil {
+		receivedAt = stamped
+	}
+	if sent, err := time.Parse(time.RFC3339Nano, event.Timestamp); err == nil {
+		entry.ClockSkewMs = receivedAt.Sub(sent).Milliseconds()
+	}
+
+	second := receivedAt.Unix()
+	slot := second % rateSeconds
+	if entry.seconds[slot] != second {
+		entry.seconds[slot] = second
+		entry.counts[slot] = 0
+	}
+	entry.counts[slot]++
+	return !known
+}
+
+// Hosts lists hosts by hostname.
+func (r *Registry) Hosts() []Host {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now().Unix()
+	hosts := make([]Host, 0, len(r.hosts))
+	for _, entry := range r.hosts {
+		hosts = append(hosts, entry.view(now))
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Hostname < hosts[j].Hostname })
+	return hosts
+}
+
+func (r *Registry) Host(hostname string) (Host, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.hosts[hostname]
+	if !ok {
+		return Host{}, false
+	}
+	return entry.view(r.now().Unix()), true
+}
+
+// Update replaces a host's settings, registering the hostname if it has
+// not been seen yet.
+func (r *Registry) Update(hostname string, settings Settings) (Host, error) {
+	if hostname == "" {
+		return Host{}, ErrEmptyHostname
+	}
+	if err := settings.Validate(); err != nil {
+		return Host{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.hosts[hostname]
+	if !ok {
+		entry = r.register(hostname)
+	}
+	entry.Settings = settings
+	return entry.view(r.now().Unix()), nil
+}
+
+func (r *Registry) Muted(hostname string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.hosts[hostname]
+	return ok && entry.Muted
+}
+
+// Settings returns the settings worth persisting, keyed by hostname.
+func (r *Registry) Settings() map[string]Settings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings := make(map[string]Settings)
+	for hostname, entry := range r.hosts {
+		if !entry.Settings.isZero() {
+			settings[hostname] = entry.Settings
+		}
+	}
+	return settings
+}
+
+// Load applies persisted settings. Nothing is changed if any entry is
+// invalid.
+func (r *Registry) Load(settings map[string]Settings) error {
+	for hostname, entry := range settings {
+		if hostname == "" {
+			return ErrEmptyHostname
+		}
+		if err := entry.Validate(); err != nil {
+			return fmt.Errorf("host %s: %w", hostname, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hostname, entry := range settings {
+		existing, ok := r.hosts[hostname]
+		if !ok {
+			existing = r.register(hostname)
+		}
+		existing.Settings = entry
+	}
+	return nil
+}
+
+func (r *Registry) register(hostname string) *host {
+	entry := &host{Host: Host{Hostname: hostname}, projects: make(map[string]bool)}
+	r.hosts[hostname] = entry
+	return entry
+}
+
+func (h *host) view(now int64) Host {
+	view := h.Host
+	view.Projects = make([]string, 0, len(h.projects))
+	for root := range h.projects {
+		view.Projects = append(view.Projects, root)
+	}
+	sort.Strings(view.Projects)
+
+	for i, second := range h.seconds {
+		if second > now-rateSeconds && second <= now {
+			view.EventsPerMinute += h.counts[i]
+		}
+	}
+	return view
+}
@@ -0,0 +1,71 @@
+package hosts
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func hostEvent(hostname string, project string, timestamp string) dump.Event {
+	return dump.Event{
+		ProjectRoot: project, Timestamp: timestamp,
+		Host: dump.HostMeta{Hostname: hostname, PID: 1, SDK: "phant-laravel/1.4.2"},
+	}
+}
+
+func TestRegistry_TracksActivityPerHost(t *testing.T) {
+	received := time.Date(2026, 3, 1, 10, 0, 30, 0, time.UTC)
+	registry := New()
+	registry.now = func() time.Time { return received }
+
+	if !registry.Observe(hostEvent("web", "/app", "2026-03-01T09:58:00Z"), received.Add(-2*time.Minute)) {
+		t.Fatalf("Observe() = false, want new host")
+	}
+	registry.Observe(hostEvent("web", "/api", "2026-03-01T10:00:28Z"), received)
+	if registry.Observe(hostEvent("web", "/app", "2026-03-01T10:00:31.5Z"), received) {
+		t.Fatalf("Observe() = true, want known host")
+	}
+	registry.Observe(hostEvent("", "/app", "2026-03-01T10:00:30Z"), received)
+
+	hosts := registry.Hosts()
+	if len(hosts) != 1 {
+		t.Fatalf("Hosts() = %+v, want one host", hosts)
+	}
+	web := hosts[0]
+	if web.Events != 3 || web.EventsPerMinute != 2 {
+		t.Fatalf("Hosts()[0] events = %d, rate = %d, want 3 and 2", web.Events, web.EventsPerMinute)
+	}
+	if !slices.Equal(web.Projects, []string{"/api", "/app"}) || web.SDK != "phant-laravel/1.4.2" {
+		t.Fatalf("Hosts()[0] = %+v, want both projects and the sdk", web)
+	}
+	if web.FirstSeen != "2026-03-01T09:58:00Z" || web.ClockSkewMs != -1500 {
+		t.Fatalf("Hosts()[0] first seen = %s, skew = %d, want 09:58 and -1500", web.FirstSeen, web.ClockSkewMs)
+	}
+}
+
+func TestRegistry_MutesAndColorsHosts(t *testing.T) {
+	registry := New()
+
+	if _, err := registry.Update("worker-1", Settings{Color: "teal"}); err == nil {
+		t.Fatalf("Update(bad color) error = nil, want error")
+	}
+	if _, err := registry.Update("", Settings{Muted: true}); err != ErrEmptyHostname {
+		t.Fatalf("Update(empty) error = %v, want %v", err, ErrEmptyHostname)
+	}
+	if _, err := registry.Update("worker-1", Settings{Color: "#0f0", Muted: true}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if !registry.Muted("worker-1") || registry.Muted("web") {
+		t.Fatalf("Muted() does not match the saved settings")
+	}
+
+	restored := New()
+	if err := restored.Load(registry.Settings()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if host, ok := restored.Host("worker-1"); !ok || host.Color != "#0f0" || !host.Muted {
+		t.Fatalf("Host(worker-1) = %+v, want restored settings", host)
+	}
+}
@@ -0,0 +1,233 @@
+// Package ignore keeps the callsites whose dumps are dropped at ingestion,
+// so a noisy dump can be silenced without editing the code that makes it.
+package ignore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+const FileName = "ignores.json"
+
+var ErrInvalidCallsite = errors.New("callsite needs a file and a positive line")
+
+// Rule ignores the dumps made at one file and line, the callsite
+// query.Origin reports.
+type Rule struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	CreatedAt string `json:"createdAt"`
+}
+
+func (r Rule) Validate() error {
+	if strings.TrimSpace(r.File) == "" || r.Line <= 0 {
+		return ErrInvalidCallsite
+	}
+	return nil
+}
+
+// Origin is the callsite as query.Origin formats it.
+func (r Rule) Origin() string {
+	return fmt.Sprintf("%s:%d", r.File, r.Line)
+}
+
+// Status is a rule and how many events it dropped since phant started.
+type Status struct {
+	Rule
+	Ignored uint64 `json:"ignored"`
+}
+
+// Set holds every rule in memory and rewrites its file after each change.
+type Set struct {
+	path string
+	now  func() time.Time
+
+	mu       sync.RWMutex
+	byOrigin map[string]Rule
+	ignored  map[string]uint64
+}
+
+// Path is the file the rules are kept in.
+func (s *Set) Path() string {
+	return s.path
+}
+
+// Open loads the rules stored in dir, if any.
+func Open(dir string) (*Set, error) {
+	set := &Set{path: filepath.Join(dir, FileName), now: time.Now, byOrigin: map[string]Rule{}, ignored: map[string]uint64{}}
+
+	data, err := os.ReadFile(set.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return set, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if set.byOrigin, err = decodeRules(set.path, data); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Reload replaces the rules with those now in the file, for edits made
+// outside phant. Rules that stay keep their counts; a file that cannot be
+// read keeps the rules as they were.
+func (s *Set) Reload() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = []byte("[]"), nil
+	}
+	if err != nil {
+		return err
+	}
+	byOrigin, err := decodeRules(s.path, data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byOrigin = byOrigin
+	for origin := range s.ignored {
+		if _, ok := byOrigin[origin]; !ok {
+			delete(s.ignored, origin)
+		}
+	}
+	return nil
+}
+
+func decodeRules(path string, data []byte) (map[string]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	byOrigin := make(map[string]Rule, len(rules))
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, rule.Origin(), err)
+		}
+		byOrigin[rule.Origin()] = rule
+	}
+	return byOrigin, nil
+}
+
+// List returns the rules ordered by file and line.
+func (s *Set) List() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(s.byOrigin))
+	for origin, rule := range s.byOrigin {
+		statuses = append(statuses, Status{Rule: rule, Ignored: s.ignored[origin]})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].File != statuses[j].File {
+			return statuses[i].File < statuses[j].File
+		}
+		return statuses[i].Line < statuses[j].Line
+	})
+	return statuses
+}
+
+// Add ignores a callsite. Adding one that is already ignored keeps the
+// existing rule.
+func (s *Set) Add(file string, line int) (Rule, error) {
+	rule := Rule{File: file, Line: line, CreatedAt: s.now().UTC().Format(time.RFC3339Nano)}
+	if err := rule.Validate(); err != nil {
+		return Rule{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.byOrigin[rule.Origin()]; ok {
+		return existing, nil
+	}
+	s.byOrigin[rule.Origin()] = rule
+	if err := s.saveLocked(); err != nil {
+		delete(s.byOrigin, rule.Origin())
+		return Rule{}, err
+	}
+	return rule, nil
+}
+
+// Remove stops ignoring a callsite; removing one that is not ignored does
+// nothing.
+func (s *Set) Remove(file string, line int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	origin := Rule{File: file, Line: line}.Origin()
+	rule, ok := s.byOrigin[origin]
+	if !ok {
+		return nil
+	}
+	delete(s.byOrigin, origin)
+	if err := s.saveLocked(); err != nil {
+		s.byOrigin[origin] = rule
+		return err
+	}
+	delete(s.ignored, origin)
+	return nil
+}
+
+// Match reports whether an event comes from an ignored callsite, and counts
+// it against the rule if so.
+func (s *Set) Match(event dump.Event) bool {
+	s.mu.RLock()
+	empty := len(s.byOrigin) == 0
+	s.mu.RUnlock()
+	if empty {
+		return false
+	}
+
+	origin := query.Origin(event)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byOrigin[origin]; !ok {
+		return false
+	}
+	s.ignored[origin]++
+	return true
+}
+
+func (s *Set) saveLocked() error {
+	rules := make([]Rule, 0, len(s.byOrigin))
+	for _, rule := range s.byOrigin {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Origin() < rules[j].Origin() })
+
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), FileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
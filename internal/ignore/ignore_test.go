@@ -0,0 +1,89 @@
+package ignore
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestSet_IgnoresCallsitesAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+	set, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if _, err := set.Add("/app/Job.php", 0); !errors.Is(err, ErrInvalidCallsite) {
+		t.Fatalf("Add(line 0) error = %v, want %v", err, ErrInvalidCallsite)
+	}
+	if _, err := set.Add("/app/Job.php", 12); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	noisy := dump.Event{Trace: []dump.TraceFrame{{File: "/app/Job.php", Line: 12}}}
+	other := dump.Event{Trace: []dump.TraceFrame{{File: "/app/Job.php", Line: 13}}}
+	if !set.Match(noisy) {
+		t.Fatalf("Match(/app/Job.php:12) = false, want true")
+	}
+	if set.Match(other) {
+		t.Fatalf("Match(/app/Job.php:13) = true, want false")
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open(again) error = %v", err)
+	}
+	if statuses := reopened.List(); len(statuses) != 1 || statuses[0].Origin() != "/app/Job.php:12" || statuses[0].Ignored != 0 {
+		t.Fatalf("List() = %+v, want the rule for /app/Job.php:12 with no count", statuses)
+	}
+	if statuses := set.List(); statuses[0].Ignored != 1 {
+		t.Fatalf("List()[0].Ignored = %d, want %d", statuses[0].Ignored, 1)
+	}
+
+	if err := reopened.Remove("/app/Job.php", 12); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if reopened.Match(noisy) {
+		t.Fatalf("Match() after Remove() = true, want false")
+	}
+}
+
+func TestSet_ReloadPicksUpOutsideEdits(t *testing.T) {
+	dir := t.TempDir()
+	set, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := set.Add("/app/Job.php", 12); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	kept := dump.Event{Trace: []dump.TraceFrame{{File: "/app/Job.php", Line: 12}}}
+	added := dump.Event{Trace: []dump.TraceFrame{{File: "/app/Mail.php", Line: 4}}}
+	set.Match(kept)
+
+	edited := `[{"file":"/app/Job.php","line":12},{"file":"/app/Mail.php","line":4}]`
+	if err := os.WriteFile(set.Path(), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if !set.Match(added) {
+		t.Fatalf("Match(/app/Mail.php:4) after Reload() = false, want true")
+	}
+	if statuses := set.List(); len(statuses) != 2 || statuses[0].Ignored != 1 {
+		t.Fatalf("List() = %+v, want two rules with the count of /app/Job.php:12 kept", statuses)
+	}
+
+	if err := os.WriteFile(set.Path(), []byte(`[{"file":"","line":1}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Reload(); !errors.Is(err, ErrInvalidCallsite) {
+		t.Fatalf("Reload(invalid rule) error = %v, want %v", err, ErrInvalidCallsite)
+	}
+	if len(set.List()) != 2 {
+		t.Fatalf("List() after a failed Reload() has %d rules, want 2", len(set.List()))
+	}
+}
@@ -0,0 +1,178 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/netauth"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []dump.Event
+}
+
+func (r *recorder) handle(event dump.Event) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+func (r *recorder) wait(t *testing.T, count int) []dump.Event {
+	t.Helper()
+
+	var events []dump.Event
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		events = append([]dump.Event(nil), r.events...)
+		r.mu.Unlock()
+		if len(events) >= count {
+			return events
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("received %d events, want %d", len(events), count)
+	return nil
+}
+
+// decodeID accepts lines such as "evt-1" and "gate:evt-2" and rejects the
+// rest.
+func decodeID(line string) (*dump.Event, error) {
+	if id, ok := strings.CutPrefix(line, "gate:"); ok {
+		return &dump.Event{ID: id, IsDD: true, Gate: true}, nil
+	}
+	if !strings.HasPrefix(line, "evt-") {
+		return nil, errors.New("not an event")
+	}
+	return &dump.Event{ID: line}, nil
+}
+
+func startListener(t *testing.T, spec Spec, r *recorder) *Listener {
+	t.Helper()
+
+	listener := NewListener(spec, decodeID, r.handle)
+	if err := listener.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Stop() })
+	return listener
+}
+
+func TestListener_ReadsLinesFromStreams(t *testing.T) {
+	for _, spec := range []Spec{
+		{Kind: KindTCP, Address: "127.0.0.1:0"},
+		{Kind: KindUnix, Address: filepath.Join(t.TempDir(), "extra.sock")},
+	} {
+		t.Run(spec.Kind, func(t *testing.T) {
+			var r recorder
+			listener := startListener(t, spec, &r)
+
+			network := "tcp"
+			if spec.Kind == KindUnix {
+				network = "unix"
+			}
+			conn, err := net.Dial(network, listener.Address())
+			if err != nil {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			defer conn.Close()
+
+			if _, err := conn.Write([]byte("evt-1\nbogus\ngate:evt-2\n")); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil || !strings.Contains(line, `"id":"evt-2"`) || !strings.Contains(line, `"reason":"disabled"`) {
+				t.Fatalf("release line = %q, %v, want evt-2 released at once", line, err)
+			}
+
+			events := r.wait(t, 2)
+			if events[0].ID != "evt-1" || events[1].ID != "evt-2" {
+				t.Fatalf("events = %+v, want evt-1 and evt-2", events)
+			}
+			if stats := listener.Stats(); stats.Connections != 1 || stats.Events != 2 || stats.Rejected != 1 || stats.LastEventAt == "" {
+				t.Fatalf("Stats() = %+v, want one connection, two events, one rejected line", stats)
+			}
+		})
+	}
+}
+
+func TestListener_AcceptsHTTPBodies(t *testing.T) {
+	var r recorder
+	listener := startListener(t, Spec{Kind: KindHTTP, Address: "127.0.0.1:0"}, &r)
+	url := "http://" + listener.Address() + "/"
+
+	resp, err := http.Post(url, "application/x-ndjson", strings.NewReader("evt-1\r\n\nbogus\nevt-2"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result HTTPResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.Accepted != 2 || result.Rejected != 1 {
+		t.Fatalf("POST result = %+v, %v, want two accepted and one rejected", result, err)
+	}
+	if events := r.wait(t, 2); events[0].ID != "evt-1" || events[1].ID != "evt-2" {
+		t.Fatalf("events = %+v, want evt-1 and evt-2", events)
+	}
+
+	resp, err = http.Get(url)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("GET status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestListener_RequiresTokenOverTheNetwork(t *testing.T) {
+	guard := netauth.NewGuard()
+	if err := guard.SetToken("0123456789abcdef"); err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+
+	var r recorder
+	listener := NewListener(Spec{Kind: KindHTTP, Address: "127.0.0.1:0"}, decodeID, r.handle)
+	listener.SetAuth(guard)
+	if err := listener.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer listener.Stop()
+
+	resp, err := http.Post("http://"+listener.Address()+"/", "application/x-ndjson", bytes.NewReader([]byte("evt-1\n")))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || listener.Stats().Unauthorized != 1 {
+		t.Fatalf("POST without a token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestSpec_Validate(t *testing.T) {
+	for _, spec := range []Spec{
+		{Kind: "udp", Address: "127.0.0.1:1"},
+		{Kind: KindUnix, Address: "relative.sock"},
+		{Kind: KindTCP, Address: "127.0.0.1"},
+		{Kind: KindHTTP, Address: "127.0.0.1:99999"},
+	} {
+		if err := spec.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) = nil, want an error", spec)
+		}
+	}
+	if spec := (Spec{Kind: KindTCP, Address: "127.0.0.1:23517"}); spec.ID() != "tcp:127.0.0.1:23517" || spec.Validate() != nil {
+		t.Fatalf("Spec %+v: ID() = %q, Validate() = %v", spec, spec.ID(), spec.Validate())
+	}
+}
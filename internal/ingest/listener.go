@@ -0,0 +1,333 @@
+package ingest
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"phant/internal/ddgate"
+	"phant/internal/dump"
+	"phant/internal/netauth"
+	"phant/internal/netline"
+)
+
+const (
+	maxRequestBytes     = 16 * 1024 * 1024
+	releaseWriteTimeout = 2 * time.Second
+)
+
+// Decoder turns a line into an event. A nil event with no error means the
+// line was consumed without producing one, such as a control line.
+type Decoder func(line string) (*dump.Event, error)
+
+type Handler func(event dump.Event)
+
+type Stats struct {
+	Address string `json:"address"`
+	// Connections counts accepted connections, or requests for HTTP
+	// listeners.
+	Connections  uint64 `json:"connections"`
+	Events       uint64 `json:"events"`
+	Rejected     uint64 `json:"rejected"`
+	Unauthorized uint64 `json:"unauthorized"`
+	LastEventAt  string `json:"lastEventAt,omitempty"`
+}
+
+// HTTPResult is the response to a POST: how many lines became events and
+// how many were rejected.
+type HTTPResult struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+}
+
+// Listener serves one Spec. Stream listeners never hold producers at dd
+// gates: a gated dd event is released at once, as on a collector without
+// gates.
+type Listener struct {
+	spec   Spec
+	decode Decoder
+	handle Handler
+	auth   *netauth.Guard
+	tls    *tls.Config
+
+	connections  atomic.Uint64
+	events       atomic.Uint64
+	rejected     atomic.Uint64
+	unauthorized atomic.Uint64
+	lastEvent    atomic.Int64
+
+	listener net.Listener
+	http     *http.Server
+	stopOnce sync.Once
+	stopped  chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewListener(spec Spec, decode Decoder, handle Handler) *Listener {
+	return &Listener{
+		spec:    spec,
+		decode:  decode,
+		handle:  handle,
+		stopped: make(chan struct{}),
+	}
+}
+
+// SetAuth makes TCP connections open with the guard's handshake line and
+// HTTP requests carry its token. Unix sockets are local and not checked.
+// It must be called before Start.
+func (l *Listener) SetAuth(guard *netauth.Guard) {
+	l.auth = guard
+}
+
+// SetTLS makes TCP and HTTP listeners serve TLS with config. It must be
+// called before Start.
+func (l *Listener) SetTLS(config *tls.Config) {
+	l.tls = config
+}
+
+func (l *Listener) Start() error {
+	if err := l.spec.Validate(); err != nil {
+		return err
+	}
+
+	var listener net.Listener
+	var err error
+	if l.spec.Kind == KindUnix {
+		listener, err = listenUnix(l.spec.Address)
+	} else {
+		listener, err = net.Listen("tcp", l.spec.Address)
+		if err == nil && l.tls != nil {
+			listener = tls.NewListener(listener, l.tls)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	l.listener = listener
+
+	l.wg.Add(1)
+	if l.spec.Kind == KindHTTP {
+		l.http = &http.Server{Handler: l, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			defer l.wg.Done()
+			_ = l.http.Serve(listener)
+		}()
+		return nil
+	}
+	go l.acceptLoop()
+	return nil
+}
+
+func listenUnix(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+func (l *Listener) Stop() error {
+	var closeErr error
+
+	l.stopOnce.Do(func() {
+		if l.http != nil {
+			closeErr = l.http.Close()
+		} else if l.listener != nil {
+			closeErr = l.listener.Close()
+		}
+		close(l.stopped)
+		l.wg.Wait()
+	})
+
+	if errors.Is(closeErr, net.ErrClosed) || errors.Is(closeErr, http.ErrServerClosed) {
+		return nil
+	}
+	return closeErr
+}
+
+// Address returns the bound address, which differs from the configured one
+// when listening on port 0.
+func (l *Listener) Address() string {
+	if l.listener != nil {
+		return l.listener.Addr().String()
+	}
+	return l.spec.Address
+}
+
+func (l *Listener) Stats() Stats {
+	stats := Stats{
+		Address:      l.Address(),
+		Connections:  l.connections.Load(),
+		Events:       l.events.Load(),
+		Rejected:     l.rejected.Load(),
+		Unauthorized: l.unauthorized.Load(),
+	}
+	if at := l.lastEvent.Load(); at != 0 {
+		stats.LastEventAt = time.Unix(0, at).UTC().Format(time.RFC3339Nano)
+	}
+	return stats
+}
+
+func (l *Listener) acceptLoop() {
+	defer l.wg.Done()
+
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			select {
+			case <-l.stopped:
+				return
+			default:
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				continue
+			}
+		}
+
+		l.connections.Add(1)
+		l.wg.Add(1)
+		go l.handleConn(conn)
+	}
+}
+
+func (l *Listener) handleConn(conn net.Conn) {
+	defer l.wg.Done()
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-l.stopped:
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	var guard *netauth.Guard
+	if l.spec.Kind == KindTCP {
+		guard = l.auth
+	}
+	handshake := guard.Expect(conn)
+	result := netline.Read(conn, netline.Options{}, func(line []byte) {
+		if len(line) == 0 || !handshake.Admit(line) {
+			return
+		}
+		event, _ := l.ingest(string(line))
+		if event != nil && event.Gate && event.IsDD {
+			release(conn, event.ID)
+		}
+	})
+	if handshake.Done() {
+		l.unauthorized.Add(1)
+		return
+	}
+	l.rejected.Add(uint64(result.Oversized))
+	if result.Torn {
+		l.rejected.Add(1)
+	}
+}
+
+// ingest decodes and hands on one line, returning the event it became.
+func (l *Listener) ingest(line string) (*dump.Event, error) {
+	event, err := l.decode(line)
+	if err != nil {
+		l.rejected.Add(1)
+		return nil, err
+	}
+	if event == nil {
+		return nil, nil
+	}
+
+	l.events.Add(1)
+	l.lastEvent.Store(time.Now().UnixNano())
+	l.handle(*event)
+	return event, nil
+}
+
+// release lets a gated producer go on at once. The write is bounded so a
+// producer that stopped reading cannot stall the connection.
+func release(conn net.Conn, id string) {
+	line, err := json.Marshal(ddgate.Release{Type: "release", ID: id, Action: ddgate.ActionExit, Reason: ddgate.ReasonDisabled})
+	if err != nil {
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(releaseWriteTimeout))
+	_, _ = conn.Write(append(line, '\n'))
+}
+
+// ServeHTTP accepts NDJSON bodies, plain or gzip-encoded, POSTed to /. The
+// size limit also applies after decompression.
+func (l *Listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.connections.Add(1)
+	if !l.auth.AllowRequest(r) {
+		l.unauthorized.Add(1)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="phant"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+	case "gzip":
+		decompressed, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, "invalid gzip body", http.StatusBadRequest)
+			return
+		}
+		defer decompressed.Close()
+		body = http.MaxBytesReader(w, decompressed, maxRequestBytes)
+	default:
+		w.Header().Set("Accept-Encoding", "gzip")
+		http.Error(w, "unsupported content encoding", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var result HTTPResult
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), netline.DefaultMaxLineBytes)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		event, err := l.ingest(line)
+		switch {
+		case err != nil:
+			result.Rejected++
+		case event != nil:
+			result.Accepted++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		http.Error(w, "request body too large or unreadable: "+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,54 @@
+// Package ingest runs extra listeners that feed NDJSON dump lines into the
+// collector next to its own socket: more Unix sockets, TCP ports, and HTTP
+// endpoints, each with its own counters.
+package ingest
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"strconv"
+)
+
+// Listener kinds.
+const (
+	// KindUnix reads lines from a Unix socket, as the collector does.
+	KindUnix = "unix"
+	// KindTCP reads lines from TCP connections.
+	KindTCP = "tcp"
+	// KindHTTP reads lines from the bodies of POST requests.
+	KindHTTP = "http"
+)
+
+// Spec configures one listener. Address is a socket path for unix
+// listeners and host:port for the others.
+type Spec struct {
+	Kind    string `json:"kind"`
+	Address string `json:"address"`
+}
+
+// ID names the listener, such as "tcp:127.0.0.1:23517". Events it receives
+// are tagged with it.
+func (s Spec) ID() string {
+	return s.Kind + ":" + s.Address
+}
+
+func (s Spec) Validate() error {
+	switch s.Kind {
+	case KindUnix:
+		if !filepath.IsAbs(s.Address) {
+			return errors.New("unix listener address must be an absolute socket path")
+		}
+	case KindTCP, KindHTTP:
+		_, port, err := net.SplitHostPort(s.Address)
+		if err != nil {
+			return err
+		}
+		if number, err := strconv.Atoi(port); err != nil || number < 0 || number > 65535 {
+			return errors.New("listener port must be a number between 0 and 65535")
+		}
+	default:
+		return errors.New("listener kind must be one of: unix, tcp, http")
+	}
+	return nil
+}
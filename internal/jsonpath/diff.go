@@ -0,0 +1,149 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of Change.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is one difference between two documents. Path is a definite
+// JSONPath into both documents, so it can be passed to Query; Before and
+// After are the fragments byte for byte, absent for added and removed
+// values respectively.
+type Change struct {
+	Kind   string          `json:"kind"`
+	Path   string          `json:"path"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Diff compares two documents member by member and returns their changes in
+// document order. Arrays are compared index by index; a value whose type
+// differs is reported as one change rather than descended into.
+func Diff(before json.RawMessage, after json.RawMessage) ([]Change, error) {
+	changes := []Change{}
+	if err := diffNode(&changes, "$", bytes.TrimSpace(before), bytes.TrimSpace(after)); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func diffNode(changes *[]Change, at string, before json.RawMessage, after json.RawMessage) error {
+	switch kind := firstByte(before); {
+	case kind == '{' && firstByte(after) == '{':
+		return diffObjects(changes, at, before, after)
+	case kind == '[' && firstByte(after) == '[':
+		return diffArrays(changes, at, before, after)
+	}
+
+	same, err := equalJSON(before, after)
+	if err != nil {
+		return err
+	}
+	if !same {
+		*changes = append(*changes, Change{Kind: Changed, Path: at, Before: before, After: after})
+	}
+	return nil
+}
+
+func diffObjects(changes *[]Change, at string, before json.RawMessage, after json.RawMessage) error {
+	beforeMembers, err := objectMembers(before)
+	if err != nil {
+		return err
+	}
+	afterMembers, err := objectMembers(after)
+	if err != nil {
+		return err
+	}
+
+	afterValues := make(map[string]json.RawMessage, len(afterMembers))
+	for _, m := range afterMembers {
+		afterValues[m.key] = m.value
+	}
+	seen := make(map[string]bool, len(beforeMembers))
+	for _, m := range beforeMembers {
+		if seen[m.key] {
+			continue
+		}
+		seen[m.key] = true
+
+		value, ok := afterValues[m.key]
+		if !ok {
+			*changes = append(*changes, Change{Kind: Removed, Path: at + MemberPath(m.key), Before: m.value})
+			continue
+		}
+		if err := diffNode(changes, at+MemberPath(m.key), m.value, value); err != nil {
+			return err
+		}
+	}
+	for _, m := range afterMembers {
+		if !seen[m.key] {
+			seen[m.key] = true
+			*changes = append(*changes, Change{Kind: Added, Path: at + MemberPath(m.key), After: m.value})
+		}
+	}
+	return nil
+}
+
+func diffArrays(changes *[]Change, at string, before json.RawMessage, after json.RawMessage) error {
+	beforeItems, err := arrayItems(before)
+	if err != nil {
+		return err
+	}
+	afterItems, err := arrayItems(after)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < max(len(beforeItems), len(afterItems)); i++ {
+		itemAt := at + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(afterItems):
+			*changes = append(*changes, Change{Kind: Removed, Path: itemAt, Before: beforeItems[i]})
+		case i >= len(beforeItems):
+			*changes = append(*changes, Change{Kind: Added, Path: itemAt, After: afterItems[i]})
+		default:
+			if err := diffNode(changes, itemAt, beforeItems[i], afterItems[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// equalJSON compares scalars, and containers of different types, ignoring
+// insignificant whitespace.
+func equalJSON(a json.RawMessage, b json.RawMessage) (bool, error) {
+	var compactA, compactB bytes.Buffer
+	if err := json.Compact(&compactA, a); err != nil {
+		return false, err
+	}
+	if err := json.Compact(&compactB, b); err != nil {
+		return false, err
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes()), nil
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// MemberPath is the path step selecting an object member, in dot notation
+// when the name allows it.
+func MemberPath(name string) string {
+	switch {
+	case identifierPattern.MatchString(name):
+		return "." + name
+	case strings.Contains(name, "'"):
+		return `["` + name + `"]`
+	default:
+		return "['" + name + "']"
+	}
+}
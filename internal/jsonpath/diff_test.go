@@ -0,0 +1,51 @@
+package jsonpath
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiff_ReportsAddedRemovedAndChangedPaths(t *testing.T) {
+	after := `{"user":{"name":"Ada","roles":["admin","owner"],"meta":{"name":"inner","age":36}},"items":[{"id":1},{"id":2}],"odd key":"yes","new":null}`
+
+	changes, err := Diff([]byte(document), []byte(after))
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	want := []string{
+		`changed $.user.roles[1] "editor" -> "owner"`,
+		`removed $.user.roles[2] "viewer" -> `,
+		`added $.user.meta.age  -> 36`,
+		`removed $.items[2] {"id":3} -> `,
+		`changed $['odd key'] true -> "yes"`,
+		`added $.new  -> null`,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Diff() = %d changes %v, want %d", len(changes), changes, len(want))
+	}
+	for i, change := range changes {
+		if got := fmt.Sprintf("%s %s %s -> %s", change.Kind, change.Path, string(change.Before), string(change.After)); got != want[i] {
+			t.Fatalf("Diff()[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+
+	for _, change := range changes {
+		if change.Kind == Removed {
+			continue
+		}
+		if _, err := Query([]byte(after), change.Path); err != nil {
+			t.Fatalf("Query(after, %s) error = %v, want path usable as a query", change.Path, err)
+		}
+	}
+}
+
+func TestDiff_IdenticalDocumentsAndScalars(t *testing.T) {
+	if changes, err := Diff([]byte(document), []byte(" "+document+"\n")); err != nil || len(changes) != 0 {
+		t.Fatalf("Diff(same) = %v, %v, want no changes", changes, err)
+	}
+	changes, err := Diff([]byte(`"dump one"`), []byte(`"dump two"`))
+	if err != nil || len(changes) != 1 || changes[0].Path != "$" {
+		t.Fatalf("Diff(text) = %v, %v, want one change at $", changes, err)
+	}
+}
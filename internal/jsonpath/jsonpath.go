@@ -0,0 +1,361 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrNoMatch = errors.New("path matched nothing")
+
+type selectorKind int
+
+const (
+	selectName selectorKind = iota
+	selectIndex
+	selectWildcard
+	selectSlice
+)
+
+type step struct {
+	recursive bool
+	kind      selectorKind
+	name      string
+	index     int
+	start     *int
+	end       *int
+}
+
+// Path is a compiled JSONPath expression. The supported subset is `$`,
+// `.name`, `['name']`, `[n]` (negative counts from the end), `[start:end]`,
+// `.*`/`[*]`, and recursive descent `..name`/`..*`.
+type Path struct {
+	expression string
+	steps      []step
+}
+
+func Compile(expression string) (Path, error) {
+	expression = strings.TrimSpace(expression)
+	if !strings.HasPrefix(expression, "$") {
+		return Path{}, fmt.Errorf("jsonpath %q must start with $", expression)
+	}
+
+	path := Path{expression: expression}
+	rest := expression[1:]
+	for rest != "" {
+		var s step
+		var err error
+
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			s.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				s, rest, err = parseBracket(rest, s)
+			} else {
+				s, rest, err = parseDotted(rest, s)
+			}
+		case strings.HasPrefix(rest, "."):
+			s, rest, err = parseDotted(rest[1:], s)
+		case strings.HasPrefix(rest, "["):
+			s, rest, err = parseBracket(rest, s)
+		default:
+			err = fmt.Errorf("unexpected %q", rest)
+		}
+		if err != nil {
+			return Path{}, fmt.Errorf("jsonpath %q: %w", expression, err)
+		}
+		path.steps = append(path.steps, s)
+	}
+
+	return path, nil
+}
+
+// Definite reports whether the path can match at most one value.
+func (p Path) Definite() bool {
+	for _, s := range p.steps {
+		if s.recursive || s.kind == selectWildcard || s.kind == selectSlice {
+			return false
+		}
+	}
+	return true
+}
+
+func parseDotted(rest string, s step) (step, string, error) {
+	end := strings.IndexAny(rest, ".[")
+	if end < 0 {
+		end = len(rest)
+	}
+	name := rest[:end]
+	if name == "" {
+		return step{}, "", errors.New("empty member name")
+	}
+
+	if name == "*" {
+		s.kind = selectWildcard
+	} else {
+		s.kind = selectName
+		s.name = name
+	}
+	return s, rest[end:], nil
+}
+
+func parseBracket(rest string, s step) (step, string, error) {
+	inner := rest[1:]
+	if len(inner) > 0 && (inner[0] == '\'' || inner[0] == '"') {
+		quote := inner[0]
+		end := strings.IndexByte(inner[1:], quote)
+		if end < 0 || len(inner) < end+3 || inner[end+2] != ']' {
+			return step{}, "", errors.New("unterminated quoted member")
+		}
+		s.kind = selectName
+		s.name = inner[1 : end+1]
+		return s, inner[end+3:], nil
+	}
+
+	end := strings.IndexByte(inner, ']')
+	if end < 0 {
+		return step{}, "", errors.New("unterminated bracket")
+	}
+	body := strings.TrimSpace(inner[:end])
+	rest = inner[end+1:]
+
+	switch {
+	case body == "*":
+		s.kind = selectWildcard
+	case strings.Contains(body, ":"):
+		s.kind = selectSlice
+		bounds := strings.SplitN(body, ":", 2)
+		for i, bound := range bounds {
+			bound = strings.TrimSpace(bound)
+			if bound == "" {
+				continue
+			}
+			value, err := strconv.Atoi(bound)
+			if err != nil {
+				return step{}, "", fmt.Errorf("invalid slice bound %q", bound)
+			}
+			if i == 0 {
+				s.start = &value
+			} else {
+				s.end = &value
+			}
+		}
+	default:
+		value, err := strconv.Atoi(body)
+		if err != nil {
+			return step{}, "", fmt.Errorf("invalid index %q", body)
+		}
+		s.kind = selectIndex
+		s.index = value
+	}
+	return s, rest, nil
+}
+
+// Evaluate returns the matching fragments of document, byte for byte as they
+// appear in it, in document order.
+func (p Path) Evaluate(document json.RawMessage) ([]json.RawMessage, error) {
+	nodes := []json.RawMessage{bytes.TrimSpace(document)}
+
+	for _, s := range p.steps {
+		var candidates []json.RawMessage
+		if s.recursive {
+			for _, node := range nodes {
+				var err error
+				if candidates, err = appendDescendants(candidates, node); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			candidates = nodes
+		}
+
+		var next []json.RawMessage
+		for _, node := range candidates {
+			matched, err := s.apply(node)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, matched...)
+		}
+		nodes = next
+	}
+
+	return nodes, nil
+}
+
+func (s step) apply(node json.RawMessage) ([]json.RawMessage, error) {
+	switch firstByte(node) {
+	case '{':
+		if s.kind != selectName && s.kind != selectWildcard {
+			return nil, nil
+		}
+		members, err := objectMembers(node)
+		if err != nil {
+			return nil, err
+		}
+		var matched []json.RawMessage
+		for _, member := range members {
+			if s.kind == selectWildcard || member.key == s.name {
+				matched = append(matched, member.value)
+			}
+		}
+		return matched, nil
+	case '[':
+		if s.kind == selectName {
+			return nil, nil
+		}
+		items, err := arrayItems(node)
+		if err != nil {
+			return nil, err
+		}
+		return s.selectItems(items), nil
+	}
+	return nil, nil
+}
+
+func (s step) selectItems(items []json.RawMessage) []json.RawMessage {
+	normalize := func(i int) int {
+		if i < 0 {
+			i += len(items)
+		}
+		return min(max(i, 0), len(items))
+	}
+
+	switch s.kind {
+	case selectWildcard:
+		return items
+	case selectIndex:
+		i := s.index
+		if i < 0 {
+			i += len(items)
+		}
+		if i < 0 || i >= len(items) {
+			return nil
+		}
+		return items[i : i+1]
+	case selectSlice:
+		start, end := 0, len(items)
+		if s.start != nil {
+			start = normalize(*s.start)
+		}
+		if s.end != nil {
+			end = normalize(*s.end)
+		}
+		if start >= end {
+			return nil
+		}
+		return items[start:end]
+	}
+	return nil
+}
+
+func appendDescendants(out []json.RawMessage, node json.RawMessage) ([]json.RawMessage, error) {
+	out = append(out, node)
+
+	var children []json.RawMessage
+	switch firstByte(node) {
+	case '{':
+		members, err := objectMembers(node)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			children = append(children, member.value)
+		}
+	case '[':
+		items, err := arrayItems(node)
+		if err != nil {
+			return nil, err
+		}
+		children = items
+	}
+
+	for _, child := range children {
+		var err error
+		if out, err = appendDescendants(out, child); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// objectMembers splits an object into its members in document order.
+func objectMembers(node json.RawMessage) ([]member, error) {
+	decoder := json.NewDecoder(bytes.NewReader(node))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var members []member
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, member{key: key, value: value})
+	}
+	return members, nil
+}
+
+func arrayItems(node json.RawMessage) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(node, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func firstByte(node json.RawMessage) byte {
+	trimmed := bytes.TrimSpace(node)
+	if len(trimmed) == 0 {
+		return 0
+	}
+	return trimmed[0]
+}
+
+// Query evaluates expression against document. A definite path returns the
+// single matching fragment; any other path returns a JSON array of matches.
+func Query(document json.RawMessage, expression string) (json.RawMessage, error) {
+	path, err := Compile(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := path.Evaluate(document)
+	if err != nil {
+		return nil, err
+	}
+
+	if path.Definite() {
+		if len(matches) == 0 {
+			return nil, ErrNoMatch
+		}
+		return matches[0], nil
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('[')
+	for i, match := range matches {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		out.Write(match)
+	}
+	out.WriteByte(']')
+	return json.RawMessage(out.Bytes()), nil
+}
@@ -0,0 +1,47 @@
+package jsonpath
+
+import (
+	"errors"
+	"testing"
+)
+
+const document = `{"user":{"name":"Ada","roles":["admin","editor","viewer"],"meta":{"name":"inner"}},"items":[{"id":1},{"id":2},{"id":3}],"odd key":true}`
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{expression: "$", want: document},
+		{expression: "$.user.name", want: `"Ada"`},
+		{expression: "$['odd key']", want: `true`},
+		{expression: "$.user.roles[-1]", want: `"viewer"`},
+		{expression: "$.user.roles[0:2]", want: `["admin","editor"]`},
+		{expression: "$.items[*].id", want: `[1,2,3]`},
+		{expression: "$..name", want: `["Ada","inner"]`},
+		{expression: "$.user.*", want: `["Ada",["admin","editor","viewer"],{"name":"inner"}]`},
+		{expression: "$.missing[*]", want: `[]`},
+	}
+
+	for _, test := range tests {
+		got, err := Query([]byte(document), test.expression)
+		if err != nil {
+			t.Fatalf("Query(%s) error = %v", test.expression, err)
+		}
+		if string(got) != test.want {
+			t.Fatalf("Query(%s) = %s, want %s", test.expression, got, test.want)
+		}
+	}
+}
+
+func TestQuery_Errors(t *testing.T) {
+	if _, err := Query([]byte(document), "$.user.age"); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("Query(missing) error = %v, want ErrNoMatch", err)
+	}
+
+	for _, expression := range []string{"user.name", "$.user[", "$.items[x]", "$['open"} {
+		if _, err := Compile(expression); err == nil {
+			t.Fatalf("Compile(%s) error = nil, want error", expression)
+		}
+	}
+}
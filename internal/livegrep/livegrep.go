@@ -0,0 +1,147 @@
+// Package livegrep matches regular expressions against a plain-text
+// rendering of events, line by line, the way `tail -f | grep` would see
+// them.
+package livegrep
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"phant/internal/dump"
+)
+
+const (
+	MaxPatternLength = 1000
+
+	// MaxMatchedLines bounds the lines reported per matching event.
+	MaxMatchedLines = 20
+)
+
+var ErrEmptyPattern = errors.New("grep pattern must not be empty")
+
+// Pattern is a regular expression in RE2 syntax, which runs in time linear
+// in the text, so a pattern cannot stall ingestion. Invert selects events
+// with no matching line, like grep -v.
+type Pattern struct {
+	Expression string `json:"expression"`
+	IgnoreCase bool   `json:"ignoreCase,omitempty"`
+	Invert     bool   `json:"invert,omitempty"`
+}
+
+// Match is an event selected by a pattern with the lines that matched;
+// Lines is empty for inverted patterns.
+type Match struct {
+	Event dump.Event `json:"event"`
+	Lines []string   `json:"lines"`
+}
+
+type Matcher struct {
+	pattern Pattern
+	re      *regexp.Regexp
+}
+
+func (p Pattern) Compile() (*Matcher, error) {
+	if p.Expression == "" {
+		return nil, ErrEmptyPattern
+	}
+	if len(p.Expression) > MaxPatternLength {
+		return nil, fmt.Errorf("grep pattern must be at most %d characters", MaxPatternLength)
+	}
+
+	expression := p.Expression
+	if p.IgnoreCase {
+		expression = "(?i)" + expression
+	}
+	re, err := regexp.Compile(expression)
+	if err != nil {
+		return nil, fmt.Errorf("grep pattern: %w", err)
+	}
+	return &Matcher{pattern: p, re: re}, nil
+}
+
+// Match reports whether the event is selected and, if so, which lines
+// matched.
+func (m *Matcher) Match(event dump.Event) (Match, bool) {
+	var matched []string
+	for _, line := range Lines(event) {
+		if !m.re.MatchString(line) {
+			continue
+		}
+		if m.pattern.Invert {
+			return Match{}, false
+		}
+		if len(matched) < MaxMatchedLines {
+			matched = append(matched, line)
+		}
+	}
+	if !m.pattern.Invert && len(matched) == 0 {
+		return Match{}, false
+	}
+	if matched == nil {
+		matched = []string{}
+	}
+	return Match{Event: event, Lines: matched}, true
+}
+
+// Lines renders an event as text: a header with its source and context,
+// the payload (JSON indented one member per line, text and HTML as sent),
+// then one line per trace frame.
+func Lines(event dump.Event) []string {
+	lines := []string{header(event)}
+
+	switch event.PayloadFormat {
+	case dump.PayloadFormatJSON:
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, event.Payload, "", "  "); err == nil {
+			lines = append(lines, strings.Split(indented.String(), "\n")...)
+		} else {
+			lines = append(lines, string(event.Payload))
+		}
+	default:
+		lines = append(lines, strings.Split(event.PayloadString(), "\n")...)
+	}
+
+	for _, frame := range event.Trace {
+		line := frame.File + ":" + strconv.Itoa(frame.Line)
+		if frame.Func != "" {
+			line += " " + frame.Func
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func header(event dump.Event) string {
+	parts := []string{"[" + event.SourceType + "]"}
+	if event.Level != "" {
+		parts = append(parts, strings.ToUpper(event.Level))
+	}
+	if event.Host.Hostname != "" {
+		parts = append(parts, event.Host.Hostname)
+	}
+	if event.ProjectRoot != "" {
+		parts = append(parts, event.ProjectRoot)
+	}
+
+	switch {
+	case event.HTTP != nil:
+		parts = append(parts, event.HTTP.Method+" "+event.HTTP.Host+event.HTTP.Path)
+	case event.Command != nil:
+		parts = append(parts, strings.TrimSpace(event.Command.Name+" "+strings.Join(event.Command.Args, " ")))
+	}
+	if event.Log != nil {
+		parts = append(parts, event.Log.Channel+": "+event.Log.Message)
+	}
+	if event.Exception != nil {
+		parts = append(parts, event.Exception.Class+": "+event.Exception.Message)
+	}
+	if event.Label != "" {
+		parts = append(parts, strconv.Quote(event.Label))
+	}
+	return strings.Join(parts, " ")
+}
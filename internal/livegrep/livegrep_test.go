@@ -0,0 +1,90 @@
+package livegrep
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func testEvent() dump.Event {
+	return dump.Event{
+		SourceType:    "http",
+		ProjectRoot:   "/srv/app",
+		HTTP:          &dump.HTTPMeta{Method: "POST", Host: "shop.test", Path: "/checkout"},
+		Label:         "order",
+		PayloadFormat: dump.PayloadFormatJSON,
+		Payload:       json.RawMessage(`{"order":{"id":42,"status":"failed"}}`),
+		Trace:         []dump.TraceFrame{{File: "/srv/app/app/Billing.php", Line: 88, Func: "charge"}},
+		Host:          dump.HostMeta{Hostname: "devbox"},
+	}
+}
+
+func TestLines_RendersHeaderPayloadAndTrace(t *testing.T) {
+	want := []string{
+		`[http] devbox /srv/app POST shop.test/checkout "order"`,
+		`{`,
+		`  "order": {`,
+		`    "id": 42,`,
+		`    "status": "failed"`,
+		`  }`,
+		`}`,
+		`/srv/app/app/Billing.php:88 charge`,
+	}
+	if got := Lines(testEvent()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lines() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMatcher_Match(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   Pattern
+		wantOK    bool
+		wantLines []string
+	}{
+		{"payload line", Pattern{Expression: `"status": "fail`}, true, []string{`    "status": "failed"`}},
+		{"anchored header", Pattern{Expression: `^\[http\]`}, true, []string{`[http] devbox /srv/app POST shop.test/checkout "order"`}},
+		{"case sensitive", Pattern{Expression: `BILLING`}, false, nil},
+		{"ignore case", Pattern{Expression: `BILLING`, IgnoreCase: true}, true, []string{`/srv/app/app/Billing.php:88 charge`}},
+		{"inverted match", Pattern{Expression: `succeeded`, Invert: true}, true, []string{}},
+		{"inverted miss", Pattern{Expression: `failed`, Invert: true}, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := tt.pattern.Compile()
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			match, ok := matcher.Match(testEvent())
+			if ok != tt.wantOK {
+				t.Fatalf("Match() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(match.Lines, tt.wantLines) {
+				t.Fatalf("Match() lines = %#v, want %#v", match.Lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestMatcher_MatchesTextPayloadLines(t *testing.T) {
+	matcher, err := Pattern{Expression: `^second`}.Compile()
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	event := dump.Event{SourceType: "cli", PayloadFormat: dump.PayloadFormatText, Payload: json.RawMessage(`"first line\nsecond line"`)}
+	match, ok := matcher.Match(event)
+	if !ok || !reflect.DeepEqual(match.Lines, []string{"second line"}) {
+		t.Fatalf("Match() = %#v, %v, want the second line", match.Lines, ok)
+	}
+}
+
+func TestPattern_CompileRejectsInvalidPatterns(t *testing.T) {
+	for _, pattern := range []Pattern{{}, {Expression: `(`}, {Expression: string(make([]byte, MaxPatternLength+1))}} {
+		if _, err := pattern.Compile(); err == nil {
+			t.Fatalf("Compile(%.20q) error = nil, want error", pattern.Expression)
+		}
+	}
+}
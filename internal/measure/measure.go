@@ -0,0 +1,108 @@
+// Package measure pairs the measure-start and measure-stop events PHP
+// clients send around a block of code into timings, so a request's dumps
+// can show how long each block took and how much memory it used.
+package measure
+
+import (
+	"time"
+
+	"phant/internal/dump"
+)
+
+// Measure is one timed block. Open is set while no stop has arrived for its
+// start; StopEventID, StoppedAt, and DurationMs are then empty.
+// MemoryDeltaBytes is set when both events reported memory usage.
+type Measure struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name,omitempty"`
+	ProjectRoot      string   `json:"projectRoot"`
+	RequestID        string   `json:"requestId,omitempty"`
+	StartEventID     string   `json:"startEventId"`
+	StopEventID      string   `json:"stopEventId,omitempty"`
+	StartedAt        string   `json:"startedAt"`
+	StoppedAt        string   `json:"stoppedAt,omitempty"`
+	DurationMs       *float64 `json:"durationMs,omitempty"`
+	MemoryDeltaBytes *int64   `json:"memoryDeltaBytes,omitempty"`
+	Open             bool     `json:"open"`
+}
+
+// key scopes measure IDs to the process that sent them, so two workers
+// timing a block under the same ID do not pair with each other.
+type key struct {
+	hostname string
+	pid      int
+	project  string
+	id       string
+}
+
+type pending struct {
+	index  int
+	memory *int64
+}
+
+// Pair matches measure events, expected oldest first, into measures in the
+// order they started. A stop pairs with the latest open start of its ID; a
+// stop without one is skipped, and a second start leaves the first open.
+func Pair(events []dump.Event) []Measure {
+	measures := []Measure{}
+	open := map[key]pending{}
+
+	for _, event := range events {
+		meta := event.Measure
+		if meta == nil {
+			continue
+		}
+		k := key{hostname: event.Host.Hostname, pid: event.Host.PID, project: event.ProjectRoot, id: meta.ID}
+
+		switch meta.Phase {
+		case dump.MeasureStart:
+			measure := Measure{
+				ID:           meta.ID,
+				Name:         meta.Name,
+				ProjectRoot:  event.ProjectRoot,
+				StartEventID: event.ID,
+				StartedAt:    event.Timestamp,
+				Open:         true,
+			}
+			if event.RequestID != nil {
+				measure.RequestID = *event.RequestID
+			}
+			open[k] = pending{index: len(measures), memory: meta.MemoryBytes}
+			measures = append(measures, measure)
+		case dump.MeasureStop:
+			start, ok := open[k]
+			if !ok {
+				continue
+			}
+			delete(open, k)
+			measure := &measures[start.index]
+			measure.Open = false
+			measure.StopEventID = event.ID
+			measure.StoppedAt = event.Timestamp
+			if measure.Name == "" {
+				measure.Name = meta.Name
+			}
+			if duration, ok := elapsed(measure.StartedAt, event.Timestamp); ok {
+				measure.DurationMs = &duration
+			}
+			if start.memory != nil && meta.MemoryBytes != nil {
+				delta := *meta.MemoryBytes - *start.memory
+				measure.MemoryDeltaBytes = &delta
+			}
+		}
+	}
+	return measures
+}
+
+// elapsed returns the milliseconds between two event timestamps.
+func elapsed(from string, to string) (float64, bool) {
+	start, err := time.Parse(time.RFC3339Nano, from)
+	if err != nil {
+		return 0, false
+	}
+	stop, err := time.Parse(time.RFC3339Nano, to)
+	if err != nil {
+		return 0, false
+	}
+	return float64(stop.Sub(start)) / float64(time.Millisecond), true
+}
@@ -0,0 +1,63 @@
+package measure
+
+import (
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func measureEvent(id string, timestamp string, phase string, memory int64) dump.Event {
+	requestID := "req-1"
+	return dump.Event{
+		ID: id, SourceType: "http", ProjectRoot: "/app", Timestamp: timestamp, RequestID: &requestID,
+		Host:    dump.HostMeta{Hostname: "h", PID: 10},
+		Measure: &dump.MeasureMeta{ID: "checkout", Phase: phase, Name: "charge card", MemoryBytes: &memory},
+	}
+}
+
+func TestPair_ComputesDurationAndMemoryDelta(t *testing.T) {
+	events := []dump.Event{
+		measureEvent("e1", "2026-03-01T10:00:00.100Z", dump.MeasureStart, 4096),
+		{ID: "plain", SourceType: "http", Timestamp: "2026-03-01T10:00:00.120Z"},
+		measureEvent("e2", "2026-03-01T10:00:00.184Z", dump.MeasureStop, 6144),
+	}
+
+	measures := Pair(events)
+	if len(measures) != 1 {
+		t.Fatalf("Pair() = %d measures, want 1", len(measures))
+	}
+	got := measures[0]
+	if got.Open || got.StartEventID != "e1" || got.StopEventID != "e2" || got.RequestID != "req-1" || got.Name != "charge card" {
+		t.Fatalf("Pair()[0] = %+v, want closed measure from e1 to e2", got)
+	}
+	if got.DurationMs == nil || *got.DurationMs != 84 {
+		t.Fatalf("Pair()[0].DurationMs = %v, want 84", got.DurationMs)
+	}
+	if got.MemoryDeltaBytes == nil || *got.MemoryDeltaBytes != 2048 {
+		t.Fatalf("Pair()[0].MemoryDeltaBytes = %v, want 2048", got.MemoryDeltaBytes)
+	}
+}
+
+func TestPair_LeavesUnmatchedStartsOpenAndSkipsStrayStops(t *testing.T) {
+	otherProcess := measureEvent("e3", "2026-03-01T10:00:00.300Z", dump.MeasureStop, 0)
+	otherProcess.Host.PID = 11
+
+	events := []dump.Event{
+		measureEvent("e0", "2026-03-01T10:00:00.000Z", dump.MeasureStop, 0),
+		measureEvent("e1", "2026-03-01T10:00:00.100Z", dump.MeasureStart, 0),
+		measureEvent("e2", "2026-03-01T10:00:00.200Z", dump.MeasureStart, 0),
+		otherProcess,
+		measureEvent("e4", "2026-03-01T10:00:00.400Z", dump.MeasureStop, 0),
+	}
+
+	measures := Pair(events)
+	if len(measures) != 2 {
+		t.Fatalf("Pair() = %d measures, want 2", len(measures))
+	}
+	if first := measures[0]; !first.Open || first.DurationMs != nil {
+		t.Fatalf("Pair()[0] = %+v, want open measure", first)
+	}
+	if second := measures[1]; second.Open || second.StopEventID != "e4" || *second.DurationMs != 200 {
+		t.Fatalf("Pair()[1] = %+v, want e2 stopped by e4 after 200ms", second)
+	}
+}
@@ -0,0 +1,246 @@
+package monolog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"phant/internal/dump"
+)
+
+// maxLaravelRecordBytes bounds one record with its stack trace; lines past
+// it are dropped from the record.
+const maxLaravelRecordBytes = dump.DefaultMaxLineBytes
+
+var (
+	// laravelHeader matches the first line of a record written by Monolog's
+	// LineFormatter as Laravel configures it:
+	// "[2026-03-02 13:00:00] local.ERROR: message {context} [extra]".
+	laravelHeader = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\] (\S+?)\.([A-Z]+): ?(.*)$`)
+	// laravelException matches the first line of an exception as Laravel
+	// puts it in the context: "[object] (Class(code: 0): message at file:line)".
+	laravelException = regexp.MustCompile(`^\[object\] \((.+?)\(code: ([^)]*)\): (.*) at (.*):(\d+)\)$`)
+	// laravelFrame matches a stack trace line: "#0 file(line): function" or
+	// "#1 [internal function]: function".
+	laravelFrame = regexp.MustCompile(`^#\d+ (?:(.+)\((\d+)\)|\[internal function\]): (.+)$`)
+)
+
+// LaravelOptions controls ReadLaravelLog.
+type LaravelOptions struct {
+	// Host is the host of every event; zero uses this machine.
+	Host dump.HostMeta
+	// BatchLines is how many lines make up a batch; zero uses
+	// dump.DefaultBatchLines.
+	BatchLines int
+}
+
+// laravelEntry is one record being read: its header fields and the lines
+// that follow it.
+type laravelEntry struct {
+	datetime string
+	channel  string
+	level    string
+	text     strings.Builder
+}
+
+// ReadLaravelLog reads a laravel.log written by Monolog's LineFormatter and
+// emits its records as log events, in batches like
+// dump.DecodeNDJSONBatches. Lines up to the next record header, such as a
+// stack trace, belong to the record before them; lines before the first
+// record are reported as errors.
+func ReadLaravelLog(ctx context.Context, r io.Reader, options LaravelOptions, emit func(dump.Batch) error) (dump.StreamResult, error) {
+	if options.Host.Hostname == "" {
+		options.Host = localHost()
+	}
+	if options.BatchLines <= 0 {
+		options.BatchLines = dump.DefaultBatchLines
+	}
+
+	result := dump.StreamResult{Events: []dump.Event{}, Errors: []dump.LineError{}}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), dump.DefaultMaxLineBytes)
+
+	var (
+		batch   dump.Batch
+		record  *laravelEntry
+		stray   bool
+		bytes   int64
+		pending int
+	)
+	finish := func() {
+		if record != nil {
+			batch.Events = append(batch.Events, record.event(options.Host))
+			record = nil
+		}
+	}
+	flush := func() error {
+		batch.Lines = result.Lines
+		batch.Bytes = bytes
+		result.Errors = append(result.Errors, batch.Errors...)
+		err := emit(batch)
+		batch = dump.Batch{}
+		pending = 0
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		result.Lines++
+		bytes += int64(len(scanner.Bytes())) + 1
+
+		if match := laravelHeader.FindStringSubmatch(line); match != nil {
+			finish()
+			if pending >= options.BatchLines {
+				if err := flush(); err != nil {
+					return result, err
+				}
+				if err := ctx.Err(); err != nil {
+					return result, err
+				}
+			}
+			record = &laravelEntry{datetime: match[1], channel: match[2], level: strings.ToLower(match[3])}
+			record.text.WriteString(match[4])
+			stray = false
+		} else if record != nil {
+			if record.text.Len()+len(line) < maxLaravelRecordBytes {
+				record.text.WriteByte('\n')
+				record.text.WriteString(line)
+			}
+		} else if !stray && strings.TrimSpace(line) != "" {
+			batch.Errors = append(batch.Errors, dump.LineError{Line: result.Lines, Message: "line is not part of a Laravel log record"})
+			stray = true
+		}
+		pending++
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+
+	finish()
+	if len(batch.Events) > 0 || len(batch.Errors) > 0 {
+		if err := flush(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// event converts the record. The message is followed by the context and
+// extra as JSON; an exception in the context becomes the exception block,
+// and a stack trace written after a plain message becomes the trace.
+func (r *laravelEntry) event(host dump.HostMeta) dump.Event {
+	text := strings.TrimRight(r.text.String(), " \r\n")
+	text, extra := cutJSONSuffix(text)
+	text, context := cutJSONSuffix(text)
+	message, details, _ := strings.Cut(text, "\n")
+
+	payload, _ := json.Marshal(struct {
+		Message string          `json:"message"`
+		Context json.RawMessage `json:"context,omitempty"`
+		Extra   json.RawMessage `json:"extra,omitempty"`
+	}{strings.TrimRight(text, " "), nonEmpty(context), nonEmpty(extra)})
+
+	event := dump.Event{
+		SchemaVersion:         dump.SchemaVersion,
+		OriginalSchemaVersion: dump.SchemaVersion,
+		ID:                    newEventID(),
+		Timestamp:             timestamp(r.datetime),
+		SourceType:            "log",
+		ProjectRoot:           "/",
+		PHPSAPI:               "unknown",
+		Log:                   &dump.LogMeta{Channel: r.channel, Message: strings.TrimRight(message, " ")},
+		Level:                 r.level,
+		PayloadFormat:         dump.PayloadFormatJSON,
+		Payload:               payload,
+		Trace:                 laravelTrace(details),
+		Host:                  host,
+	}
+
+	var fields struct {
+		Exception string `json:"exception"`
+	}
+	if json.Unmarshal(context, &fields) == nil && fields.Exception != "" {
+		if exception := laravelExceptionChain(fields.Exception); exception != nil {
+			event.Exception = exception
+			event.Trace = exception.Trace
+			event.IsError = true
+		}
+	}
+	event.ProjectRoot = projectRoot(event.Trace)
+	return event
+}
+
+// cutJSONSuffix splits a trailing JSON object or array, which Monolog
+// separates from the rest by a space, off text. Line breaks inside its
+// strings are written as is and restored to escapes here.
+func cutJSONSuffix(text string) (string, json.RawMessage) {
+	escape := strings.NewReplacer("\r", `\r`, "\n", `\n`)
+	for i := 0; i < len(text); i++ {
+		if text[i] != '{' && text[i] != '[' || (i > 0 && text[i-1] != ' ') {
+			continue
+		}
+		candidate := escape.Replace(text[i:])
+		if json.Valid([]byte(candidate)) {
+			return strings.TrimRight(text[:i], " "), json.RawMessage(candidate)
+		}
+	}
+	return text, nil
+}
+
+// laravelExceptionChain parses the exception Laravel writes into the
+// context, with its stack trace and every previous exception.
+func laravelExceptionChain(text string) *dump.ExceptionMeta {
+	var first, last *dump.ExceptionMeta
+	for _, block := range strings.Split(text, "\n[previous exception] ") {
+		header, rest, _ := strings.Cut(block, "\n")
+		match := laravelException.FindStringSubmatch(header)
+		if match == nil {
+			break
+		}
+		line, _ := strconv.Atoi(match[5])
+		exception := &dump.ExceptionMeta{
+			Class:   match[1],
+			Message: match[3],
+			Code:    dump.ExceptionCode(match[2]),
+			File:    match[4],
+			Line:    line,
+			Trace:   laravelTrace(rest),
+		}
+		if first == nil {
+			first = exception
+		} else {
+			last.Previous = exception
+		}
+		last = exception
+	}
+	return first
+}
+
+func laravelTrace(text string) []dump.TraceFrame {
+	frames := []dump.TraceFrame{}
+	for line := range strings.SplitSeq(text, "\n") {
+		match := laravelFrame.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		frame := dump.TraceFrame{File: match[1], Func: match[3]}
+		frame.Line, _ = strconv.Atoi(match[2])
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// projectRoot guesses the application root from the first frame inside
+// vendor/, falling back to "/".
+func projectRoot(trace []dump.TraceFrame) string {
+	for _, frame := range trace {
+		if root, _, ok := strings.Cut(frame.File, "/vendor/"); ok && root != "" {
+			return root
+		}
+	}
+	return "/"
+}
@@ -0,0 +1,214 @@
+package monolog
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/netauth"
+)
+
+const laravelRecord = `{"message":"Payment failed","context":{"order":42},"level":400,"level_name":"ERROR","channel":"payments","datetime":"2026-03-02T13:00:00.123456+01:00","extra":{"uid":"a1b2c3","process_id":4242,"file":"/srv/app/app/Billing.php","line":88,"class":"App\\Billing","function":"charge","url":"/checkout?step=2","http_method":"POST","server":"shop.test","ip":"127.0.0.1"}}`
+
+func TestDecode_MapsRecordAndProcessorExtras(t *testing.T) {
+	event, err := Decode([]byte(laravelRecord), dump.HostMeta{Hostname: "devbox"})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if event.SourceType != "log" || event.Level != "error" {
+		t.Fatalf("Decode() sourceType = %q, level = %q, want log and error", event.SourceType, event.Level)
+	}
+	if event.Log == nil || event.Log.Channel != "payments" || event.Log.Message != "Payment failed" {
+		t.Fatalf("Decode() log = %+v, want payments channel", event.Log)
+	}
+	if event.Timestamp != "2026-03-02T12:00:00.123456Z" {
+		t.Fatalf("Decode() timestamp = %q, want 2026-03-02T12:00:00.123456Z", event.Timestamp)
+	}
+	if event.RequestID == nil || *event.RequestID != "a1b2c3" {
+		t.Fatalf("Decode() requestId = %v, want a1b2c3", event.RequestID)
+	}
+	if event.Host.Hostname != "devbox" || event.Host.PID != 4242 {
+		t.Fatalf("Decode() host = %+v, want devbox pid 4242", event.Host)
+	}
+	if len(event.Trace) != 1 || event.Trace[0].Func != `App\Billing::charge` || event.Trace[0].Line != 88 {
+		t.Fatalf("Decode() trace = %v, want Billing::charge:88", event.Trace)
+	}
+	if event.HTTP == nil || event.HTTP.Method != "POST" || event.HTTP.Host != "shop.test" || event.HTTP.Path != "/checkout" {
+		t.Fatalf("Decode() http = %+v, want POST shop.test/checkout", event.HTTP)
+	}
+	if len(event.Warnings) != 0 {
+		t.Fatalf("Decode() warnings = %v, want none", event.Warnings)
+	}
+
+	data, _ := event.Payload.MarshalJSON()
+	if want := `{"message":"Payment failed","context":{"order":42},"extra":`; string(data[:len(want)]) != want {
+		t.Fatalf("Decode() payload = %s, want message, context, and extra", data)
+	}
+}
+
+func TestDecode_MinimalRecord(t *testing.T) {
+	event, err := Decode([]byte(`{"message":"hi","context":[],"level":250,"channel":"app","datetime":"2026-03-02T12:00:00+00:00","extra":[]}`), dump.HostMeta{Hostname: "devbox"})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if event.Level != "notice" || string(event.Payload) != `{"message":"hi"}` {
+		t.Fatalf("Decode() level = %q, payload = %s, want notice and bare message", event.Level, event.Payload)
+	}
+	if len(event.Warnings) != 2 {
+		t.Fatalf("Decode() warnings = %v, want missing pid and trace", event.Warnings)
+	}
+}
+
+func TestDecode_RejectsNonRecords(t *testing.T) {
+	for _, line := range []string{`not json`, `{"message":"no channel","level":200}`, `{"channel":"app"}`} {
+		if _, err := Decode([]byte(line), dump.HostMeta{}); err == nil {
+			t.Fatalf("Decode(%s) error = nil, want error", line)
+		}
+	}
+}
+
+const laravelLog = `PHP Warning:  stray output
+[2026-03-02 13:00:00] local.INFO: User logged in {"user":7} []
+[2026-03-02 13:00:05] production.ERROR: Division by zero {"userId":7,"exception":"[object] (RuntimeException(code: 0): Report failed at /srv/app/app/Reports.php:40)
+[stacktrace]
+#0 /srv/app/vendor/laravel/framework/src/Illuminate/Routing/Controller.php(54): App\\Reports->build()
+#1 [internal function]: Illuminate\\Routing\\Controller->callAction()
+#2 {main}
+
+[previous exception] [object] (DivisionByZeroError(code: 0): Division by zero at /srv/app/app/Math.php:12)
+[stacktrace]
+#0 /srv/app/app/Reports.php(38): App\\Math::ratio()
+#1 {main}
+"} []
+[2026-03-02 13:00:06] local.WARNING: Disk almost full [] {"free":"2%"}
+`
+
+func TestReadLaravelLog_ParsesRecordsAndStackTraces(t *testing.T) {
+	var events []dump.Event
+	var errors []dump.LineError
+	result, err := ReadLaravelLog(context.Background(), strings.NewReader(laravelLog), LaravelOptions{Host: dump.HostMeta{Hostname: "devbox"}, BatchLines: 2}, func(batch dump.Batch) error {
+		events = append(events, batch.Events...)
+		errors = append(errors, batch.Errors...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadLaravelLog() error = %v", err)
+	}
+	if result.Lines != 14 || len(errors) != 1 || errors[0].Line != 1 {
+		t.Fatalf("ReadLaravelLog() lines = %d, errors = %v, want 14 lines and line 1 rejected", result.Lines, errors)
+	}
+	if len(events) != 3 {
+		t.Fatalf("ReadLaravelLog() events = %d, want 3", len(events))
+	}
+
+	info := events[0]
+	if info.SourceType != "log" || info.Level != "info" || info.Log.Channel != "local" || info.Log.Message != "User logged in" {
+		t.Fatalf("info event = %+v, want local info log", info)
+	}
+	if info.Timestamp != "2026-03-02T13:00:00Z" || string(info.Payload) != `{"message":"User logged in","context":{"user":7}}` {
+		t.Fatalf("info event timestamp = %q, payload = %s", info.Timestamp, info.Payload)
+	}
+
+	failure := events[1]
+	if !failure.IsError || failure.Level != "error" || failure.Log.Message != "Division by zero" {
+		t.Fatalf("error event = %+v, want an error", failure)
+	}
+	exception := failure.Exception
+	if exception == nil || exception.Class != "RuntimeException" || exception.Message != "Report failed" || exception.File != "/srv/app/app/Reports.php" || exception.Line != 40 {
+		t.Fatalf("exception = %+v, want RuntimeException at Reports.php:40", exception)
+	}
+	if len(failure.Trace) != 2 || failure.Trace[0].Line != 54 || failure.Trace[0].Func != `App\Reports->build()` || failure.Trace[1].File != "" {
+		t.Fatalf("trace = %+v, want the controller frame and an internal one", failure.Trace)
+	}
+	if exception.Previous == nil || exception.Previous.Class != "DivisionByZeroError" || len(exception.Previous.Trace) != 1 {
+		t.Fatalf("previous = %+v, want DivisionByZeroError with one frame", exception.Previous)
+	}
+	if failure.ProjectRoot != "/srv/app" {
+		t.Fatalf("projectRoot = %q, want /srv/app", failure.ProjectRoot)
+	}
+
+	if warning := events[2]; warning.Log.Message != "Disk almost full" || string(warning.Payload) != `{"message":"Disk almost full","extra":{"free":"2%"}}` {
+		t.Fatalf("warning event = %+v, payload = %s", warning.Log, warning.Payload)
+	}
+}
+
+func TestServer_IngestsNewlineDelimitedRecords(t *testing.T) {
+	events := make(chan dump.Event, 2)
+	server := NewServer("127.0.0.1:0", func(event dump.Event) { events <- event })
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.Address())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "%s\n{}\n%s\n", laravelRecord, laravelRecord)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-events:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d events, want 2", i)
+		}
+	}
+}
+
+func TestServer_RequiresHandshake(t *testing.T) {
+	guard := netauth.NewGuard()
+	if err := guard.SetToken("0123456789abcdef0123"); err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+	events := make(chan dump.Event, 2)
+	server := NewServer("127.0.0.1:0", func(event dump.Event) { events <- event })
+	server.SetAuth(guard)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer server.Stop()
+
+	refused, err := net.Dial("tcp", server.Address())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer refused.Close()
+	fmt.Fprintf(refused, "PHANT-AUTH wrong-token-wrong-token\n%s\n", laravelRecord)
+
+	accepted, err := net.Dial("tcp", server.Address())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer accepted.Close()
+	fmt.Fprintf(accepted, "PHANT-AUTH 0123456789abcdef0123\n%s\n", laravelRecord)
+
+	select {
+	case <-events:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no event received")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for server.Stats().Unauthorized != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Stats() = %+v, want 1 unauthorized", server.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("refused connection delivered %+v", event)
+	default:
+	}
+	if stats := server.Stats(); stats.Received != 1 {
+		t.Fatalf("Received = %d, want 1", stats.Received)
+	}
+}
@@ -0,0 +1,200 @@
+package monolog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+var ErrInvalidRecord = errors.New("not a Monolog JSON record")
+
+// Record is what Monolog's JsonFormatter writes for a log record.
+type Record struct {
+	Message   string          `json:"message"`
+	Context   json.RawMessage `json:"context"`
+	Level     int             `json:"level"`
+	LevelName string          `json:"level_name"`
+	Channel   string          `json:"channel"`
+	Datetime  string          `json:"datetime"`
+	Extra     Extra           `json:"extra"`
+}
+
+// Extra holds the fields Monolog's bundled processors add; anything else in
+// extra is kept in the payload only.
+type Extra struct {
+	UID        string `json:"uid"`
+	ProcessID  int    `json:"process_id"`
+	Hostname   string `json:"hostname"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Class      string `json:"class"`
+	Function   string `json:"function"`
+	URL        string `json:"url"`
+	HTTPMethod string `json:"http_method"`
+	Server     string `json:"server"`
+	IP         string `json:"ip"`
+
+	raw json.RawMessage
+}
+
+func (e *Extra) UnmarshalJSON(data []byte) error {
+	type plain Extra
+	var decoded plain
+	// Monolog writes an empty extra as [] rather than {}, and custom
+	// processors may reuse these keys with other types; either way the raw
+	// value is still kept for the payload.
+	if err := json.Unmarshal(data, &decoded); err == nil {
+		*e = Extra(decoded)
+	} else {
+		*e = Extra{}
+	}
+	e.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// Monolog's numeric levels, which match RFC 5424 scaled as Monolog does.
+var levelNames = map[int]string{
+	100: "debug",
+	200: "info",
+	250: "notice",
+	300: "warning",
+	400: "error",
+	500: "critical",
+	550: "alert",
+	600: "emergency",
+}
+
+var datetimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999-07:00",
+	"2006-01-02 15:04:05.999999",
+	"2006-01-02 15:04:05",
+}
+
+// Decode converts one JSON-formatted Monolog record into a log event. host
+// fills in what the processors did not provide.
+func Decode(line []byte, host dump.HostMeta) (dump.Event, error) {
+	var record Record
+	if err := json.Unmarshal(line, &record); err != nil {
+		return dump.Event{}, errors.Join(ErrInvalidRecord, err)
+	}
+	if record.Channel == "" || (record.LevelName == "" && record.Level == 0) {
+		return dump.Event{}, ErrInvalidRecord
+	}
+
+	payload, err := json.Marshal(struct {
+		Message string          `json:"message"`
+		Context json.RawMessage `json:"context,omitempty"`
+		Extra   json.RawMessage `json:"extra,omitempty"`
+	}{record.Message, nonEmpty(record.Context), nonEmpty(record.Extra.raw)})
+	if err != nil {
+		return dump.Event{}, err
+	}
+
+	event := dump.Event{
+		SchemaVersion:         dump.SchemaVersion,
+		OriginalSchemaVersion: dump.SchemaVersion,
+		ID:                    newEventID(),
+		Timestamp:             timestamp(record.Datetime),
+		SourceType:            "log",
+		ProjectRoot:           "/",
+		PHPSAPI:               "unknown",
+		Log:                   &dump.LogMeta{Channel: record.Channel, Message: record.Message},
+		Level:                 level(record),
+		PayloadFormat:         dump.PayloadFormatJSON,
+		Payload:               payload,
+		Trace:                 []dump.TraceFrame{},
+		Host:                  host,
+	}
+
+	extra := record.Extra
+	if extra.UID != "" {
+		uid := extra.UID
+		event.RequestID = &uid
+	}
+	if extra.Hostname != "" {
+		event.Host.Hostname = extra.Hostname
+	}
+	if extra.ProcessID > 0 {
+		event.Host.PID = extra.ProcessID
+	}
+	if extra.File != "" {
+		function := extra.Function
+		if extra.Class != "" && function != "" {
+			function = extra.Class + "::" + function
+		}
+		event.Trace = []dump.TraceFrame{{File: extra.File, Line: extra.Line, Func: function}}
+		event.ProjectRoot = filepath.Dir(extra.File)
+	}
+	if extra.URL != "" || extra.HTTPMethod != "" {
+		event.HTTP = httpMeta(extra)
+	}
+
+	if event.Host.PID <= 0 {
+		event.Warnings = append(event.Warnings, dump.Warning{
+			Field:   "host.pid",
+			Message: "record has no extra.process_id; add Monolog's ProcessIdProcessor",
+		})
+	}
+	if len(event.Trace) == 0 {
+		event.Warnings = append(event.Warnings, dump.Warning{
+			Field:   "trace",
+			Message: "trace is empty; add Monolog's IntrospectionProcessor for the callsite",
+		})
+	}
+	return event, nil
+}
+
+func level(record Record) string {
+	if name := strings.ToLower(record.LevelName); name != "" {
+		for _, known := range levelNames {
+			if known == name {
+				return name
+			}
+		}
+	}
+	return levelNames[record.Level]
+}
+
+func timestamp(datetime string) string {
+	for _, layout := range datetimeLayouts {
+		if parsed, err := time.Parse(layout, datetime); err == nil {
+			return parsed.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+func httpMeta(extra Extra) *dump.HTTPMeta {
+	meta := &dump.HTTPMeta{Method: extra.HTTPMethod, Host: extra.Server, ClientIP: extra.IP}
+	if parsed, err := url.Parse(extra.URL); err == nil {
+		meta.Path = parsed.Path
+		meta.Query = parsed.RawQuery
+		if parsed.Host != "" {
+			meta.Scheme = parsed.Scheme
+			meta.Host = parsed.Host
+		}
+	}
+	return meta
+}
+
+func nonEmpty(raw json.RawMessage) json.RawMessage {
+	switch strings.TrimSpace(string(raw)) {
+	case "", "[]", "{}", "null":
+		return nil
+	}
+	return raw
+}
+
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
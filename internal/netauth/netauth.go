@@ -0,0 +1,186 @@
+// Package netauth guards the network ingestion listeners with a shared
+// secret, so only producers configured with the token can reach the viewer
+// from the LAN.
+package netauth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TokenFileName = "listener-token"
+
+	// HandshakePrefix starts the line stream producers send before their
+	// first message: "PHANT-AUTH <token>\n".
+	HandshakePrefix = "PHANT-AUTH "
+
+	// HandshakeTimeout is how long a stream connection may take to send the
+	// handshake line.
+	HandshakeTimeout = 5 * time.Second
+
+	tokenBytes     = 32
+	minTokenLength = 16
+)
+
+var ErrShortToken = errors.New("auth token must be at least 16 characters")
+
+// NewToken returns a random hex token.
+func NewToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Guard checks credentials against the current token. A nil Guard or one
+// without a token lets everything through. The token may change while
+// listeners run; it applies to requests and connections from then on.
+type Guard struct {
+	mu    sync.RWMutex
+	token []byte
+}
+
+func NewGuard() *Guard {
+	return &Guard{}
+}
+
+// SetToken replaces the token; an empty token turns checking off.
+func (g *Guard) SetToken(token string) error {
+	if token != "" && len(token) < minTokenLength {
+		return ErrShortToken
+	}
+	g.mu.Lock()
+	g.token = []byte(token)
+	g.mu.Unlock()
+	return nil
+}
+
+func (g *Guard) Enabled() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.token) > 0
+}
+
+// AllowRequest checks an "Authorization: Bearer <token>" header.
+func (g *Guard) AllowRequest(r *http.Request) bool {
+	if !g.Enabled() {
+		return true
+	}
+	scheme, credential, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	return ok && strings.EqualFold(scheme, "Bearer") && g.matches([]byte(strings.TrimSpace(credential)))
+}
+
+// AllowHandshake checks the first line of a stream connection.
+func (g *Guard) AllowHandshake(line []byte) bool {
+	if !g.Enabled() {
+		return true
+	}
+	credential, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte(HandshakePrefix))
+	return ok && g.matches(bytes.TrimSpace(credential))
+}
+
+func (g *Guard) matches(credential []byte) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.token) == 0 || subtle.ConstantTimeCompare(credential, g.token) == 1
+}
+
+// Handshake tracks the opening line of one stream connection.
+type Handshake struct {
+	guard   *Guard
+	conn    net.Conn
+	timer   *time.Timer
+	pending bool
+	refused bool
+}
+
+// Expect starts waiting for conn's handshake. The connection is closed if
+// the line does not arrive within HandshakeTimeout.
+func (g *Guard) Expect(conn net.Conn) *Handshake {
+	handshake := &Handshake{guard: g, conn: conn, pending: g.Enabled()}
+	if handshake.pending {
+		handshake.timer = time.AfterFunc(HandshakeTimeout, func() { conn.Close() })
+	}
+	return handshake
+}
+
+// Admit reports whether line is a message to handle. It consumes the
+// handshake line and closes the connection when the handshake is wrong;
+// lines read after that are never admitted.
+func (h *Handshake) Admit(line []byte) bool {
+	if h.refused {
+		return false
+	}
+	if !h.pending {
+		return true
+	}
+
+	h.pending = false
+	h.timer.Stop()
+	if !h.guard.AllowHandshake(line) {
+		h.refused = true
+		h.conn.Close()
+	}
+	return false
+}
+
+// Done stops waiting and reports whether the connection was refused,
+// including when it ended before sending a handshake.
+func (h *Handshake) Done() bool {
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	return h.refused || h.pending
+}
+
+// LoadToken reads the token stored in dir, returning "" when there is none.
+func LoadToken(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, TokenFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SaveToken writes the token to dir, readable only by the current user.
+func SaveToken(dir string, token string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, TokenFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.WriteString(token + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, TokenFileName))
+}
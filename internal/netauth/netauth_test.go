@@ -0,0 +1,157 @@
+package netauth
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const testToken = "0123456789abcdef0123"
+
+func TestGuard_AllowRequest(t *testing.T) {
+	guard := NewGuard()
+	request, _ := http.NewRequest(http.MethodPost, "http://phant.test/", nil)
+	if !guard.AllowRequest(request) {
+		t.Fatalf("AllowRequest() = false without a token, want true")
+	}
+
+	if err := guard.SetToken(testToken); err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"Bearer " + testToken, true},
+		{"bearer " + testToken, true},
+		{"Basic " + testToken, false},
+		{"Bearer " + testToken + "x", false},
+		{"Bearer", false},
+	}
+	for _, tt := range tests {
+		request.Header.Set("Authorization", tt.header)
+		if got := guard.AllowRequest(request); got != tt.want {
+			t.Fatalf("AllowRequest(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+
+	var none *Guard
+	if !none.AllowRequest(request) {
+		t.Fatalf("nil AllowRequest() = false, want true")
+	}
+}
+
+func TestGuard_AllowHandshake(t *testing.T) {
+	guard := NewGuard()
+	if err := guard.SetToken(testToken); err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"PHANT-AUTH " + testToken, true},
+		{"PHANT-AUTH " + testToken + "\r", true},
+		{"PHANT-AUTH nope", false},
+		{testToken, false},
+		{`{"message":"hi"}`, false},
+	}
+	for _, tt := range tests {
+		if got := guard.AllowHandshake([]byte(tt.line)); got != tt.want {
+			t.Fatalf("AllowHandshake(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestGuard_SetTokenRejectsShortTokens(t *testing.T) {
+	if err := NewGuard().SetToken("short"); err != ErrShortToken {
+		t.Fatalf("SetToken() error = %v, want %v", err, ErrShortToken)
+	}
+}
+
+func TestHandshake_ConsumesFirstLineAndRefusesWrongOnes(t *testing.T) {
+	guard := NewGuard()
+	if err := guard.SetToken(testToken); err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	handshake := guard.Expect(server)
+	if handshake.Admit([]byte("PHANT-AUTH " + testToken)) {
+		t.Fatalf("Admit(handshake) = true, want false")
+	}
+	if !handshake.Admit([]byte("message")) {
+		t.Fatalf("Admit(message) = false, want true")
+	}
+	if handshake.Done() {
+		t.Fatalf("Done() = true, want false")
+	}
+
+	server, client = net.Pipe()
+	defer client.Close()
+	handshake = guard.Expect(server)
+	if handshake.Admit([]byte("message")) || handshake.Admit([]byte("message")) {
+		t.Fatalf("Admit() = true after a wrong handshake, want false")
+	}
+	if !handshake.Done() {
+		t.Fatalf("Done() = false, want true")
+	}
+	if _, err := server.Write([]byte("x")); err == nil {
+		t.Fatalf("refused connection still open")
+	}
+}
+
+func TestHandshake_ClosesSilentConnections(t *testing.T) {
+	guard := NewGuard()
+	if err := guard.SetToken(testToken); err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	handshake := &Handshake{guard: guard, conn: server, pending: true}
+	handshake.timer = time.AfterFunc(10*time.Millisecond, func() { server.Close() })
+
+	if _, err := server.Read(make([]byte, 1)); err == nil {
+		t.Fatalf("Read() error = nil, want closed connection")
+	}
+	if !handshake.Done() {
+		t.Fatalf("Done() = false, want true")
+	}
+}
+
+func TestSaveToken_RoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	if token, err := LoadToken(dir); err != nil || token != "" {
+		t.Fatalf("LoadToken() = %q, %v, want empty", token, err)
+	}
+
+	token, err := NewToken()
+	if err != nil {
+		t.Fatalf("NewToken() error = %v", err)
+	}
+	if len(token) != 2*tokenBytes {
+		t.Fatalf("NewToken() length = %d, want %d", len(token), 2*tokenBytes)
+	}
+	if err := SaveToken(dir, token); err != nil {
+		t.Fatalf("SaveToken() error = %v", err)
+	}
+	if loaded, err := LoadToken(dir); err != nil || loaded != token {
+		t.Fatalf("LoadToken() = %q, %v, want %q", loaded, err, token)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, TokenFileName))
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Fatalf("token file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,148 @@
+// Package netline reads newline-delimited messages from stream connections
+// in a way a misbehaving or flaky client cannot use to corrupt or stall
+// ingestion.
+package netline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	DefaultMaxLineBytes = 4 * 1024 * 1024
+	// DefaultPartialLineTimeout is how long the rest of a started line may
+	// take to arrive. Idle time between lines is not limited, since
+	// producers such as ServerDumper keep their socket open across dumps.
+	DefaultPartialLineTimeout = 30 * time.Second
+
+	readChunk = 32 * 1024
+	// keepBuffer is the largest line buffer reused between lines; a rare
+	// huge line does not pin its memory for the life of the connection.
+	keepBuffer = 1024 * 1024
+
+	// gzipMagic starts every gzip stream. No text line starts with it, so
+	// it tells compressed connections apart without any negotiation.
+	gzipMagic = 0x1f
+)
+
+type Options struct {
+	MaxLineBytes       int
+	PartialLineTimeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxLineBytes <= 0 {
+		o.MaxLineBytes = DefaultMaxLineBytes
+	}
+	if o.PartialLineTimeout <= 0 {
+		o.PartialLineTimeout = DefaultPartialLineTimeout
+	}
+	return o
+}
+
+// Result summarises one connection.
+type Result struct {
+	Lines int
+	// Oversized counts lines longer than MaxLineBytes; they are skipped and
+	// reading resumes at the next newline.
+	Oversized int
+	// Torn is set when the connection ended or stalled in the middle of a
+	// line. The partial line is dropped, never handled.
+	Torn bool
+	// Err is the read error that ended the connection, other than a normal
+	// close.
+	Err error
+	// Compressed is set when the connection sent a gzip stream.
+	Compressed bool
+}
+
+// Read calls handle for every complete line until the connection ends.
+// Lines are passed without their "\n" or "\r\n" terminator, and the slice is
+// only valid until handle returns.
+//
+// A connection whose first byte starts a gzip stream is decompressed as it
+// arrives; the line limits apply to the decompressed lines. Producers that
+// keep the connection open must flush the compressor after each line.
+// Concatenated gzip members are read as one stream.
+func Read(conn net.Conn, options Options, handle func(line []byte)) Result {
+	options = options.withDefaults()
+
+	var result Result
+	buf := make([]byte, 0, 64*1024)
+	chunk := make([]byte, readChunk)
+	discarding := false
+	var source io.Reader = conn
+	sniffed := false
+
+	for {
+		var deadline time.Time
+		if len(buf) > 0 || discarding {
+			deadline = time.Now().Add(options.PartialLineTimeout)
+		}
+		conn.SetReadDeadline(deadline)
+
+		n, err := source.Read(chunk)
+		if !sniffed && n > 0 {
+			sniffed = true
+			if chunk[0] == gzipMagic {
+				result.Compressed = true
+				if source, err = gunzip(conn, chunk[:n], options.PartialLineTimeout); err != nil {
+					result.Err = err
+					return result
+				}
+				continue
+			}
+		}
+		data := chunk[:n]
+		for len(data) > 0 {
+			end := bytes.IndexByte(data, '\n')
+			if end < 0 {
+				if !discarding {
+					buf = append(buf, data...)
+					if len(buf) > options.MaxLineBytes {
+						discarding = true
+						result.Oversized++
+						buf = buf[:0]
+					}
+				}
+				break
+			}
+
+			if discarding {
+				discarding = false
+			} else if len(buf)+end > options.MaxLineBytes {
+				result.Oversized++
+			} else {
+				buf = append(buf, data[:end]...)
+				handle(bytes.TrimSuffix(buf, []byte{'\r'}))
+				result.Lines++
+			}
+
+			if cap(buf) > keepBuffer {
+				buf = make([]byte, 0, 64*1024)
+			}
+			buf = buf[:0]
+			data = data[end+1:]
+		}
+
+		if err != nil {
+			result.Torn = len(buf) > 0 || discarding
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				result.Err = err
+			}
+			return result
+		}
+	}
+}
+
+// gunzip decompresses the rest of conn, starting with the bytes already
+// read. The gzip header is read at once, so it gets the partial line
+// timeout.
+func gunzip(conn net.Conn, head []byte, timeout time.Duration) (io.Reader, error) {
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	return gzip.NewReader(io.MultiReader(bytes.NewReader(bytes.Clone(head)), conn))
+}
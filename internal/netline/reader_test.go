@@ -0,0 +1,113 @@
+package netline
+
+import (
+	"compress/gzip"
+	"errors"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve runs Read on the server side of a pipe and returns the lines it
+// handled once the client side is done.
+func serve(t *testing.T, options Options, client func(net.Conn)) ([]string, Result) {
+	t.Helper()
+
+	server, clientConn := net.Pipe()
+	done := make(chan Result, 1)
+	var lines []string
+	go func() {
+		done <- Read(server, options, func(line []byte) { lines = append(lines, string(line)) })
+		server.Close()
+	}()
+
+	client(clientConn)
+	clientConn.Close()
+
+	select {
+	case result := <-done:
+		return lines, result
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Read() did not return")
+		return nil, Result{}
+	}
+}
+
+func TestRead_ReassemblesSplitLinesAndDropsTornTail(t *testing.T) {
+	lines, result := serve(t, Options{}, func(conn net.Conn) {
+		for _, part := range []string{`{"a":`, "1}\r\n{\"b\"", ":2}\n\n", `{"torn":`} {
+			conn.Write([]byte(part))
+		}
+	})
+
+	if want := []string{`{"a":1}`, `{"b":2}`, ""}; !slices.Equal(lines, want) {
+		t.Fatalf("Read() lines = %q, want %q", lines, want)
+	}
+	if !result.Torn || result.Err != nil {
+		t.Fatalf("Read() result = %+v, want torn without error", result)
+	}
+}
+
+func TestRead_SkipsOversizedLinesAndContinues(t *testing.T) {
+	lines, result := serve(t, Options{MaxLineBytes: 8}, func(conn net.Conn) {
+		conn.Write([]byte("short\n" + strings.Repeat("x", 20)))
+		conn.Write([]byte(strings.Repeat("y", 20) + "\nnext\n123456789\n"))
+	})
+
+	if want := []string{"short", "next"}; !slices.Equal(lines, want) {
+		t.Fatalf("Read() lines = %q, want %q", lines, want)
+	}
+	if result.Oversized != 2 || result.Torn {
+		t.Fatalf("Read() result = %+v, want 2 oversized, not torn", result)
+	}
+}
+
+func TestRead_TimesOutStalledLinesButNotIdleConnections(t *testing.T) {
+	lines, result := serve(t, Options{PartialLineTimeout: 50 * time.Millisecond}, func(conn net.Conn) {
+		time.Sleep(100 * time.Millisecond)
+		conn.Write([]byte("after idle\n{\"stalled\":"))
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	if want := []string{"after idle"}; !slices.Equal(lines, want) {
+		t.Fatalf("Read() lines = %q, want %q", lines, want)
+	}
+	if !result.Torn || !errors.Is(result.Err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Read() result = %+v, want torn by deadline", result)
+	}
+}
+
+func TestRead_DecompressesGzipStreams(t *testing.T) {
+	lines, result := serve(t, Options{MaxLineBytes: 16}, func(conn net.Conn) {
+		compressed := gzip.NewWriter(conn)
+		compressed.Write([]byte("{\"a\":1}\n{\"b\""))
+		compressed.Flush()
+		compressed.Write([]byte(":2}\n" + strings.Repeat("x", 40) + "\n"))
+		compressed.Close()
+
+		// A second member continues the same stream.
+		compressed = gzip.NewWriter(conn)
+		compressed.Write([]byte("{\"c\":3}\n"))
+		compressed.Close()
+	})
+
+	if want := []string{`{"a":1}`, `{"b":2}`, `{"c":3}`}; !slices.Equal(lines, want) {
+		t.Fatalf("Read() lines = %q, want %q", lines, want)
+	}
+	if !result.Compressed || result.Oversized != 1 || result.Torn || result.Err != nil {
+		t.Fatalf("Read() result = %+v, want compressed, 1 oversized, no error", result)
+	}
+}
+
+func TestRead_ReportsCorruptGzipStreams(t *testing.T) {
+	lines, result := serve(t, Options{}, func(conn net.Conn) {
+		conn.Write([]byte{0x1f, 0x8b, 0x08, 0, 0, 0, 0, 0, 0, 0xff, 'n', 'o', 't'})
+	})
+
+	if len(lines) != 0 || !result.Compressed || result.Err == nil {
+		t.Fatalf("Read() = %q, %+v, want no lines and an error", lines, result)
+	}
+}
@@ -0,0 +1,203 @@
+// Package nettls provides the TLS configuration of the network ingestion
+// listeners: a user-supplied certificate, or a self-signed one generated
+// once and kept next to the session logs so clients can pin its
+// fingerprint.
+package nettls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	ModeOff        = "off"
+	ModeSelfSigned = "self-signed"
+	ModeFiles      = "files"
+
+	CertFileName = "listener-cert.pem"
+	KeyFileName  = "listener-key.pem"
+
+	// selfSignedLifetime is how long a generated certificate is valid; it
+	// is regenerated once less than renewBefore remains.
+	selfSignedLifetime = 2 * 365 * 24 * time.Hour
+	renewBefore        = 30 * 24 * time.Hour
+)
+
+var ErrMissingFiles = errors.New("tls mode files requires a certificate and a key file")
+
+// Settings choose how the listeners serve TLS. CertFile and KeyFile are PEM
+// files, used only in files mode.
+type Settings struct {
+	Mode     string `json:"mode"`
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+}
+
+func (s Settings) Validate() error {
+	switch s.Mode {
+	case "", ModeOff, ModeSelfSigned:
+		return nil
+	case ModeFiles:
+		if s.CertFile == "" || s.KeyFile == "" {
+			return ErrMissingFiles
+		}
+		return nil
+	}
+	return errors.New("tls mode must be one of: off, self-signed, files")
+}
+
+func (s Settings) Enabled() bool {
+	return s.Mode == ModeSelfSigned || s.Mode == ModeFiles
+}
+
+// Certificate is a loaded listener certificate. Fingerprint is the
+// SHA-256 of the leaf certificate, as colon-separated hex pairs, which is
+// what clients pin.
+type Certificate struct {
+	Config      *tls.Config
+	Fingerprint string
+	Subject     string
+	DNSNames    []string
+	NotAfter    time.Time
+}
+
+// Load returns the certificate the settings select, or nil when TLS is
+// off. In self-signed mode the certificate stored in dir is reused, and
+// generated when missing or close to expiry.
+func Load(settings Settings, dir string) (*Certificate, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch settings.Mode {
+	case ModeFiles:
+		return loadPair(settings.CertFile, settings.KeyFile)
+	case ModeSelfSigned:
+		certFile, keyFile := filepath.Join(dir, CertFileName), filepath.Join(dir, KeyFileName)
+		certificate, err := loadPair(certFile, keyFile)
+		if err == nil && time.Until(certificate.NotAfter) > renewBefore {
+			return certificate, nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return Generate(dir)
+	}
+	return nil, nil
+}
+
+// Generate replaces the self-signed certificate stored in dir. Clients that
+// pinned the old fingerprint must pin the new one.
+func Generate(dir string) (*Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	dnsNames := []string{"localhost"}
+	if hostname != "" && hostname != "localhost" {
+		dnsNames = append(dnsNames, hostname)
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "phant ingest listener"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedLifetime),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              dnsNames,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	certFile, keyFile := filepath.Join(dir, CertFileName), filepath.Join(dir, KeyFileName)
+	if err := writeFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return nil, err
+	}
+	if err := writeFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return nil, err
+	}
+	return loadPair(certFile, keyFile)
+}
+
+func loadPair(certFile string, keyFile string) (*Certificate, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	pair.Leaf = leaf
+
+	return &Certificate{
+		Config:      &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12},
+		Fingerprint: Fingerprint(leaf.Raw),
+		Subject:     leaf.Subject.CommonName,
+		DNSNames:    leaf.DNSNames,
+		NotAfter:    leaf.NotAfter,
+	}, nil
+}
+
+// Fingerprint formats the SHA-256 of a DER certificate as AB:CD:... pairs.
+func Fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	digits := strings.ToUpper(hex.EncodeToString(sum[:]))
+	pairs := make([]string, 0, len(sum))
+	for i := 0; i < len(digits); i += 2 {
+		pairs = append(pairs, digits[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
+
+func writeFile(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
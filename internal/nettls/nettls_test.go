@@ -0,0 +1,103 @@
+package nettls
+
+import (
+	"crypto/tls"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSettings_Validate(t *testing.T) {
+	tests := []struct {
+		settings Settings
+		wantErr  bool
+	}{
+		{Settings{}, false},
+		{Settings{Mode: ModeOff}, false},
+		{Settings{Mode: ModeSelfSigned}, false},
+		{Settings{Mode: ModeFiles, CertFile: "cert.pem", KeyFile: "key.pem"}, false},
+		{Settings{Mode: ModeFiles, CertFile: "cert.pem"}, true},
+		{Settings{Mode: "acme"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.settings.Validate(); (err != nil) != tt.wantErr {
+			t.Fatalf("Validate(%+v) error = %v, wantErr %v", tt.settings, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoad_ReusesSelfSignedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	if certificate, err := Load(Settings{Mode: ModeOff}, dir); err != nil || certificate != nil {
+		t.Fatalf("Load(off) = %v, %v, want nil", certificate, err)
+	}
+
+	first, err := Load(Settings{Mode: ModeSelfSigned}, dir)
+	if err != nil {
+		t.Fatalf("Load(self-signed) error = %v", err)
+	}
+	second, err := Load(Settings{Mode: ModeSelfSigned}, dir)
+	if err != nil {
+		t.Fatalf("Load(self-signed) error = %v", err)
+	}
+	if first.Fingerprint != second.Fingerprint || len(first.Fingerprint) != 95 {
+		t.Fatalf("fingerprints = %q, %q, want the same 32-byte fingerprint", first.Fingerprint, second.Fingerprint)
+	}
+
+	regenerated, err := Generate(dir)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if regenerated.Fingerprint == first.Fingerprint {
+		t.Fatalf("Generate() kept fingerprint %q, want a new one", first.Fingerprint)
+	}
+
+	files, err := Load(Settings{Mode: ModeFiles, CertFile: filepath.Join(dir, CertFileName), KeyFile: filepath.Join(dir, KeyFileName)}, "")
+	if err != nil || files.Fingerprint != regenerated.Fingerprint {
+		t.Fatalf("Load(files) = %v, %v, want the stored certificate", files, err)
+	}
+	if _, err := Load(Settings{Mode: ModeFiles, CertFile: filepath.Join(dir, "missing.pem"), KeyFile: filepath.Join(dir, KeyFileName)}, ""); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Load(missing files) error = %v, want not exist", err)
+	}
+}
+
+func TestCertificate_ServesPinnedTLS(t *testing.T) {
+	certificate, err := Generate(t.TempDir())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", certificate.Config)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.Write([]byte("ok"))
+			conn.Close()
+		}
+	}()
+
+	// Clients pin the fingerprint rather than verifying a CA chain.
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if Fingerprint(state.PeerCertificates[0].Raw) != certificate.Fingerprint {
+				return errors.New("fingerprint mismatch")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	reply := make([]byte, 2)
+	if _, err := conn.Read(reply); err != nil || string(reply) != "ok" {
+		t.Fatalf("Read() = %q, %v, want ok", reply, err)
+	}
+}
@@ -0,0 +1,212 @@
+// Package notify decides which incoming events deserve a desktop
+// notification: dd() calls and errors, unless their project is muted or
+// has already notified too often within the last minute.
+package notify
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+const (
+	DefaultPerMinute = 5
+	MaxPerMinute     = 60
+
+	// window is the span PerMinute applies to.
+	window = time.Minute
+	// maxBodyLength keeps bodies within what notification centers show.
+	maxBodyLength = 200
+)
+
+// Settings choose what notifies. PerMinute caps notifications per project
+// within any minute; events over the cap are counted and mentioned by the
+// next notification instead, so a dd() in a loop shows a handful of popups
+// rather than hundreds.
+type Settings struct {
+	Enabled       bool     `json:"enabled"`
+	DD            bool     `json:"dd"`
+	Errors        bool     `json:"errors"`
+	PerMinute     int      `json:"perMinute"`
+	MutedProjects []string `json:"mutedProjects"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{Enabled: true, DD: true, Errors: true, PerMinute: DefaultPerMinute, MutedProjects: []string{}}
+}
+
+func (s Settings) Validate() error {
+	if s.PerMinute < 1 || s.PerMinute > MaxPerMinute {
+		return fmt.Errorf("perMinute must be between 1 and %d", MaxPerMinute)
+	}
+	return nil
+}
+
+// Notification is what to show for one event. ID is stable per event, so
+// a platform that replaces notifications by ID never shows one twice.
+type Notification struct {
+	ID      string `json:"id"`
+	EventID string `json:"eventId"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+}
+
+type Stats struct {
+	Sent uint64 `json:"sent"`
+	// Suppressed counts events that would have notified but were over
+	// their project's rate limit.
+	Suppressed uint64 `json:"suppressed"`
+}
+
+type projectWindow struct {
+	sentAt     []time.Time
+	suppressed int
+}
+
+type Notifier struct {
+	mu         sync.Mutex
+	settings   Settings
+	muted      map[string]bool
+	projects   map[string]*projectWindow
+	sent       uint64
+	suppressed uint64
+}
+
+func New() *Notifier {
+	notifier := &Notifier{projects: map[string]*projectWindow{}}
+	notifier.apply(DefaultSettings())
+	return notifier
+}
+
+func (n *Notifier) Settings() Settings {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	settings := n.settings
+	settings.MutedProjects = append([]string{}, n.settings.MutedProjects...)
+	return settings
+}
+
+func (n *Notifier) SetSettings(settings Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.apply(settings)
+	return nil
+}
+
+func (n *Notifier) apply(settings Settings) {
+	muted := make(map[string]bool, len(settings.MutedProjects))
+	projects := []string{}
+	for _, project := range settings.MutedProjects {
+		if project = strings.TrimSpace(project); project != "" && !muted[project] {
+			muted[project] = true
+			projects = append(projects, project)
+		}
+	}
+	settings.MutedProjects = projects
+	n.settings = settings
+	n.muted = muted
+}
+
+func (n *Notifier) Stats() Stats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return Stats{Sent: n.sent, Suppressed: n.suppressed}
+}
+
+// Consider returns the notification for an event, if it should show one at
+// now. Callers only ask while the user is not looking at phant, so events
+// seen in the window never count against the limit.
+func (n *Notifier) Consider(event dump.Event, now time.Time) (Notification, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	settings := n.settings
+	dd := event.IsDD && settings.DD
+	failed := IsError(event) && settings.Errors
+	if !settings.Enabled || !dd && !failed || n.muted[event.ProjectRoot] {
+		return Notification{}, false
+	}
+
+	project := n.projects[event.ProjectRoot]
+	if project == nil {
+		project = &projectWindow{}
+		n.projects[event.ProjectRoot] = project
+	}
+	recent := project.sentAt[:0]
+	for _, sentAt := range project.sentAt {
+		if now.Sub(sentAt) < window {
+			recent = append(recent, sentAt)
+		}
+	}
+	project.sentAt = recent
+	if len(project.sentAt) >= settings.PerMinute {
+		project.suppressed++
+		n.suppressed++
+		return Notification{}, false
+	}
+
+	project.sentAt = append(project.sentAt, now)
+	notification := build(event, dd, project.suppressed)
+	project.suppressed = 0
+	n.sent++
+	return notification, true
+}
+
+// IsError reports events that report a failure: flagged errors,
+// exceptions, and error level or above.
+func IsError(event dump.Event) bool {
+	if event.IsError || event.Exception != nil {
+		return true
+	}
+	switch event.Level {
+	case "error", "critical", "alert", "emergency":
+		return true
+	}
+	return false
+}
+
+func build(event dump.Event, dd bool, suppressed int) Notification {
+	where := event.Host.Hostname
+	if event.ProjectRoot != "" {
+		where = path.Base(event.ProjectRoot)
+	}
+	title := "Error"
+	if dd {
+		title = "dd()"
+	}
+	if where != "" {
+		title += " in " + where
+	}
+
+	var lines []string
+	switch {
+	case event.Exception != nil:
+		lines = append(lines, event.Exception.Class+": "+event.Exception.Message)
+	case event.Log != nil:
+		lines = append(lines, event.Log.Message)
+	case event.Label != "":
+		lines = append(lines, event.Label)
+	}
+	if origin := query.Origin(event); origin != "" {
+		lines = append(lines, path.Base(origin))
+	}
+	if suppressed > 0 {
+		lines = append(lines, fmt.Sprintf("%d more in the last minute", suppressed))
+	}
+
+	body := strings.Join(lines, "\n")
+	if len(body) > maxBodyLength {
+		body = strings.ToValidUTF8(body[:maxBodyLength], "") + "…"
+	}
+	return Notification{ID: "phant-" + event.ID, EventID: event.ID, Title: title, Body: body}
+}
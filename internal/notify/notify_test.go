@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func TestNotifier_SelectsDDAndErrors(t *testing.T) {
+	notifier := New()
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		event dump.Event
+		want  bool
+	}{
+		{dump.Event{ID: "1", ProjectRoot: "/a", IsDD: true}, true},
+		{dump.Event{ID: "2", ProjectRoot: "/a", Level: "critical"}, true},
+		{dump.Event{ID: "3", ProjectRoot: "/a", Exception: &dump.ExceptionMeta{Class: "RuntimeException"}}, true},
+		{dump.Event{ID: "4", ProjectRoot: "/a", Level: "warning"}, false},
+		{dump.Event{ID: "5", ProjectRoot: "/a"}, false},
+	}
+	for _, tt := range tests {
+		if _, got := notifier.Consider(tt.event, now); got != tt.want {
+			t.Fatalf("Consider(%s) = %v, want %v", tt.event.ID, got, tt.want)
+		}
+	}
+
+	settings := DefaultSettings()
+	settings.DD = false
+	settings.MutedProjects = []string{"/b"}
+	if err := notifier.SetSettings(settings); err != nil {
+		t.Fatalf("SetSettings() error = %v", err)
+	}
+	if _, ok := notifier.Consider(dump.Event{ID: "6", ProjectRoot: "/a", IsDD: true}, now); ok {
+		t.Fatalf("Consider(dd) notified with dd notifications off")
+	}
+	if _, ok := notifier.Consider(dump.Event{ID: "7", ProjectRoot: "/b", IsError: true}, now); ok {
+		t.Fatalf("Consider(muted project) notified")
+	}
+}
+
+func TestNotifier_RateLimitsPerProjectAndReportsSuppressed(t *testing.T) {
+	notifier := New()
+	settings := DefaultSettings()
+	settings.PerMinute = 2
+	if err := notifier.SetSettings(settings); err != nil {
+		t.Fatalf("SetSettings() error = %v", err)
+	}
+
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	sent := 0
+	for i := 0; i < 100; i++ {
+		if _, ok := notifier.Consider(dump.Event{ID: "dd", ProjectRoot: "/app", IsDD: true}, start.Add(time.Duration(i)*100*time.Millisecond)); ok {
+			sent++
+		}
+	}
+	if sent != 2 {
+		t.Fatalf("Consider() in a loop sent %d, want 2", sent)
+	}
+	if _, ok := notifier.Consider(dump.Event{ID: "other", ProjectRoot: "/other", IsDD: true}, start); !ok {
+		t.Fatalf("Consider(other project) was limited by /app")
+	}
+
+	notification, ok := notifier.Consider(dump.Event{ID: "later", ProjectRoot: "/app", IsDD: true, Label: "cart"}, start.Add(time.Minute))
+	if !ok {
+		t.Fatalf("Consider() after a minute was still limited")
+	}
+	if notification.Title != "dd() in app" || !strings.Contains(notification.Body, "cart") || !strings.Contains(notification.Body, "98 more") {
+		t.Fatalf("Consider() = %+v, want dd() in app mentioning 98 more", notification)
+	}
+	if stats := notifier.Stats(); stats.Sent != 4 || stats.Suppressed != 98 {
+		t.Fatalf("Stats() = %+v, want 4 sent and 98 suppressed", stats)
+	}
+
+	if err := notifier.SetSettings(Settings{PerMinute: 0}); err == nil {
+		t.Fatalf("SetSettings(perMinute 0) succeeded")
+	}
+}
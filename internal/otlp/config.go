@@ -0,0 +1,112 @@
+// Package otlp exports events to an OpenTelemetry collector over OTLP/HTTP
+// with JSON encoding, either as log records or as span events grouped by
+// request, so local dumps line up with the traces of the requests that
+// made them.
+package otlp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	"phant/internal/query"
+)
+
+// Signals an exporter can send events as.
+const (
+	// SignalLogs sends one log record per event, carrying the trace ID of
+	// its request.
+	SignalLogs = "logs"
+	// SignalTraces sends one span per request and batch, with an event for
+	// every dump.
+	SignalTraces = "traces"
+)
+
+const (
+	DefaultEndpoint    = "http://localhost:4318"
+	DefaultServiceName = "phant"
+
+	MaxHeaders           = 16
+	MaxServiceNameLength = 100
+)
+
+// Config says where and how events are exported. Endpoint is the base URL
+// of the collector's OTLP/HTTP receiver; /v1/logs or /v1/traces is appended
+// as the signal requires. Headers are sent with every request, typically
+// for authentication.
+type Config struct {
+	Enabled     bool              `json:"enabled"`
+	Endpoint    string            `json:"endpoint"`
+	Signal      string            `json:"signal"`
+	ServiceName string            `json:"serviceName"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Filter      query.Filter      `json:"filter"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Endpoint:    DefaultEndpoint,
+		Signal:      SignalLogs,
+		ServiceName: DefaultServiceName,
+	}
+}
+
+func (c Config) Validate() error {
+	_, err := c.compile()
+	return err
+}
+
+type compiledConfig struct {
+	Config
+	url     string
+	matcher query.Matcher
+}
+
+func (c Config) compile() (compiledConfig, error) {
+	switch c.Signal {
+	case SignalLogs, SignalTraces:
+	default:
+		return compiledConfig{}, errors.New("signal must be one of: logs, traces")
+	}
+
+	target, err := url.Parse(c.Endpoint)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return compiledConfig{}, errors.New("endpoint must be an absolute http or https URL")
+	}
+	if target.RawQuery != "" || target.Fragment != "" {
+		return compiledConfig{}, errors.New("endpoint must not have a query or fragment")
+	}
+
+	if strings.TrimSpace(c.ServiceName) == "" {
+		return compiledConfig{}, errors.New("serviceName must not be empty")
+	}
+	if len(c.ServiceName) > MaxServiceNameLength {
+		return compiledConfig{}, fmt.Errorf("serviceName must be at most %d characters", MaxServiceNameLength)
+	}
+
+	if len(c.Headers) > MaxHeaders {
+		return compiledConfig{}, fmt.Errorf("at most %d headers are allowed", MaxHeaders)
+	}
+	for name, value := range c.Headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return compiledConfig{}, fmt.Errorf("header name %q is not valid", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return compiledConfig{}, fmt.Errorf("header %q has a value that is not valid", name)
+		}
+	}
+
+	matcher, err := c.Filter.Compile()
+	if err != nil {
+		return compiledConfig{}, err
+	}
+
+	return compiledConfig{
+		Config:  c,
+		url:     strings.TrimSuffix(target.String(), "/") + "/v1/" + c.Signal,
+		matcher: matcher,
+	}, nil
+}
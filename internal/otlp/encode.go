@@ -0,0 +1,368 @@
+package otlp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+// MaxBodyBytes caps the payload text a record carries.
+const MaxBodyBytes = 64 * 1024
+
+const scopeName = "phant"
+
+// spanKindInternal is SPAN_KIND_INTERNAL.
+const spanKindInternal = 1
+
+// severities maps event levels to OpenTelemetry severity numbers.
+var severities = map[string]int{
+	"debug":     5,
+	"info":      9,
+	"notice":    10,
+	"warning":   13,
+	"error":     17,
+	"critical":  18,
+	"alert":     19,
+	"emergency": 21,
+}
+
+// The types below follow the OTLP/JSON encoding: 64-bit integers are
+// strings and trace and span IDs are hex rather than base64.
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    string   `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type instrumentationScope struct {
+	Name string `json:"name"`
+}
+
+type logsRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	Scope      instrumentationScope `json:"scope"`
+	LogRecords []logRecord          `json:"logRecords"`
+}
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber,omitempty"`
+	SeverityText         string     `json:"severityText,omitempty"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes"`
+	TraceID              string     `json:"traceId,omitempty"`
+	SpanID               string     `json:"spanId,omitempty"`
+}
+
+type tracesRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Scope instrumentationScope `json:"scope"`
+	Spans []span               `json:"spans"`
+}
+
+type span struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []keyValue  `json:"attributes"`
+	Events            []spanEvent `json:"events"`
+}
+
+type spanEvent struct {
+	TimeUnixNano string     `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []keyValue `json:"attributes"`
+}
+
+// encodeLogs renders events as one OTLP logs request.
+func encodeLogs(events []dump.Event, serviceName string, now time.Time) ([]byte, error) {
+	records := make([]logRecord, len(events))
+	for i, event := range events {
+		record := logRecord{
+			TimeUnixNano:         nanos(eventTime(event, now)),
+			ObservedTimeUnixNano: nanos(receivedTime(event, now)),
+			Body:                 stringValue(body(event)),
+			Attributes:           attributes(event),
+		}
+		if level := severityLevel(event); level != "" {
+			record.SeverityNumber = severities[level]
+			record.SeverityText = strings.ToUpper(level)
+		}
+		if requestID := requestID(event); requestID != "" {
+			record.TraceID, record.SpanID = traceContext(requestID)
+		}
+		records[i] = record
+	}
+
+	return json.Marshal(logsRequest{ResourceLogs: []resourceLogs{{
+		Resource:  serviceResource(serviceName),
+		ScopeLogs: []scopeLogs{{Scope: instrumentationScope{Name: scopeName}, LogRecords: records}},
+	}}})
+}
+
+// encodeTraces renders events as one OTLP traces request with a span for
+// each request, spanning its events in the batch. Events without a request
+// ID get a span of their own.
+func encodeTraces(events []dump.Event, serviceName string, now time.Time) ([]byte, error) {
+	var spans []span
+	index := map[string]int{}
+	for _, event := range events {
+		key := requestID(event)
+		if key == "" {
+			key = event.ID
+		}
+		at := eventTime(event, now)
+
+		i, ok := index[key]
+		if !ok {
+			traceID, parentID := traceContext(key)
+			i = len(spans)
+			index[key] = i
+			spans = append(spans, span{
+				TraceID:           traceID,
+				SpanID:            newSpanID(),
+				ParentSpanID:      parentID,
+				Name:              spanName(event),
+				Kind:              spanKindInternal,
+				StartTimeUnixNano: nanos(at),
+				EndTimeUnixNano:   nanos(at),
+				Attributes:        spanAttributes(event),
+			})
+		}
+
+		s := &spans[i]
+		if start, _ := strconv.ParseInt(s.StartTimeUnixNano, 10, 64); at.UnixNano() < start {
+			s.StartTimeUnixNano = nanos(at)
+		}
+		if end, _ := strconv.ParseInt(s.EndTimeUnixNano, 10, 64); at.UnixNano() > end {
+			s.EndTimeUnixNano = nanos(at)
+		}
+		s.Events = append(s.Events, spanEvent{
+			TimeUnixNano: nanos(at),
+			Name:         eventName(event),
+			Attributes:   append(attributes(event), stringAttribute("phant.payload", body(event))),
+		})
+	}
+
+	return json.Marshal(tracesRequest{ResourceSpans: []resourceSpans{{
+		Resource:   serviceResource(serviceName),
+		ScopeSpans: []scopeSpans{{Scope: instrumentationScope{Name: scopeName}, Spans: spans}},
+	}}})
+}
+
+// traceContext derives the trace a request ID belongs to. A W3C
+// traceparent or a bare 32-digit trace ID is used as is, along with the
+// parent span a traceparent names; any other ID is hashed, so every event
+// of a request still shares one trace.
+func traceContext(requestID string) (traceID string, spanID string) {
+	id := strings.ToLower(strings.TrimSpace(requestID))
+	if parts := strings.Split(id, "-"); len(parts) == 4 && len(parts[0]) == 2 && isTraceID(parts[1]) && isSpanID(parts[2]) {
+		return parts[1], parts[2]
+	}
+	if isTraceID(id) {
+		return id, ""
+	}
+	sum := sha256.Sum256([]byte(requestID))
+	return hex.EncodeToString(sum[:16]), ""
+}
+
+func isTraceID(id string) bool {
+	return len(id) == 32 && isHex(id) && id != strings.Repeat("0", 32)
+}
+
+func isSpanID(id string) bool {
+	return len(id) == 16 && isHex(id) && id != strings.Repeat("0", 16)
+}
+
+func isHex(text string) bool {
+	for _, c := range text {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func newSpanID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+func serviceResource(serviceName string) resource {
+	return resource{Attributes: []keyValue{stringAttribute("service.name", serviceName)}}
+}
+
+// attributes describes an event with the semantic convention names where
+// one fits and phant.* names otherwise. Empty values are left out.
+func attributes(event dump.Event) []keyValue {
+	var attrs []keyValue
+	add := func(key string, value string) {
+		if value != "" {
+			attrs = append(attrs, stringAttribute(key, value))
+		}
+	}
+
+	add("phant.event.id", event.ID)
+	add("phant.source_type", event.SourceType)
+	add("phant.project_root", event.ProjectRoot)
+	add("phant.request_id", requestID(event))
+	add("phant.label", event.Label)
+	add("phant.origin", query.Origin(event))
+	add("host.name", event.Host.Hostname)
+	if event.Host.PID > 0 {
+		attrs = append(attrs, keyValue{Key: "process.pid", Value: anyValue{IntValue: strconv.Itoa(event.Host.PID)}})
+	}
+	if event.DurationMs != nil {
+		duration := *event.DurationMs
+		attrs = append(attrs, keyValue{Key: "phant.duration_ms", Value: anyValue{DoubleValue: &duration}})
+	}
+	if event.HTTP != nil {
+		add("http.request.method", event.HTTP.Method)
+		add("server.address", event.HTTP.Host)
+		add("url.path", event.HTTP.Path)
+	}
+	if event.Command != nil {
+		add("process.command", event.Command.Name)
+	}
+	if event.Log != nil {
+		add("phant.log.channel", event.Log.Channel)
+	}
+	if event.SQL != nil {
+		add("db.query.text", event.SQL.Query)
+	}
+	if event.Exception != nil {
+		add("exception.type", event.Exception.Class)
+		add("exception.message", event.Exception.Message)
+	}
+	return attrs
+}
+
+func spanAttributes(event dump.Event) []keyValue {
+	attrs := []keyValue{}
+	if id := requestID(event); id != "" {
+		attrs = append(attrs, stringAttribute("phant.request_id", id))
+	}
+	if event.ProjectRoot != "" {
+		attrs = append(attrs, stringAttribute("phant.project_root", event.ProjectRoot))
+	}
+	return attrs
+}
+
+func spanName(event dump.Event) string {
+	if event.HTTP != nil && event.HTTP.Method != "" {
+		return fmt.Sprintf("phant %s %s", event.HTTP.Method, event.HTTP.Path)
+	}
+	if event.Command != nil && event.Command.Name != "" {
+		return "phant " + event.Command.Name
+	}
+	return "phant " + event.SourceType
+}
+
+func eventName(event dump.Event) string {
+	if event.Label != "" {
+		return event.Label
+	}
+	return event.SourceType
+}
+
+func severityLevel(event dump.Event) string {
+	if _, ok := severities[event.Level]; ok {
+		return event.Level
+	}
+	if event.IsError || event.Exception != nil {
+		return "error"
+	}
+	return ""
+}
+
+func requestID(event dump.Event) string {
+	if event.RequestID == nil {
+		return ""
+	}
+	return *event.RequestID
+}
+
+// body is the payload as text, cut to MaxBodyBytes. Binary payloads are
+// described rather than sent.
+func body(event dump.Event) string {
+	if event.PayloadFormat == dump.PayloadFormatBinary {
+		return fmt.Sprintf("%s, %d bytes", event.MimeType, event.OriginalBytes)
+	}
+	text := event.PayloadString()
+	if len(text) <= MaxBodyBytes {
+		return text
+	}
+	end := MaxBodyBytes
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[:end] + "…"
+}
+
+func eventTime(event dump.Event, now time.Time) time.Time {
+	if at, err := time.Parse(time.RFC3339Nano, event.Timestamp); err == nil {
+		return at
+	}
+	return receivedTime(event, now)
+}
+
+func receivedTime(event dump.Event, now time.Time) time.Time {
+	if at, err := time.Parse(time.RFC3339Nano, event.ReceivedAt); err == nil {
+		return at
+	}
+	return now
+}
+
+func nanos(at time.Time) string {
+	return strconv.FormatInt(at.UnixNano(), 10)
+}
+
+func stringValue(text string) anyValue {
+	return anyValue{StringValue: &text}
+}
+
+func stringAttribute(key string, value string) keyValue {
+	return keyValue{Key: key, Value: stringValue(value)}
+}
@@ -0,0 +1,255 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = time.Second
+	DefaultQueueDepth    = 2048
+	DefaultTimeout       = 10 * time.Second
+)
+
+// maxErrorBody bounds how much of a rejected request's response is kept
+// in the status.
+const maxErrorBody = 512
+
+type Options struct {
+	BatchSize     int
+	FlushInterval time.Duration
+	QueueDepth    int
+	Timeout       time.Duration
+}
+
+// Status counts events since the exporter was created. Dropped events
+// found the queue full or were waiting in a batch when the exporter
+// stopped; failed ones were in a batch the collector did not accept.
+type Status struct {
+	Exported     int64  `json:"exported"`
+	Dropped      int64  `json:"dropped"`
+	Failed       int64  `json:"failed"`
+	LastExportAt string `json:"lastExportAt,omitempty"`
+	LastError    string `json:"lastError,omitempty"`
+	LastErrorAt  string `json:"lastErrorAt,omitempty"`
+}
+
+// Exporter batches matching events off the ingest path and posts each
+// batch to the configured collector. A failed batch is not retried: the
+// events stay in the session either way.
+type Exporter struct {
+	client *http.Client
+	opts   Options
+	now    func() time.Time
+
+	mu     sync.RWMutex
+	config compiledConfig
+
+	statusMu sync.Mutex
+	status   Status
+
+	queue  chan dump.Event
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewExporter(opts Options) *Exporter {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.QueueDepth <= 0 {
+		opts.QueueDepth = DefaultQueueDepth
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	config, _ := DefaultConfig().compile()
+	return &Exporter{
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+		now:    time.Now,
+		config: config,
+		queue:  make(chan dump.Event, opts.QueueDepth),
+	}
+}
+
+// Start runs the export worker. The config and status outlive it, so the
+// exporter can be stopped and started again with the collector.
+func (e *Exporter) Start() {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	if e.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.run(ctx)
+	}()
+}
+
+// Stop abandons an in-flight request and drops the batch being gathered;
+// queued events wait for the next Start.
+func (e *Exporter) Stop() {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	e.wg.Wait()
+	e.cancel = nil
+}
+
+func (e *Exporter) Config() Config {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config.Config
+}
+
+// SetConfig replaces the config; nothing changes if it is invalid.
+func (e *Exporter) SetConfig(config Config) error {
+	compiled, err := config.compile()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.config = compiled
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *Exporter) Status() Status {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	return e.status
+}
+
+// Handle queues event when export is enabled and it matches the filter. It
+// never blocks: when the queue is full the event is counted as dropped.
+func (e *Exporter) Handle(event dump.Event) {
+	e.mu.RLock()
+	wanted := e.config.Enabled && e.config.matcher.Match(event)
+	e.mu.RUnlock()
+	if !wanted {
+		return
+	}
+
+	select {
+	case e.queue <- event:
+	default:
+		e.count(func(status *Status) { status.Dropped++ })
+	}
+}
+
+func (e *Exporter) run(ctx context.Context) {
+	ticker := time.NewTicker(e.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []dump.Event
+	for {
+		select {
+		case <-ctx.Done():
+			if len(batch) > 0 {
+				dropped := int64(len(batch))
+				e.count(func(status *Status) { status.Dropped += dropped })
+			}
+			return
+		case event := <-e.queue:
+			batch = append(batch, event)
+			if len(batch) < e.opts.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		e.export(ctx, batch)
+		batch = nil
+	}
+}
+
+func (e *Exporter) export(ctx context.Context, batch []dump.Event) {
+	e.mu.RLock()
+	config := e.config
+	e.mu.RUnlock()
+
+	err := e.post(ctx, config, batch)
+	at := e.now().UTC().Format(time.RFC3339Nano)
+	e.count(func(status *Status) {
+		if err != nil {
+			status.Failed += int64(len(batch))
+			status.LastError = err.Error()
+			status.LastErrorAt = at
+			return
+		}
+		status.Exported += int64(len(batch))
+		status.LastExportAt = at
+	})
+}
+
+func (e *Exporter) post(ctx context.Context, config compiledConfig, batch []dump.Event) error {
+	var body []byte
+	var err error
+	if config.Signal == SignalTraces {
+		body, err = encodeTraces(batch, config.ServiceName, e.now())
+	} else {
+		body, err = encodeLogs(batch, config.ServiceName, e.now())
+	}
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, value := range config.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if text := strings.TrimSpace(string(detail)); text != "" {
+			return fmt.Errorf("collector returned %s: %s", resp.Status, text)
+		}
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (e *Exporter) count(update func(*Status)) {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	update(&e.status)
+}
@@ -0,0 +1,208 @@
+package otlp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+func otlpEvent(id string, requestID string, timestamp string) dump.Event {
+	event := dump.Event{
+		ID:            id,
+		Timestamp:     timestamp,
+		SourceType:    "http",
+		ProjectRoot:   "/srv/app",
+		HTTP:          &dump.HTTPMeta{Method: "GET", Path: "/cart"},
+		PayloadFormat: dump.PayloadFormatJSON,
+		Payload:       json.RawMessage(`{"user":1}`),
+		Host:          dump.HostMeta{Hostname: "web-1", PID: 42},
+	}
+	if requestID != "" {
+		event.RequestID = &requestID
+	}
+	return event
+}
+
+func TestTraceContext(t *testing.T) {
+	cases := []struct {
+		requestID string
+		traceID   string
+		spanID    string
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"},
+		{"4BF92F3577B34DA6A3CE929D0E0E4736", "4bf92f3577b34da6a3ce929d0e0e4736", ""},
+		{"00000000000000000000000000000000", "", ""},
+		{"req-1", "", ""},
+	}
+	for _, tc := range cases {
+		traceID, spanID := traceContext(tc.requestID)
+		if tc.traceID == "" {
+			if !isTraceID(traceID) || spanID != "" {
+				t.Fatalf("traceContext(%q) = %q, %q, want a hashed trace ID", tc.requestID, traceID, spanID)
+			}
+			if again, _ := traceContext(tc.requestID); again != traceID {
+				t.Fatalf("traceContext(%q) = %q then %q, want a stable ID", tc.requestID, traceID, again)
+			}
+			continue
+		}
+		if traceID != tc.traceID || spanID != tc.spanID {
+			t.Fatalf("traceContext(%q) = %q, %q, want %q, %q", tc.requestID, traceID, spanID, tc.traceID, tc.spanID)
+		}
+	}
+}
+
+func TestEncodeTraces_GroupsEventsByRequest(t *testing.T) {
+	events := []dump.Event{
+		otlpEvent("evt-1", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "2026-02-28T11:20:31.500Z"),
+		otlpEvent("evt-2", "", "2026-02-28T11:20:31.600Z"),
+		otlpEvent("evt-3", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "2026-02-28T11:20:31.100Z"),
+	}
+
+	data, err := encodeTraces(events, "shop", time.Now())
+	if err != nil {
+		t.Fatalf("encodeTraces() error = %v", err)
+	}
+	var request tracesRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		t.Fatalf("encodeTraces() = %s, not JSON: %v", data, err)
+	}
+
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("spans = %+v, want one per request", spans)
+	}
+	first := spans[0]
+	if first.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || first.ParentSpanID != "00f067aa0ba902b7" || len(first.Events) != 2 {
+		t.Fatalf("first span = %+v, want both events of the traced request under its span", first)
+	}
+	if first.StartTimeUnixNano != "1772277631100000000" || first.EndTimeUnixNano != "1772277631500000000" {
+		t.Fatalf("first span runs %s to %s, want the earliest to the latest event", first.StartTimeUnixNano, first.EndTimeUnixNano)
+	}
+	if first.Name != "phant GET /cart" || first.Events[0].Name != "http" {
+		t.Fatalf("first span = %q with event %q, want it named after the request", first.Name, first.Events[0].Name)
+	}
+	if spans[1].TraceID == first.TraceID || spans[1].ParentSpanID != "" || len(spans[1].Events) != 1 {
+		t.Fatalf("second span = %+v, want the event without a request in a trace of its own", spans[1])
+	}
+	if service := request.ResourceSpans[0].Resource.Attributes[0]; service.Key != "service.name" || *service.Value.StringValue != "shop" {
+		t.Fatalf("resource = %+v, want service.name shop", request.ResourceSpans[0].Resource)
+	}
+}
+
+func TestExporter_PostsLogRecords(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var headers []string
+	var requests []logsRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var request logsRequest
+		_ = json.Unmarshal(data, &request)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		headers = append(headers, r.Header.Get("Authorization"))
+		requests = append(requests, request)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	e := NewExporter(Options{BatchSize: 2, FlushInterval: 10 * time.Millisecond})
+	e.Start()
+	defer e.Stop()
+
+	err := e.SetConfig(Config{
+		Enabled:     true,
+		Endpoint:    server.URL + "/otlp/",
+		Signal:      SignalLogs,
+		ServiceName: DefaultServiceName,
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		Filter:      query.Filter{SourceType: "http"},
+	})
+	if err != nil {
+		t.Fatalf("SetConfig() error = %v", err)
+	}
+
+	warning := otlpEvent("evt-1", "4bf92f3577b34da6a3ce929d0e0e4736", "2026-02-28T11:20:31.331Z")
+	warning.Level = "warning"
+	e.Handle(warning)
+	skipped := otlpEvent("evt-2", "", "2026-02-28T11:20:31.331Z")
+	skipped.SourceType = "cli"
+	e.Handle(skipped)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for e.Status().Exported == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status := e.Status(); status.Exported != 1 || status.Failed != 0 || status.LastExportAt == "" {
+		t.Fatalf("Status() = %+v, want one exported event", status)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 1 || paths[0] != "/otlp/v1/logs" || headers[0] != "Bearer token" {
+		t.Fatalf("requests to %v with %v, want one to /otlp/v1/logs with the configured header", paths, headers)
+	}
+	record := requests[0].ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if record.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || record.SeverityNumber != 13 || record.SeverityText != "WARNING" {
+		t.Fatalf("record = %+v, want the request's trace ID and a WARNING severity", record)
+	}
+	if *record.Body.StringValue != `{"user":1}` || record.TimeUnixNano != "1772277631331000000" {
+		t.Fatalf("record = %+v, want the payload at the event's time", record)
+	}
+}
+
+func TestExporter_CountsRejectedBatches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	e := NewExporter(Options{FlushInterval: 10 * time.Millisecond})
+	e.Start()
+	defer e.Stop()
+
+	config := DefaultConfig()
+	config.Enabled = true
+	config.Endpoint = server.URL
+	config.Signal = SignalTraces
+	if err := e.SetConfig(config); err != nil {
+		t.Fatalf("SetConfig() error = %v", err)
+	}
+	e.Handle(otlpEvent("evt-1", "req-1", "2026-02-28T11:20:31.331Z"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for e.Status().Failed == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status := e.Status(); status.Failed != 1 || status.LastError != "collector returned 401 Unauthorized: bad token" {
+		t.Fatalf("Status() = %+v, want the rejected batch with the collector's reason", status)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cases := map[string]func(*Config){
+		"signal":   func(c *Config) { c.Signal = "metrics" },
+		"endpoint": func(c *Config) { c.Endpoint = "localhost:4318" },
+		"query":    func(c *Config) { c.Endpoint = "http://localhost:4318?x=1" },
+		"service":  func(c *Config) { c.ServiceName = " " },
+		"header":   func(c *Config) { c.Headers = map[string]string{"Bad Name": "x"} },
+		"value":    func(c *Config) { c.Headers = map[string]string{"X-Key": "a\nb"} },
+	}
+	for name, change := range cases {
+		config := DefaultConfig()
+		change(&config)
+		if err := config.Validate(); err == nil {
+			t.Fatalf("%s: Validate(%+v) = nil, want an error", name, config)
+		}
+	}
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("Validate(DefaultConfig()) error = %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	DefaultMinBatch      = 1
+	DefaultMaxBatch      = 512
+	DefaultMaxInterval   = 250 * time.Millisecond
+	DefaultTargetLatency = 8 * time.Millisecond
+
+	// latencyWeight is the EWMA weight given to the newest emit latency.
+	latencyWeight = 0.3
+	// minInterval is the first non-zero flush delay used when backing off.
+	minInterval = 4 * time.Millisecond
+)
+
+type BatcherOptions struct {
+	MinBatch      int
+	MaxBatch      int
+	MaxInterval   time.Duration
+	TargetLatency time.Duration
+}
+
+type BatchStats struct {
+	Batches       uint64  `json:"batches"`
+	Events        uint64  `json:"events"`
+	BatchSize     int     `json:"batchSize"`
+	IntervalMs    float64 `json:"intervalMs"`
+	Backlog       int     `json:"backlog"`
+	LastLatencyMs float64 `json:"lastLatencyMs"`
+	AvgLatencyMs  float64 `json:"avgLatencyMs"`
+	MaxLatencyMs  float64 `json:"maxLatencyMs"`
+	Lagging       bool    `json:"lagging"`
+}
+
+// Batcher groups events before handing them to a slow consumer such as the
+// webview bridge. While the consumer keeps up, every event is flushed on its
+// own; when emits get slow or a backlog builds, batches grow and flushes are
+// spaced out, and both shrink back once the consumer recovers.
+type Batcher struct {
+	emit    func([]dump.Event)
+	options BatcherOptions
+
+	mu         sync.Mutex
+	batchSize  int
+	interval   time.Duration
+	avgLatency time.Duration
+	stats      BatchStats
+}
+
+func NewBatcher(emit func([]dump.Event), options BatcherOptions) *Batcher {
+	if options.MinBatch < 1 {
+		options.MinBatch = DefaultMinBatch
+	}
+	if options.MaxBatch < options.MinBatch {
+		options.MaxBatch = max(DefaultMaxBatch, options.MinBatch)
+	}
+	if options.MaxInterval <= 0 {
+		options.MaxInterval = DefaultMaxInterval
+	}
+	if options.TargetLatency <= 0 {
+		options.TargetLatency = DefaultTargetLatency
+	}
+
+	return &Batcher{
+		emit:      emit,
+		options:   options,
+		batchSize: options.MinBatch,
+	}
+}
+
+// Run batches events from in until done is closed or in is closed; pending
+// events are flushed before returning.
+func (b *Batcher) Run(in <-chan dump.Event, done <-chan struct{}) {
+	var pending []dump.Event
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	flush := func() {
+		timer.Stop()
+		if len(pending) == 0 {
+			return
+		}
+		b.flush(pending, len(in))
+		pending = nil
+	}
+
+	for {
+		select {
+		case <-done:
+			flush()
+			return
+		case event, ok := <-in:
+			if !ok {
+				flush()
+				return
+			}
+
+			pending = append(pending, event)
+			size, interval := b.target()
+			switch {
+			case len(pending) >= size || interval == 0:
+				flush()
+			case len(pending) == 1:
+				timer.Reset(interval)
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+func (b *Batcher) Stats() BatchStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.BatchSize = b.batchSize
+	stats.IntervalMs = milliseconds(b.interval)
+	return stats
+}
+
+func (b *Batcher) target() (int, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.batchSize, b.interval
+}
+
+func (b *Batcher) flush(batch []dump.Event, backlog int) {
+	started := time.Now()
+	b.emit(batch)
+	latency := time.Since(started)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stats.Batches == 0 {
+		b.avgLatency = latency
+	} else {
+		b.avgLatency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(b.avgLatency))
+	}
+
+	b.stats.Batches++
+	b.stats.Events += uint64(len(batch))
+	b.stats.Backlog = backlog
+	b.stats.LastLatencyMs = milliseconds(latency)
+	b.stats.AvgLatencyMs = milliseconds(b.avgLatency)
+	b.stats.MaxLatencyMs = max(b.stats.MaxLatencyMs, b.stats.LastLatencyMs)
+
+	lagging := b.avgLatency > b.options.TargetLatency || backlog > b.batchSize
+	b.stats.Lagging = lagging
+	if lagging {
+		b.batchSize = min(b.batchSize*2, b.options.MaxBatch)
+		b.interval = min(max(b.interval*2, minInterval), b.options.MaxInterval)
+		return
+	}
+
+	if b.avgLatency < b.options.TargetLatency/2 {
+		b.batchSize = max(b.batchSize/2, b.options.MinBatch)
+		b.interval /= 2
+		if b.interval < minInterval {
+			b.interval = 0
+		}
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]dump.Event
+	delay   time.Duration
+}
+
+func (r *batchRecorder) emit(batch []dump.Event) {
+	time.Sleep(r.delay)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, batch)
+}
+
+func (r *batchRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.batches)
+}
+
+func TestBatcher_FlushesEachEventWhileConsumerKeepsUp(t *testing.T) {
+	recorder := &batchRecorder{}
+	batcher := NewBatcher(recorder.emit, BatcherOptions{})
+
+	in := make(chan dump.Event)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		batcher.Run(in, done)
+		close(finished)
+	}()
+
+	for _, id := range []string{"1", "2", "3"} {
+		in <- dump.Event{ID: id}
+	}
+	close(done)
+	<-finished
+
+	if got := recorder.count(); got != 3 {
+		t.Fatalf("batches = %d, want 3", got)
+	}
+	if stats := batcher.Stats(); stats.BatchSize != 1 || stats.Events != 3 || stats.Lagging {
+		t.Fatalf("Stats() = %+v, want batch size 1 and 3 events", stats)
+	}
+}
+
+func TestBatcher_GrowsBatchesWhenConsumerLags(t *testing.T) {
+	recorder := &batchRecorder{delay: 5 * time.Millisecond}
+	batcher := NewBatcher(recorder.emit, BatcherOptions{TargetLatency: time.Millisecond, MaxBatch: 16})
+
+	in := make(chan dump.Event, 200)
+	for i := 0; i < 200; i++ {
+		in <- dump.Event{ID: "e"}
+	}
+	close(in)
+	batcher.Run(in, make(chan struct{}))
+
+	stats := batcher.Stats()
+	if stats.Events != 200 {
+		t.Fatalf("Stats().Events = %d, want 200", stats.Events)
+	}
+	if stats.BatchSize != 16 || stats.Batches >= 100 {
+		t.Fatalf("Stats() = %+v, want batch size grown to 16 and fewer batches", stats)
+	}
+	if stats.MaxLatencyMs < 5 {
+		t.Fatalf("Stats().MaxLatencyMs = %v, want >= 5", stats.MaxLatencyMs)
+	}
+}
@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const DefaultGateLimit = 5000
+
+type GateStatus struct {
+	Paused   bool   `json:"paused"`
+	PausedAt string `json:"pausedAt,omitempty"`
+	// Received counts events that arrived while paused; Held of them are
+	// waiting to be delivered and Dropped were let go to stay in bounds.
+	Received int `json:"received"`
+	Held     int `json:"held"`
+	Dropped  int `json:"dropped"`
+}
+
+// Gate holds a live feed back while the user is inspecting it. Events that
+// arrive while paused are kept up to a limit, dropping the oldest first, and
+// handed back together on resume. The gate only affects delivery; events
+// are buffered and stored as usual upstream.
+type Gate struct {
+	limit int
+	now   func() time.Time
+
+	mu       sync.Mutex
+	paused   bool
+	pausedAt time.Time
+	held     []dump.Event
+	received int
+	dropped  int
+}
+
+func NewGate(limit int) *Gate {
+	if limit <= 0 {
+		limit = DefaultGateLimit
+	}
+	return &Gate{limit: limit, now: time.Now}
+}
+
+// Pause starts holding events. Pausing an already paused gate keeps the
+// events held so far.
+func (g *Gate) Pause() GateStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.paused {
+		g.paused = true
+		g.pausedAt = g.now()
+	}
+	return g.statusLocked()
+}
+
+// Admit returns the events to deliver now: the batch itself while the gate
+// is open, nothing while it is paused.
+func (g *Gate) Admit(batch []dump.Event) []dump.Event {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.paused {
+		return batch
+	}
+
+	g.received += len(batch)
+	g.held = append(g.held, batch...)
+	if over := len(g.held) - g.limit; over > 0 {
+		g.dropped += over
+		g.held = append(g.held[:0:0], g.held[over:]...)
+	}
+	return nil
+}
+
+// Resume opens the gate and returns the held events, oldest first, with
+// the status as it was just before resuming.
+func (g *Gate) Resume() ([]dump.Event, GateStatus) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	status := g.statusLocked()
+	held := g.held
+	g.paused = false
+	g.pausedAt = time.Time{}
+	g.held = nil
+	g.received = 0
+	g.dropped = 0
+	return held, status
+}
+
+func (g *Gate) Status() GateStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.statusLocked()
+}
+
+func (g *Gate) statusLocked() GateStatus {
+	status := GateStatus{
+		Paused:   g.paused,
+		Received: g.received,
+		Held:     len(g.held),
+		Dropped:  g.dropped,
+	}
+	if g.paused {
+		status.PausedAt = g.pausedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return status
+}
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestGate_HoldsWhilePausedAndDropsOldest(t *testing.T) {
+	gate := NewGate(2)
+
+	if got := gate.Admit([]dump.Event{{ID: "1"}}); len(got) != 1 {
+		t.Fatalf("gate.Admit(open) = %v, want the batch", got)
+	}
+
+	gate.Pause()
+	gate.Admit([]dump.Event{{ID: "2"}, {ID: "3"}})
+	if got := gate.Admit([]dump.Event{{ID: "4"}}); got != nil {
+		t.Fatalf("gate.Admit(paused) = %v, want nil", got)
+	}
+
+	held, status := gate.Resume()
+	if status.Received != 3 || status.Held != 2 || status.Dropped != 1 || !status.Paused {
+		t.Fatalf("gate.Resume() status = %+v, want received=3 held=2 dropped=1", status)
+	}
+	if len(held) != 2 || held[0].ID != "3" || held[1].ID != "4" {
+		t.Fatalf("gate.Resume() held = %v, want events 3 and 4", held)
+	}
+	if after := gate.Status(); after.Paused || after.Received != 0 {
+		t.Fatalf("gate.Status() after resume = %+v, want open and reset", after)
+	}
+}
+
+func TestGate_PauseTwiceKeepsHeldEvents(t *testing.T) {
+	gate := NewGate(0)
+	first := gate.Pause()
+	gate.Admit([]dump.Event{{ID: "1"}})
+
+	second := gate.Pause()
+	if second.PausedAt != first.PausedAt || second.Held != 1 {
+		t.Fatalf("gate.Pause() again = %+v, want original pause with one held event", second)
+	}
+}
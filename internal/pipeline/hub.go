@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"sync"
+
+	"phant/internal/dump"
+)
+
+const DefaultSubscriberBuffer = 256
+
+type subscriber struct {
+	ch      chan dump.Event
+	dropped uint64
+	block   bool
+}
+
+// Hub fans decoded events out to subscribers. Each subscriber has its own
+// bounded channel; when a subscriber falls behind, new events for it are
+// dropped and counted instead of blocking ingestion, unless it subscribed
+// with SubscribeBlocking.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[int]*subscriber
+	nextID      int
+	closed      bool
+}
+
+type SubscriberStats struct {
+	ID       int    `json:"id"`
+	Buffered int    `json:"buffered"`
+	Capacity int    `json:"capacity"`
+	Dropped  uint64 `json:"dropped"`
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[int]*subscriber),
+	}
+}
+
+func (h *Hub) Subscribe(bufferSize int) (int, <-chan dump.Event) {
+	return h.subscribe(bufferSize, false)
+}
+
+// SubscribeBlocking subscribes without ever dropping an event: when the
+// channel is full, publishing waits for room. The subscriber must keep
+// draining it without waiting on the hub, or ingestion stalls.
+func (h *Hub) SubscribeBlocking(bufferSize int) (int, <-chan dump.Event) {
+	return h.subscribe(bufferSize, true)
+}
+
+func (h *Hub) subscribe(bufferSize int, block bool) (int, <-chan dump.Event) {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	ch := make(chan dump.Event, bufferSize)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	id := h.nextID
+	h.nextID++
+	if h.closed {
+		close(ch)
+		return id, ch
+	}
+
+	h.subscribers[id] = &subscriber{ch: ch, block: block}
+	return id, ch
+}
+
+func (h *Hub) Unsubscribe(id int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub, ok := h.subscribers[id]
+	if !ok {
+		return
+	}
+
+	close(sub.ch)
+	delete(h.subscribers, id)
+}
+
+func (h *Hub) Publish(event dump.Event) {
+	h.PublishExcept(event, -1)
+}
+
+// PublishExcept fans event out to every subscriber but skip.
+func (h *Hub) PublishExcept(event dump.Event, skip int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, sub := range h.subscribers {
+		if id == skip {
+			continue
+		}
+		if sub.block {
+			sub.ch <- event
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Close closes every subscriber channel; later subscriptions are closed
+// immediately.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, sub := range h.subscribers {
+		close(sub.ch)
+		delete(h.subscribers, id)
+	}
+	h.closed = true
+}
+
+func (h *Hub) Stats(id int) (SubscriberStats, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sub, ok := h.subscribers[id]
+	if !ok {
+		return SubscriberStats{}, false
+	}
+
+	return SubscriberStats{
+		ID:       id,
+		Buffered: len(sub.ch),
+		Capacity: cap(sub.ch),
+		Dropped:  sub.dropped,
+	}, true
+}
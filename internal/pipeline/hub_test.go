@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"fmt"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestHub_DropsWhenSubscriberIsFull(t *testing.T) {
+	hub := NewHub()
+	id, ch := hub.Subscribe(1)
+
+	hub.Publish(dump.Event{ID: "1"})
+	hub.Publish(dump.Event{ID: "2"})
+
+	stats, ok := hub.Stats(id)
+	if !ok {
+		t.Fatalf("hub.Stats(%d) ok = false, want true", id)
+	}
+	if stats.Dropped != 1 || stats.Buffered != 1 {
+		t.Fatalf("hub.Stats(%d) = %+v, want dropped=1 buffered=1", id, stats)
+	}
+	if got := <-ch; got.ID != "1" {
+		t.Fatalf("received ID = %q, want %q", got.ID, "1")
+	}
+}
+
+func TestHub_BlockingSubscriberWaitsForRoom(t *testing.T) {
+	hub := NewHub()
+	id, ch := hub.SubscribeBlocking(1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 10 {
+			hub.Publish(dump.Event{ID: fmt.Sprint(i)})
+		}
+	}()
+	for i := range 10 {
+		if got := <-ch; got.ID != fmt.Sprint(i) {
+			t.Fatalf("received ID = %q, want %d", got.ID, i)
+		}
+	}
+	<-done
+
+	if stats, _ := hub.Stats(id); stats.Dropped != 0 {
+		t.Fatalf("hub.Stats(%d).Dropped = %d, want 0", id, stats.Dropped)
+	}
+}
+
+func TestHub_CloseClosesSubscribers(t *testing.T) {
+	hub := NewHub()
+	_, ch := hub.Subscribe(1)
+	hub.Close()
+
+	if _, ok := <-ch; ok {
+		t.Fatalf("subscriber channel open after hub.Close()")
+	}
+
+	_, late := hub.Subscribe(1)
+	if _, ok := <-late; ok {
+		t.Fatalf("subscription after hub.Close() returned an open channel")
+	}
+}
+
+func TestHub_UnsubscribeIsIdempotent(t *testing.T) {
+	hub := NewHub()
+	id, ch := hub.Subscribe(1)
+	hub.Unsubscribe(id)
+	hub.Unsubscribe(id)
+
+	if _, ok := <-ch; ok {
+		t.Fatalf("subscriber channel open after hub.Unsubscribe()")
+	}
+	hub.Publish(dump.Event{ID: "1"})
+}
@@ -0,0 +1,198 @@
+package pipeline
+
+import (
+	"errors"
+	"hash/fnv"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"phant/internal/dump"
+)
+
+const DefaultShardDepth = 1024
+
+// OverflowPolicy decides what Submit does when a shard queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits for room, slowing the producing connection down
+	// instead of losing events.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest evicts the oldest queued event to make room.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowDropNewest discards the incoming event.
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+)
+
+func (p OverflowPolicy) Validate() error {
+	switch p {
+	case "", OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+		return nil
+	}
+	return errors.New("overflow policy must be one of: block, drop-oldest, drop-newest")
+}
+
+func DefaultShardCount() int {
+	return max(runtime.NumCPU(), 1)
+}
+
+type ShardStats struct {
+	Shard     int    `json:"shard"`
+	Queued    int    `json:"queued"`
+	Capacity  int    `json:"capacity"`
+	Received  uint64 `json:"received"`
+	Processed uint64 `json:"processed"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+// Shards spreads events over a fixed set of FIFO queues, each drained by its
+// own worker. Events with the same key always land on the same shard, so
+// they are handled in submission order while unrelated sources proceed in
+// parallel.
+type Shards struct {
+	handle    func(dump.Event)
+	queues    []chan dump.Event
+	received  []atomic.Uint64
+	processed []atomic.Uint64
+	dropped   []atomic.Uint64
+	overflow  atomic.Value
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewShards(count int, depth int, handle func(dump.Event)) *Shards {
+	if count < 1 {
+		count = DefaultShardCount()
+	}
+	if depth < 1 {
+		depth = DefaultShardDepth
+	}
+
+	queues := make([]chan dump.Event, count)
+	for i := range queues {
+		queues[i] = make(chan dump.Event, depth)
+	}
+
+	shards := &Shards{
+		handle:    handle,
+		queues:    queues,
+		received:  make([]atomic.Uint64, count),
+		processed: make([]atomic.Uint64, count),
+		dropped:   make([]atomic.Uint64, count),
+	}
+	shards.overflow.Store(OverflowBlock)
+	return shards
+}
+
+// SetOverflow changes the policy for full queues; it takes effect for the
+// next Submit. An empty policy means OverflowBlock.
+func (s *Shards) SetOverflow(policy OverflowPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if policy == "" {
+		policy = OverflowBlock
+	}
+	s.overflow.Store(policy)
+	return nil
+}
+
+func (s *Shards) Overflow() OverflowPolicy {
+	return s.overflow.Load().(OverflowPolicy)
+}
+
+func (s *Shards) Start() {
+	for i, queue := range s.queues {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for event := range queue {
+				s.handle(event)
+				s.processed[i].Add(1)
+			}
+		}()
+	}
+}
+
+// Submit queues an event on the shard owning key. When that shard is full
+// the overflow policy decides whether to wait or drop an event; drops are
+// counted per shard. It reports false once the shards are stopped.
+func (s *Shards) Submit(key string, event dump.Event) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return false
+	}
+
+	index := shardIndex(key, len(s.queues))
+	queue := s.queues[index]
+	s.received[index].Add(1)
+
+	switch s.Overflow() {
+	case OverflowDropNewest:
+		select {
+		case queue <- event:
+		default:
+			s.dropped[index].Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case queue <- event:
+				return true
+			default:
+			}
+			// The worker may drain the queue between the two selects, in
+			// which case nothing needs evicting.
+			select {
+			case <-queue:
+				s.dropped[index].Add(1)
+			default:
+			}
+		}
+	default:
+		queue <- event
+	}
+	return true
+}
+
+// Stop rejects new events and waits until queued ones are handled.
+func (s *Shards) Stop() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	for _, queue := range s.queues {
+		close(queue)
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Shards) Stats() []ShardStats {
+	stats := make([]ShardStats, len(s.queues))
+	for i, queue := range s.queues {
+		stats[i] = ShardStats{
+			Shard:     i,
+			Queued:    len(queue),
+			Capacity:  cap(queue),
+			Received:  s.received[i].Load(),
+			Processed: s.processed[i].Load(),
+			Dropped:   s.dropped[i].Load(),
+		}
+	}
+	return stats
+}
+
+func shardIndex(key string, count int) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(count))
+}
@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestShards_KeepPerKeyOrder(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string][]int{}
+
+	shards := NewShards(4, 2, func(event dump.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		var key string
+		var seq int
+		fmt.Sscanf(event.ID, "%s %d", &key, &seq)
+		seen[key] = append(seen[key], seq)
+	})
+	shards.Start()
+
+	var wg sync.WaitGroup
+	for k := 0; k < 8; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := fmt.Sprintf("req-%d", k)
+			for seq := 0; seq < 50; seq++ {
+				shards.Submit(key, dump.Event{ID: fmt.Sprintf("%s %d", key, seq)})
+			}
+		}()
+	}
+	wg.Wait()
+	shards.Stop()
+
+	for key, seqs := range seen {
+		if len(seqs) != 50 {
+			t.Fatalf("key %s handled %d events, want 50", key, len(seqs))
+		}
+		for i, seq := range seqs {
+			if seq != i {
+				t.Fatalf("key %s order = %v, want ascending", key, seqs)
+			}
+		}
+	}
+
+	var processed uint64
+	for _, stats := range shards.Stats() {
+		processed += stats.Processed
+	}
+	if processed != 400 {
+		t.Fatalf("processed = %d, want 400", processed)
+	}
+
+	if shards.Submit("late", dump.Event{}) {
+		t.Fatalf("Submit() after Stop = true, want false")
+	}
+}
+
+func TestShards_OverflowPoliciesDropAndCount(t *testing.T) {
+	tests := []struct {
+		policy OverflowPolicy
+		want   []string
+	}{
+		{OverflowDropNewest, []string{"1", "2"}},
+		{OverflowDropOldest, []string{"4", "5"}},
+	}
+
+	for _, test := range tests {
+		var handled []string
+		shards := NewShards(1, 2, func(event dump.Event) {
+			handled = append(handled, event.ID)
+		})
+		if err := shards.SetOverflow(test.policy); err != nil {
+			t.Fatalf("SetOverflow(%s) error = %v", test.policy, err)
+		}
+
+		// Without workers the queue fills after two events.
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			shards.Submit("key", dump.Event{ID: id})
+		}
+		stats := shards.Stats()[0]
+		if stats.Received != 5 || stats.Dropped != 3 {
+			t.Fatalf("%s: Stats() = %+v, want received=5 dropped=3", test.policy, stats)
+		}
+
+		shards.Start()
+		shards.Stop()
+		if fmt.Sprint(handled) != fmt.Sprint(test.want) {
+			t.Fatalf("%s: handled %v, want %v", test.policy, handled, test.want)
+		}
+	}
+
+	if err := NewShards(1, 1, func(dump.Event) {}).SetOverflow("spill"); err == nil {
+		t.Fatalf("SetOverflow(spill) error = nil, want error")
+	}
+}
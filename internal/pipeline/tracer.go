@@ -0,0 +1,192 @@
+package pipeline
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTracedEvents  = 2048
+	DefaultLatencySample = 1024
+)
+
+// Stage is a point an event passes on its way from a producer to the UI.
+type Stage string
+
+const (
+	StageReceived Stage = "received"
+	StageDecoded  Stage = "decoded"
+	StageStored   Stage = "stored"
+	StagePushed   Stage = "pushed"
+)
+
+var tracedStages = []Stage{StageReceived, StageDecoded, StageStored, StagePushed}
+
+// EventTimings is one event's trip through the pipeline. Latencies are
+// measured from StageReceived.
+type EventTimings struct {
+	EventID     string            `json:"eventId"`
+	Stages      map[Stage]string  `json:"stages"`
+	LatenciesMs map[Stage]float64 `json:"latenciesMs"`
+}
+
+type StageStats struct {
+	Stage  Stage   `json:"stage"`
+	Count  uint64  `json:"count"`
+	MeanMs float64 `json:"meanMs"`
+	P50Ms  float64 `json:"p50Ms"`
+	P95Ms  float64 `json:"p95Ms"`
+	MaxMs  float64 `json:"maxMs"`
+}
+
+type TraceStats struct {
+	Tracked int          `json:"tracked"`
+	Stages  []StageStats `json:"stages"`
+}
+
+type latencyWindow struct {
+	count   uint64
+	samples []time.Duration
+	next    int
+}
+
+// Tracer records when recent events reach each stage. It keeps the last
+// few thousand events for per-event inspection and a sliding window of
+// latencies per stage for aggregate stats.
+type Tracer struct {
+	capacity int
+	sample   int
+
+	mu      sync.Mutex
+	events  map[string]map[Stage]time.Time
+	order   []string
+	next    int
+	windows map[Stage]*latencyWindow
+}
+
+func NewTracer(capacity int, sample int) *Tracer {
+	if capacity <= 0 {
+		capacity = DefaultTracedEvents
+	}
+	if sample <= 0 {
+		sample = DefaultLatencySample
+	}
+
+	windows := make(map[Stage]*latencyWindow, len(tracedStages))
+	for _, stage := range tracedStages[1:] {
+		windows[stage] = &latencyWindow{}
+	}
+	return &Tracer{
+		capacity: capacity,
+		sample:   sample,
+		events:   make(map[string]map[Stage]time.Time, capacity),
+		windows:  windows,
+	}
+}
+
+// Mark records that the event reached stage at the given time. Only the
+// first mark per stage counts, so re-pushed events keep their original
+// timings.
+func (t *Tracer) Mark(eventID string, stage Stage, at time.Time) {
+	if eventID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	marks, ok := t.events[eventID]
+	if !ok {
+		if stage != StageReceived {
+			// Evicted, or never traced (bulk imports); nothing to measure from.
+			return
+		}
+		marks = make(map[Stage]time.Time, len(tracedStages))
+		t.track(eventID, marks)
+	}
+	if _, seen := marks[stage]; seen {
+		return
+	}
+	marks[stage] = at
+
+	if window, ok := t.windows[stage]; ok {
+		window.add(at.Sub(marks[StageReceived]), t.sample)
+	}
+}
+
+func (t *Tracer) track(eventID string, marks map[Stage]time.Time) {
+	if len(t.order) < t.capacity {
+		t.order = append(t.order, eventID)
+	} else {
+		delete(t.events, t.order[t.next])
+		t.order[t.next] = eventID
+		t.next = (t.next + 1) % t.capacity
+	}
+	t.events[eventID] = marks
+}
+
+func (t *Tracer) Timings(eventID string) (EventTimings, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	marks, ok := t.events[eventID]
+	if !ok {
+		return EventTimings{}, false
+	}
+
+	timings := EventTimings{
+		EventID:     eventID,
+		Stages:      make(map[Stage]string, len(marks)),
+		LatenciesMs: make(map[Stage]float64, len(marks)),
+	}
+	received := marks[StageReceived]
+	for stage, at := range marks {
+		timings.Stages[stage] = at.UTC().Format(time.RFC3339Nano)
+		if stage != StageReceived {
+			timings.LatenciesMs[stage] = milliseconds(at.Sub(received))
+		}
+	}
+	return timings, true
+}
+
+func (t *Tracer) Stats() TraceStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := TraceStats{Tracked: len(t.events), Stages: make([]StageStats, 0, len(t.windows))}
+	for _, stage := range tracedStages[1:] {
+		stats.Stages = append(stats.Stages, t.windows[stage].stats(stage))
+	}
+	return stats
+}
+
+func (w *latencyWindow) add(latency time.Duration, size int) {
+	w.count++
+	if len(w.samples) < size {
+		w.samples = append(w.samples, latency)
+		return
+	}
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % size
+}
+
+func (w *latencyWindow) stats(stage Stage) StageStats {
+	stats := StageStats{Stage: stage, Count: w.count}
+	if len(w.samples) == 0 {
+		return stats
+	}
+
+	sorted := slices.Clone(w.samples)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, sample := range sorted {
+		total += sample
+	}
+	stats.MeanMs = milliseconds(total / time.Duration(len(sorted)))
+	stats.P50Ms = milliseconds(sorted[len(sorted)/2])
+	stats.P95Ms = milliseconds(sorted[len(sorted)*95/100])
+	stats.MaxMs = milliseconds(sorted[len(sorted)-1])
+	return stats
+}
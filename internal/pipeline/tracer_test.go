@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracer_RecordsStageLatencies(t *testing.T) {
+	tracer := NewTracer(10, 10)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tracer.Mark("evt-1", StageReceived, start)
+	tracer.Mark("evt-1", StageDecoded, start.Add(time.Millisecond))
+	tracer.Mark("evt-1", StagePushed, start.Add(5*time.Millisecond))
+	tracer.Mark("evt-1", StagePushed, start.Add(50*time.Millisecond))
+
+	timings, ok := tracer.Timings("evt-1")
+	if !ok {
+		t.Fatalf("Timings() ok = false, want true")
+	}
+	if timings.LatenciesMs[StageDecoded] != 1 || timings.LatenciesMs[StagePushed] != 5 {
+		t.Fatalf("Timings().LatenciesMs = %v, want decoded 1ms and pushed 5ms", timings.LatenciesMs)
+	}
+	if _, stored := timings.Stages[StageStored]; stored {
+		t.Fatalf("Timings().Stages = %v, want no stored stage yet", timings.Stages)
+	}
+
+	stats := tracer.Stats()
+	if stats.Tracked != 1 || len(stats.Stages) != 3 {
+		t.Fatalf("Stats() = %+v, want 1 tracked event and 3 stages", stats)
+	}
+	if pushed := stats.Stages[2]; pushed.Stage != StagePushed || pushed.Count != 1 || pushed.MaxMs != 5 {
+		t.Fatalf("Stats() pushed = %+v, want one 5ms sample", pushed)
+	}
+}
+
+func TestTracer_IgnoresUnreceivedEventsAndEvictsOldest(t *testing.T) {
+	tracer := NewTracer(2, 10)
+	now := time.Now()
+
+	tracer.Mark("imported", StagePushed, now)
+	if _, ok := tracer.Timings("imported"); ok {
+		t.Fatalf("Timings(imported) ok = true, want events without a received mark ignored")
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		tracer.Mark(id, StageReceived, now)
+	}
+	if _, ok := tracer.Timings("a"); ok {
+		t.Fatalf("Timings(a) ok = true, want the oldest event evicted")
+	}
+	if _, ok := tracer.Timings("c"); !ok {
+		t.Fatalf("Timings(c) ok = false, want the newest event tracked")
+	}
+}
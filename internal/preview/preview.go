@@ -0,0 +1,324 @@
+// Package preview renders short, human-oriented one-line previews of dump
+// payloads for list rows: leading scalar values, collection sizes, and class
+// names, so the UI never has to walk a full payload to draw a row.
+package preview
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"phant/internal/dump"
+)
+
+const DefaultCacheSize = 8192
+
+// Options bound how much of a payload a preview shows. Collections nested
+// deeper than MaxDepth are summarized by size; at most MaxItems members of
+// each collection are shown; the whole preview is cut to MaxLength runes.
+type Options struct {
+	MaxDepth  int `json:"maxDepth"`
+	MaxItems  int `json:"maxItems"`
+	MaxLength int `json:"maxLength"`
+}
+
+func DefaultOptions() Options {
+	return Options{MaxDepth: 2, MaxItems: 3, MaxLength: 120}
+}
+
+func (o Options) Validate() error {
+	if o.MaxDepth < 0 || o.MaxDepth > 5 {
+		return errors.New("preview depth must be between 0 and 5")
+	}
+	if o.MaxItems < 1 || o.MaxItems > 20 {
+		return errors.New("preview items must be between 1 and 20")
+	}
+	if o.MaxLength < 20 || o.MaxLength > 1000 {
+		return errors.New("preview length must be between 20 and 1000")
+	}
+	return nil
+}
+
+// Render builds the preview of an event's payload.
+func Render(event dump.Event, options Options) string {
+	var text string
+	switch event.PayloadFormat {
+	case dump.PayloadFormatJSON:
+		text = renderJSON(event.Payload, options)
+	case dump.PayloadFormatHTML:
+		text = renderMarkup(event.Payload, true)
+	case dump.PayloadFormatBinary:
+		text = renderBinary(event)
+	default:
+		text = renderMarkup(event.Payload, false)
+	}
+	return shorten(text, options.MaxLength)
+}
+
+var (
+	tagPattern        = regexp.MustCompile(`(?s)<script.*?</script>|<style.*?</style>|<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// renderBinary names the file a binary payload holds; its data is on disk.
+func renderBinary(event dump.Event) string {
+	if event.OriginalBytes == 0 {
+		return event.MimeType
+	}
+	return fmt.Sprintf("%s, %d bytes", event.MimeType, event.OriginalBytes)
+}
+
+func renderMarkup(payload json.RawMessage, isHTML bool) string {
+	var text string
+	if err := json.Unmarshal(payload, &text); err != nil {
+		return ""
+	}
+	if isHTML {
+		text = html.UnescapeString(tagPattern.ReplaceAllString(text, " "))
+	}
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+func shorten(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimRight(string(runes[:limit-1]), " ,") + "…"
+}
+
+// node is a decoded JSON value that keeps object members in document order.
+type node struct {
+	scalar  string
+	isArray bool
+	isObj   bool
+	keys    []string
+	values  []*node
+	// count is the number of members, including those not kept past
+	// MaxItems.
+	count int
+}
+
+func (n *node) member(key string) *node {
+	for i, k := range n.keys {
+		if k == key {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+func (n *node) text(key string) string {
+	value := n.member(key)
+	if value == nil {
+		return ""
+	}
+	text, _ := strconv.Unquote(value.scalar)
+	return text
+}
+
+func renderJSON(payload json.RawMessage, options Options) string {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	root, err := decodeNode(decoder, options.MaxItems+reservedKeys)
+	if err != nil {
+		return ""
+	}
+	var out strings.Builder
+	render(&out, root, 0, options)
+	return out.String()
+}
+
+// reservedKeys leaves room for the __phant* members ahead of an object's
+// properties.
+const reservedKeys = 4
+
+func decodeNode(decoder *json.Decoder, keep int) (*node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		n := &node{isArray: token == '[', isObj: token == '{'}
+		for decoder.More() {
+			key := ""
+			if n.isObj {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ = keyToken.(string)
+			}
+			value, err := decodeNode(decoder, keep)
+			if err != nil {
+				return nil, err
+			}
+			if n.count < keep || key == "__properties" {
+				n.keys = append(n.keys, key)
+				n.values = append(n.values, value)
+			}
+			n.count++
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &node{scalar: strconv.Quote(token)}, nil
+	case json.Number:
+		return &node{scalar: token.String()}, nil
+	case bool:
+		return &node{scalar: strconv.FormatBool(token)}, nil
+	default:
+		return &node{scalar: "null"}, nil
+	}
+}
+
+func render(out *strings.Builder, n *node, depth int, options Options) {
+	switch {
+	case n.isArray:
+		renderMembers(out, n, "[", "]", "item", "items", depth, options)
+	case n.isObj:
+		renderObject(out, n, depth, options)
+	default:
+		out.WriteString(n.scalar)
+	}
+}
+
+// renderObject shows objects normalized by the PHP hook by their short class
+// name; other objects are shown as plain maps.
+func renderObject(out *strings.Builder, n *node, depth int, options Options) {
+	className := shortClass(n.text("__className"))
+	switch n.text("__phantType") {
+	case "object":
+		out.WriteString(className)
+		if properties := n.member("__properties"); properties != nil && properties.isObj && properties.count > 0 {
+			out.WriteString(" ")
+			renderMembers(out, properties, "{", "}", "property", "properties", depth, options)
+		}
+		return
+	case "object-ref":
+		out.WriteString(className + " (ref)")
+		return
+	case "resource":
+		out.WriteString("resource(" + n.text("__resourceType") + ")")
+		return
+	case "max-depth":
+		out.WriteString("…")
+		return
+	}
+	renderMembers(out, n, "{", "}", "key", "keys", depth, options)
+}
+
+func renderMembers(out *strings.Builder, n *node, open string, close string, one string, many string, depth int, options Options) {
+	if n.count == 0 {
+		out.WriteString(open + close)
+		return
+	}
+	if depth >= options.MaxDepth {
+		noun := many
+		if n.count == 1 {
+			noun = one
+		}
+		fmt.Fprintf(out, "%s%d %s%s", open, n.count, noun, close)
+		return
+	}
+
+	out.WriteString(open)
+	shown := min(len(n.values), options.MaxItems)
+	for i := 0; i < shown; i++ {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		if n.isObj {
+			out.WriteString(n.keys[i] + ": ")
+		}
+		render(out, n.values[i], depth+1, options)
+	}
+	if rest := n.count - shown; rest > 0 {
+		fmt.Fprintf(out, ", …+%d", rest)
+	}
+	out.WriteString(close)
+}
+
+func shortClass(name string) string {
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+type cacheKey struct {
+	id   string
+	size int
+}
+
+// Cache memoizes previews per event. Entries are keyed by event ID and
+// payload size, so a re-decoded payload gets a fresh preview.
+type Cache struct {
+	capacity int
+
+	mu      sync.Mutex
+	options Options
+	entries map[cacheKey]string
+}
+
+func NewCache(capacity int, options Options) *Cache {
+	if capacity <= 0 {
+		capacity = DefaultCacheSize
+	}
+	return &Cache{capacity: capacity, options: options, entries: make(map[cacheKey]string)}
+}
+
+func (c *Cache) Preview(event dump.Event) string {
+	key := cacheKey{id: event.ID, size: len(event.Payload)}
+
+	c.mu.Lock()
+	cached, ok := c.entries[key]
+	options := c.options
+	c.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	text := Render(event, options)
+
+	c.mu.Lock()
+	// Options may have changed while rendering; keep only current previews.
+	if c.options == options {
+		if len(c.entries) >= c.capacity {
+			c.entries = make(map[cacheKey]string)
+		}
+		c.entries[key] = text
+	}
+	c.mu.Unlock()
+	return text
+}
+
+func (c *Cache) Options() Options {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.options
+}
+
+// SetOptions changes the options and drops previews rendered with the old
+// ones.
+func (c *Cache) SetOptions(options Options) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.options = options
+	c.entries = make(map[cacheKey]string)
+	c.mu.Unlock()
+	return nil
+}
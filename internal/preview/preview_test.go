@@ -0,0 +1,81 @@
+package preview
+
+import (
+	"encoding/json"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func jsonEvent(payload string) dump.Event {
+	return dump.Event{ID: "evt-1", PayloadFormat: dump.PayloadFormatJSON, Payload: json.RawMessage(payload)}
+}
+
+func TestRender_JSONPayloads(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    string
+	}{
+		{`"hello"`, `"hello"`},
+		{`{"user":{"id":42,"name":"Ada","roles":["admin","dev"]},"ok":true}`, `{user: {id: 42, name: "Ada", roles: [2 items]}, ok: true}`},
+		{`[1,2,3,4,5]`, `[1, 2, 3, …+2]`},
+		{`{"a":[],"b":{}}`, `{a: [], b: {}}`},
+		{`{"__phantType":"object","__className":"App\\Models\\User","__objectId":7,"__properties":{"id":42,"email":"ada@example.test"}}`, `User {id: 42, email: "ada@example.test"}`},
+		{`{"__phantType":"object-ref","__className":"App\\Models\\User","__objectId":7}`, `User (ref)`},
+		{`{"__phantType":"resource","__resourceType":"stream"}`, `resource(stream)`},
+	}
+
+	for _, test := range tests {
+		if got := Render(jsonEvent(test.payload), DefaultOptions()); got != test.want {
+			t.Fatalf("Render(%s) = %s, want %s", test.payload, got, test.want)
+		}
+	}
+}
+
+func TestRender_RespectsDepthAndLength(t *testing.T) {
+	event := jsonEvent(`{"order":{"lines":[{"sku":"A"}]},"note":"a very long note that keeps going well past the limit"}`)
+
+	if got, want := Render(event, Options{MaxDepth: 0, MaxItems: 3, MaxLength: 120}), "{2 keys}"; got != want {
+		t.Fatalf("Render(depth 0) = %s, want %s", got, want)
+	}
+	if got, want := Render(event, Options{MaxDepth: 1, MaxItems: 3, MaxLength: 30}), `{order: {1 key}, note: "a ver…`; got != want {
+		t.Fatalf("Render(length 30) = %s, want %s", got, want)
+	}
+}
+
+func TestRender_HTMLAndText(t *testing.T) {
+	htmlEvent := dump.Event{PayloadFormat: dump.PayloadFormatHTML, Payload: json.RawMessage(`"<pre class=sf-dump><style>.x{}</style><span>array:2</span> [\n  &quot;a&quot; =&gt; 1\n]</pre>"`)}
+	if got, want := Render(htmlEvent, DefaultOptions()), `array:2 [ "a" => 1 ]`; got != want {
+		t.Fatalf("Render(html) = %s, want %s", got, want)
+	}
+
+	textEvent := dump.Event{PayloadFormat: dump.PayloadFormatText, Payload: json.RawMessage(`"  line one\n  line two "`)}
+	if got, want := Render(textEvent, DefaultOptions()), "line one line two"; got != want {
+		t.Fatalf("Render(text) = %s, want %s", got, want)
+	}
+}
+
+func TestRender_Binary(t *testing.T) {
+	event := dump.Event{PayloadFormat: dump.PayloadFormatBinary, MimeType: "image/png", Payload: json.RawMessage(`""`), Offloaded: true, OriginalBytes: 2048}
+	if got, want := Render(event, DefaultOptions()), "image/png, 2048 bytes"; got != want {
+		t.Fatalf("Render(binary) = %s, want %s", got, want)
+	}
+}
+
+func TestCache_RerendersAfterOptionsChange(t *testing.T) {
+	cache := NewCache(0, DefaultOptions())
+	event := jsonEvent(`[1,2,3,4]`)
+
+	if got := cache.Preview(event); got != "[1, 2, 3, …+1]" {
+		t.Fatalf("Preview() = %s, want three items", got)
+	}
+	if err := cache.SetOptions(Options{MaxDepth: 2, MaxItems: 0, MaxLength: 120}); err == nil {
+		t.Fatalf("SetOptions(0 items) error = nil, want error")
+	}
+	if err := cache.SetOptions(Options{MaxDepth: 2, MaxItems: 1, MaxLength: 120}); err != nil {
+		t.Fatalf("SetOptions() error = %v", err)
+	}
+	if got := cache.Preview(event); got != "[1, …+3]" {
+		t.Fatalf("Preview() after SetOptions = %s, want one item", got)
+	}
+}
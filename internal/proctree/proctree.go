@@ -0,0 +1,117 @@
+// Package proctree correlates command events into process trees per host
+// using the parent PIDs that v2 producers report.
+package proctree
+
+import (
+	"sort"
+	"strings"
+
+	"phant/internal/dump"
+)
+
+// Process is one command process seen on a host. PPID is kept even when
+// the parent sent no events; such a process is a root of its host's tree.
+type Process struct {
+	PID         int       `json:"pid"`
+	PPID        int       `json:"ppid,omitempty"`
+	ProjectRoot string    `json:"projectRoot"`
+	SourceType  string    `json:"sourceType"`
+	Command     string    `json:"command"`
+	FirstSeen   string    `json:"firstSeen"`
+	LastSeen    string    `json:"lastSeen"`
+	EventIDs    []string  `json:"eventIds"`
+	Children    []Process `json:"children"`
+}
+
+type Host struct {
+	Hostname string    `json:"hostname"`
+	Roots    []Process `json:"roots"`
+}
+
+type node struct {
+	process  Process
+	parent   *node
+	children []*node
+}
+
+// Build groups command events, expected oldest first, by host and process
+// and links each process under its parent. Hosts are sorted by name;
+// processes within a level by when they were first seen. Events without
+// command metadata are skipped.
+func Build(events []dump.Event) []Host {
+	hosts := map[string]map[int]*node{}
+	var order []*node
+
+	for _, event := range events {
+		if event.Command == nil || event.Host.PID <= 0 {
+			continue
+		}
+
+		processes := hosts[event.Host.Hostname]
+		if processes == nil {
+			processes = map[int]*node{}
+			hosts[event.Host.Hostname] = processes
+		}
+		current := processes[event.Host.PID]
+		if current == nil {
+			current = &node{process: Process{
+				PID:         event.Host.PID,
+				ProjectRoot: event.ProjectRoot,
+				SourceType:  event.SourceType,
+				Command:     strings.TrimSpace(event.Command.Name + " " + strings.Join(event.Command.Args, " ")),
+				FirstSeen:   event.Timestamp,
+			}}
+			processes[event.Host.PID] = current
+			order = append(order, current)
+		}
+		if event.Host.PPID > 0 {
+			current.process.PPID = event.Host.PPID
+		}
+		current.process.LastSeen = event.Timestamp
+		current.process.EventIDs = append(current.process.EventIDs, event.ID)
+	}
+
+	roots := map[string][]*node{}
+	for hostname, processes := range hosts {
+		for _, current := range order {
+			if processes[current.process.PID] != current {
+				continue
+			}
+			parent := processes[current.process.PPID]
+			if parent == nil || descends(parent, current) {
+				roots[hostname] = append(roots[hostname], current)
+				continue
+			}
+			current.parent = parent
+			parent.children = append(parent.children, current)
+		}
+	}
+
+	tree := make([]Host, 0, len(roots))
+	for hostname, nodes := range roots {
+		tree = append(tree, Host{Hostname: hostname, Roots: flatten(nodes)})
+	}
+	sort.Slice(tree, func(i, j int) bool { return tree[i].Hostname < tree[j].Hostname })
+	return tree
+}
+
+// descends reports whether candidate is current or already linked below
+// it. Reused PIDs can make two processes claim each other as parent; the
+// one seen second then stays a root instead of closing a cycle.
+func descends(candidate *node, current *node) bool {
+	for at := candidate; at != nil; at = at.parent {
+		if at == current {
+			return true
+		}
+	}
+	return false
+}
+
+func flatten(nodes []*node) []Process {
+	processes := make([]Process, len(nodes))
+	for i, current := range nodes {
+		processes[i] = current.process
+		processes[i].Children = flatten(current.children)
+	}
+	return processes
+}
@@ -0,0 +1,64 @@
+package proctree
+
+import (
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func commandEvent(id string, hostname string, pid int, ppid int, name string, args ...string) dump.Event {
+	return dump.Event{
+		ID: id, SourceType: "cli", ProjectRoot: "/app", Timestamp: "2026-03-01T10:00:00Z",
+		Host:    dump.HostMeta{Hostname: hostname, PID: pid, PPID: ppid},
+		Command: &dump.CommandMeta{Name: name, Args: args},
+	}
+}
+
+func TestBuild_LinksChildrenUnderParents(t *testing.T) {
+	events := []dump.Event{
+		commandEvent("e1", "web", 100, 1, "artisan", "deploy"),
+		commandEvent("e2", "web", 101, 100, "artisan", "migrate"),
+		commandEvent("e3", "web", 102, 100, "artisan", "cache:clear"),
+		commandEvent("e4", "web", 101, 100, "artisan", "migrate"),
+		commandEvent("e5", "worker", 200, 100, "artisan", "queue:work"),
+		{ID: "http", SourceType: "http", Host: dump.HostMeta{Hostname: "web", PID: 300, PPID: 100}},
+	}
+
+	hosts := Build(events)
+	if len(hosts) != 2 || hosts[0].Hostname != "web" || hosts[1].Hostname != "worker" {
+		t.Fatalf("Build() = %+v, want web and worker hosts", hosts)
+	}
+
+	web := hosts[0].Roots
+	if len(web) != 1 || web[0].PID != 100 || web[0].Command != "artisan deploy" {
+		t.Fatalf("Build() web roots = %+v, want artisan deploy", web)
+	}
+	children := web[0].Children
+	if len(children) != 2 || children[0].PID != 101 || children[1].PID != 102 {
+		t.Fatalf("Build() children = %+v, want 101 then 102", children)
+	}
+	if got := children[0].EventIDs; len(got) != 2 || got[0] != "e2" || got[1] != "e4" {
+		t.Fatalf("Build() child events = %v, want [e2 e4]", got)
+	}
+
+	// A parent on another host is not correlated.
+	if worker := hosts[1].Roots; len(worker) != 1 || worker[0].PPID != 100 || len(worker[0].Children) != 0 {
+		t.Fatalf("Build() worker roots = %+v, want an orphaned root", worker)
+	}
+}
+
+func TestBuild_BreaksCyclesFromReusedPIDs(t *testing.T) {
+	events := []dump.Event{
+		commandEvent("e1", "web", 10, 20, "artisan", "a"),
+		commandEvent("e2", "web", 20, 10, "artisan", "b"),
+	}
+
+	hosts := Build(events)
+	if len(hosts) != 1 || len(hosts[0].Roots) != 1 {
+		t.Fatalf("Build() = %+v, want one root", hosts)
+	}
+	root := hosts[0].Roots[0]
+	if root.PID != 20 || len(root.Children) != 1 || root.Children[0].PID != 10 {
+		t.Fatalf("Build() root = %+v, want 20 with child 10", root)
+	}
+}
@@ -0,0 +1,148 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// Cursor marks where a page ended, so the next page starts after it even
+// when new events arrive in between. The zero Cursor starts at the newest
+// event.
+type Cursor struct {
+	key cursorKey
+	set bool
+}
+
+// cursorKey orders events as Paginate does: by time, then ULID, with the
+// ID breaking the remaining ties so every event has its own place.
+type cursorKey struct {
+	time time.Time
+	ulid string
+	id   string
+}
+
+// wireCursor is the cursor's JSON form before it is base64-encoded.
+type wireCursor struct {
+	Time string `json:"t,omitempty"`
+	ULID string `json:"u,omitempty"`
+	ID   string `json:"i"`
+}
+
+// CursorPage is a page of summaries ordered newest first. Next continues
+// with older events and is empty on the last page; Total counts every
+// match, across all pages.
+type CursorPage struct {
+	Events []Summary `json:"events"`
+	Total  int       `json:"total"`
+	Next   string    `json:"next"`
+}
+
+// ParseCursor reads a cursor returned in CursorPage.Next; an empty one is
+// the zero Cursor.
+func ParseCursor(text string) (Cursor, error) {
+	if text == "" {
+		return Cursor{}, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var wire wireCursor
+	if err := json.Unmarshal(data, &wire); err != nil || wire.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	key := cursorKey{ulid: wire.ULID, id: wire.ID}
+	if wire.Time != "" {
+		if key.time, err = time.Parse(time.RFC3339Nano, wire.Time); err != nil {
+			return Cursor{}, ErrInvalidCursor
+		}
+	}
+	return Cursor{key: key, set: true}, nil
+}
+
+func (c Cursor) String() string {
+	if !c.set {
+		return ""
+	}
+	wire := wireCursor{ULID: c.key.ulid, ID: c.key.id}
+	if !c.key.time.IsZero() {
+		wire.Time = c.key.time.Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(wire)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Before reports whether event belongs on a page after the cursor, that is
+// whether it is older. Every event is before the zero Cursor.
+func (c Cursor) Before(event dump.Event, basis string) bool {
+	return !c.set || compareKeys(eventKey(event, basis), c.key) < 0
+}
+
+// PageBefore returns the newest limit matches before cursor, newest first,
+// and the cursor of the page after them, empty when none are left. Matches
+// need not be sorted and may include events that are not before cursor.
+func PageBefore(matches []dump.Event, cursor Cursor, limit int, basis string) ([]dump.Event, Cursor) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	limit = min(limit, MaxPageLimit)
+
+	type keyed struct {
+		event dump.Event
+		key   cursorKey
+	}
+	candidates := make([]keyed, 0, len(matches))
+	for _, event := range matches {
+		key := eventKey(event, basis)
+		if !cursor.set || compareKeys(key, cursor.key) < 0 {
+			candidates = append(candidates, keyed{event, key})
+		}
+	}
+	slices.SortFunc(candidates, func(a, b keyed) int { return compareKeys(b.key, a.key) })
+
+	page := make([]dump.Event, 0, min(limit, len(candidates)))
+	for _, candidate := range candidates[:min(limit, len(candidates))] {
+		page = append(page, candidate.event)
+	}
+	if len(candidates) <= limit {
+		return page, Cursor{}
+	}
+	return page, Cursor{key: candidates[limit-1].key, set: true}
+}
+
+func eventKey(event dump.Event, basis string) cursorKey {
+	key := cursorKey{id: event.ID}
+	key.time, _ = time.Parse(time.RFC3339Nano, event.Time(basis))
+	if _, err := dump.ParseULIDTime(event.ID); err == nil {
+		key.ulid = strings.ToUpper(event.ID)
+	}
+	return key
+}
+
+// compareKeys orders keys as dump.SortEventsBy does, oldest first: events
+// whose time does not parse come first, and at the same time events
+// without a ULID follow those with one.
+func compareKeys(a, b cursorKey) int {
+	if c := a.time.Compare(b.time); c != 0 {
+		return c
+	}
+	if (a.ulid == "") != (b.ulid == "") {
+		if a.ulid != "" {
+			return -1
+		}
+		return 1
+	}
+	if c := strings.Compare(a.ulid, b.ulid); c != 0 {
+		return c
+	}
+	return strings.Compare(a.id, b.id)
+}
@@ -0,0 +1,78 @@
+package query
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestPageBefore_WalksPagesNewestFirst(t *testing.T) {
+	events := []dump.Event{
+		{ID: "b", Timestamp: "2026-03-01T10:00:01Z"},
+		{ID: "01KJHZPFXVA4CNV3K2E12YVYTG", Timestamp: "2026-03-01T10:00:01Z"},
+		{ID: "a", Timestamp: "2026-03-01T10:00:01Z"},
+		{ID: "e", Timestamp: "2026-03-01T10:00:03Z"},
+		{ID: "bad", Timestamp: "not a time"},
+	}
+
+	var seen []string
+	cursor := Cursor{}
+	for range 3 {
+		page, next := PageBefore(events, cursor, 2, dump.TimeSent)
+		for _, event := range page {
+			seen = append(seen, event.ID)
+		}
+		if next == (Cursor{}) {
+			break
+		}
+
+		parsed, err := ParseCursor(next.String())
+		if err != nil {
+			t.Fatalf("ParseCursor(%q) error = %v", next, err)
+		}
+		cursor = parsed
+		// Newer events arriving between pages must not shift the next one.
+		events = append(events, dump.Event{ID: "z", Timestamp: "2026-03-01T11:00:00Z"})
+	}
+
+	want := []string{"e", "b", "a", "01KJHZPFXVA4CNV3K2E12YVYTG", "bad"}
+	if !slices.Equal(seen, want) {
+		t.Fatalf("pages = %v, want %v", seen, want)
+	}
+
+	_, next := PageBefore(events[:5], Cursor{}, 2, dump.TimeSent)
+	if next.Before(events[3], dump.TimeSent) || !next.Before(events[2], dump.TimeSent) {
+		t.Fatalf("Before() disagrees with page order")
+	}
+}
+
+func TestParseCursor_RejectsGarbage(t *testing.T) {
+	for _, text := range []string{"%%", "bm90IGpzb24", "e30"} {
+		if _, err := ParseCursor(text); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("ParseCursor(%q) error = %v, want ErrInvalidCursor", text, err)
+		}
+	}
+	if cursor, err := ParseCursor(""); err != nil || cursor != (Cursor{}) {
+		t.Fatalf("ParseCursor(\"\") = %v, %v, want the zero cursor", cursor, err)
+	}
+}
+
+func TestSummarize_DropsPayload(t *testing.T) {
+	status := 500
+	summary := Summarize(dump.Event{
+		ID:        "e1",
+		Payload:   []byte(`{"big":true}`),
+		Preview:   "{big: true}",
+		HTTP:      &dump.HTTPMeta{Method: "GET", Path: "/", StatusCode: &status, Body: "secret"},
+		Exception: &dump.ExceptionMeta{Class: "RuntimeException"},
+		Warnings:  []dump.Warning{{Field: "trace"}},
+	})
+	if summary.Preview != "{big: true}" || summary.HTTP.StatusCode == nil || *summary.HTTP.StatusCode != 500 {
+		t.Fatalf("Summarize() = %+v, want preview and status", summary)
+	}
+	if summary.Exception != "RuntimeException" || summary.Warnings != 1 {
+		t.Fatalf("Summarize() exception = %q, warnings = %d", summary.Exception, summary.Warnings)
+	}
+}
@@ -0,0 +1,78 @@
+package query
+
+import (
+	"fmt"
+
+	"phant/internal/dump"
+)
+
+// Facets count the events a filter selects by the fields the explorer
+// sidebar narrows on. Events without a value for a field are left out of
+// that field's counts, so each facet's counts may add up to less than
+// Total. Tags counts events per tag; an event with several tags counts
+// toward each of them.
+type Facets struct {
+	Total         int     `json:"total"`
+	BySourceType  []Count `json:"bySourceType"`
+	ByStatusClass []Count `json:"byStatusClass"`
+	ByProject     []Count `json:"byProject"`
+	ByLevel       []Count `json:"byLevel"`
+	ByTag         []Count `json:"byTag"`
+}
+
+// Browse is a page of events together with the facets of every match, not
+// only of the page.
+type Browse struct {
+	Result
+	Facets Facets `json:"facets"`
+}
+
+// Facet counts every facet of events in a single pass. tags returns an
+// event's tags and may be nil when no annotations are available.
+func Facet(events []dump.Event, tags func(eventID string) []string) Facets {
+	sources := map[string]int{}
+	statuses := map[string]int{}
+	projects := map[string]int{}
+	levels := map[string]int{}
+	tagged := map[string]int{}
+
+	for _, event := range events {
+		sources[event.SourceType]++
+		if class := StatusClass(event); class != "" {
+			statuses[class]++
+		}
+		if event.ProjectRoot != "" {
+			projects[event.ProjectRoot]++
+		}
+		if event.Level != "" {
+			levels[event.Level]++
+		}
+		if tags != nil {
+			for _, tag := range tags(event.ID) {
+				tagged[tag]++
+			}
+		}
+	}
+
+	return Facets{
+		Total:         len(events),
+		BySourceType:  ranked(sources, 0),
+		ByStatusClass: ranked(statuses, 0),
+		ByProject:     ranked(projects, 0),
+		ByLevel:       ranked(levels, 0),
+		ByTag:         ranked(tagged, 0),
+	}
+}
+
+// StatusClass is the class of an event's HTTP response status, such as
+// "2xx" or "5xx", or empty for events without one.
+func StatusClass(event dump.Event) string {
+	if event.HTTP == nil || event.HTTP.StatusCode == nil {
+		return ""
+	}
+	code := *event.HTTP.StatusCode
+	if code < 100 || code > 599 {
+		return ""
+	}
+	return fmt.Sprintf("%dxx", code/100)
+}
@@ -0,0 +1,43 @@
+package query
+
+import (
+	"slices"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestFacet_CountsEveryFacetOfTheMatches(t *testing.T) {
+	ok, missing, failed := 200, 404, 503
+	events := []dump.Event{
+		{ID: "a", SourceType: "http", ProjectRoot: "/app", Level: "info", HTTP: &dump.HTTPMeta{Method: "GET", Path: "/", StatusCode: &ok}},
+		{ID: "b", SourceType: "http", ProjectRoot: "/app", Level: "error", HTTP: &dump.HTTPMeta{Method: "GET", Path: "/x", StatusCode: &missing}},
+		{ID: "c", SourceType: "http", ProjectRoot: "/api", Level: "error", HTTP: &dump.HTTPMeta{Method: "POST", Path: "/y", StatusCode: &failed}},
+		{ID: "d", SourceType: "cli", ProjectRoot: "/api"},
+	}
+	tags := map[string][]string{"b": {"bug", "checkout"}, "c": {"bug"}}
+
+	facets := Facet(events, func(eventID string) []string { return tags[eventID] })
+	if facets.Total != 4 {
+		t.Fatalf("Facet() total = %d, want 4", facets.Total)
+	}
+	if want := []Count{{"http", 3}, {"cli", 1}}; !slices.Equal(facets.BySourceType, want) {
+		t.Fatalf("Facet() bySourceType = %v, want %v", facets.BySourceType, want)
+	}
+	if want := []Count{{"2xx", 1}, {"4xx", 1}, {"5xx", 1}}; !slices.Equal(facets.ByStatusClass, want) {
+		t.Fatalf("Facet() byStatusClass = %v, want %v", facets.ByStatusClass, want)
+	}
+	if want := []Count{{"/api", 2}, {"/app", 2}}; !slices.Equal(facets.ByProject, want) {
+		t.Fatalf("Facet() byProject = %v, want %v", facets.ByProject, want)
+	}
+	if want := []Count{{"error", 2}, {"info", 1}}; !slices.Equal(facets.ByLevel, want) {
+		t.Fatalf("Facet() byLevel = %v, want %v", facets.ByLevel, want)
+	}
+	if want := []Count{{"bug", 2}, {"checkout", 1}}; !slices.Equal(facets.ByTag, want) {
+		t.Fatalf("Facet() byTag = %v, want %v", facets.ByTag, want)
+	}
+
+	if facets := Facet(nil, nil); facets.ByTag == nil || len(facets.ByTag) != 0 {
+		t.Fatalf("Facet(nil) byTag = %v, want empty", facets.ByTag)
+	}
+}
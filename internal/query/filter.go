@@ -0,0 +1,267 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// Filter selects events by their envelope fields. Empty fields match
+// everything; set fields must all match.
+type Filter struct {
+	SourceType  string `json:"sourceType"`
+	ProjectRoot string `json:"projectRoot"`
+	PHPSAPI     string `json:"phpSapi"`
+	IsDD        *bool  `json:"isDd"`
+	IsError     *bool  `json:"isError"`
+	// From and To take any time ParseTime reads, so relative bounds such
+	// as "now-1h" or "yesterday" are resolved when the filter is compiled.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Since is shorthand for a From of that long ago, such as "5m" or "2d".
+	Since string `json:"since"`
+	// Duration selects events by their durationMs: a comparison such as
+	// "> 200ms" or an inclusive range such as "100ms..2s". Events without
+	// a duration never match.
+	Duration       string `json:"duration"`
+	HTTPMethod     string `json:"httpMethod"`
+	HTTPPathPrefix string `json:"httpPathPrefix"`
+	CommandName    string `json:"commandName"`
+	// Origin selects events made at one callsite, as file:line.
+	Origin string `json:"origin"`
+	// IngestSource selects events that arrived through one listener or
+	// file; see dump.Event.IngestSource.
+	IngestSource string `json:"ingestSource"`
+	// UserID, JobName, Queue, and Schedule select by the user, job, and
+	// schedule blocks; events without the block never match.
+	UserID   string `json:"userId"`
+	JobName  string `json:"jobName"`
+	Queue    string `json:"queue"`
+	Schedule string `json:"schedule"`
+	// SlowQuery selects events flagged as slow database queries, or with
+	// false every other event.
+	SlowQuery *bool `json:"slowQuery"`
+	// Tag and Pinned select by user annotations; they need a matcher with
+	// annotations attached and otherwise match only unpinned events.
+	Tag    string `json:"tag"`
+	Pinned *bool  `json:"pinned"`
+	// TimeBasis selects the time From and To apply to: dump.TimeSent (the
+	// default) or dump.TimeReceived, which falls back to the sent time for
+	// events received without the receive clock.
+	TimeBasis string `json:"timeBasis"`
+}
+
+// Annotations answers annotation lookups for Tag and Pinned filters.
+type Annotations interface {
+	Pinned(eventID string) bool
+	HasTag(eventID string, tag string) bool
+}
+
+type Page struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type Result struct {
+	Events []dump.Event `json:"events"`
+	Total  int          `json:"total"`
+	Offset int          `json:"offset"`
+	Limit  int          `json:"limit"`
+}
+
+// Matcher is a validated filter ready to be evaluated against many events.
+type Matcher struct {
+	filter      Filter
+	from        time.Time
+	to          time.Time
+	duration    *durationBound
+	annotations Annotations
+}
+
+func (f Filter) Compile() (Matcher, error) {
+	return f.CompileAt(time.Now())
+}
+
+// CompileAt is Compile with relative times resolved against now.
+func (f Filter) CompileAt(now time.Time) (Matcher, error) {
+	matcher := Matcher{filter: f}
+
+	if f.SourceType != "" {
+		if _, ok := dump.LookupSourceType(f.SourceType); !ok {
+			return Matcher{}, dump.InvalidSourceTypeError()
+		}
+	}
+
+	switch f.TimeBasis {
+	case "", dump.TimeSent, dump.TimeReceived:
+	default:
+		return Matcher{}, errors.New("time basis must be sent or received")
+	}
+
+	var err error
+	if f.From != "" {
+		if matcher.from, err = ParseTime(f.From, now); err != nil {
+			return Matcher{}, fmt.Errorf("from: %w", err)
+		}
+	}
+	if f.Since != "" {
+		if f.From != "" {
+			return Matcher{}, errors.New("since and from must not both be set")
+		}
+		age, err := ParseDuration(f.Since)
+		if err != nil {
+			return Matcher{}, fmt.Errorf("since: %w", err)
+		}
+		if age < 0 {
+			return Matcher{}, errors.New("since must not be negative")
+		}
+		matcher.from = now.Add(-age)
+	}
+	if f.To != "" {
+		if matcher.to, err = ParseTime(f.To, now); err != nil {
+			return Matcher{}, fmt.Errorf("to: %w", err)
+		}
+	}
+	if !matcher.from.IsZero() && !matcher.to.IsZero() && matcher.to.Before(matcher.from) {
+		return Matcher{}, errors.New("to must not be before from")
+	}
+	if f.Duration != "" {
+		if matcher.duration, err = parseDurationBound(f.Duration); err != nil {
+			return Matcher{}, err
+		}
+	}
+
+	return matcher, nil
+}
+
+// WithAnnotations returns a matcher that resolves Tag and Pinned through
+// annotations.
+func (m Matcher) WithAnnotations(annotations Annotations) Matcher {
+	m.annotations = annotations
+	return m
+}
+
+func (f Filter) IsZero() bool {
+	return f == Filter{}
+}
+
+func (m Matcher) Match(event dump.Event) bool {
+	f := m.filter
+
+	if f.SourceType != "" && event.SourceType != f.SourceType {
+		return false
+	}
+	if f.ProjectRoot != "" && event.ProjectRoot != f.ProjectRoot {
+		return false
+	}
+	if f.PHPSAPI != "" && event.PHPSAPI != f.PHPSAPI {
+		return false
+	}
+	if f.IsDD != nil && event.IsDD != *f.IsDD {
+		return false
+	}
+	if f.IsError != nil && event.IsError != *f.IsError {
+		return false
+	}
+
+	if f.HTTPMethod != "" || f.HTTPPathPrefix != "" {
+		if event.HTTP == nil {
+			return false
+		}
+		if f.HTTPMethod != "" && !strings.EqualFold(event.HTTP.Method, f.HTTPMethod) {
+			return false
+		}
+		if f.HTTPPathPrefix != "" && !strings.HasPrefix(event.HTTP.Path, f.HTTPPathPrefix) {
+			return false
+		}
+	}
+
+	if f.CommandName != "" && (event.Command == nil || event.Command.Name != f.CommandName) {
+		return false
+	}
+	if f.Origin != "" && Origin(event) != f.Origin {
+		return false
+	}
+	if f.IngestSource != "" && event.IngestSource != f.IngestSource {
+		return false
+	}
+	if f.UserID != "" && (event.User == nil || event.User.ID != f.UserID) {
+		return false
+	}
+	if f.JobName != "" && (event.Job == nil || event.Job.Name != f.JobName) {
+		return false
+	}
+	if f.Queue != "" && (event.Job == nil || event.Job.Queue != f.Queue) {
+		return false
+	}
+	if f.Schedule != "" && (event.Schedule == nil || event.Schedule.Expression != f.Schedule) {
+		return false
+	}
+	if f.SlowQuery != nil && event.SlowQuery != *f.SlowQuery {
+		return false
+	}
+	if m.duration != nil && !m.duration.match(event.DurationMs) {
+		return false
+	}
+
+	if f.Tag != "" && (m.annotations == nil || !m.annotations.HasTag(event.ID, f.Tag)) {
+		return false
+	}
+	if f.Pinned != nil {
+		pinned := m.annotations != nil && m.annotations.Pinned(event.ID)
+		if pinned != *f.Pinned {
+			return false
+		}
+	}
+
+	if !m.from.IsZero() || !m.to.IsZero() {
+		timestamp, err := time.Parse(time.RFC3339Nano, event.Time(f.TimeBasis))
+		if err != nil {
+			return false
+		}
+		if !m.from.IsZero() && timestamp.Before(m.from) {
+			return false
+		}
+		if !m.to.IsZero() && timestamp.After(m.to) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Paginate orders matches by timestamp, then ULID, and slices them into a
+// page ordered newest first: offset 0 is the most recent page. Matches that
+// cannot be told apart keep their given order, which should be arrival.
+func Paginate(matches []dump.Event, page Page) Result {
+	return PaginateBy(matches, page, dump.TimeSent)
+}
+
+// PaginateBy is Paginate ordering by the given time basis; see
+// dump.Event.Time.
+func PaginateBy(matches []dump.Event, page Page, basis string) Result {
+	matches = slices.Clone(matches)
+	dump.SortEventsBy(matches, basis)
+
+	limit := page.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	limit = min(limit, MaxPageLimit)
+	offset := max(page.Offset, 0)
+
+	result := Result{Events: []dump.Event{}, Total: len(matches), Offset: offset, Limit: limit}
+	for i := len(matches) - 1 - offset; i >= 0 && len(result.Events) < limit; i-- {
+		result.Events = append(result.Events, matches[i])
+	}
+	return result
+}
@@ -0,0 +1,206 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	isDD := true
+	httpEvent := dump.Event{
+		SourceType: "http", ProjectRoot: "/app", PHPSAPI: "fpm-fcgi", IsDD: true,
+		Timestamp:    "2026-03-01T10:00:00Z",
+		HTTP:         &dump.HTTPMeta{Method: "POST", Path: "/api/users/42"},
+		IngestSource: "tcp:127.0.0.1:23517",
+		User:         &dump.UserMeta{ID: "42", Name: "ada"},
+	}
+	workerEvent := dump.Event{
+		SourceType: "worker", ProjectRoot: "/app", PHPSAPI: "cli",
+		Timestamp: "2026-03-01T12:00:00Z", ReceivedAt: "2026-03-01T10:30:00Z",
+		Command: &dump.CommandMeta{Name: "artisan"},
+		Trace:   []dump.TraceFrame{{File: "/app/Job.php", Line: 12}},
+		SQL:     &dump.SQLMeta{Query: "select 1"}, SlowQuery: true,
+		Job: &dump.JobMeta{Name: "App\\Jobs\\Prune", Queue: "low"},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []bool
+	}{
+		{name: "empty matches all", filter: Filter{}, want: []bool{true, true}},
+		{name: "source type", filter: Filter{SourceType: "worker"}, want: []bool{false, true}},
+		{name: "isDd", filter: Filter{IsDD: &isDD}, want: []bool{true, false}},
+		{name: "http method and prefix", filter: Filter{HTTPMethod: "post", HTTPPathPrefix: "/api/"}, want: []bool{true, false}},
+		{name: "command name", filter: Filter{CommandName: "artisan"}, want: []bool{false, true}},
+		{name: "slow query", filter: Filter{SlowQuery: &isDD}, want: []bool{false, true}},
+		{name: "origin", filter: Filter{Origin: "/app/Job.php:12"}, want: []bool{false, true}},
+		{name: "ingest source", filter: Filter{IngestSource: "tcp:127.0.0.1:23517"}, want: []bool{true, false}},
+		{name: "user", filter: Filter{UserID: "42"}, want: []bool{true, false}},
+		{name: "job and queue", filter: Filter{JobName: "App\\Jobs\\Prune", Queue: "low"}, want: []bool{false, true}},
+		{name: "other queue", filter: Filter{Queue: "high"}, want: []bool{false, false}},
+		{name: "schedule", filter: Filter{Schedule: "@daily"}, want: []bool{false, false}},
+		{name: "time range", filter: Filter{From: "2026-03-01T11:00:00Z", To: "2026-03-01T13:00:00+01:00"}, want: []bool{false, true}},
+		{name: "received time range", filter: Filter{From: "2026-03-01T10:15:00Z", To: "2026-03-01T11:00:00Z", TimeBasis: dump.TimeReceived}, want: []bool{false, true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher, err := test.filter.Compile()
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			for i, event := range []dump.Event{httpEvent, workerEvent} {
+				if got := matcher.Match(event); got != test.want[i] {
+					t.Fatalf("Match(event %d) = %v, want %v", i, got, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFilter_CompileRejectsInvalidFilters(t *testing.T) {
+	for _, filter := range []Filter{
+		{SourceType: "daemon"},
+		{From: "last tuesday"},
+		{Since: "5 minutes"},
+		{Since: "5m", From: "today"},
+		{Since: "-5m"},
+		{Duration: "about 2s"},
+		{Duration: "2s..1s"},
+		{From: "2026-03-02T00:00:00Z", To: "2026-03-01T00:00:00Z"},
+		{TimeBasis: "local"},
+	} {
+		if _, err := filter.Compile(); err == nil {
+			t.Fatalf("Compile(%+v) error = nil, want error", filter)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 2, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2026-03-01T10:00:00+01:00", time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-03-01 14:30", time.Date(2026, 3, 1, 14, 30, 0, 0, time.UTC)},
+		{"1772400000", time.Unix(1772400000, 0)},
+		{"1772400000123", time.UnixMilli(1772400000123)},
+		{"now", now},
+		{"today", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"yesterday", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"now-15m", now.Add(-15 * time.Minute)},
+		{"now + 1h", now.Add(time.Hour)},
+		{"-2d", now.Add(-48 * time.Hour)},
+		{"1w ago", now.Add(-7 * 24 * time.Hour)},
+	}
+	for _, test := range tests {
+		got, err := ParseTime(test.value, now)
+		if err != nil {
+			t.Fatalf("ParseTime(%q) error = %v", test.value, err)
+		}
+		if !got.Equal(test.want) {
+			t.Fatalf("ParseTime(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestMatcher_MatchesRelativeTimesAndDurations(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	fast, slow := 50.0, 250.0
+	events := []dump.Event{
+		{Timestamp: "2026-03-02T11:50:00Z", DurationMs: &fast},
+		{Timestamp: "2026-03-02T11:58:00Z", DurationMs: &slow},
+		{Timestamp: "2026-03-02T11:59:00Z"},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []bool
+	}{
+		{name: "since", filter: Filter{Since: "5m"}, want: []bool{false, true, true}},
+		{name: "relative range", filter: Filter{From: "now-15m", To: "now-90s"}, want: []bool{true, true, false}},
+		{name: "slower than", filter: Filter{Duration: "> 200ms"}, want: []bool{false, true, false}},
+		{name: "at most", filter: Filter{Duration: "<=50ms"}, want: []bool{true, false, false}},
+		{name: "range", filter: Filter{Duration: "10ms..0.1s"}, want: []bool{true, false, false}},
+		{name: "since and duration", filter: Filter{Since: "5m", Duration: ">=250ms"}, want: []bool{false, true, false}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher, err := test.filter.CompileAt(now)
+			if err != nil {
+				t.Fatalf("CompileAt() error = %v", err)
+			}
+			for i, event := range events {
+				if got := matcher.Match(event); got != test.want[i] {
+					t.Fatalf("Match(event %d) = %v, want %v", i, got, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPaginate_NewestFirst(t *testing.T) {
+	events := make([]dump.Event, 5)
+	for i := range events {
+		events[i].ID = fmt.Sprint(i)
+	}
+
+	result := Paginate(events, Page{Offset: 1, Limit: 2})
+	if result.Total != 5 || len(result.Events) != 2 || result.Events[0].ID != "3" || result.Events[1].ID != "2" {
+		t.Fatalf("Paginate() = %+v, want IDs [3 2] of 5", result)
+	}
+
+	if result := Paginate(events, Page{Offset: 10}); len(result.Events) != 0 || result.Limit != DefaultPageLimit {
+		t.Fatalf("Paginate() past end = %+v, want empty page with default limit", result)
+	}
+}
+
+type fakeAnnotations map[string][]string
+
+func (a fakeAnnotations) Pinned(id string) bool {
+	return slices.Contains(a[id], "pinned")
+}
+
+func (a fakeAnnotations) HasTag(id string, tag string) bool {
+	return slices.Contains(a[id], tag)
+}
+
+func TestMatcher_MatchesAnnotations(t *testing.T) {
+	pinned := true
+	annotations := fakeAnnotations{"a": {"pinned", "bug"}, "b": {"bug"}}
+	events := []dump.Event{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	tests := []struct {
+		filter Filter
+		want   []bool
+	}{
+		{filter: Filter{Tag: "bug"}, want: []bool{true, true, false}},
+		{filter: Filter{Pinned: &pinned}, want: []bool{true, false, false}},
+		{filter: Filter{Tag: "bug", Pinned: &pinned}, want: []bool{true, false, false}},
+	}
+	for _, test := range tests {
+		matcher, err := test.filter.Compile()
+		if err != nil {
+			t.Fatalf("Compile() error = %v", err)
+		}
+		matcher = matcher.WithAnnotations(annotations)
+		for i, event := range events {
+			if got := matcher.Match(event); got != test.want[i] {
+				t.Fatalf("Match(%+v, %s) = %v, want %v", test.filter, event.ID, got, test.want[i])
+			}
+		}
+	}
+
+	matcher, _ := Filter{Tag: "bug"}.Compile()
+	if matcher.Match(events[0]) {
+		t.Fatalf("Match(tag without annotations) = true, want false")
+	}
+}
@@ -0,0 +1,164 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	// DefaultStatsTop caps the route and origin rankings.
+	DefaultStatsTop = 10
+	// MaxMinuteBuckets caps the events-per-minute series at one day; longer
+	// spans keep their most recent minutes.
+	MaxMinuteBuckets = 24 * 60
+)
+
+// TimeRange bounds the events a statistic covers. Either end may be empty
+// to leave it open.
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+type MinuteBucket struct {
+	Start string `json:"start"`
+	Count int    `json:"count"`
+}
+
+// Stats is what the dashboard charts: event counts grouped several ways.
+// Routes are "METHOD /path" with id-like segments collapsed to {id};
+// origins are the first application frame as file:line.
+type Stats struct {
+	Total        int            `json:"total"`
+	BySourceType []Count        `json:"bySourceType"`
+	ByProject    []Count        `json:"byProject"`
+	ByRoute      []Count        `json:"byRoute"`
+	PerMinute    []MinuteBucket `json:"perMinute"`
+	TopOrigins   []Count        `json:"topOrigins"`
+}
+
+func (r TimeRange) Compile() (Matcher, error) {
+	return Filter{From: r.From, To: r.To}.Compile()
+}
+
+// Aggregate computes Stats for events that are already limited to the
+// range; the minute series spans the range ends when set, else the events.
+func Aggregate(events []dump.Event, timeRange TimeRange) Stats {
+	sources := map[string]int{}
+	projects := map[string]int{}
+	routes := map[string]int{}
+	origins := map[string]int{}
+	minutes := map[time.Time]int{}
+	var first, last time.Time
+
+	for _, event := range events {
+		sources[event.SourceType]++
+		projects[event.ProjectRoot]++
+		if event.HTTP != nil {
+			routes[Route(event.HTTP.Method, event.HTTP.Path)]++
+		}
+		if origin := Origin(event); origin != "" {
+			origins[origin]++
+		}
+
+		timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+		if err != nil {
+			continue
+		}
+		minute := timestamp.UTC().Truncate(time.Minute)
+		minutes[minute]++
+		if first.IsZero() || minute.Before(first) {
+			first = minute
+		}
+		if minute.After(last) {
+			last = minute
+		}
+	}
+
+	if from, err := time.Parse(time.RFC3339Nano, timeRange.From); err == nil {
+		first = from.UTC().Truncate(time.Minute)
+	}
+	if to, err := time.Parse(time.RFC3339Nano, timeRange.To); err == nil {
+		last = to.UTC().Truncate(time.Minute)
+	}
+
+	return Stats{
+		Total:        len(events),
+		BySourceType: ranked(sources, 0),
+		ByProject:    ranked(projects, 0),
+		ByRoute:      ranked(routes, DefaultStatsTop),
+		PerMinute:    minuteSeries(minutes, first, last),
+		TopOrigins:   ranked(origins, DefaultStatsTop),
+	}
+}
+
+var idSegmentPattern = regexp.MustCompile(`^(?:\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9A-HJKMNP-TV-Z]{26})$`)
+
+// Route groups requests to the same endpoint: numeric, UUID, and ULID path
+// segments become {id}.
+func Route(method string, path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if idSegmentPattern.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.ToUpper(method) + " " + strings.Join(segments, "/")
+}
+
+// Origin is the first application frame of an event as file:line, or empty
+// when the event carries no trace.
+func Origin(event dump.Event) string {
+	frame := event.Origin
+	if frame == nil && len(event.Trace) > 0 {
+		frame = &event.Trace[0]
+	}
+	if frame == nil || frame.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+}
+
+// ranked orders counts highest first, ties by key, keeping at most top
+// entries when top is positive.
+func ranked(counts map[string]int, top int) []Count {
+	ranking := make([]Count, 0, len(counts))
+	for key, count := range counts {
+		ranking = append(ranking, Count{Key: key, Count: count})
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].Count != ranking[j].Count {
+			return ranking[i].Count > ranking[j].Count
+		}
+		return ranking[i].Key < ranking[j].Key
+	})
+	if top > 0 && len(ranking) > top {
+		ranking = ranking[:top]
+	}
+	return ranking
+}
+
+func minuteSeries(counts map[time.Time]int, first time.Time, last time.Time) []MinuteBucket {
+	if first.IsZero() || last.IsZero() || last.Before(first) {
+		return []MinuteBucket{}
+	}
+	if span := int(last.Sub(first) / time.Minute); span >= MaxMinuteBuckets {
+		first = last.Add(-(MaxMinuteBuckets - 1) * time.Minute)
+	}
+
+	series := []MinuteBucket{}
+	for minute := first; !minute.After(last); minute = minute.Add(time.Minute) {
+		series = append(series, MinuteBucket{Start: minute.Format(time.RFC3339), Count: counts[minute]})
+	}
+	return series
+}
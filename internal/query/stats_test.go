@@ -0,0 +1,49 @@
+package query
+
+import (
+	"slices"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestAggregate_GroupsEventsForTheDashboard(t *testing.T) {
+	events := []dump.Event{
+		{SourceType: "http", ProjectRoot: "/app", Timestamp: "2026-03-01T10:00:05Z", HTTP: &dump.HTTPMeta{Method: "get", Path: "/users/42"}, Origin: &dump.TraceFrame{File: "/app/a.php", Line: 3}},
+		{SourceType: "http", ProjectRoot: "/app", Timestamp: "2026-03-01T10:00:40Z", HTTP: &dump.HTTPMeta{Method: "GET", Path: "/users/7"}, Trace: []dump.TraceFrame{{File: "/app/a.php", Line: 3}}},
+		{SourceType: "cli", ProjectRoot: "/api", Timestamp: "2026-03-01T10:02:00Z", Command: &dump.CommandMeta{Name: "artisan"}},
+	}
+
+	stats := Aggregate(events, TimeRange{})
+	if stats.Total != 3 {
+		t.Fatalf("Aggregate() total = %d, want 3", stats.Total)
+	}
+	if want := []Count{{"http", 2}, {"cli", 1}}; !slices.Equal(stats.BySourceType, want) {
+		t.Fatalf("Aggregate() bySourceType = %v, want %v", stats.BySourceType, want)
+	}
+	if want := []Count{{"/app", 2}, {"/api", 1}}; !slices.Equal(stats.ByProject, want) {
+		t.Fatalf("Aggregate() byProject = %v, want %v", stats.ByProject, want)
+	}
+	if want := []Count{{"GET /users/{id}", 2}}; !slices.Equal(stats.ByRoute, want) {
+		t.Fatalf("Aggregate() byRoute = %v, want %v", stats.ByRoute, want)
+	}
+	if want := []Count{{"/app/a.php:3", 2}}; !slices.Equal(stats.TopOrigins, want) {
+		t.Fatalf("Aggregate() topOrigins = %v, want %v", stats.TopOrigins, want)
+	}
+	want := []MinuteBucket{{"2026-03-01T10:00:00Z", 2}, {"2026-03-01T10:01:00Z", 0}, {"2026-03-01T10:02:00Z", 1}}
+	if !slices.Equal(stats.PerMinute, want) {
+		t.Fatalf("Aggregate() perMinute = %v, want %v", stats.PerMinute, want)
+	}
+}
+
+func TestAggregate_MinuteSeriesSpansRangeAndIsCapped(t *testing.T) {
+	stats := Aggregate(nil, TimeRange{From: "2026-03-01T10:00:00Z", To: "2026-03-01T10:03:30Z"})
+	if len(stats.PerMinute) != 4 || stats.PerMinute[3].Start != "2026-03-01T10:03:00Z" {
+		t.Fatalf("Aggregate(empty) perMinute = %v, want 4 zero buckets", stats.PerMinute)
+	}
+
+	stats = Aggregate(nil, TimeRange{From: "2026-03-01T00:00:00Z", To: "2026-03-05T00:00:00Z"})
+	if len(stats.PerMinute) != MaxMinuteBuckets || stats.PerMinute[0].Start != "2026-03-04T00:01:00Z" {
+		t.Fatalf("Aggregate(4 days) perMinute = %d buckets from %v, want the last day", len(stats.PerMinute), stats.PerMinute[0])
+	}
+}
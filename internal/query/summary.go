@@ -0,0 +1,74 @@
+package query
+
+import "phant/internal/dump"
+
+// Summary is what a list row shows of an event: its envelope and preview
+// without the payload, trace, or raw line, which are fetched on demand.
+type Summary struct {
+	ID            string           `json:"id"`
+	Timestamp     string           `json:"timestamp"`
+	ReceivedAt    string           `json:"receivedAt,omitempty"`
+	SourceType    string           `json:"sourceType"`
+	ProjectRoot   string           `json:"projectRoot"`
+	RequestID     *string          `json:"requestId"`
+	IsDD          bool             `json:"isDd"`
+	IsError       bool             `json:"isError,omitempty"`
+	Level         string           `json:"level,omitempty"`
+	Label         string           `json:"label,omitempty"`
+	Color         string           `json:"color,omitempty"`
+	Preview       string           `json:"preview,omitempty"`
+	PayloadFormat string           `json:"payloadFormat"`
+	DurationMs    *float64         `json:"durationMs,omitempty"`
+	HTTP          *HTTPSummary     `json:"http,omitempty"`
+	CommandName   string           `json:"commandName,omitempty"`
+	LogChannel    string           `json:"logChannel,omitempty"`
+	Exception     string           `json:"exception,omitempty"`
+	Origin        *dump.TraceFrame `json:"origin,omitempty"`
+	RepeatCount   int              `json:"repeatCount,omitempty"`
+	SlowQuery     bool             `json:"slowQuery,omitempty"`
+	Warnings      int              `json:"warnings,omitempty"`
+}
+
+type HTTPSummary struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	StatusCode *int   `json:"statusCode,omitempty"`
+}
+
+// Summarize copies the list fields of event. Exception is the class of the
+// reported exception, if any.
+func Summarize(event dump.Event) Summary {
+	summary := Summary{
+		ID:            event.ID,
+		Timestamp:     event.Timestamp,
+		ReceivedAt:    event.ReceivedAt,
+		SourceType:    event.SourceType,
+		ProjectRoot:   event.ProjectRoot,
+		RequestID:     event.RequestID,
+		IsDD:          event.IsDD,
+		IsError:       event.IsError,
+		Level:         event.Level,
+		Label:         event.Label,
+		Color:         event.Color,
+		Preview:       event.Preview,
+		PayloadFormat: event.PayloadFormat,
+		DurationMs:    event.DurationMs,
+		Origin:        event.Origin,
+		RepeatCount:   event.RepeatCount,
+		SlowQuery:     event.SlowQuery,
+		Warnings:      len(event.Warnings),
+	}
+	if event.HTTP != nil {
+		summary.HTTP = &HTTPSummary{Method: event.HTTP.Method, Path: event.HTTP.Path, StatusCode: event.HTTP.StatusCode}
+	}
+	if event.Command != nil {
+		summary.CommandName = event.Command.Name
+	}
+	if event.Log != nil {
+		summary.LogChannel = event.Log.Channel
+	}
+	if event.Exception != nil {
+		summary.Exception = event.Exception.Class
+	}
+	return summary
+}
@@ -0,0 +1,180 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localLayouts are the calendar formats accepted besides RFC3339. They are
+// read in the time zone of now, which Compile takes from the local clock:
+// queries come from the desktop app on the same machine.
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var (
+	dayUnitPattern    = regexp.MustCompile(`(\d+(?:\.\d+)?)([dw])`)
+	relativePattern   = regexp.MustCompile(`^now\s*([+-])\s*(\S+)$`)
+	agoPattern        = regexp.MustCompile(`^(\S+)\s+ago$`)
+	durationOpPattern = regexp.MustCompile(`^(>=|<=|>|<|=)?\s*(\S+)$`)
+)
+
+// ParseTime reads an absolute or relative time: RFC3339, a local date or
+// date and time ("2026-03-01", "2026-03-01 14:30"), Unix seconds or
+// milliseconds, "now", "today", "yesterday", or an offset from now such as
+// "now-15m", "-2h", or "3d ago".
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return time.Time{}, errors.New("time is empty")
+	}
+
+	if parsed, err := time.Parse(time.RFC3339Nano, strings.ToUpper(value)); err == nil {
+		return parsed, nil
+	}
+	for _, layout := range localLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return parsed, nil
+		}
+	}
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// Thirteen digits reach back to 2001 in milliseconds and forward
+		// past year 33000 in seconds, so length tells the two apart.
+		if len(value) >= 13 {
+			return time.UnixMilli(epoch), nil
+		}
+		return time.Unix(epoch, 0), nil
+	}
+
+	switch value {
+	case "now":
+		return now, nil
+	case "today":
+		return startOfDay(now), nil
+	case "yesterday":
+		return startOfDay(now).AddDate(0, 0, -1), nil
+	}
+
+	sign, amount := "", ""
+	if match := relativePattern.FindStringSubmatch(value); match != nil {
+		sign, amount = match[1], match[2]
+	} else if match := agoPattern.FindStringSubmatch(value); match != nil {
+		sign, amount = "-", match[1]
+	} else if value[0] == '-' || value[0] == '+' {
+		sign, amount = value[:1], value[1:]
+	}
+	if amount != "" {
+		offset, err := ParseDuration(amount)
+		if err == nil {
+			if sign == "-" {
+				offset = -offset
+			}
+			return now.Add(offset), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q: use RFC3339, a date, Unix seconds or milliseconds, or a relative time such as now-5m", value)
+}
+
+// ParseDuration is time.ParseDuration with day ("d") and week ("w") units,
+// as in "2d" or "1w3d".
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	expanded := dayUnitPattern.ReplaceAllStringFunc(value, func(part string) string {
+		match := dayUnitPattern.FindStringSubmatch(part)
+		amount, _ := strconv.ParseFloat(match[1], 64)
+		hours := amount * 24
+		if match[2] == "w" {
+			hours *= 7
+		}
+		return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+	})
+	duration, err := time.ParseDuration(expanded)
+	if err != nil {
+		return 0, fmt.Errorf("unrecognized duration %q: use units such as ms, s, m, h, d, or w", value)
+	}
+	return duration, nil
+}
+
+// durationBound is a compiled Filter.Duration: durations between min and
+// max, with each end inclusive unless marked otherwise. A nil end is open.
+type durationBound struct {
+	min, max                   *float64
+	minExclusive, maxExclusive bool
+}
+
+// parseDurationBound reads a comparison ("> 200ms", "<=1s", "=0ms") or an
+// inclusive range ("100ms..2s").
+func parseDurationBound(value string) (*durationBound, error) {
+	value = strings.TrimSpace(value)
+	milliseconds := func(text string) (*float64, error) {
+		duration, err := ParseDuration(text)
+		if err != nil {
+			return nil, err
+		}
+		ms := float64(duration) / float64(time.Millisecond)
+		return &ms, nil
+	}
+
+	if low, high, ok := strings.Cut(value, ".."); ok {
+		lowMs, err := milliseconds(low)
+		if err != nil {
+			return nil, err
+		}
+		highMs, err := milliseconds(high)
+		if err != nil {
+			return nil, err
+		}
+		if *highMs < *lowMs {
+			return nil, errors.New("duration range must not end before it starts")
+		}
+		return &durationBound{min: lowMs, max: highMs}, nil
+	}
+
+	match := durationOpPattern.FindStringSubmatch(value)
+	if match == nil {
+		return nil, errors.New("duration must be a comparison such as > 200ms or a range such as 100ms..2s")
+	}
+	ms, err := milliseconds(match[2])
+	if err != nil {
+		return nil, err
+	}
+	switch match[1] {
+	case ">":
+		return &durationBound{min: ms, minExclusive: true}, nil
+	case ">=":
+		return &durationBound{min: ms}, nil
+	case "<":
+		return &durationBound{max: ms, maxExclusive: true}, nil
+	case "<=":
+		return &durationBound{max: ms}, nil
+	default:
+		return &durationBound{min: ms, max: ms}, nil
+	}
+}
+
+func (b *durationBound) match(durationMs *float64) bool {
+	if durationMs == nil {
+		return false
+	}
+	value := *durationMs
+	if b.min != nil && (value < *b.min || b.minExclusive && value == *b.min) {
+		return false
+	}
+	if b.max != nil && (value > *b.max || b.maxExclusive && value == *b.max) {
+		return false
+	}
+	return true
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,234 @@
+package ray
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// Request is the body spatie/ray's Client POSTs for every ray() call and for
+// each chained modifier; calls on the same ray() instance share a UUID.
+type Request struct {
+	UUID     string          `json:"uuid"`
+	Payloads []Payload       `json:"payloads"`
+	Meta     json.RawMessage `json:"meta"`
+}
+
+type Payload struct {
+	Type    string          `json:"type"`
+	Content json.RawMessage `json:"content"`
+	Origin  Origin          `json:"origin"`
+}
+
+type Origin struct {
+	Function string `json:"function_name"`
+	File     string `json:"file"`
+	Line     int    `json:"line_number"`
+	Hostname string `json:"hostname"`
+}
+
+// Action is how a payload type affects phant.
+type Action int
+
+const (
+	// ActionContent carries dump content and becomes an event.
+	ActionContent Action = iota
+	// ActionModify changes the event created for the same UUID.
+	ActionModify
+	// ActionClear asks to clear the event list.
+	ActionClear
+	// ActionIgnore covers client-window payloads with no phant equivalent.
+	ActionIgnore
+)
+
+func (p Payload) Action() Action {
+	switch p.Type {
+	case "color", "label", "size":
+		return ActionModify
+	case "clear_all":
+		return ActionClear
+	case "hide", "show_app", "hide_app", "remove", "notify", "confetti", "separator", "screen_color":
+		return ActionIgnore
+	}
+	return ActionContent
+}
+
+var rayColors = map[string]string{
+	"green":  "green",
+	"orange": "orange",
+	"red":    "red",
+	"purple": "purple",
+	"blue":   "blue",
+	"gray":   "gray",
+}
+
+// Modify applies a color or label payload to event.
+func (p Payload) Modify(event *dump.Event) {
+	var content struct {
+		Color string `json:"color"`
+		Label string `json:"label"`
+	}
+	if err := json.Unmarshal(p.Content, &content); err != nil {
+		return
+	}
+
+	switch p.Type {
+	case "color":
+		if color, ok := rayColors[content.Color]; ok {
+			event.Color = color
+		}
+	case "label":
+		event.Label = content.Label
+	}
+}
+
+// Event converts a content payload into a dump event with the given ID.
+func (p Payload) Event(id string, receivedAt time.Time) (dump.Event, error) {
+	event := dump.Event{
+		SchemaVersion:         dump.SchemaVersion,
+		OriginalSchemaVersion: dump.SchemaVersion,
+		ID:                    id,
+		Timestamp:             receivedAt.UTC().Format(time.RFC3339Nano),
+		SourceType:            "cli",
+		PHPSAPI:               "unknown",
+		RequestID:             nil,
+		Command:               &dump.CommandMeta{Name: "ray"},
+		PayloadFormat:         dump.PayloadFormatJSON,
+		Trace:                 []dump.TraceFrame{},
+		Host:                  dump.HostMeta{Hostname: p.Origin.Hostname},
+	}
+
+	if p.Origin.File != "" {
+		event.Trace = []dump.TraceFrame{{File: p.Origin.File, Line: p.Origin.Line, Func: p.Origin.Function}}
+		event.ProjectRoot = filepath.Dir(p.Origin.File)
+	}
+	if event.ProjectRoot == "" {
+		event.ProjectRoot = "/"
+	}
+	if event.Host.Hostname == "" {
+		event.Host.Hostname = "localhost"
+	}
+
+	if err := p.fillContent(&event); err != nil {
+		return dump.Event{}, err
+	}
+
+	event.Warnings = append(event.Warnings, dump.Warning{
+		Field:   "host.pid",
+		Message: "ray requests do not carry a process id",
+	})
+	if len(event.Trace) == 0 {
+		event.Warnings = append(event.Warnings, dump.Warning{
+			Field:   "trace",
+			Message: "trace is empty; the dump callsite is unknown",
+		})
+	}
+	return event, nil
+}
+
+func (p Payload) fillContent(event *dump.Event) error {
+	switch p.Type {
+	case "log":
+		var content struct {
+			Values []json.RawMessage `json:"values"`
+		}
+		if err := json.Unmarshal(p.Content, &content); err != nil {
+			return fmt.Errorf("log content: %w", err)
+		}
+		if len(content.Values) == 1 {
+			event.Payload = content.Values[0]
+			return nil
+		}
+		payload, err := json.Marshal(content.Values)
+		if err != nil {
+			return err
+		}
+		event.Payload = payload
+	case "custom", "html", "text":
+		var content struct {
+			Content string `json:"content"`
+			Label   string `json:"label"`
+		}
+		if err := json.Unmarshal(p.Content, &content); err != nil {
+			return fmt.Errorf("%s content: %w", p.Type, err)
+		}
+		payload, err := marshalString(content.Content)
+		if err != nil {
+			return err
+		}
+		event.PayloadFormat = dump.PayloadFormatHTML
+		if p.Type == "text" {
+			event.PayloadFormat = dump.PayloadFormatText
+		}
+		event.Payload = payload
+		event.Label = content.Label
+	case "json_string":
+		var content struct {
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal(p.Content, &content); err != nil {
+			return fmt.Errorf("json_string content: %w", err)
+		}
+		if !json.Valid([]byte(content.Value)) {
+			return fmt.Errorf("json_string content is not valid JSON")
+		}
+		event.Payload = json.RawMessage(content.Value)
+	case "measure":
+		var content struct {
+			Name      string  `json:"name"`
+			TotalTime float64 `json:"total_time"`
+		}
+		if err := json.Unmarshal(p.Content, &content); err != nil {
+			return fmt.Errorf("measure content: %w", err)
+		}
+		event.Label = content.Name
+		event.DurationMs = &content.TotalTime
+		event.Payload = p.Content
+	case "executed_query":
+		var content struct {
+			SQL        string          `json:"sql"`
+			Bindings   json.RawMessage `json:"bindings"`
+			Connection string          `json:"connection_name"`
+			Time       *float64        `json:"time"`
+		}
+		if err := json.Unmarshal(p.Content, &content); err != nil {
+			return fmt.Errorf("executed_query content: %w", err)
+		}
+		event.SQL = &dump.SQLMeta{Query: content.SQL, Bindings: content.Bindings, Connection: content.Connection}
+		event.DurationMs = content.Time
+		event.Label = "query"
+		event.Payload = p.Content
+	case "new_screen":
+		var content struct {
+			Name string `json:"name"`
+		}
+		_ = json.Unmarshal(p.Content, &content)
+		event.Label = strings.TrimSpace("new screen " + content.Name)
+		event.Payload = json.RawMessage(`null`)
+	default:
+		event.Label = p.Type
+		event.Payload = p.Content
+	}
+
+	if len(event.Payload) == 0 {
+		event.Payload = json.RawMessage(`null`)
+	}
+	return nil
+}
+
+// marshalString keeps markup readable in stored payloads; json.Marshal would
+// escape every < and >.
+func marshalString(value string) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
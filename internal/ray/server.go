@@ -0,0 +1,353 @@
+package ray
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"phant/internal/capture"
+	"phant/internal/dump"
+	"phant/internal/netauth"
+)
+
+// DefaultAddress is the port spatie/ray sends to unless configured otherwise.
+const DefaultAddress = "127.0.0.1:23517"
+
+// DefaultSettleWindow is how long an event waits for chained modifiers such
+// as ->red() or ->label(), which Ray sends as separate requests.
+const DefaultSettleWindow = 150 * time.Millisecond
+
+const (
+	maxRequestBytes = 16 * 1024 * 1024
+	maxSeenUUIDs    = 4096
+)
+
+type Stats struct {
+	Address  string `json:"address"`
+	Requests uint64 `json:"requests"`
+	Events   uint64 `json:"events"`
+	Rejected uint64 `json:"rejected"`
+	// Unauthorized counts requests turned away for a missing or wrong
+	// token.
+	Unauthorized uint64 `json:"unauthorized"`
+}
+
+type pendingEvent struct {
+	event     *dump.Event
+	modifiers []Payload
+	timer     *time.Timer
+}
+
+// Server implements the subset of the Ray desktop app's HTTP API that the
+// PHP client relies on: POST / with payloads, the availability check, and
+// pause locks (always released).
+type Server struct {
+	address string
+	window  time.Duration
+	onEvent func(dump.Event)
+	onClear func()
+	auth    *netauth.Guard
+	tls     *tls.Config
+
+	requests     atomic.Uint64
+	events       atomic.Uint64
+	rejected     atomic.Uint64
+	unauthorized atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[string]*pendingEvent
+	seen    map[string]int
+	closed  bool
+
+	capture func() *capture.Recorder
+
+	listener net.Listener
+	http     *http.Server
+	wg       sync.WaitGroup
+}
+
+func NewServer(address string, onEvent func(dump.Event), onClear func()) *Server {
+	if address == "" {
+		address = DefaultAddress
+	}
+
+	return &Server{
+		address: address,
+		window:  DefaultSettleWindow,
+		onEvent: onEvent,
+		onClear: onClear,
+		pending: make(map[string]*pendingEvent),
+		seen:    make(map[string]int),
+	}
+}
+
+// SetSettleWindow changes how long events wait for modifiers. It must be
+// called before Start.
+func (s *Server) SetSettleWindow(window time.Duration) {
+	if window > 0 {
+		s.window = window
+	}
+}
+
+// SetAuth makes every request carry the guard's token as a bearer
+// credential. It must be called before Start.
+func (s *Server) SetAuth(guard *netauth.Guard) {
+	s.auth = guard
+}
+
+// SetTLS makes the listener serve TLS with config. It must be called
+// before Start.
+func (s *Server) SetTLS(config *tls.Config) {
+	s.tls = config
+}
+
+// SetCapture records connections while recorder returns a recorder. It
+// must be called before Start.
+func (s *Server) SetCapture(recorder func() *capture.Recorder) {
+	s.capture = recorder
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	if s.tls != nil {
+		listener = tls.NewListener(listener, s.tls)
+	}
+	if s.capture != nil {
+		listener = capture.Tap(listener, capture.ListenerRay, s.capture)
+	}
+
+	s.listener = listener
+	s.http = &http.Server{Handler: s, ReadHeaderTimeout: 5 * time.Second}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		_ = s.http.Serve(listener)
+	}()
+	return nil
+}
+
+// Stop closes the listener and emits events still waiting for modifiers.
+func (s *Server) Stop() error {
+	var err error
+	if s.http != nil {
+		err = s.http.Close()
+		s.wg.Wait()
+	}
+
+	s.mu.Lock()
+	s.closed = true
+	uuids := make([]string, 0, len(s.pending))
+	for uuid, entry := range s.pending {
+		entry.timer.Stop()
+		uuids = append(uuids, uuid)
+	}
+	s.mu.Unlock()
+
+	for _, uuid := range uuids {
+		s.settle(uuid)
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) Address() string {
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
+	return s.address
+}
+
+func (s *Server) Stats() Stats {
+	return Stats{
+		Address:      s.Address(),
+		Requests:     s.requests.Load(),
+		Events:       s.events.Load(),
+		Rejected:     s.rejected.Load(),
+		Unauthorized: s.unauthorized.Load(),
+	}
+}
+
+// ServeConn serves the HTTP requests read from conn, as if the listener had
+// accepted it, returning once it ends. Replayed captures arrive this way;
+// responses are discarded.
+func (s *Server) ServeConn(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		request, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		s.ServeHTTP(&discardResponse{header: http.Header{}}, request)
+		_, _ = io.Copy(io.Discard, request.Body)
+		request.Body.Close()
+	}
+}
+
+type discardResponse struct {
+	header http.Header
+}
+
+func (w *discardResponse) Header() http.Header         { return w.header }
+func (w *discardResponse) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardResponse) WriteHeader(int)             {}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.auth.AllowRequest(r) {
+		s.unauthorized.Add(1)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="phant"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/":
+		s.handlePayloads(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/locks/"):
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"active":false,"stop_execution":false}`))
+	default:
+		// The client's availability check expects a 404 from a live app.
+		http.NotFound(w, r)
+	}
+}
+
+// handlePayloads accepts plain or gzip-encoded bodies. The size limit also
+// applies after decompression, so a small body cannot inflate without bound.
+func (s *Server) handlePayloads(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+	case "gzip":
+		decompressed, err := gzip.NewReader(body)
+		if err != nil {
+			s.rejected.Add(1)
+			http.Error(w, "invalid gzip body", http.StatusBadRequest)
+			return
+		}
+		defer decompressed.Close()
+		body = http.MaxBytesReader(w, decompressed, maxRequestBytes)
+	default:
+		s.rejected.Add(1)
+		w.Header().Set("Accept-Encoding", "gzip")
+		http.Error(w, "unsupported content encoding", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var request Request
+	if err := json.NewDecoder(body).Decode(&request); err != nil || request.UUID == "" {
+		s.rejected.Add(1)
+		http.Error(w, "invalid ray request", http.StatusBadRequest)
+		return
+	}
+
+	s.requests.Add(1)
+	s.receive(request, time.Now())
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) receive(request Request, receivedAt time.Time) {
+	var ready []dump.Event
+	clear := false
+
+	s.mu.Lock()
+	entry, ok := s.pending[request.UUID]
+	if !ok {
+		entry = &pendingEvent{}
+	}
+
+	for _, payload := range request.Payloads {
+		switch payload.Action() {
+		case ActionClear:
+			clear = true
+		case ActionModify:
+			if entry.event != nil {
+				payload.Modify(entry.event)
+			} else {
+				entry.modifiers = append(entry.modifiers, payload)
+			}
+		case ActionContent:
+			event, err := payload.Event(s.nextIDLocked(request.UUID), receivedAt)
+			if err != nil {
+				s.rejected.Add(1)
+				continue
+			}
+			if entry.event != nil {
+				ready = append(ready, *entry.event)
+			}
+			for _, modifier := range entry.modifiers {
+				modifier.Modify(&event)
+			}
+			entry.modifiers = nil
+			entry.event = &event
+		}
+	}
+
+	if s.closed {
+		if entry.event != nil {
+			ready = append(ready, *entry.event)
+		}
+	} else if entry.event != nil || len(entry.modifiers) > 0 {
+		if entry.timer == nil {
+			uuid := request.UUID
+			entry.timer = time.AfterFunc(s.window, func() { s.settle(uuid) })
+		} else {
+			entry.timer.Reset(s.window)
+		}
+		s.pending[request.UUID] = entry
+	}
+	s.mu.Unlock()
+
+	if clear && s.onClear != nil {
+		s.onClear()
+	}
+	for _, event := range ready {
+		s.emit(event)
+	}
+}
+
+// nextIDLocked keeps event IDs unique when one ray() instance sends content
+// more than once.
+func (s *Server) nextIDLocked(uuid string) string {
+	if len(s.seen) >= maxSeenUUIDs {
+		s.seen = make(map[string]int)
+	}
+
+	n := s.seen[uuid]
+	s.seen[uuid] = n + 1
+	if n == 0 {
+		return uuid
+	}
+	return uuid + "-" + strconv.Itoa(n+1)
+}
+
+func (s *Server) settle(uuid string) {
+	s.mu.Lock()
+	entry, ok := s.pending[uuid]
+	delete(s.pending, uuid)
+	s.mu.Unlock()
+
+	if ok && entry.event != nil {
+		s.emit(*entry.event)
+	}
+}
+
+func (s *Server) emit(event dump.Event) {
+	s.events.Add(1)
+	s.onEvent(event)
+}
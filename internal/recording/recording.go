@@ -0,0 +1,297 @@
+// Package recording keeps named recordings of the event stream, each event
+// with the time it arrived relative to the start, and plays them back with
+// that timing, for demos and for watching a request's dumps unfold again.
+package recording
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	fileSuffix = ".ndjson"
+
+	// MaxSpeed bounds how much faster than recorded a replay may run.
+	MaxSpeed = 100
+
+	// maxFrameLine bounds a frame when reading; payloads are already capped
+	// at ingest.
+	maxFrameLine = 64 * 1024 * 1024
+)
+
+var (
+	ErrInvalidName  = errors.New("recording names must be 1 to 64 letters, digits, spaces, dots, dashes, or underscores")
+	ErrExists       = errors.New("a recording with this name already exists")
+	ErrNotFound     = errors.New("recording not found")
+	ErrInvalidSpeed = fmt.Errorf("replay speed must be above 0 and at most %d", MaxSpeed)
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._-]{0,63}$`)
+
+// Info describes a recording. Events and DurationMs are only known once it
+// is closed or read back.
+type Info struct {
+	Name       string `json:"name"`
+	StartedAt  string `json:"startedAt"`
+	Events     int    `json:"events"`
+	DurationMs int64  `json:"durationMs"`
+	Bytes      int64  `json:"bytes"`
+}
+
+// Frame is one recorded event and when it arrived, in milliseconds after
+// the recording started.
+type Frame struct {
+	OffsetMs int64      `json:"offsetMs"`
+	Event    dump.Event `json:"event"`
+}
+
+// header is the first line of a recording file.
+type header struct {
+	Name      string `json:"name"`
+	StartedAt string `json:"startedAt"`
+}
+
+// Writer appends frames to a new recording.
+type Writer struct {
+	now     func() time.Time
+	started time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	out  *bufio.Writer
+	info Info
+	err  error
+}
+
+// ValidName reports whether name can name a recording; names become file
+// names, so they cannot hold path separators.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name) && strings.TrimSpace(name) == name && !strings.Contains(name, "..")
+}
+
+// Create starts a recording called name in dir. An existing recording is
+// never overwritten.
+func Create(dir string, name string) (*Writer, error) {
+	if !ValidName(name) {
+		return nil, ErrInvalidName
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, ErrExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{now: time.Now, file: file, out: bufio.NewWriter(file)}
+	w.started = w.now()
+	w.info = Info{Name: name, StartedAt: w.started.UTC().Format(time.RFC3339Nano)}
+	if err := w.writeLine(header{Name: name, StartedAt: w.info.StartedAt}); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends event as arriving now. After a write error every later
+// write fails with it.
+func (w *Writer) Write(event dump.Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	offset := w.now().Sub(w.started).Milliseconds()
+	if err := w.writeLine(Frame{OffsetMs: offset, Event: event}); err != nil {
+		w.err = err
+		return err
+	}
+	w.info.Events++
+	w.info.DurationMs = offset
+	return nil
+}
+
+func (w *Writer) writeLine(value any) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if _, err := w.out.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	w.info.Bytes += int64(len(line)) + 1
+	return nil
+}
+
+func (w *Writer) Info() Info {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.info
+}
+
+// Close finishes the recording; its duration runs until now, so a quiet
+// end is kept.
+func (w *Writer) Close() (Info, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.info.DurationMs = max(w.info.DurationMs, w.now().Sub(w.started).Milliseconds())
+	err := errors.Join(w.err, w.out.Flush(), w.file.Close())
+	return w.info, err
+}
+
+// Read loads a recording. A torn final line, left when phant exited while
+// recording, is skipped.
+func Read(dir string, name string) (Info, []Frame, error) {
+	if !ValidName(name) {
+		return Info{}, nil, ErrInvalidName
+	}
+	file, err := os.Open(path(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return Info{}, nil, ErrNotFound
+	}
+	if err != nil {
+		return Info{}, nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFrameLine)
+	var head header
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &head) != nil {
+		return Info{}, nil, fmt.Errorf("%s: not a recording", file.Name())
+	}
+
+	info := Info{Name: name, StartedAt: head.StartedAt}
+	frames := []Frame{}
+	for scanner.Scan() {
+		var frame Frame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			break
+		}
+		frames = append(frames, frame)
+		info.DurationMs = max(info.DurationMs, frame.OffsetMs)
+	}
+	if err := scanner.Err(); err != nil {
+		return Info{}, nil, err
+	}
+	if stat, err := file.Stat(); err == nil {
+		info.Bytes = stat.Size()
+	}
+	info.Events = len(frames)
+	return info, frames, nil
+}
+
+// List describes the recordings in dir, newest first, from their headers
+// alone; Events and DurationMs are left zero.
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Info{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []Info{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), fileSuffix)
+		if !ok || entry.IsDir() || !ValidName(name) {
+			continue
+		}
+		info, err := stat(dir, name)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].StartedAt > infos[j].StartedAt })
+	return infos, nil
+}
+
+func stat(dir string, name string) (Info, error) {
+	file, err := os.Open(path(dir, name))
+	if err != nil {
+		return Info{}, err
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil {
+		return Info{}, err
+	}
+	var head header
+	if err := json.Unmarshal(line, &head); err != nil {
+		return Info{}, err
+	}
+	info := Info{Name: name, StartedAt: head.StartedAt}
+	if stat, err := file.Stat(); err == nil {
+		info.Bytes = stat.Size()
+	}
+	return info, nil
+}
+
+func Delete(dir string, name string) error {
+	if !ValidName(name) {
+		return ErrInvalidName
+	}
+	err := os.Remove(path(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ValidSpeed reports whether a replay can run at speed times the recorded
+// pace.
+func ValidSpeed(speed float64) bool {
+	return speed > 0 && speed <= MaxSpeed
+}
+
+// Play calls emit with each frame at its offset divided by speed, counted
+// from the call, and returns once every frame was emitted or ctx is done.
+func Play(ctx context.Context, frames []Frame, speed float64, emit func(index int, frame Frame)) error {
+	if !ValidSpeed(speed) {
+		return ErrInvalidSpeed
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for i, frame := range frames {
+		due := start.Add(time.Duration(float64(frame.OffsetMs) * float64(time.Millisecond) / speed))
+		if wait := time.Until(due); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		emit(i, frame)
+	}
+	return nil
+}
+
+func path(dir string, name string) string {
+	return filepath.Join(dir, name+fileSuffix)
+}
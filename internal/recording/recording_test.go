@@ -0,0 +1,110 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func TestWriterRead_RoundTripsFramesWithOffsets(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Create(dir, "checkout bug")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	clock := w.started
+	w.now = func() time.Time { return clock }
+
+	clock = clock.Add(250 * time.Millisecond)
+	if err := w.Write(dump.Event{ID: "e1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	clock = clock.Add(time.Second)
+	if err := w.Write(dump.Event{ID: "e2"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	clock = clock.Add(500 * time.Millisecond)
+	info, err := w.Close()
+	if err != nil || info.Events != 2 || info.DurationMs != 1750 {
+		t.Fatalf("Close() = %+v, %v, want 2 events over 1750ms", info, err)
+	}
+
+	if _, err := Create(dir, "checkout bug"); !errors.Is(err, ErrExists) {
+		t.Fatalf("Create() of an existing name error = %v, want ErrExists", err)
+	}
+
+	// A torn last line is what an unclean exit leaves behind.
+	file, _ := os.OpenFile(filepath.Join(dir, "checkout bug"+fileSuffix), os.O_APPEND|os.O_WRONLY, 0)
+	file.WriteString(`{"offsetMs":1800,"ev`)
+	file.Close()
+
+	read, frames, err := Read(dir, "checkout bug")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(frames) != 2 || frames[0].OffsetMs != 250 || frames[1].OffsetMs != 1250 || frames[1].Event.ID != "e2" {
+		t.Fatalf("Read() frames = %+v", frames)
+	}
+	if read.Events != 2 || read.StartedAt != info.StartedAt {
+		t.Fatalf("Read() info = %+v, want %+v", read, info)
+	}
+
+	infos, err := List(dir)
+	if err != nil || len(infos) != 1 || infos[0].Name != "checkout bug" {
+		t.Fatalf("List() = %+v, %v", infos, err)
+	}
+	if err := Delete(dir, "checkout bug"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, _, err := Read(dir, "checkout bug"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Read() after Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidName_RejectsPaths(t *testing.T) {
+	for _, name := range []string{"", "../secrets", "a/b", `a\b`, " padded", "..", "x..y"} {
+		if ValidName(name) {
+			t.Fatalf("ValidName(%q) = true", name)
+		}
+	}
+	if !ValidName("demo-2026_10.1") {
+		t.Fatalf("ValidName() rejected a plain name")
+	}
+}
+
+func TestPlay_ScalesTimingAndStopsOnCancel(t *testing.T) {
+	frames := []Frame{{OffsetMs: 0}, {OffsetMs: 200}, {OffsetMs: 400}}
+
+	start := time.Now()
+	var emitted []int
+	if err := Play(context.Background(), frames, 4, func(i int, _ Frame) { emitted = append(emitted, i) }); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("Play() at 4x took %v, want about 100ms", elapsed)
+	}
+	if len(emitted) != 3 {
+		t.Fatalf("Play() emitted %v", emitted)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Play(ctx, frames, 1, func(i int, _ Frame) {
+		if i == 0 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Play() after cancel error = %v", err)
+	}
+
+	for _, speed := range []float64{0, -1, MaxSpeed + 1} {
+		if err := Play(context.Background(), frames, speed, func(int, Frame) {}); !errors.Is(err, ErrInvalidSpeed) {
+			t.Fatalf("Play(speed %v) error = %v, want ErrInvalidSpeed", speed, err)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+// Package redact masks sensitive values in events before they are stored,
+// displayed, or forwarded.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"phant/internal/config"
+	"phant/internal/dump"
+)
+
+// Mask replaces every redacted value or match.
+const Mask = "[redacted]"
+
+// maxReportedPaths caps Event.Redacted; a payload with thousands of masked
+// emails only needs to say so, not list every one.
+const maxReportedPaths = 50
+
+type rule struct {
+	key     string
+	pattern *regexp.Regexp
+}
+
+// matchesKey reports whether a JSON object key or query parameter name
+// matches the rule's key. Keys compare case-insensitively and may use glob
+// wildcards, so "*token*" covers access_token and X-Token.
+func (r rule) matchesKey(key string) bool {
+	if r.key == "" {
+		return false
+	}
+	matched, _ := path.Match(r.key, strings.ToLower(key))
+	return matched
+}
+
+// Redactor applies a fixed set of rules. A rule with only a key masks the
+// whole value stored under matching keys; a rule with only a pattern masks
+// matches in every string; a rule with both masks pattern matches inside
+// values under matching keys.
+type Redactor struct {
+	rules []rule
+}
+
+// New compiles rules. Invalid rules are reported by their 1-based position.
+func New(rules []config.RedactionRule) (*Redactor, error) {
+	compiled := make([]rule, 0, len(rules))
+	for i, source := range rules {
+		if err := Validate(source); err != nil {
+			return nil, fmt.Errorf("redaction rule %d: %w", i+1, err)
+		}
+		r := rule{key: strings.ToLower(source.Key)}
+		if source.Pattern != "" {
+			r.pattern = regexp.MustCompile(source.Pattern)
+		}
+		compiled = append(compiled, r)
+	}
+	return &Redactor{rules: compiled}, nil
+}
+
+func Validate(source config.RedactionRule) error {
+	if source.Key == "" && source.Pattern == "" {
+		return errors.New("requires key or pattern")
+	}
+	if _, err := path.Match(strings.ToLower(source.Key), ""); err != nil {
+		return fmt.Errorf("invalid key pattern %q: %w", source.Key, err)
+	}
+	if source.Pattern != "" {
+		if _, err := regexp.Compile(source.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *Redactor) Empty() bool {
+	return r == nil || len(r.rules) == 0
+}
+
+// Event masks the payload unless it is binary, label, REPL expression, log
+// and exception messages, user name, HTTP query, headers, and body, command arguments,
+// source type metadata, and retained raw line in place. It sets event.Redacted to the masked
+// locations and returns whether anything changed.
+func (r *Redactor) Event(event *dump.Event) bool {
+	if r.Empty() {
+		return false
+	}
+
+	var report reporter
+	// A binary payload is base64 data; a match in it would only corrupt it.
+	if event.PayloadFormat != dump.PayloadFormatBinary {
+		if payload, paths, err := r.JSON(event.Payload, "payload"); err == nil && len(paths) > 0 {
+			event.Payload = payload
+			report.add(paths...)
+		}
+	}
+	r.field(&event.Label, "label", &report)
+	r.field(&event.Expression, "expression", &report)
+	if event.Log != nil {
+		r.field(&event.Log.Message, "log.message", &report)
+	}
+	if event.User != nil {
+		r.field(&event.User.Name, "user.name", &report)
+	}
+	for exception, at := event.Exception, "exception"; exception != nil; exception, at = exception.Previous, at+".previous" {
+		r.field(&exception.Message, at+".message", &report)
+	}
+	if event.SQL != nil {
+		r.field(&event.SQL.Query, "sql.query", &report)
+		if bindings, paths, err := r.JSON(event.SQL.Bindings, "sql.bindings"); err == nil && len(paths) > 0 {
+			event.SQL.Bindings = bindings
+			report.add(paths...)
+		}
+	}
+	if event.HTTP != nil {
+		if query, changed := r.query(event.HTTP.Query); changed {
+			event.HTTP.Query = query
+			report.add("http.query")
+		}
+		for _, name := range slices.Sorted(maps.Keys(event.HTTP.Headers)) {
+			if value, changed := r.header(name, event.HTTP.Headers[name]); changed {
+				event.HTTP.Headers[name] = value
+				report.add("http.headers." + name)
+			}
+		}
+		r.body(&event.HTTP.Body, &report)
+	}
+	if event.Command != nil {
+		for i := range event.Command.Args {
+			r.field(&event.Command.Args[i], fmt.Sprintf("command.args[%d]", i), &report)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(event.Meta)) {
+		if meta, paths, err := r.JSON(event.Meta[key], "meta."+key); err == nil && len(paths) > 0 {
+			event.Meta[key] = meta
+			report.add(paths...)
+		}
+	}
+	if len(event.Raw) > 0 {
+		if raw, paths, err := r.JSON(event.Raw, ""); err == nil && len(paths) > 0 {
+			event.Raw = raw
+		}
+	}
+
+	if len(report.paths) == 0 {
+		return false
+	}
+	event.Redacted = report.paths
+	return true
+}
+
+func (r *Redactor) field(value *string, at string, report *reporter) {
+	if masked, changed := r.Text(*value); changed {
+		*value = masked
+		report.add(at)
+	}
+}
+
+// Text masks pattern matches in free text; key-only rules do not apply.
+func (r *Redactor) Text(text string) (string, bool) {
+	return r.text(text, nil)
+}
+
+func (r *Redactor) text(text string, scoped []rule) (string, bool) {
+	masked := text
+	for _, candidate := range r.rules {
+		if candidate.pattern != nil && candidate.key == "" {
+			masked = candidate.pattern.ReplaceAllLiteralString(masked, Mask)
+		}
+	}
+	for _, candidate := range scoped {
+		masked = candidate.pattern.ReplaceAllLiteralString(masked, Mask)
+	}
+	return masked, masked != text
+}
+
+// query masks values of URL query parameters whose names match a key rule,
+// then pattern matches in what is left. Parameter order and encoding are
+// kept.
+func (r *Redactor) query(query string) (string, bool) {
+	if query == "" {
+		return query, false
+	}
+
+	params := strings.Split(query, "&")
+	changed := false
+	for i, param := range params {
+		name, _, hasValue := strings.Cut(param, "=")
+		if !hasValue {
+			continue
+		}
+		for _, candidate := range r.rules {
+			if candidate.pattern == nil && candidate.matchesKey(name) {
+				params[i] = name + "=" + Mask
+				changed = true
+				break
+			}
+		}
+	}
+
+	masked, matched := r.Text(strings.Join(params, "&"))
+	return masked, changed || matched
+}
+
+// header masks a header value as JSON masks a member: key rules match the
+// header name.
+func (r *Redactor) header(name string, value string) (string, bool) {
+	masked, scoped := r.matchKey(name, nil)
+	if masked {
+		return Mask, value != Mask
+	}
+	return r.text(value, scoped)
+}
+
+// body masks a captured request body by what it holds: a JSON document, a
+// form-encoded query string, or free text.
+func (r *Redactor) body(body *string, report *reporter) {
+	trimmed := strings.TrimSpace(*body)
+	switch {
+	case trimmed == "":
+	case json.Valid([]byte(trimmed)):
+		if masked, paths, err := r.JSON(json.RawMessage(*body), "http.body"); err == nil && len(paths) > 0 {
+			*body = string(masked)
+			report.add(paths...)
+		}
+	case strings.Contains(trimmed, "=") && !strings.ContainsAny(trimmed, " \t\r\n"):
+		if masked, changed := r.query(*body); changed {
+			*body = masked
+			report.add("http.body")
+		}
+	default:
+		r.field(body, "http.body", report)
+	}
+}
+
+// JSON masks a JSON document and returns the masked locations as paths
+// below root, such as payload.user.password or payload.items[2].email. The
+// input is returned unchanged, byte for byte, when nothing matched.
+func (r *Redactor) JSON(data json.RawMessage, root string) (json.RawMessage, []string, error) {
+	if r.Empty() || len(data) == 0 {
+		return data, nil, nil
+	}
+
+	w := walker{redactor: r, decoder: json.NewDecoder(bytes.NewReader(data))}
+	w.decoder.UseNumber()
+	if err := w.value(root, nil); err != nil {
+		return data, nil, err
+	}
+	if _, err := w.decoder.Token(); err != io.EOF {
+		return data, nil, errors.New("trailing data after JSON value")
+	}
+	if len(w.report.paths) == 0 {
+		return data, nil, nil
+	}
+	return json.RawMessage(w.out.Bytes()), w.report.paths, nil
+}
+
+type walker struct {
+	redactor *Redactor
+	decoder  *json.Decoder
+	out      bytes.Buffer
+	report   reporter
+}
+
+// value copies one JSON value to out. scoped holds key+pattern rules whose
+// key matched an enclosing member.
+func (w *walker) value(at string, scoped []rule) error {
+	token, err := w.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		if value == '{' {
+			return w.object(at, scoped)
+		}
+		return w.array(at, scoped)
+	case string:
+		masked, changed := w.redactor.text(value, scoped)
+		if changed {
+			w.report.add(at)
+		}
+		return w.encode(masked)
+	case json.Number:
+		w.out.WriteString(value.String())
+	case nil:
+		w.out.WriteString("null")
+	default:
+		return w.encode(value)
+	}
+	return nil
+}
+
+func (w *walker) object(at string, scoped []rule) error {
+	w.out.WriteByte('{')
+	for first := true; w.decoder.More(); first = false {
+		token, err := w.decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		if !first {
+			w.out.WriteByte(',')
+		}
+		if err := w.encode(key); err != nil {
+			return err
+		}
+		w.out.WriteByte(':')
+
+		member := key
+		if at != "" {
+			member = at + "." + key
+		}
+		masked, inner := w.redactor.matchKey(key, scoped)
+		if masked {
+			var skipped json.RawMessage
+			if err := w.decoder.Decode(&skipped); err != nil {
+				return err
+			}
+			w.report.add(member)
+			if err := w.encode(Mask); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.value(member, inner); err != nil {
+			return err
+		}
+	}
+	_, err := w.decoder.Token()
+	w.out.WriteByte('}')
+	return err
+}
+
+func (w *walker) array(at string, scoped []rule) error {
+	w.out.WriteByte('[')
+	for i := 0; w.decoder.More(); i++ {
+		if i > 0 {
+			w.out.WriteByte(',')
+		}
+		if err := w.value(at+"["+strconv.Itoa(i)+"]", scoped); err != nil {
+			return err
+		}
+	}
+	_, err := w.decoder.Token()
+	w.out.WriteByte(']')
+	return err
+}
+
+// matchKey reports whether a member's whole value is masked by a key-only
+// rule, and otherwise which pattern rules apply below it.
+func (r *Redactor) matchKey(key string, scoped []rule) (bool, []rule) {
+	inner := scoped
+	for _, candidate := range r.rules {
+		if !candidate.matchesKey(key) {
+			continue
+		}
+		if candidate.pattern == nil {
+			return true, nil
+		}
+		inner = append(inner[:len(inner):len(inner)], candidate)
+	}
+	return false, inner
+}
+
+func (w *walker) encode(value any) error {
+	encoder := json.NewEncoder(&w.out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	// Encode terminates every value with a newline.
+	w.out.Truncate(w.out.Len() - 1)
+	return nil
+}
+
+type reporter struct {
+	paths []string
+	seen  map[string]bool
+}
+
+func (r *reporter) add(paths ...string) {
+	for _, at := range paths {
+		if r.seen[at] || len(r.paths) >= maxReportedPaths {
+			continue
+		}
+		if r.seen == nil {
+			r.seen = make(map[string]bool)
+		}
+		r.seen[at] = true
+		r.paths = append(r.paths, at)
+	}
+}
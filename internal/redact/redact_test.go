@@ -0,0 +1,106 @@
+package redact
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"phant/internal/config"
+	"phant/internal/dump"
+)
+
+func mustNew(t *testing.T, rules ...config.RedactionRule) *Redactor {
+	t.Helper()
+
+	redactor, err := New(rules)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return redactor
+}
+
+func TestRedactor_JSONMasksKeysAndPatterns(t *testing.T) {
+	redactor := mustNew(t,
+		config.RedactionRule{Key: "password"},
+		config.RedactionRule{Key: "*token*"},
+		config.RedactionRule{Pattern: `[\w.+-]+@[\w-]+\.[\w.]+`},
+		config.RedactionRule{Key: "card", Pattern: `\d{12}(\d{4})`},
+	)
+
+	payload := json.RawMessage(`{"user":{"Password":{"hash":"x"},"email":"ada@example.com","id":7},"Access_Token":"abc","items":[{"note":"mail bob@example.org <now>"}],"card":{"number":"4111111111111111"},"ok":1.50}`)
+	got, paths, err := redactor.JSON(payload, "payload")
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	want := `{"user":{"Password":"[redacted]","email":"[redacted]","id":7},"Access_Token":"[redacted]","items":[{"note":"mail [redacted] <now>"}],"card":{"number":"[redacted]"},"ok":1.50}`
+	if string(got) != want {
+		t.Fatalf("JSON() = %s, want %s", got, want)
+	}
+	wantPaths := []string{"payload.user.Password", "payload.user.email", "payload.Access_Token", "payload.items[0].note", "payload.card.number"}
+	if !slices.Equal(paths, wantPaths) {
+		t.Fatalf("JSON() paths = %v, want %v", paths, wantPaths)
+	}
+}
+
+func TestRedactor_JSONLeavesUnmatchedInputUntouched(t *testing.T) {
+	payload := json.RawMessage(`{ "name": "café",  "n": 1e3 }`)
+	got, paths, err := mustNew(t, config.RedactionRule{Key: "password"}).JSON(payload, "payload")
+	if err != nil || len(paths) != 0 || string(got) != string(payload) {
+		t.Fatalf("JSON() = %s, %v, %v, want input unchanged", got, paths, err)
+	}
+}
+
+func TestRedactor_EventMasksEnvelopeFieldsAndRawLine(t *testing.T) {
+	redactor := mustNew(t, config.RedactionRule{Key: "token"}, config.RedactionRule{Pattern: `secret-\w+`})
+	event := dump.Event{
+		Label:   "secret-label",
+		User:    &dump.UserMeta{ID: "7", Name: "secret-name"},
+		Payload: json.RawMessage(`{"token":"t1"}`),
+		HTTP: &dump.HTTPMeta{
+			Query:   "page=2&token=t2",
+			Headers: map[string]string{"Accept": "text/html", "Token": "t4", "Cookie": "id=secret-c"},
+			Body:    `{"user":"ada","token":"t5"}`,
+		},
+		Command:   &dump.CommandMeta{Args: []string{"--key=secret-arg"}},
+		Meta:      map[string]json.RawMessage{"websocket": json.RawMessage(`{"channel":"chat","token":"t3"}`)},
+		Exception: &dump.ExceptionMeta{Message: "ok", Previous: &dump.ExceptionMeta{Message: "bad secret-x"}},
+		SQL:       &dump.SQLMeta{Query: "select * from keys where name = 'secret-q'", Bindings: json.RawMessage(`[1,"secret-b"]`)},
+		Raw:       json.RawMessage(`{"payload":{"token":"t1"}}`),
+	}
+
+	if !redactor.Event(&event) {
+		t.Fatalf("Event() = false, want true")
+	}
+	want := []string{"payload.token", "label", "user.name", "exception.previous.message", "sql.query", "sql.bindings[1]", "http.query", "http.headers.Cookie", "http.headers.Token", "http.body.token", "command.args[0]", "meta.websocket.token"}
+	if !slices.Equal(event.Redacted, want) {
+		t.Fatalf("Event() redacted = %v, want %v", event.Redacted, want)
+	}
+	if event.HTTP.Query != "page=2&token=[redacted]" || event.Command.Args[0] != "--key=[redacted]" {
+		t.Fatalf("Event() query = %q, args = %q", event.HTTP.Query, event.Command.Args)
+	}
+	if event.HTTP.Headers["Token"] != "[redacted]" || event.HTTP.Headers["Cookie"] != "id=[redacted]" || event.HTTP.Headers["Accept"] != "text/html" {
+		t.Fatalf("Event() headers = %v, want token and cookie masked", event.HTTP.Headers)
+	}
+	if event.HTTP.Body != `{"user":"ada","token":"[redacted]"}` {
+		t.Fatalf("Event() body = %s, want masked token", event.HTTP.Body)
+	}
+	form := dump.Event{HTTP: &dump.HTTPMeta{Body: "user=ada&token=t6"}}
+	if !redactor.Event(&form) || form.HTTP.Body != "user=ada&token=[redacted]" {
+		t.Fatalf("Event() form body = %q, want masked token", form.HTTP.Body)
+	}
+	if got := string(event.Meta["websocket"]); got != `{"channel":"chat","token":"[redacted]"}` {
+		t.Fatalf("Event() meta = %s, want masked token", got)
+	}
+	if string(event.Raw) != `{"payload":{"token":"[redacted]"}}` {
+		t.Fatalf("Event() raw = %s, want masked", event.Raw)
+	}
+}
+
+func TestNew_RejectsInvalidRules(t *testing.T) {
+	for _, rule := range []config.RedactionRule{{}, {Pattern: "("}, {Key: "[a"}} {
+		if _, err := New([]config.RedactionRule{rule}); err == nil {
+			t.Fatalf("New(%+v) error = nil, want error", rule)
+		}
+	}
+}
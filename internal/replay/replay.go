@@ -0,0 +1,204 @@
+// Package replay re-issues the HTTP request an event was dumped from, so
+// the code path that produced a dump can be triggered again from phant.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/redact"
+)
+
+const (
+	DefaultTimeout = 30 * time.Second
+	MaxTimeout     = 5 * time.Minute
+	// MaxResponseBytes caps the response body returned; the rest is read
+	// and discarded so the connection can be reused.
+	MaxResponseBytes = 1 << 20
+)
+
+var (
+	ErrMissingTarget = errors.New("request needs a method and a host")
+	ErrTimeoutRange  = errors.New("timeoutMs must be between 0 and 300000")
+)
+
+// hopHeaders describe the connection the request arrived on, not the
+// request, and are never replayed.
+var hopHeaders = map[string]bool{
+	"Connection":          true,
+	"Content-Length":      true,
+	"Host":                true,
+	"Keep-Alive":          true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+// Overrides change the captured request before it is sent. Empty strings
+// keep the captured value, as does a nil Query or Body; Headers are set on
+// top of the captured ones, and an empty value removes a header.
+type Overrides struct {
+	Method    string            `json:"method"`
+	Scheme    string            `json:"scheme"`
+	Host      string            `json:"host"`
+	Path      string            `json:"path"`
+	Query     *string           `json:"query"`
+	Headers   map[string]string `json:"headers"`
+	Body      *string           `json:"body"`
+	TimeoutMs int               `json:"timeoutMs"`
+}
+
+// Response is what the replayed request got back. Redirects are not
+// followed, so a redirect is returned as it was received.
+type Response struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	StatusCode int                 `json:"statusCode"`
+	Status     string              `json:"status"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body"`
+	Truncated  bool                `json:"truncated"`
+	DurationMs float64             `json:"durationMs"`
+	// Skipped lists the captured headers left out because redaction masked
+	// their values; override them to send them.
+	Skipped []string `json:"skipped"`
+}
+
+// Request builds the request an event's HTTP block describes, with
+// overrides applied, and returns the captured headers it left out.
+func Request(ctx context.Context, meta dump.HTTPMeta, overrides Overrides) (*http.Request, []string, error) {
+	method := strings.ToUpper(first(overrides.Method, meta.Method))
+	target := url.URL{
+		Scheme:   first(overrides.Scheme, meta.Scheme, "http"),
+		Host:     first(overrides.Host, meta.Host),
+		Path:     first(overrides.Path, meta.Path, "/"),
+		RawQuery: meta.Query,
+	}
+	if overrides.Query != nil {
+		target.RawQuery = *overrides.Query
+	}
+	if method == "" || target.Host == "" {
+		return nil, nil, ErrMissingTarget
+	}
+	body := meta.Body
+	if overrides.Body != nil {
+		body = *overrides.Body
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if body == "" {
+		request.Body = http.NoBody
+		request.ContentLength = 0
+	}
+
+	skipped := []string{}
+	for name, value := range meta.Headers {
+		name = http.CanonicalHeaderKey(name)
+		if hopHeaders[name] {
+			continue
+		}
+		if strings.Contains(value, redact.Mask) {
+			skipped = append(skipped, name)
+			continue
+		}
+		request.Header.Set(name, value)
+	}
+	if meta.UserAgent != "" && request.Header.Get("User-Agent") == "" {
+		request.Header.Set("User-Agent", meta.UserAgent)
+	}
+	for name, value := range overrides.Headers {
+		name = http.CanonicalHeaderKey(name)
+		skipped = remove(skipped, name)
+		if value == "" {
+			request.Header.Del(name)
+			continue
+		}
+		request.Header.Set(name, value)
+	}
+	sort.Strings(skipped)
+	return request, skipped, nil
+}
+
+// Do sends the request an event's HTTP block describes and reads the
+// response.
+func Do(ctx context.Context, meta dump.HTTPMeta, overrides Overrides) (Response, error) {
+	timeout := DefaultTimeout
+	switch {
+	case overrides.TimeoutMs < 0 || time.Duration(overrides.TimeoutMs)*time.Millisecond > MaxTimeout:
+		return Response{}, ErrTimeoutRange
+	case overrides.TimeoutMs > 0:
+		timeout = time.Duration(overrides.TimeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, skipped, err := Request(ctx, meta, overrides)
+	if err != nil {
+		return Response{}, err
+	}
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	started := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		return Response{}, err
+	}
+	defer response.Body.Close()
+
+	var body bytes.Buffer
+	if _, err := io.Copy(&body, io.LimitReader(response.Body, MaxResponseBytes+1)); err != nil {
+		return Response{}, err
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	result := Response{
+		Method:     request.Method,
+		URL:        request.URL.String(),
+		StatusCode: response.StatusCode,
+		Status:     response.Status,
+		Headers:    response.Header,
+		DurationMs: float64(time.Since(started).Microseconds()) / 1000,
+		Skipped:    skipped,
+	}
+	if body.Len() > MaxResponseBytes {
+		body.Truncate(MaxResponseBytes)
+		result.Truncated = true
+	}
+	result.Body = body.String()
+	return result, nil
+}
+
+func first(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func remove(names []string, name string) []string {
+	kept := names[:0]
+	for _, candidate := range names {
+		if candidate != name {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept
+}
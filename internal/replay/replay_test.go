@@ -0,0 +1,81 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestDo_ReplaysCapturedRequestWithOverrides(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got, gotBody = r, string(body)
+		w.Header().Set("Location", "/done")
+		w.WriteHeader(http.StatusFound)
+		_, _ = w.Write([]byte("moved"))
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+
+	meta := dump.HTTPMeta{
+		Method:    "post",
+		Scheme:    "https",
+		Host:      "app.test",
+		Path:      "/orders",
+		Query:     "page=2",
+		UserAgent: "curl/8",
+		Headers: map[string]string{
+			"content-type":   "application/json",
+			"Authorization":  "Bearer [redacted]",
+			"Cookie":         "id=[redacted]",
+			"Content-Length": "9",
+		},
+		Body: `{"id":7}`,
+	}
+	query := "page=3"
+	response, err := Do(context.Background(), meta, Overrides{
+		Scheme:  "http",
+		Host:    target.Host,
+		Query:   &query,
+		Headers: map[string]string{"Authorization": "Bearer live", "X-Replay": "1"},
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if got.Method != http.MethodPost || got.URL.Path != "/orders" || got.URL.RawQuery != "page=3" || gotBody != `{"id":7}` {
+		t.Fatalf("server got %s %s body %q, want POST /orders?page=3 with the captured body", got.Method, got.URL, gotBody)
+	}
+	if got.Header.Get("Content-Type") != "application/json" || got.Header.Get("Authorization") != "Bearer live" ||
+		got.Header.Get("User-Agent") != "curl/8" || got.Header.Get("X-Replay") != "1" || got.Header.Get("Cookie") != "" {
+		t.Fatalf("server got headers %v, want captured and overridden headers without masked ones", got.Header)
+	}
+	if response.StatusCode != http.StatusFound || response.Body != "moved" || response.Headers["Location"][0] != "/done" {
+		t.Fatalf("Do() = %+v, want the redirect as received", response)
+	}
+	if !slices.Equal(response.Skipped, []string{"Cookie"}) {
+		t.Fatalf("Do().Skipped = %v, want [Cookie]", response.Skipped)
+	}
+	if response.DurationMs < 0 || response.URL != server.URL+"/orders?page=3" {
+		t.Fatalf("Do() url = %q, duration = %v", response.URL, response.DurationMs)
+	}
+}
+
+func TestDo_RejectsIncompleteRequests(t *testing.T) {
+	if _, err := Do(context.Background(), dump.HTTPMeta{Method: "GET"}, Overrides{}); !errors.Is(err, ErrMissingTarget) {
+		t.Fatalf("Do(no host) error = %v, want %v", err, ErrMissingTarget)
+	}
+	meta := dump.HTTPMeta{Method: "GET", Host: "app.test"}
+	if _, err := Do(context.Background(), meta, Overrides{TimeoutMs: -1}); !errors.Is(err, ErrTimeoutRange) {
+		t.Fatalf("Do(negative timeout) error = %v, want %v", err, ErrTimeoutRange)
+	}
+}
@@ -0,0 +1,101 @@
+package restapi
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"phant/internal/query"
+)
+
+// filterParams maps query parameters to the string fields of query.Filter,
+// named as in its JSON form.
+var filterParams = map[string]func(*query.Filter) *string{
+	"sourceType":     func(f *query.Filter) *string { return &f.SourceType },
+	"projectRoot":    func(f *query.Filter) *string { return &f.ProjectRoot },
+	"phpSapi":        func(f *query.Filter) *string { return &f.PHPSAPI },
+	"from":           func(f *query.Filter) *string { return &f.From },
+	"to":             func(f *query.Filter) *string { return &f.To },
+	"since":          func(f *query.Filter) *string { return &f.Since },
+	"duration":       func(f *query.Filter) *string { return &f.Duration },
+	"httpMethod":     func(f *query.Filter) *string { return &f.HTTPMethod },
+	"httpPathPrefix": func(f *query.Filter) *string { return &f.HTTPPathPrefix },
+	"commandName":    func(f *query.Filter) *string { return &f.CommandName },
+	"origin":         func(f *query.Filter) *string { return &f.Origin },
+	"ingestSource":   func(f *query.Filter) *string { return &f.IngestSource },
+	"userId":         func(f *query.Filter) *string { return &f.UserID },
+	"jobName":        func(f *query.Filter) *string { return &f.JobName },
+	"queue":          func(f *query.Filter) *string { return &f.Queue },
+	"schedule":       func(f *query.Filter) *string { return &f.Schedule },
+	"tag":            func(f *query.Filter) *string { return &f.Tag },
+	"timeBasis":      func(f *query.Filter) *string { return &f.TimeBasis },
+}
+
+var flagParams = map[string]func(*query.Filter) **bool{
+	"isDd":      func(f *query.Filter) **bool { return &f.IsDD },
+	"isError":   func(f *query.Filter) **bool { return &f.IsError },
+	"slowQuery": func(f *query.Filter) **bool { return &f.SlowQuery },
+	"pinned":    func(f *query.Filter) **bool { return &f.Pinned },
+}
+
+// parseEventsQuery reads a filter and page from query parameters. Unknown
+// and repeated parameters are rejected, so a typo never widens a query
+// silently.
+func parseEventsQuery(values url.Values) (query.Filter, query.Page, error) {
+	var filter query.Filter
+	var page query.Page
+	for name, list := range values {
+		if len(list) != 1 {
+			return query.Filter{}, query.Page{}, fmt.Errorf("parameter %q must be given once", name)
+		}
+		value := list[0]
+
+		if field, ok := filterParams[name]; ok {
+			*field(&filter) = value
+			continue
+		}
+		if field, ok := flagParams[name]; ok {
+			flag, err := strconv.ParseBool(value)
+			if err != nil {
+				return query.Filter{}, query.Page{}, fmt.Errorf("parameter %q must be true or false", name)
+			}
+			*field(&filter) = &flag
+			continue
+		}
+
+		var target *int
+		switch name {
+		case "offset":
+			target = &page.Offset
+		case "limit":
+			target = &page.Limit
+		default:
+			return query.Filter{}, query.Page{}, fmt.Errorf("unknown parameter %q", name)
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 0 {
+			return query.Filter{}, query.Page{}, fmt.Errorf("parameter %q must be a non-negative integer", name)
+		}
+		*target = number
+	}
+	return filter, page, nil
+}
+
+// parseStatsQuery reads the time range of /api/stats.
+func parseStatsQuery(values url.Values) (query.TimeRange, error) {
+	var timeRange query.TimeRange
+	for name, list := range values {
+		if len(list) != 1 {
+			return query.TimeRange{}, fmt.Errorf("parameter %q must be given once", name)
+		}
+		switch name {
+		case "from":
+			timeRange.From = list[0]
+		case "to":
+			timeRange.To = list[0]
+		default:
+			return query.TimeRange{}, fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	return timeRange, nil
+}
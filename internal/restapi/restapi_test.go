@@ -0,0 +1,225 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"phant/internal/deeplink"
+	"phant/internal/dump"
+	"phant/internal/netauth"
+	"phant/internal/query"
+)
+
+type fakeSource struct {
+	events []dump.Event
+	opened []deeplink.Link
+}
+
+func (f *fakeSource) Events(filter query.Filter, page query.Page) (query.Result, error) {
+	matcher, err := filter.Compile()
+	if err != nil {
+		return query.Result{}, err
+	}
+	var matches []dump.Event
+	for _, event := range f.events {
+		if matcher.Match(event) {
+			matches = append(matches, event)
+		}
+	}
+	return query.Paginate(matches, page), nil
+}
+
+func (f *fakeSource) Event(id string) (dump.Event, error) {
+	for _, event := range f.events {
+		if event.ID == id {
+			return event, nil
+		}
+	}
+	return dump.Event{}, ErrEventNotFound
+}
+
+func (f *fakeSource) RequestEvents(requestID string) []dump.Event {
+	var events []dump.Event
+	for _, event := range f.events {
+		if event.RequestID != nil && *event.RequestID == requestID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (f *fakeSource) Stats(timeRange query.TimeRange) (query.Stats, error) {
+	if _, err := timeRange.Compile(); err != nil {
+		return query.Stats{}, err
+	}
+	return query.Aggregate(f.events, timeRange), nil
+}
+
+func (f *fakeSource) Open(link deeplink.Link) {
+	f.opened = append(f.opened, link)
+}
+
+func apiEvent(id string, sourceType string, requestID string, isError bool) dump.Event {
+	return dump.Event{
+		ID:         id,
+		Timestamp:  "2026-02-28T11:20:31.331Z",
+		SourceType: sourceType,
+		RequestID:  &requestID,
+		IsError:    isError,
+		Payload:    json.RawMessage(`{"ok":true}`),
+	}
+}
+
+func newTestServer() *Server {
+	return NewServer("", &fakeSource{events: []dump.Event{
+		apiEvent("evt-1", "http", "req-1", false),
+		apiEvent("evt-2", "http", "req-1", true),
+		apiEvent("evt-3", "cli", "req-2", true),
+	}})
+}
+
+func get(t *testing.T, server *Server, target string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	request.Host = "127.0.0.1:9914"
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	var body map[string]any
+	_ = json.Unmarshal(recorder.Body.Bytes(), &body)
+	return recorder, body
+}
+
+func TestServer_QueriesEvents(t *testing.T) {
+	server := newTestServer()
+
+	recorder, body := get(t, server, "/api/events?sourceType=http&isError=true&limit=10")
+	if recorder.Code != http.StatusOK || body["total"] != float64(1) {
+		t.Fatalf("GET /api/events = %d %v, want the one failing http event", recorder.Code, body)
+	}
+	if events := body["events"].([]any); events[0].(map[string]any)["id"] != "evt-2" {
+		t.Fatalf("events = %v, want evt-2", events)
+	}
+
+	recorder, body = get(t, server, "/api/events/evt-3")
+	if recorder.Code != http.StatusOK || body["id"] != "evt-3" {
+		t.Fatalf("GET /api/events/evt-3 = %d %v, want the event", recorder.Code, body)
+	}
+
+	recorder, body = get(t, server, "/api/requests/req-1")
+	if recorder.Code != http.StatusOK || body["requestId"] != "req-1" || len(body["events"].([]any)) != 2 {
+		t.Fatalf("GET /api/requests/req-1 = %d %v, want both events of the request", recorder.Code, body)
+	}
+
+	recorder, body = get(t, server, "/api/requests/req-9")
+	if recorder.Code != http.StatusOK || len(body["events"].([]any)) != 0 {
+		t.Fatalf("GET /api/requests/req-9 = %d %v, want an empty list", recorder.Code, body)
+	}
+
+	recorder, body = get(t, server, "/api/stats")
+	if recorder.Code != http.StatusOK || body["total"] != float64(3) {
+		t.Fatalf("GET /api/stats = %d %v, want three events", recorder.Code, body)
+	}
+}
+
+func TestServer_RejectsBadQueries(t *testing.T) {
+	server := newTestServer()
+
+	for target, status := range map[string]int{
+		"/api/events?sourcetype=http": http.StatusBadRequest,
+		"/api/events?isError=maybe":   http.StatusBadRequest,
+		"/api/events?limit=-1":        http.StatusBadRequest,
+		"/api/events?tag=a&tag=b":     http.StatusBadRequest,
+		"/api/events?from=not-a-time": http.StatusBadRequest,
+		"/api/stats?since=5m":         http.StatusBadRequest,
+		"/api/events/evt-9":           http.StatusNotFound,
+		"/api/unknown":                http.StatusNotFound,
+	} {
+		recorder, body := get(t, server, target)
+		if recorder.Code != status {
+			t.Fatalf("GET %s = %d %v, want %d", target, recorder.Code, body, status)
+		}
+		if status == http.StatusBadRequest && body["error"] == "" {
+			t.Fatalf("GET %s body = %v, want an error message", target, body)
+		}
+	}
+	if stats := server.Stats(); stats.Rejected != 6 {
+		t.Fatalf("Stats().Rejected = %d, want 6", stats.Rejected)
+	}
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/events/evt-1", nil)
+	request.Host = "127.0.0.1:9914"
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE /api/events/evt-1 = %d, want %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServer_GuardsAccess(t *testing.T) {
+	server := newTestServer()
+
+	request := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+	request.Host = "attacker.example:9914"
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusMisdirectedRequest {
+		t.Fatalf("GET with Host attacker.example = %d, want %d", recorder.Code, http.StatusMisdirectedRequest)
+	}
+
+	for _, host := range []string{"localhost:9914", "[::1]:9914", "192.168.1.20"} {
+		request := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+		request.Host = host
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("GET with Host %s = %d, want %d", host, recorder.Code, http.StatusOK)
+		}
+	}
+
+	guard := netauth.NewGuard()
+	if err := guard.SetToken("0123456789abcdef"); err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+	server.SetAuth(guard)
+
+	recorder, _ = get(t, server, "/api/stats")
+	if recorder.Code != http.StatusUnauthorized || server.Stats().Unauthorized != 1 {
+		t.Fatalf("GET without a token = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+	request.Host = "127.0.0.1:9914"
+	request.Header.Set("Authorization", "Bearer 0123456789abcdef")
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET with the token = %d, want %d", recorder.Code, http.StatusOK)
+	}
+}
+
+func TestServer_OpensLinks(t *testing.T) {
+	source := &fakeSource{}
+	server := NewServer("", source)
+
+	link := deeplink.Link{Kind: deeplink.KindEvent, Target: "evt/1", Params: map[string]string{"tab": "payload"}}
+	target, err := link.HTTPURL("http://127.0.0.1:9914")
+	if err != nil {
+		t.Fatalf("HTTPURL() error = %v", err)
+	}
+	recorder, body := get(t, server, target)
+	if recorder.Code != http.StatusAccepted || body["target"] != "evt/1" {
+		t.Fatalf("GET %s = %d %v, want the link accepted", target, recorder.Code, body)
+	}
+	if len(source.opened) != 1 || source.opened[0].Target != "evt/1" || source.opened[0].Params["tab"] != "payload" {
+		t.Fatalf("opened = %+v, want the event link", source.opened)
+	}
+
+	recorder, _ = get(t, server, "/open/unknown/x")
+	if recorder.Code != http.StatusBadRequest || len(source.opened) != 1 {
+		t.Fatalf("GET /open/unknown/x = %d, opened %d, want it rejected", recorder.Code, len(source.opened))
+	}
+}
@@ -0,0 +1,261 @@
+// Package restapi serves a read-only HTTP API over the captured events, so
+// shell scripts and editor plugins can query them, and opens the HTTP form
+// of phant links in the viewer. It is separate from the ingestion listeners
+// and only runs when given an address.
+package restapi
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"phant/internal/deeplink"
+	"phant/internal/dump"
+	"phant/internal/netauth"
+	"phant/internal/query"
+)
+
+const DefaultAddress = "127.0.0.1:9914"
+
+// ErrEventNotFound is what Source.Event returns for an unknown ID.
+var ErrEventNotFound = errors.New("event not found")
+
+// Source answers the queries the API serves, with the same filter
+// semantics as the viewer.
+type Source interface {
+	Events(filter query.Filter, page query.Page) (query.Result, error)
+	// Event returns one event with its full payload.
+	Event(id string) (dump.Event, error)
+	RequestEvents(requestID string) []dump.Event
+	Stats(timeRange query.TimeRange) (query.Stats, error)
+	// Open shows the link in the viewer.
+	Open(link deeplink.Link)
+}
+
+type Stats struct {
+	Address  string `json:"address"`
+	Requests uint64 `json:"requests"`
+	// Rejected counts requests with a bad query or a Host header naming
+	// something other than an IP address or localhost.
+	Rejected     uint64 `json:"rejected"`
+	Unauthorized uint64 `json:"unauthorized"`
+}
+
+// RequestEvents is the body of /api/requests/{requestId}.
+type RequestEvents struct {
+	RequestID string       `json:"requestId"`
+	Events    []dump.Event `json:"events"`
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// Server answers GET requests under /api/ with JSON:
+//
+//	/api/events                 a page of events matching query.Filter
+//	                            parameters, plus offset and limit
+//	/api/events/{id}            one event with its full payload
+//	/api/requests/{requestId}   every event of a request, oldest first
+//	/api/stats                  the dashboard stats, optionally from/to
+//	/open/{kind}/{target}       opens a link from deeplink.Link.HTTPURL
+type Server struct {
+	address string
+	source  Source
+	auth    *netauth.Guard
+	tls     *tls.Config
+	mux     *http.ServeMux
+
+	requests     atomic.Uint64
+	rejected     atomic.Uint64
+	unauthorized atomic.Uint64
+
+	listener net.Listener
+	http     *http.Server
+	wg       sync.WaitGroup
+}
+
+func NewServer(address string, source Source) *Server {
+	if address == "" {
+		address = DefaultAddress
+	}
+
+	s := &Server{address: address, source: source, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /api/events", s.handleEvents)
+	s.mux.HandleFunc("GET /api/events/{id}", s.handleEvent)
+	s.mux.HandleFunc("GET /api/requests/{requestId}", s.handleRequest)
+	s.mux.HandleFunc("GET /api/stats", s.handleStats)
+	s.mux.HandleFunc("GET /open/", s.handleOpen)
+	return s
+}
+
+// SetAuth makes every request carry the guard's token as a bearer
+// credential. It must be called before Start.
+func (s *Server) SetAuth(guard *netauth.Guard) {
+	s.auth = guard
+}
+
+// SetTLS makes the listener serve TLS with config. It must be called
+// before Start.
+func (s *Server) SetTLS(config *tls.Config) {
+	s.tls = config
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	if s.tls != nil {
+		listener = tls.NewListener(listener, s.tls)
+	}
+
+	s.listener = listener
+	s.http = &http.Server{Handler: s, ReadHeaderTimeout: 5 * time.Second}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		_ = s.http.Serve(listener)
+	}()
+	return nil
+}
+
+func (s *Server) Stop() error {
+	if s.http == nil {
+		return nil
+	}
+	err := s.http.Close()
+	s.wg.Wait()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) Address() string {
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
+	return s.address
+}
+
+func (s *Server) Stats() Stats {
+	return Stats{
+		Address:      s.Address(),
+		Requests:     s.requests.Load(),
+		Rejected:     s.rejected.Load(),
+		Unauthorized: s.unauthorized.Load(),
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	// A web page that rebinds its own domain to this address would be
+	// able to read the responses; its requests still name that domain.
+	if !allowedHost(r.Host) {
+		s.rejected.Add(1)
+		writeError(w, http.StatusMisdirectedRequest, "host must be an IP address or localhost")
+		return
+	}
+	if !s.auth.AllowRequest(r) {
+		s.unauthorized.Add(1)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="phant"`)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	filter, page, err := parseEventsQuery(r.URL.Query())
+	if err != nil {
+		s.reject(w, err)
+		return
+	}
+	result, err := s.source.Events(filter, page)
+	if err != nil {
+		s.reject(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleEvent(w http.ResponseWriter, r *http.Request) {
+	event, err := s.source.Event(r.PathValue("id"))
+	if errors.Is(err, ErrEventNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, event)
+}
+
+func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
+	requestID := r.PathValue("requestId")
+	events := s.source.RequestEvents(requestID)
+	if events == nil {
+		events = []dump.Event{}
+	}
+	writeJSON(w, http.StatusOK, RequestEvents{RequestID: requestID, Events: events})
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	timeRange, err := parseStatsQuery(r.URL.Query())
+	if err != nil {
+		s.reject(w, err)
+		return
+	}
+	stats, err := s.source.Stats(timeRange)
+	if err != nil {
+		s.reject(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) handleOpen(w http.ResponseWriter, r *http.Request) {
+	target := *r.URL
+	target.Scheme, target.Host = "http", r.Host
+	link, err := deeplink.Parse(target.String())
+	if err != nil {
+		s.reject(w, err)
+		return
+	}
+	s.source.Open(link)
+	writeJSON(w, http.StatusAccepted, link)
+}
+
+// reject answers a query the source could not run, such as one with a
+// malformed time bound.
+func (s *Server) reject(w http.ResponseWriter, err error) {
+	s.rejected.Add(1)
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
+func allowedHost(host string) bool {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return strings.EqualFold(host, "localhost") || net.ParseIP(host) != nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorBody{Error: message})
+}
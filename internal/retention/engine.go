@@ -0,0 +1,149 @@
+package retention
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+type Store interface {
+	Events() []dump.Event
+	Remove(ids map[string]struct{}) int
+}
+
+// Engine periodically applies the current policy to a store and reports a
+// summary whenever something was pruned.
+type Engine struct {
+	store    Store
+	interval time.Duration
+	now      func() time.Time
+	onPrune  func(Summary)
+
+	mu       sync.RWMutex
+	policy   Policy
+	projects map[string]Policy
+	keep     func(dump.Event) bool
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewEngine(store Store, policy Policy, onPrune func(Summary)) *Engine {
+	return &Engine{
+		store:    store,
+		interval: DefaultInterval,
+		now:      time.Now,
+		onPrune:  onPrune,
+		policy:   policy,
+		stopped:  make(chan struct{}),
+	}
+}
+
+func (e *Engine) Start() {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.stopped:
+				return
+			case <-ticker.C:
+				e.Apply()
+			}
+		}
+	}()
+}
+
+func (e *Engine) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stopped)
+		e.wg.Wait()
+	})
+}
+
+func (e *Engine) Policy() Policy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.policy
+}
+
+// SetPolicy swaps the policy and applies it immediately.
+func (e *Engine) SetPolicy(policy Policy) (Summary, error) {
+	if err := policy.Validate(); err != nil {
+		return Summary{}, err
+	}
+
+	e.mu.Lock()
+	e.policy = policy
+	e.mu.Unlock()
+
+	return e.Apply(), nil
+}
+
+// SetProjectPolicies replaces the per-project policies, keyed by project
+// root, and applies them immediately.
+func (e *Engine) SetProjectPolicies(policies map[string]Policy) (Summary, error) {
+	projects := make(map[string]Policy, len(policies))
+	for root, policy := range policies {
+		if err := policy.Validate(); err != nil {
+			return Summary{}, fmt.Errorf("project %s: %w", root, err)
+		}
+		if !policy.IsZero() {
+			projects[root] = policy
+		}
+	}
+
+	e.mu.Lock()
+	e.projects = projects
+	e.mu.Unlock()
+
+	return e.Apply(), nil
+}
+
+// SetKeep exempts events for which keep returns true from pruning; they do
+// not count toward any limit either.
+func (e *Engine) SetKeep(keep func(dump.Event) bool) {
+	e.mu.Lock()
+	e.keep = keep
+	e.mu.Unlock()
+}
+
+func (e *Engine) Apply() Summary {
+	e.mu.RLock()
+	policy, projects, keep := e.policy, e.projects, e.keep
+	e.mu.RUnlock()
+
+	// Even without limits, events may carry their own TTL.
+	events := e.store.Events()
+	kept := 0
+	if keep != nil {
+		candidates := events[:0:0]
+		for _, event := range events {
+			if keep(event) {
+				kept++
+				continue
+			}
+			candidates = append(candidates, event)
+		}
+		events = candidates
+	}
+
+	ids, summary := EvaluateProjects(policy, projects, events, e.now())
+	summary.Remaining += kept
+	if len(ids) == 0 {
+		return summary
+	}
+
+	e.store.Remove(ids)
+	if e.onPrune != nil {
+		e.onPrune(summary)
+	}
+	return summary
+}
@@ -0,0 +1,134 @@
+package retention
+
+import (
+	"errors"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const DefaultInterval = 30 * time.Second
+
+// Policy bounds how much dump history is kept. Zero values disable a limit.
+type Policy struct {
+	MaxEvents       int   `json:"maxEvents"`
+	MaxAgeSeconds   int64 `json:"maxAgeSeconds"`
+	MaxPayloadBytes int64 `json:"maxPayloadBytes"`
+}
+
+type Summary struct {
+	PrunedAt     string `json:"prunedAt"`
+	Removed      int    `json:"removed"`
+	RemovedBytes int64  `json:"removedBytes"`
+	ByAge        int    `json:"byAge"`
+	ByTTL        int    `json:"byTtl"`
+	ByCount      int    `json:"byCount"`
+	ByBytes      int    `json:"byBytes"`
+	Remaining    int    `json:"remaining"`
+}
+
+func (p Policy) Validate() error {
+	if p.MaxEvents < 0 || p.MaxAgeSeconds < 0 || p.MaxPayloadBytes < 0 {
+		return errors.New("retention limits must not be negative")
+	}
+	return nil
+}
+
+func (p Policy) IsZero() bool {
+	return p.MaxEvents == 0 && p.MaxAgeSeconds == 0 && p.MaxPayloadBytes == 0
+}
+
+// Evaluate decides which events to prune. Events are expected oldest first;
+// age is applied first, then count, then total payload bytes, each dropping
+// the oldest survivors. An event's own TTL hint replaces the age limit for
+// it and applies even when the policy sets none.
+func Evaluate(policy Policy, events []dump.Event, now time.Time) (map[string]struct{}, Summary) {
+	summary := Summary{PrunedAt: now.UTC().Format(time.RFC3339)}
+	pruned := make(map[string]struct{})
+
+	prune := func(event dump.Event, counter *int) {
+		pruned[event.ID] = struct{}{}
+		summary.RemovedBytes += int64(len(event.Payload))
+		*counter++
+	}
+
+	survivors := make([]dump.Event, 0, len(events))
+	var totalBytes int64
+	for _, event := range events {
+		if event.TTLSeconds > 0 {
+			if isExpired(event, now, time.Duration(event.TTLSeconds)*time.Second) {
+				prune(event, &summary.ByTTL)
+				continue
+			}
+		} else if policy.MaxAgeSeconds > 0 && isExpired(event, now, time.Duration(policy.MaxAgeSeconds)*time.Second) {
+			prune(event, &summary.ByAge)
+			continue
+		}
+		survivors = append(survivors, event)
+		totalBytes += int64(len(event.Payload))
+	}
+
+	if policy.MaxEvents > 0 {
+		for len(survivors) > policy.MaxEvents {
+			totalBytes -= int64(len(survivors[0].Payload))
+			prune(survivors[0], &summary.ByCount)
+			survivors = survivors[1:]
+		}
+	}
+
+	if policy.MaxPayloadBytes > 0 {
+		for len(survivors) > 0 && totalBytes > policy.MaxPayloadBytes {
+			totalBytes -= int64(len(survivors[0].Payload))
+			prune(survivors[0], &summary.ByBytes)
+			survivors = survivors[1:]
+		}
+	}
+
+	summary.Removed = len(pruned)
+	summary.Remaining = len(survivors)
+	return pruned, summary
+}
+
+// EvaluateProjects applies the global policy to all events, then each
+// project's policy to that project's survivors, so a project policy can
+// only tighten what the global one keeps.
+func EvaluateProjects(global Policy, projects map[string]Policy, events []dump.Event, now time.Time) (map[string]struct{}, Summary) {
+	pruned, summary := Evaluate(global, events, now)
+	if len(projects) == 0 {
+		return pruned, summary
+	}
+
+	scoped := make(map[string][]dump.Event, len(projects))
+	for _, event := range events {
+		if _, gone := pruned[event.ID]; gone {
+			continue
+		}
+		if _, ok := projects[event.ProjectRoot]; ok {
+			scoped[event.ProjectRoot] = append(scoped[event.ProjectRoot], event)
+		}
+	}
+
+	for root, projectEvents := range scoped {
+		ids, projectSummary := Evaluate(projects[root], projectEvents, now)
+		for id := range ids {
+			pruned[id] = struct{}{}
+		}
+		summary.RemovedBytes += projectSummary.RemovedBytes
+		summary.ByAge += projectSummary.ByAge
+		summary.ByTTL += projectSummary.ByTTL
+		summary.ByCount += projectSummary.ByCount
+		summary.ByBytes += projectSummary.ByBytes
+	}
+
+	summary.Removed = len(pruned)
+	summary.Remaining = len(events) - len(pruned)
+	return pruned, summary
+}
+
+func isExpired(event dump.Event, now time.Time, maxAge time.Duration) bool {
+	timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+	if err != nil {
+		return false
+	}
+	return now.Sub(timestamp) > maxAge
+}
@@ -0,0 +1,171 @@
+package retention
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func testEvent(id string, timestamp string, payload string) dump.Event {
+	return dump.Event{ID: id, Timestamp: timestamp, Payload: json.RawMessage(payload)}
+}
+
+func TestEvaluate_AppliesAgeCountAndBytesLimits(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	events := []dump.Event{
+		testEvent("old", "2026-03-02T10:00:00Z", `"aaaa"`),
+		testEvent("a", "2026-03-02T11:59:00Z", `"aaaa"`),
+		testEvent("b", "2026-03-02T11:59:10Z", `"aaaa"`),
+		testEvent("c", "2026-03-02T11:59:20Z", `"aaaa"`),
+		testEvent("d", "2026-03-02T11:59:30Z", `"aaaa"`),
+	}
+
+	ids, summary := Evaluate(Policy{MaxAgeSeconds: 3600, MaxEvents: 3, MaxPayloadBytes: 12}, events, now)
+
+	for _, id := range []string{"old", "a", "b"} {
+		if _, ok := ids[id]; !ok {
+			t.Fatalf("Evaluate() pruned %v, want %q included", ids, id)
+		}
+	}
+	if summary.ByAge != 1 || summary.ByCount != 1 || summary.ByBytes != 1 {
+		t.Fatalf("Evaluate() summary = %+v, want one prune per limit", summary)
+	}
+	if summary.Removed != 3 || summary.Remaining != 2 || summary.RemovedBytes != 18 {
+		t.Fatalf("Evaluate() summary = %+v, want removed=3 remaining=2 bytes=18", summary)
+	}
+}
+
+func TestEvaluate_ZeroPolicyKeepsEverything(t *testing.T) {
+	events := []dump.Event{testEvent("a", "2000-01-01T00:00:00Z", `1`)}
+	ids, summary := Evaluate(Policy{}, events, time.Now())
+	if len(ids) != 0 || summary.Remaining != 1 {
+		t.Fatalf("Evaluate(zero policy) = %v %+v, want nothing pruned", ids, summary)
+	}
+}
+
+func TestEvaluate_HonorsTTLHints(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	heartbeat := testEvent("heartbeat", "2026-03-02T11:59:00Z", `1`)
+	heartbeat.TTLSeconds = 30
+	fresh := testEvent("fresh", "2026-03-02T11:59:50Z", `1`)
+	fresh.TTLSeconds = 30
+	important := testEvent("important", "2026-03-02T09:00:00Z", `1`)
+	important.TTLSeconds = 86400
+	events := []dump.Event{important, testEvent("old", "2026-03-02T10:00:00Z", `1`), heartbeat, fresh}
+
+	ids, summary := Evaluate(Policy{}, events, now)
+	if _, ok := ids["heartbeat"]; !ok || len(ids) != 1 || summary.ByTTL != 1 {
+		t.Fatalf("Evaluate(zero policy) = %v %+v, want only the expired heartbeat pruned", ids, summary)
+	}
+
+	ids, summary = Evaluate(Policy{MaxAgeSeconds: 3600}, events, now)
+	if _, ok := ids["important"]; ok {
+		t.Fatalf("Evaluate() pruned %v, want the long TTL to outlive the age limit", ids)
+	}
+	if summary.ByAge != 1 || summary.ByTTL != 1 || summary.Remaining != 2 {
+		t.Fatalf("Evaluate() summary = %+v, want old by age and heartbeat by ttl", summary)
+	}
+}
+
+func TestEvaluateProjects_TightensOnlyTheProjectsEvents(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	event := func(id string, root string) dump.Event {
+		event := testEvent(id, "2026-03-02T11:59:00Z", `1`)
+		event.ProjectRoot = root
+		return event
+	}
+	events := []dump.Event{event("a1", "/srv/a"), event("b1", "/srv/b"), event("a2", "/srv/a"), event("b2", "/srv/b"), event("a3", "/srv/a")}
+
+	ids, summary := EvaluateProjects(Policy{MaxEvents: 4}, map[string]Policy{"/srv/b": {MaxEvents: 1}}, events, now)
+
+	if len(ids) != 2 {
+		t.Fatalf("EvaluateProjects() pruned %v, want a1 and b1", ids)
+	}
+	for _, id := range []string{"a1", "b1"} {
+		if _, ok := ids[id]; !ok {
+			t.Fatalf("EvaluateProjects() pruned %v, want %q included", ids, id)
+		}
+	}
+	if summary.ByCount != 2 || summary.Remaining != 3 {
+		t.Fatalf("EvaluateProjects() summary = %+v, want two count prunes and three remaining", summary)
+	}
+}
+
+type memoryStore struct {
+	events []dump.Event
+}
+
+func (s *memoryStore) Events() []dump.Event {
+	return s.events
+}
+
+func (s *memoryStore) Remove(ids map[string]struct{}) int {
+	kept := s.events[:0]
+	for _, event := range s.events {
+		if _, ok := ids[event.ID]; !ok {
+			kept = append(kept, event)
+		}
+	}
+	removed := len(s.events) - len(kept)
+	s.events = kept
+	return removed
+}
+
+func TestEngine_SetPolicyPrunesAndReports(t *testing.T) {
+	store := &memoryStore{events: []dump.Event{
+		testEvent("a", "2026-03-02T11:00:00Z", `1`),
+		testEvent("b", "2026-03-02T11:00:01Z", `1`),
+	}}
+
+	var reported []Summary
+	engine := NewEngine(store, Policy{}, func(summary Summary) {
+		reported = append(reported, summary)
+	})
+
+	if _, err := engine.SetPolicy(Policy{MaxEvents: -1}); err == nil {
+		t.Fatalf("engine.SetPolicy(negative) error = nil, want error")
+	}
+
+	summary, err := engine.SetPolicy(Policy{MaxEvents: 1})
+	if err != nil {
+		t.Fatalf("engine.SetPolicy() error = %v", err)
+	}
+	if summary.Removed != 1 || len(store.events) != 1 || store.events[0].ID != "b" {
+		t.Fatalf("engine.SetPolicy() summary = %+v events = %v, want oldest pruned", summary, store.events)
+	}
+	if len(reported) != 1 {
+		t.Fatalf("onPrune calls = %d, want 1", len(reported))
+	}
+}
+
+func TestEngine_AppliesTTLWithoutPolicy(t *testing.T) {
+	heartbeat := testEvent("heartbeat", "2026-03-02T11:00:00Z", `1`)
+	heartbeat.TTLSeconds = 10
+	store := &memoryStore{events: []dump.Event{heartbeat, testEvent("a", "2026-03-02T11:00:01Z", `1`)}}
+	engine := NewEngine(store, Policy{}, nil)
+	engine.now = func() time.Time { return time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC) }
+
+	if summary := engine.Apply(); summary.ByTTL != 1 || len(store.events) != 1 || store.events[0].ID != "a" {
+		t.Fatalf("engine.Apply() summary = %+v events = %v, want heartbeat expired", summary, store.events)
+	}
+}
+
+func TestEngine_SetKeepExemptsEventsFromPruning(t *testing.T) {
+	store := &memoryStore{events: []dump.Event{
+		testEvent("pinned", "2026-03-02T11:00:00Z", `1`),
+		testEvent("a", "2026-03-02T11:00:01Z", `1`),
+		testEvent("b", "2026-03-02T11:00:02Z", `1`),
+	}}
+	engine := NewEngine(store, Policy{}, nil)
+	engine.SetKeep(func(event dump.Event) bool { return event.ID == "pinned" })
+
+	summary, err := engine.SetPolicy(Policy{MaxEvents: 1})
+	if err != nil {
+		t.Fatalf("engine.SetPolicy() error = %v", err)
+	}
+	if summary.Removed != 1 || summary.Remaining != 2 || len(store.events) != 2 || store.events[0].ID != "pinned" {
+		t.Fatalf("engine.SetPolicy() summary = %+v events = %v, want a pruned and pinned kept", summary, store.events)
+	}
+}
@@ -0,0 +1,261 @@
+// Package savedfilter keeps named filter presets, an event filter plus a
+// search query, and reads and writes the document teams use to share them.
+package savedfilter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"phant/internal/query"
+)
+
+const (
+	FileName = "filters.json"
+
+	// DocumentVersion is the version of the export document.
+	DocumentVersion = 1
+
+	MaxNameLength   = 100
+	MaxSearchLength = 1000
+)
+
+var (
+	ErrEmptyName                  = errors.New("filter name must not be empty")
+	ErrUnsupportedDocumentVersion = errors.New("unsupported saved filter document version")
+)
+
+// Preset is a named filter. Search is a full-text query applied on top of
+// Filter; empty matches every event the filter selects.
+type Preset struct {
+	Name      string       `json:"name"`
+	Filter    query.Filter `json:"filter"`
+	Search    string       `json:"search,omitempty"`
+	UpdatedAt string       `json:"updatedAt"`
+}
+
+func (p Preset) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return ErrEmptyName
+	}
+	if len(p.Name) > MaxNameLength {
+		return fmt.Errorf("filter name must be at most %d characters", MaxNameLength)
+	}
+	if len(p.Search) > MaxSearchLength {
+		return fmt.Errorf("filter search must be at most %d characters", MaxSearchLength)
+	}
+	if _, err := p.Filter.Compile(); err != nil {
+		return fmt.Errorf("filter %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// Document is the portable file written by Export and read by Import.
+type Document struct {
+	Version    int      `json:"version"`
+	ExportedAt string   `json:"exportedAt"`
+	Filters    []Preset `json:"filters"`
+}
+
+// Store holds every preset in memory and rewrites its file after each
+// change. Names are unique; saving an existing name replaces it.
+type Store struct {
+	path string
+	now  func() time.Time
+
+	mu     sync.RWMutex
+	byName map[string]Preset
+}
+
+// Open loads the presets stored in dir, if any.
+func Open(dir string) (*Store, error) {
+	store := &Store{path: filepath.Join(dir, FileName), now: time.Now, byName: map[string]Preset{}}
+
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var presets []Preset
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("%s: %w", store.path, err)
+	}
+	for _, preset := range presets {
+		store.byName[preset.Name] = preset
+	}
+	return store, nil
+}
+
+func (s *Store) Get(name string) (Preset, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	preset, ok := s.byName[name]
+	return preset, ok
+}
+
+// List returns presets sorted by name, case-insensitively.
+func (s *Store) List() []Preset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedLocked(nil)
+}
+
+func (s *Store) Save(preset Preset) (Preset, error) {
+	saved, err := s.Merge([]Preset{preset})
+	if err != nil {
+		return Preset{}, err
+	}
+	return saved[0], nil
+}
+
+// Merge saves several presets at once, replacing those with the same name.
+// Nothing is saved if any preset is invalid.
+func (s *Store) Merge(presets []Preset) ([]Preset, error) {
+	updatedAt := s.now().UTC().Format(time.RFC3339Nano)
+	saved := make([]Preset, len(presets))
+	for i, preset := range presets {
+		preset.Name = strings.TrimSpace(preset.Name)
+		preset.Search = strings.TrimSpace(preset.Search)
+		if err := preset.Validate(); err != nil {
+			return nil, err
+		}
+		preset.UpdatedAt = updatedAt
+		saved[i] = preset
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := make(map[string]Preset, len(s.byName))
+	for name, preset := range s.byName {
+		previous[name] = preset
+	}
+	for _, preset := range saved {
+		s.byName[preset.Name] = preset
+	}
+	if err := s.saveLocked(); err != nil {
+		s.byName = previous
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	preset, ok := s.byName[name]
+	if !ok {
+		return nil
+	}
+	delete(s.byName, name)
+	if err := s.saveLocked(); err != nil {
+		s.byName[name] = preset
+		return err
+	}
+	return nil
+}
+
+// Document collects the named presets, or every preset when names is
+// empty, for sharing. Unknown names are skipped.
+func (s *Store) Document(names []string) Document {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var only map[string]bool
+	if len(names) > 0 {
+		only = make(map[string]bool, len(names))
+		for _, name := range names {
+			only[name] = true
+		}
+	}
+	return Document{
+		Version:    DocumentVersion,
+		ExportedAt: s.now().UTC().Format(time.RFC3339),
+		Filters:    s.sortedLocked(only),
+	}
+}
+
+// Export writes the named presets, or every preset, to path.
+func (s *Store) Export(path string, names []string) (Document, error) {
+	document := s.Document(names)
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return Document{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return Document{}, err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return Document{}, err
+	}
+	return document, nil
+}
+
+// Import merges the presets of a shared document, replacing presets with
+// the same name, and returns what was saved.
+func (s *Store) Import(path string) ([]Preset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document Document
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("decode saved filter document: %w", err)
+	}
+	if document.Version < 1 || document.Version > DocumentVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedDocumentVersion, document.Version)
+	}
+	return s.Merge(document.Filters)
+}
+
+func (s *Store) sortedLocked(only map[string]bool) []Preset {
+	presets := make([]Preset, 0, len(s.byName))
+	for name, preset := range s.byName {
+		if only == nil || only[name] {
+			presets = append(presets, preset)
+		}
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		a, b := strings.ToLower(presets[i].Name), strings.ToLower(presets[j].Name)
+		if a != b {
+			return a < b
+		}
+		return presets[i].Name < presets[j].Name
+	})
+	return presets
+}
+
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.sortedLocked(nil), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), FileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
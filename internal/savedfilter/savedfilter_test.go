@@ -0,0 +1,90 @@
+package savedfilter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"phant/internal/query"
+)
+
+func TestStore_PersistsAndReplacesByName(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if _, err := store.Save(Preset{Name: " stripe workers ", Filter: query.Filter{SourceType: "worker", ProjectRoot: "/app"}, Search: "stripe"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	store.Save(Preset{Name: "Errors", Filter: query.Filter{SourceType: "http"}})
+	store.Save(Preset{Name: "Errors", Filter: query.Filter{SourceType: "cli"}})
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open(again) error = %v", err)
+	}
+	presets := reopened.List()
+	if len(presets) != 2 || presets[0].Name != "Errors" || presets[1].Name != "stripe workers" {
+		t.Fatalf("List() = %+v, want Errors and stripe workers", presets)
+	}
+	if presets[0].Filter.SourceType != "cli" || presets[1].Search != "stripe" {
+		t.Fatalf("List() = %+v, want replaced filter and kept search", presets)
+	}
+
+	if err := reopened.Delete("Errors"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := reopened.Get("Errors"); ok {
+		t.Fatalf("Get(Errors) found a deleted preset")
+	}
+}
+
+func TestStore_RejectsInvalidPresets(t *testing.T) {
+	store, _ := Open(t.TempDir())
+
+	if _, err := store.Save(Preset{Name: "  "}); !errors.Is(err, ErrEmptyName) {
+		t.Fatalf("Save(blank name) error = %v, want %v", err, ErrEmptyName)
+	}
+	if _, err := store.Save(Preset{Name: "bad", Filter: query.Filter{From: "last tuesday"}}); err == nil {
+		t.Fatalf("Save(bad from) error = nil, want error")
+	}
+	if len(store.List()) != 0 {
+		t.Fatalf("List() = %+v, want nothing saved", store.List())
+	}
+}
+
+func TestStore_ExportsAndImportsSharedPresets(t *testing.T) {
+	source, _ := Open(t.TempDir())
+	source.Save(Preset{Name: "stripe workers", Filter: query.Filter{SourceType: "worker"}, Search: "stripe"})
+	source.Save(Preset{Name: "local only"})
+
+	path := filepath.Join(t.TempDir(), "team", "filters.json")
+	document, err := source.Export(path, []string{"stripe workers", "missing"})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if document.Version != DocumentVersion || len(document.Filters) != 1 {
+		t.Fatalf("Export() = %+v, want one preset", document)
+	}
+
+	target, _ := Open(t.TempDir())
+	target.Save(Preset{Name: "stripe workers", Filter: query.Filter{SourceType: "cli"}})
+	imported, err := target.Import(path)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(imported) != 1 {
+		t.Fatalf("Import() = %+v, want one preset", imported)
+	}
+	if got, _ := target.Get("stripe workers"); got.Filter.SourceType != "worker" || got.Search != "stripe" {
+		t.Fatalf("Get(stripe workers) = %+v, want imported preset", got)
+	}
+
+	os.WriteFile(path, []byte(`{"version":9,"filters":[]}`), 0o644)
+	if _, err := target.Import(path); !errors.Is(err, ErrUnsupportedDocumentVersion) {
+		t.Fatalf("Import(v9) error = %v, want %v", err, ErrUnsupportedDocumentVersion)
+	}
+}
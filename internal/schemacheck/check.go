@@ -0,0 +1,223 @@
+package schemacheck
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"phant/internal/dump"
+)
+
+const (
+	// MaxSamples caps how many breaking lines are reported individually.
+	MaxSamples = 100
+
+	maxLineBytes = dump.DefaultMaxLineBytes
+)
+
+// Report summarizes a dry run of a corpus against a schema version.
+type Report struct {
+	Version int      `json:"version"`
+	Files   []string `json:"files"`
+	Lines   int      `json:"lines"`
+	// Passing lines are valid both as declared and under Version; Breaking
+	// lines are valid as declared but not under Version. AlreadyInvalid
+	// lines fail either way and Fixed ones only pass under Version.
+	Passing        int          `json:"passing"`
+	Breaking       int          `json:"breaking"`
+	AlreadyInvalid int          `json:"alreadyInvalid"`
+	Fixed          int          `json:"fixed"`
+	Issues         []IssueCount `json:"issues"`
+	Samples        []Breakage   `json:"samples"`
+}
+
+// IssueCount groups the errors behind breaking lines.
+type IssueCount struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+type Breakage struct {
+	File    string                 `json:"file"`
+	Line    int                    `json:"line"`
+	EventID string                 `json:"eventId,omitempty"`
+	Issues  []dump.ValidationIssue `json:"issues"`
+}
+
+// ValidateCorpusAgainst re-validates every event in path as if it had been
+// sent with the given schemaVersion. path is an NDJSON file of producer
+// lines or session log, or a store directory, whose session logs are read
+// in order. Stored events are checked through their retained raw line when
+// they have one, else as stored at their original version.
+func ValidateCorpusAgainst(version int, path string) (Report, error) {
+	if version < dump.MinSchemaVersion || version > dump.SchemaVersion {
+		return Report{}, fmt.Errorf("%w: %d (this build knows %d to %d)", dump.ErrUnsupportedSchemaVersion, version, dump.MinSchemaVersion, dump.SchemaVersion)
+	}
+
+	files, err := corpusFiles(path)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Version: version, Files: files, Issues: []IssueCount{}, Samples: []Breakage{}}
+	counts := map[IssueCount]int{}
+	for _, file := range files {
+		if err := checkFile(file, version, &report, counts); err != nil {
+			return Report{}, err
+		}
+	}
+
+	for issue, count := range counts {
+		issue.Count = count
+		report.Issues = append(report.Issues, issue)
+	}
+	sort.Slice(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return a.Message < b.Message
+	})
+	return report, nil
+}
+
+func corpusFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.ndjson"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .ndjson files in %s", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func checkFile(path string, version int, report *Report, counts map[IssueCount]int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	number := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if errors.Is(err, io.EOF) && line == "" {
+			return nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		number++
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(line) > maxLineBytes {
+			return fmt.Errorf("%s:%d: line exceeds %d bytes", path, number, maxLineBytes)
+		}
+		if err := checkLine(path, number, line, version, report, counts); err != nil {
+			return err
+		}
+	}
+}
+
+func checkLine(path string, number int, line string, version int, report *Report, counts map[IssueCount]int) error {
+	line, declared := producerLine(line)
+
+	var current []dump.ValidationIssue
+	if declared > 0 {
+		issues, err := dump.ValidateAs(line, declared)
+		if err != nil {
+			issues = []dump.ValidationIssue{{Field: "schemaVersion", Message: err.Error(), Severity: dump.SeverityError}}
+		}
+		current = issues
+	} else {
+		_, current = dump.DecodeNDJSONLineLenient(line)
+	}
+
+	proposed, err := dump.ValidateAs(line, version)
+	if err != nil {
+		return err
+	}
+
+	report.Lines++
+	validNow, validThen := !hasError(current), !hasError(proposed)
+	switch {
+	case validNow && validThen:
+		report.Passing++
+	case validNow:
+		report.Breaking++
+		errs := errorsOnly(proposed)
+		for _, issue := range errs {
+			counts[IssueCount{Field: issue.Field, Message: issue.Message}]++
+		}
+		if len(report.Samples) < MaxSamples {
+			report.Samples = append(report.Samples, Breakage{File: path, Line: number, EventID: eventID(line), Issues: errs})
+		}
+	case validThen:
+		report.Fixed++
+	default:
+		report.AlreadyInvalid++
+	}
+	return nil
+}
+
+// producerLine picks what to validate from a corpus line. Session logs
+// store consumer events: their retained raw line is the producer's own
+// bytes; without one, the stored event is checked at the version it was
+// originally sent with. Producer lines report declared as 0.
+func producerLine(line string) (string, int) {
+	var stored struct {
+		OriginalSchemaVersion int             `json:"originalSchemaVersion"`
+		Raw                   json.RawMessage `json:"raw"`
+	}
+	if err := json.Unmarshal([]byte(line), &stored); err != nil {
+		return line, 0
+	}
+	if len(stored.Raw) > 0 {
+		return string(stored.Raw), 0
+	}
+	return line, stored.OriginalSchemaVersion
+}
+
+func eventID(line string) string {
+	var event struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal([]byte(line), &event)
+	return event.ID
+}
+
+func hasError(issues []dump.ValidationIssue) bool {
+	return len(errorsOnly(issues)) > 0
+}
+
+func errorsOnly(issues []dump.ValidationIssue) []dump.ValidationIssue {
+	var errs []dump.ValidationIssue
+	for _, issue := range issues {
+		if issue.Severity == dump.SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,68 @@
+package schemacheck
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+const v1Line = `{"schemaVersion":1,"id":"evt-1","timestamp":"2026-03-02T12:00:00Z","sourceType":"cli","projectRoot":"/app","phpSapi":"cli","requestId":null,"command":{"name":"artisan"},"isDd":false,"payloadFormat":"json","payload":{"ok":true},"trace":[],"host":{"hostname":"h","pid":1}}`
+
+func writeCorpus(t *testing.T, dir string, name string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestValidateCorpusAgainst_ReportsWhatBreaks(t *testing.T) {
+	badColor := strings.Replace(v1Line, `"id":"evt-1"`, `"id":"evt-2","color":"magenta"`, 1)
+	noHost := strings.Replace(v1Line, `"host":{"hostname":"h","pid":1}`, `"host":{}`, 1)
+	logEvent := strings.Replace(strings.Replace(v1Line, `"sourceType":"cli"`, `"sourceType":"log"`, 1), `"isDd":false`, `"isDd":false,"log":{"channel":"app","message":"x"}`, 1)
+	path := writeCorpus(t, t.TempDir(), "producer.ndjson", v1Line, badColor, "", noHost, logEvent)
+
+	report, err := ValidateCorpusAgainst(2, path)
+	if err != nil {
+		t.Fatalf("ValidateCorpusAgainst() error = %v", err)
+	}
+	if report.Lines != 4 || report.Passing != 1 || report.Breaking != 1 || report.AlreadyInvalid != 1 || report.Fixed != 1 {
+		t.Fatalf("ValidateCorpusAgainst() = %+v, want one line in each outcome", report)
+	}
+	if len(report.Samples) != 1 || report.Samples[0].Line != 2 || report.Samples[0].EventID != "evt-2" {
+		t.Fatalf("ValidateCorpusAgainst() samples = %+v, want line 2 (evt-2)", report.Samples)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Field != "color" || report.Issues[0].Count != 1 {
+		t.Fatalf("ValidateCorpusAgainst() issues = %+v, want one color issue", report.Issues)
+	}
+}
+
+func TestValidateCorpusAgainst_ReadsStoreDirectories(t *testing.T) {
+	dir := t.TempDir()
+	stored, _ := json.Marshal(dump.Event{
+		SchemaVersion: 2, ID: "evt-9", Timestamp: "2026-03-02T12:00:00Z", SourceType: "cli", ProjectRoot: "/app", PHPSAPI: "cli",
+		PayloadFormat: "json", Payload: json.RawMessage(`1`), Trace: []dump.TraceFrame{}, Host: dump.HostMeta{Hostname: "h", PID: 1},
+		OriginalSchemaVersion: 1,
+		Raw:                   json.RawMessage(strings.Replace(v1Line, `"id":"evt-1"`, `"id":"evt-9","color":"magenta"`, 1)),
+	})
+	writeCorpus(t, dir, "20260302T120000.000000000Z.ndjson", v1Line)
+	writeCorpus(t, dir, "20260302T130000.000000000Z.ndjson", string(stored))
+
+	report, err := ValidateCorpusAgainst(2, dir)
+	if err != nil {
+		t.Fatalf("ValidateCorpusAgainst(dir) error = %v", err)
+	}
+	if len(report.Files) != 2 || report.Lines != 2 || report.Breaking != 1 {
+		t.Fatalf("ValidateCorpusAgainst(dir) = %+v, want the stored raw line to break", report)
+	}
+
+	if _, err := ValidateCorpusAgainst(dump.SchemaVersion+1, dir); !errors.Is(err, dump.ErrUnsupportedSchemaVersion) {
+		t.Fatalf("ValidateCorpusAgainst(unknown version) error = %v, want ErrUnsupportedSchemaVersion", err)
+	}
+}
@@ -0,0 +1,206 @@
+package search
+
+import (
+	"encoding/json"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"phant/internal/dump"
+)
+
+const (
+	minTokenLength = 2
+	maxTokenLength = 64
+	// maxDocumentTokens bounds the work and memory spent on huge payloads.
+	maxDocumentTokens = 4096
+)
+
+// Index is an in-memory inverted index from lowercase tokens to event IDs.
+type Index struct {
+	mu       sync.RWMutex
+	postings map[string]map[string]struct{}
+	docs     map[string][]string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[string]struct{}),
+		docs:     make(map[string][]string),
+	}
+}
+
+func (i *Index) Add(event dump.Event) {
+	tokens := Tokens(event)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.removeLocked(event.ID)
+	i.docs[event.ID] = tokens
+	for _, token := range tokens {
+		ids, ok := i.postings[token]
+		if !ok {
+			ids = make(map[string]struct{})
+			i.postings[token] = ids
+		}
+		ids[event.ID] = struct{}{}
+	}
+}
+
+func (i *Index) Remove(ids []string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, id := range ids {
+		i.removeLocked(id)
+	}
+}
+
+func (i *Index) removeLocked(id string) {
+	for _, token := range i.docs[id] {
+		ids := i.postings[token]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(i.postings, token)
+		}
+	}
+	delete(i.docs, id)
+}
+
+func (i *Index) Has(id string) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	_, ok := i.docs[id]
+	return ok
+}
+
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return len(i.docs)
+}
+
+// Search returns the IDs of events containing every token of query.
+func (i *Index) Search(query string) map[string]struct{} {
+	terms := tokenize(query, nil, map[string]bool{})
+	matches := map[string]struct{}{}
+	if len(terms) == 0 {
+		return matches
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for id := range i.postings[terms[0]] {
+		matches[id] = struct{}{}
+	}
+	for _, term := range terms[1:] {
+		ids := i.postings[term]
+		for id := range matches {
+			if _, ok := ids[id]; !ok {
+				delete(matches, id)
+			}
+		}
+	}
+	return matches
+}
+
+// Tokens extracts the searchable words of an event: payload keys and values,
+// label, request, command, and source type context, and trace file names.
+func Tokens(event dump.Event) []string {
+	seen := map[string]bool{}
+	var tokens []string
+
+	add := func(text string) {
+		tokens = tokenize(text, tokens, seen)
+	}
+
+	add(event.ID)
+	add(event.Label)
+	add(event.Expression)
+	if event.Test != nil {
+		add(event.Test.Class + " " + event.Test.Name + " " + event.Test.Dataset)
+	}
+	if event.User != nil {
+		add(event.User.ID + " " + event.User.Name)
+	}
+	if event.Job != nil {
+		add(event.Job.Name + " " + event.Job.Queue)
+	}
+	if event.Schedule != nil {
+		add(event.Schedule.Description)
+	}
+	add(event.SourceType)
+	if event.RequestID != nil {
+		add(*event.RequestID)
+	}
+	if event.HTTP != nil {
+		add(event.HTTP.Method + " " + event.HTTP.Host + " " + event.HTTP.Path)
+	}
+	if event.Command != nil {
+		add(event.Command.Name + " " + strings.Join(event.Command.Args, " "))
+	}
+	for _, key := range slices.Sorted(maps.Keys(event.Meta)) {
+		var meta any
+		if err := json.Unmarshal(event.Meta[key], &meta); err == nil {
+			walkPayload(meta, add)
+		}
+	}
+	for _, frame := range event.Trace {
+		add(filepath.Base(frame.File) + " " + frame.Func)
+	}
+
+	if event.PayloadFormat == dump.PayloadFormatJSON {
+		var payload any
+		if err := json.Unmarshal(event.Payload, &payload); err == nil {
+			walkPayload(payload, add)
+		}
+	} else {
+		add(event.PayloadString())
+	}
+
+	return tokens
+}
+
+func walkPayload(value any, add func(string)) {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, child := range typed {
+			add(key)
+			walkPayload(child, add)
+		}
+	case []any:
+		for _, child := range typed {
+			walkPayload(child, add)
+		}
+	case string:
+		add(typed)
+	case float64:
+		add(strconv.FormatFloat(typed, 'f', -1, 64))
+	case bool:
+		add(strconv.FormatBool(typed))
+	}
+}
+
+func tokenize(text string, tokens []string, seen map[string]bool) []string {
+	for _, field := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len(tokens) >= maxDocumentTokens {
+			break
+		}
+		if len(field) < minTokenLength || len(field) > maxTokenLength || seen[field] {
+			continue
+		}
+		seen[field] = true
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
@@ -0,0 +1,248 @@
+package search
+
+import (
+	"sync"
+
+	"phant/internal/dump"
+)
+
+const DefaultMaxDocuments = 10000
+
+type IndexerStats struct {
+	Indexed     uint64 `json:"indexed"`
+	Pending     int    `json:"pending"`
+	Prioritized int    `json:"prioritized"`
+	Documents   int    `json:"documents"`
+	Paused      bool   `json:"paused"`
+}
+
+// Indexer keeps search indexing off the ingest path. Enqueue only records
+// the event; a background worker indexes pending events, visible events
+// first, then the most recently arrived.
+type Indexer struct {
+	index        *Index
+	maxDocuments int
+	load         func(dump.Event) dump.Event
+
+	mu       sync.Mutex
+	pending  map[string]dump.Event
+	arrivals []string
+	priority []string
+	indexed  []string
+	count    uint64
+	paused   bool
+	workers  int
+
+	wake     chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewIndexer(index *Index, maxDocuments int) *Indexer {
+	if maxDocuments <= 0 {
+		maxDocuments = DefaultMaxDocuments
+	}
+
+	return &Indexer{
+		index:        index,
+		maxDocuments: maxDocuments,
+		pending:      make(map[string]dump.Event),
+		workers:      1,
+		wake:         make(chan struct{}, 1),
+		stopped:      make(chan struct{}),
+	}
+}
+
+func (x *Indexer) Index() *Index {
+	return x.index
+}
+
+// SetLoader sets a function applied to each event before it is indexed, to
+// bring back what was kept out of memory, such as an offloaded payload. It
+// must be called before Start.
+func (x *Indexer) SetLoader(load func(dump.Event) dump.Event) {
+	x.load = load
+}
+
+func (x *Indexer) Enqueue(event dump.Event) {
+	x.mu.Lock()
+	if _, queued := x.pending[event.ID]; !queued {
+		x.arrivals = append(x.arrivals, event.ID)
+	}
+	x.pending[event.ID] = event
+	x.mu.Unlock()
+
+	x.signal()
+}
+
+// Prioritize moves the given events, typically the ones currently visible
+// or matching the active filter, to the front of the queue.
+func (x *Indexer) Prioritize(ids []string) {
+	x.mu.Lock()
+	x.priority = x.priority[:0]
+	for _, id := range ids {
+		if _, ok := x.pending[id]; ok {
+			x.priority = append(x.priority, id)
+		}
+	}
+	x.mu.Unlock()
+
+	x.signal()
+}
+
+// Pause stops indexing without dropping queued events; they are indexed once
+// Resume is called.
+func (x *Indexer) Pause() {
+	x.mu.Lock()
+	x.paused = true
+	x.mu.Unlock()
+}
+
+func (x *Indexer) Resume() {
+	x.mu.Lock()
+	x.paused = false
+	x.mu.Unlock()
+
+	x.signal()
+}
+
+// SetWorkers sets how many goroutines index in parallel. It must be called
+// before Start.
+func (x *Indexer) SetWorkers(n int) {
+	x.workers = max(n, 1)
+	x.wake = make(chan struct{}, x.workers)
+}
+
+func (x *Indexer) Start() {
+	for range x.workers {
+		x.wg.Add(1)
+		go x.work()
+	}
+}
+
+func (x *Indexer) work() {
+	defer x.wg.Done()
+
+	for {
+		if x.step() {
+			select {
+			case <-x.stopped:
+				return
+			default:
+			}
+			continue
+		}
+
+		select {
+		case <-x.stopped:
+			return
+		case <-x.wake:
+		}
+	}
+}
+
+func (x *Indexer) Stop() {
+	x.stopOnce.Do(func() {
+		close(x.stopped)
+		x.wg.Wait()
+	})
+}
+
+// Unindexed returns the events, expected oldest first, that should be
+// searchable but are neither indexed nor queued: only the newest
+// maxDocuments events are kept in the index.
+func (x *Indexer) Unindexed(events []dump.Event) []dump.Event {
+	if over := len(events) - x.maxDocuments; over > 0 {
+		events = events[over:]
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var missing []dump.Event
+	for _, event := range events {
+		if _, queued := x.pending[event.ID]; queued || x.index.Has(event.ID) {
+			continue
+		}
+		missing = append(missing, event)
+	}
+	return missing
+}
+
+func (x *Indexer) Stats() IndexerStats {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	return IndexerStats{
+		Indexed:     x.count,
+		Pending:     len(x.pending),
+		Prioritized: len(x.priority),
+		Documents:   x.index.Len(),
+		Paused:      x.paused,
+	}
+}
+
+func (x *Indexer) signal() {
+	select {
+	case x.wake <- struct{}{}:
+	default:
+	}
+}
+
+// step indexes one pending event and reports whether there was one.
+func (x *Indexer) step() bool {
+	event, ok := x.next()
+	if !ok {
+		return false
+	}
+
+	if x.load != nil {
+		event = x.load(event)
+	}
+	x.index.Add(event)
+
+	x.mu.Lock()
+	x.count++
+	x.indexed = append(x.indexed, event.ID)
+	var evicted []string
+	if over := len(x.indexed) - x.maxDocuments; over > 0 {
+		evicted = append(evicted, x.indexed[:over]...)
+		x.indexed = x.indexed[over:]
+	}
+	x.mu.Unlock()
+
+	if len(evicted) > 0 {
+		x.index.Remove(evicted)
+	}
+	return true
+}
+
+func (x *Indexer) next() (dump.Event, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.paused {
+		return dump.Event{}, false
+	}
+
+	for len(x.priority) > 0 {
+		id := x.priority[0]
+		x.priority = x.priority[1:]
+		if event, ok := x.pending[id]; ok {
+			delete(x.pending, id)
+			return event, true
+		}
+	}
+
+	for len(x.arrivals) > 0 {
+		id := x.arrivals[len(x.arrivals)-1]
+		x.arrivals = x.arrivals[:len(x.arrivals)-1]
+		if event, ok := x.pending[id]; ok {
+			delete(x.pending, id)
+			return event, true
+		}
+	}
+
+	return dump.Event{}, false
+}
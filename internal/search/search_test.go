@@ -0,0 +1,151 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func TestIndex_SearchMatchesAllTerms(t *testing.T) {
+	index := NewIndex()
+	index.Add(dump.Event{ID: "1", PayloadFormat: dump.PayloadFormatJSON, Payload: json.RawMessage(`{"user":{"name":"Ada Lovelace"}}`)})
+	index.Add(dump.Event{ID: "2", PayloadFormat: dump.PayloadFormatText, Payload: json.RawMessage(`"Ada logged in"`), Label: "auth"})
+
+	if got := index.Search("ada"); len(got) != 2 {
+		t.Fatalf("Search(ada) = %v, want both events", got)
+	}
+	if got := index.Search("ADA lovelace"); len(got) != 1 {
+		t.Fatalf("Search(ADA lovelace) = %v, want event 1", got)
+	}
+	if _, ok := index.Search("auth ada")["2"]; !ok {
+		t.Fatalf("Search(auth ada) missing event 2")
+	}
+
+	index.Remove([]string{"1"})
+	if got := index.Search("lovelace"); len(got) != 0 {
+		t.Fatalf("Search(lovelace) after Remove = %v, want empty", got)
+	}
+}
+
+func TestIndexer_IndexesPrioritizedThenNewestFirst(t *testing.T) {
+	indexer := NewIndexer(NewIndex(), 2)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		indexer.Enqueue(dump.Event{ID: id})
+	}
+	indexer.Prioritize([]string{"1", "missing"})
+
+	var order []string
+	for {
+		event, ok := indexer.next()
+		if !ok {
+			break
+		}
+		order = append(order, event.ID)
+	}
+
+	want := []string{"1", "4", "3", "2"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("index order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestIndexer_LoaderRestoresOffloadedPayloads(t *testing.T) {
+	indexer := NewIndexer(NewIndex(), 10)
+	indexer.SetLoader(func(event dump.Event) dump.Event {
+		if event.Offloaded {
+			event.Payload = json.RawMessage(`{"note":"beyond the preview"}`)
+		}
+		return event
+	})
+	indexer.Enqueue(dump.Event{ID: "1", Offloaded: true, PayloadFormat: dump.PayloadFormatJSON, Payload: json.RawMessage(`{}`)})
+
+	for indexer.step() {
+	}
+	if _, ok := indexer.Index().Search("preview")["1"]; !ok {
+		t.Fatalf("Search(preview) missing the loaded payload of event 1")
+	}
+}
+
+func TestIndexer_BackgroundWorkerCapsDocuments(t *testing.T) {
+	indexer := NewIndexer(NewIndex(), 2)
+	indexer.Start()
+	defer indexer.Stop()
+
+	for _, id := range []string{"aa", "bb", "cc"} {
+		indexer.Enqueue(dump.Event{ID: id})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for indexer.Stats().Indexed < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Stats() = %+v, want 3 indexed", indexer.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if stats := indexer.Stats(); stats.Documents != 2 || stats.Pending != 0 {
+		t.Fatalf("Stats() = %+v, want 2 documents and nothing pending", stats)
+	}
+	if indexer.Index().Has("cc") {
+		t.Fatalf("Index().Has(cc) = true, want the first-indexed document evicted")
+	}
+}
+
+func TestIndexer_ParallelWorkersIndexEverything(t *testing.T) {
+	indexer := NewIndexer(NewIndex(), 0)
+	indexer.SetWorkers(4)
+	indexer.Start()
+	defer indexer.Stop()
+
+	for i := range 200 {
+		indexer.Enqueue(dump.Event{ID: fmt.Sprintf("evt-%d", i)})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for indexer.Stats().Indexed < 200 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Stats() = %+v, want 200 indexed", indexer.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if stats := indexer.Stats(); stats.Documents != 200 || stats.Pending != 0 {
+		t.Fatalf("Stats() = %+v, want 200 documents and nothing pending", stats)
+	}
+}
+
+func TestIndexer_PauseKeepsQueueUntilResume(t *testing.T) {
+	indexer := NewIndexer(NewIndex(), 10)
+	indexer.Pause()
+	indexer.Enqueue(dump.Event{ID: "1"})
+
+	if _, ok := indexer.next(); ok {
+		t.Fatalf("next() while paused = true, want nothing indexed")
+	}
+	if stats := indexer.Stats(); !stats.Paused || stats.Pending != 1 {
+		t.Fatalf("Stats() = %+v, want paused with 1 pending", stats)
+	}
+
+	indexer.Resume()
+	if event, ok := indexer.next(); !ok || event.ID != "1" {
+		t.Fatalf("next() after Resume = %v, %v, want event 1", event.ID, ok)
+	}
+}
+
+func TestIndexer_UnindexedSkipsIndexedQueuedAndEvicted(t *testing.T) {
+	indexer := NewIndexer(NewIndex(), 3)
+	indexer.Index().Add(dump.Event{ID: "2"})
+	indexer.Pause()
+	indexer.Enqueue(dump.Event{ID: "3"})
+
+	events := []dump.Event{{ID: "0"}, {ID: "1"}, {ID: "2"}, {ID: "3"}}
+	missing := indexer.Unindexed(events)
+	if len(missing) != 1 || missing[0].ID != "1" {
+		t.Fatalf("Unindexed() = %v, want only event 1", missing)
+	}
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"phant/internal/alert"
+	"phant/internal/annotation"
+	"phant/internal/notify"
+)
+
+const (
+	alertSubscriberBuffer = 1024
+	alertWebhookTimeout   = 10 * time.Second
+)
+
+var errNotificationsUnavailable = errors.New("notifications are not available")
+
+// alertEngine opens the alert rules kept next to the session logs on first
+// use.
+func (r *collectorRuntime) alertEngine() (*alert.Engine, error) {
+	r.alertsMu.Lock()
+	defer r.alertsMu.Unlock()
+
+	if r.alerts == nil {
+		engine, err := alert.Open(r.storeDir)
+		if err != nil {
+			return nil, err
+		}
+		r.alerts = engine
+	}
+	return r.alerts, nil
+}
+
+func (r *collectorRuntime) mergeAlertRules(rules []alert.Rule) ([]alert.Rule, error) {
+	engine, err := r.alertEngine()
+	if err != nil {
+		return nil, err
+	}
+	return engine.Merge(rules)
+}
+
+// startAlerting evaluates alert rules against every published event on a
+// subscription of its own, so rate conditions count events the live feed
+// holds back or drops.
+func (r *collectorRuntime) startAlerting() {
+	if r.collector == nil || r.alerting {
+		return
+	}
+	engine, err := r.alertEngine()
+	if err != nil {
+		return
+	}
+
+	subID, ch := r.collector.Subscribe(alertSubscriberBuffer)
+	r.alertSubID = subID
+	r.alerting = true
+
+	r.alertWG.Add(1)
+	go func() {
+		defer r.alertWG.Done()
+		for event := range ch {
+			// Collapsed repeats were evaluated when they first arrived.
+			if event.RepeatCount > 0 {
+				continue
+			}
+			for _, raised := range engine.Evaluate(event, time.Now()) {
+				r.alertWG.Add(1)
+				go func() {
+					defer r.alertWG.Done()
+					r.raiseAlert(engine, raised)
+				}()
+			}
+		}
+	}()
+}
+
+func (r *collectorRuntime) stopAlerting() {
+	if !r.alerting {
+		return
+	}
+
+	r.collector.Unsubscribe(r.alertSubID)
+	r.alertWG.Wait()
+	r.alerting = false
+}
+
+// raiseAlert carries out an alert's actions in order, records the outcome
+// of each, and tells the UI.
+func (r *collectorRuntime) raiseAlert(engine *alert.Engine, raised alert.Alert) {
+	for i := range raised.Actions {
+		result := &raised.Actions[i]
+		var err error
+		switch result.Kind {
+		case alert.ActionNotify:
+			err = r.notifyAlert(raised)
+		case alert.ActionWebhook:
+			err = r.postAlert(result.Target, raised)
+		case alert.ActionTag:
+			err = r.tagAlertedEvent(raised.EventID, result.Target)
+		}
+		result.Status = alert.StatusDone
+		if err != nil {
+			result.Status = alert.StatusFailed
+			result.Error = err.Error()
+		}
+	}
+
+	engine.Record(raised)
+	if r.app != nil {
+		r.app.Event.Emit(AlertRuntimeChannel, raised)
+	}
+}
+
+// notifyAlert shows an alert even while a phant window has focus, unlike
+// the notifications for dd() calls and errors: the user asked for it.
+func (r *collectorRuntime) notifyAlert(raised alert.Alert) error {
+	r.notifyMu.Lock()
+	send := r.sendNotification
+	r.notifyMu.Unlock()
+	if send == nil {
+		return errNotificationsUnavailable
+	}
+	return send(notify.Notification{
+		ID:      "alert-" + raised.ID,
+		EventID: raised.EventID,
+		Title:   raised.RuleName,
+		Body:    raised.Message,
+	})
+}
+
+func (r *collectorRuntime) postAlert(url string, raised alert.Alert) error {
+	body, err := json.Marshal(raised)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "phant")
+
+	response, err := r.alertClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (r *collectorRuntime) tagAlertedEvent(eventID string, tag string) error {
+	_, err := r.annotate(func(set *annotation.Set) (annotation.Annotation, error) {
+		current, _ := set.Get(eventID)
+		if slices.Contains(current.Tags, tag) {
+			return current, nil
+		}
+		return set.SetTags(eventID, append(slices.Clone(current.Tags), tag))
+	})
+	return err
+}
+
+func (r *collectorRuntime) alertHistory(ruleID string) ([]alert.Alert, error) {
+	engine, err := r.alertEngine()
+	if err != nil {
+		return nil, err
+	}
+	return engine.History(ruleID), nil
+}
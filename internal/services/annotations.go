@@ -0,0 +1,79 @@
+package services
+
+import (
+	"phant/internal/annotation"
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+// annotationSet opens the annotations kept next to the session logs on
+// first use.
+func (r *collectorRuntime) annotationSet() (*annotation.Set, error) {
+	r.annotationsMu.Lock()
+	defer r.annotationsMu.Unlock()
+
+	if r.annotations == nil {
+		set, err := annotation.Open(r.storeDir)
+		if err != nil {
+			return nil, err
+		}
+		r.annotations = set
+	}
+	return r.annotations, nil
+}
+
+// compileFilter compiles filter with annotations attached, so Tag and
+// Pinned filters work wherever events are selected.
+func (r *collectorRuntime) compileFilter(filter query.Filter) (query.Matcher, error) {
+	matcher, err := filter.Compile()
+	if err != nil {
+		return query.Matcher{}, err
+	}
+	if set, err := r.annotationSet(); err == nil {
+		matcher = matcher.WithAnnotations(set)
+	}
+	return matcher, nil
+}
+
+// eventTags looks up the tags of events for facets, or returns nil when the
+// annotations cannot be read.
+func (r *collectorRuntime) eventTags() func(eventID string) []string {
+	set, err := r.annotationSet()
+	if err != nil {
+		return nil
+	}
+	return func(eventID string) []string {
+		annotation, _ := set.Get(eventID)
+		return annotation.Tags
+	}
+}
+
+// keepPinned stops retention from pruning pinned events.
+func (r *collectorRuntime) keepPinned() {
+	set, err := r.annotationSet()
+	if err != nil || r.retention == nil {
+		return
+	}
+	r.retention.SetKeep(func(event dump.Event) bool {
+		return set.Pinned(event.ID)
+	})
+}
+
+func (r *collectorRuntime) annotate(change func(*annotation.Set) (annotation.Annotation, error)) (annotation.Annotation, error) {
+	set, err := r.annotationSet()
+	if err != nil {
+		return annotation.Annotation{}, err
+	}
+	updated, err := change(set)
+	if err != nil {
+		return annotation.Annotation{}, err
+	}
+	r.emitAnnotationChanged(updated)
+	return updated, nil
+}
+
+func (r *collectorRuntime) emitAnnotationChanged(updated annotation.Annotation) {
+	if r.app != nil {
+		r.app.Event.Emit(AnnotationsChangedRuntimeChannel, updated)
+	}
+}
@@ -1,14 +1,45 @@
 package services
 
+import (
+	"context"
+	"net/http"
+
+	"phant/internal/config"
+	"phant/internal/ddgate"
+	"phant/internal/dump"
+	"phant/internal/forward"
+	"phant/internal/health"
+	"phant/internal/hook"
+	"phant/internal/hosts"
+	"phant/internal/ingest"
+	"phant/internal/netauth"
+	"phant/internal/notify"
+	"phant/internal/otlp"
+	"phant/internal/pipeline"
+	"phant/internal/preview"
+	"phant/internal/signature"
+	"phant/internal/source"
+	"phant/internal/store"
+	"phant/internal/trash"
+	"phant/internal/workspace"
+
+	"github.com/wailsapp/wails/v3/pkg/services/notifications"
+)
+
 type Options struct {
 	SocketPath string
+	PayloadDir string
+	StoreDir   string
 }
 
 type AppServices struct {
-	Lifecycle *CollectorLifecycleService
-	Dump      *DumpService
-	Setup     *SetupService
-	PHP       *PHPService
+	Lifecycle     *CollectorLifecycleService
+	Dump          *DumpService
+	Setup         *SetupService
+	PHP           *PHPService
+	Config        *ConfigService
+	Notifications *NotificationService
+	Binary        *BinaryService
 }
 
 func NewAppServices() *AppServices {
@@ -17,13 +48,67 @@ func NewAppServices() *AppServices {
 
 func NewAppServicesWithOptions(options Options) *AppServices {
 	runtime := &collectorRuntime{
-		socketPath: options.SocketPath,
+		socketPath:       options.SocketPath,
+		storeDir:         options.StoreDir,
+		config:           config.NewRegistry(),
+		projects:         config.NewProjects(),
+		workspace:        workspace.New(),
+		hosts:            hosts.New(),
+		sources:          source.NewCache(source.DefaultCacheSize),
+		redactors:        make(map[string]cachedRedactor),
+		streams:          make(map[int]DumpStreamSubscription),
+		summaries:        make(map[string]sessionSummary),
+		imports:          make(map[string]context.CancelFunc),
+		ingestListeners:  make(map[string]*ingest.Listener),
+		ingestErrors:     make(map[string]string),
+		ruleFiles:        make(map[string]RuleFile),
+		signatures:       signature.NewCache(signature.DefaultCacheSize),
+		previews:         preview.NewCache(preview.DefaultCacheSize, preview.DefaultOptions()),
+		healthThresholds: health.DefaultThresholds(),
+		shapes:           signature.NewTracker(signature.DefaultMinSamples),
+		forwarder:        forward.NewForwarder(forward.Options{}),
+		alertClient:      &http.Client{Timeout: alertWebhookTimeout},
+		hooks:            hook.NewRunner(hook.Options{}),
+		otlpExporter:     otlp.NewExporter(otlp.Options{}),
+		liveGate:         pipeline.NewGate(pipeline.DefaultGateLimit),
+		auth:             netauth.NewGuard(),
+		notifier:         notify.New(),
+		trash:            trash.New(trash.DefaultWindow),
+		clock:            ClockSettings{SkewWarningMs: DefaultSkewWarningMs},
+		payloadStorage:   DefaultPayloadStorageSettings(),
+		tracer:           pipeline.NewTracer(pipeline.DefaultTracedEvents, pipeline.DefaultLatencySample),
+		decodeOptions: dump.DecodeOptions{
+			MaxTraceFrames: dump.DefaultMaxTraceFrames,
+		},
+	}
+	payloadDir := options.PayloadDir
+	if payloadDir == "" {
+		payloadDir = defaultPayloadDir()
+	}
+	runtime.payloads = store.NewPayloads(payloadDir)
+	runtime.hooks.SetLoader(runtime.loadOffloadedPayload)
+	runtime.signatures.SetLoader(runtime.loadOffloadedPayload)
+	if runtime.storeDir == "" {
+		runtime.storeDir = defaultStoreDir()
 	}
+	runtime.slowQueryMs.Store(config.DefaultSlowQueryMs)
+	runtime.gates = ddgate.NewQueue(ddgate.DefaultSettings(), runtime.emitGatesChanged)
+	runtime.varDumper = newListenerSlot(runtime.newVarDumperServer)
+	runtime.ray = newListenerSlot(runtime.newRayServer)
+	runtime.api = newListenerSlot(runtime.newAPIServer)
+	runtime.logs = newListenerSlot(runtime.newLogServer)
+	runtime.registerConfigSections()
 
 	return &AppServices{
 		Lifecycle: &CollectorLifecycleService{runtime: runtime},
 		Dump:      &DumpService{runtime: runtime},
 		Setup:     &SetupService{runtime: runtime},
 		PHP:       NewPHPService(),
+		Config:    &ConfigService{runtime: runtime},
+		Notifications: &NotificationService{
+			runtime:  runtime,
+			platform: notifications.New(),
+		},
+		Binary: &BinaryService{runtime: runtime},
 	}
 }
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// BinaryRoute is where BinaryService is mounted on the asset server.
+const BinaryRoute = "/binary/"
+
+// BinaryPayload describes an event's binary payload. The viewer loads the
+// data from URL, which BinaryService serves, so images and PDFs never pass
+// through the bindings as base64.
+type BinaryPayload struct {
+	MimeType string `json:"mimeType"`
+	Bytes    int    `json:"bytes"`
+	URL      string `json:"url"`
+}
+
+// BinaryService streams binary payloads from disk to the webview. A request
+// names the event after BinaryRoute and may ask for byte ranges, as video
+// and PDF viewers do.
+type BinaryService struct {
+	runtime *collectorRuntime
+}
+
+func (s *BinaryService) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// The asset server strips BinaryRoute from the path.
+	eventID := strings.TrimPrefix(req.URL.Path, "/")
+	event, content, err := s.runtime.openBinaryPayload(eventID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", event.MimeType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// The payload shares the app's origin: a dumped HTML page or SVG must
+	// not run scripts that can reach the bindings.
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	http.ServeContent(w, req, "", time.Time{}, content)
+}
+
+// offloadBinary moves the data of a binary payload to disk, leaving an
+// empty string in the event. When it cannot be stored the event keeps the
+// base64 data; it is never truncated, which would corrupt it.
+func (r *collectorRuntime) offloadBinary(event *dump.Event) {
+	data, err := event.BinaryPayload()
+	if err != nil {
+		return
+	}
+	ref, err := r.payloads.PutBinary(data)
+	if err != nil {
+		event.Warnings = append(event.Warnings, dump.Warning{Field: "payload", Message: "binary payload could not be stored: " + err.Error()})
+		return
+	}
+	event.Payload = json.RawMessage(`""`)
+	event.Offloaded = true
+	event.PayloadRef = ref
+	event.OriginalBytes = len(data)
+}
+
+func (r *collectorRuntime) binaryPayload(eventID string) (BinaryPayload, error) {
+	event, err := r.findEvent(eventID)
+	if err != nil {
+		return BinaryPayload{}, err
+	}
+	if event.PayloadFormat != dump.PayloadFormatBinary {
+		return BinaryPayload{}, ErrNotBinaryPayload
+	}
+
+	size := event.OriginalBytes
+	if !event.Offloaded {
+		data, err := event.BinaryPayload()
+		if err != nil {
+			return BinaryPayload{}, err
+		}
+		size = len(data)
+	}
+	return BinaryPayload{MimeType: event.MimeType, Bytes: size, URL: BinaryRoute + url.PathEscape(eventID)}, nil
+}
+
+// openBinaryPayload opens the data of a binary payload, from disk or, when
+// it could not be stored, from the event.
+func (r *collectorRuntime) openBinaryPayload(eventID string) (dump.Event, io.ReadSeekCloser, error) {
+	event, err := r.findEvent(eventID)
+	if err != nil {
+		return dump.Event{}, nil, err
+	}
+	if event.PayloadFormat != dump.PayloadFormatBinary {
+		return dump.Event{}, nil, ErrNotBinaryPayload
+	}
+
+	if !event.Offloaded {
+		data, err := event.BinaryPayload()
+		if err != nil {
+			return dump.Event{}, nil, err
+		}
+		return event, memoryContent{bytes.NewReader(data)}, nil
+	}
+	file, err := r.payloads.OpenBinary(event.PayloadRef)
+	if err != nil {
+		return dump.Event{}, nil, err
+	}
+	return event, file, nil
+}
+
+// encodedBinaryPayload returns an offloaded binary payload as it was sent,
+// a base64 JSON string, for exports and the payload API.
+func (r *collectorRuntime) encodedBinaryPayload(ref string) (json.RawMessage, error) {
+	file, err := r.payloads.OpenBinary(ref)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(base64.StdEncoding.EncodeToString(data))
+}
+
+type memoryContent struct {
+	*bytes.Reader
+}
+
+func (memoryContent) Close() error {
+	return nil
+}
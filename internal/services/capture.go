@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+
+	"phant/internal/capture"
+)
+
+// captureDirName is where captures are kept, next to the session logs.
+const captureDirName = "capture"
+
+// CaptureStatus reports whether listener traffic is being captured and how
+// much of it is on disk.
+type CaptureStatus struct {
+	Active bool `json:"active"`
+	capture.Stats
+}
+
+// ReplayResult counts what a replay fed through the pipeline. Partial
+// connections lost their start to the ring and were replayed from
+// mid-stream; skipped ones came from a listener this build does not know.
+type ReplayResult struct {
+	Connections int `json:"connections"`
+	Bytes       int `json:"bytes"`
+	Partial     int `json:"partial"`
+	Skipped     int `json:"skipped"`
+}
+
+func (r *collectorRuntime) captureDir() string {
+	return filepath.Join(r.storeDir, captureDirName)
+}
+
+func (r *collectorRuntime) captureRecorder() *capture.Recorder {
+	r.captureMu.Lock()
+	defer r.captureMu.Unlock()
+	return r.capture
+}
+
+// startCapture records connections accepted from now on; connections that
+// are already open, such as a ServerDumper that keeps its socket, are only
+// captured once they reconnect.
+func (r *collectorRuntime) startCapture(maxBytes int64) (CaptureStatus, error) {
+	r.captureMu.Lock()
+	defer r.captureMu.Unlock()
+
+	if r.capture != nil {
+		return CaptureStatus{Active: true, Stats: r.capture.Stats()}, nil
+	}
+	recorder, err := capture.Open(r.captureDir(), maxBytes)
+	if err != nil {
+		return CaptureStatus{}, err
+	}
+	r.capture = recorder
+	return CaptureStatus{Active: true, Stats: recorder.Stats()}, nil
+}
+
+func (r *collectorRuntime) stopCapture() (CaptureStatus, error) {
+	r.captureMu.Lock()
+	defer r.captureMu.Unlock()
+
+	if r.capture == nil {
+		return CaptureStatus{Stats: capture.Stats{Dir: r.captureDir()}}, nil
+	}
+	err := r.capture.Close()
+	status := CaptureStatus{Stats: r.capture.Stats()}
+	r.capture = nil
+	return status, err
+}
+
+func (r *collectorRuntime) captureStatus() CaptureStatus {
+	r.captureMu.Lock()
+	defer r.captureMu.Unlock()
+
+	if r.capture == nil {
+		return CaptureStatus{Stats: capture.Stats{Dir: r.captureDir()}}
+	}
+	return CaptureStatus{Active: true, Stats: r.capture.Stats()}
+}
+
+func (r *collectorRuntime) exportCapture(path string) error {
+	if path == "" {
+		return errors.New("export path must not be empty")
+	}
+	return capture.Export(r.captureDir(), path)
+}
+
+// replayCapture feeds every captured connection through the listener that
+// received it, in the order they were accepted. Socket connections go to
+// the running collector; adapter connections go to a fresh adapter server
+// with the current auth settings, so replays neither need the listener
+// enabled nor count in its stats. An empty path replays the capture on
+// disk.
+func (r *collectorRuntime) replayCapture(path string) (ReplayResult, error) {
+	if !r.collectorRunning() {
+		return ReplayResult{}, ErrCollectorNotRunning
+	}
+	if path == "" {
+		path = r.captureDir()
+	}
+	connections, err := capture.Load(path)
+	if err != nil {
+		return ReplayResult{}, err
+	}
+
+	var result ReplayResult
+	for _, connection := range connections {
+		var serve func(net.Conn)
+		var stop func() error
+		switch connection.Listener {
+		case capture.ListenerCollector:
+			serve = r.collector.ServeConn
+		case capture.ListenerVarDumper:
+			server := r.newVarDumperServer("")
+			serve, stop = server.ServeConn, server.Stop
+		case capture.ListenerRay:
+			server := r.newRayServer("")
+			serve, stop = server.ServeConn, server.Stop
+		case capture.ListenerMonolog:
+			server := r.newLogServer("")
+			serve, stop = server.ServeConn, server.Stop
+		default:
+			result.Skipped++
+			continue
+		}
+
+		capture.Replay(connection.Data, serve)
+		if stop != nil {
+			// Ray events waiting for modifiers are emitted on stop.
+			_ = stop()
+		}
+		result.Connections++
+		result.Bytes += connection.Bytes
+		if connection.Partial {
+			result.Partial++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"time"
+
+	"phant/internal/query"
+	"phant/internal/trash"
+)
+
+// RestoreResult reports an undone clear: how many events it held and how
+// many went back into the buffer, fewer if some arrived again meanwhile.
+type RestoreResult struct {
+	Batch    trash.Batch `json:"batch"`
+	Restored int         `json:"restored"`
+}
+
+// clearDumpEvents removes the buffered events the filter matches, typically
+// a project or a time range, and holds them in the trash for the undo
+// window. An empty filter clears everything.
+func (r *collectorRuntime) clearDumpEvents(filter query.Filter) (trash.Batch, error) {
+	if r.collector == nil {
+		return trash.Batch{}, ErrCollectorNotRunning
+	}
+	matcher, err := r.compileFilter(filter)
+	if err != nil {
+		return trash.Batch{}, err
+	}
+
+	matches := r.collector.Select(matcher.Match)
+	ids := make(map[string]struct{}, len(matches))
+	for _, event := range matches {
+		ids[event.ID] = struct{}{}
+	}
+	r.collector.Remove(ids)
+	batch := r.trash.Put(matches)
+	r.markRead()
+
+	if window := r.trash.Window(); window > 0 && batch.Count > 0 {
+		time.AfterFunc(window, r.purgeExpired)
+	} else {
+		r.forgetCleared([]trash.Batch{batch})
+	}
+	if r.app != nil {
+		r.app.Event.Emit(EventsClearedRuntimeChannel, batch)
+	}
+	return batch, nil
+}
+
+// undoClear puts a held batch back into the buffer where its events were.
+func (r *collectorRuntime) undoClear(batchID string) (RestoreResult, error) {
+	if r.collector == nil {
+		return RestoreResult{}, ErrCollectorNotRunning
+	}
+	batch, err := r.trash.Take(batchID)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+
+	result := RestoreResult{Batch: batch, Restored: r.collector.Restore(batch.Events())}
+	if r.app != nil {
+		r.app.Event.Emit(EventsRestoredRuntimeChannel, result)
+	}
+	return result, nil
+}
+
+func (r *collectorRuntime) clearedBatches() []trash.Batch {
+	return r.trash.List()
+}
+
+// purgeCleared deletes a held batch, or every one for an empty ID, without
+// waiting for the undo window.
+func (r *collectorRuntime) purgeCleared(batchID string) (int, error) {
+	var purged []trash.Batch
+	if batchID == "" {
+		purged = r.trash.Empty()
+	} else {
+		batch, err := r.trash.Take(batchID)
+		if err != nil {
+			return 0, err
+		}
+		purged = []trash.Batch{batch}
+	}
+	return r.forgetCleared(purged), nil
+}
+
+func (r *collectorRuntime) purgeExpired() {
+	r.forgetCleared(r.trash.Purge())
+}
+
+// forgetCleared drops purged events from the search index, which keeps
+// them while they can still be restored, and returns how many there were.
+func (r *collectorRuntime) forgetCleared(batches []trash.Batch) int {
+	var ids []string
+	for _, batch := range batches {
+		for _, event := range batch.Events() {
+			ids = append(ids, event.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0
+	}
+
+	r.searchMu.Lock()
+	if r.indexer != nil {
+		r.indexer.Index().Remove(ids)
+	}
+	r.searchMu.Unlock()
+	return len(ids)
+}
@@ -0,0 +1,635 @@
+package services
+
+import (
+	"encoding/json"
+
+	"phant/internal/alert"
+	"phant/internal/config"
+	"phant/internal/dashboard"
+	"phant/internal/ddgate"
+	"phant/internal/dump"
+	"phant/internal/editor"
+	"phant/internal/forward"
+	"phant/internal/health"
+	"phant/internal/hosts"
+	"phant/internal/nettls"
+	"phant/internal/notify"
+	"phant/internal/otlp"
+	"phant/internal/preview"
+	"phant/internal/retention"
+	"phant/internal/savedfilter"
+	"phant/internal/workspace"
+)
+
+type ConfigService struct {
+	runtime *collectorRuntime
+}
+
+func (s *ConfigService) ExportConfig(path string) error {
+	return s.runtime.config.Export(path)
+}
+
+func (s *ConfigService) ImportConfig(path string) (config.ImportResult, error) {
+	return s.runtime.config.Import(path)
+}
+
+func (s *ConfigService) GetProjectConfig(projectRoot string) config.ResolvedProject {
+	return s.runtime.projects.Resolve(projectRoot)
+}
+
+func (s *ConfigService) ListProjectConfigs() []config.ResolvedProject {
+	roots := s.runtime.projects.Roots()
+	projects := make([]config.ResolvedProject, 0, len(roots))
+	for _, root := range roots {
+		projects = append(projects, s.runtime.projects.Resolve(root))
+	}
+	return projects
+}
+
+func (s *ConfigService) SetProjectOverrides(projectRoot string, overrides config.ProjectConfig) config.ResolvedProject {
+	s.runtime.projects.SetOverrides(projectRoot, overrides)
+	s.runtime.resetRedactors()
+	return s.runtime.projects.Resolve(projectRoot)
+}
+
+// GetPathMappings returns the effective mappings for a project: local
+// overrides first, then those committed in .phant.toml.
+func (s *ConfigService) GetPathMappings(projectRoot string) []config.PathMapping {
+	return s.runtime.projects.Resolve(projectRoot).Config.PathMappings
+}
+
+// SetPathMappings replaces the project's local mappings, keeping its other
+// overrides. Remote prefixes are paths as producers report them; local
+// prefixes must be absolute host paths.
+func (s *ConfigService) SetPathMappings(projectRoot string, mappings []config.PathMapping) (config.ResolvedProject, error) {
+	return s.runtime.setPathMappings(projectRoot, mappings)
+}
+
+func (s *ConfigService) MapPath(projectRoot string, path string) string {
+	mapped, _ := s.runtime.projects.MapPath(projectRoot, path)
+	return mapped
+}
+
+// GetRedactionRules returns the global rules, applied to every project in
+// addition to its own.
+func (s *ConfigService) GetRedactionRules() []config.RedactionRule {
+	return s.runtime.getRedactionRules()
+}
+
+// SetRedactionRules replaces the global rules. A key masks whole values
+// under matching JSON keys and query parameters (case-insensitive, globs
+// allowed); a pattern is a regular expression masked in every string.
+// Rules apply to events received from now on.
+func (s *ConfigService) SetRedactionRules(rules []config.RedactionRule) error {
+	return s.runtime.setRedactionRules(rules)
+}
+
+// GetRuleVersions reports the version of each rule file in effect. Edits
+// made to them on disk apply within a second, and RulesReloadedRuntimeChannel
+// carries the new versions.
+func (s *ConfigService) GetRuleVersions() RuleVersions {
+	return s.runtime.ruleVersions()
+}
+
+// ListProjects returns the projects seen in incoming events or configured
+// by the user, sorted by name. Archived projects are left out unless
+// includeArchived is set.
+func (s *ConfigService) ListProjects(includeArchived bool) []ProjectInfo {
+	return s.runtime.listProjects(includeArchived)
+}
+
+func (s *ConfigService) GetProject(projectRoot string) (ProjectInfo, bool) {
+	return s.runtime.getProject(projectRoot)
+}
+
+// UpdateProject replaces a project's name, color, archived flag, and
+// retention policy. A project retention policy applies to that project's
+// events on top of the global one.
+func (s *ConfigService) UpdateProject(projectRoot string, settings workspace.Settings) (ProjectInfo, error) {
+	return s.runtime.updateProject(projectRoot, settings)
+}
+
+func (s *ConfigService) ArchiveProject(projectRoot string, archived bool) (ProjectInfo, error) {
+	return s.runtime.archiveProject(projectRoot, archived)
+}
+
+// ListHosts returns the machines and containers sending dumps, by
+// hostname, with their event rate over the last minute, projects, latest
+// SDK version, and clock skew against the collector.
+func (s *ConfigService) ListHosts() []hosts.Host {
+	return s.runtime.hosts.Hosts()
+}
+
+func (s *ConfigService) GetHost(hostname string) (hosts.Host, bool) {
+	return s.runtime.hosts.Host(hostname)
+}
+
+// UpdateHost sets a host's color and muted flag. Muted hosts are still
+// collected, stored, and searchable but stay out of the live feed.
+func (s *ConfigService) UpdateHost(hostname string, settings hosts.Settings) (hosts.Host, error) {
+	return s.runtime.updateHost(hostname, settings)
+}
+
+// ListSourceTypes returns the accepted source types with their display
+// hints, built-in ones first.
+func (s *ConfigService) ListSourceTypes() []dump.SourceType {
+	return dump.SourceTypes()
+}
+
+// SaveSourceType registers a custom source type or updates one saved
+// earlier. Metadata names the block its events must carry: http, command,
+// log, or empty.
+func (s *ConfigService) SaveSourceType(source dump.SourceType) error {
+	return dump.RegisterSourceType(source, true)
+}
+
+func (s *ConfigService) DeleteSourceType(name string) error {
+	return dump.UnregisterSourceType(name)
+}
+
+func (s *ConfigService) GetEditor() config.EditorConfig {
+	return s.runtime.globalEditor()
+}
+
+// SetEditor sets the global editor used when a project has no override.
+// Name selects a preset; Command is a template with {file} and {line}.
+func (s *ConfigService) SetEditor(editorConfig config.EditorConfig) error {
+	return s.runtime.setEditor(editorConfig)
+}
+
+func (s *ConfigService) GetNotifications() NotificationStatus {
+	return s.runtime.notificationStatus()
+}
+
+// SetNotifications chooses which events raise a desktop notification while
+// phant is in the background, mutes projects, and caps notifications per
+// project and minute.
+func (s *ConfigService) SetNotifications(settings notify.Settings) (NotificationStatus, error) {
+	return s.runtime.setNotificationSettings(settings)
+}
+
+// GetSettings returns the app settings that are saved across restarts:
+// listener addresses, retention, editor, theme, redaction rules, and
+// whether to start hidden in the tray.
+func (s *ConfigService) GetSettings() SettingsStatus {
+	return s.runtime.settingsStatus()
+}
+
+// UpdateSettings validates and applies every setting, then saves them.
+// Nothing changes if any setting is invalid; a listener that cannot bind
+// is reported but keeps its new address, as with SetRayAddress.
+func (s *ConfigService) UpdateSettings(settings config.Settings) (SettingsStatus, error) {
+	return s.runtime.updateSettings(settings)
+}
+
+func (s *ConfigService) GetTray() TrayStatus {
+	return s.runtime.trayStatus()
+}
+
+// SetTray saves the tray settings; StartHidden applies from the next launch.
+func (s *ConfigService) SetTray(settings TraySettings) (TrayStatus, error) {
+	return s.runtime.setTraySettings(settings)
+}
+
+func (s *ConfigService) ListEditorPresets() []string {
+	return editor.Presets()
+}
+
+func (r *collectorRuntime) registerConfigSections() {
+	r.config.Register(config.Section{
+		Name: "dumpFiles",
+		Export: func() (any, error) {
+			paths := []string{}
+			if r.tails != nil {
+				for _, status := range r.tails.Statuses() {
+					paths = append(paths, status.Path)
+				}
+			}
+			return paths, nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var paths []string
+			if err := json.Unmarshal(raw, &paths); err != nil {
+				return err
+			}
+			if r.tails == nil {
+				return ErrCollectorNotRunning
+			}
+			for _, path := range paths {
+				if err := r.tails.Follow(path); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "projects",
+		Export: func() (any, error) {
+			return r.projects.Overrides(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var overrides map[string]config.ProjectConfig
+			if err := json.Unmarshal(raw, &overrides); err != nil {
+				return err
+			}
+			for root, project := range overrides {
+				r.projects.SetOverrides(root, project)
+			}
+			r.resetRedactors()
+			return nil
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "retention",
+		Export: func() (any, error) {
+			return r.getRetentionPolicy(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var policy retention.Policy
+			if err := json.Unmarshal(raw, &policy); err != nil {
+				return err
+			}
+			_, err := r.setRetentionPolicy(policy)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "decoding",
+		Export: func() (any, error) {
+			return r.getDecodeOptions(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var options dump.DecodeOptions
+			if err := json.Unmarshal(raw, &options); err != nil {
+				return err
+			}
+			r.setDecodeOptions(options)
+			return nil
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "varDumper",
+		Export: func() (any, error) {
+			return r.varDumper.configured(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var address string
+			if err := json.Unmarshal(raw, &address); err != nil {
+				return err
+			}
+			_, err := r.setVarDumperAddress(address)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "ray",
+		Export: func() (any, error) {
+			return r.ray.configured(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var address string
+			if err := json.Unmarshal(raw, &address); err != nil {
+				return err
+			}
+			_, err := r.setRayAddress(address)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "logIngest",
+		Export: func() (any, error) {
+			return r.logs.configured(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var address string
+			if err := json.Unmarshal(raw, &address); err != nil {
+				return err
+			}
+			_, err := r.setLogAddress(address)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "health",
+		Export: func() (any, error) {
+			return r.healthThresholds, nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var thresholds health.Thresholds
+			if err := json.Unmarshal(raw, &thresholds); err != nil {
+				return err
+			}
+			r.setHealthThresholds(thresholds)
+			return nil
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "forwarding",
+		Export: func() (any, error) {
+			return r.forwarder.Rules(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var rules []forward.Rule
+			if err := json.Unmarshal(raw, &rules); err != nil {
+				return err
+			}
+			return r.forwarder.SetRules(rules)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "otlp",
+		Export: func() (any, error) {
+			return r.otlpExporter.Config(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			exportConfig := otlp.DefaultConfig()
+			if err := json.Unmarshal(raw, &exportConfig); err != nil {
+				return err
+			}
+			return r.otlpExporter.SetConfig(exportConfig)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "pipeline",
+		Export: func() (any, error) {
+			return r.tuning, nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var tuning PipelineTuning
+			if err := json.Unmarshal(raw, &tuning); err != nil {
+				return err
+			}
+			_, err := r.setPipelineTuning(tuning)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "editor",
+		Export: func() (any, error) {
+			return r.globalEditor(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var editorConfig config.EditorConfig
+			if err := json.Unmarshal(raw, &editorConfig); err != nil {
+				return err
+			}
+			return r.setEditor(editorConfig)
+		},
+	})
+
+	r.config.Register(config.Section{
+		Name: "redaction",
+		Export: func() (any, error) {
+			return r.getRedactionRules(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var rules []config.RedactionRule
+			if err := json.Unmarshal(raw, &rules); err != nil {
+				return err
+			}
+			return r.setRedactionRules(rules)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "workspace",
+		Export: func() (any, error) {
+			return r.workspace.Settings(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var settings map[string]workspace.Settings
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			return r.loadWorkspace(settings)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "sourceTypes",
+		Export: func() (any, error) {
+			custom := []dump.SourceType{}
+			for _, source := range dump.SourceTypes() {
+				if !source.Builtin {
+					custom = append(custom, source)
+				}
+			}
+			return custom, nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var sources []dump.SourceType
+			if err := json.Unmarshal(raw, &sources); err != nil {
+				return err
+			}
+			for _, source := range sources {
+				if err := dump.RegisterSourceType(source, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "dedup",
+		Export: func() (any, error) {
+			return r.getDedup(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var settings DedupSettings
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			return r.setDedup(settings)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "ddGates",
+		Export: func() (any, error) {
+			return r.gates.Settings(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			settings := ddgate.DefaultSettings()
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			return r.gates.SetSettings(settings)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "savedFilters",
+		Export: func() (any, error) {
+			store, err := r.filterStore()
+			if err != nil {
+				return nil, err
+			}
+			return store.List(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var presets []savedfilter.Preset
+			if err := json.Unmarshal(raw, &presets); err != nil {
+				return err
+			}
+			_, err := r.mergeFilters(presets)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "hosts",
+		Export: func() (any, error) {
+			return r.hosts.Settings(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var settings map[string]hosts.Settings
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			return r.loadHosts(settings)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "preview",
+		Export: func() (any, error) {
+			return r.previews.Options(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			options := preview.DefaultOptions()
+			if err := json.Unmarshal(raw, &options); err != nil {
+				return err
+			}
+			return r.setPreviewOptions(options)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "payloadStorage",
+		Export: func() (any, error) {
+			return r.getPayloadStorage(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			settings := DefaultPayloadStorageSettings()
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			return r.setPayloadStorage(settings)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "clock",
+		Export: func() (any, error) {
+			return r.getClock(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			settings := ClockSettings{SkewWarningMs: DefaultSkewWarningMs}
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			return r.setClock(settings)
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "listenerAuth",
+		Export: func() (any, error) {
+			r.authMu.Lock()
+			defer r.authMu.Unlock()
+			return ListenerAuthSettings{Required: r.authRequired}, nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var settings ListenerAuthSettings
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			_, err := r.setListenerAuth(settings)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "listenerTLS",
+		Export: func() (any, error) {
+			return r.listenerTLSStatus().Settings, nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var settings nettls.Settings
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			_, err := r.setListenerTLS(settings)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "discovery",
+		Export: func() (any, error) {
+			return DiscoverySettings{Enabled: r.discoveryStatus().Enabled}, nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var settings DiscoverySettings
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			_, err := r.setDiscovery(settings)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "dashboards",
+		Export: func() (any, error) {
+			store, err := r.dashboardStore()
+			if err != nil {
+				return nil, err
+			}
+			return store.List(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var dashboards []dashboard.Dashboard
+			if err := json.Unmarshal(raw, &dashboards); err != nil {
+				return err
+			}
+			_, err := r.mergeDashboards(dashboards)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "alerts",
+		Export: func() (any, error) {
+			engine, err := r.alertEngine()
+			if err != nil {
+				return nil, err
+			}
+			return engine.Rules(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var rules []alert.Rule
+			if err := json.Unmarshal(raw, &rules); err != nil {
+				return err
+			}
+			_, err := r.mergeAlertRules(rules)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "notifications",
+		Export: func() (any, error) {
+			return r.notifier.Settings(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			settings := notify.DefaultSettings()
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			_, err := r.setNotificationSettings(settings)
+			return err
+		},
+	})
+	r.config.Register(config.Section{
+		Name: "tray",
+		Export: func() (any, error) {
+			return r.traySettings(), nil
+		},
+		Import: func(raw json.RawMessage) error {
+			var settings TraySettings
+			if err := json.Unmarshal(raw, &settings); err != nil {
+				return err
+			}
+			_, err := r.setTraySettings(settings)
+			return err
+		},
+	})
+}
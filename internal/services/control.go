@@ -0,0 +1,126 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+const (
+	maxStreamSections = 200
+	// maxRequestLabels bounds the set-label controls remembered at once;
+	// the oldest request's label is forgotten first.
+	maxRequestLabels = 1024
+)
+
+// StreamSection marks where a PHP client started a new section of the event
+// stream, so the UI can draw a divider with its title.
+type StreamSection struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	ProjectRoot string `json:"projectRoot,omitempty"`
+	StartedAt   string `json:"startedAt"`
+}
+
+// ingestControl applies a control line from a live source. Invalid controls
+// are quarantined like invalid events.
+func (r *collectorRuntime) ingestControl(line string) {
+	control, issues := dump.DecodeControlLine(line)
+	if control == nil {
+		entry := QuarantinedEvent{
+			ReceivedAt: time.Now().UTC().Format(time.RFC3339Nano),
+			Line:       line,
+			Issues:     issues,
+		}
+		r.redactQuarantined(&entry)
+		r.quarantine(entry)
+		return
+	}
+	r.applyControl(*control)
+}
+
+// applyControl acts on a control when it arrives. Events the client sent
+// just before it may still be queued for ingestion, so a clear-screen can
+// miss the last of them.
+func (r *collectorRuntime) applyControl(control dump.Control) {
+	switch control.Action {
+	case dump.ControlClearScreen:
+		_, _ = r.clearDumpEvents(query.Filter{ProjectRoot: control.ProjectRoot})
+	case dump.ControlNewSection:
+		r.startSection(control.Title, control.ProjectRoot, control.Timestamp)
+	case dump.ControlSetLabel:
+		r.labelRequest(control.RequestID, control.Label)
+	case dump.ControlPause:
+		if control.Paused != nil && !*control.Paused {
+			r.resumeStream()
+		} else {
+			r.pauseStream()
+		}
+	}
+}
+
+// startSection records a section in timestamp order; sections imported
+// from a session export may predate the live ones.
+func (r *collectorRuntime) startSection(title string, projectRoot string, startedAt string) {
+	r.controlMu.Lock()
+	r.sectionSeq++
+	section := StreamSection{
+		ID:          "section-" + strconv.FormatUint(r.sectionSeq, 10),
+		Title:       title,
+		ProjectRoot: projectRoot,
+		StartedAt:   startedAt,
+	}
+	r.sections = append(r.sections, section)
+	sort.SliceStable(r.sections, func(i, j int) bool {
+		a, _ := time.Parse(time.RFC3339Nano, r.sections[i].StartedAt)
+		b, _ := time.Parse(time.RFC3339Nano, r.sections[j].StartedAt)
+		return a.Before(b)
+	})
+	if len(r.sections) > maxStreamSections {
+		r.sections = r.sections[len(r.sections)-maxStreamSections:]
+	}
+	r.controlMu.Unlock()
+
+	if r.app != nil {
+		r.app.Event.Emit(StreamSectionRuntimeChannel, section)
+	}
+}
+
+func (r *collectorRuntime) streamSections() []StreamSection {
+	r.controlMu.Lock()
+	defer r.controlMu.Unlock()
+	return append([]StreamSection{}, r.sections...)
+}
+
+func (r *collectorRuntime) labelRequest(requestID string, label string) {
+	r.controlMu.Lock()
+	defer r.controlMu.Unlock()
+
+	if r.requestLabels == nil {
+		r.requestLabels = map[string]string{}
+	}
+	if _, ok := r.requestLabels[requestID]; !ok {
+		r.labelOrder = append(r.labelOrder, requestID)
+	}
+	r.requestLabels[requestID] = label
+	if len(r.labelOrder) > maxRequestLabels {
+		delete(r.requestLabels, r.labelOrder[0])
+		r.labelOrder = r.labelOrder[1:]
+	}
+}
+
+// applyRequestLabel gives an unlabeled event the label a set-label control
+// chose for its request.
+func (r *collectorRuntime) applyRequestLabel(event *dump.Event) {
+	if event.Label != "" || event.RequestID == nil || *event.RequestID == "" {
+		return
+	}
+	r.controlMu.Lock()
+	defer r.controlMu.Unlock()
+	if label, ok := r.requestLabels[*event.RequestID]; ok {
+		event.Label = label
+	}
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"phant/internal/conversation"
+	"phant/internal/dump"
+)
+
+// conversations groups the buffered REPL dumps, optionally of one project.
+func (r *collectorRuntime) conversations(projectRoot string) []conversation.Conversation {
+	if r.collector == nil {
+		return []conversation.Conversation{}
+	}
+	events := r.collector.Select(func(event dump.Event) bool {
+		return (projectRoot == "" || event.ProjectRoot == projectRoot) && conversation.IsREPL(event)
+	})
+	return conversation.Group(events, conversation.DefaultGap)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"time"
+
+	"phant/internal/dashboard"
+	"phant/internal/dump"
+)
+
+// dashboardStore opens the dashboards kept next to the session logs on
+// first use.
+func (r *collectorRuntime) dashboardStore() (*dashboard.Store, error) {
+	r.dashboardsMu.Lock()
+	defer r.dashboardsMu.Unlock()
+
+	if r.dashboards == nil {
+		store, err := dashboard.Open(r.storeDir)
+		if err != nil {
+			return nil, err
+		}
+		r.dashboards = store
+	}
+	return r.dashboards, nil
+}
+
+func (r *collectorRuntime) mergeDashboards(dashboards []dashboard.Dashboard) ([]dashboard.Dashboard, error) {
+	store, err := r.dashboardStore()
+	if err != nil {
+		return nil, err
+	}
+	saved, err := store.Merge(dashboards)
+	if err != nil {
+		return nil, err
+	}
+	r.emitDashboardsChanged(store)
+	return saved, nil
+}
+
+func (r *collectorRuntime) deleteDashboard(name string) error {
+	store, err := r.dashboardStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(name); err != nil {
+		return err
+	}
+	r.emitDashboardsChanged(store)
+	return nil
+}
+
+// evaluateDashboard computes a saved dashboard over the buffered events.
+func (r *collectorRuntime) evaluateDashboard(name string) (dashboard.Result, error) {
+	store, err := r.dashboardStore()
+	if err != nil {
+		return dashboard.Result{}, err
+	}
+	saved, ok := store.Get(name)
+	if !ok {
+		return dashboard.Result{}, ErrDashboardNotFound
+	}
+
+	var events []dump.Event
+	if r.collector != nil {
+		events = r.collector.Events()
+	}
+	return dashboard.Evaluate(saved, events, r.compileFilter, time.Now())
+}
+
+func (r *collectorRuntime) emitDashboardsChanged(store *dashboard.Store) {
+	if r.app != nil {
+		r.app.Event.Emit(DashboardsChangedRuntimeChannel, store.List())
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"net"
+	"os"
+	"strconv"
+
+	"phant/internal/discovery"
+	"phant/internal/dump"
+)
+
+// DiscoverySettings toggles advertising the network listeners over mDNS.
+type DiscoverySettings struct {
+	Enabled bool `json:"enabled"`
+}
+
+// DiscoveryStatus lists what is currently advertised. Listeners bound to a
+// loopback address are never advertised: no other machine could reach them.
+type DiscoveryStatus struct {
+	Enabled   bool                `json:"enabled"`
+	Running   bool                `json:"running"`
+	Services  []discovery.Service `json:"services"`
+	LastError string              `json:"lastError,omitempty"`
+}
+
+func (r *collectorRuntime) setDiscovery(settings DiscoverySettings) (DiscoveryStatus, error) {
+	r.discoveryMu.Lock()
+	r.discoveryOn = settings.Enabled
+	r.discoveryMu.Unlock()
+	return r.discoveryStatus(), r.refreshDiscovery()
+}
+
+// refreshDiscovery re-advertises the running listeners. It is called
+// whenever one of them starts, stops, moves, or changes its auth or TLS
+// settings, since all of those show in the records.
+func (r *collectorRuntime) refreshDiscovery() error {
+	r.discoveryMu.Lock()
+	defer r.discoveryMu.Unlock()
+
+	if r.advertiser != nil {
+		_ = r.advertiser.Stop()
+		r.advertiser = nil
+	}
+	r.advertised = nil
+	r.advertiseErr = ""
+	if !r.discoveryOn || !r.collectorRunning() {
+		return nil
+	}
+
+	services := r.discoverableListeners()
+	if len(services) == 0 {
+		return nil
+	}
+	hostname, _ := os.Hostname()
+	advertiser, err := discovery.New(hostname, services)
+	if err == nil {
+		err = advertiser.Start()
+	}
+	if err != nil {
+		r.advertiseErr = err.Error()
+		return err
+	}
+	r.advertiser = advertiser
+	r.advertised = services
+	return nil
+}
+
+// discoverableListeners describes each running listener reachable from the
+// network. The TXT record carries what a client needs before connecting:
+// the schema version it should send, whether it needs the shared token,
+// and the certificate fingerprint to pin when TLS is on.
+func (r *collectorRuntime) discoverableListeners() []discovery.Service {
+	hostname, _ := os.Hostname()
+	auth := "none"
+	if r.auth.Enabled() {
+		auth = "token"
+	}
+	tlsStatus := r.listenerTLSStatus()
+
+	varDumper, ray, logs := r.varDumperStatus(), r.rayStatus(), r.logIngestStatus()
+	listeners := []struct {
+		protocol string
+		address  string
+		running  bool
+	}{
+		{"var-dumper", varDumper.Address, varDumper.Running},
+		{"ray", ray.Address, ray.Running},
+		{"monolog", logs.Address, logs.Running},
+	}
+
+	var services []discovery.Service
+	for _, listener := range listeners {
+		if !listener.running {
+			continue
+		}
+		host, portText, err := net.SplitHostPort(listener.address)
+		if err != nil {
+			continue
+		}
+		if ip := net.ParseIP(host); host == "localhost" || ip != nil && ip.IsLoopback() {
+			continue
+		}
+		port, err := strconv.Atoi(portText)
+		if err != nil {
+			continue
+		}
+
+		txt := map[string]string{
+			"schema":   strconv.Itoa(dump.SchemaVersion),
+			"protocol": listener.protocol,
+			"auth":     auth,
+			"tls":      strconv.FormatBool(tlsStatus.Fingerprint != ""),
+		}
+		if tlsStatus.Fingerprint != "" {
+			txt["fingerprint"] = tlsStatus.Fingerprint
+		}
+		services = append(services, discovery.Service{
+			Instance: "phant " + listener.protocol + " on " + hostname,
+			Port:     port,
+			TXT:      txt,
+		})
+	}
+	return services
+}
+
+func (r *collectorRuntime) discoveryStatus() DiscoveryStatus {
+	r.discoveryMu.Lock()
+	defer r.discoveryMu.Unlock()
+
+	services := r.advertised
+	if services == nil {
+		services = []discovery.Service{}
+	}
+	return DiscoveryStatus{
+		Enabled:   r.discoveryOn,
+		Running:   r.advertiser != nil,
+		Services:  services,
+		LastError: r.advertiseErr,
+	}
+}
@@ -1,6 +1,46 @@
 package services
 
-import "phant/internal/dump"
+import (
+	"encoding/json"
+	"errors"
+
+	"phant/internal/alert"
+	"phant/internal/annotation"
+	"phant/internal/archive"
+	"phant/internal/collector"
+	"phant/internal/conversation"
+	"phant/internal/dashboard"
+	"phant/internal/ddgate"
+	"phant/internal/deeplink"
+	"phant/internal/dump"
+	"phant/internal/export"
+	"phant/internal/forward"
+	"phant/internal/health"
+	"phant/internal/hook"
+	"phant/internal/ignore"
+	"phant/internal/jsonpath"
+	"phant/internal/livegrep"
+	"phant/internal/measure"
+	"phant/internal/nettls"
+	"phant/internal/otlp"
+	"phant/internal/pipeline"
+	"phant/internal/preview"
+	"phant/internal/proctree"
+	"phant/internal/query"
+	"phant/internal/recording"
+	"phant/internal/replay"
+	"phant/internal/retention"
+	"phant/internal/savedfilter"
+	"phant/internal/schemacheck"
+	"phant/internal/search"
+	"phant/internal/signature"
+	"phant/internal/source"
+	"phant/internal/store"
+	"phant/internal/storm"
+	"phant/internal/tail"
+	"phant/internal/testcase"
+	"phant/internal/trash"
+)
 
 type DumpService struct {
 	runtime *collectorRuntime
@@ -11,17 +51,1110 @@ func (s *DumpService) SupportedDumpEventSchemaVersion() int {
 }
 
 func (s *DumpService) DecodeDumpEventNDJSONLine(line string) (*dump.Event, error) {
-	return dump.DecodeNDJSONLine(line)
+	return s.runtime.decodeLine(line)
+}
+
+func (s *DumpService) DecodeDumpEventNDJSONLineLenient(line string) (*dump.Event, []dump.ValidationIssue) {
+	return dump.DecodeNDJSONLineLenientWithOptions(line, s.runtime.getDecodeOptions())
+}
+
+// ValidateCorpusAgainst re-validates an NDJSON file or store directory as
+// if every event declared schemaVersion version, and reports the events
+// that are valid today but would be rejected.
+func (s *DumpService) ValidateCorpusAgainst(version int, path string) (schemacheck.Report, error) {
+	return schemacheck.ValidateCorpusAgainst(version, path)
+}
+
+func (s *DumpService) GetQuarantinedEvents() []QuarantinedEvent {
+	return s.runtime.quarantinedEvents()
+}
+
+func (s *DumpService) ClearQuarantine() {
+	s.runtime.clearQuarantine()
+}
+
+func (s *DumpService) GetDecodeOptions() dump.DecodeOptions {
+	return s.runtime.getDecodeOptions()
+}
+
+func (s *DumpService) SetLenientTimestamps(enabled bool) dump.DecodeOptions {
+	options := s.runtime.getDecodeOptions()
+	options.LenientTimestamps = enabled
+	s.runtime.setDecodeOptions(options)
+	return options
+}
+
+// SetRetainRawLines keeps each ingested line on its event so it can be
+// re-decoded with RedecodeEvents after decoder fixes.
+func (s *DumpService) SetRetainRawLines(enabled bool) dump.DecodeOptions {
+	options := s.runtime.getDecodeOptions()
+	options.RetainRawLines = enabled
+	s.runtime.setDecodeOptions(options)
+	return options
+}
+
+func (s *DumpService) RedecodeEvents(filter query.Filter) (RedecodeResult, error) {
+	return s.runtime.redecodeEvents(filter)
+}
+
+func (s *DumpService) SetMaxTraceFrames(limit int) (dump.DecodeOptions, error) {
+	if limit < 0 {
+		return s.runtime.getDecodeOptions(), errors.New("max trace frames must not be negative")
+	}
+
+	options := s.runtime.getDecodeOptions()
+	options.MaxTraceFrames = limit
+	s.runtime.setDecodeOptions(options)
+	return options, nil
+}
+
+func (s *DumpService) SetMaxPayloadBytes(limit int) (dump.DecodeOptions, error) {
+	if limit < 0 {
+		return s.runtime.getDecodeOptions(), errors.New("max payload bytes must not be negative")
+	}
+
+	options := s.runtime.getDecodeOptions()
+	options.MaxPayloadBytes = limit
+	s.runtime.setDecodeOptions(options)
+	return options, nil
+}
+
+func (s *DumpService) GetFullPayload(eventID string) (json.RawMessage, error) {
+	return s.runtime.fullPayload(eventID)
+}
+
+// GetDumpEventPayload returns an event's payload in full, decompressing it
+// from disk when the event in memory only holds a preview (offloaded or
+// truncated).
+func (s *DumpService) GetDumpEventPayload(eventID string) (json.RawMessage, error) {
+	return s.runtime.fullPayload(eventID)
+}
+
+func (s *DumpService) GetPayloadStorageSettings() PayloadStorageSettings {
+	return s.runtime.getPayloadStorage()
+}
+
+// SetPayloadStorageSettings changes which payloads are kept out of memory.
+// It applies to events that arrive afterwards.
+func (s *DumpService) SetPayloadStorageSettings(settings PayloadStorageSettings) error {
+	return s.runtime.setPayloadStorage(settings)
+}
+
+// GetHighlightedPayload returns the full payload with the language and
+// token ranges of each string value that holds SQL, HTML, XML, PHP, or
+// markdown, so the UI can color code without detecting it itself.
+func (s *DumpService) GetHighlightedPayload(eventID string) (HighlightedPayload, error) {
+	return s.runtime.highlightedPayload(eventID)
+}
+
+// GetBinaryPayload describes the image, PDF, or other file dumped with a
+// binary payload. Its data streams from the returned URL, which the viewer
+// can use as an img or iframe source.
+func (s *DumpService) GetBinaryPayload(eventID string) (BinaryPayload, error) {
+	return s.runtime.binaryPayload(eventID)
+}
+
+// ExpandPayload returns the full payload with every string value that holds
+// a JSON object or array replaced by it, so the viewer can render API
+// responses and other encoded documents as trees. Expansions lists the
+// JSONPath of each replaced value.
+func (s *DumpService) ExpandPayload(eventID string) (ExpandedPayload, error) {
+	return s.runtime.expandedPayload(eventID)
+}
+
+// QueryPayload evaluates a JSONPath expression against an event's full
+// payload and returns only the matching fragment.
+func (s *DumpService) QueryPayload(eventID string, expression string) (json.RawMessage, error) {
+	payload, err := s.runtime.fullPayload(eventID)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpath.Query(payload, expression)
+}
+
+// DiffDumpEvents compares the full payloads of two events, typically the
+// same variable dumped at two points. Change paths are JSONPath
+// expressions accepted by QueryPayload.
+func (s *DumpService) DiffDumpEvents(idA string, idB string) ([]jsonpath.Change, error) {
+	return s.runtime.diffPayloads(idA, idB)
 }
 
 func (s *DumpService) GetCollectorStatus() CollectorStatus {
 	return s.runtime.getCollectorStatus()
 }
 
+// GetIngestStats reports received, stored, and dropped event counts for
+// the status bar, with the per-shard queues behind them.
+func (s *DumpService) GetIngestStats() IngestStats {
+	return s.runtime.ingestStats()
+}
+
+func (s *DumpService) GetDedupSettings() DedupSettings {
+	return s.runtime.getDedup()
+}
+
+// SetDedupSettings collapses a dump that repeats the previous event (same
+// callsite, label, and payload) within WindowMs of its last occurrence into
+// that event, bumping its repeatCount. The updated event is pushed again
+// under its original ID.
+func (s *DumpService) SetDedupSettings(settings DedupSettings) error {
+	return s.runtime.setDedup(settings)
+}
+
+func (s *DumpService) GetClockSettings() ClockSettings {
+	return s.runtime.getClock()
+}
+
+// SetClockSettings turns recording of receive times on or off. With them
+// recorded, set timeBasis to "received" on a filter to order and filter by
+// receive time instead of the producer's possibly skewed clock.
+func (s *DumpService) SetClockSettings(settings ClockSettings) error {
+	return s.runtime.setClock(settings)
+}
+
+func (s *DumpService) GetStoreStats() store.Stats {
+	return s.runtime.storeStats()
+}
+
+func (s *DumpService) ListSessions() ([]SessionInfo, error) {
+	return s.runtime.listSessions()
+}
+
+func (s *DumpService) GetSessionSummary(name string) (store.Summary, error) {
+	return s.runtime.sessionSummary(name)
+}
+
+func (s *DumpService) BackupSessions(dest string) (store.BackupResult, error) {
+	return s.runtime.backupSessions(dest)
+}
+
+// VerifyStore checks the session store, and with repair set fixes what it
+// can.
+func (s *DumpService) VerifyStore(repair bool) (StoreReport, error) {
+	return s.runtime.verifyStore(repair)
+}
+
+func (s *DumpService) GetHealth() HealthStatus {
+	return s.runtime.healthStatus()
+}
+
+func (s *DumpService) SetHealthThresholds(thresholds health.Thresholds) HealthStatus {
+	s.runtime.setHealthThresholds(thresholds)
+	return s.runtime.healthStatus()
+}
+
+func (s *DumpService) HealthChangedChannelName() string {
+	return HealthChangedRuntimeChannel
+}
+
+func (s *DumpService) GetPayloadInternStats() collector.InternStats {
+	if s.runtime.collector == nil {
+		return collector.InternStats{}
+	}
+	return s.runtime.collector.InternStats()
+}
+
 func (s *DumpService) GetRecentEvents(limit int) []dump.Event {
-	return s.runtime.getRecentEvents(limit)
+	return s.runtime.withPreviews(s.runtime.getRecentEvents(limit))
+}
+
+func (s *DumpService) QueryDumpEvents(filter query.Filter, page query.Page) (query.Result, error) {
+	result, err := s.runtime.queryEvents(filter, page)
+	result.Events = s.runtime.withPreviews(result.Events)
+	return result, err
+}
+
+// GetEventPage returns a page of event summaries, newest first, without
+// payloads, so a virtualized list can page through large buffers; the
+// payload of an opened event comes from GetDumpEventPayload. Pass the
+// returned Next as cursor for the following page, or "" for the newest.
+// Pages stay in place when events arrive meanwhile.
+func (s *DumpService) GetEventPage(cursor string, limit int, filter query.Filter) (query.CursorPage, error) {
+	return s.runtime.eventPage(cursor, limit, filter)
+}
+
+// QueryProjectEvents is QueryDumpEvents limited to one project root.
+func (s *DumpService) QueryProjectEvents(projectRoot string, filter query.Filter, page query.Page) (query.Result, error) {
+	result, err := s.runtime.queryProjectEvents(projectRoot, filter, page)
+	result.Events = s.runtime.withPreviews(result.Events)
+	return result, err
+}
+
+// BrowseDumpEvents is QueryDumpEvents with facets: counts of every match
+// by source type, HTTP status class, project, level, and tag, so the
+// explorer sidebar stays in step with the page from one call.
+func (s *DumpService) BrowseDumpEvents(filter query.Filter, page query.Page) (query.Browse, error) {
+	browse, err := s.runtime.browseEvents(filter, page)
+	browse.Events = s.runtime.withPreviews(browse.Events)
+	return browse, err
+}
+
+// GetDumpFacets returns only the facets of the events filter selects.
+func (s *DumpService) GetDumpFacets(filter query.Filter) (query.Facets, error) {
+	return s.runtime.dumpFacets(filter)
+}
+
+// GetPreviewOptions returns the depth, item, and length limits of the
+// payload previews attached to events sent to the UI.
+func (s *DumpService) GetPreviewOptions() preview.Options {
+	return s.runtime.previews.Options()
+}
+
+// SetPreviewOptions changes the preview limits; previews are rendered again
+// with the new limits as events are next sent.
+func (s *DumpService) SetPreviewOptions(options preview.Options) error {
+	return s.runtime.setPreviewOptions(options)
+}
+
+// GetDumpStats aggregates the buffered events in timeRange for the
+// dashboard, so charts do not have to page through every event.
+func (s *DumpService) GetDumpStats(timeRange query.TimeRange) (query.Stats, error) {
+	return s.runtime.dumpStats(timeRange)
+}
+
+// GetConversations groups dumps from tinker, psysh, and other REPL sessions
+// into input/output turns, newest conversation first. An empty projectRoot
+// covers every project.
+func (s *DumpService) GetConversations(projectRoot string) []conversation.Conversation {
+	return s.runtime.conversations(projectRoot)
+}
+
+// GetDumpStorms lists bursts of at least 100 events from one callsite, each
+// following the previous within a second, newest first, with samples and
+// one payload per shape. An empty projectRoot covers every project.
+func (s *DumpService) GetDumpStorms(projectRoot string) []storm.Storm {
+	return s.runtime.storms(projectRoot)
+}
+
+// ExpandDumpStorm pages through every event of the storm with the given
+// ID, newest first.
+func (s *DumpService) ExpandDumpStorm(id string, page query.Page) (query.Result, error) {
+	result, err := s.runtime.expandStorm(id, page)
+	result.Events = s.runtime.withPreviews(result.Events)
+	return result, err
+}
+
+// StartCapture records the raw bytes every ingestion listener receives from
+// new connections into a ring of files next to the session logs, keeping
+// at most maxBytes (64 MiB when zero). It is a debugging aid for protocol
+// bugs and slows ingestion down.
+func (s *DumpService) StartCapture(maxBytes int64) (CaptureStatus, error) {
+	return s.runtime.startCapture(maxBytes)
+}
+
+// StopCapture stops recording; the capture stays on disk for export or
+// replay.
+func (s *DumpService) StopCapture() (CaptureStatus, error) {
+	return s.runtime.stopCapture()
+}
+
+func (s *DumpService) GetCaptureStatus() CaptureStatus {
+	return s.runtime.captureStatus()
+}
+
+// ExportCapture writes the capture on disk into one file, for attaching to
+// a bug report.
+func (s *DumpService) ExportCapture(path string) error {
+	return s.runtime.exportCapture(path)
+}
+
+// ReplayCapture feeds a capture file, or the capture on disk when path is
+// empty, through the listeners it was recorded on.
+func (s *DumpService) ReplayCapture(path string) (ReplayResult, error) {
+	return s.runtime.replayCapture(path)
+}
+
+// StartRecording records every event received from now on, with its
+// timing, into a session called name until StopRecording.
+func (s *DumpService) StartRecording(name string) (RecordingStatus, error) {
+	return s.runtime.startRecording(name)
+}
+
+func (s *DumpService) StopRecording() (RecordingStatus, error) {
+	return s.runtime.stopRecording()
+}
+
+func (s *DumpService) GetRecordingStatus() RecordingStatus {
+	return s.runtime.recordingStatus()
+}
+
+// ListRecordings lists the recorded sessions, newest first.
+func (s *DumpService) ListRecordings() ([]recording.Info, error) {
+	return s.runtime.listRecordings()
+}
+
+func (s *DumpService) DeleteRecording(name string) error {
+	return s.runtime.deleteRecording(name)
+}
+
+// ReplaySession re-emits a recorded session on the replay event channel
+// with its original relative timing, speed times faster (1 for real time,
+// at most 100). It returns once the replay starts and replaces any replay
+// already running.
+func (s *DumpService) ReplaySession(name string, speed float64) (recording.Info, error) {
+	return s.runtime.replaySession(name, speed)
+}
+
+func (s *DumpService) StopSessionReplay() {
+	s.runtime.stopSessionReplay()
+}
+
+// GetTestCases groups dumps sent during Pest or PHPUnit runs by test case,
+// in the order the tests started, marking cases that reported errors.
+func (s *DumpService) GetTestCases(projectRoot string) []testcase.Case {
+	return s.runtime.testCases(projectRoot)
+}
+
+// GetFormattedQuery returns the database query of an event with its
+// bindings filled in and its clauses laid out on lines of their own.
+func (s *DumpService) GetFormattedQuery(eventID string) (FormattedQuery, error) {
+	return s.runtime.formattedQuery(eventID)
+}
+
+// ReplayRequest re-issues the HTTP request an event was dumped from, with
+// overrides applied, and returns the response and how long it took.
+func (s *DumpService) ReplayRequest(eventID string, overrides replay.Overrides) (replay.Response, error) {
+	return s.runtime.replayRequest(eventID, overrides)
+}
+
+// GetMeasures returns the timed blocks of a request, pairing its
+// measure-start and measure-stop events into wall time and memory deltas.
+// An empty requestID covers every request.
+func (s *DumpService) GetMeasures(requestID string) []measure.Measure {
+	return s.runtime.measures(requestID)
+}
+
+// GetProcessTree nests command processes under the process that spawned
+// them, per host, so output of artisan calling artisan or of forked queue
+// workers reads together. An empty hostname covers every host.
+func (s *DumpService) GetProcessTree(hostname string) []proctree.Host {
+	return s.runtime.processTree(hostname)
+}
+
+// PauseStream freezes the live feed. Events are still collected, buffered,
+// and stored; up to pipeline.DefaultGateLimit of them are held for delivery
+// on resume and the rest can be fetched with QueryDumpEvents.
+func (s *DumpService) PauseStream() pipeline.GateStatus {
+	return s.runtime.pauseStream()
+}
+
+// ResumeStream delivers the held events and reports how many arrived while
+// paused and how many were not held.
+func (s *DumpService) ResumeStream() pipeline.GateStatus {
+	return s.runtime.resumeStream()
+}
+
+func (s *DumpService) GetStreamPauseStatus() pipeline.GateStatus {
+	return s.runtime.liveGate.Status()
+}
+
+// StreamPauseChangedChannelName is where the stream's pause status is sent
+// whenever it changes, including from the tray menu.
+func (s *DumpService) StreamPauseChangedChannelName() string {
+	return StreamPauseChangedRuntimeChannel
+}
+
+func (s *DumpService) ProjectsChangedChannelName() string {
+	return ProjectsChangedRuntimeChannel
+}
+
+func (s *DumpService) HostsChangedChannelName() string {
+	return HostsChangedRuntimeChannel
+}
+
+// ListPendingGates lists processes waiting at a gated dd(), oldest first.
+func (s *DumpService) ListPendingGates() []ddgate.Gate {
+	return s.runtime.gates.Pending()
+}
+
+// ReleaseGates lets the given processes go. action is exit, continue, or
+// empty for each gate's policy action.
+func (s *DumpService) ReleaseGates(ids []string, action string) (int, error) {
+	return s.runtime.gates.Release(ids, action)
+}
+
+// ReleaseProjectGates releases every gate of a project, or all of them when
+// projectRoot is empty.
+func (s *DumpService) ReleaseProjectGates(projectRoot string, action string) (int, error) {
+	return s.runtime.gates.ReleaseProject(projectRoot, action)
+}
+
+func (s *DumpService) GetGateSettings() ddgate.Settings {
+	return s.runtime.gates.Settings()
+}
+
+func (s *DumpService) SetGateSettings(settings ddgate.Settings) error {
+	return s.runtime.gates.SetSettings(settings)
+}
+
+func (s *DumpService) GatesChangedChannelName() string {
+	return GatesChangedRuntimeChannel
+}
+
+// ListAnnotations returns every pinned, noted, or tagged event's
+// annotation, most recently updated first.
+func (s *DumpService) ListAnnotations() ([]annotation.Annotation, error) {
+	set, err := s.runtime.annotationSet()
+	if err != nil {
+		return nil, err
+	}
+	return set.All(), nil
+}
+
+// PinEvent pins or unpins an event. Pinned events are never pruned by
+// retention; query them with the pinned filter.
+func (s *DumpService) PinEvent(eventID string, pinned bool) (annotation.Annotation, error) {
+	return s.runtime.annotate(func(set *annotation.Set) (annotation.Annotation, error) {
+		return set.Pin(eventID, pinned)
+	})
+}
+
+// SetEventNote attaches free text to an event; an empty note removes it.
+func (s *DumpService) SetEventNote(eventID string, note string) (annotation.Annotation, error) {
+	return s.runtime.annotate(func(set *annotation.Set) (annotation.Annotation, error) {
+		return set.SetNote(eventID, note)
+	})
+}
+
+// SetEventTags replaces an event's tags.
+func (s *DumpService) SetEventTags(eventID string, tags []string) (annotation.Annotation, error) {
+	return s.runtime.annotate(func(set *annotation.Set) (annotation.Annotation, error) {
+		return set.SetTags(eventID, tags)
+	})
+}
+
+func (s *DumpService) DeleteAnnotation(eventID string) error {
+	set, err := s.runtime.annotationSet()
+	if err != nil {
+		return err
+	}
+	if err := set.Delete(eventID); err != nil {
+		return err
+	}
+	s.runtime.emitAnnotationChanged(annotation.Annotation{EventID: eventID})
+	return nil
+}
+
+// ListTags counts events per tag, most used first.
+func (s *DumpService) ListTags() ([]annotation.TagCount, error) {
+	set, err := s.runtime.annotationSet()
+	if err != nil {
+		return nil, err
+	}
+	return set.Tags(), nil
+}
+
+func (s *DumpService) AnnotationsChangedChannelName() string {
+	return AnnotationsChangedRuntimeChannel
+}
+
+// SaveFilter stores a named filter and search query, replacing any preset
+// with the same name.
+func (s *DumpService) SaveFilter(preset savedfilter.Preset) (savedfilter.Preset, error) {
+	saved, err := s.runtime.mergeFilters([]savedfilter.Preset{preset})
+	if err != nil {
+		return savedfilter.Preset{}, err
+	}
+	return saved[0], nil
+}
+
+// ListFilters returns the saved filters sorted by name.
+func (s *DumpService) ListFilters() ([]savedfilter.Preset, error) {
+	store, err := s.runtime.filterStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.List(), nil
+}
+
+func (s *DumpService) DeleteFilter(name string) error {
+	store, err := s.runtime.filterStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(name); err != nil {
+		return err
+	}
+	s.runtime.emitFiltersChanged(store)
+	return nil
+}
+
+// ExportFilters writes the named filters, or all of them when names is
+// empty, to a file teammates can import.
+func (s *DumpService) ExportFilters(path string, names []string) (savedfilter.Document, error) {
+	store, err := s.runtime.filterStore()
+	if err != nil {
+		return savedfilter.Document{}, err
+	}
+	return store.Export(path, names)
+}
+
+// ImportFilters adds the filters of a shared file, replacing saved filters
+// with the same name.
+func (s *DumpService) ImportFilters(path string) ([]savedfilter.Preset, error) {
+	store, err := s.runtime.filterStore()
+	if err != nil {
+		return nil, err
+	}
+	imported, err := store.Import(path)
+	if err != nil {
+		return nil, err
+	}
+	s.runtime.emitFiltersChanged(store)
+	return imported, nil
+}
+
+func (s *DumpService) FiltersChangedChannelName() string {
+	return FiltersChangedRuntimeChannel
+}
+
+// SaveDashboard stores a named set of aggregation, histogram, and watch
+// panels, replacing any dashboard with the same name.
+func (s *DumpService) SaveDashboard(saved dashboard.Dashboard) (dashboard.Dashboard, error) {
+	dashboards, err := s.runtime.mergeDashboards([]dashboard.Dashboard{saved})
+	if err != nil {
+		return dashboard.Dashboard{}, err
+	}
+	return dashboards[0], nil
+}
+
+// ListDashboards returns the saved dashboards sorted by name.
+func (s *DumpService) ListDashboards() ([]dashboard.Dashboard, error) {
+	store, err := s.runtime.dashboardStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.List(), nil
+}
+
+func (s *DumpService) DeleteDashboard(name string) error {
+	return s.runtime.deleteDashboard(name)
+}
+
+// EvaluateDashboard computes every panel of a saved dashboard over the
+// buffered events. Relative times in its filters are resolved now, so
+// calling it again refreshes the dashboard.
+func (s *DumpService) EvaluateDashboard(name string) (dashboard.Result, error) {
+	return s.runtime.evaluateDashboard(name)
+}
+
+func (s *DumpService) DashboardsChangedChannelName() string {
+	return DashboardsChangedRuntimeChannel
+}
+
+// SaveAlertRule creates an alert rule, or updates the one with the same ID.
+// A rule raises an alert for matching events, bursts of them, or slow
+// queries, and notifies, calls a webhook, or tags the event.
+func (s *DumpService) SaveAlertRule(rule alert.Rule) (alert.Rule, error) {
+	rules, err := s.runtime.mergeAlertRules([]alert.Rule{rule})
+	if err != nil {
+		return alert.Rule{}, err
+	}
+	return rules[0], nil
+}
+
+// ListAlertRules returns the alert rules sorted by name.
+func (s *DumpService) ListAlertRules() ([]alert.Rule, error) {
+	engine, err := s.runtime.alertEngine()
+	if err != nil {
+		return nil, err
+	}
+	return engine.Rules(), nil
+}
+
+func (s *DumpService) DeleteAlertRule(id string) error {
+	engine, err := s.runtime.alertEngine()
+	if err != nil {
+		return err
+	}
+	return engine.Delete(id)
+}
+
+// GetAlertHistory returns recent alerts with the outcome of each action,
+// newest first, for one rule or, with an empty ruleID, for all of them.
+func (s *DumpService) GetAlertHistory(ruleID string) ([]alert.Alert, error) {
+	return s.runtime.alertHistory(ruleID)
+}
+
+func (s *DumpService) ClearAlertHistory() error {
+	engine, err := s.runtime.alertEngine()
+	if err != nil {
+		return err
+	}
+	engine.ClearHistory()
+	return nil
+}
+
+// AlertChannelName is where each alert is pushed once its actions ran.
+func (s *DumpService) AlertChannelName() string {
+	return AlertRuntimeChannel
+}
+
+func (s *DumpService) GetRequestTimeline(requestID string) []dump.Event {
+	return s.runtime.getRequestTimeline(requestID)
 }
 
 func (s *DumpService) DumpEventChannelName() string {
 	return DumpEventRuntimeChannel
 }
+
+func (s *DumpService) GetBridgeStats() pipeline.BatchStats {
+	if s.runtime.bridge == nil {
+		return pipeline.BatchStats{}
+	}
+	return s.runtime.bridge.Stats()
+}
+
+// GetPipelineStats reports per-stage ingest latencies (received → decoded →
+// stored → pushed to the UI) alongside shard and bridge stats.
+func (s *DumpService) GetPipelineStats() PipelineStats {
+	return s.runtime.pipelineStats()
+}
+
+// GetEventTimings returns when a recent event reached each pipeline stage.
+func (s *DumpService) GetEventTimings(eventID string) (pipeline.EventTimings, error) {
+	return s.runtime.eventTimings(eventID)
+}
+
+func (s *DumpService) GetPipelineTuning() PipelineTuningStatus {
+	return s.runtime.pipelineTuningStatus()
+}
+
+// SetPipelineTuning sets worker pool sizes and queue depths; zero fields use
+// CPU-derived defaults. Pools are sized at startup, so changes apply the next
+// time phant starts.
+func (s *DumpService) SetPipelineTuning(tuning PipelineTuning) (PipelineTuningStatus, error) {
+	return s.runtime.setPipelineTuning(tuning)
+}
+
+func (s *DumpService) GetQueueOccupancy() []QueueOccupancy {
+	return s.runtime.queueOccupancy()
+}
+
+func (s *DumpService) SubscribeToDumpStream(bufferSize int) (DumpStreamSubscription, error) {
+	return s.runtime.subscribeDumpStream(bufferSize)
+}
+
+// Subscribe returns the latest limit events matching filter and a channel
+// that then streams further matches, with no gap or overlap between the two.
+// End it with UnsubscribeFromDumpStream.
+func (s *DumpService) Subscribe(filter query.Filter, limit int) (FilteredSubscription, error) {
+	return s.runtime.subscribeFiltered(filter, limit)
+}
+
+// SubscribeLiveGrep streams incoming events whose text rendering (header,
+// payload, trace) has a line matching pattern. Its channel carries
+// livegrep.Match values; end it with UnsubscribeFromDumpStream.
+func (s *DumpService) SubscribeLiveGrep(pattern livegrep.Pattern) (DumpStreamSubscription, error) {
+	return s.runtime.subscribeLiveGrep(pattern)
+}
+
+func (s *DumpService) UnsubscribeFromDumpStream(id int) {
+	s.runtime.unsubscribeDumpStream(id)
+}
+
+func (s *DumpService) GetDumpStreamStats(id int) (pipeline.SubscriberStats, bool) {
+	return s.runtime.dumpStreamStats(id)
+}
+
+// ImportDumpEventsFromFile imports an NDJSON dump file, decoding it in
+// parallel and reporting progress on ImportProgressRuntimeChannel.
+func (s *DumpService) ImportDumpEventsFromFile(path string) (DumpImportResult, error) {
+	return s.runtime.importDumpFile(path)
+}
+
+// ImportLaravelLog imports a laravel.log as log events, with stack traces
+// and exception chains, reporting progress like ImportDumpEventsFromFile.
+// Lines before the first record are reported as errors.
+func (s *DumpService) ImportLaravelLog(path string) (DumpImportResult, error) {
+	return s.runtime.importLaravelLog(path)
+}
+
+// CancelImport stops the running import of path, keeping what was already
+// imported, and reports whether one was running.
+func (s *DumpService) CancelImport(path string) bool {
+	return s.runtime.cancelImport(path)
+}
+
+// ExportSession writes the buffered events matching filter to path as a
+// gzip-compressed NDJSON archive that ImportSession can load elsewhere,
+// with notes and stream sections in chronological position.
+func (s *DumpService) ExportSession(path string, filter query.Filter) (export.Manifest, error) {
+	return s.runtime.exportSession(path, filter)
+}
+
+func (s *DumpService) ImportSession(path string) (SessionImportResult, error) {
+	return s.runtime.importSession(path)
+}
+
+func (s *DumpService) TailDumpFile(path string) error {
+	if s.runtime.tails == nil {
+		return ErrCollectorNotRunning
+	}
+	return s.runtime.tails.Follow(path)
+}
+
+func (s *DumpService) StopTail(path string) error {
+	if s.runtime.tails == nil {
+		return ErrCollectorNotRunning
+	}
+	return s.runtime.tails.Stop(path)
+}
+
+func (s *DumpService) GetTailedDumpFiles() []tail.Status {
+	if s.runtime.tails == nil {
+		return []tail.Status{}
+	}
+	return s.runtime.tails.Statuses()
+}
+
+// SetVarDumperAddress listens for symfony/var-dumper's ServerDumper on the
+// given address (VAR_DUMPER_SERVER); an empty address disables the listener.
+func (s *DumpService) SetVarDumperAddress(address string) (VarDumperStatus, error) {
+	return s.runtime.setVarDumperAddress(address)
+}
+
+func (s *DumpService) GetVarDumperStatus() VarDumperStatus {
+	return s.runtime.varDumperStatus()
+}
+
+// SetRayAddress accepts spatie/ray requests on the given address so ray()
+// calls show up as events; an empty address disables the listener.
+func (s *DumpService) SetRayAddress(address string) (RayStatus, error) {
+	return s.runtime.setRayAddress(address)
+}
+
+func (s *DumpService) GetRayStatus() RayStatus {
+	return s.runtime.rayStatus()
+}
+
+// SetLogAddress accepts newline-delimited Monolog JSON records (SocketHandler
+// with JsonFormatter) on the given address as log events; an empty address
+// disables the listener.
+func (s *DumpService) SetLogAddress(address string) (LogIngestStatus, error) {
+	return s.runtime.setLogAddress(address)
+}
+
+func (s *DumpService) GetLogIngestStatus() LogIngestStatus {
+	return s.runtime.logIngestStatus()
+}
+
+// SetAPIAddress serves the read-only REST API (/api/events, /api/stats, and
+// more) on the given address; an empty address disables it. The address is
+// kept out of the config bundle, so importing one never exposes events.
+func (s *DumpService) SetAPIAddress(address string) (APIStatus, error) {
+	return s.runtime.setAPIAddress(address)
+}
+
+func (s *DumpService) GetAPIStatus() APIStatus {
+	return s.runtime.apiStatus()
+}
+
+// ListSources reports every source events arrive through: the collector
+// socket, the adapter listeners, added listeners, and tailed files, with
+// their counters and last error. Events carry the source ID in
+// IngestSource.
+func (s *DumpService) ListSources() []SourceStatus {
+	return s.runtime.listSources()
+}
+
+// AddSource starts a TCP, HTTP, or Unix socket listener, or tails a file.
+// Added listeners are kept in the settings and start with the collector.
+func (s *DumpService) AddSource(spec SourceSpec) (SourceStatus, error) {
+	return s.runtime.addSource(spec)
+}
+
+// RemoveSource stops an added listener or tailed file by its ID; built-in
+// sources return ErrBuiltinSource.
+func (s *DumpService) RemoveSource(id string) error {
+	return s.runtime.removeSource(id)
+}
+
+// GetListenerAuth returns the listener token, generating one on first use,
+// and the requests and connections each listener refused.
+func (s *DumpService) GetListenerAuth() (ListenerAuthStatus, error) {
+	return s.runtime.listenerAuthStatus()
+}
+
+// SetListenerAuth turns the token requirement on or off for the var-dumper,
+// Ray, and Monolog listeners and the REST API. It applies to requests and connections from
+// then on without restarting them.
+func (s *DumpService) SetListenerAuth(settings ListenerAuthSettings) (ListenerAuthStatus, error) {
+	return s.runtime.setListenerAuth(settings)
+}
+
+// RotateListenerToken replaces the token with a new random one.
+func (s *DumpService) RotateListenerToken() (ListenerAuthStatus, error) {
+	return s.runtime.rotateListenerToken()
+}
+
+func (s *DumpService) GetListenerTLS() ListenerTLSStatus {
+	return s.runtime.listenerTLSStatus()
+}
+
+// SetListenerTLS serves the var-dumper, Ray, and Monolog listeners over TLS
+// with a certificate and key from PEM files, or with a self-signed
+// certificate generated on first use. Running listeners restart with it.
+func (s *DumpService) SetListenerTLS(settings nettls.Settings) (ListenerTLSStatus, error) {
+	return s.runtime.setListenerTLS(settings)
+}
+
+// RegenerateListenerCertificate replaces the self-signed certificate;
+// clients pinning the old fingerprint must be updated.
+func (s *DumpService) RegenerateListenerCertificate() (ListenerTLSStatus, error) {
+	return s.runtime.regenerateListenerCertificate()
+}
+
+func (s *DumpService) GetDiscovery() DiscoveryStatus {
+	return s.runtime.discoveryStatus()
+}
+
+// SetDiscovery advertises the network listeners over mDNS as _phant._tcp,
+// so PHP clients on the LAN can find them without a configured address.
+func (s *DumpService) SetDiscovery(settings DiscoverySettings) (DiscoveryStatus, error) {
+	return s.runtime.setDiscovery(settings)
+}
+
+func (s *DumpService) EventsClearedChannelName() string {
+	return EventsClearedRuntimeChannel
+}
+
+func (s *DumpService) EventsRestoredChannelName() string {
+	return EventsRestoredRuntimeChannel
+}
+
+func (s *DumpService) StreamSectionChannelName() string {
+	return StreamSectionRuntimeChannel
+}
+
+// DeleteByCallsite clears every buffered event made at file:line, the
+// callsite an event's origin names; it can be undone like ClearDumpEvents.
+func (s *DumpService) DeleteByCallsite(file string, line int) (trash.Batch, error) {
+	return s.runtime.deleteByCallsite(file, line)
+}
+
+// IgnoreCallsite silences a noisy dump in one call: events from file:line
+// are dropped at ingestion from now on, across restarts, and the buffered
+// ones are cleared. dd() calls are never ignored.
+func (s *DumpService) IgnoreCallsite(file string, line int) (IgnoreResult, error) {
+	return s.runtime.ignoreCallsite(file, line)
+}
+
+func (s *DumpService) UnignoreCallsite(file string, line int) error {
+	return s.runtime.unignoreCallsite(file, line)
+}
+
+// ListIgnoredCallsites returns the ignore rules with how many events each
+// dropped since phant started.
+func (s *DumpService) ListIgnoredCallsites() ([]ignore.Status, error) {
+	return s.runtime.ignoreRules()
+}
+
+// GetStreamSections lists the sections PHP clients started with
+// new-section controls, oldest first; the most recent 200 are kept.
+func (s *DumpService) GetStreamSections() []StreamSection {
+	return s.runtime.streamSections()
+}
+
+// ClearDumpEvents removes the events the filter matches, such as one
+// project's or those in a time range; an empty filter clears everything.
+// The returned batch can be restored with UndoClear until it expires, which
+// takes the settings' undo window.
+func (s *DumpService) ClearDumpEvents(filter query.Filter) (trash.Batch, error) {
+	return s.runtime.clearDumpEvents(filter)
+}
+
+func (s *DumpService) UndoClear(batchID string) (RestoreResult, error) {
+	return s.runtime.undoClear(batchID)
+}
+
+// GetClearedBatches lists the cleared batches that can still be restored.
+func (s *DumpService) GetClearedBatches() []trash.Batch {
+	return s.runtime.clearedBatches()
+}
+
+// PurgeClearedEvents deletes a cleared batch now, or every held batch when
+// batchID is empty, and returns how many events were purged.
+func (s *DumpService) PurgeClearedEvents(batchID string) (int, error) {
+	return s.runtime.purgeCleared(batchID)
+}
+
+func (s *DumpService) ListForwardingRules() []forward.Rule {
+	return s.runtime.forwarder.Rules()
+}
+
+// SaveForwardingRule creates a rule, or updates the one with the same ID.
+func (s *DumpService) SaveForwardingRule(rule forward.Rule) (forward.Rule, error) {
+	return s.runtime.forwarder.Save(rule)
+}
+
+func (s *DumpService) DeleteForwardingRule(id string) error {
+	return s.runtime.forwarder.Delete(id)
+}
+
+// GetForwardingDeliveries returns recent webhook deliveries, newest first.
+func (s *DumpService) GetForwardingDeliveries() []forward.Delivery {
+	return s.runtime.forwarder.Deliveries()
+}
+
+// GetOTLPConfig returns where and how events are exported to an
+// OpenTelemetry collector.
+func (s *DumpService) GetOTLPConfig() otlp.Config {
+	return s.runtime.otlpExporter.Config()
+}
+
+// SetOTLPConfig replaces the OTLP export config and returns it; events
+// already queued are sent with the new one.
+func (s *DumpService) SetOTLPConfig(exportConfig otlp.Config) (otlp.Config, error) {
+	if err := s.runtime.otlpExporter.SetConfig(exportConfig); err != nil {
+		return otlp.Config{}, err
+	}
+	return s.runtime.otlpExporter.Config(), nil
+}
+
+// GetOTLPStatus returns how many events were exported, dropped, or
+// rejected, and the last error.
+func (s *DumpService) GetOTLPStatus() otlp.Status {
+	return s.runtime.otlpExporter.Status()
+}
+
+// ListCommandHooks returns the hooks that run an external command for
+// matching events, with the event JSON on its stdin.
+func (s *DumpService) ListCommandHooks() ([]hook.Rule, error) {
+	return s.runtime.commandHooks()
+}
+
+// SaveCommandHook creates a hook, or updates the one with the same ID, and
+// stores it next to the session logs. Hooks are not part of the config
+// bundle.
+func (s *DumpService) SaveCommandHook(rule hook.Rule) (hook.Rule, error) {
+	return s.runtime.saveCommandHook(rule)
+}
+
+func (s *DumpService) DeleteCommandHook(id string) error {
+	return s.runtime.deleteCommandHook(id)
+}
+
+// GetCommandHookRuns returns recent hook runs with their exit status and
+// output, newest first.
+func (s *DumpService) GetCommandHookRuns() []hook.Run {
+	return s.runtime.hooks.Runs()
+}
+
+// ResolveTrace returns an event's trace with each file mapped to the host
+// through the project's path mappings.
+func (s *DumpService) ResolveTrace(eventID string) ([]ResolvedFrame, error) {
+	return s.runtime.resolveTrace(eventID)
+}
+
+// GetTraceFrameContext returns the source lines around a trace frame, with
+// the frame's line marked. contextLines applies to each side and defaults
+// to 5.
+func (s *DumpService) GetTraceFrameContext(eventID string, frameIndex int, contextLines int) (source.Context, error) {
+	return s.runtime.traceFrameContext(eventID, frameIndex, contextLines)
+}
+
+// OpenInEditor opens file at line in the editor configured for the project
+// containing it, falling back to the global editor. Container paths are
+// mapped to the host first.
+func (s *DumpService) OpenInEditor(file string, line int) error {
+	return s.runtime.openInEditor(file, line)
+}
+
+func (s *DumpService) GetEventLink(id string) string {
+	return deeplink.ForEvent(id).URL()
+}
+
+func (s *DumpService) GetRequestLink(requestID string) string {
+	return deeplink.ForRequest(requestID).URL()
+}
+
+func (s *DumpService) GetFilterLink(params map[string]string) string {
+	return deeplink.ForFilter(params).URL()
+}
+
+// GetHTTPLink renders link as a URL on the running REST API, which opens it
+// in the viewer.
+func (s *DumpService) GetHTTPLink(link deeplink.Link) (string, error) {
+	return s.runtime.httpLink(link)
+}
+
+func (s *DumpService) ParseLink(raw string) (deeplink.Link, error) {
+	return deeplink.Parse(raw)
+}
+
+func (s *DumpService) DeepLinkChannelName() string {
+	return DeepLinkRuntimeChannel
+}
+
+func (s *DumpService) GetRetentionPolicy() retention.Policy {
+	return s.runtime.getRetentionPolicy()
+}
+
+func (s *DumpService) SetRetentionPolicy(policy retention.Policy) (retention.Summary, error) {
+	return s.runtime.setRetentionPolicy(policy)
+}
+
+func (s *DumpService) RetentionPrunedChannelName() string {
+	return RetentionPrunedRuntimeChannel
+}
+
+func (s *DumpService) GetPayloadSignature(eventID string) (signature.Signature, error) {
+	event, err := s.runtime.findEvent(eventID)
+	if err != nil {
+		return signature.Signature{}, err
+	}
+	return s.runtime.signatures.EventSignature(event)
+}
+
+func (s *DumpService) GetPayloadSignatureGroups() []signature.Group {
+	return s.runtime.signatures.GroupEvents(s.runtime.getRecentEvents(0))
+}
+
+func (s *DumpService) GetEventsBySignature(hash string) []dump.Event {
+	return s.runtime.signatures.Filter(s.runtime.getRecentEvents(0), hash)
+}
+
+// GetPayloadSchema summarizes the payloads dumped at origin, a file:line
+// callsite: every path with its types and array lengths, which paths are
+// optional, and the distinct shapes seen, so a structure that changes
+// between requests stands out.
+func (s *DumpService) GetPayloadSchema(origin string) (signature.Schema, error) {
+	return s.runtime.payloadSchema(origin)
+}
+
+func (s *DumpService) GetShapeChanges() []signature.ShapeChange {
+	return s.runtime.shapes.Changes()
+}
+
+func (s *DumpService) ResetLabelShapeHistory(label string) {
+	s.runtime.shapes.Reset(label)
+}
+
+func (s *DumpService) ShapeChangedChannelName() string {
+	return ShapeChangedRuntimeChannel
+}
+
+func (s *DumpService) SearchArchiveSegment(path string, query string, from string, to string, limit int) ([]dump.Event, error) {
+	reader, err := archive.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return reader.Search(query, from, to, limit)
+}
+
+func (s *DumpService) SearchDumpEvents(query string, limit int) (SearchResult, error) {
+	return s.runtime.searchEvents(query, limit)
+}
+
+// SetVisibleEvents tells the indexer which events the UI currently shows so
+// they are indexed ahead of the backlog.
+func (s *DumpService) SetVisibleEvents(ids []string) {
+	s.runtime.prioritizeSearch(ids)
+}
+
+func (s *DumpService) GetSearchIndexStats() search.IndexerStats {
+	return s.runtime.searchIndexStats()
+}
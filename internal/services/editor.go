@@ -0,0 +1,40 @@
+package services
+
+import (
+	"phant/internal/config"
+	"phant/internal/editor"
+)
+
+// editorFor picks the editor for a file: the override of the project that
+// contains it, or the global editor.
+func (r *collectorRuntime) editorFor(file string) config.EditorConfig {
+	if root := r.projectFor(file); root != "" {
+		project := r.projects.Resolve(root).Config.Editor
+		if project.Name != "" || project.Command != "" {
+			return project
+		}
+	}
+	return r.globalEditor()
+}
+
+func (r *collectorRuntime) globalEditor() config.EditorConfig {
+	r.editorMu.Lock()
+	defer r.editorMu.Unlock()
+	return r.editor
+}
+
+// openInEditor accepts files as reported by producers; container paths are
+// mapped to the host checkout before the editor sees them.
+func (r *collectorRuntime) openInEditor(file string, line int) error {
+	return editor.Open(r.editorFor(file), r.localPath(file), line)
+}
+
+func (r *collectorRuntime) setEditor(cfg config.EditorConfig) error {
+	if err := editor.Validate(cfg); err != nil {
+		return err
+	}
+	r.editorMu.Lock()
+	r.editor = cfg
+	r.editorMu.Unlock()
+	return nil
+}
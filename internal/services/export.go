@@ -0,0 +1,118 @@
+package services
+
+import (
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/export"
+	"phant/internal/query"
+)
+
+// SessionImportResult reports an imported session export. Notes counts the
+// annotations restored onto imported events, and Markers the sections
+// restored between them.
+type SessionImportResult struct {
+	DumpImportResult
+	Manifest export.Manifest `json:"manifest"`
+	Notes    int             `json:"notes"`
+	Markers  int             `json:"markers"`
+}
+
+// exportSession writes the buffered events matching filter to path, with
+// their notes, pins, and tags and the stream sections of their project in
+// chronological position. Events whose payload was cut to the size limit
+// are exported with the full original when it is still on disk.
+func (r *collectorRuntime) exportSession(path string, filter query.Filter) (export.Manifest, error) {
+	matcher, err := r.compileFilter(filter)
+	if err != nil {
+		return export.Manifest{}, err
+	}
+
+	var events []dump.Event
+	if r.collector != nil {
+		events = r.collector.Select(matcher.Match)
+	}
+
+	for i := range events {
+		if !events[i].Truncated && !events[i].Offloaded {
+			continue
+		}
+		if payload, err := r.fullPayload(events[i].ID); err == nil {
+			events[i].Payload = payload
+		}
+	}
+
+	return export.WriteFile(path, events, r.sessionTimeline(filter.ProjectRoot), time.Now())
+}
+
+// sessionTimeline gathers the annotations and the stream sections of
+// projectRoot, or of every project when it is empty. Annotations that
+// cannot be read are left out rather than failing the export.
+func (r *collectorRuntime) sessionTimeline(projectRoot string) export.Timeline {
+	timeline := export.Timeline{}
+	if set, err := r.annotationSet(); err == nil {
+		timeline.Notes = set.All()
+	}
+	for _, section := range r.streamSections() {
+		if projectRoot != "" && section.ProjectRoot != "" && section.ProjectRoot != projectRoot {
+			continue
+		}
+		timeline.Markers = append(timeline.Markers, export.Marker{
+			Title:       section.Title,
+			ProjectRoot: section.ProjectRoot,
+			At:          section.StartedAt,
+		})
+	}
+	return timeline
+}
+
+func (r *collectorRuntime) importSession(path string) (SessionImportResult, error) {
+	if r.collector == nil {
+		return SessionImportResult{}, ErrCollectorNotRunning
+	}
+
+	options := r.getDecodeOptions()
+	limit := options.MaxPayloadBytes
+	options.MaxPayloadBytes = 0
+
+	manifest, decoded, err := export.ReadFile(path, dump.StreamOptions{Decode: options})
+	if err != nil {
+		return SessionImportResult{Manifest: manifest}, err
+	}
+
+	imported := make(map[string]struct{}, len(decoded.Events))
+	for _, event := range decoded.Events {
+		r.finishEvent(&event, limit)
+		r.collector.Publish(event)
+		imported[event.ID] = struct{}{}
+	}
+
+	notes := 0
+	if set, err := r.annotationSet(); err == nil {
+		for _, note := range decoded.Notes {
+			if _, ok := imported[note.EventID]; !ok {
+				continue
+			}
+			if restored, err := set.Put(note); err == nil {
+				r.emitAnnotationChanged(restored)
+				notes++
+			}
+		}
+	}
+	for _, marker := range decoded.Markers {
+		r.startSection(marker.Title, marker.ProjectRoot, marker.At)
+	}
+
+	return SessionImportResult{
+		DumpImportResult: DumpImportResult{
+			Path:      path,
+			Imported:  len(decoded.Events),
+			Lines:     decoded.Lines,
+			Errors:    decoded.Errors,
+			Truncated: decoded.Truncated,
+		},
+		Manifest: manifest,
+		Notes:    notes,
+		Markers:  len(decoded.Markers),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package services
+
+const forwardSubscriberBuffer = 1024
+
+// startForwarding feeds every published event to the webhook forwarder,
+// which matches rules and queues deliveries without blocking.
+func (r *collectorRuntime) startForwarding() {
+	if r.collector == nil || r.forwarding {
+		return
+	}
+
+	subID, ch := r.collector.Subscribe(forwardSubscriberBuffer)
+	r.forwardSubID = subID
+	r.forwarding = true
+	r.forwarder.Start()
+
+	r.forwardWG.Add(1)
+	go func() {
+		defer r.forwardWG.Done()
+		for event := range ch {
+			// Collapsed repeats were forwarded when they first arrived.
+			if event.RepeatCount > 0 {
+				continue
+			}
+			r.forwarder.Handle(event)
+		}
+	}()
+}
+
+func (r *collectorRuntime) stopForwarding() {
+	if !r.forwarding {
+		return
+	}
+
+	r.collector.Unsubscribe(r.forwardSubID)
+	r.forwardWG.Wait()
+	r.forwarder.Stop()
+	r.forwarding = false
+}
@@ -0,0 +1,9 @@
+package services
+
+import "phant/internal/ddgate"
+
+func (r *collectorRuntime) emitGatesChanged(pending []ddgate.Gate) {
+	if r.app != nil {
+		r.app.Event.Emit(GatesChangedRuntimeChannel, pending)
+	}
+}
@@ -0,0 +1,25 @@
+package services
+
+import "phant/internal/dump"
+
+// StartHeadless runs the collector and its listeners without a Wails
+// application, for `phant tail`. Runtime channels are simply not emitted.
+func (a *AppServices) StartHeadless() error {
+	return a.Lifecycle.runtime.startupCollector()
+}
+
+func (a *AppServices) StopHeadless() {
+	a.Lifecycle.runtime.shutdownCollector()
+}
+
+// Events subscribes to every ingested event. The channel is closed by the
+// returned cancel function or when the collector stops.
+func (a *AppServices) Events(bufferSize int) (<-chan dump.Event, func(), error) {
+	r := a.Lifecycle.runtime
+	if r.collector == nil {
+		return nil, nil, ErrCollectorNotRunning
+	}
+
+	id, ch := r.collector.Subscribe(bufferSize)
+	return ch, func() { r.collector.Unsubscribe(id) }, nil
+}
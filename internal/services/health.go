@@ -0,0 +1,118 @@
+package services
+
+import (
+	"phant/internal/health"
+)
+
+const (
+	// degradedShapeSampling tracks the shape of one labeled event in this
+	// many while under pressure.
+	degradedShapeSampling = 10
+	// criticalPayloadBytes caps payloads under critical pressure; originals
+	// are still spilled to disk and can be fetched in full.
+	criticalPayloadBytes = 64 * 1024
+)
+
+type HealthStatus struct {
+	health.State
+	Thresholds health.Thresholds `json:"thresholds"`
+	// Degradations lists what phant currently does less of to stay out of
+	// the way of the app being debugged.
+	Degradations []string `json:"degradations"`
+}
+
+func (r *collectorRuntime) startHealthMonitor() {
+	if r.health != nil {
+		return
+	}
+
+	r.health = health.NewMonitor(r.healthThresholds, r.onHealthChange)
+	r.health.Start(health.DefaultInterval)
+}
+
+func (r *collectorRuntime) stopHealthMonitor() {
+	if r.health == nil {
+		return
+	}
+
+	r.health.Stop()
+	r.health = nil
+	r.setDegradation(health.LevelNormal)
+}
+
+func (r *collectorRuntime) onHealthChange(state health.State) {
+	r.setDegradation(state.Level)
+	if r.app != nil {
+		r.app.Event.Emit(HealthChangedRuntimeChannel, r.healthStatus())
+	}
+}
+
+func (r *collectorRuntime) setDegradation(level health.Level) {
+	r.degradation.Store(string(level))
+
+	r.searchMu.Lock()
+	defer r.searchMu.Unlock()
+	if r.indexer == nil {
+		return
+	}
+	if level == health.LevelNormal {
+		r.indexer.Resume()
+	} else {
+		r.indexer.Pause()
+	}
+}
+
+func (r *collectorRuntime) degradationLevel() health.Level {
+	level, _ := r.degradation.Load().(string)
+	if level == "" {
+		return health.LevelNormal
+	}
+	return health.Level(level)
+}
+
+// sampleShape reports whether this event's shape should be tracked; under
+// pressure only every degradedShapeSampling-th labeled event is.
+func (r *collectorRuntime) sampleShape() bool {
+	if r.degradationLevel() == health.LevelNormal {
+		return true
+	}
+	return r.shapeSamples.Add(1)%degradedShapeSampling == 0
+}
+
+// effectivePayloadLimit tightens the configured payload limit under critical
+// pressure.
+func (r *collectorRuntime) effectivePayloadLimit(limit int) int {
+	if r.degradationLevel() != health.LevelCritical {
+		return limit
+	}
+	if limit <= 0 || limit > criticalPayloadBytes {
+		return criticalPayloadBytes
+	}
+	return limit
+}
+
+func (r *collectorRuntime) healthStatus() HealthStatus {
+	status := HealthStatus{
+		State:        health.State{Level: health.LevelNormal, Reasons: []string{}},
+		Thresholds:   r.healthThresholds,
+		Degradations: []string{},
+	}
+	if r.health != nil {
+		status.State = r.health.State()
+	}
+
+	switch r.degradationLevel() {
+	case health.LevelCritical:
+		status.Degradations = append(status.Degradations, "search indexing paused", "payload shape tracking sampled", "payloads truncated to 64 KiB")
+	case health.LevelElevated:
+		status.Degradations = append(status.Degradations, "search indexing paused", "payload shape tracking sampled")
+	}
+	return status
+}
+
+func (r *collectorRuntime) setHealthThresholds(thresholds health.Thresholds) {
+	r.healthThresholds = thresholds
+	if r.health != nil {
+		r.health.SetThresholds(thresholds)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import "phant/internal/hook"
+
+const hookSubscriberBuffer = 1024
+
+// loadHooks reads the stored command hooks on first use. A file that
+// cannot be read is reported by every hook binding and never overwritten.
+func (r *collectorRuntime) loadHooks() error {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+
+	if r.hooksLoaded {
+		return nil
+	}
+	rules, err := hook.Load(r.storeDir)
+	if err != nil {
+		return err
+	}
+	if err := r.hooks.SetRules(rules); err != nil {
+		return err
+	}
+	r.hooksLoaded = true
+	return nil
+}
+
+func (r *collectorRuntime) commandHooks() ([]hook.Rule, error) {
+	if err := r.loadHooks(); err != nil {
+		return nil, err
+	}
+	return r.hooks.Rules(), nil
+}
+
+func (r *collectorRuntime) saveCommandHook(rule hook.Rule) (hook.Rule, error) {
+	var saved hook.Rule
+	err := r.changeCommandHooks(func() (err error) {
+		saved, err = r.hooks.Save(rule)
+		return err
+	})
+	return saved, err
+}
+
+func (r *collectorRuntime) deleteCommandHook(id string) error {
+	return r.changeCommandHooks(func() error {
+		return r.hooks.Delete(id)
+	})
+}
+
+// changeCommandHooks applies change and stores the result, putting the
+// previous hooks back when they cannot be stored.
+func (r *collectorRuntime) changeCommandHooks(change func() error) error {
+	if err := r.loadHooks(); err != nil {
+		return err
+	}
+
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+
+	previous := r.hooks.Rules()
+	if err := change(); err != nil {
+		return err
+	}
+	if err := hook.Save(r.storeDir, r.hooks.Rules()); err != nil {
+		_ = r.hooks.SetRules(previous)
+		return err
+	}
+	return nil
+}
+
+// startHooks feeds every published event to the command hooks, which
+// match rules and queue runs without blocking.
+func (r *collectorRuntime) startHooks() {
+	if r.collector == nil || r.hooking || r.loadHooks() != nil {
+		return
+	}
+
+	subID, ch := r.collector.Subscribe(hookSubscriberBuffer)
+	r.hookSubID = subID
+	r.hooking = true
+	r.hooks.Start()
+
+	r.hookWG.Add(1)
+	go func() {
+		defer r.hookWG.Done()
+		for event := range ch {
+			// Collapsed repeats ran the hooks when they first arrived.
+			if event.RepeatCount > 0 {
+				continue
+			}
+			r.hooks.Handle(event)
+		}
+	}()
+}
+
+func (r *collectorRuntime) stopHooks() {
+	if !r.hooking {
+		return
+	}
+
+	r.collector.Unsubscribe(r.hookSubID)
+	r.hookWG.Wait()
+	r.hooks.Stop()
+	r.hooking = false
+}
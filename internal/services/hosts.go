@@ -0,0 +1,48 @@
+package services
+
+import (
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/hosts"
+)
+
+func (r *collectorRuntime) observeHost(event dump.Event, receivedAt time.Time) {
+	if r.hosts.Observe(event, receivedAt) {
+		r.emitHostsChanged()
+	}
+}
+
+// unmuted drops events of muted hosts from a batch bound for the live feed.
+func (r *collectorRuntime) unmuted(batch []dump.Event) []dump.Event {
+	kept := batch[:0]
+	for _, event := range batch {
+		if !r.hosts.Muted(event.Host.Hostname) {
+			kept = append(kept, event)
+		}
+	}
+	return kept
+}
+
+func (r *collectorRuntime) updateHost(hostname string, settings hosts.Settings) (hosts.Host, error) {
+	host, err := r.hosts.Update(hostname, settings)
+	if err != nil {
+		return hosts.Host{}, err
+	}
+	r.emitHostsChanged()
+	return host, nil
+}
+
+func (r *collectorRuntime) loadHosts(settings map[string]hosts.Settings) error {
+	if err := r.hosts.Load(settings); err != nil {
+		return err
+	}
+	r.emitHostsChanged()
+	return nil
+}
+
+func (r *collectorRuntime) emitHostsChanged() {
+	if r.app != nil {
+		r.app.Event.Emit(HostsChangedRuntimeChannel, r.hosts.Hosts())
+	}
+}
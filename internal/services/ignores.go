@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+
+	"phant/internal/dump"
+	"phant/internal/ignore"
+	"phant/internal/query"
+	"phant/internal/trash"
+)
+
+// IgnoreResult is a new ignore rule and the events it cleared from the
+// callsite.
+type IgnoreResult struct {
+	Rule    ignore.Rule `json:"rule"`
+	Cleared trash.Batch `json:"cleared"`
+}
+
+// ignoreSet opens the ignore rules kept next to the session logs on first
+// use. A file that cannot be read leaves every callsite unignored rather
+// than being read again for each event.
+func (r *collectorRuntime) ignoreSet() (*ignore.Set, error) {
+	r.ignoresMu.Lock()
+	defer r.ignoresMu.Unlock()
+
+	if r.ignores == nil && r.ignoreErr == nil {
+		r.ignores, r.ignoreErr = ignore.Open(r.storeDir)
+	}
+	return r.ignores, r.ignoreErr
+}
+
+// ignored reports live events from an ignored callsite. dd() calls are
+// never ignored: their producer waits for phant to release it.
+func (r *collectorRuntime) ignored(event dump.Event) bool {
+	if event.IsDD {
+		return false
+	}
+	set, err := r.ignoreSet()
+	return err == nil && set.Match(event)
+}
+
+// deleteByCallsite clears the buffered events made at file:line, with the
+// usual undo window.
+func (r *collectorRuntime) deleteByCallsite(file string, line int) (trash.Batch, error) {
+	if err := (ignore.Rule{File: file, Line: line}).Validate(); err != nil {
+		return trash.Batch{}, err
+	}
+	return r.clearDumpEvents(query.Filter{Origin: fmt.Sprintf("%s:%d", file, line)})
+}
+
+// ignoreCallsite drops later events from file:line and clears the ones
+// already buffered.
+func (r *collectorRuntime) ignoreCallsite(file string, line int) (IgnoreResult, error) {
+	set, err := r.ignoreSet()
+	if err != nil {
+		return IgnoreResult{}, err
+	}
+	rule, err := set.Add(file, line)
+	if err != nil {
+		return IgnoreResult{}, err
+	}
+	cleared, err := r.deleteByCallsite(file, line)
+	if err != nil {
+		return IgnoreResult{Rule: rule}, err
+	}
+	return IgnoreResult{Rule: rule, Cleared: cleared}, nil
+}
+
+func (r *collectorRuntime) unignoreCallsite(file string, line int) error {
+	set, err := r.ignoreSet()
+	if err != nil {
+		return err
+	}
+	return set.Remove(file, line)
+}
+
+func (r *collectorRuntime) ignoreRules() ([]ignore.Status, error) {
+	set, err := r.ignoreSet()
+	if err != nil {
+		return nil, err
+	}
+	return set.List(), nil
+}
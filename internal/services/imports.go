@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/monolog"
+)
+
+// importProgressInterval spaces out progress reports, so a fast import does
+// not flood the UI.
+const importProgressInterval = 250 * time.Millisecond
+
+// ImportProgress is pushed on ImportProgressRuntimeChannel while a dump file
+// is imported, and once more with Done set when it ends.
+type ImportProgress struct {
+	Path       string `json:"path"`
+	Bytes      int64  `json:"bytes"`
+	TotalBytes int64  `json:"totalBytes"`
+	Lines      int    `json:"lines"`
+	Imported   int    `json:"imported"`
+	Errors     int    `json:"errors"`
+	Done       bool   `json:"done"`
+}
+
+// importDumpFile decodes path on a pool of workers, sized like the ingest
+// shards, and publishes the events in file order one store batch at a
+// time. Each batch is committed to the session log before the next is
+// published.
+func (r *collectorRuntime) importDumpFile(path string) (DumpImportResult, error) {
+	return r.importFile(path, func(ctx context.Context, file io.Reader, emit func(dump.Batch) error) (dump.StreamResult, error) {
+		options := r.getDecodeOptions()
+		options.MaxPayloadBytes = 0
+		return dump.DecodeNDJSONBatches(ctx, file, dump.BatchOptions{
+			Decode:     options,
+			Workers:    r.activeTuning.DecodeWorkers,
+			BatchLines: r.activeTuning.StoreBatchSize,
+		}, emit)
+	})
+}
+
+// importLaravelLog imports a laravel.log as log events, one store batch at
+// a time like importDumpFile, with their traces classified.
+func (r *collectorRuntime) importLaravelLog(path string) (DumpImportResult, error) {
+	return r.importFile(path, func(ctx context.Context, file io.Reader, emit func(dump.Batch) error) (dump.StreamResult, error) {
+		return monolog.ReadLaravelLog(ctx, file, monolog.LaravelOptions{BatchLines: r.activeTuning.StoreBatchSize}, func(batch dump.Batch) error {
+			// Like adapter events, these were not decoded by the dump package.
+			for i := range batch.Events {
+				dump.ClassifyTrace(&batch.Events[i])
+			}
+			return emit(batch)
+		})
+	})
+}
+
+// importFile runs decode over path, publishing each batch it emits and
+// reporting progress. The payload limit is applied here, after decoding.
+func (r *collectorRuntime) importFile(path string, decode func(ctx context.Context, file io.Reader, emit func(dump.Batch) error) (dump.StreamResult, error)) (DumpImportResult, error) {
+	if r.collector == nil {
+		return DumpImportResult{}, ErrCollectorNotRunning
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return DumpImportResult{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return DumpImportResult{}, err
+	}
+
+	ctx, done, err := r.beginImport(path)
+	if err != nil {
+		return DumpImportResult{}, err
+	}
+	defer done()
+
+	limit := r.getDecodeOptions().MaxPayloadBytes
+	progress := ImportProgress{Path: path, TotalBytes: info.Size()}
+	var reported time.Time
+	decoded, err := decode(ctx, file, func(batch dump.Batch) error {
+		for i := range batch.Events {
+			r.finishEvent(&batch.Events[i], limit)
+		}
+		if err := r.publishImported(batch.Events); err != nil {
+			return err
+		}
+
+		progress.Bytes = batch.Bytes
+		progress.Lines = batch.Lines
+		progress.Imported += len(batch.Events)
+		progress.Errors += len(batch.Errors)
+		if time.Since(reported) >= importProgressInterval {
+			r.emitImportProgress(progress)
+			reported = time.Now()
+		}
+		return nil
+	})
+
+	progress.Done = true
+	r.emitImportProgress(progress)
+
+	result := DumpImportResult{
+		Path:      path,
+		Imported:  progress.Imported,
+		Lines:     decoded.Lines,
+		Errors:    decoded.Errors,
+		Truncated: decoded.Truncated,
+		Cancelled: errors.Is(err, context.Canceled),
+	}
+	if err != nil && !result.Cancelled {
+		return result, err
+	}
+	return result, nil
+}
+
+// beginImport registers an import of path so CancelImport can stop it. The
+// returned function unregisters it.
+func (r *collectorRuntime) beginImport(path string) (context.Context, func(), error) {
+	r.importsMu.Lock()
+	defer r.importsMu.Unlock()
+
+	if _, ok := r.imports[path]; ok {
+		return nil, nil, ErrImportRunning
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.imports[path] = cancel
+	return ctx, func() {
+		r.importsMu.Lock()
+		defer r.importsMu.Unlock()
+		delete(r.imports, path)
+		cancel()
+	}, nil
+}
+
+func (r *collectorRuntime) cancelImport(path string) bool {
+	r.importsMu.Lock()
+	defer r.importsMu.Unlock()
+
+	cancel, ok := r.imports[path]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+func (r *collectorRuntime) cancelImports() {
+	r.importsMu.Lock()
+	defer r.importsMu.Unlock()
+
+	for _, cancel := range r.imports {
+		cancel()
+	}
+}
+
+// publishImported buffers and fans out an imported batch, and commits it to
+// the session log itself rather than through the store's subscription,
+// which drops events once it is full.
+func (r *collectorRuntime) publishImported(events []dump.Event) error {
+	if r.store == nil {
+		r.collector.PublishBatch(events, -1)
+		return nil
+	}
+
+	published := r.collector.PublishBatch(events, r.storeSubID)
+	if err := r.store.Append(published...); err != nil {
+		return err
+	}
+	return r.store.Flush()
+}
+
+func (r *collectorRuntime) emitImportProgress(progress ImportProgress) {
+	if r.app != nil {
+		r.app.Event.Emit(ImportProgressRuntimeChannel, progress)
+	}
+}
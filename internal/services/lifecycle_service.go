@@ -2,10 +2,17 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"phant/internal/collector"
+	"phant/internal/deeplink"
+	"phant/internal/dump"
+	"phant/internal/pipeline"
+	"phant/internal/retention"
+	"phant/internal/tail"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
+	"github.com/wailsapp/wails/v3/pkg/events"
 )
 
 type CollectorLifecycleService struct {
@@ -18,6 +25,9 @@ func AttachApplication(service *CollectorLifecycleService, app *application.App)
 
 func (s *CollectorLifecycleService) setApplication(app *application.App) {
 	s.runtime.app = app
+	app.Event.OnApplicationEvent(events.Common.ApplicationLaunchedWithUrl, func(event *application.ApplicationEvent) {
+		s.runtime.openDeepLink(event.Context().URL())
+	})
 }
 
 func (s *CollectorLifecycleService) ServiceStartup(_ context.Context, _ application.ServiceOptions) error {
@@ -29,23 +39,74 @@ func (s *CollectorLifecycleService) ServiceShutdown() error {
 	return nil
 }
 
+func (r *collectorRuntime) openDeepLink(raw string) {
+	link, err := deeplink.Parse(raw)
+	if err != nil {
+		return
+	}
+	r.emitDeepLink(link)
+}
+
+func (r *collectorRuntime) emitDeepLink(link deeplink.Link) {
+	if r.app != nil {
+		r.app.Event.Emit(DeepLinkRuntimeChannel, link)
+	}
+}
+
 func (r *collectorRuntime) startupCollector() error {
+	r.applySavedSettings()
 	socketPath := r.collectorSocketPath()
-	server := collector.NewServer(socketPath, collector.DefaultBufferSize)
+	r.activeTuning = r.tuning.withDefaults()
+	server := collector.NewServerWithOptions(socketPath, collector.ServerOptions{
+		Shards:     r.activeTuning.DecodeWorkers,
+		ShardDepth: r.activeTuning.DecodeQueueDepth,
+		Overflow:   r.activeTuning.Overflow,
+	})
+	server.SetDecoder(r.sourceDecoder(SourceSocket, &r.socketCounts))
+	server.SetDedupWindow(time.Duration(r.getDedup().WindowMs) * time.Millisecond)
+	clock := r.getClock()
+	server.SetReceiveClock(clock.RecordReceivedAt, time.Duration(clock.SkewWarningMs)*time.Millisecond)
+	server.SetGates(r.gates)
+	server.SetCapture(r.captureRecorder)
+	server.SetJournal(r.journalEvent)
 
 	r.collectorStatus = CollectorStatus{
 		Running:    false,
 		SocketPath: socketPath,
 	}
 
+	r.startStoreWriter(server)
 	if err := server.Start(); err != nil {
+		r.stopStoreWriter(server)
 		r.collectorStatus.LastError = err.Error()
 		return err
 	}
 
 	r.collector = server
 	r.collectorStatus.Running = true
+	r.tails = tail.NewManager(r.ingestLine, server.Ingest)
+	r.tails.SetDecoderFor(r.fileDecoder)
+	r.retentionMu.Lock()
+	r.retention = retention.NewEngine(server, r.retentionPolicy, r.emitPruneSummary)
+	r.retentionMu.Unlock()
+	r.applyProjectRetention()
+	r.keepPinned()
+	r.retention.Start()
+	go r.loadLatestSessionSummary()
+	r.startSessionArchiver()
 	r.startCollectorEventBridge()
+	r.startForwarding()
+	r.startAlerting()
+	r.startHooks()
+	r.startOTLPExport()
+	r.startHealthMonitor()
+	r.startRuleWatcher()
+	r.varDumper.start()
+	r.ray.start()
+	r.logs.start()
+	r.api.start()
+	r.startIngestListeners()
+	_ = r.refreshDiscovery()
 
 	return nil
 }
@@ -55,7 +116,40 @@ func (r *collectorRuntime) shutdownCollector() {
 		return
 	}
 
+	r.stopHealthMonitor()
+	r.stopRuleWatcher()
+	r.stopSessionArchiver()
+	r.cancelImports()
+	r.stopSessionReplay()
+	r.gates.ReleaseProject("", "")
+	r.varDumper.stop()
+	r.ray.stop()
+	r.logs.stop()
+	r.api.stop()
+	r.stopIngestListeners()
+	_ = r.refreshDiscovery()
+
+	if r.tails != nil {
+		r.tails.StopAll()
+		r.tails = nil
+	}
+
+	if r.retention != nil {
+		r.retention.Stop()
+		r.retentionMu.Lock()
+		r.retention = nil
+		r.retentionMu.Unlock()
+	}
+
 	r.stopCollectorEventBridge()
+	r.stopSearchIndexer()
+	r.dropDumpStreams()
+	r.stopForwarding()
+	r.stopAlerting()
+	r.stopHooks()
+	r.stopOTLPExport()
+	_, _ = r.stopRecording()
+	r.stopStoreWriter(r.collector)
 
 	if err := r.collector.Stop(); err != nil {
 		r.collectorStatus.LastError = err.Error()
@@ -63,6 +157,7 @@ func (r *collectorRuntime) shutdownCollector() {
 
 	r.collectorStatus.Dropped = r.collector.DroppedCount()
 	r.collectorStatus.Running = false
+	r.saveSettingsOnExit()
 }
 
 func (r *collectorRuntime) startCollectorEventBridge() {
@@ -70,30 +165,72 @@ func (r *collectorRuntime) startCollectorEventBridge() {
 		return
 	}
 
-	subID, ch := r.collector.Subscribe(256)
+	subID, ch := r.collector.Subscribe(r.activeTuning.BridgeQueueDepth)
 	r.collectorSubID = subID
 	r.collectorDone = make(chan struct{})
+	r.bridge = pipeline.NewBatcher(r.emitDumpBatch, pipeline.BatcherOptions{})
 	r.collectorWG.Add(1)
 
 	go func() {
 		defer r.collectorWG.Done()
-
-		for {
-			select {
-			case <-r.collectorDone:
-				return
-			case event, ok := <-ch:
-				if !ok {
-					return
-				}
-				if r.app != nil {
-					r.app.Event.Emit(DumpEventRuntimeChannel, event)
-				}
-			}
-		}
+		r.bridge.Run(ch, r.collectorDone)
 	}()
 }
 
+// emitDumpBatch forwards a batch to the frontend as one runtime event whose
+// data is an array of dump events. While the live feed is paused the batch
+// is held back instead; events of muted hosts are never pushed.
+func (r *collectorRuntime) emitDumpBatch(batch []dump.Event) {
+	received := time.Now()
+	for i, event := range batch {
+		r.observeProject(event)
+		r.observeHost(event, received)
+		r.trackShape(event)
+		// Raw lines stay in the buffer and the session log; the UI never
+		// needs them.
+		batch[i].Raw = nil
+		batch[i].Preview = r.previews.Preview(event)
+	}
+
+	batch = r.unmuted(batch)
+	r.notifyEvents(batch)
+	r.countUnread(batch)
+
+	r.pushMu.Lock()
+	defer r.pushMu.Unlock()
+	r.pushDumpBatch(r.liveGate.Admit(batch))
+}
+
+func (r *collectorRuntime) pushDumpBatch(batch []dump.Event) {
+	if len(batch) == 0 {
+		return
+	}
+	if r.app != nil {
+		r.app.Event.Emit(DumpEventRuntimeChannel, batch)
+	}
+
+	pushed := time.Now()
+	for _, event := range batch {
+		r.tracer.Mark(event.ID, pipeline.StagePushed, pushed)
+	}
+}
+
+// resumeStream delivers held events before any batch that arrives after
+// the gate opens.
+func (r *collectorRuntime) resumeStream() pipeline.GateStatus {
+	r.pushMu.Lock()
+	held, status := r.liveGate.Resume()
+	for len(held) > 0 {
+		n := min(len(held), pipeline.DefaultMaxBatch)
+		r.pushDumpBatch(held[:n])
+		held = held[n:]
+	}
+	r.pushMu.Unlock()
+
+	r.streamPauseChanged(status)
+	return status
+}
+
 func (r *collectorRuntime) stopCollectorEventBridge() {
 	if r.collector == nil || r.collectorDone == nil {
 		return
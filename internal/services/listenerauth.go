@@ -0,0 +1,116 @@
+package services
+
+import (
+	"phant/internal/netauth"
+)
+
+// ListenerAuthSettings controls the shared secret the network listeners
+// (var-dumper, Ray, Monolog) and the REST API require. The token itself is kept in its own
+// file next to the session logs, never in the config bundle.
+type ListenerAuthSettings struct {
+	Required bool `json:"required"`
+}
+
+// ListenerAuthStatus reports the token and how many requests or
+// connections each running listener turned away for a missing or wrong
+// one. HTTP producers send "Authorization: Bearer <token>"; stream
+// producers send "PHANT-AUTH <token>" as their first line.
+type ListenerAuthStatus struct {
+	Required     bool                   `json:"required"`
+	Token        string                 `json:"token"`
+	Unauthorized UnauthorizedByListener `json:"unauthorized"`
+}
+
+type UnauthorizedByListener struct {
+	VarDumper uint64 `json:"varDumper"`
+	Ray       uint64 `json:"ray"`
+	Logs      uint64 `json:"logs"`
+	API       uint64 `json:"api"`
+}
+
+// listenerTokenLocked loads the stored token on first use, creating one if
+// none was stored yet.
+func (r *collectorRuntime) listenerTokenLocked() (string, error) {
+	if r.authToken != "" {
+		return r.authToken, nil
+	}
+
+	token, err := netauth.LoadToken(r.storeDir)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		if token, err = netauth.NewToken(); err != nil {
+			return "", err
+		}
+		if err := netauth.SaveToken(r.storeDir, token); err != nil {
+			return "", err
+		}
+	}
+	r.authToken = token
+	return token, nil
+}
+
+func (r *collectorRuntime) setListenerAuth(settings ListenerAuthSettings) (ListenerAuthStatus, error) {
+	r.authMu.Lock()
+	defer r.authMu.Unlock()
+
+	token := ""
+	if settings.Required {
+		var err error
+		if token, err = r.listenerTokenLocked(); err != nil {
+			return ListenerAuthStatus{}, err
+		}
+	}
+	if err := r.auth.SetToken(token); err != nil {
+		return ListenerAuthStatus{}, err
+	}
+	r.authRequired = settings.Required
+	_ = r.refreshDiscovery()
+	return r.listenerAuthStatusLocked()
+}
+
+// rotateListenerToken replaces the token. Producers using the old one are
+// turned away from then on; connections already open stay open.
+func (r *collectorRuntime) rotateListenerToken() (ListenerAuthStatus, error) {
+	r.authMu.Lock()
+	defer r.authMu.Unlock()
+
+	token, err := netauth.NewToken()
+	if err != nil {
+		return ListenerAuthStatus{}, err
+	}
+	if err := netauth.SaveToken(r.storeDir, token); err != nil {
+		return ListenerAuthStatus{}, err
+	}
+	r.authToken = token
+	if r.authRequired {
+		if err := r.auth.SetToken(token); err != nil {
+			return ListenerAuthStatus{}, err
+		}
+	}
+	return r.listenerAuthStatusLocked()
+}
+
+func (r *collectorRuntime) listenerAuthStatus() (ListenerAuthStatus, error) {
+	r.authMu.Lock()
+	defer r.authMu.Unlock()
+	return r.listenerAuthStatusLocked()
+}
+
+func (r *collectorRuntime) listenerAuthStatusLocked() (ListenerAuthStatus, error) {
+	token, err := r.listenerTokenLocked()
+	if err != nil {
+		return ListenerAuthStatus{}, err
+	}
+	return ListenerAuthStatus{
+		Required: r.authRequired,
+		Token:    token,
+		Unauthorized: UnauthorizedByListener{
+			VarDumper: r.varDumperStatus().Unauthorized,
+			Ray:       r.rayStatus().Unauthorized,
+			Logs:      r.logIngestStatus().Unauthorized,
+			API:       r.apiStatus().Unauthorized,
+		},
+	}, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/pipeline"
+)
+
+// ingestListener is an optional protocol adapter (var-dumper, Ray, Monolog)
+// that feeds events into the collector while it runs.
+type ingestListener interface {
+	Start() error
+	Stop() error
+}
+
+// listenerSlot holds one adapter's configured address and running instance.
+// An empty address disables the adapter.
+type listenerSlot[T ingestListener] struct {
+	create func(address string) T
+
+	mu      sync.Mutex
+	address string
+	server  T
+	running bool
+	lastErr string
+}
+
+func newListenerSlot[T ingestListener](create func(address string) T) *listenerSlot[T] {
+	return &listenerSlot[T]{create: create}
+}
+
+// configure stores the address and, when the collector is up, rebinds the
+// adapter to it.
+func (l *listenerSlot[T]) configure(address string, collectorRunning bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.address = address
+	l.lastErr = ""
+	if !collectorRunning {
+		l.stopLocked()
+		return nil
+	}
+	return l.startLocked()
+}
+
+func (l *listenerSlot[T]) start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_ = l.startLocked()
+}
+
+func (l *listenerSlot[T]) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stopLocked()
+}
+
+func (l *listenerSlot[T]) startLocked() error {
+	l.stopLocked()
+	if l.address == "" {
+		return nil
+	}
+
+	server := l.create(l.address)
+	if err := server.Start(); err != nil {
+		l.lastErr = err.Error()
+		return err
+	}
+	l.server = server
+	l.running = true
+	return nil
+}
+
+func (l *listenerSlot[T]) stopLocked() {
+	if !l.running {
+		return
+	}
+	if err := l.server.Stop(); err != nil {
+		l.lastErr = err.Error()
+	}
+	var zero T
+	l.server = zero
+	l.running = false
+}
+
+// restart rebinds a running adapter so it picks up settings read at start,
+// such as TLS.
+func (l *listenerSlot[T]) restart() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.running {
+		return nil
+	}
+	l.lastErr = ""
+	return l.startLocked()
+}
+
+func (l *listenerSlot[T]) configured() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.address
+}
+
+// inspect calls fn with the slot state under the lock; server is only valid
+// when running is true.
+func (l *listenerSlot[T]) inspect(fn func(address string, server T, running bool, lastErr string)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fn(l.address, l.server, l.running, l.lastErr)
+}
+
+// ingestAdapterEvent is the shared entry point for adapter events: they get
+// the same payload limit as socket events and keep per-request ordering.
+func (r *collectorRuntime) ingestAdapterEvent(event dump.Event) {
+	collector := r.collector
+	if collector == nil {
+		return
+	}
+
+	// Adapters decode on their own, so their events are traced from here.
+	now := time.Now()
+	r.tracer.Mark(event.ID, pipeline.StageReceived, now)
+	r.tracer.Mark(event.ID, pipeline.StageDecoded, now)
+
+	dump.ClassifyTrace(&event)
+	if r.ignored(event) {
+		return
+	}
+	r.finishEvent(&event, r.getDecodeOptions().MaxPayloadBytes)
+	collector.Ingest(event)
+}
+
+func (r *collectorRuntime) collectorRunning() bool {
+	return r.collector != nil && r.collectorStatus.Running
+}
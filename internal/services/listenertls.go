@@ -0,0 +1,91 @@
+package services
+
+import (
+	"crypto/tls"
+	"errors"
+	"time"
+
+	"phant/internal/nettls"
+)
+
+// ListenerTLSStatus reports the TLS settings of the network listeners and
+// the certificate they serve. Clients connecting over the internet can pin
+// Fingerprint instead of trusting a self-signed certificate blindly.
+type ListenerTLSStatus struct {
+	nettls.Settings
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Subject     string   `json:"subject,omitempty"`
+	DNSNames    []string `json:"dnsNames,omitempty"`
+	NotAfter    string   `json:"notAfter,omitempty"`
+}
+
+func (r *collectorRuntime) listenerTLSConfig() *tls.Config {
+	r.tlsMu.Lock()
+	defer r.tlsMu.Unlock()
+
+	if r.tlsCert == nil {
+		return nil
+	}
+	return r.tlsCert.Config
+}
+
+// setListenerTLS loads the certificate the settings select and restarts
+// running listeners with it. Nothing changes if it cannot be loaded.
+func (r *collectorRuntime) setListenerTLS(settings nettls.Settings) (ListenerTLSStatus, error) {
+	certificate, err := nettls.Load(settings, r.storeDir)
+	if err != nil {
+		return r.listenerTLSStatus(), err
+	}
+
+	r.tlsMu.Lock()
+	r.tlsSettings = settings
+	r.tlsCert = certificate
+	r.tlsMu.Unlock()
+	return r.listenerTLSStatus(), r.restartListeners()
+}
+
+// regenerateListenerCertificate replaces the self-signed certificate, for
+// instance after its key leaked.
+func (r *collectorRuntime) regenerateListenerCertificate() (ListenerTLSStatus, error) {
+	r.tlsMu.Lock()
+	mode := r.tlsSettings.Mode
+	r.tlsMu.Unlock()
+	if mode != nettls.ModeSelfSigned {
+		return r.listenerTLSStatus(), errors.New("tls mode is not self-signed")
+	}
+
+	certificate, err := nettls.Generate(r.storeDir)
+	if err != nil {
+		return r.listenerTLSStatus(), err
+	}
+
+	r.tlsMu.Lock()
+	r.tlsCert = certificate
+	r.tlsMu.Unlock()
+	return r.listenerTLSStatus(), r.restartListeners()
+}
+
+func (r *collectorRuntime) listenerTLSStatus() ListenerTLSStatus {
+	r.tlsMu.Lock()
+	defer r.tlsMu.Unlock()
+
+	status := ListenerTLSStatus{Settings: r.tlsSettings}
+	if status.Mode == "" {
+		status.Mode = nettls.ModeOff
+	}
+	if r.tlsCert != nil {
+		status.Fingerprint = r.tlsCert.Fingerprint
+		status.Subject = r.tlsCert.Subject
+		status.DNSNames = r.tlsCert.DNSNames
+		status.NotAfter = r.tlsCert.NotAfter.UTC().Format(time.RFC3339)
+	}
+	return status
+}
+
+// restartListeners also re-advertises them, since the TXT records carry the
+// certificate fingerprint.
+func (r *collectorRuntime) restartListeners() error {
+	err := errors.Join(r.varDumper.restart(), r.ray.restart(), r.logs.restart(), r.api.restart(), r.restartIngestListeners())
+	_ = r.refreshDiscovery()
+	return err
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"phant/internal/dump"
+	"phant/internal/measure"
+)
+
+// measures pairs the buffered measure events of one request, or of every
+// request when requestID is empty.
+func (r *collectorRuntime) measures(requestID string) []measure.Measure {
+	if r.collector == nil {
+		return []measure.Measure{}
+	}
+	var events []dump.Event
+	if requestID != "" {
+		events = r.collector.RequestEvents(requestID)
+	} else {
+		events = r.collector.Select(func(event dump.Event) bool {
+			return event.Measure != nil
+		})
+	}
+	return measure.Pair(events)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"phant/internal/monolog"
+)
+
+// LogIngestStatus reports the Monolog record listener. An empty Address
+// means the listener is disabled.
+type LogIngestStatus struct {
+	Address      string `json:"address"`
+	Running      bool   `json:"running"`
+	Received     uint64 `json:"received"`
+	Rejected     uint64 `json:"rejected"`
+	Unauthorized uint64 `json:"unauthorized"`
+	LastError    string `json:"lastError,omitempty"`
+}
+
+func (r *collectorRuntime) newLogServer(address string) *monolog.Server {
+	server := monolog.NewServer(address, r.sourceHandler(SourceMonolog))
+	server.SetAuth(r.auth)
+	server.SetTLS(r.listenerTLSConfig())
+	server.SetCapture(r.captureRecorder)
+	return server
+}
+
+func (r *collectorRuntime) setLogAddress(address string) (LogIngestStatus, error) {
+	err := r.logs.configure(address, r.collectorRunning())
+	_ = r.refreshDiscovery()
+	return r.logIngestStatus(), err
+}
+
+func (r *collectorRuntime) logIngestStatus() LogIngestStatus {
+	var status LogIngestStatus
+	r.logs.inspect(func(address string, server *monolog.Server, running bool, lastErr string) {
+		status = LogIngestStatus{Address: address, LastError: lastErr}
+		if running {
+			stats := server.Stats()
+			status.Address = stats.Address
+			status.Running = true
+			status.Received = stats.Received
+			status.Rejected = stats.Rejected
+			status.Unauthorized = stats.Unauthorized
+		}
+	})
+	return status
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"phant/internal/deeplink"
+	"phant/internal/dump"
+	"phant/internal/notify"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+	"github.com/wailsapp/wails/v3/pkg/services/notifications"
+)
+
+var errNotificationsDenied = errors.New("notifications are not authorized for phant")
+
+// NotificationStatus reports the notification settings and whether the
+// platform can show notifications at all.
+type NotificationStatus struct {
+	notify.Settings
+	Available bool         `json:"available"`
+	Stats     notify.Stats `json:"stats"`
+	LastError string       `json:"lastError,omitempty"`
+}
+
+// NotificationService delivers desktop notifications through the platform
+// notifier. It is a service of its own so a platform that cannot notify,
+// such as a Linux session without D-Bus, leaves notifications unavailable
+// instead of failing startup.
+type NotificationService struct {
+	runtime  *collectorRuntime
+	platform *notifications.NotificationService
+	started  bool
+}
+
+func (s *NotificationService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	if err := s.platform.ServiceStartup(ctx, options); err != nil {
+		s.runtime.setNotificationSender(nil, err)
+		return nil
+	}
+	s.started = true
+	s.platform.OnNotificationResponse(func(result notifications.NotificationResult) {
+		if result.Error == nil {
+			eventID, _ := result.Response.UserInfo["eventId"].(string)
+			s.runtime.openNotifiedEvent(eventID)
+		}
+	})
+
+	// macOS asks the user on first launch; startup must not wait for the
+	// answer.
+	go func() {
+		if authorized, err := s.platform.RequestNotificationAuthorization(); err != nil || !authorized {
+			if err == nil {
+				err = errNotificationsDenied
+			}
+			s.runtime.setNotificationSender(nil, err)
+			return
+		}
+		s.runtime.setNotificationSender(s.send, nil)
+	}()
+	return nil
+}
+
+func (s *NotificationService) send(notification notify.Notification) error {
+	return s.platform.SendNotification(notifications.NotificationOptions{
+		ID:    notification.ID,
+		Title: notification.Title,
+		Body:  notification.Body,
+		Data:  map[string]any{"eventId": notification.EventID},
+	})
+}
+
+func (s *NotificationService) ServiceShutdown() error {
+	s.runtime.setNotificationSender(nil, nil)
+	if !s.started {
+		return nil
+	}
+	return s.platform.ServiceShutdown()
+}
+
+func (r *collectorRuntime) setNotificationSender(send func(notify.Notification) error, err error) {
+	r.notifyMu.Lock()
+	defer r.notifyMu.Unlock()
+
+	r.sendNotification = send
+	r.notifyErr = ""
+	if err != nil {
+		r.notifyErr = err.Error()
+	}
+}
+
+func (r *collectorRuntime) setNotificationSettings(settings notify.Settings) (NotificationStatus, error) {
+	if err := r.notifier.SetSettings(settings); err != nil {
+		return r.notificationStatus(), err
+	}
+	return r.notificationStatus(), nil
+}
+
+func (r *collectorRuntime) notificationStatus() NotificationStatus {
+	r.notifyMu.Lock()
+	defer r.notifyMu.Unlock()
+
+	return NotificationStatus{
+		Settings:  r.notifier.Settings(),
+		Available: r.sendNotification != nil,
+		Stats:     r.notifier.Stats(),
+		LastError: r.notifyErr,
+	}
+}
+
+// notifyEvents shows notifications for dd() calls and errors in a batch
+// while no phant window has focus; with a window focused the user already
+// sees them arrive.
+func (r *collectorRuntime) notifyEvents(batch []dump.Event) {
+	r.notifyMu.Lock()
+	send := r.sendNotification
+	r.notifyMu.Unlock()
+	if send == nil {
+		return
+	}
+
+	candidate := slices.ContainsFunc(batch, func(event dump.Event) bool {
+		return event.IsDD || notify.IsError(event)
+	})
+	if !candidate || r.windowFocused() {
+		return
+	}
+
+	now := time.Now()
+	var pending []notify.Notification
+	for _, event := range batch {
+		if notification, ok := r.notifier.Consider(event, now); ok {
+			pending = append(pending, notification)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	// Platform notifiers make IPC calls; the event bridge must not wait
+	// on them.
+	go func() {
+		for _, notification := range pending {
+			if err := send(notification); err != nil {
+				r.notifyMu.Lock()
+				r.notifyErr = err.Error()
+				r.notifyMu.Unlock()
+			}
+		}
+	}()
+}
+
+func (r *collectorRuntime) windowFocused() bool {
+	if r.app == nil {
+		return false
+	}
+	for _, window := range r.app.Window.GetAll() {
+		if window.IsFocused() {
+			return true
+		}
+	}
+	return false
+}
+
+// openNotifiedEvent brings the window forward and opens the event a
+// clicked notification was about, as a phant:// link to it would.
+func (r *collectorRuntime) openNotifiedEvent(eventID string) {
+	if r.app == nil {
+		return
+	}
+	r.showWindow()
+	if eventID != "" {
+		r.app.Event.Emit(DeepLinkRuntimeChannel, deeplink.ForEvent(eventID))
+	}
+}
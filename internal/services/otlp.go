@@ -0,0 +1,39 @@
+package services
+
+const otlpSubscriberBuffer = 1024
+
+// startOTLPExport feeds every published event to the OTLP exporter, which
+// filters and batches them without blocking.
+func (r *collectorRuntime) startOTLPExport() {
+	if r.collector == nil || r.exportingOTLP {
+		return
+	}
+
+	subID, ch := r.collector.Subscribe(otlpSubscriberBuffer)
+	r.otlpSubID = subID
+	r.exportingOTLP = true
+	r.otlpExporter.Start()
+
+	r.otlpWG.Add(1)
+	go func() {
+		defer r.otlpWG.Done()
+		for event := range ch {
+			// Collapsed repeats were exported when they first arrived.
+			if event.RepeatCount > 0 {
+				continue
+			}
+			r.otlpExporter.Handle(event)
+		}
+	}()
+}
+
+func (r *collectorRuntime) stopOTLPExport() {
+	if !r.exportingOTLP {
+		return
+	}
+
+	r.collector.Unsubscribe(r.otlpSubID)
+	r.otlpWG.Wait()
+	r.otlpExporter.Stop()
+	r.exportingOTLP = false
+}
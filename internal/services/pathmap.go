@@ -0,0 +1,73 @@
+package services
+
+import (
+	"strings"
+
+	"phant/internal/config"
+	"phant/internal/dump"
+)
+
+// ResolvedFrame is a trace frame with its file rewritten to the host path.
+type ResolvedFrame struct {
+	dump.TraceFrame
+	LocalFile string `json:"localFile"`
+	Mapped    bool   `json:"mapped"`
+}
+
+// projectFor returns the known project root containing file, matched on
+// either the reported root or its mapped local root. The longest root wins.
+func (r *collectorRuntime) projectFor(file string) string {
+	root := ""
+	best := 0
+	for _, candidate := range r.projects.Roots() {
+		resolved := r.projects.Resolve(candidate)
+		for _, prefix := range []string{resolved.ProjectRoot, resolved.LocalRoot} {
+			trimmed := strings.TrimRight(prefix, "/\\")
+			if trimmed == "" || len(trimmed) <= best {
+				continue
+			}
+			if file == trimmed || strings.HasPrefix(file, trimmed+"/") || strings.HasPrefix(file, trimmed+"\\") {
+				root, best = candidate, len(trimmed)
+			}
+		}
+	}
+	return root
+}
+
+// localPath maps a producer-reported file to the host. Files outside every
+// known project, or already local, are returned unchanged.
+func (r *collectorRuntime) localPath(file string) string {
+	root := r.projectFor(file)
+	if root == "" {
+		return file
+	}
+	mapped, _ := r.projects.MapPath(root, file)
+	return mapped
+}
+
+func (r *collectorRuntime) resolveTrace(eventID string) ([]ResolvedFrame, error) {
+	event, err := r.findEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := r.projects.Resolve(event.ProjectRoot).Config.PathMappings
+	frames := make([]ResolvedFrame, len(event.Trace))
+	for i, frame := range event.Trace {
+		local, mapped := config.MapPath(mappings, frame.File)
+		frames[i] = ResolvedFrame{TraceFrame: frame, LocalFile: local, Mapped: mapped}
+	}
+	return frames, nil
+}
+
+func (r *collectorRuntime) setPathMappings(projectRoot string, mappings []config.PathMapping) (config.ResolvedProject, error) {
+	if err := config.ValidatePathMappings(mappings); err != nil {
+		return config.ResolvedProject{}, err
+	}
+
+	overrides := r.projects.Overrides()[projectRoot]
+	overrides.PathMappings = mappings
+	r.projects.SetOverrides(projectRoot, overrides)
+	r.resetRedactors()
+	return r.projects.Resolve(projectRoot), nil
+}
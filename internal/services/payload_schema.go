@@ -0,0 +1,32 @@
+package services
+
+import (
+	"errors"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+	"phant/internal/signature"
+)
+
+// payloadSchema infers the schema of the payloads of every buffered event
+// made at origin, a file:line callsite as in query.Origin, with offloaded
+// payloads loaded back.
+func (r *collectorRuntime) payloadSchema(origin string) (signature.Schema, error) {
+	if origin == "" {
+		return signature.Schema{}, errors.New("origin must not be empty")
+	}
+	schema := signature.Schema{Origin: origin, Fields: []signature.FieldSummary{}, Variants: []signature.Group{}}
+	if r.collector == nil {
+		return schema, nil
+	}
+
+	events := r.collector.Select(func(event dump.Event) bool {
+		return query.Origin(event) == origin
+	})
+	for i := range events {
+		events[i] = r.loadOffloadedPayload(events[i])
+	}
+	schema = r.signatures.Schema(events)
+	schema.Origin = origin
+	return schema, nil
+}
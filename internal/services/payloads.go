@@ -0,0 +1,204 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"phant/internal/dump"
+	"phant/internal/expand"
+	"phant/internal/highlight"
+	"phant/internal/jsonpath"
+	"phant/internal/store"
+)
+
+var ErrPayloadNotStored = store.ErrPayloadNotStored
+
+const (
+	DefaultOffloadBytes = 64 * 1024
+	DefaultPreviewBytes = 4 * 1024
+)
+
+// PayloadStorageSettings control which payloads are kept out of memory.
+// Payloads larger than OffloadBytes are stored compressed on disk, and their
+// events keep a preview of at most PreviewBytes for lists and pushes to the
+// UI; zero OffloadBytes keeps every payload in memory.
+type PayloadStorageSettings struct {
+	OffloadBytes int `json:"offloadBytes"`
+	PreviewBytes int `json:"previewBytes"`
+}
+
+func DefaultPayloadStorageSettings() PayloadStorageSettings {
+	return PayloadStorageSettings{OffloadBytes: DefaultOffloadBytes, PreviewBytes: DefaultPreviewBytes}
+}
+
+func (s PayloadStorageSettings) Validate() error {
+	switch {
+	case s.OffloadBytes < 0 || s.PreviewBytes < 0:
+		return errors.New("payload storage sizes must not be negative")
+	case s.OffloadBytes > 0 && (s.PreviewBytes == 0 || s.PreviewBytes >= s.OffloadBytes):
+		return errors.New("previewBytes must be positive and smaller than offloadBytes")
+	}
+	return nil
+}
+
+// HighlightedPayload is an event's full payload with hints for the string
+// values in it that hold code.
+type HighlightedPayload struct {
+	Payload    json.RawMessage  `json:"payload"`
+	Highlights []highlight.Hint `json:"highlights"`
+}
+
+// ExpandedPayload is an event's full payload with the string values that
+// held a JSON document replaced by that document, and where that happened.
+type ExpandedPayload struct {
+	Payload    json.RawMessage    `json:"payload"`
+	Expansions []expand.Expansion `json:"expansions"`
+}
+
+func defaultPayloadDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, "phant", "payloads")
+}
+
+func (r *collectorRuntime) getPayloadStorage() PayloadStorageSettings {
+	r.payloadMu.Lock()
+	defer r.payloadMu.Unlock()
+	return r.payloadStorage
+}
+
+func (r *collectorRuntime) setPayloadStorage(settings PayloadStorageSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	r.payloadMu.Lock()
+	defer r.payloadMu.Unlock()
+	r.payloadStorage = settings
+	return nil
+}
+
+// limitPayload applies the payload size limit and keeps the full original on
+// disk so the UI can fetch it on demand. A payload still over the offload
+// size is then cut to a preview, unless it was truncated: the truncated
+// payload is what the event reports, so it stays in memory.
+func (r *collectorRuntime) limitPayload(event *dump.Event, limit int) {
+	if event.PayloadFormat == dump.PayloadFormatBinary {
+		r.offloadBinary(event)
+		return
+	}
+
+	original, truncated := event.LimitPayload(r.effectivePayloadLimit(limit))
+	if truncated {
+		ref, err := r.payloads.Put(original)
+		if err != nil {
+			event.Warnings = append(event.Warnings, dump.Warning{Field: "payload", Message: "full payload could not be stored: " + err.Error()})
+			return
+		}
+		event.PayloadRef = ref
+	}
+
+	settings := r.getPayloadStorage()
+	if truncated || settings.OffloadBytes <= 0 || len(event.Payload) <= settings.OffloadBytes {
+		return
+	}
+	kept := *event
+	original, offloaded := event.OffloadPayload(settings.OffloadBytes, settings.PreviewBytes)
+	if !offloaded {
+		return
+	}
+	ref, err := r.payloads.Put(original)
+	if err != nil {
+		*event = kept
+		return
+	}
+	event.PayloadRef = ref
+}
+
+func (r *collectorRuntime) fullPayload(eventID string) (json.RawMessage, error) {
+	event, err := r.findEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !event.Truncated && !event.Offloaded {
+		return event.Payload, nil
+	}
+	if event.PayloadFormat == dump.PayloadFormatBinary {
+		return r.encodedBinaryPayload(event.PayloadRef)
+	}
+	return r.payloads.Get(event.PayloadRef)
+}
+
+// loadOffloadedPayload brings back the payload of an offloaded event for
+// the search index. Truncated events keep their preview: what is stored for
+// them is the original, which is over the size limit.
+func (r *collectorRuntime) loadOffloadedPayload(event dump.Event) dump.Event {
+	if !event.Offloaded || event.PayloadFormat == dump.PayloadFormatBinary {
+		return event
+	}
+	if payload, err := r.payloads.Get(event.PayloadRef); err == nil && !event.Truncated {
+		event.Payload = payload
+	}
+	return event
+}
+
+// highlightedPayload detects code in the full payload. HTML payloads are
+// VarDumper's own rendering, not code the app dumped, and get no hints.
+func (r *collectorRuntime) highlightedPayload(eventID string) (HighlightedPayload, error) {
+	event, err := r.findEvent(eventID)
+	if err != nil {
+		return HighlightedPayload{}, err
+	}
+	payload, err := r.fullPayload(eventID)
+	if err != nil {
+		return HighlightedPayload{}, err
+	}
+
+	highlighted := HighlightedPayload{Payload: payload, Highlights: []highlight.Hint{}}
+	if event.PayloadFormat != dump.PayloadFormatHTML {
+		if highlighted.Highlights, err = highlight.Scan(payload); err != nil {
+			return HighlightedPayload{}, err
+		}
+	}
+	return highlighted, nil
+}
+
+// expandedPayload expands the JSON documents encoded in the full payload's
+// strings. Redaction rules run again on the result: members that were part
+// of a string when the event arrived can match them now.
+func (r *collectorRuntime) expandedPayload(eventID string) (ExpandedPayload, error) {
+	event, err := r.findEvent(eventID)
+	if err != nil {
+		return ExpandedPayload{}, err
+	}
+	payload, err := r.fullPayload(eventID)
+	if err != nil {
+		return ExpandedPayload{}, err
+	}
+
+	expanded, expansions, err := expand.Payload(payload)
+	if err != nil {
+		return ExpandedPayload{}, err
+	}
+	if len(expansions) > 0 {
+		if expanded, _, err = r.redactorFor(event.ProjectRoot).JSON(expanded, "payload"); err != nil {
+			return ExpandedPayload{}, err
+		}
+	}
+	return ExpandedPayload{Payload: expanded, Expansions: expansions}, nil
+}
+
+func (r *collectorRuntime) diffPayloads(idA string, idB string) ([]jsonpath.Change, error) {
+	before, err := r.fullPayload(idA)
+	if err != nil {
+		return nil, err
+	}
+	after, err := r.fullPayload(idB)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpath.Diff(before, after)
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"phant/internal/dump"
+	"phant/internal/preview"
+)
+
+// withPreviews fills in the list row preview of events bound for the UI.
+// Events must be copies; buffered and stored events never carry one.
+func (r *collectorRuntime) withPreviews(events []dump.Event) []dump.Event {
+	for i := range events {
+		events[i].Preview = r.previews.Preview(events[i])
+	}
+	return events
+}
+
+func (r *collectorRuntime) setPreviewOptions(options preview.Options) error {
+	return r.previews.SetOptions(options)
+}
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"phant/internal/dump"
+	"phant/internal/proctree"
+)
+
+// processTree links the buffered command events into process trees,
+// optionally of one host.
+func (r *collectorRuntime) processTree(hostname string) []proctree.Host {
+	if r.collector == nil {
+		return []proctree.Host{}
+	}
+	events := r.collector.Select(func(event dump.Event) bool {
+		return event.Command != nil && (hostname == "" || event.Host.Hostname == hostname)
+	})
+	return proctree.Build(events)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/pipeline"
+)
+
+const maxQuarantinedEvents = 200
+
+// QuarantinedEvent is an ingested line that failed strict validation. Event
+// holds whatever could still be decoded and is nil for lines that are not
+// JSON objects at all.
+type QuarantinedEvent struct {
+	ReceivedAt string                 `json:"receivedAt"`
+	Line       string                 `json:"line"`
+	Event      *dump.Event            `json:"event"`
+	Issues     []dump.ValidationIssue `json:"issues"`
+}
+
+// ingestLine decodes lines from live sources; rejected lines are kept in
+// the quarantine rather than dropped silently.
+// Control lines are applied instead of being ingested.
+func (r *collectorRuntime) ingestLine(line string) (*dump.Event, error) {
+	if dump.IsControlLine(line) {
+		r.ingestControl(line)
+		return nil, nil
+	}
+
+	received := time.Now()
+	event, err := r.decodeLine(line)
+	if err == nil {
+		if event != nil && r.ignored(*event) {
+			return nil, nil
+		}
+		if event != nil {
+			r.tracer.Mark(event.ID, pipeline.StageReceived, received)
+			r.tracer.Mark(event.ID, pipeline.StageDecoded, time.Now())
+		}
+		return event, nil
+	}
+
+	partial, issues := dump.DecodeNDJSONLineLenientWithOptions(line, r.getDecodeOptions())
+	entry := QuarantinedEvent{
+		ReceivedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Line:       line,
+		Event:      partial,
+		Issues:     issues,
+	}
+	r.redactQuarantined(&entry)
+	r.quarantine(entry)
+	return nil, err
+}
+
+func (r *collectorRuntime) quarantine(entry QuarantinedEvent) {
+	r.quarantineMu.Lock()
+	defer r.quarantineMu.Unlock()
+
+	r.quarantined = append(r.quarantined, entry)
+	if len(r.quarantined) > maxQuarantinedEvents {
+		r.quarantined = r.quarantined[len(r.quarantined)-maxQuarantinedEvents:]
+	}
+}
+
+func (r *collectorRuntime) quarantinedEvents() []QuarantinedEvent {
+	r.quarantineMu.Lock()
+	defer r.quarantineMu.Unlock()
+
+	return append([]QuarantinedEvent{}, r.quarantined...)
+}
+
+func (r *collectorRuntime) clearQuarantine() {
+	r.quarantineMu.Lock()
+	defer r.quarantineMu.Unlock()
+
+	r.quarantined = nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"phant/internal/query"
+	"phant/internal/ray"
+)
+
+// RayStatus reports the spatie/ray compatible listener. An empty Address
+// means the listener is disabled.
+type RayStatus struct {
+	Address      string `json:"address"`
+	Running      bool   `json:"running"`
+	Requests     uint64 `json:"requests"`
+	Events       uint64 `json:"events"`
+	Rejected     uint64 `json:"rejected"`
+	Unauthorized uint64 `json:"unauthorized"`
+	LastError    string `json:"lastError,omitempty"`
+}
+
+func (r *collectorRuntime) newRayServer(address string) *ray.Server {
+	server := ray.NewServer(address, r.sourceHandler(SourceRay), r.clearEvents)
+	server.SetAuth(r.auth)
+	server.SetTLS(r.listenerTLSConfig())
+	server.SetCapture(r.captureRecorder)
+	return server
+}
+
+func (r *collectorRuntime) setRayAddress(address string) (RayStatus, error) {
+	err := r.ray.configure(address, r.collectorRunning())
+	_ = r.refreshDiscovery()
+	return r.rayStatus(), err
+}
+
+func (r *collectorRuntime) rayStatus() RayStatus {
+	var status RayStatus
+	r.ray.inspect(func(address string, server *ray.Server, running bool, lastErr string) {
+		status = RayStatus{Address: address, LastError: lastErr}
+		if running {
+			stats := server.Stats()
+			status.Address = stats.Address
+			status.Running = true
+			status.Requests = stats.Requests
+			status.Events = stats.Events
+			status.Rejected = stats.Rejected
+			status.Unauthorized = stats.Unauthorized
+		}
+	})
+	return status
+}
+
+// clearEvents empties the in-memory event list, as Ray's clear_all does.
+func (r *collectorRuntime) clearEvents() {
+	_, _ = r.clearDumpEvents(query.Filter{})
+}
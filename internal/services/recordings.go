@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+
+	"phant/internal/dump"
+	"phant/internal/recording"
+)
+
+// recordingDirName is where session recordings are kept, next to the
+// session logs.
+const recordingDirName = "recordings"
+
+const recordSubscriberBuffer = 4096
+
+// RecordingStatus reports whether a session is being recorded and how much
+// of it is on disk.
+type RecordingStatus struct {
+	Active bool `json:"active"`
+	recording.Info
+}
+
+// ReplayFrame is one replayed event, sent on ReplayEventRuntimeChannel at
+// its recorded pace. The last frame of a replay has Done set and no event;
+// Cancelled tells a replay that was stopped from one that finished.
+type ReplayFrame struct {
+	Name      string      `json:"name"`
+	Index     int         `json:"index"`
+	Total     int         `json:"total"`
+	OffsetMs  int64       `json:"offsetMs"`
+	Event     *dump.Event `json:"event,omitempty"`
+	Done      bool        `json:"done,omitempty"`
+	Cancelled bool        `json:"cancelled,omitempty"`
+}
+
+func (r *collectorRuntime) recordingDir() string {
+	return filepath.Join(r.storeDir, recordingDirName)
+}
+
+// startRecording writes every event published from now on into a new
+// recording called name, with the time it arrived.
+func (r *collectorRuntime) startRecording(name string) (RecordingStatus, error) {
+	r.recordingMu.Lock()
+	defer r.recordingMu.Unlock()
+
+	if r.recorder != nil {
+		return RecordingStatus{Active: true, Info: r.recorder.Info()}, ErrRecordingActive
+	}
+	if !r.collectorRunning() {
+		return RecordingStatus{}, ErrCollectorNotRunning
+	}
+	writer, err := recording.Create(r.recordingDir(), name)
+	if err != nil {
+		return RecordingStatus{}, err
+	}
+
+	subID, ch := r.collector.Subscribe(recordSubscriberBuffer)
+	r.recorder = writer
+	r.recordSubID = subID
+
+	r.recordWG.Add(1)
+	go func() {
+		defer r.recordWG.Done()
+		for event := range ch {
+			// Collapsed repeats were recorded when they first arrived.
+			if event.RepeatCount > 0 {
+				continue
+			}
+			// A failed write is reported when the recording stops.
+			_ = writer.Write(r.loadOffloadedPayload(event))
+		}
+	}()
+	return RecordingStatus{Active: true, Info: writer.Info()}, nil
+}
+
+func (r *collectorRuntime) stopRecording() (RecordingStatus, error) {
+	r.recordingMu.Lock()
+	defer r.recordingMu.Unlock()
+
+	if r.recorder == nil {
+		return RecordingStatus{}, nil
+	}
+	r.collector.Unsubscribe(r.recordSubID)
+	r.recordWG.Wait()
+	info, err := r.recorder.Close()
+	r.recorder = nil
+	return RecordingStatus{Info: info}, err
+}
+
+func (r *collectorRuntime) recordingStatus() RecordingStatus {
+	r.recordingMu.Lock()
+	defer r.recordingMu.Unlock()
+
+	if r.recorder == nil {
+		return RecordingStatus{}
+	}
+	return RecordingStatus{Active: true, Info: r.recorder.Info()}
+}
+
+func (r *collectorRuntime) recordingSubscription() (int, bool) {
+	r.recordingMu.Lock()
+	defer r.recordingMu.Unlock()
+	return r.recordSubID, r.recorder != nil
+}
+
+func (r *collectorRuntime) listRecordings() ([]recording.Info, error) {
+	return recording.List(r.recordingDir())
+}
+
+// deleteRecording removes a stored recording; the one being recorded
+// cannot be deleted until it stops.
+func (r *collectorRuntime) deleteRecording(name string) error {
+	if status := r.recordingStatus(); status.Active && status.Name == name {
+		return ErrRecordingActive
+	}
+	return recording.Delete(r.recordingDir(), name)
+}
+
+// replaySession emits the recorded events of name to the frontend with
+// their recorded timing, divided by speed, and returns once the replay has
+// started. Starting a replay stops the one already running.
+func (r *collectorRuntime) replaySession(name string, speed float64) (recording.Info, error) {
+	if !recording.ValidSpeed(speed) {
+		return recording.Info{}, recording.ErrInvalidSpeed
+	}
+	info, frames, err := recording.Read(r.recordingDir(), name)
+	if err != nil {
+		return recording.Info{}, err
+	}
+
+	r.replayMu.Lock()
+	defer r.replayMu.Unlock()
+
+	r.stopReplayLocked()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	r.replayCancel, r.replayDone = cancel, done
+
+	go func() {
+		defer close(done)
+		defer cancel()
+		err := recording.Play(ctx, frames, speed, func(index int, frame recording.Frame) {
+			event := frame.Event
+			event.Preview = r.previews.Preview(event)
+			r.emitReplayFrame(ReplayFrame{Name: name, Index: index, Total: len(frames), OffsetMs: frame.OffsetMs, Event: &event})
+		})
+		r.emitReplayFrame(ReplayFrame{Name: name, Index: len(frames), Total: len(frames), Done: true, Cancelled: err != nil})
+	}()
+	return info, nil
+}
+
+// stopSessionReplay stops the running replay, if any, and waits for its
+// last frame to be sent.
+func (r *collectorRuntime) stopSessionReplay() {
+	r.replayMu.Lock()
+	defer r.replayMu.Unlock()
+	r.stopReplayLocked()
+}
+
+func (r *collectorRuntime) stopReplayLocked() {
+	if r.replayCancel == nil {
+		return
+	}
+	r.replayCancel()
+	<-r.replayDone
+	r.replayCancel, r.replayDone = nil, nil
+}
+
+func (r *collectorRuntime) emitReplayFrame(frame ReplayFrame) {
+	if r.app != nil {
+		r.app.Event.Emit(ReplayEventRuntimeChannel, frame)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"time"
+
+	"phant/internal/config"
+	"phant/internal/dump"
+	"phant/internal/redact"
+)
+
+// redactorTTL bounds how long a compiled project redactor is reused, so
+// edits to .phant.toml apply within a second without a stat per event.
+const redactorTTL = time.Second
+
+type cachedRedactor struct {
+	redactor *redact.Redactor
+	built    time.Time
+}
+
+// redactorFor combines the global rules with those of the event's project.
+// A project whose rules do not compile falls back to the global rules.
+func (r *collectorRuntime) redactorFor(projectRoot string) *redact.Redactor {
+	r.redactMu.Lock()
+	cached, ok := r.redactors[projectRoot]
+	global := r.redactionRules
+	r.redactMu.Unlock()
+	if ok && time.Since(cached.built) < redactorTTL {
+		return cached.redactor
+	}
+
+	rules := global
+	if projectRoot != "" {
+		rules = append(append([]config.RedactionRule{}, global...), r.projects.Resolve(projectRoot).Config.Redaction...)
+	}
+	redactor, err := redact.New(rules)
+	if err != nil {
+		redactor, _ = redact.New(global)
+	}
+
+	r.redactMu.Lock()
+	r.redactors[projectRoot] = cachedRedactor{redactor: redactor, built: time.Now()}
+	r.redactMu.Unlock()
+	return redactor
+}
+
+// finishEvent runs the consumer-side steps every ingest path shares after
+// decoding: redaction first, so neither the buffer nor the full payload
+// saved for truncated events ever holds a masked value, then the slow query
+// flag and the payload limit.
+func (r *collectorRuntime) finishEvent(event *dump.Event, limit int) {
+	r.redactorFor(event.ProjectRoot).Event(event)
+	r.flagSlowQuery(event)
+	r.limitPayload(event, limit)
+}
+
+// redactQuarantined masks a rejected line and its partial decode before
+// they are shown.
+func (r *collectorRuntime) redactQuarantined(entry *QuarantinedEvent) {
+	projectRoot := ""
+	if entry.Event != nil {
+		projectRoot = entry.Event.ProjectRoot
+	}
+	redactor := r.redactorFor(projectRoot)
+	if redactor.Empty() {
+		return
+	}
+
+	if entry.Event != nil {
+		redactor.Event(entry.Event)
+	}
+	if line, paths, err := redactor.JSON([]byte(entry.Line), ""); err == nil {
+		if len(paths) > 0 {
+			entry.Line = string(line)
+		}
+	} else {
+		entry.Line, _ = redactor.Text(entry.Line)
+	}
+}
+
+func (r *collectorRuntime) getRedactionRules() []config.RedactionRule {
+	r.redactMu.Lock()
+	defer r.redactMu.Unlock()
+	return append([]config.RedactionRule{}, r.redactionRules...)
+}
+
+func (r *collectorRuntime) setRedactionRules(rules []config.RedactionRule) error {
+	if _, err := redact.New(rules); err != nil {
+		return err
+	}
+
+	r.redactMu.Lock()
+	r.redactionRules = append([]config.RedactionRule{}, rules...)
+	r.redactMu.Unlock()
+	r.resetRedactors()
+	return nil
+}
+
+// resetRedactors drops compiled redactors after rules or project overrides
+// change.
+func (r *collectorRuntime) resetRedactors() {
+	r.redactMu.Lock()
+	defer r.redactMu.Unlock()
+	r.redactors = make(map[string]cachedRedactor)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"phant/internal/dump"
+	"phant/internal/query"
+)
+
+type RedecodeFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+type RedecodeResult struct {
+	Matched   int `json:"matched"`
+	Redecoded int `json:"redecoded"`
+	// NoRawLine counts matches ingested without a retained line, either
+	// before retention was enabled or through a protocol adapter.
+	NoRawLine int               `json:"noRawLine"`
+	Failed    []RedecodeFailure `json:"failed"`
+}
+
+// redecodeEvents runs the retained raw lines of matching buffered events
+// through the current decoder and replaces the events in place. Events the
+// decoder now rejects are left as they were and reported.
+func (r *collectorRuntime) redecodeEvents(filter query.Filter) (RedecodeResult, error) {
+	if r.collector == nil {
+		return RedecodeResult{}, ErrCollectorNotRunning
+	}
+	matcher, err := r.compileFilter(filter)
+	if err != nil {
+		return RedecodeResult{}, err
+	}
+
+	result := RedecodeResult{Failed: []RedecodeFailure{}}
+	replacements := map[string]dump.Event{}
+	for _, event := range r.collector.Select(matcher.Match) {
+		result.Matched++
+		if len(event.Raw) == 0 {
+			result.NoRawLine++
+			continue
+		}
+
+		decoded, err := r.decodeLine(string(event.Raw))
+		if err != nil {
+			result.Failed = append(result.Failed, RedecodeFailure{ID: event.ID, Error: err.Error()})
+			continue
+		}
+		// Keep the line even if retention has since been turned off.
+		decoded.Raw = event.Raw
+		replacements[event.ID] = *decoded
+	}
+
+	result.Redecoded = r.collector.Replace(replacements)
+	return result, nil
+}
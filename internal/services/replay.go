@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+
+	"phant/internal/replay"
+)
+
+// replayRequest sends the request a buffered event was dumped from again.
+// The event is stored redacted, so masked headers are only sent when the
+// overrides supply them.
+func (r *collectorRuntime) replayRequest(eventID string, overrides replay.Overrides) (replay.Response, error) {
+	event, err := r.findEvent(eventID)
+	if err != nil {
+		return replay.Response{}, err
+	}
+	if event.HTTP == nil {
+		return replay.Response{}, ErrNotHTTPRequest
+	}
+	return replay.Do(context.Background(), *event.HTTP, overrides)
+}
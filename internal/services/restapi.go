@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"phant/internal/deeplink"
+	"phant/internal/dump"
+	"phant/internal/query"
+	"phant/internal/restapi"
+)
+
+// APIStatus reports the read-only REST API. An empty Address means it is
+// disabled, which is the default.
+type APIStatus struct {
+	Address      string `json:"address"`
+	Running      bool   `json:"running"`
+	Requests     uint64 `json:"requests"`
+	Rejected     uint64 `json:"rejected"`
+	Unauthorized uint64 `json:"unauthorized"`
+	LastError    string `json:"lastError,omitempty"`
+}
+
+// apiSource answers REST API queries from the buffer, as the bindings of
+// the same names do.
+type apiSource struct {
+	runtime *collectorRuntime
+}
+
+func (s apiSource) Events(filter query.Filter, page query.Page) (query.Result, error) {
+	return s.runtime.queryEvents(filter, page)
+}
+
+// Event returns the event with its full payload when one was kept; a
+// payload that cannot be read leaves the preview in place.
+func (s apiSource) Event(id string) (dump.Event, error) {
+	event, err := s.runtime.findEvent(id)
+	if errors.Is(err, ErrEventNotFound) {
+		return dump.Event{}, restapi.ErrEventNotFound
+	}
+	if err != nil {
+		return dump.Event{}, err
+	}
+	if payload, err := s.runtime.fullPayload(id); err == nil {
+		event.Payload = payload
+	}
+	return event, nil
+}
+
+func (s apiSource) RequestEvents(requestID string) []dump.Event {
+	return s.runtime.getRequestTimeline(requestID)
+}
+
+func (s apiSource) Stats(timeRange query.TimeRange) (query.Stats, error) {
+	return s.runtime.dumpStats(timeRange)
+}
+
+func (s apiSource) Open(link deeplink.Link) {
+	s.runtime.emitDeepLink(link)
+}
+
+// httpLink renders link against the running REST API, so it can be opened
+// from a browser or a terminal that does not handle phant:// URLs.
+func (r *collectorRuntime) httpLink(link deeplink.Link) (string, error) {
+	if err := link.Validate(); err != nil {
+		return "", err
+	}
+	status := r.apiStatus()
+	if !status.Running {
+		return "", ErrAPINotRunning
+	}
+	scheme := "http"
+	if r.listenerTLSConfig() != nil {
+		scheme = "https"
+	}
+	return link.HTTPURL(fmt.Sprintf("%s://%s", scheme, status.Address))
+}
+
+func (r *collectorRuntime) newAPIServer(address string) *restapi.Server {
+	server := restapi.NewServer(address, apiSource{runtime: r})
+	server.SetAuth(r.auth)
+	server.SetTLS(r.listenerTLSConfig())
+	return server
+}
+
+// setAPIAddress serves the REST API on address while the collector runs.
+// It is not advertised: scripts using it run on a machine that knows it.
+func (r *collectorRuntime) setAPIAddress(address string) (APIStatus, error) {
+	err := r.api.configure(address, r.collectorRunning())
+	return r.apiStatus(), err
+}
+
+func (r *collectorRuntime) apiStatus() APIStatus {
+	var status APIStatus
+	r.api.inspect(func(address string, server *restapi.Server, running bool, lastErr string) {
+		status = APIStatus{Address: address, LastError: lastErr}
+		if running {
+			stats := server.Stats()
+			status.Address = stats.Address
+			status.Running = true
+			status.Requests = stats.Requests
+			status.Rejected = stats.Rejected
+			status.Unauthorized = stats.Unauthorized
+		}
+	})
+	return status
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"phant/internal/config"
+	"phant/internal/ignore"
+)
+
+// RuleFile is a watched rule file and the version of it in effect. Version
+// is "" while the file does not exist; a file that fails to load keeps the
+// version it had and reports why in LastError.
+type RuleFile struct {
+	Path        string `json:"path"`
+	ProjectRoot string `json:"projectRoot,omitempty"`
+	Version     string `json:"version"`
+	AppliedAt   string `json:"appliedAt"`
+	LastError   string `json:"lastError,omitempty"`
+}
+
+// RuleVersions are the rule files phant applies live: the settings with
+// the global redaction rules, the ignored callsites, and each project's
+// .phant.toml.
+type RuleVersions struct {
+	Settings RuleFile   `json:"settings"`
+	Ignores  RuleFile   `json:"ignores"`
+	Projects []RuleFile `json:"projects"`
+}
+
+func (r *collectorRuntime) settingsPath() string {
+	return filepath.Join(r.storeDir, config.SettingsFileName)
+}
+
+func (r *collectorRuntime) ignoresPath() string {
+	return filepath.Join(r.storeDir, ignore.FileName)
+}
+
+// startRuleWatcher applies edits made to rule files outside phant, such as
+// a pulled .phant.toml, without restarting listeners. The files are read
+// at startup already, so their current contents are only recorded.
+func (r *collectorRuntime) startRuleWatcher() {
+	if r.ruleWatcher != nil {
+		return
+	}
+
+	r.recordRuleFile(r.settingsPath(), "", nil)
+	r.recordRuleFile(r.ignoresPath(), "", nil)
+	r.ruleWatcher = config.NewWatcher(r.ruleFilePaths, r.reloadRules)
+	r.ruleWatcher.Start(config.DefaultWatchInterval)
+}
+
+func (r *collectorRuntime) stopRuleWatcher() {
+	if r.ruleWatcher == nil {
+		return
+	}
+
+	r.ruleWatcher.Stop()
+	r.ruleWatcher = nil
+}
+
+// ruleFilePaths lists the files to watch; projects join as their first
+// events arrive.
+func (r *collectorRuntime) ruleFilePaths() []string {
+	paths := []string{r.settingsPath(), r.ignoresPath()}
+	for _, root := range r.projects.Roots() {
+		path := r.projects.ConfigPath(root)
+		r.rulesMu.Lock()
+		_, known := r.ruleFiles[path]
+		r.rulesMu.Unlock()
+		if !known {
+			r.recordRuleFile(path, root, nil)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func (r *collectorRuntime) reloadRules(paths []string) {
+	for _, path := range paths {
+		switch path {
+		case r.settingsPath():
+			r.recordRuleFile(path, "", r.reloadRedactionRules())
+		case r.ignoresPath():
+			r.recordRuleFile(path, "", r.reloadIgnores())
+		default:
+			r.rulesMu.Lock()
+			root := r.ruleFiles[path].ProjectRoot
+			r.rulesMu.Unlock()
+			var err error
+			if lastError := r.projects.Resolve(root).LastError; lastError != "" {
+				err = errors.New(lastError)
+			}
+			r.resetRedactors()
+			r.recordRuleFile(path, root, err)
+		}
+	}
+
+	if r.app != nil {
+		r.app.Event.Emit(RulesReloadedRuntimeChannel, r.ruleVersions())
+	}
+}
+
+// reloadRedactionRules applies the redaction rules of an edited settings
+// file. The other settings are applied at startup or from the UI, which
+// may restart listeners.
+func (r *collectorRuntime) reloadRedactionRules() error {
+	settings, err := config.LoadSettings(r.storeDir)
+	if err != nil {
+		return err
+	}
+	return r.setRedactionRules(settings.RedactionRules)
+}
+
+// reloadIgnores rereads the ignored callsites, or retries opening them if
+// the file could not be read before.
+func (r *collectorRuntime) reloadIgnores() error {
+	r.ignoresMu.Lock()
+	defer r.ignoresMu.Unlock()
+
+	if r.ignores == nil {
+		r.ignores, r.ignoreErr = ignore.Open(r.storeDir)
+		return r.ignoreErr
+	}
+	return r.ignores.Reload()
+}
+
+// recordRuleFile notes the version of path now in effect, unless applying
+// it failed with err.
+func (r *collectorRuntime) recordRuleFile(path string, projectRoot string, err error) {
+	r.rulesMu.Lock()
+	defer r.rulesMu.Unlock()
+
+	file, known := r.ruleFiles[path]
+	file.Path = path
+	file.ProjectRoot = projectRoot
+	file.LastError = ""
+	if err == nil {
+		file.Version, err = config.FileVersion(path)
+		file.AppliedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	if err != nil {
+		file.LastError = err.Error()
+		if !known {
+			file.AppliedAt = time.Now().UTC().Format(time.RFC3339Nano)
+		}
+	}
+	r.ruleFiles[path] = file
+}
+
+func (r *collectorRuntime) ruleVersions() RuleVersions {
+	r.rulesMu.Lock()
+	defer r.rulesMu.Unlock()
+
+	versions := RuleVersions{
+		Settings: r.ruleFiles[r.settingsPath()],
+		Ignores:  r.ruleFiles[r.ignoresPath()],
+		Projects: []RuleFile{},
+	}
+	versions.Settings.Path = r.settingsPath()
+	versions.Ignores.Path = r.ignoresPath()
+	for path, file := range r.ruleFiles {
+		if path != versions.Settings.Path && path != versions.Ignores.Path {
+			versions.Projects = append(versions.Projects, file)
+		}
+	}
+	sort.Slice(versions.Projects, func(i, j int) bool {
+		return versions.Projects[i].ProjectRoot < versions.Projects[j].ProjectRoot
+	})
+	return versions
+}
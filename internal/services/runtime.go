@@ -1,22 +1,201 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"phant/internal/alert"
+	"phant/internal/annotation"
+	"phant/internal/capture"
 	"phant/internal/collector"
+	"phant/internal/config"
+	"phant/internal/dashboard"
+	"phant/internal/ddgate"
+	"phant/internal/discovery"
 	"phant/internal/dump"
+	"phant/internal/forward"
+	"phant/internal/health"
+	"phant/internal/hook"
+	"phant/internal/hosts"
+	"phant/internal/ignore"
+	"phant/internal/ingest"
+	"phant/internal/monolog"
+	"phant/internal/netauth"
+	"phant/internal/nettls"
+	"phant/internal/notify"
+	"phant/internal/otlp"
+	"phant/internal/pipeline"
+	"phant/internal/preview"
+	"phant/internal/query"
+	"phant/internal/ray"
+	"phant/internal/recording"
+	"phant/internal/restapi"
+	"phant/internal/retention"
+	"phant/internal/savedfilter"
+	"phant/internal/search"
+	"phant/internal/signature"
+	"phant/internal/source"
+	"phant/internal/store"
+	"phant/internal/tail"
+	"phant/internal/trash"
+	"phant/internal/vardumper"
+	"phant/internal/workspace"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
 type collectorRuntime struct {
-	app             *application.App
-	socketPath      string
-	collector       *collector.Server
-	collectorStatus CollectorStatus
-	collectorSubID  int
-	collectorDone   chan struct{}
-	collectorWG     sync.WaitGroup
+	app              *application.App
+	socketPath       string
+	collector        *collector.Server
+	collectorStatus  CollectorStatus
+	collectorSubID   int
+	collectorDone    chan struct{}
+	collectorWG      sync.WaitGroup
+	bridge           *pipeline.Batcher
+	pushMu           sync.Mutex
+	liveGate         *pipeline.Gate
+	dedupMu          sync.Mutex
+	dedup            DedupSettings
+	clockMu          sync.Mutex
+	clock            ClockSettings
+	gates            *ddgate.Queue
+	annotationsMu    sync.Mutex
+	annotations      *annotation.Set
+	filtersMu        sync.Mutex
+	filters          *savedfilter.Store
+	dashboardsMu     sync.Mutex
+	dashboards       *dashboard.Store
+	tails            *tail.Manager
+	config           *config.Registry
+	projects         *config.Projects
+	workspace        *workspace.Workspace
+	hosts            *hosts.Registry
+	sources          *source.Cache
+	redactMu         sync.Mutex
+	redactionRules   []config.RedactionRule
+	redactors        map[string]cachedRedactor
+	retentionMu      sync.Mutex
+	retentionPolicy  retention.Policy
+	retention        *retention.Engine
+	streamMu         sync.Mutex
+	streams          map[int]DumpStreamSubscription
+	decodeMu         sync.RWMutex
+	decodeOptions    dump.DecodeOptions
+	signatures       *signature.Cache
+	previews         *preview.Cache
+	shapes           *signature.Tracker
+	quarantineMu     sync.Mutex
+	quarantined      []QuarantinedEvent
+	payloads         *store.Payloads
+	payloadMu        sync.Mutex
+	payloadStorage   PayloadStorageSettings
+	indexer          *search.Indexer
+	indexSubID       int
+	indexWG          sync.WaitGroup
+	varDumper        *listenerSlot[*vardumper.Server]
+	ray              *listenerSlot[*ray.Server]
+	logs             *listenerSlot[*monolog.Server]
+	api              *listenerSlot[*restapi.Server]
+	ingestMu         sync.Mutex
+	ingestSpecs      []ingest.Spec
+	ingestListeners  map[string]*ingest.Listener
+	ingestErrors     map[string]string
+	socketCounts     sourceCounts
+	auth             *netauth.Guard
+	authMu           sync.Mutex
+	authRequired     bool
+	authToken        string
+	tlsMu            sync.Mutex
+	tlsSettings      nettls.Settings
+	tlsCert          *nettls.Certificate
+	discoveryMu      sync.Mutex
+	discoveryOn      bool
+	advertised       []discovery.Service
+	advertiser       *discovery.Advertiser
+	advertiseErr     string
+	storeDir         string
+	store            *store.Store
+	journal          atomic.Pointer[store.Journal]
+	journalErr       string
+	storeErr         string
+	storeSubID       int
+	storeWG          sync.WaitGroup
+	archiveCancel    context.CancelFunc
+	archiveWG        sync.WaitGroup
+	importsMu        sync.Mutex
+	imports          map[string]context.CancelFunc
+	summaryMu        sync.Mutex
+	summaries        map[string]sessionSummary
+	searchMu         sync.Mutex
+	health           *health.Monitor
+	healthThresholds health.Thresholds
+	notifier         *notify.Notifier
+	notifyMu         sync.Mutex
+	sendNotification func(notify.Notification) error
+	notifyErr        string
+	settingsMu       sync.Mutex
+	settings         config.Settings
+	settingsLoaded   bool
+	settingsBad      bool
+	settingsErr      string
+	trash            *trash.Bin
+	ignoresMu        sync.Mutex
+	ignores          *ignore.Set
+	ignoreErr        error
+	ruleWatcher      *config.Watcher
+	rulesMu          sync.Mutex
+	ruleFiles        map[string]RuleFile
+	controlMu        sync.Mutex
+	sections         []StreamSection
+	sectionSeq       uint64
+	requestLabels    map[string]string
+	labelOrder       []string
+	captureMu        sync.Mutex
+	capture          *capture.Recorder
+	recordingMu      sync.Mutex
+	recorder         *recording.Writer
+	recordSubID      int
+	recordWG         sync.WaitGroup
+	replayMu         sync.Mutex
+	replayCancel     context.CancelFunc
+	replayDone       chan struct{}
+	trayMu           sync.Mutex
+	tray             *application.SystemTray
+	trayPause        *application.MenuItem
+	unread           int
+	degradation      atomic.Value
+	shapeSamples     atomic.Uint64
+	slowQueryMs      atomic.Int64
+	forwarder        *forward.Forwarder
+	forwarding       bool
+	forwardSubID     int
+	forwardWG        sync.WaitGroup
+	alertsMu         sync.Mutex
+	alerts           *alert.Engine
+	alerting         bool
+	alertSubID       int
+	alertWG          sync.WaitGroup
+	alertClient      *http.Client
+	hooksMu          sync.Mutex
+	hooks            *hook.Runner
+	hooksLoaded      bool
+	hooking          bool
+	hookSubID        int
+	hookWG           sync.WaitGroup
+	otlpExporter     *otlp.Exporter
+	exportingOTLP    bool
+	otlpSubID        int
+	otlpWG           sync.WaitGroup
+	tracer           *pipeline.Tracer
+	tuning           PipelineTuning
+	activeTuning     PipelineTuning
+	editorMu         sync.Mutex
+	editor           config.EditorConfig
 }
 
 func (r *collectorRuntime) collectorSocketPath() string {
@@ -51,3 +230,222 @@ func (r *collectorRuntime) getRecentEvents(limit int) []dump.Event {
 
 	return events[len(events)-limit:]
 }
+
+func (r *collectorRuntime) queryEvents(filter query.Filter, page query.Page) (query.Result, error) {
+	matcher, err := r.compileFilter(filter)
+	if err != nil {
+		return query.Result{}, err
+	}
+
+	var matches []dump.Event
+	if r.collector != nil {
+		matches = r.collector.Select(matcher.Match)
+	}
+	return query.PaginateBy(matches, page, filter.TimeBasis), nil
+}
+
+// eventPage selects the matches before cursor, counting every match on the
+// way, and summarizes the page of them that follows cursor.
+func (r *collectorRuntime) eventPage(cursor string, limit int, filter query.Filter) (query.CursorPage, error) {
+	matcher, err := r.compileFilter(filter)
+	if err != nil {
+		return query.CursorPage{}, err
+	}
+	after, err := query.ParseCursor(cursor)
+	if err != nil {
+		return query.CursorPage{}, err
+	}
+
+	total := 0
+	var matches []dump.Event
+	if r.collector != nil {
+		matches = r.collector.Select(func(event dump.Event) bool {
+			if !matcher.Match(event) {
+				return false
+			}
+			total++
+			return after.Before(event, filter.TimeBasis)
+		})
+	}
+
+	events, next := query.PageBefore(matches, after, limit, filter.TimeBasis)
+	page := query.CursorPage{Events: make([]query.Summary, 0, len(events)), Total: total, Next: next.String()}
+	for _, event := range r.withPreviews(events) {
+		page.Events = append(page.Events, query.Summarize(event))
+	}
+	return page, nil
+}
+
+// browseEvents selects the filter's matches once and returns both a page
+// of them and their facets.
+func (r *collectorRuntime) browseEvents(filter query.Filter, page query.Page) (query.Browse, error) {
+	matcher, err := r.compileFilter(filter)
+	if err != nil {
+		return query.Browse{}, err
+	}
+
+	var matches []dump.Event
+	if r.collector != nil {
+		matches = r.collector.Select(matcher.Match)
+	}
+	return query.Browse{
+		Result: query.PaginateBy(matches, page, filter.TimeBasis),
+		Facets: query.Facet(matches, r.eventTags()),
+	}, nil
+}
+
+func (r *collectorRuntime) dumpFacets(filter query.Filter) (query.Facets, error) {
+	matcher, err := r.compileFilter(filter)
+	if err != nil {
+		return query.Facets{}, err
+	}
+
+	var matches []dump.Event
+	if r.collector != nil {
+		matches = r.collector.Select(matcher.Match)
+	}
+	return query.Facet(matches, r.eventTags()), nil
+}
+
+func (r *collectorRuntime) dumpStats(timeRange query.TimeRange) (query.Stats, error) {
+	matcher, err := timeRange.Compile()
+	if err != nil {
+		return query.Stats{}, err
+	}
+
+	var matches []dump.Event
+	if r.collector != nil {
+		matches = r.collector.Select(matcher.Match)
+	}
+	return query.Aggregate(matches, timeRange), nil
+}
+
+func (r *collectorRuntime) getRequestTimeline(requestID string) []dump.Event {
+	if r.collector == nil || requestID == "" {
+		return []dump.Event{}
+	}
+	return r.collector.RequestEvents(requestID)
+}
+
+func (r *collectorRuntime) findEvent(id string) (dump.Event, error) {
+	for _, event := range r.getRecentEvents(0) {
+		if event.ID == id {
+			return event, nil
+		}
+	}
+	return dump.Event{}, ErrEventNotFound
+}
+
+func (r *collectorRuntime) trackShape(event dump.Event) {
+	if event.Label == "" || !r.sampleShape() {
+		return
+	}
+
+	sig, err := r.signatures.EventSignature(event)
+	if err != nil {
+		return
+	}
+
+	change, changed := r.shapes.Observe(event, sig)
+	if changed && r.app != nil {
+		r.app.Event.Emit(ShapeChangedRuntimeChannel, change)
+	}
+}
+
+func (r *collectorRuntime) getRetentionPolicy() retention.Policy {
+	r.retentionMu.Lock()
+	defer r.retentionMu.Unlock()
+	return r.retentionPolicy
+}
+
+// setRetentionPolicy holds retentionMu until the engine has the policy, so
+// concurrent callers cannot leave the engine and the setting apart.
+func (r *collectorRuntime) setRetentionPolicy(policy retention.Policy) (retention.Summary, error) {
+	if err := policy.Validate(); err != nil {
+		return retention.Summary{}, err
+	}
+
+	r.retentionMu.Lock()
+	defer r.retentionMu.Unlock()
+
+	r.retentionPolicy = policy
+	if r.retention == nil {
+		return retention.Summary{}, nil
+	}
+	return r.retention.SetPolicy(policy)
+}
+
+func (r *collectorRuntime) getDedup() DedupSettings {
+	r.dedupMu.Lock()
+	defer r.dedupMu.Unlock()
+	return r.dedup
+}
+
+func (r *collectorRuntime) setDedup(settings DedupSettings) error {
+	if settings.WindowMs < 0 {
+		return errors.New("dedup window must not be negative")
+	}
+	r.dedupMu.Lock()
+	defer r.dedupMu.Unlock()
+
+	r.dedup = settings
+	if r.collector != nil {
+		r.collector.SetDedupWindow(time.Duration(settings.WindowMs) * time.Millisecond)
+	}
+	return nil
+}
+
+func (r *collectorRuntime) getClock() ClockSettings {
+	r.clockMu.Lock()
+	defer r.clockMu.Unlock()
+	return r.clock
+}
+
+func (r *collectorRuntime) setClock(settings ClockSettings) error {
+	if settings.SkewWarningMs < 0 {
+		return errors.New("clock skew warning must not be negative")
+	}
+	r.clockMu.Lock()
+	defer r.clockMu.Unlock()
+
+	r.clock = settings
+	if r.collector != nil {
+		r.collector.SetReceiveClock(settings.RecordReceivedAt, time.Duration(settings.SkewWarningMs)*time.Millisecond)
+	}
+	return nil
+}
+
+func (r *collectorRuntime) emitPruneSummary(summary retention.Summary) {
+	if r.app != nil {
+		r.app.Event.Emit(RetentionPrunedRuntimeChannel, summary)
+	}
+}
+
+func (r *collectorRuntime) getDecodeOptions() dump.DecodeOptions {
+	r.decodeMu.RLock()
+	defer r.decodeMu.RUnlock()
+	return r.decodeOptions
+}
+
+func (r *collectorRuntime) setDecodeOptions(options dump.DecodeOptions) {
+	r.decodeMu.Lock()
+	defer r.decodeMu.Unlock()
+	r.decodeOptions = options
+}
+
+// decodeLine is shared by every ingest source so decode settings changed at
+// runtime apply to sockets, tailed files, and imports alike.
+func (r *collectorRuntime) decodeLine(line string) (*dump.Event, error) {
+	options := r.getDecodeOptions()
+	limit := options.MaxPayloadBytes
+	options.MaxPayloadBytes = 0
+
+	event, err := dump.DecodeNDJSONLineWithOptions(line, options)
+	if err != nil || event == nil {
+		return event, err
+	}
+
+	r.applyRequestLabel(event)
+	r.finishEvent(event, limit)
+	return event, nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"phant/internal/savedfilter"
+)
+
+// filterStore opens the saved filters kept next to the session logs on
+// first use.
+func (r *collectorRuntime) filterStore() (*savedfilter.Store, error) {
+	r.filtersMu.Lock()
+	defer r.filtersMu.Unlock()
+
+	if r.filters == nil {
+		store, err := savedfilter.Open(r.storeDir)
+		if err != nil {
+			return nil, err
+		}
+		r.filters = store
+	}
+	return r.filters, nil
+}
+
+func (r *collectorRuntime) mergeFilters(presets []savedfilter.Preset) ([]savedfilter.Preset, error) {
+	store, err := r.filterStore()
+	if err != nil {
+		return nil, err
+	}
+	saved, err := store.Merge(presets)
+	if err != nil {
+		return nil, err
+	}
+	r.emitFiltersChanged(store)
+	return saved, nil
+}
+
+func (r *collectorRuntime) emitFiltersChanged(store *savedfilter.Store) {
+	if r.app != nil {
+		r.app.Event.Emit(FiltersChangedRuntimeChannel, store.List())
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"phant/internal/dump"
+	"phant/internal/health"
+	"phant/internal/search"
+)
+
+type SearchResult struct {
+	Events []dump.Event `json:"events"`
+	// Pending counts events not indexed yet; they may match once indexed.
+	Pending int `json:"pending"`
+}
+
+// ensureSearchIndexer builds the index on first use rather than at startup,
+// seeding it with whatever the buffer already holds.
+func (r *collectorRuntime) ensureSearchIndexer() *search.Indexer {
+	r.searchMu.Lock()
+	defer r.searchMu.Unlock()
+
+	if r.indexer != nil || r.collector == nil || !r.collectorStatus.Running {
+		return r.indexer
+	}
+
+	r.indexer = search.NewIndexer(search.NewIndex(), search.DefaultMaxDocuments)
+	r.indexer.SetWorkers(r.activeTuning.IndexWorkers)
+	r.indexer.SetLoader(r.loadOffloadedPayload)
+	if r.degradationLevel() != health.LevelNormal {
+		r.indexer.Pause()
+	}
+	r.indexer.Start()
+
+	subID, ch := r.collector.Subscribe(r.activeTuning.IndexQueueDepth)
+	r.indexSubID = subID
+	r.indexWG.Add(1)
+	indexer := r.indexer
+	go func() {
+		defer r.indexWG.Done()
+		for event := range ch {
+			indexer.Enqueue(event)
+		}
+	}()
+
+	for _, event := range r.collector.Events() {
+		indexer.Enqueue(event)
+	}
+	return indexer
+}
+
+func (r *collectorRuntime) stopSearchIndexer() {
+	r.searchMu.Lock()
+	defer r.searchMu.Unlock()
+
+	if r.indexer == nil {
+		return
+	}
+
+	r.collector.Unsubscribe(r.indexSubID)
+	r.indexWG.Wait()
+	r.indexer.Stop()
+	r.indexer = nil
+}
+
+// prioritizeSearch only reorders an index that already exists; scrolling the
+// list should not be what triggers building it.
+func (r *collectorRuntime) prioritizeSearch(ids []string) {
+	r.searchMu.Lock()
+	defer r.searchMu.Unlock()
+
+	if r.indexer != nil {
+		r.indexer.Prioritize(ids)
+	}
+}
+
+func (r *collectorRuntime) searchIndexStats() search.IndexerStats {
+	r.searchMu.Lock()
+	defer r.searchMu.Unlock()
+
+	if r.indexer == nil {
+		return search.IndexerStats{}
+	}
+	return r.indexer.Stats()
+}
+
+func (r *collectorRuntime) searchEvents(query string, limit int) (SearchResult, error) {
+	indexer := r.ensureSearchIndexer()
+	if indexer == nil {
+		return SearchResult{}, ErrCollectorNotRunning
+	}
+
+	index := indexer.Index()
+	matches := index.Search(query)
+
+	result := SearchResult{Events: []dump.Event{}, Pending: indexer.Stats().Pending}
+	events := r.collector.Events()
+	for i := len(events) - 1; i >= 0; i-- {
+		if _, ok := matches[events[i].ID]; !ok {
+			continue
+		}
+		delete(matches, events[i].ID)
+		if limit <= 0 || len(result.Events) < limit {
+			result.Events = append(result.Events, events[i])
+		}
+	}
+
+	// Whatever is left was pruned or evicted from the store since it was
+	// indexed.
+	if len(matches) > 0 {
+		stale := make([]string, 0, len(matches))
+		for id := range matches {
+			stale = append(stale, id)
+		}
+		index.Remove(stale)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"phant/internal/archive"
+	"phant/internal/dump"
+	"phant/internal/store"
+)
+
+// archiveDirName is where closed session logs are archived as indexed
+// segments, inside the session store.
+const archiveDirName = "archive"
+
+const segmentExt = ".phseg"
+
+// backupPayloadDirName holds the full payloads in a session backup.
+const backupPayloadDirName = "payloads"
+
+type SessionInfo struct {
+	store.Session
+	// Summary is nil until the session has been summarized; only the latest
+	// previous session is summarized at startup.
+	Summary *store.Summary `json:"summary"`
+	Current bool           `json:"current"`
+	// Archive is the path of the session's archive segment, for
+	// SearchArchiveSegment, once it has been archived.
+	Archive string `json:"archive,omitempty"`
+}
+
+type sessionSummary struct {
+	bytes   int64
+	summary store.Summary
+}
+
+func (r *collectorRuntime) listSessions() ([]SessionInfo, error) {
+	sessions, err := store.ListSessions(r.storeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	current := ""
+	if r.store != nil {
+		current = r.store.Path()
+	}
+
+	r.summaryMu.Lock()
+	defer r.summaryMu.Unlock()
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		info := SessionInfo{Session: session, Current: session.Path == current}
+		if segment := r.segmentPath(session); segmentCurrent(segment, session) {
+			info.Archive = segment
+		}
+		if cached, ok := r.summaries[session.Path]; ok && cached.bytes == session.Bytes {
+			summary := cached.summary
+			info.Summary = &summary
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// backupSessions copies the session store to dest while ingestion keeps
+// running, with the full payloads its events refer to in dest/payloads.
+func (r *collectorRuntime) backupSessions(dest string) (store.BackupResult, error) {
+	if dest == "" || filepath.Clean(dest) == filepath.Clean(r.storeDir) {
+		return store.BackupResult{}, errors.New("backup directory must differ from the session store")
+	}
+	result, err := store.Backup(r.storeDir, dest, r.store)
+	if err != nil {
+		return result, err
+	}
+	files, bytes, err := r.payloads.CopyTo(filepath.Join(dest, backupPayloadDirName))
+	result.Payloads = files
+	result.Bytes += bytes
+	return result, err
+}
+
+func (r *collectorRuntime) sessionSummary(name string) (store.Summary, error) {
+	path, err := store.SessionPath(r.storeDir, name)
+	if err != nil {
+		return store.Summary{}, err
+	}
+	return r.summarize(store.Session{Name: name, Path: path})
+}
+
+// summarize reads a session log once per size; the current session keeps
+// growing, so its cache entry is replaced whenever more was committed.
+func (r *collectorRuntime) summarize(session store.Session) (store.Summary, error) {
+	r.summaryMu.Lock()
+	cached, ok := r.summaries[session.Path]
+	r.summaryMu.Unlock()
+	if ok && session.Bytes > 0 && cached.bytes == session.Bytes {
+		return cached.summary, nil
+	}
+
+	summary, err := store.Summarize(session.Path)
+	if err != nil {
+		return store.Summary{}, err
+	}
+
+	if session.Bytes > 0 {
+		r.summaryMu.Lock()
+		r.summaries[session.Path] = sessionSummary{bytes: session.Bytes, summary: summary}
+		r.summaryMu.Unlock()
+	}
+	return summary, nil
+}
+
+// loadLatestSessionSummary runs in the background at startup so history on
+// disk, however large, never delays the first window.
+func (r *collectorRuntime) loadLatestSessionSummary() {
+	sessions, err := store.ListSessions(r.storeDir)
+	if err != nil || len(sessions) == 0 {
+		return
+	}
+
+	latest := sessions[len(sessions)-1]
+	if r.store != nil && latest.Path == r.store.Path() {
+		if len(sessions) == 1 {
+			return
+		}
+		latest = sessions[len(sessions)-2]
+	}
+	_, _ = r.summarize(latest)
+}
+
+func (r *collectorRuntime) segmentPath(session store.Session) string {
+	return filepath.Join(r.storeDir, archiveDirName, strings.TrimSuffix(session.Name, filepath.Ext(session.Name))+segmentExt)
+}
+
+// segmentCurrent reports whether segment was written after the session log
+// last changed; a repaired log is archived again.
+func segmentCurrent(segment string, session store.Session) bool {
+	segmentInfo, err := os.Stat(segment)
+	if err != nil {
+		return false
+	}
+	sessionInfo, err := os.Stat(session.Path)
+	return err == nil && !segmentInfo.ModTime().Before(sessionInfo.ModTime())
+}
+
+// startSessionArchiver archives, in the background, every closed session
+// log that has no current segment yet, so searching old history only
+// decompresses the blocks it visits. The logs are kept: restores, backups,
+// and verification read them.
+func (r *collectorRuntime) startSessionArchiver() {
+	if r.archiveCancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.archiveCancel = cancel
+
+	current := ""
+	if r.store != nil {
+		current = r.store.Path()
+	}
+	r.archiveWG.Add(1)
+	go func() {
+		defer r.archiveWG.Done()
+		sessions, err := store.ListSessions(r.storeDir)
+		if err != nil {
+			return
+		}
+		for _, session := range sessions {
+			if ctx.Err() != nil {
+				return
+			}
+			segment := r.segmentPath(session)
+			if session.Path == current || segmentCurrent(segment, session) {
+				continue
+			}
+			_ = archiveSession(ctx, session, segment)
+		}
+	}()
+}
+
+func (r *collectorRuntime) stopSessionArchiver() {
+	if r.archiveCancel == nil {
+		return
+	}
+	r.archiveCancel()
+	r.archiveWG.Wait()
+	r.archiveCancel = nil
+}
+
+func archiveSession(ctx context.Context, session store.Session, segment string) error {
+	writer, err := archive.CreateSegment(segment, archive.DefaultBlockEvents)
+	if err != nil {
+		return err
+	}
+	err = store.ReadSession(session.Path, func(event dump.Event) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return writer.Write(event)
+	})
+	if err != nil {
+		writer.Abort()
+		return err
+	}
+	_, err = writer.Close()
+	return err
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"phant/internal/config"
+	"phant/internal/editor"
+	"phant/internal/redact"
+)
+
+// SettingsStatus is the app settings and the last error loading or saving
+// them.
+type SettingsStatus struct {
+	config.Settings
+	LastError string `json:"lastError,omitempty"`
+}
+
+// savedSettings loads the settings file on first use. A file that cannot be
+// read leaves the defaults in place and is not overwritten until the user
+// saves settings explicitly.
+func (r *collectorRuntime) savedSettings() config.Settings {
+	r.settingsMu.Lock()
+	defer r.settingsMu.Unlock()
+
+	if !r.settingsLoaded {
+		r.settingsLoaded = true
+		settings, err := config.LoadSettings(r.storeDir)
+		if err != nil {
+			settings = config.DefaultSettings()
+			r.settingsBad = true
+			r.settingsErr = err.Error()
+		}
+		r.settings = settings
+	}
+	return r.settings
+}
+
+// applySavedSettings puts the saved settings in place before the collector
+// starts its listeners and retention.
+func (r *collectorRuntime) applySavedSettings() {
+	if err := r.applySettings(r.savedSettings()); err != nil {
+		r.settingsMu.Lock()
+		r.settingsErr = err.Error()
+		r.settingsMu.Unlock()
+	}
+}
+
+func validateSettings(settings config.Settings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if err := editor.Validate(settings.Editor); err != nil {
+		return err
+	}
+	_, err := redact.New(settings.RedactionRules)
+	return err
+}
+
+// applySettings validates every setting before changing any. Listeners are
+// only restarted when their address changes; one that cannot bind keeps
+// its new address and reports the error, as its own setter does.
+func (r *collectorRuntime) applySettings(settings config.Settings) error {
+	if err := validateSettings(settings); err != nil {
+		return err
+	}
+
+	var errs []error
+	if settings.Listeners.VarDumper != r.varDumper.configured() {
+		_, err := r.setVarDumperAddress(settings.Listeners.VarDumper)
+		errs = append(errs, err)
+	}
+	if settings.Listeners.Ray != r.ray.configured() {
+		_, err := r.setRayAddress(settings.Listeners.Ray)
+		errs = append(errs, err)
+	}
+	if settings.Listeners.Monolog != r.logs.configured() {
+		_, err := r.setLogAddress(settings.Listeners.Monolog)
+		errs = append(errs, err)
+	}
+	if settings.Listeners.API != r.api.configured() {
+		_, err := r.setAPIAddress(settings.Listeners.API)
+		errs = append(errs, err)
+	}
+	if !slices.Equal(settings.Listeners.Extra, r.configuredIngestListeners()) {
+		errs = append(errs, r.setIngestListeners(settings.Listeners.Extra))
+	}
+	_, err := r.setRetentionPolicy(settings.Retention)
+	errs = append(errs, err, r.setEditor(settings.Editor), r.setRedactionRules(settings.RedactionRules))
+	errs = append(errs, r.trash.SetWindow(time.Duration(settings.UndoWindowMs)*time.Millisecond))
+	r.slowQueryMs.Store(int64(settings.SlowQueryMs))
+
+	r.settingsMu.Lock()
+	r.settings.Theme = settings.Theme
+	r.settings.StartHidden = settings.StartHidden
+	r.settingsMu.Unlock()
+	return errors.Join(errs...)
+}
+
+// currentSettings reads the settings the runtime uses now, so changes made
+// through the individual setters are saved as well.
+func (r *collectorRuntime) currentSettings() config.Settings {
+	settings := r.savedSettings()
+	settings.Listeners = config.Listeners{
+		VarDumper: r.varDumper.configured(),
+		Ray:       r.ray.configured(),
+		Monolog:   r.logs.configured(),
+		API:       r.api.configured(),
+		Extra:     r.configuredIngestListeners(),
+	}
+	settings.Retention = r.getRetentionPolicy()
+	settings.Editor = r.globalEditor()
+	settings.RedactionRules = r.getRedactionRules()
+	settings.UndoWindowMs = int(r.trash.Window() / time.Millisecond)
+	settings.SlowQueryMs = int(r.slowQueryMs.Load())
+	return settings
+}
+
+func (r *collectorRuntime) saveSettings() error {
+	settings := r.currentSettings()
+	err := config.SaveSettings(r.storeDir, settings)
+
+	r.settingsMu.Lock()
+	defer r.settingsMu.Unlock()
+	r.settingsErr = ""
+	if err != nil {
+		r.settingsErr = err.Error()
+		return err
+	}
+	r.settings = settings
+	r.settingsBad = false
+	return nil
+}
+
+// saveSettingsOnExit keeps changes made since the last save, unless the
+// file could not be read and would be lost.
+func (r *collectorRuntime) saveSettingsOnExit() {
+	r.settingsMu.Lock()
+	skip := !r.settingsLoaded || r.settingsBad
+	r.settingsMu.Unlock()
+	if !skip {
+		_ = r.saveSettings()
+	}
+}
+
+func (r *collectorRuntime) settingsStatus() SettingsStatus {
+	settings := r.currentSettings()
+
+	r.settingsMu.Lock()
+	defer r.settingsMu.Unlock()
+	return SettingsStatus{Settings: settings, LastError: r.settingsErr}
+}
+
+func (r *collectorRuntime) updateSettings(settings config.Settings) (SettingsStatus, error) {
+	if err := validateSettings(settings); err != nil {
+		return r.settingsStatus(), err
+	}
+	err := errors.Join(r.applySettings(settings), r.saveSettings())
+	return r.settingsStatus(), err
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"phant/internal/source"
+)
+
+var ErrFrameNotFound = errors.New("trace frame not found")
+
+// traceFrameContext reads the code around one of an event's trace frames,
+// after mapping the frame's file to the host.
+func (r *collectorRuntime) traceFrameContext(eventID string, frameIndex int, contextLines int) (source.Context, error) {
+	frames, err := r.resolveTrace(eventID)
+	if err != nil {
+		return source.Context{}, err
+	}
+	if frameIndex < 0 || frameIndex >= len(frames) {
+		return source.Context{}, fmt.Errorf("%w: event has %d frames, want index %d", ErrFrameNotFound, len(frames), frameIndex)
+	}
+
+	frame := frames[frameIndex]
+	if frame.File == "" || frame.Line <= 0 {
+		return source.Context{}, fmt.Errorf("%w: frame %d has no file location", ErrFrameNotFound, frameIndex)
+	}
+	return r.sources.Context(frame.LocalFile, frame.Line, contextLines)
+}
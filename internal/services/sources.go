@@ -0,0 +1,331 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"phant/internal/dump"
+	"phant/internal/ingest"
+	"phant/internal/tail"
+)
+
+// Built-in ingest sources, which events name in dump.Event.IngestSource.
+// Added listeners are named by ingest.Spec.ID and tailed files by
+// SourceFilePrefix and their path.
+const (
+	SourceSocket    = "socket"
+	SourceVarDumper = "varDumper"
+	SourceRay       = "ray"
+	SourceMonolog   = "monolog"
+
+	SourceFilePrefix = "file:"
+)
+
+// SourceKindFile is the AddSource kind that tails a dump file; the other
+// kinds are the ingest listener kinds.
+const SourceKindFile = "file"
+
+// SourceSpec is what AddSource takes: an ingest listener kind with its
+// address, or SourceKindFile with the path of a file to tail.
+type SourceSpec struct {
+	Kind    string `json:"kind"`
+	Address string `json:"address"`
+}
+
+// SourceStatus reports one ingest source. Kind is one of the built-in
+// sources, an ingest listener kind, or SourceKindFile; only added listeners
+// and tailed files are Removable.
+type SourceStatus struct {
+	ID           string `json:"id"`
+	Kind         string `json:"kind"`
+	Address      string `json:"address"`
+	Removable    bool   `json:"removable"`
+	Running      bool   `json:"running"`
+	Events       uint64 `json:"events"`
+	Rejected     uint64 `json:"rejected"`
+	Unauthorized uint64 `json:"unauthorized"`
+	LastEventAt  string `json:"lastEventAt,omitempty"`
+	LastError    string `json:"lastError,omitempty"`
+}
+
+// sourceCounts counts what a source without counters of its own decoded.
+type sourceCounts struct {
+	events   atomic.Uint64
+	rejected atomic.Uint64
+}
+
+// sourceDecoder decodes lines as ingestLine does and tags the events with
+// the source they came through, counting them when counts is set.
+func (r *collectorRuntime) sourceDecoder(id string, counts *sourceCounts) func(line string) (*dump.Event, error) {
+	return func(line string) (*dump.Event, error) {
+		event, err := r.ingestLine(line)
+		if counts != nil {
+			if err != nil {
+				counts.rejected.Add(1)
+			} else if event != nil {
+				counts.events.Add(1)
+			}
+		}
+		if event != nil {
+			event.IngestSource = id
+		}
+		return event, err
+	}
+}
+
+// sourceHandler tags adapter events with their source before ingesting
+// them.
+func (r *collectorRuntime) sourceHandler(id string) func(event dump.Event) {
+	return func(event dump.Event) {
+		event.IngestSource = id
+		r.ingestAdapterEvent(event)
+	}
+}
+
+func (r *collectorRuntime) fileDecoder(path string) tail.Decoder {
+	return r.sourceDecoder(SourceFilePrefix+path, nil)
+}
+
+func (r *collectorRuntime) newIngestListener(spec ingest.Spec) *ingest.Listener {
+	listener := ingest.NewListener(spec, r.sourceDecoder(spec.ID(), nil), r.collector.Ingest)
+	listener.SetAuth(r.auth)
+	listener.SetTLS(r.listenerTLSConfig())
+	return listener
+}
+
+// startIngestListeners starts every added listener with the collector. One
+// that cannot bind stays configured and reports the error.
+func (r *collectorRuntime) startIngestListeners() {
+	r.ingestMu.Lock()
+	defer r.ingestMu.Unlock()
+
+	for _, spec := range r.ingestSpecs {
+		r.startIngestListenerLocked(spec)
+	}
+}
+
+func (r *collectorRuntime) startIngestListenerLocked(spec ingest.Spec) error {
+	delete(r.ingestErrors, spec.ID())
+	listener := r.newIngestListener(spec)
+	if err := listener.Start(); err != nil {
+		r.ingestErrors[spec.ID()] = err.Error()
+		return err
+	}
+	r.ingestListeners[spec.ID()] = listener
+	return nil
+}
+
+func (r *collectorRuntime) stopIngestListeners() {
+	r.ingestMu.Lock()
+	defer r.ingestMu.Unlock()
+
+	for id := range r.ingestListeners {
+		r.stopIngestListenerLocked(id)
+	}
+}
+
+func (r *collectorRuntime) stopIngestListenerLocked(id string) {
+	listener, ok := r.ingestListeners[id]
+	if !ok {
+		return
+	}
+	if err := listener.Stop(); err != nil {
+		r.ingestErrors[id] = err.Error()
+	}
+	delete(r.ingestListeners, id)
+}
+
+// restartIngestListeners rebinds the running listeners so they pick up
+// the TLS settings.
+func (r *collectorRuntime) restartIngestListeners() error {
+	r.ingestMu.Lock()
+	defer r.ingestMu.Unlock()
+
+	var errs []error
+	for _, spec := range r.ingestSpecs {
+		if _, ok := r.ingestListeners[spec.ID()]; !ok {
+			continue
+		}
+		r.stopIngestListenerLocked(spec.ID())
+		errs = append(errs, r.startIngestListenerLocked(spec))
+	}
+	return errors.Join(errs...)
+}
+
+func (r *collectorRuntime) configuredIngestListeners() []ingest.Spec {
+	r.ingestMu.Lock()
+	defer r.ingestMu.Unlock()
+	return slices.Clone(r.ingestSpecs)
+}
+
+// setIngestListeners replaces the added listeners, as loading settings
+// does. Listeners kept by the new list are left running.
+func (r *collectorRuntime) setIngestListeners(specs []ingest.Spec) error {
+	r.ingestMu.Lock()
+	defer r.ingestMu.Unlock()
+
+	for _, spec := range r.ingestSpecs {
+		if !slices.Contains(specs, spec) {
+			r.stopIngestListenerLocked(spec.ID())
+			delete(r.ingestErrors, spec.ID())
+		}
+	}
+	r.ingestSpecs = slices.Clone(specs)
+
+	if !r.collectorRunning() {
+		return nil
+	}
+	var errs []error
+	for _, spec := range specs {
+		if _, ok := r.ingestListeners[spec.ID()]; !ok {
+			errs = append(errs, r.startIngestListenerLocked(spec))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addSource starts a listener or tails a file. A listener that cannot bind
+// is not added.
+func (r *collectorRuntime) addSource(spec SourceSpec) (SourceStatus, error) {
+	if spec.Kind == SourceKindFile {
+		if r.tails == nil {
+			return SourceStatus{}, ErrCollectorNotRunning
+		}
+		if err := r.tails.Follow(spec.Address); err != nil {
+			return SourceStatus{}, err
+		}
+		path, err := filepath.Abs(spec.Address)
+		if err != nil {
+			return SourceStatus{}, err
+		}
+		return r.sourceStatus(SourceFilePrefix + path)
+	}
+
+	listenerSpec := ingest.Spec{Kind: spec.Kind, Address: spec.Address}
+	if err := listenerSpec.Validate(); err != nil {
+		return SourceStatus{}, err
+	}
+	id := listenerSpec.ID()
+
+	r.ingestMu.Lock()
+	if slices.Contains(r.ingestSpecs, listenerSpec) {
+		r.ingestMu.Unlock()
+		return SourceStatus{}, ErrSourceExists
+	}
+	if r.collectorRunning() {
+		if err := r.startIngestListenerLocked(listenerSpec); err != nil {
+			delete(r.ingestErrors, id)
+			r.ingestMu.Unlock()
+			return SourceStatus{}, err
+		}
+	}
+	r.ingestSpecs = append(r.ingestSpecs, listenerSpec)
+	r.ingestMu.Unlock()
+
+	return r.sourceStatus(id)
+}
+
+// removeSource stops an added listener or a tailed file. Built-in sources
+// are turned off through their own settings instead.
+func (r *collectorRuntime) removeSource(id string) error {
+	switch id {
+	case SourceSocket, SourceVarDumper, SourceRay, SourceMonolog:
+		return ErrBuiltinSource
+	}
+	if path, ok := strings.CutPrefix(id, SourceFilePrefix); ok {
+		if r.tails == nil {
+			return ErrCollectorNotRunning
+		}
+		if err := r.tails.Stop(path); err != nil {
+			return ErrSourceNotFound
+		}
+		return nil
+	}
+
+	r.ingestMu.Lock()
+	defer r.ingestMu.Unlock()
+
+	index := slices.IndexFunc(r.ingestSpecs, func(spec ingest.Spec) bool { return spec.ID() == id })
+	if index < 0 {
+		return ErrSourceNotFound
+	}
+	r.stopIngestListenerLocked(id)
+	delete(r.ingestErrors, id)
+	r.ingestSpecs = slices.Delete(r.ingestSpecs, index, index+1)
+	return nil
+}
+
+func (r *collectorRuntime) sourceStatus(id string) (SourceStatus, error) {
+	for _, status := range r.listSources() {
+		if status.ID == id {
+			return status, nil
+		}
+	}
+	return SourceStatus{}, ErrSourceNotFound
+}
+
+// listSources reports the collector socket, the configured adapters, the
+// added listeners, and the tailed files, in that order.
+func (r *collectorRuntime) listSources() []SourceStatus {
+	sources := []SourceStatus{{
+		ID:        SourceSocket,
+		Kind:      SourceSocket,
+		Address:   r.collectorSocketPath(),
+		Running:   r.collectorRunning(),
+		Events:    r.socketCounts.events.Load(),
+		Rejected:  r.socketCounts.rejected.Load(),
+		LastError: r.collectorStatus.LastError,
+	}}
+
+	varDumper, ray, logs := r.varDumperStatus(), r.rayStatus(), r.logIngestStatus()
+	for _, adapter := range []SourceStatus{
+		{ID: SourceVarDumper, Address: varDumper.Address, Running: varDumper.Running, Events: varDumper.Received, Rejected: varDumper.Rejected, Unauthorized: varDumper.Unauthorized, LastError: varDumper.LastError},
+		{ID: SourceRay, Address: ray.Address, Running: ray.Running, Events: ray.Events, Rejected: ray.Rejected, Unauthorized: ray.Unauthorized, LastError: ray.LastError},
+		{ID: SourceMonolog, Address: logs.Address, Running: logs.Running, Events: logs.Received, Rejected: logs.Rejected, Unauthorized: logs.Unauthorized, LastError: logs.LastError},
+	} {
+		if adapter.Address == "" {
+			continue
+		}
+		adapter.Kind = adapter.ID
+		sources = append(sources, adapter)
+	}
+
+	r.ingestMu.Lock()
+	listeners := make([]SourceStatus, 0, len(r.ingestSpecs))
+	for _, spec := range r.ingestSpecs {
+		status := SourceStatus{ID: spec.ID(), Kind: spec.Kind, Address: spec.Address, Removable: true, LastError: r.ingestErrors[spec.ID()]}
+		if listener, ok := r.ingestListeners[spec.ID()]; ok {
+			stats := listener.Stats()
+			status.Address = stats.Address
+			status.Running = true
+			status.Events = stats.Events
+			status.Rejected = stats.Rejected
+			status.Unauthorized = stats.Unauthorized
+			status.LastEventAt = stats.LastEventAt
+		}
+		listeners = append(listeners, status)
+	}
+	r.ingestMu.Unlock()
+	sort.Slice(listeners, func(i, j int) bool { return listeners[i].ID < listeners[j].ID })
+	sources = append(sources, listeners...)
+
+	if r.tails != nil {
+		for _, file := range r.tails.Statuses() {
+			sources = append(sources, SourceStatus{
+				ID:        SourceFilePrefix + file.Path,
+				Kind:      SourceKindFile,
+				Address:   file.Path,
+				Removable: true,
+				Running:   true,
+				Events:    file.Lines,
+				Rejected:  file.Rejected,
+				LastError: file.LastError,
+			})
+		}
+	}
+	return sources
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"phant/internal/dump"
+	"phant/internal/sqlfmt"
+)
+
+// FormattedQuery is an event's database query laid out for reading. Warning
+// says why Interpolated may still hold placeholders.
+type FormattedQuery struct {
+	Query        string   `json:"query"`
+	Interpolated string   `json:"interpolated"`
+	Formatted    string   `json:"formatted"`
+	Connection   string   `json:"connection,omitempty"`
+	DurationMs   *float64 `json:"durationMs,omitempty"`
+	Slow         bool     `json:"slow"`
+	Warning      string   `json:"warning,omitempty"`
+}
+
+// flagSlowQuery marks a query that took at least the slow query threshold.
+// Changing the threshold leaves events that already arrived as they are.
+func (r *collectorRuntime) flagSlowQuery(event *dump.Event) {
+	threshold := r.slowQueryMs.Load()
+	if event.SQL == nil || event.DurationMs == nil || threshold <= 0 {
+		return
+	}
+	event.SlowQuery = *event.DurationMs >= float64(threshold)
+}
+
+func (r *collectorRuntime) formattedQuery(eventID string) (FormattedQuery, error) {
+	event, err := r.findEvent(eventID)
+	if err != nil {
+		return FormattedQuery{}, err
+	}
+	if event.SQL == nil {
+		return FormattedQuery{}, ErrNotSQLQuery
+	}
+
+	formatted := FormattedQuery{
+		Query:      event.SQL.Query,
+		Connection: event.SQL.Connection,
+		DurationMs: event.DurationMs,
+		Slow:       event.SlowQuery,
+	}
+	interpolated, err := sqlfmt.Interpolate(event.SQL.Query, event.SQL.Bindings)
+	if err != nil {
+		formatted.Warning = err.Error()
+	}
+	formatted.Interpolated = interpolated
+	formatted.Formatted = sqlfmt.Format(interpolated)
+	return formatted, nil
+}
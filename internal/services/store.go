@@ -0,0 +1,111 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+
+	"phant/internal/collector"
+	"phant/internal/dump"
+	"phant/internal/store"
+)
+
+func defaultStoreDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, "phant", "sessions")
+}
+
+// startStoreWriter persists every event server publishes to a new session
+// log. It runs before server accepts connections, so no event reaches the
+// pipeline unjournaled or misses the log, and its subscription never drops:
+// a full queue holds back publishing until the loop catches up. The store
+// batches writes itself, so this loop only hands events over.
+func (r *collectorRuntime) startStoreWriter(server *collector.Server) {
+	if r.store != nil {
+		return
+	}
+
+	s, err := store.Open(r.storeDir, store.Options{BatchSize: r.activeTuning.StoreBatchSize, OnCommit: r.storeCommitted})
+	if err != nil {
+		r.storeErr = err.Error()
+		return
+	}
+	r.store = s
+	r.storeErr = ""
+
+	subID, ch := server.SubscribeBlocking(r.activeTuning.StoreQueueDepth)
+	r.storeSubID = subID
+	r.openJournal(server)
+	r.storeWG.Add(1)
+	go func() {
+		defer r.storeWG.Done()
+		for event := range ch {
+			_ = s.Append(event)
+		}
+	}()
+}
+
+func (r *collectorRuntime) stopStoreWriter(server *collector.Server) {
+	if r.store == nil {
+		return
+	}
+
+	server.Unsubscribe(r.storeSubID)
+	r.storeWG.Wait()
+	if err := r.store.Close(); err != nil {
+		r.storeErr = err.Error()
+	}
+	r.store = nil
+	if journal := r.journal.Swap(nil); journal != nil {
+		_ = journal.Close()
+	}
+}
+
+// openJournal starts the write-ahead journal of ingested events, after
+// committing and publishing whatever it held from a run that crashed.
+// Recovered events skip the store subscription, as bulk imports do, so a
+// full queue cannot drop them; they reach the live stream through the
+// buffer, without running hooks or forwarding again. Their full payloads are
+// still on disk under their payloadRef. Without a journal, ingestion goes on
+// unjournaled.
+func (r *collectorRuntime) openJournal(server *collector.Server) {
+	journal, recovered, err := store.OpenJournal(r.storeDir)
+	if err != nil {
+		r.journalErr = err.Error()
+		return
+	}
+	r.journalErr = ""
+	r.journal.Store(journal)
+	if len(recovered) > 0 {
+		_ = r.store.Append(recovered...)
+		server.PublishBatch(recovered, r.storeSubID)
+	}
+}
+
+// journalEvent writes an ingested event ahead of the pipeline. A failed
+// write only costs the event its crash safety.
+func (r *collectorRuntime) journalEvent(event dump.Event) {
+	if journal := r.journal.Load(); journal != nil {
+		_ = journal.Append(event)
+	}
+}
+
+func (r *collectorRuntime) storeCommitted(batch []dump.Event) {
+	r.traceStored(batch)
+	if journal := r.journal.Load(); journal != nil {
+		_ = journal.Done(batch)
+	}
+}
+
+func (r *collectorRuntime) storeStats() store.Stats {
+	if r.store == nil {
+		return store.Stats{LastError: r.storeErr}
+	}
+	stats := r.store.Stats()
+	if stats.LastError == "" && r.journalErr != "" {
+		stats.LastError = "journal: " + r.journalErr
+	}
+	return stats
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/store"
+)
+
+// Issue kinds verifyStore reports on top of those store.Verify finds.
+const (
+	// IssueOrphanedPayload is a stored full payload that no buffered event
+	// and no event in a session log refers to.
+	IssueOrphanedPayload = "orphaned-payload"
+	// IssueUnindexed is a buffered event the search index lost track of.
+	IssueUnindexed = "unindexed"
+)
+
+// StoreReport is what verifyStore found, and with repair set, fixed.
+type StoreReport struct {
+	store.VerifyResult
+	CheckedAt string `json:"checkedAt"`
+}
+
+// verifyStore checks the session logs, the full payloads kept beside them,
+// and the search index. Repair quarantines corrupt lines, removes stale
+// temporary files and orphaned payloads, and re-queues unindexed events.
+func (r *collectorRuntime) verifyStore(repair bool) (StoreReport, error) {
+	now := time.Now()
+	active := ""
+	if r.store != nil {
+		if err := r.store.Flush(); err != nil && err != store.ErrClosed {
+			return StoreReport{}, err
+		}
+		active = r.store.Path()
+	}
+
+	buffered := r.getRecentEvents(0)
+	referenced := map[string]bool{}
+	for _, event := range buffered {
+		referenced[event.PayloadRef] = true
+	}
+
+	result, err := store.Verify(r.storeDir, store.VerifyOptions{
+		Repair: repair,
+		Active: active,
+		Event:  func(event dump.Event) { referenced[event.PayloadRef] = true },
+		Now:    now,
+	})
+	if err != nil {
+		return StoreReport{}, err
+	}
+
+	result.Issues = append(result.Issues, r.checkPayloads(referenced, repair, now)...)
+	result.Issues = append(result.Issues, r.checkIndex(buffered, repair)...)
+	return StoreReport{VerifyResult: result, CheckedAt: now.UTC().Format(time.RFC3339Nano)}, nil
+}
+
+// checkPayloads reports payload files no known event refers to. Files
+// younger than store.StaleAfter are skipped: their event may not have
+// reached the buffer yet.
+func (r *collectorRuntime) checkPayloads(referenced map[string]bool, repair bool, now time.Time) []store.Issue {
+	entries, err := os.ReadDir(r.payloads.Dir())
+	if err != nil {
+		return nil
+	}
+
+	var issues []store.Issue
+	for _, entry := range entries {
+		ref, stored := store.Ref(entry.Name())
+		if entry.IsDir() || !stored || referenced[ref] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < store.StaleAfter {
+			continue
+		}
+		issue := store.Issue{
+			Kind:    IssueOrphanedPayload,
+			Path:    filepath.Join(r.payloads.Dir(), entry.Name()),
+			Message: fmt.Sprintf("payload of %d bytes belongs to no stored event", info.Size()),
+		}
+		if repair {
+			issue.Repaired = os.Remove(issue.Path) == nil
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkIndex reports buffered events the search index should hold but
+// does not. The index is built on first search, so there is nothing to
+// check before then.
+func (r *collectorRuntime) checkIndex(buffered []dump.Event, repair bool) []store.Issue {
+	r.searchMu.Lock()
+	indexer := r.indexer
+	r.searchMu.Unlock()
+	if indexer == nil {
+		return nil
+	}
+
+	var issues []store.Issue
+	for _, event := range indexer.Unindexed(buffered) {
+		issue := store.Issue{Kind: IssueUnindexed, Message: "event " + event.ID + " is missing from the search index"}
+		if repair {
+			indexer.Enqueue(event)
+			issue.Repaired = true
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
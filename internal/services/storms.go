@@ -0,0 +1,29 @@
+package services
+
+import (
+	"phant/internal/dump"
+	"phant/internal/query"
+	"phant/internal/storm"
+)
+
+// storms finds the dump storms among the buffered events, optionally of
+// one project.
+func (r *collectorRuntime) storms(projectRoot string) []storm.Storm {
+	if r.collector == nil {
+		return []storm.Storm{}
+	}
+	events := r.collector.Select(func(event dump.Event) bool {
+		return projectRoot == "" || event.ProjectRoot == projectRoot
+	})
+	return storm.Detect(events, storm.DefaultMinEvents, storm.DefaultGap, r.signatures)
+}
+
+// expandStorm pages through every event of a storm, newest first.
+func (r *collectorRuntime) expandStorm(id string, page query.Page) (query.Result, error) {
+	for _, found := range r.storms("") {
+		if found.ID == id {
+			return query.Paginate(found.Events(), page), nil
+		}
+	}
+	return query.Result{}, ErrStormNotFound
+}
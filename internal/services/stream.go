@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+
+	"phant/internal/dump"
+	"phant/internal/livegrep"
+	"phant/internal/pipeline"
+	"phant/internal/query"
+)
+
+type DumpStreamSubscription struct {
+	ID      int    `json:"id"`
+	Channel string `json:"channel"`
+}
+
+func dumpStreamChannel(id int) string {
+	return fmt.Sprintf("%s:%d", DumpStreamRuntimeChannelPrefix, id)
+}
+
+// subscribeDumpStream gives a frontend view its own bounded event stream,
+// emitted on a dedicated runtime channel until it unsubscribes.
+func (r *collectorRuntime) subscribeDumpStream(bufferSize int) (DumpStreamSubscription, error) {
+	if r.collector == nil {
+		return DumpStreamSubscription{}, ErrCollectorNotRunning
+	}
+	if bufferSize <= 0 {
+		bufferSize = pipeline.DefaultSubscriberBuffer
+	}
+
+	id, ch := r.collector.Subscribe(bufferSize)
+	subscription := DumpStreamSubscription{ID: id, Channel: dumpStreamChannel(id)}
+
+	r.streamMu.Lock()
+	r.streams[id] = subscription
+	r.streamMu.Unlock()
+
+	go func() {
+		for event := range ch {
+			if r.app != nil {
+				event.Preview = r.previews.Preview(event)
+				r.app.Event.Emit(subscription.Channel, event)
+			}
+		}
+	}()
+
+	return subscription, nil
+}
+
+// FilteredSubscription is a snapshot of the latest matching events plus a
+// stream of later matches, emitted on Channel. Cursor counts every event
+// published before the snapshot.
+type FilteredSubscription struct {
+	DumpStreamSubscription
+	Snapshot []dump.Event `json:"snapshot"`
+	Cursor   uint64       `json:"cursor"`
+}
+
+// subscribeFiltered takes the snapshot and subscribes in one step, so a view
+// that lists and then listens neither misses nor repeats events.
+func (r *collectorRuntime) subscribeFiltered(filter query.Filter, limit int) (FilteredSubscription, error) {
+	if r.collector == nil {
+		return FilteredSubscription{}, ErrCollectorNotRunning
+	}
+	matcher, err := r.compileFilter(filter)
+	if err != nil {
+		return FilteredSubscription{}, err
+	}
+
+	snapshot, ch := r.collector.SubscribeSnapshot(matcher.Match, limit, pipeline.DefaultSubscriberBuffer)
+	subscription := FilteredSubscription{
+		DumpStreamSubscription: DumpStreamSubscription{ID: snapshot.SubscriptionID, Channel: dumpStreamChannel(snapshot.SubscriptionID)},
+		Snapshot:               r.withPreviews(snapshot.Events),
+		Cursor:                 snapshot.Cursor,
+	}
+	if subscription.Snapshot == nil {
+		subscription.Snapshot = []dump.Event{}
+	}
+
+	r.streamMu.Lock()
+	r.streams[subscription.ID] = subscription.DumpStreamSubscription
+	r.streamMu.Unlock()
+
+	go func() {
+		for event := range ch {
+			if r.app != nil && matcher.Match(event) {
+				event.Preview = r.previews.Preview(event)
+				r.app.Event.Emit(subscription.Channel, event)
+			}
+		}
+	}()
+
+	return subscription, nil
+}
+
+// subscribeLiveGrep streams incoming events whose text rendering matches
+// pattern, each with its matching lines. Events already received are not
+// searched.
+func (r *collectorRuntime) subscribeLiveGrep(pattern livegrep.Pattern) (DumpStreamSubscription, error) {
+	if r.collector == nil {
+		return DumpStreamSubscription{}, ErrCollectorNotRunning
+	}
+	matcher, err := pattern.Compile()
+	if err != nil {
+		return DumpStreamSubscription{}, err
+	}
+
+	id, ch := r.collector.Subscribe(pipeline.DefaultSubscriberBuffer)
+	subscription := DumpStreamSubscription{ID: id, Channel: dumpStreamChannel(id)}
+
+	r.streamMu.Lock()
+	r.streams[id] = subscription
+	r.streamMu.Unlock()
+
+	go func() {
+		for event := range ch {
+			if r.app == nil {
+				continue
+			}
+			if match, ok := matcher.Match(event); ok {
+				match.Event.Preview = r.previews.Preview(event)
+				r.app.Event.Emit(subscription.Channel, match)
+			}
+		}
+	}()
+
+	return subscription, nil
+}
+
+func (r *collectorRuntime) unsubscribeDumpStream(id int) {
+	r.streamMu.Lock()
+	_, ok := r.streams[id]
+	delete(r.streams, id)
+	r.streamMu.Unlock()
+
+	if ok && r.collector != nil {
+		r.collector.Unsubscribe(id)
+	}
+}
+
+func (r *collectorRuntime) dumpStreamStats(id int) (pipeline.SubscriberStats, bool) {
+	r.streamMu.Lock()
+	_, ok := r.streams[id]
+	r.streamMu.Unlock()
+
+	if !ok || r.collector == nil {
+		return pipeline.SubscriberStats{}, false
+	}
+	return r.collector.SubscriberStats(id)
+}
+
+func (r *collectorRuntime) dropDumpStreams() {
+	r.streamMu.Lock()
+	defer r.streamMu.Unlock()
+
+	for id := range r.streams {
+		delete(r.streams, id)
+	}
+}
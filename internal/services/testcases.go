@@ -0,0 +1,18 @@
+package services
+
+import (
+	"phant/internal/dump"
+	"phant/internal/testcase"
+)
+
+// testCases groups the buffered dumps that carry test context, optionally
+// of one project.
+func (r *collectorRuntime) testCases(projectRoot string) []testcase.Case {
+	if r.collector == nil {
+		return []testcase.Case{}
+	}
+	events := r.collector.Select(func(event dump.Event) bool {
+		return event.Test != nil && (projectRoot == "" || event.ProjectRoot == projectRoot)
+	})
+	return testcase.Group(events)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/pipeline"
+)
+
+var ErrEventNotTraced = errors.New("no pipeline timings recorded for event")
+
+// PipelineStats gathers everything needed to tell where ingest time goes:
+// per-stage latencies, shard queues, and the UI bridge.
+type PipelineStats struct {
+	Latency pipeline.TraceStats   `json:"latency"`
+	Shards  []pipeline.ShardStats `json:"shards"`
+	Bridge  pipeline.BatchStats   `json:"bridge"`
+}
+
+func (r *collectorRuntime) pipelineStats() PipelineStats {
+	stats := PipelineStats{Latency: r.tracer.Stats(), Shards: []pipeline.ShardStats{}}
+	if r.collector != nil {
+		stats.Shards = r.collector.IngestStats()
+	}
+	if r.bridge != nil {
+		stats.Bridge = r.bridge.Stats()
+	}
+	return stats
+}
+
+func (r *collectorRuntime) eventTimings(eventID string) (pipeline.EventTimings, error) {
+	timings, ok := r.tracer.Timings(eventID)
+	if !ok {
+		return pipeline.EventTimings{}, ErrEventNotTraced
+	}
+	return timings, nil
+}
+
+// traceStored runs on the store's committer after each group commit.
+func (r *collectorRuntime) traceStored(batch []dump.Event) {
+	stored := time.Now()
+	for _, event := range batch {
+		r.tracer.Mark(event.ID, pipeline.StageStored, stored)
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"fmt"
+	goruntime "runtime"
+	"strconv"
+
+	"phant/internal/dump"
+	"phant/internal/pipeline"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+	"github.com/wailsapp/wails/v3/pkg/events"
+	"github.com/wailsapp/wails/v3/pkg/icons"
+)
+
+// TraySettings choose how phant runs from the system tray. StartHidden
+// opens phant with its window hidden, reached from the tray icon.
+type TraySettings struct {
+	StartHidden bool `json:"startHidden"`
+}
+
+// TrayStatus reports the tray settings and how many events arrived while
+// no phant window had focus.
+type TrayStatus struct {
+	TraySettings
+	Available bool `json:"available"`
+	Unread    int  `json:"unread"`
+}
+
+// StartsHidden reports whether the main window should be created hidden.
+func StartsHidden(service *CollectorLifecycleService) bool {
+	return service.runtime.traySettings().StartHidden
+}
+
+// AttachTray puts phant in the system tray with window as its main window.
+// Closing the window hides it instead of quitting; the tray menu shows it
+// again, pauses the live stream, clears events, or quits.
+func AttachTray(service *CollectorLifecycleService, window *application.WebviewWindow, icon []byte) {
+	service.runtime.attachTray(window, icon)
+}
+
+func (r *collectorRuntime) attachTray(window *application.WebviewWindow, icon []byte) {
+	if r.app == nil {
+		return
+	}
+
+	tray := r.app.SystemTray.New()
+	if goruntime.GOOS == "darwin" {
+		tray.SetTemplateIcon(icons.SystrayMacTemplate)
+	} else {
+		tray.SetIcon(icon)
+	}
+	tray.SetTooltip("Phant")
+	tray.OnClick(func() {
+		if window.IsVisible() {
+			window.Hide()
+			return
+		}
+		r.showWindow()
+	})
+
+	menu := application.NewMenu()
+	menu.Add("Show Phant").OnClick(func(*application.Context) { r.showWindow() })
+	menu.Add("Hide Phant").OnClick(func(*application.Context) { window.Hide() })
+	menu.AddSeparator()
+	pause := menu.AddCheckbox("Pause Live Stream", r.liveGate.Status().Paused)
+	pause.OnClick(func(ctx *application.Context) {
+		if ctx.ClickedMenuItem().Checked() {
+			r.pauseStream()
+			return
+		}
+		r.resumeStream()
+	})
+	menu.Add("Clear Events").OnClick(func(*application.Context) { r.clearEvents() })
+	menu.AddSeparator()
+	menu.Add("Quit Phant").OnClick(func(*application.Context) { r.app.Quit() })
+	tray.SetMenu(menu)
+
+	window.RegisterHook(events.Common.WindowClosing, func(event *application.WindowEvent) {
+		window.Hide()
+		event.Cancel()
+	})
+	window.OnWindowEvent(events.Common.WindowFocus, func(*application.WindowEvent) {
+		r.markRead()
+	})
+
+	r.trayMu.Lock()
+	r.tray = tray
+	r.trayPause = pause
+	r.trayMu.Unlock()
+}
+
+// showWindow brings the main window forward, restoring it if minimised.
+func (r *collectorRuntime) showWindow() {
+	if r.app == nil {
+		return
+	}
+	if windows := r.app.Window.GetAll(); len(windows) > 0 {
+		window := windows[0]
+		if window.IsMinimised() {
+			window.Restore()
+		}
+		window.Show()
+		window.Focus()
+	}
+}
+
+func (r *collectorRuntime) traySettings() TraySettings {
+	return TraySettings{StartHidden: r.savedSettings().StartHidden}
+}
+
+func (r *collectorRuntime) setTraySettings(settings TraySettings) (TrayStatus, error) {
+	r.savedSettings()
+	r.settingsMu.Lock()
+	r.settings.StartHidden = settings.StartHidden
+	r.settingsMu.Unlock()
+	return r.trayStatus(), r.saveSettings()
+}
+
+func (r *collectorRuntime) trayStatus() TrayStatus {
+	settings := r.traySettings()
+
+	r.trayMu.Lock()
+	defer r.trayMu.Unlock()
+	return TrayStatus{TraySettings: settings, Available: r.tray != nil, Unread: r.unread}
+}
+
+// countUnread adds a batch to the tray's unread count while no phant
+// window has focus.
+func (r *collectorRuntime) countUnread(batch []dump.Event) {
+	if len(batch) == 0 || r.windowFocused() {
+		return
+	}
+
+	r.trayMu.Lock()
+	if r.tray == nil {
+		r.trayMu.Unlock()
+		return
+	}
+	r.unread += len(batch)
+	r.trayMu.Unlock()
+	r.showUnread()
+}
+
+func (r *collectorRuntime) markRead() {
+	r.trayMu.Lock()
+	r.unread = 0
+	r.trayMu.Unlock()
+	r.showUnread()
+}
+
+// showUnread puts the unread count next to the tray icon where the
+// platform shows a label (the macOS menu bar) and in the tooltip. Tray
+// calls wait for the main thread, so they are made without holding trayMu.
+func (r *collectorRuntime) showUnread() {
+	r.trayMu.Lock()
+	tray, unread := r.tray, r.unread
+	r.trayMu.Unlock()
+	if tray == nil {
+		return
+	}
+
+	label, tooltip := "", "Phant"
+	switch {
+	case unread > 999:
+		label, tooltip = "999+", fmt.Sprintf("Phant: %d unread events", unread)
+	case unread > 0:
+		label, tooltip = strconv.Itoa(unread), fmt.Sprintf("Phant: %d unread events", unread)
+	}
+	tray.SetLabel(label)
+	tray.SetTooltip(tooltip)
+}
+
+// pauseStream pauses the live feed and tells the tray and the UI, since
+// either may have asked.
+func (r *collectorRuntime) pauseStream() pipeline.GateStatus {
+	status := r.liveGate.Pause()
+	r.streamPauseChanged(status)
+	return status
+}
+
+func (r *collectorRuntime) streamPauseChanged(status pipeline.GateStatus) {
+	r.trayMu.Lock()
+	pause := r.trayPause
+	r.trayMu.Unlock()
+	if pause != nil && pause.Checked() != status.Paused {
+		pause.SetChecked(status.Paused)
+	}
+	if r.app != nil {
+		r.app.Event.Emit(StreamPauseChangedRuntimeChannel, status)
+	}
+}
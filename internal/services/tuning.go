@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"runtime"
+
+	"phant/internal/pipeline"
+	"phant/internal/store"
+)
+
+const (
+	maxTunedWorkers    = 256
+	maxTunedQueueDepth = 1 << 20
+)
+
+// PipelineTuning sizes the worker pools and queues along the ingest path.
+// Zero fields use defaults derived from the CPU count. The session log is
+// always written by a single committer, so the store is tuned by batch size
+// rather than worker count.
+type PipelineTuning struct {
+	DecodeWorkers    int `json:"decodeWorkers"`
+	DecodeQueueDepth int `json:"decodeQueueDepth"`
+	StoreBatchSize   int `json:"storeBatchSize"`
+	StoreQueueDepth  int `json:"storeQueueDepth"`
+	IndexWorkers     int `json:"indexWorkers"`
+	IndexQueueDepth  int `json:"indexQueueDepth"`
+	BridgeQueueDepth int `json:"bridgeQueueDepth"`
+	// Overflow is what a full decode queue does with the next event. It is
+	// the one setting applied without a restart.
+	Overflow pipeline.OverflowPolicy `json:"overflow"`
+}
+
+type PipelineTuningStatus struct {
+	Configured PipelineTuning `json:"configured"`
+	Defaults   PipelineTuning `json:"defaults"`
+	// Active is what the running collector was started with; pools are
+	// sized at startup, so changes apply the next time phant starts.
+	Active          PipelineTuning `json:"active"`
+	RestartRequired bool           `json:"restartRequired"`
+}
+
+// QueueOccupancy is a live view of one queue on the ingest path.
+type QueueOccupancy struct {
+	Name     string `json:"name"`
+	Queued   int    `json:"queued"`
+	Capacity int    `json:"capacity"`
+	Dropped  uint64 `json:"dropped"`
+}
+
+func defaultPipelineTuning() PipelineTuning {
+	cpus := max(runtime.NumCPU(), 1)
+	return PipelineTuning{
+		DecodeWorkers:    cpus,
+		DecodeQueueDepth: pipeline.DefaultShardDepth,
+		StoreBatchSize:   store.DefaultBatchSize,
+		StoreQueueDepth:  4096,
+		// Indexing is background work; keep it from competing with ingest.
+		IndexWorkers:     max(cpus/4, 1),
+		IndexQueueDepth:  4096,
+		BridgeQueueDepth: pipeline.DefaultSubscriberBuffer,
+		Overflow:         pipeline.OverflowBlock,
+	}
+}
+
+func (t PipelineTuning) withDefaults() PipelineTuning {
+	defaults := defaultPipelineTuning()
+	fill := func(value *int, fallback int) {
+		if *value == 0 {
+			*value = fallback
+		}
+	}
+	fill(&t.DecodeWorkers, defaults.DecodeWorkers)
+	fill(&t.DecodeQueueDepth, defaults.DecodeQueueDepth)
+	fill(&t.StoreBatchSize, defaults.StoreBatchSize)
+	fill(&t.StoreQueueDepth, defaults.StoreQueueDepth)
+	fill(&t.IndexWorkers, defaults.IndexWorkers)
+	fill(&t.IndexQueueDepth, defaults.IndexQueueDepth)
+	fill(&t.BridgeQueueDepth, defaults.BridgeQueueDepth)
+	if t.Overflow == "" {
+		t.Overflow = defaults.Overflow
+	}
+	return t
+}
+
+func (t PipelineTuning) validate() error {
+	for _, workers := range []int{t.DecodeWorkers, t.IndexWorkers} {
+		if workers < 0 || workers > maxTunedWorkers {
+			return errors.New("worker counts must be between 0 and 256")
+		}
+	}
+	for _, depth := range []int{t.DecodeQueueDepth, t.StoreBatchSize, t.StoreQueueDepth, t.IndexQueueDepth, t.BridgeQueueDepth} {
+		if depth < 0 || depth > maxTunedQueueDepth {
+			return errors.New("queue depths and batch sizes must be between 0 and 1048576")
+		}
+	}
+	return t.Overflow.Validate()
+}
+
+func (r *collectorRuntime) pipelineTuningStatus() PipelineTuningStatus {
+	status := PipelineTuningStatus{
+		Configured: r.tuning,
+		Defaults:   defaultPipelineTuning(),
+		Active:     r.activeTuning,
+	}
+	status.RestartRequired = r.collector != nil && r.tuning.withDefaults() != r.activeTuning
+	return status
+}
+
+func (r *collectorRuntime) setPipelineTuning(tuning PipelineTuning) (PipelineTuningStatus, error) {
+	if err := tuning.validate(); err != nil {
+		return r.pipelineTuningStatus(), err
+	}
+	r.tuning = tuning
+	if r.collector != nil {
+		overflow := tuning.withDefaults().Overflow
+		r.collector.SetOverflowPolicy(overflow)
+		r.activeTuning.Overflow = overflow
+	}
+	return r.pipelineTuningStatus(), nil
+}
+
+// IngestStats are the counters behind the status bar: events accepted from
+// producers, events committed to the session log, and events lost between
+// the two.
+type IngestStats struct {
+	Overflow pipeline.OverflowPolicy `json:"overflow"`
+	Received uint64                  `json:"received"`
+	Stored   uint64                  `json:"stored"`
+	// Dropped counts events discarded by the overflow policy. The session
+	// log takes every published event, however far behind it falls.
+	Dropped uint64 `json:"dropped"`
+	// Collapsed counts repeats folded into an earlier event; they are not
+	// drops.
+	Collapsed uint64                `json:"collapsed"`
+	Shards    []pipeline.ShardStats `json:"shards"`
+}
+
+func (r *collectorRuntime) ingestStats() IngestStats {
+	stats := IngestStats{Overflow: r.tuning.withDefaults().Overflow, Shards: []pipeline.ShardStats{}}
+	if r.collector == nil {
+		return stats
+	}
+
+	stats.Overflow = r.collector.OverflowPolicy()
+	stats.Shards = r.collector.IngestStats()
+	for _, shard := range stats.Shards {
+		stats.Received += shard.Received
+		stats.Dropped += shard.Dropped
+	}
+	stats.Stored = r.storeStats().Committed
+	stats.Collapsed = r.collector.CollapsedCount()
+	return stats
+}
+
+// queueOccupancy reports how full each queue between the socket and its
+// consumers is right now.
+func (r *collectorRuntime) queueOccupancy() []QueueOccupancy {
+	queues := []QueueOccupancy{}
+	if r.collector == nil {
+		return queues
+	}
+
+	decode := QueueOccupancy{Name: "decode"}
+	for _, shard := range r.collector.IngestStats() {
+		decode.Queued += shard.Queued
+		decode.Capacity += shard.Capacity
+		decode.Dropped += shard.Dropped
+	}
+	queues = append(queues, decode)
+
+	subscription := func(name string, id int, active bool) {
+		if !active {
+			return
+		}
+		if stats, ok := r.collector.SubscriberStats(id); ok {
+			queues = append(queues, QueueOccupancy{Name: name, Queued: stats.Buffered, Capacity: stats.Capacity, Dropped: stats.Dropped})
+		}
+	}
+	subscription("bridge", r.collectorSubID, r.collectorDone != nil)
+	subscription("store", r.storeSubID, r.store != nil)
+	subscription("forward", r.forwardSubID, r.forwarding)
+	subscription("alert", r.alertSubID, r.alerting)
+	subscription("hook", r.hookSubID, r.hooking)
+	subscription("otlp", r.otlpSubID, r.exportingOTLP)
+	recordSubID, recording := r.recordingSubscription()
+	subscription("record", recordSubID, recording)
+
+	r.searchMu.Lock()
+	indexing := r.indexer != nil
+	r.searchMu.Unlock()
+	subscription("index", r.indexSubID, indexing)
+
+	return queues
+}
@@ -1,15 +1,83 @@
 package services
 
-import "phant/internal/dump"
+import (
+	"errors"
+
+	"phant/internal/dump"
+)
 
 const DumpEventSchemaVersion = dump.SchemaVersion
 const DumpEventRuntimeChannel = "phant:dump:event"
+const DumpStreamRuntimeChannelPrefix = "phant:dump:stream"
+const DeepLinkRuntimeChannel = "phant:deeplink:open"
+const RetentionPrunedRuntimeChannel = "phant:dump:pruned"
+const ShapeChangedRuntimeChannel = "phant:dump:shape-changed"
+const EventsClearedRuntimeChannel = "phant:dump:cleared"
+const HealthChangedRuntimeChannel = "phant:health:changed"
+const ProjectsChangedRuntimeChannel = "phant:projects:changed"
+const GatesChangedRuntimeChannel = "phant:gates:changed"
+const AnnotationsChangedRuntimeChannel = "phant:annotations:changed"
+const FiltersChangedRuntimeChannel = "phant:filters:changed"
+const HostsChangedRuntimeChannel = "phant:hosts:changed"
+const DashboardsChangedRuntimeChannel = "phant:dashboards:changed"
+const StreamPauseChangedRuntimeChannel = "phant:stream:pause-changed"
+const EventsRestoredRuntimeChannel = "phant:dump:restored"
+const StreamSectionRuntimeChannel = "phant:stream:section"
+const RulesReloadedRuntimeChannel = "phant:rules:reloaded"
+const ImportProgressRuntimeChannel = "phant:import:progress"
+const AlertRuntimeChannel = "phant:alert"
+const ReplayEventRuntimeChannel = "phant:replay:event"
 
 var ErrUnsupportedSchemaVersion = dump.ErrUnsupportedSchemaVersion
 
+var ErrCollectorNotRunning = errors.New("collector is not running")
+var ErrEventNotFound = errors.New("dump event not found")
+var ErrDashboardNotFound = errors.New("dashboard not found")
+var ErrStormNotFound = errors.New("dump storm not found")
+var ErrNotSQLQuery = errors.New("dump event is not a database query")
+var ErrNotHTTPRequest = errors.New("dump event has no http request")
+var ErrImportRunning = errors.New("file is already being imported")
+var ErrNotBinaryPayload = errors.New("dump event payload is not binary")
+var ErrSourceNotFound = errors.New("ingest source not found")
+var ErrSourceExists = errors.New("ingest source already exists")
+var ErrBuiltinSource = errors.New("built-in ingest sources are configured by their own settings")
+var ErrRecordingActive = errors.New("a session is already being recorded")
+var ErrAPINotRunning = errors.New("rest api is not running")
+
+// DedupSettings controls collapsing of consecutive identical dumps (same
+// callsite and payload) into one event with a repeat count. A zero window
+// keeps every dump.
+type DedupSettings struct {
+	WindowMs int `json:"windowMs"`
+}
+
+// DefaultSkewWarningMs is the clock skew that adds a warning once receive
+// times are recorded.
+const DefaultSkewWarningMs = 5000
+
+// ClockSettings controls the receive clock. When RecordReceivedAt is set,
+// ingested events carry the time they were received, queries can order and
+// filter by it, and events whose timestamp is more than SkewWarningMs away
+// from it get a warning; zero never warns.
+type ClockSettings struct {
+	RecordReceivedAt bool `json:"recordReceivedAt"`
+	SkewWarningMs    int  `json:"skewWarningMs"`
+}
+
 type CollectorStatus struct {
 	Running    bool   `json:"running"`
 	SocketPath string `json:"socketPath"`
 	LastError  string `json:"lastError"`
 	Dropped    uint64 `json:"dropped"`
 }
+
+type DumpImportResult struct {
+	Path      string           `json:"path"`
+	Imported  int              `json:"imported"`
+	Lines     int              `json:"lines"`
+	Errors    []dump.LineError `json:"errors"`
+	Truncated bool             `json:"truncated"`
+	// Cancelled is set when CancelImport stopped the import; the events
+	// imported until then are kept.
+	Cancelled bool `json:"cancelled"`
+}
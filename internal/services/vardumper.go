@@ -0,0 +1,46 @@
+package services
+
+import (
+	"phant/internal/vardumper"
+)
+
+// VarDumperStatus reports the symfony/var-dumper compatible listener. An empty
+// Address means the listener is disabled.
+type VarDumperStatus struct {
+	Address      string `json:"address"`
+	Running      bool   `json:"running"`
+	Received     uint64 `json:"received"`
+	Rejected     uint64 `json:"rejected"`
+	Unauthorized uint64 `json:"unauthorized"`
+	LastError    string `json:"lastError,omitempty"`
+}
+
+func (r *collectorRuntime) newVarDumperServer(address string) *vardumper.Server {
+	server := vardumper.NewServer(address, r.sourceHandler(SourceVarDumper))
+	server.SetAuth(r.auth)
+	server.SetTLS(r.listenerTLSConfig())
+	server.SetCapture(r.captureRecorder)
+	return server
+}
+
+func (r *collectorRuntime) setVarDumperAddress(address string) (VarDumperStatus, error) {
+	err := r.varDumper.configure(address, r.collectorRunning())
+	_ = r.refreshDiscovery()
+	return r.varDumperStatus(), err
+}
+
+func (r *collectorRuntime) varDumperStatus() VarDumperStatus {
+	var status VarDumperStatus
+	r.varDumper.inspect(func(address string, server *vardumper.Server, running bool, lastErr string) {
+		status = VarDumperStatus{Address: address, LastError: lastErr}
+		if running {
+			stats := server.Stats()
+			status.Address = stats.Address
+			status.Running = true
+			status.Received = stats.Received
+			status.Rejected = stats.Rejected
+			status.Unauthorized = stats.Unauthorized
+		}
+	})
+	return status
+}
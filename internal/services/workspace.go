@@ -0,0 +1,96 @@
+package services
+
+import (
+	"phant/internal/config"
+	"phant/internal/dump"
+	"phant/internal/query"
+	"phant/internal/retention"
+	"phant/internal/workspace"
+)
+
+// ProjectInfo is a workspace project together with its resolved project
+// configuration, which carries the editor and path mappings.
+type ProjectInfo struct {
+	workspace.Project
+	Config config.ResolvedProject `json:"config"`
+}
+
+func (r *collectorRuntime) observeProject(event dump.Event) {
+	if !r.workspace.Observe(event) {
+		return
+	}
+	r.projects.Observe(event.ProjectRoot)
+	r.emitProjectsChanged()
+}
+
+func (r *collectorRuntime) projectInfo(project workspace.Project) ProjectInfo {
+	return ProjectInfo{Project: project, Config: r.projects.Resolve(project.Root)}
+}
+
+func (r *collectorRuntime) listProjects(includeArchived bool) []ProjectInfo {
+	projects := r.workspace.Projects(includeArchived)
+	infos := make([]ProjectInfo, len(projects))
+	for i, project := range projects {
+		infos[i] = r.projectInfo(project)
+	}
+	return infos
+}
+
+func (r *collectorRuntime) getProject(root string) (ProjectInfo, bool) {
+	project, ok := r.workspace.Project(root)
+	if !ok {
+		return ProjectInfo{}, false
+	}
+	return r.projectInfo(project), true
+}
+
+func (r *collectorRuntime) updateProject(root string, settings workspace.Settings) (ProjectInfo, error) {
+	project, err := r.workspace.Update(root, settings)
+	if err != nil {
+		return ProjectInfo{}, err
+	}
+	if _, err := r.applyProjectRetention(); err != nil {
+		return ProjectInfo{}, err
+	}
+	r.emitProjectsChanged()
+	return r.projectInfo(project), nil
+}
+
+func (r *collectorRuntime) archiveProject(root string, archived bool) (ProjectInfo, error) {
+	project, _ := r.workspace.Project(root)
+	settings := project.Settings
+	settings.Archived = archived
+	return r.updateProject(root, settings)
+}
+
+func (r *collectorRuntime) loadWorkspace(settings map[string]workspace.Settings) error {
+	if err := r.workspace.Load(settings); err != nil {
+		return err
+	}
+	_, err := r.applyProjectRetention()
+	r.emitProjectsChanged()
+	return err
+}
+
+// applyProjectRetention hands the per-project policies to the running
+// retention engine; they are picked up at startup otherwise.
+func (r *collectorRuntime) applyProjectRetention() (retention.Summary, error) {
+	if r.retention == nil {
+		return retention.Summary{}, nil
+	}
+	return r.retention.SetProjectPolicies(r.workspace.RetentionPolicies())
+}
+
+func (r *collectorRuntime) queryProjectEvents(root string, filter query.Filter, page query.Page) (query.Result, error) {
+	if root == "" {
+		return query.Result{}, workspace.ErrEmptyRoot
+	}
+	filter.ProjectRoot = root
+	return r.queryEvents(filter, page)
+}
+
+func (r *collectorRuntime) emitProjectsChanged() {
+	if r.app != nil {
+		r.app.Event.Emit(ProjectsChangedRuntimeChannel, r.listProjects(true))
+	}
+}
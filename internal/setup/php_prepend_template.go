@@ -237,6 +237,108 @@ function phant_emit_value($var, bool $isDd): void {
     phant_send_event($event);
 }
 
+function phant_exception_meta(\Throwable $throwable, int $depth = 0): array {
+    $trace = [];
+    foreach (array_slice($throwable->getTrace(), 0, 50) as $frame) {
+        $function = isset($frame['function']) ? (string)$frame['function'] : '';
+        if (isset($frame['class'])) {
+            $function = $frame['class'] . ($frame['type'] ?? '::') . $function;
+        }
+        $trace[] = [
+            'file' => isset($frame['file']) ? (string)$frame['file'] : '',
+            'line' => isset($frame['line']) ? (int)$frame['line'] : 0,
+            'func' => $function,
+        ];
+    }
+
+    $code = $throwable->getCode();
+    $meta = [
+        'class' => get_class($throwable),
+        'message' => $throwable->getMessage(),
+        'code' => is_int($code) ? $code : (string)$code,
+        'file' => $throwable->getFile(),
+        'line' => $throwable->getLine(),
+        'trace' => $trace,
+    ];
+
+    $previous = $throwable->getPrevious();
+    if ($previous !== null && $depth < 31) {
+        $meta['previous'] = phant_exception_meta($previous, $depth + 1);
+    }
+
+    return $meta;
+}
+
+function phant_report(\Throwable $throwable, $context = null): void {
+    $seen = [];
+    $event = [
+        'schemaVersion' => 2,
+        'id' => uniqid('phant_', true),
+        'timestamp' => gmdate('Y-m-d\\TH:i:s\\Z'),
+        'sourceType' => phant_source_type(),
+        'projectRoot' => getcwd() ?: '',
+        'phpSapi' => PHP_SAPI,
+        'requestId' => $_SERVER['HTTP_X_REQUEST_ID'] ?? null,
+        'http' => phant_http_meta(),
+        'command' => phant_command_meta(),
+        'isDd' => false,
+        'isError' => true,
+        'level' => 'error',
+        'exception' => phant_exception_meta($throwable),
+        'payloadFormat' => 'json',
+        'payload' => phant_normalize_value($context, 0, $seen),
+        'trace' => phant_trace_callsite(),
+        'host' => [
+            'hostname' => gethostname() ?: 'unknown',
+            'pid' => getmypid() ?: 0,
+        ],
+    ];
+
+    phant_send_event($event);
+}
+
+function phant_query(string $sql, array $bindings = [], ?float $timeMs = null, ?string $connection = null): void {
+    foreach ($bindings as $key => $binding) {
+        if ($binding instanceof \DateTimeInterface) {
+            $bindings[$key] = $binding->format('Y-m-d H:i:s');
+        } elseif (is_object($binding) || is_resource($binding)) {
+            $bindings[$key] = is_object($binding) && method_exists($binding, '__toString') ? (string)$binding : get_debug_type($binding);
+        }
+    }
+
+    $sqlMeta = ['query' => $sql, 'bindings' => array_keys($bindings) === range(0, count($bindings) - 1) || $bindings === [] ? $bindings : (object)$bindings];
+    if ($connection !== null) {
+        $sqlMeta['connection'] = $connection;
+    }
+
+    $event = [
+        'schemaVersion' => 2,
+        'id' => uniqid('phant_', true),
+        'timestamp' => gmdate('Y-m-d\\TH:i:s\\Z'),
+        'sourceType' => phant_source_type(),
+        'projectRoot' => getcwd() ?: '',
+        'phpSapi' => PHP_SAPI,
+        'requestId' => $_SERVER['HTTP_X_REQUEST_ID'] ?? null,
+        'http' => phant_http_meta(),
+        'command' => phant_command_meta(),
+        'isDd' => false,
+        'label' => 'query',
+        'sql' => $sqlMeta,
+        'payloadFormat' => 'text',
+        'payload' => $sql,
+        'trace' => phant_trace_callsite(),
+        'host' => [
+            'hostname' => gethostname() ?: 'unknown',
+            'pid' => getmypid() ?: 0,
+        ],
+    ];
+    if ($timeMs !== null) {
+        $event['durationMs'] = max(0.0, $timeMs);
+    }
+
+    phant_send_event($event);
+}
+
 function phant_install_vardumper_handler(): bool {
     static $installed = false;
 
@@ -52,3 +52,23 @@ func TestPHPPrependTemplate_NormalizesObjectMetadata(t *testing.T) {
 		t.Fatalf("phpPrependTemplate should substitute invalid UTF-8 during encoding")
 	}
 }
+
+func TestPHPPrependTemplate_ReportsExceptions(t *testing.T) {
+	if !strings.Contains(phpPrependTemplate, "function phant_report(\\Throwable $throwable, $context = null): void") {
+		t.Fatalf("phpPrependTemplate missing phant_report helper")
+	}
+
+	if !strings.Contains(phpPrependTemplate, "'exception' => phant_exception_meta($throwable)") {
+		t.Fatalf("phpPrependTemplate should emit an exception block from phant_report")
+	}
+}
+
+func TestPHPPrependTemplate_SendsQueries(t *testing.T) {
+	if !strings.Contains(phpPrependTemplate, "function phant_query(string $sql, array $bindings = [], ?float $timeMs = null, ?string $connection = null): void") {
+		t.Fatalf("phpPrependTemplate missing phant_query helper")
+	}
+
+	if !strings.Contains(phpPrependTemplate, "'sql' => $sqlMeta") {
+		t.Fatalf("phpPrependTemplate should emit an sql block from phant_query")
+	}
+}
@@ -0,0 +1,133 @@
+package signature
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"phant/internal/dump"
+)
+
+const DefaultCacheSize = 4096
+
+// Cache memoizes signatures by payload content hash, so identical payloads
+// dumped in a loop are only inferred once.
+type Cache struct {
+	capacity int
+	load     func(dump.Event) dump.Event
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]Signature
+}
+
+func NewCache(capacity int) *Cache {
+	if capacity <= 0 {
+		capacity = DefaultCacheSize
+	}
+
+	return &Cache{
+		capacity: capacity,
+		entries:  make(map[[sha256.Size]byte]Signature),
+	}
+}
+
+// SetLoader sets a function that brings back the full payload of an
+// offloaded event; without one, offloaded events are described by their
+// preview. It must be called before the cache is shared.
+func (c *Cache) SetLoader(load func(dump.Event) dump.Event) {
+	c.load = load
+}
+
+func (c *Cache) Signature(payload json.RawMessage) (Signature, error) {
+	key := sha256.Sum256(payload)
+	if cached, ok := c.lookup(key); ok {
+		return cached, nil
+	}
+
+	signature, err := Infer(payload)
+	if err != nil {
+		return Signature{}, err
+	}
+
+	c.mu.Lock()
+	if len(c.entries) >= c.capacity {
+		c.entries = make(map[[sha256.Size]byte]Signature)
+	}
+	c.entries[key] = signature
+	c.mu.Unlock()
+
+	return signature, nil
+}
+
+// EventSignature infers the signature of event's full payload. An offloaded
+// payload's PayloadRef is the hash of its full content, which is what the
+// cache is keyed by, so it is only loaded on a miss.
+func (c *Cache) EventSignature(event dump.Event) (Signature, error) {
+	if !event.Offloaded || event.Truncated || c.load == nil {
+		return c.Signature(event.Payload)
+	}
+	var key [sha256.Size]byte
+	if ref, err := hex.DecodeString(event.PayloadRef); err == nil && len(ref) == len(key) {
+		copy(key[:], ref)
+		if cached, ok := c.lookup(key); ok {
+			return cached, nil
+		}
+	}
+	return c.Signature(c.load(event).Payload)
+}
+
+func (c *Cache) lookup(key [sha256.Size]byte) (Signature, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.entries[key]
+	return cached, ok
+}
+
+type Group struct {
+	Signature
+	Count         int    `json:"count"`
+	FirstEventID  string `json:"firstEventId"`
+	LatestEventID string `json:"latestEventId"`
+}
+
+// GroupEvents buckets events by the signature of their full payload, most
+// frequent first. Events are expected oldest first.
+func (c *Cache) GroupEvents(events []dump.Event) []Group {
+	index := map[string]int{}
+	groups := []Group{}
+
+	for _, event := range events {
+		signature, err := c.EventSignature(event)
+		if err != nil {
+			continue
+		}
+
+		idx, ok := index[signature.Hash]
+		if !ok {
+			idx = len(groups)
+			index[signature.Hash] = idx
+			groups = append(groups, Group{Signature: signature, FirstEventID: event.ID})
+		}
+		groups[idx].Count++
+		groups[idx].LatestEventID = event.ID
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+	return groups
+}
+
+// Filter returns the events whose full payload's signature hash matches.
+func (c *Cache) Filter(events []dump.Event, hash string) []dump.Event {
+	matched := []dump.Event{}
+	for _, event := range events {
+		signature, err := c.EventSignature(event)
+		if err == nil && signature.Hash == hash {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
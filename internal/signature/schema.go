@@ -0,0 +1,162 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"phant/internal/dump"
+	"phant/internal/jsonpath"
+)
+
+// maxSchemaFields bounds the paths a schema tracks, so payloads keyed by
+// IDs or timestamps cannot grow it without end.
+const maxSchemaFields = 1000
+
+// Schema summarizes the structure of a set of payloads: every path seen,
+// the types found there, and the distinct shapes the payloads took. More
+// than one variant means the structure changed between events.
+type Schema struct {
+	Origin    string         `json:"origin"`
+	Samples   int            `json:"samples"`
+	Skipped   int            `json:"skipped"`
+	MaxDepth  int            `json:"maxDepth"`
+	Fields    []FieldSummary `json:"fields"`
+	Truncated bool           `json:"truncated,omitempty"`
+	Variants  []Group        `json:"variants"`
+}
+
+// FieldSummary describes one path, as a JSONPath where [*] stands for
+// every array element. Count is how often a value was found there;
+// Optional is set when some of the objects holding it lacked it.
+type FieldSummary struct {
+	Path      string      `json:"path"`
+	Types     []TypeCount `json:"types"`
+	Count     int         `json:"count"`
+	Optional  bool        `json:"optional,omitempty"`
+	MinLength *int        `json:"minLength,omitempty"`
+	MaxLength *int        `json:"maxLength,omitempty"`
+}
+
+type TypeCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+type fieldStats struct {
+	parent  string
+	count   int
+	objects int
+	types   map[string]int
+	arrays  int
+	minLen  int
+	maxLen  int
+}
+
+type schemaBuilder struct {
+	schema Schema
+	fields map[string]*fieldStats
+}
+
+// Schema infers the schema of the payloads of events, which are expected
+// oldest first. Binary payloads and ones that are not JSON, such as
+// truncated ones, are counted as skipped.
+func (c *Cache) Schema(events []dump.Event) Schema {
+	builder := schemaBuilder{fields: map[string]*fieldStats{}}
+	decodable := make([]dump.Event, 0, len(events))
+	for _, event := range events {
+		if event.PayloadFormat == dump.PayloadFormatBinary || builder.add(event.Payload) != nil {
+			builder.schema.Skipped++
+			continue
+		}
+		decodable = append(decodable, event)
+	}
+
+	schema := builder.result()
+	schema.Variants = c.GroupEvents(decodable)
+	return schema
+}
+
+func (b *schemaBuilder) add(payload json.RawMessage) error {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	b.schema.Samples++
+	b.schema.MaxDepth = max(b.schema.MaxDepth, b.visit("$", "", value, 0))
+	return nil
+}
+
+// visit records value at path and returns how deeply containers nest
+// inside it.
+func (b *schemaBuilder) visit(path string, parent string, value any, depth int) int {
+	stats, ok := b.fields[path]
+	if !ok {
+		if len(b.fields) >= maxSchemaFields {
+			b.schema.Truncated = true
+			return 0
+		}
+		stats = &fieldStats{parent: parent, types: map[string]int{}}
+		b.fields[path] = stats
+	}
+	stats.count++
+
+	if depth >= maxDepth {
+		stats.types["any"]++
+		return 0
+	}
+
+	nested := 0
+	switch typed := value.(type) {
+	case []any:
+		stats.types["array"]++
+		if stats.arrays == 0 || len(typed) < stats.minLen {
+			stats.minLen = len(typed)
+		}
+		stats.maxLen = max(stats.maxLen, len(typed))
+		stats.arrays++
+		for _, item := range typed {
+			nested = max(nested, b.visit(path+"[*]", "", item, depth+1))
+		}
+		return nested + 1
+	case map[string]any:
+		stats.types["object"]++
+		stats.objects++
+		for key, member := range typed {
+			nested = max(nested, b.visit(path+jsonpath.MemberPath(key), path, member, depth+1))
+		}
+		return nested + 1
+	default:
+		stats.types[shapeAt(value, depth)]++
+		return 0
+	}
+}
+
+func (b *schemaBuilder) result() Schema {
+	schema := b.schema
+	schema.Fields = make([]FieldSummary, 0, len(b.fields))
+	for path, stats := range b.fields {
+		field := FieldSummary{Path: path, Count: stats.count}
+		if parent, ok := b.fields[stats.parent]; ok {
+			field.Optional = stats.count < parent.objects
+		}
+		if stats.arrays > 0 {
+			field.MinLength, field.MaxLength = &stats.minLen, &stats.maxLen
+		}
+		for name, count := range stats.types {
+			field.Types = append(field.Types, TypeCount{Type: name, Count: count})
+		}
+		sort.Slice(field.Types, func(i, j int) bool {
+			if field.Types[i].Count != field.Types[j].Count {
+				return field.Types[i].Count > field.Types[j].Count
+			}
+			return field.Types[i].Type < field.Types[j].Type
+		})
+		schema.Fields = append(schema.Fields, field)
+	}
+	sort.Slice(schema.Fields, func(i, j int) bool { return schema.Fields[i].Path < schema.Fields[j].Path })
+	return schema
+}
@@ -0,0 +1,44 @@
+package signature
+
+import (
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestCache_SchemaAggregatesFieldsAcrossPayloads(t *testing.T) {
+	events := []dump.Event{
+		{ID: "e1", Payload: []byte(`{"user":{"id":1,"name":"Ada"},"tags":["a","b"]}`)},
+		{ID: "e2", Payload: []byte(`{"user":{"id":"2"},"tags":[]}`)},
+		{ID: "e3", Payload: []byte(`{"user":{"id":3,"name":"Lin"},"tags":["c"]}`)},
+		{ID: "e4", Payload: []byte(`{"user":`), Truncated: true},
+		{ID: "e5", Payload: []byte(`AAEC`), PayloadFormat: dump.PayloadFormatBinary},
+	}
+
+	schema := NewCache(0).Schema(events)
+	if schema.Samples != 3 || schema.Skipped != 2 || schema.MaxDepth != 2 {
+		t.Fatalf("Schema() samples = %d, skipped = %d, depth = %d", schema.Samples, schema.Skipped, schema.MaxDepth)
+	}
+	if len(schema.Variants) != 2 || schema.Variants[0].Count != 2 {
+		t.Fatalf("Schema() variants = %+v, want two shapes, the common one first", schema.Variants)
+	}
+
+	fields := map[string]FieldSummary{}
+	for _, field := range schema.Fields {
+		fields[field.Path] = field
+	}
+	id := fields["$.user.id"]
+	if id.Count != 3 || id.Optional || len(id.Types) != 2 || id.Types[0] != (TypeCount{Type: "number", Count: 2}) {
+		t.Fatalf("$.user.id = %+v, want number twice and string once", id)
+	}
+	if name := fields["$.user.name"]; name.Count != 2 || !name.Optional {
+		t.Fatalf("$.user.name = %+v, want optional", name)
+	}
+	tags := fields["$.tags"]
+	if tags.MinLength == nil || *tags.MinLength != 0 || *tags.MaxLength != 2 {
+		t.Fatalf("$.tags = %+v, want lengths 0 to 2", tags)
+	}
+	if element := fields["$.tags[*]"]; element.Count != 3 || element.Optional {
+		t.Fatalf("$.tags[*] = %+v, want three strings", element)
+	}
+}
@@ -0,0 +1,94 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const maxDepth = 16
+
+// Signature describes the structural type of a JSON payload. Shape is a
+// canonical rendering such as {id:number,tags:[string]}; Hash is a short
+// stable identifier of that shape.
+type Signature struct {
+	Hash  string `json:"hash"`
+	Shape string `json:"shape"`
+}
+
+func Infer(payload json.RawMessage) (Signature, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return Signature{}, err
+	}
+
+	shape := Shape(value)
+	sum := sha256.Sum256([]byte(shape))
+	return Signature{Hash: hex.EncodeToString(sum[:8]), Shape: shape}, nil
+}
+
+// Shape renders the canonical type of a decoded JSON value. Object keys are
+// sorted and array element types are merged into a sorted union.
+func Shape(value any) string {
+	return shapeAt(value, 0)
+}
+
+func shapeAt(value any, depth int) string {
+	if depth >= maxDepth {
+		return "any"
+	}
+
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		seen := map[string]bool{}
+		var members []string
+		for _, item := range typed {
+			member := shapeAt(item, depth+1)
+			if !seen[member] {
+				seen[member] = true
+				members = append(members, member)
+			}
+		}
+		sort.Strings(members)
+		return "[" + strings.Join(members, "|") + "]"
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fields := make([]string, len(keys))
+		for i, key := range keys {
+			fields[i] = quoteKey(key) + ":" + shapeAt(typed[key], depth+1)
+		}
+		return "{" + strings.Join(fields, ",") + "}"
+	default:
+		return "any"
+	}
+}
+
+func quoteKey(key string) string {
+	if key != "" && strings.IndexFunc(key, func(r rune) bool {
+		return !(r == '_' || r == '-' || r == '$' || r == '@' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	}) < 0 {
+		return key
+	}
+
+	quoted, _ := json.Marshal(key)
+	return string(quoted)
+}
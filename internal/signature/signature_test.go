@@ -0,0 +1,96 @@
+package signature
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestInfer_CanonicalShape(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    string
+	}{
+		{payload: `{"name":"Ada","id":42,"tags":["a",1,"b"],"meta":null}`, want: `{id:number,meta:null,name:string,tags:[number|string]}`},
+		{payload: `[]`, want: `[]`},
+		{payload: `"text"`, want: `string`},
+		{payload: `{"a b":true}`, want: `{"a b":bool}`},
+	}
+
+	for _, test := range tests {
+		got, err := Infer(json.RawMessage(test.payload))
+		if err != nil {
+			t.Fatalf("Infer(%s) error = %v", test.payload, err)
+		}
+		if got.Shape != test.want {
+			t.Fatalf("Infer(%s).Shape = %q, want %q", test.payload, got.Shape, test.want)
+		}
+	}
+}
+
+func TestInfer_SameShapeSameHash(t *testing.T) {
+	a, _ := Infer(json.RawMessage(`{"id":1,"name":"a"}`))
+	b, _ := Infer(json.RawMessage(`{"name":"b","id":2}`))
+	c, _ := Infer(json.RawMessage(`{"id":"1","name":"a"}`))
+
+	if a.Hash != b.Hash {
+		t.Fatalf("hashes differ for same shape: %q vs %q", a.Hash, b.Hash)
+	}
+	if a.Hash == c.Hash {
+		t.Fatalf("hashes equal for different shapes: %q", a.Hash)
+	}
+}
+
+func TestCache_GroupAndFilterEvents(t *testing.T) {
+	events := []dump.Event{
+		{ID: "1", Payload: json.RawMessage(`{"id":1}`)},
+		{ID: "2", Payload: json.RawMessage(`"x"`)},
+		{ID: "3", Payload: json.RawMessage(`{"id":2}`)},
+	}
+
+	cache := NewCache(0)
+	groups := cache.GroupEvents(events)
+	if len(groups) != 2 {
+		t.Fatalf("GroupEvents() len = %d, want 2", len(groups))
+	}
+	if groups[0].Count != 2 || groups[0].FirstEventID != "1" || groups[0].LatestEventID != "3" {
+		t.Fatalf("GroupEvents()[0] = %+v, want object group with 2 events", groups[0])
+	}
+
+	matched := cache.Filter(events, groups[0].Hash)
+	if len(matched) != 2 || matched[1].ID != "3" {
+		t.Fatalf("Filter() = %+v, want events 1 and 3", matched)
+	}
+}
+
+func TestCache_SignsOffloadedEventsByTheirFullPayload(t *testing.T) {
+	full := json.RawMessage(`{"id":1,"rows":[1,2,3]}`)
+	sum := sha256.Sum256(full)
+	offloaded := dump.Event{ID: "2", Payload: json.RawMessage(`{"id":1}`), Offloaded: true, PayloadRef: hex.EncodeToString(sum[:])}
+
+	loads := 0
+	cache := NewCache(0)
+	cache.SetLoader(func(event dump.Event) dump.Event {
+		loads++
+		event.Payload = full
+		return event
+	})
+
+	want, _ := Infer(full)
+	for range 2 {
+		if got, err := cache.EventSignature(offloaded); err != nil || got != want {
+			t.Fatalf("EventSignature(offloaded) = %+v, %v, want %+v", got, err, want)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("loaded the payload %d times, want once", loads)
+	}
+
+	groups := cache.GroupEvents([]dump.Event{{ID: "1", Payload: full}, offloaded})
+	if len(groups) != 1 || groups[0].Count != 2 {
+		t.Fatalf("GroupEvents() = %+v, want the offloaded event grouped with the full one", groups)
+	}
+}
@@ -0,0 +1,130 @@
+package signature
+
+import (
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	DefaultMinSamples = 3
+	maxShapeChanges   = 200
+)
+
+// ShapeChange reports a labeled event whose payload shape was never seen
+// for that label before, once the label has an established history.
+type ShapeChange struct {
+	Label      string    `json:"label"`
+	EventID    string    `json:"eventId"`
+	Signature  Signature `json:"signature"`
+	Expected   Signature `json:"expected"`
+	Samples    int       `json:"samples"`
+	DetectedAt string    `json:"detectedAt"`
+}
+
+type labelHistory struct {
+	total      int
+	counts     map[string]int
+	signatures map[string]Signature
+}
+
+// Tracker keeps per-label signature histories and flags deviations from the
+// dominant shape. Each new shape is flagged once; repeats become part of the
+// label's history.
+type Tracker struct {
+	minSamples int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	labels  map[string]*labelHistory
+	changes []ShapeChange
+}
+
+func NewTracker(minSamples int) *Tracker {
+	if minSamples <= 0 {
+		minSamples = DefaultMinSamples
+	}
+
+	return &Tracker{
+		minSamples: minSamples,
+		now:        time.Now,
+		labels:     make(map[string]*labelHistory),
+	}
+}
+
+func (t *Tracker) Observe(event dump.Event, signature Signature) (ShapeChange, bool) {
+	if event.Label == "" {
+		return ShapeChange{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	history, ok := t.labels[event.Label]
+	if !ok {
+		history = &labelHistory{
+			counts:     make(map[string]int),
+			signatures: make(map[string]Signature),
+		}
+		t.labels[event.Label] = history
+	}
+
+	var change ShapeChange
+	flagged := history.total >= t.minSamples && history.counts[signature.Hash] == 0
+	if flagged {
+		change = ShapeChange{
+			Label:      event.Label,
+			EventID:    event.ID,
+			Signature:  signature,
+			Expected:   history.dominant(),
+			Samples:    history.total,
+			DetectedAt: t.now().UTC().Format(time.RFC3339),
+		}
+		t.changes = append(t.changes, change)
+		if len(t.changes) > maxShapeChanges {
+			t.changes = t.changes[len(t.changes)-maxShapeChanges:]
+		}
+	}
+
+	history.total++
+	history.counts[signature.Hash]++
+	history.signatures[signature.Hash] = signature
+
+	return change, flagged
+}
+
+func (t *Tracker) Changes() []ShapeChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]ShapeChange{}, t.changes...)
+}
+
+// Reset forgets a label's history and its recorded changes, accepting the
+// current shape as the new norm.
+func (t *Tracker) Reset(label string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.labels, label)
+	kept := t.changes[:0]
+	for _, change := range t.changes {
+		if change.Label != label {
+			kept = append(kept, change)
+		}
+	}
+	t.changes = kept
+}
+
+func (h *labelHistory) dominant() Signature {
+	var best Signature
+	bestCount := 0
+	for hash, count := range h.counts {
+		if count > bestCount || count == bestCount && hash < best.Hash {
+			best = h.signatures[hash]
+			bestCount = count
+		}
+	}
+	return best
+}
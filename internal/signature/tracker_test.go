@@ -0,0 +1,44 @@
+package signature
+
+import (
+	"encoding/json"
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func TestTracker_FlagsNewShapeAfterHistory(t *testing.T) {
+	tracker := NewTracker(2)
+	userShape, _ := Infer(json.RawMessage(`{"id":1}`))
+	changedShape, _ := Infer(json.RawMessage(`{"id":"1"}`))
+
+	observe := func(id string, signature Signature) bool {
+		_, flagged := tracker.Observe(dump.Event{ID: id, Label: "user"}, signature)
+		return flagged
+	}
+
+	if observe("1", changedShape) || observe("2", userShape) || observe("3", userShape) {
+		t.Fatalf("Observe() flagged before history was established")
+	}
+
+	unseen, _ := Infer(json.RawMessage(`[]`))
+	change, flagged := tracker.Observe(dump.Event{ID: "4", Label: "user"}, unseen)
+	if !flagged {
+		t.Fatalf("Observe() flagged = false, want true for unseen shape")
+	}
+	if change.Expected.Hash != userShape.Hash || change.Samples != 3 || change.EventID != "4" {
+		t.Fatalf("Observe() change = %+v, want expected %q after 3 samples", change, userShape.Hash)
+	}
+	if observe("5", unseen) {
+		t.Fatalf("Observe() flagged repeat of an already reported shape")
+	}
+
+	if _, flagged := tracker.Observe(dump.Event{ID: "6"}, unseen); flagged {
+		t.Fatalf("Observe() flagged unlabeled event")
+	}
+
+	tracker.Reset("user")
+	if got := tracker.Changes(); len(got) != 0 {
+		t.Fatalf("Changes() after Reset = %+v, want empty", got)
+	}
+}
@@ -0,0 +1,114 @@
+// Package soak drives the ingest pipeline with synthetic events for hours
+// while sampling heap and goroutine counts, and flags steady growth that
+// points to a leak in the buffer, store, or bridge batching.
+package soak
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+type Sample struct {
+	Elapsed time.Duration `json:"elapsed"`
+	// HeapBytes is live heap after a forced GC, so garbage waiting for
+	// collection does not look like growth.
+	HeapBytes  uint64 `json:"heapBytes"`
+	Goroutines int    `json:"goroutines"`
+	Sent       uint64 `json:"sent"`
+}
+
+func TakeSample(elapsed time.Duration, sent uint64) Sample {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return Sample{
+		Elapsed:    elapsed,
+		HeapBytes:  stats.HeapAlloc,
+		Goroutines: runtime.NumGoroutine(),
+		Sent:       sent,
+	}
+}
+
+// Thresholds tune leak detection; zero fields use the defaults.
+type Thresholds struct {
+	// Windows is how many consecutive slices the samples are split into.
+	Windows int `json:"windows"`
+	// MinHeapGrowth and MinGoroutineGrowth ignore growth too small to
+	// matter, such as caches filling up once.
+	MinHeapGrowth      uint64 `json:"minHeapGrowth"`
+	MinGoroutineGrowth int    `json:"minGoroutineGrowth"`
+}
+
+const (
+	DefaultWindows            = 6
+	DefaultMinHeapGrowth      = 16 * 1024 * 1024
+	DefaultMinGoroutineGrowth = 10
+)
+
+func (t Thresholds) withDefaults() Thresholds {
+	if t.Windows < 2 {
+		t.Windows = DefaultWindows
+	}
+	if t.MinHeapGrowth == 0 {
+		t.MinHeapGrowth = DefaultMinHeapGrowth
+	}
+	if t.MinGoroutineGrowth <= 0 {
+		t.MinGoroutineGrowth = DefaultMinGoroutineGrowth
+	}
+	return t
+}
+
+type Finding struct {
+	Metric string `json:"metric"`
+	First  uint64 `json:"first"`
+	Last   uint64 `json:"last"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s grew monotonically from %d to %d", f.Metric, f.First, f.Last)
+}
+
+// Detect reports metrics that grew monotonically. Samples are split into
+// windows and each window is reduced to its minimum, which filters out the
+// sawtooth of normal allocation and batching: a healthy process returns to
+// the same floor, a leaking one raises it in every window.
+func Detect(samples []Sample, thresholds Thresholds) []Finding {
+	thresholds = thresholds.withDefaults()
+	if len(samples) < thresholds.Windows {
+		return nil
+	}
+
+	var findings []Finding
+	heap := windowMinima(samples, thresholds.Windows, func(s Sample) uint64 { return s.HeapBytes })
+	if rising(heap) && heap[len(heap)-1]-heap[0] >= thresholds.MinHeapGrowth {
+		findings = append(findings, Finding{Metric: "heap bytes", First: heap[0], Last: heap[len(heap)-1]})
+	}
+	goroutines := windowMinima(samples, thresholds.Windows, func(s Sample) uint64 { return uint64(s.Goroutines) })
+	if rising(goroutines) && goroutines[len(goroutines)-1]-goroutines[0] >= uint64(thresholds.MinGoroutineGrowth) {
+		findings = append(findings, Finding{Metric: "goroutines", First: goroutines[0], Last: goroutines[len(goroutines)-1]})
+	}
+	return findings
+}
+
+func windowMinima(samples []Sample, windows int, value func(Sample) uint64) []uint64 {
+	minima := make([]uint64, windows)
+	for w := range windows {
+		start := w * len(samples) / windows
+		end := (w + 1) * len(samples) / windows
+		minima[w] = value(samples[start])
+		for _, sample := range samples[start+1 : end] {
+			minima[w] = min(minima[w], value(sample))
+		}
+	}
+	return minima
+}
+
+func rising(values []uint64) bool {
+	for i := 1; i < len(values); i++ {
+		if values[i] <= values[i-1] {
+			return false
+		}
+	}
+	return true
+}
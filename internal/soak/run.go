@@ -0,0 +1,132 @@
+package soak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultRate           = 100
+	DefaultSampleInterval = 30 * time.Second
+	DefaultWarmup         = 5 * time.Minute
+)
+
+type Options struct {
+	// Duration is how long to ingest after warmup.
+	Duration time.Duration
+	// Rate is events per second.
+	Rate           int
+	SampleInterval time.Duration
+	// Warmup runs before sampling starts, so buffers and caches reach their
+	// steady size and are not mistaken for leaks.
+	Warmup     time.Duration
+	Thresholds Thresholds
+}
+
+func (o Options) withDefaults() Options {
+	if o.Rate <= 0 {
+		o.Rate = DefaultRate
+	}
+	if o.SampleInterval <= 0 {
+		o.SampleInterval = DefaultSampleInterval
+	}
+	if o.Warmup < 0 {
+		o.Warmup = 0
+	}
+	return o
+}
+
+type Report struct {
+	Sent     uint64    `json:"sent"`
+	Samples  []Sample  `json:"samples"`
+	Findings []Finding `json:"findings"`
+}
+
+// Run sends synthetic events to the collector socket at the configured rate
+// and samples this process. It is meant to run in the same process as the
+// pipeline under test. Cancelling ctx ends the run early; the samples taken
+// so far are still checked.
+func Run(ctx context.Context, socketPath string, options Options, observe func(Sample)) (Report, error) {
+	options = options.withDefaults()
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return Report{}, err
+	}
+	defer conn.Close()
+
+	var report Report
+	start := time.Now()
+	warmupEnd := start.Add(options.Warmup)
+	deadline := warmupEnd.Add(options.Duration)
+
+	// Send in 10ms slices; carry the remainder so low rates stay accurate.
+	const tick = 10 * time.Millisecond
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	nextSample := warmupEnd
+	var owed float64
+
+	for {
+		select {
+		case <-ctx.Done():
+			report.Findings = Detect(report.Samples, options.Thresholds)
+			return report, nil
+		case now := <-ticker.C:
+			owed += float64(options.Rate) * tick.Seconds()
+			var lines strings.Builder
+			for ; owed >= 1; owed-- {
+				report.Sent++
+				lines.WriteString(syntheticLine(report.Sent, now))
+				lines.WriteByte('\n')
+			}
+			if lines.Len() > 0 {
+				if _, err := conn.Write([]byte(lines.String())); err != nil {
+					return report, fmt.Errorf("send events: %w", err)
+				}
+			}
+
+			if !now.Before(nextSample) {
+				sample := TakeSample(now.Sub(start), report.Sent)
+				report.Samples = append(report.Samples, sample)
+				if observe != nil {
+					observe(sample)
+				}
+				nextSample = now.Add(options.SampleInterval)
+			}
+			if !now.Before(deadline) {
+				report.Findings = Detect(report.Samples, options.Thresholds)
+				return report, nil
+			}
+		}
+	}
+}
+
+var syntheticSources = []struct {
+	sourceType string
+	sapi       string
+	context    string
+}{
+	{"http", "fpm-fcgi", `"http":{"method":"GET","scheme":"https","host":"soak.test","path":"/orders"}`},
+	{"cli", "cli", `"command":{"name":"artisan","args":["queue:work"]}`},
+	{"log", "cli", `"log":{"channel":"app","message":"soak"},"level":"info"`},
+}
+
+// syntheticLine builds a valid event. Request IDs, labels, and payload sizes
+// vary so interning, indexing, and per-request grouping all see churn.
+func syntheticLine(seq uint64, now time.Time) string {
+	source := syntheticSources[seq%uint64(len(syntheticSources))]
+	payload, _ := json.Marshal(map[string]any{
+		"seq":   seq,
+		"items": make([]int, seq%64),
+		"note":  strings.Repeat("x", int(seq%512)),
+	})
+	return fmt.Sprintf(
+		`{"schemaVersion":2,"id":"soak-%d","timestamp":%q,"sourceType":%q,"projectRoot":"/srv/soak%d","phpSapi":%q,"requestId":"req-%d",%s,"isDd":false,"label":"batch-%d","payloadFormat":"json","payload":%s,"trace":[{"file":"/srv/soak/app/Job.php","line":%d}],"host":{"hostname":"soak","pid":1}}`,
+		seq, now.UTC().Format(time.RFC3339Nano), source.sourceType, seq%4, source.sapi, seq/16, source.context, seq%32, payload, 1+seq%200,
+	)
+}
@@ -0,0 +1,86 @@
+package soak
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"phant/internal/collector"
+	"phant/internal/dump"
+)
+
+func samples(heap ...uint64) []Sample {
+	result := make([]Sample, len(heap))
+	for i, bytes := range heap {
+		result[i] = Sample{HeapBytes: bytes, Goroutines: 20}
+	}
+	return result
+}
+
+func TestDetect_FlagsRisingFloorsOnly(t *testing.T) {
+	thresholds := Thresholds{Windows: 3, MinHeapGrowth: 100}
+
+	// The sawtooth returns to the same floor in every window.
+	if findings := Detect(samples(100, 900, 100, 800, 100, 950), thresholds); len(findings) != 0 {
+		t.Fatalf("Detect(sawtooth) = %v, want none", findings)
+	}
+	if findings := Detect(samples(100, 900, 300, 800, 500, 950), thresholds); len(findings) != 1 || findings[0].Metric != "heap bytes" {
+		t.Fatalf("Detect(rising floor) = %v, want heap finding", findings)
+	}
+	if findings := Detect(samples(100, 110, 120, 130, 140, 150), thresholds); len(findings) != 0 {
+		t.Fatalf("Detect(small growth) = %v, want none below threshold", findings)
+	}
+}
+
+func TestDetect_FlagsGoroutineGrowth(t *testing.T) {
+	leaking := samples(0, 0, 0, 0)
+	for i := range leaking {
+		leaking[i].Goroutines = 10 + 10*i
+	}
+	findings := Detect(leaking, Thresholds{Windows: 4, MinGoroutineGrowth: 5})
+	if len(findings) != 1 || findings[0].Metric != "goroutines" || findings[0].First != 10 || findings[0].Last != 40 {
+		t.Fatalf("Detect() = %v, want goroutines 10 → 40", findings)
+	}
+}
+
+func TestSyntheticLine_IsValid(t *testing.T) {
+	for seq := uint64(1); seq <= 6; seq++ {
+		if _, err := dump.DecodeNDJSONLine(syntheticLine(seq, time.Now())); err != nil {
+			t.Fatalf("DecodeNDJSONLine(syntheticLine(%d)) error = %v", seq, err)
+		}
+	}
+}
+
+func TestRun_SendsAtRateAndSamples(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "collector.sock")
+	server := collector.NewServer(socketPath, 1000)
+	if err := server.Start(); err != nil {
+		t.Fatalf("server.Start() error = %v", err)
+	}
+	defer server.Stop()
+
+	observed := 0
+	report, err := Run(context.Background(), socketPath, Options{
+		Duration:       300 * time.Millisecond,
+		Rate:           1000,
+		SampleInterval: 50 * time.Millisecond,
+	}, func(Sample) { observed++ })
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.Sent < 100 {
+		t.Fatalf("Run() sent = %d, want about 300", report.Sent)
+	}
+	if len(report.Samples) < 3 || observed != len(report.Samples) {
+		t.Fatalf("Run() samples = %d, observed = %d, want several, all observed", len(report.Samples), observed)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for uint64(len(server.Events())) < min(report.Sent, 1000) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := len(server.Events()); uint64(got) < min(report.Sent, 1000) {
+		t.Fatalf("collector events = %d, want %d", got, min(report.Sent, 1000))
+	}
+}
@@ -0,0 +1,134 @@
+// Package source reads the code around trace frame locations so the UI can
+// show a preview next to each dump.
+package source
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCacheSize    = 64
+	DefaultContextLines = 5
+	MaxContextLines     = 50
+	// MaxFileBytes skips generated bundles and fixtures; PHP sources this
+	// large are not worth previewing.
+	MaxFileBytes = 2 * 1024 * 1024
+)
+
+var (
+	ErrFileTooLarge = errors.New("source file is too large to preview")
+	ErrBinaryFile   = errors.New("source file is not text")
+	ErrLineNotFound = errors.New("line is outside the source file")
+)
+
+type Line struct {
+	Number  int    `json:"number"`
+	Text    string `json:"text"`
+	Current bool   `json:"current"`
+}
+
+// Context is a window of lines around Line in File.
+type Context struct {
+	File  string `json:"file"`
+	Line  int    `json:"line"`
+	Lines []Line `json:"lines"`
+}
+
+type cachedFile struct {
+	modTime time.Time
+	size    int64
+	lines   []string
+}
+
+// Cache keeps recently read files split into lines. Entries are checked
+// against the file's mtime and size on every lookup, so edits show up
+// without invalidation.
+type Cache struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]cachedFile
+}
+
+func NewCache(capacity int) *Cache {
+	if capacity <= 0 {
+		capacity = DefaultCacheSize
+	}
+
+	return &Cache{
+		capacity: capacity,
+		entries:  make(map[string]cachedFile),
+	}
+}
+
+// Context returns up to contextLines lines on each side of line, with line
+// marked as current. contextLines is clamped to MaxContextLines; zero or
+// less uses DefaultContextLines.
+func (c *Cache) Context(path string, line int, contextLines int) (Context, error) {
+	if contextLines <= 0 {
+		contextLines = DefaultContextLines
+	}
+	contextLines = min(contextLines, MaxContextLines)
+
+	lines, err := c.lines(path)
+	if err != nil {
+		return Context{}, err
+	}
+	if line < 1 || line > len(lines) {
+		return Context{}, fmt.Errorf("%w: %s has %d lines, want line %d", ErrLineNotFound, path, len(lines), line)
+	}
+
+	first := max(line-contextLines, 1)
+	last := min(line+contextLines, len(lines))
+	context := Context{File: path, Line: line, Lines: make([]Line, 0, last-first+1)}
+	for number := first; number <= last; number++ {
+		context.Lines = append(context.Lines, Line{Number: number, Text: lines[number-1], Current: number == line})
+	}
+	return context, nil
+}
+
+func (c *Cache) lines(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	cached, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.lines, nil
+	}
+
+	if info.Size() > MaxFileBytes {
+		return nil, fmt.Errorf("%w: %s is %d bytes", ErrFileTooLarge, path, info.Size())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrBinaryFile, path)
+	}
+
+	text := strings.TrimSuffix(string(data), "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	c.mu.Lock()
+	if len(c.entries) >= c.capacity {
+		c.entries = make(map[string]cachedFile)
+	}
+	c.entries[path] = cachedFile{modTime: info.ModTime(), size: info.Size(), lines: lines}
+	c.mu.Unlock()
+
+	return lines, nil
+}
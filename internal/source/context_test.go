@@ -0,0 +1,74 @@
+package source
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSource(t *testing.T, path string, content string, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("os.Chtimes() error = %v", err)
+	}
+}
+
+func TestCache_ContextMarksLineAndClampsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Cart.php")
+	writeSource(t, path, "<?php\r\n\nclass Cart\n{\n    dump($items);\n}\n", time.Now())
+
+	context, err := NewCache(0).Context(path, 5, 2)
+	if err != nil {
+		t.Fatalf("Context() error = %v", err)
+	}
+
+	want := []Line{
+		{Number: 3, Text: "class Cart"},
+		{Number: 4, Text: "{"},
+		{Number: 5, Text: "    dump($items);", Current: true},
+		{Number: 6, Text: "}"},
+	}
+	if len(context.Lines) != len(want) {
+		t.Fatalf("Context() lines = %+v, want %+v", context.Lines, want)
+	}
+	for i := range want {
+		if context.Lines[i] != want[i] {
+			t.Fatalf("Context() line %d = %+v, want %+v", i, context.Lines[i], want[i])
+		}
+	}
+
+	if _, err := NewCache(0).Context(path, 7, 2); !errors.Is(err, ErrLineNotFound) {
+		t.Fatalf("Context(line 7) error = %v, want ErrLineNotFound", err)
+	}
+}
+
+func TestCache_RereadsChangedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.php")
+	modTime := time.Now().Add(-time.Hour)
+	writeSource(t, path, "one\n", modTime)
+
+	cache := NewCache(0)
+	if context, _ := cache.Context(path, 1, 1); context.Lines[0].Text != "one" {
+		t.Fatalf("Context() = %+v, want one", context.Lines)
+	}
+
+	writeSource(t, path, "two\n", modTime.Add(time.Second))
+	if context, _ := cache.Context(path, 1, 1); context.Lines[0].Text != "two" {
+		t.Fatalf("Context() after edit = %+v, want two", context.Lines)
+	}
+}
+
+func TestCache_RejectsBinaryFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blob")
+	writeSource(t, path, "a\x00b\n", time.Now())
+
+	if _, err := NewCache(0).Context(path, 1, 1); !errors.Is(err, ErrBinaryFile) {
+		t.Fatalf("Context(binary) error = %v, want ErrBinaryFile", err)
+	}
+}
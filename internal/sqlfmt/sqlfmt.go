@@ -0,0 +1,369 @@
+// Package sqlfmt lays out SQL queries for reading and fills in their
+// bindings, so a query reported by a framework's query listener reads as
+// the statement that ran.
+package sqlfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrBindingCount reports bindings that do not match the placeholders of a
+// query. Interpolate still fills in what it can.
+var ErrBindingCount = errors.New("bindings do not match the query's placeholders")
+
+// Interpolate replaces the placeholders of query with its bindings as SQL
+// literals: ? placeholders take a JSON array in order, and :name ones take
+// a JSON object by name. Placeholders inside strings and comments are left
+// alone, as are PostgreSQL :: casts and PDO's escaped ??.
+func Interpolate(query string, bindings json.RawMessage) (string, error) {
+	var positional []json.RawMessage
+	var named map[string]json.RawMessage
+	switch trimmed := bytes.TrimSpace(bindings); {
+	case len(trimmed) == 0 || string(trimmed) == "null":
+	case trimmed[0] == '[':
+		if err := json.Unmarshal(trimmed, &positional); err != nil {
+			return query, err
+		}
+	default:
+		if err := json.Unmarshal(trimmed, &named); err != nil {
+			return query, err
+		}
+	}
+
+	var out strings.Builder
+	used, missing := 0, false
+	for _, token := range tokenize(query) {
+		switch {
+		case token.kind == kindPlaceholder && token.text == "?":
+			if used < len(positional) {
+				out.WriteString(literal(positional[used]))
+			} else {
+				out.WriteString(token.text)
+				missing = true
+			}
+			used++
+		case token.kind == kindPlaceholder && token.text[0] == ':':
+			name := token.text[1:]
+			value, ok := named[name]
+			if !ok {
+				value, ok = named[token.text]
+			}
+			if ok {
+				out.WriteString(literal(value))
+			} else {
+				out.WriteString(token.text)
+				missing = true
+			}
+		default:
+			out.WriteString(token.text)
+		}
+	}
+
+	if missing || used < len(positional) {
+		return out.String(), ErrBindingCount
+	}
+	return out.String(), nil
+}
+
+// literal renders a JSON binding as a SQL literal. Booleans become 1 and 0,
+// as PDO sends them; arrays and objects are quoted as JSON text.
+func literal(value json.RawMessage) string {
+	value = bytes.TrimSpace(value)
+	switch {
+	case len(value) == 0 || string(value) == "null":
+		return "NULL"
+	case string(value) == "true":
+		return "1"
+	case string(value) == "false":
+		return "0"
+	case value[0] == '"':
+		var text string
+		if err := json.Unmarshal(value, &text); err == nil {
+			return quote(text)
+		}
+	case value[0] == '[' || value[0] == '{':
+		return quote(string(value))
+	}
+	return string(value)
+}
+
+func quote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
+// clauses start a line of their own.
+var clauses = words(`select from where group order having limit offset union intersect except insert values update set
+	delete returning with join window`)
+
+// joinWords start a join; the first of them starts the line.
+var joinWords = words(`left right inner outer cross full natural`)
+
+// functions are keywords written directly before their parenthesis.
+var functions = words(`count sum avg min max coalesce`)
+
+var keywords = words(`select from where and or not in is null like ilike between join inner left right outer full cross on
+	as group by order having limit offset union all distinct insert into values update set delete create table index
+	view alter add column drop primary key foreign references default unique case when then else end exists asc desc
+	with returning count sum avg min max coalesce true false cascade constraint if begin commit rollback transaction
+	for share nowait skip locked lateral using intersect except natural window over partition conflict do nothing`)
+
+func words(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(text) {
+		set[word] = true
+	}
+	return set
+}
+
+const indentUnit = "  "
+
+// Format lays query out with each clause on a line of its own, AND and OR
+// conditions indented below their clause, and subqueries indented inside
+// their parentheses. Keywords are uppercased; strings, quoted identifiers,
+// and comments are kept as written.
+func Format(query string) string {
+	tokens := tokenize(query)
+	var out strings.Builder
+	depth := 0
+	// subqueries holds, for each open parenthesis, whether it opened a
+	// subquery; clauses only break lines outside other parentheses.
+	var subqueries []bool
+	between := false
+	// unary is set after a sign that belongs to the number following it.
+	unary := false
+	prev := token{}
+	// before is the lowercased word preceding prev.
+	before := ""
+
+	atClauseLevel := func() bool {
+		return len(subqueries) == 0 || subqueries[len(subqueries)-1]
+	}
+	newline := func(level int) {
+		if out.Len() == 0 {
+			return
+		}
+		out.WriteString("\n")
+		out.WriteString(strings.Repeat(indentUnit, level))
+	}
+
+	for i, current := range tokens {
+		if current.kind == kindSpace {
+			continue
+		}
+		next := nextToken(tokens, i)
+		lower := strings.ToLower(current.text)
+		text := current.text
+		if current.kind == kindWord && keywords[lower] {
+			text = strings.ToUpper(text)
+		}
+		prevLower := strings.ToLower(prev.text)
+		// LEFT and RIGHT are functions as well as joins.
+		call := current.kind == kindWord && next.text == "(" && joinWords[lower]
+		// Parentheses hug a function name, but not the table of INSERT
+		// INTO or CREATE TABLE.
+		glue := current.text == "(" && prev.kind == kindWord && (!keywords[prevLower] || functions[prevLower]) &&
+			before != "into" && before != "table"
+
+		switch {
+		case current.kind == kindWord && atClauseLevel() && !call && clauseStarts(lower, prevLower):
+			newline(depth)
+		case current.kind == kindWord && (lower == "and" || lower == "or") && atClauseLevel():
+			if lower == "and" && between {
+				between = false
+				writeSpaced(&out, prev, text, false)
+				text = ""
+				break
+			}
+			newline(depth + 1)
+		case current.text == ")" && len(subqueries) > 0 && subqueries[len(subqueries)-1]:
+			depth--
+			newline(depth)
+		case prev.kind == kindComment && (strings.HasPrefix(prev.text, "--") || strings.HasPrefix(prev.text, "#")):
+			newline(depth)
+		case unary:
+		default:
+			writeSpaced(&out, prev, text, glue)
+			text = ""
+		}
+		out.WriteString(text)
+
+		switch {
+		case current.text == "(":
+			subquery := strings.EqualFold(next.text, "select") || strings.EqualFold(next.text, "with")
+			subqueries = append(subqueries, subquery)
+			if subquery {
+				depth++
+			}
+		case current.text == ")" && len(subqueries) > 0:
+			subqueries = subqueries[:len(subqueries)-1]
+		case lower == "between":
+			between = true
+		}
+		unary = (current.text == "-" || current.text == "+") && (prev.text == "" || prev.text == "(" || prev.text == "," ||
+			prev.kind == kindPunct && prev.text != ")" || prev.kind == kindWord && keywords[prevLower])
+		before = prevLower
+		prev = current
+	}
+	return out.String()
+}
+
+// clauseStarts reports whether word starts a clause line after the word
+// before it, keeping multi-word clauses such as LEFT OUTER JOIN, DELETE
+// FROM, GROUP BY, and UNION ALL together.
+func clauseStarts(word string, before string) bool {
+	switch {
+	case joinWords[word]:
+		return !joinWords[before]
+	case word == "join":
+		return !joinWords[before]
+	case word == "from":
+		return before != "delete"
+	case word == "update":
+		return before != "do"
+	}
+	return clauses[word]
+}
+
+// writeSpaced writes the space text needs after prev, if any; glue writes
+// an opening parenthesis directly after a function name.
+func writeSpaced(out *strings.Builder, prev token, text string, glue bool) {
+	if out.Len() == 0 || strings.HasSuffix(out.String(), " ") || strings.HasSuffix(out.String(), "\n") {
+		out.WriteString(text)
+		return
+	}
+	switch {
+	case text == "," || text == ")" || text == ";" || text == "." || text == "::":
+	case prev.text == "(" || prev.text == "." || prev.text == "::":
+	case glue:
+	default:
+		out.WriteString(" ")
+	}
+	out.WriteString(text)
+}
+
+func nextToken(tokens []token, i int) token {
+	for _, next := range tokens[i+1:] {
+		if next.kind != kindSpace {
+			return next
+		}
+	}
+	return token{}
+}
+
+type kind int
+
+const (
+	kindSpace kind = iota
+	kindWord
+	kindString
+	kindComment
+	kindPlaceholder
+	kindPunct
+)
+
+type token struct {
+	text string
+	kind kind
+}
+
+// tokenize splits query into tokens that join back into it exactly.
+func tokenize(query string) []token {
+	var tokens []token
+	for i := 0; i < len(query); {
+		c := query[i]
+		rest := query[i:]
+		end := i + 1
+		k := kindPunct
+
+		switch {
+		case isSpace(c):
+			for end < len(query) && isSpace(query[end]) {
+				end++
+			}
+			k = kindSpace
+		case strings.HasPrefix(rest, "--") || c == '#':
+			end = i + len(rest)
+			if newline := strings.IndexByte(rest, '\n'); newline >= 0 {
+				end = i + newline
+			}
+			k = kindComment
+		case strings.HasPrefix(rest, "/*"):
+			end = len(query)
+			if close := strings.Index(rest[2:], "*/"); close >= 0 {
+				end = i + 2 + close + 2
+			}
+			k = kindComment
+		case c == '\'' || c == '"' || c == '`':
+			end = quoted(query, i)
+			k = kindString
+		case c == '[':
+			// SQL Server quotes identifiers in brackets.
+			end = len(query)
+			if close := strings.IndexByte(rest, ']'); close >= 0 {
+				end = i + close + 1
+			}
+			k = kindString
+		case strings.HasPrefix(rest, "??"):
+			end = i + 2
+		case c == '?':
+			k = kindPlaceholder
+		case strings.HasPrefix(rest, "::"):
+			end = i + 2
+		case c == ':' && end < len(query) && isWordStart(query[end]):
+			for end < len(query) && isWord(query[end]) {
+				end++
+			}
+			k = kindPlaceholder
+		case isWordStart(c) || isDigit(c) || c == '$' || c == '@':
+			for end < len(query) && (isWord(query[end]) || query[end] == '.' && isDigit(c)) {
+				end++
+			}
+			k = kindWord
+		case strings.IndexByte("<>=!|&+-*/%~^", c) >= 0:
+			for end < len(query) && strings.IndexByte("<>=!|&+-*/%~^", query[end]) >= 0 && !strings.HasPrefix(query[end:], "--") {
+				end++
+			}
+		}
+		tokens = append(tokens, token{text: query[i:end], kind: k})
+		i = end
+	}
+	return tokens
+}
+
+// quoted returns the end of the string or quoted identifier starting at
+// start. Quotes are escaped by doubling them or with a backslash.
+func quoted(text string, start int) int {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && quote != '`':
+			i++
+		case text[i] == quote:
+			if i+1 < len(text) && text[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isWord(c byte) bool {
+	return isWordStart(c) || isDigit(c)
+}
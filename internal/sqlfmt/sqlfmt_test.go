@@ -0,0 +1,79 @@
+package sqlfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestInterpolate_FillsPositionalAndNamedBindings(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		bindings string
+		want     string
+	}{
+		{
+			query:    "select * from users where email = ? and active = ? and deleted_at is ? -- ?",
+			bindings: `["o'brien@example.com", true, null]`,
+			want:     "select * from users where email = 'o''brien@example.com' and active = 1 and deleted_at is NULL -- ?",
+		},
+		{
+			query:    "update posts set meta = :meta where id = :id and '?' <> :id",
+			bindings: `{"id": 7, ":meta": {"a": 1}}`,
+			want:     `update posts set meta = '{"a": 1}' where id = 7 and '?' <> 7`,
+		},
+		{
+			query:    "select payload::jsonb ?? 'key' from jobs where id = ?",
+			bindings: `[3]`,
+			want:     "select payload::jsonb ?? 'key' from jobs where id = 3",
+		},
+	} {
+		got, err := Interpolate(tc.query, json.RawMessage(tc.bindings))
+		if err != nil || got != tc.want {
+			t.Fatalf("Interpolate(%q) = %q, %v, want %q", tc.query, got, err, tc.want)
+		}
+	}
+}
+
+func TestInterpolate_ReportsMismatchedBindings(t *testing.T) {
+	got, err := Interpolate("select * from users where id = ? and team_id = ?", json.RawMessage(`[1]`))
+	if !errors.Is(err, ErrBindingCount) || got != "select * from users where id = 1 and team_id = ?" {
+		t.Fatalf("Interpolate(missing) = %q, %v, want partial result and ErrBindingCount", got, err)
+	}
+	if _, err := Interpolate("select 1", json.RawMessage(`[1]`)); !errors.Is(err, ErrBindingCount) {
+		t.Fatalf("Interpolate(extra) error = %v, want ErrBindingCount", err)
+	}
+}
+
+func TestFormat_BreaksClausesAndIndentsSubqueries(t *testing.T) {
+	query := "select u.id, count(*) as posts from users u left outer join posts p on p.user_id = u.id " +
+		"where u.active = 1 and u.id in (select user_id from bans where until between ? and ?) or u.score > -1 " +
+		"group by u.id order by posts desc limit 10"
+
+	want := "SELECT u.id, COUNT(*) AS posts\n" +
+		"FROM users u\n" +
+		"LEFT OUTER JOIN posts p ON p.user_id = u.id\n" +
+		"WHERE u.active = 1\n" +
+		"  AND u.id IN (\n" +
+		"  SELECT user_id\n" +
+		"  FROM bans\n" +
+		"  WHERE until BETWEEN ? AND ?\n" +
+		")\n" +
+		"  OR u.score > -1\n" +
+		"GROUP BY u.id\n" +
+		"ORDER BY posts DESC\n" +
+		"LIMIT 10"
+	if got := Format(query); got != want {
+		t.Fatalf("Format() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormat_KeepsStringsCommentsAndCasts(t *testing.T) {
+	query := "insert into logs (message, at) values ('select from where', now()::date) -- audit\nreturning id"
+	want := "INSERT INTO logs (message, at)\n" +
+		"VALUES ('select from where', now()::date) -- audit\n" +
+		"RETURNING id"
+	if got := Format(query); got != want {
+		t.Fatalf("Format() =\n%s\nwant\n%s", got, want)
+	}
+}
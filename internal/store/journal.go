@@ -0,0 +1,264 @@
+package store
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	// JournalFile is the write-ahead journal kept next to the session logs.
+	JournalFile = "ingest.journal"
+
+	// JournalGrace is how long a journaled event may go without being
+	// committed before a commit resolves it anyway. The session log receives
+	// every published event, so by then it was never published: it was
+	// folded into an earlier event as a repeat, or discarded by the ingest
+	// overflow policy the user chose.
+	JournalGrace = time.Minute
+
+	// journalCompactBytes is the size past which a commit rewrites the
+	// journal with only its outstanding events.
+	journalCompactBytes = 16 << 20
+)
+
+// journalLine is one line of the journal: an ingested event, or the IDs of
+// events that no longer need it.
+type journalLine struct {
+	Event *dump.Event `json:"event,omitempty"`
+	Done  []string    `json:"done,omitempty"`
+}
+
+type journalEntry struct {
+	seq  uint64
+	line []byte
+	at   time.Time
+}
+
+// Journal is a write-ahead log of ingested events, so events still queued
+// in the pipeline when phant crashes are not lost with it. Each event is
+// written before it is queued, without an fsync: it survives the process
+// dying, not the machine. Events leave the journal once the store commits
+// them, and the file is emptied whenever nothing is outstanding.
+type Journal struct {
+	path string
+	now  func() time.Time
+
+	mu          sync.Mutex
+	file        *os.File
+	size        int64
+	seq         uint64
+	outstanding map[string]journalEntry
+	closed      bool
+}
+
+// OpenJournal opens the journal in dir and returns the events it holds
+// that were never committed, in the order they were ingested. A torn last
+// line, left by the crash, is skipped. The recovered events stay
+// outstanding until they are committed again.
+func OpenJournal(dir string) (*Journal, []dump.Event, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, err
+	}
+	j := &Journal{
+		path:        filepath.Join(dir, JournalFile),
+		now:         time.Now,
+		outstanding: map[string]journalEntry{},
+	}
+
+	recovered, err := j.recover()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := j.rewrite(); err != nil {
+		return nil, nil, err
+	}
+	return j, recovered, nil
+}
+
+func (j *Journal) recover() ([]dump.Event, error) {
+	file, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []dump.Event{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events := map[string]dump.Event{}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var entry journalLine
+		if json.Unmarshal(line, &entry) != nil {
+			continue
+		}
+		if entry.Event != nil {
+			j.seq++
+			j.outstanding[entry.Event.ID] = journalEntry{seq: j.seq, line: line, at: j.now()}
+			events[entry.Event.ID] = *entry.Event
+		}
+		for _, id := range entry.Done {
+			delete(j.outstanding, id)
+		}
+	}
+
+	recovered := make([]dump.Event, 0, len(j.outstanding))
+	for _, id := range j.pendingIDs() {
+		recovered = append(recovered, events[id])
+	}
+	return recovered, nil
+}
+
+// Append writes event to the journal before it enters the pipeline.
+func (j *Journal) Append(event dump.Event) error {
+	line, err := json.Marshal(journalLine{Event: &event})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrClosed
+	}
+	if _, err := j.file.Write(line); err != nil {
+		return err
+	}
+	j.size += int64(len(line))
+	j.seq++
+	j.outstanding[event.ID] = journalEntry{seq: j.seq, line: line, at: j.now()}
+	return nil
+}
+
+// Done marks committed events as persisted, along with every event past
+// JournalGrace, which was never published. It is meant for
+// Options.OnCommit, on a store that receives every published event.
+func (j *Journal) Done(committed []dump.Event) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrClosed
+	}
+	var done []string
+	for _, event := range committed {
+		if _, ok := j.outstanding[event.ID]; ok {
+			delete(j.outstanding, event.ID)
+			done = append(done, event.ID)
+		}
+	}
+	expired := j.now().Add(-JournalGrace)
+	for id, entry := range j.outstanding {
+		if entry.at.Before(expired) {
+			delete(j.outstanding, id)
+			done = append(done, id)
+		}
+	}
+
+	switch {
+	case len(j.outstanding) == 0:
+		if j.size == 0 {
+			return nil
+		}
+		if err := j.file.Truncate(0); err != nil {
+			return err
+		}
+		j.size = 0
+		return nil
+	case j.size > journalCompactBytes:
+		return j.rewrite()
+	case len(done) > 0:
+		line, err := json.Marshal(journalLine{Done: done})
+		if err != nil {
+			return err
+		}
+		n, err := j.file.Write(append(line, '\n'))
+		j.size += int64(n)
+		return err
+	}
+	return nil
+}
+
+// Outstanding counts the journaled events not yet committed.
+func (j *Journal) Outstanding() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.outstanding)
+}
+
+// Close closes the file; whatever is still outstanding is recovered by the
+// next OpenJournal.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return nil
+	}
+	j.closed = true
+	return j.file.Close()
+}
+
+// rewrite replaces the journal with its outstanding events and reopens it
+// for appending.
+func (j *Journal) rewrite() error {
+	err := writeAtomic(j.path, func(w io.Writer) error {
+		for _, id := range j.pendingIDs() {
+			if _, err := w.Write(j.outstanding[id].line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file, j.size = file, info.Size()
+	return nil
+}
+
+// pendingIDs lists the outstanding events in the order they were
+// journaled.
+func (j *Journal) pendingIDs() []string {
+	ids := make([]string, 0, len(j.outstanding))
+	for id := range j.outstanding {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		return cmp.Compare(j.outstanding[a].seq, j.outstanding[b].seq)
+	})
+	return ids
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func TestJournal_RecoversEventsThatWereNeverCommitted(t *testing.T) {
+	dir := t.TempDir()
+	j, recovered, err := OpenJournal(dir)
+	if err != nil || len(recovered) != 0 {
+		t.Fatalf("OpenJournal() = %v, %v, want an empty journal", recovered, err)
+	}
+	for id := 1; id <= 3; id++ {
+		if err := j.Append(storeEvent(id)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if err := j.Done([]dump.Event{storeEvent(2)}); err != nil {
+		t.Fatalf("Done() error = %v", err)
+	}
+	// A crash mid-write leaves a torn last line.
+	file, _ := os.OpenFile(filepath.Join(dir, JournalFile), os.O_APPEND|os.O_WRONLY, 0)
+	file.WriteString(`{"event":{"id":"evt-4"`)
+	file.Close()
+	j.Close()
+
+	j, recovered, err = OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	defer j.Close()
+	if len(recovered) != 2 || recovered[0].ID != "evt-1" || recovered[1].ID != "evt-3" {
+		t.Fatalf("recovered = %v, want evt-1 and evt-3", recovered)
+	}
+
+	if err := j.Done(recovered); err != nil {
+		t.Fatalf("Done() error = %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, JournalFile)); err != nil || info.Size() != 0 {
+		t.Fatalf("journal after every event was committed = %v, %v, want empty", info, err)
+	}
+}
+
+func TestJournal_ResolvesEventsPastTheGrace(t *testing.T) {
+	j, _, err := OpenJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	defer j.Close()
+
+	now := time.Now()
+	j.now = func() time.Time { return now }
+	j.Append(storeEvent(1))
+	j.Append(storeEvent(2))
+
+	// evt-1 was folded into an earlier event and is never committed.
+	now = now.Add(JournalGrace + time.Second)
+	j.Append(storeEvent(3))
+	j.Done([]dump.Event{storeEvent(2)})
+	if got := j.Outstanding(); got != 1 {
+		t.Fatalf("Outstanding() = %d, want only evt-3", got)
+	}
+}
+
+func TestJournal_RecoveredOffloadedEventsKeepTheirPayload(t *testing.T) {
+	dir := t.TempDir()
+	payloadDir := filepath.Join(dir, "payloads")
+	j, _, err := OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+
+	event := storeEvent(1)
+	event.Payload = []byte(`{"rows":"` + strings.Repeat("x", 4096) + `"}`)
+	original, offloaded := event.OffloadPayload(1024, 128)
+	if !offloaded {
+		t.Fatal("OffloadPayload() did not offload")
+	}
+	ref, err := NewPayloads(payloadDir).Put(original)
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	event.PayloadRef = ref
+	if err := j.Append(event); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	// The process dies before the event is committed.
+	j.Close()
+
+	j, recovered, err := OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	defer j.Close()
+	if len(recovered) != 1 || !recovered[0].Offloaded || recovered[0].PayloadRef != ref {
+		t.Fatalf("recovered = %+v, want the offloaded event with its payload ref", recovered)
+	}
+	payload, err := NewPayloads(payloadDir).Get(recovered[0].PayloadRef)
+	if err != nil || string(payload) != string(original) {
+		t.Fatalf("Get(recovered ref) = %d bytes, %v, want the full payload", len(payload), err)
+	}
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PayloadExt is the extension of the gzip-compressed payload files.
+const PayloadExt = ".json.gz"
+
+// BinaryExt is the extension of binary payload files, which are stored as
+// sent: images and PDFs are compressed already.
+const BinaryExt = ".bin"
+
+var ErrPayloadNotStored = errors.New("full payload is not stored")
+
+// Payloads keeps event payloads out of memory, one gzip-compressed file per
+// distinct payload, named after a hash of its content. The name is the
+// reference events keep, so it stays valid across sessions and events with
+// the same payload share the file.
+type Payloads struct {
+	dir string
+}
+
+func NewPayloads(dir string) *Payloads {
+	return &Payloads{dir: dir}
+}
+
+func (p *Payloads) Dir() string {
+	return p.dir
+}
+
+func (p *Payloads) Path(ref string) string {
+	return filepath.Join(p.dir, ref+PayloadExt)
+}
+
+func (p *Payloads) BinaryPath(ref string) string {
+	return filepath.Join(p.dir, ref+BinaryExt)
+}
+
+// PayloadRef returns the reference a payload with this content is stored
+// under.
+func PayloadRef(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// validRef keeps references read back from session logs or the journal
+// from naming paths outside the payload directory.
+func validRef(ref string) bool {
+	return len(ref) == sha256.Size*2 && strings.Trim(ref, "0123456789abcdef") == ""
+}
+
+// Put compresses payload to its file, unless it is stored already, and
+// returns its reference.
+func (p *Payloads) Put(payload json.RawMessage) (string, error) {
+	ref := PayloadRef(payload)
+	if _, err := os.Stat(p.Path(ref)); err == nil {
+		return ref, nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(payload); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return ref, p.write(p.Path(ref), buf.Bytes())
+}
+
+// PutBinary stores the decoded data of a binary payload and returns its
+// reference.
+func (p *Payloads) PutBinary(data []byte) (string, error) {
+	ref := PayloadRef(data)
+	if _, err := os.Stat(p.BinaryPath(ref)); err == nil {
+		return ref, nil
+	}
+	return ref, p.write(p.BinaryPath(ref), data)
+}
+
+// write puts data next to path first and renames it into place, so readers
+// never see half a payload.
+func (p *Payloads) write(path string, data []byte) error {
+	if err := os.MkdirAll(p.dir, 0o700); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(p.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(temp.Name())
+	}
+	return err
+}
+
+// Get decompresses the payload stored under ref, or returns
+// ErrPayloadNotStored.
+func (p *Payloads) Get(ref string) (json.RawMessage, error) {
+	if !validRef(ref) {
+		return nil, ErrPayloadNotStored
+	}
+	file, err := os.Open(p.Path(ref))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrPayloadNotStored
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// OpenBinary opens the binary payload stored under ref for reading, or
+// returns ErrPayloadNotStored. The caller closes the file.
+func (p *Payloads) OpenBinary(ref string) (*os.File, error) {
+	if !validRef(ref) {
+		return nil, ErrPayloadNotStored
+	}
+	file, err := os.Open(p.BinaryPath(ref))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrPayloadNotStored
+	}
+	return file, err
+}
+
+// Remove deletes the payload stored under ref, which every event with the
+// same payload shares.
+func (p *Payloads) Remove(ref string) error {
+	if !validRef(ref) {
+		return nil
+	}
+	for _, path := range []string{p.Path(ref), p.BinaryPath(ref)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ref returns the reference a stored payload file name stands for.
+func Ref(name string) (string, bool) {
+	for _, ext := range []string{PayloadExt, BinaryExt} {
+		if ref, ok := strings.CutSuffix(name, ext); ok && validRef(ref) {
+			return ref, true
+		}
+	}
+	return "", false
+}
+
+// CopyTo copies every stored payload into dest and returns how many files
+// and bytes it copied. Files dest already holds are skipped: a payload file
+// never changes once written.
+func (p *Payloads) CopyTo(dest string) (int, int64, error) {
+	entries, err := os.ReadDir(p.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := os.MkdirAll(dest, 0o700); err != nil {
+		return 0, 0, err
+	}
+
+	files, copied := 0, int64(0)
+	for _, entry := range entries {
+		if _, ok := Ref(entry.Name()); !ok || entry.IsDir() {
+			continue
+		}
+		target := filepath.Join(dest, entry.Name())
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+		err := writeAtomic(target, func(w io.Writer) error {
+			src, err := os.Open(filepath.Join(p.dir, entry.Name()))
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+			n, err := io.Copy(w, src)
+			copied += n
+			return err
+		})
+		if err != nil {
+			return files, copied, err
+		}
+		files++
+	}
+	return files, copied, nil
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPayloads_StoresCompressedAndReadsBack(t *testing.T) {
+	payloads := NewPayloads(t.TempDir())
+	payload := []byte(`{"rows":[` + strings.Repeat(`{"name":"row"},`, 1000) + `{}]}`)
+
+	ref, err := payloads.Put(payload)
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	info, err := os.Stat(payloads.Path(ref))
+	if err != nil {
+		t.Fatalf("stat payload: %v", err)
+	}
+	if info.Size() >= int64(len(payload))/10 {
+		t.Fatalf("stored %d bytes for a %d byte payload, want it compressed", info.Size(), len(payload))
+	}
+
+	got, err := payloads.Get(ref)
+	if err != nil || string(got) != string(payload) {
+		t.Fatalf("Get() = %d bytes, %v, want the payload back", len(got), err)
+	}
+	if again, err := payloads.Put(payload); err != nil || again != ref {
+		t.Fatalf("Put(same payload) = %q, %v, want %q", again, err, ref)
+	}
+
+	if err := payloads.Remove(ref); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := payloads.Get(ref); !errors.Is(err, ErrPayloadNotStored) {
+		t.Fatalf("Get(removed) error = %v, want %v", err, ErrPayloadNotStored)
+	}
+}
+
+func TestPayloads_StoresBinaryAsSent(t *testing.T) {
+	payloads := NewPayloads(t.TempDir())
+	data := []byte("\x89PNG\r\n\x1a\n\x00\x00")
+
+	ref, err := payloads.PutBinary(data)
+	if err != nil {
+		t.Fatalf("PutBinary() error = %v", err)
+	}
+	file, err := payloads.OpenBinary(ref)
+	if err != nil {
+		t.Fatalf("OpenBinary() error = %v", err)
+	}
+	got, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || string(got) != string(data) {
+		t.Fatalf("OpenBinary() read %q, %v, want %q", got, err, data)
+	}
+
+	if err := payloads.Remove(ref); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := payloads.OpenBinary(ref); !errors.Is(err, ErrPayloadNotStored) {
+		t.Fatalf("OpenBinary(removed) error = %v, want %v", err, ErrPayloadNotStored)
+	}
+}
+
+func TestPayloads_RejectsRefsThatAreNotHashes(t *testing.T) {
+	dir := t.TempDir()
+	payloads := NewPayloads(filepath.Join(dir, "payloads"))
+	os.WriteFile(filepath.Join(dir, "secret"+PayloadExt), []byte("x"), 0o600)
+
+	for _, ref := range []string{"", "../secret", "evt-1"} {
+		if _, err := payloads.Get(ref); !errors.Is(err, ErrPayloadNotStored) {
+			t.Fatalf("Get(%q) error = %v, want %v", ref, err, ErrPayloadNotStored)
+		}
+	}
+}
+
+func TestPayloads_CopiesToBackup(t *testing.T) {
+	payloads := NewPayloads(t.TempDir())
+	ref, _ := payloads.Put([]byte(`{"a":1}`))
+	binary, _ := payloads.PutBinary([]byte("data"))
+
+	dest := t.TempDir()
+	files, _, err := payloads.CopyTo(dest)
+	if err != nil || files != 2 {
+		t.Fatalf("CopyTo() = %d, %v, want both payloads", files, err)
+	}
+	if got, err := NewPayloads(dest).Get(ref); err != nil || string(got) != `{"a":1}` {
+		t.Fatalf("Get(copy) = %s, %v", got, err)
+	}
+	if _, err := os.Stat(NewPayloads(dest).BinaryPath(binary)); err != nil {
+		t.Fatalf("binary copy: %v", err)
+	}
+
+	if files, _, err := payloads.CopyTo(dest); err != nil || files != 0 {
+		t.Fatalf("CopyTo(again) = %d, %v, want nothing copied", files, err)
+	}
+}
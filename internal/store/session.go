@@ -0,0 +1,96 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+var ErrInvalidSession = errors.New("invalid session name")
+
+// Session describes a session log from its directory entry alone; reading
+// the log is left to Summarize.
+type Session struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Bytes      int64  `json:"bytes"`
+	ModifiedAt string `json:"modifiedAt"`
+}
+
+type Summary struct {
+	Events         int    `json:"events"`
+	FirstTimestamp string `json:"firstTimestamp"`
+	LastTimestamp  string `json:"lastTimestamp"`
+}
+
+// ListSessions returns the session logs in dir, oldest first. A missing
+// directory has no sessions.
+func ListSessions(dir string) ([]Session, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Session{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []Session{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != sessionExt {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, Session{
+			Name:       entry.Name(),
+			Path:       filepath.Join(dir, entry.Name()),
+			Bytes:      info.Size(),
+			ModifiedAt: info.ModTime().UTC().Format(time.RFC3339Nano),
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Name < sessions[j].Name
+	})
+	return sessions, nil
+}
+
+// SessionPath resolves a session name from ListSessions inside dir.
+func SessionPath(dir string, name string) (string, error) {
+	if name == "" || filepath.Base(name) != name || strings.HasPrefix(name, ".") || filepath.Ext(name) != sessionExt {
+		return "", ErrInvalidSession
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Summarize counts the events of a session log and finds its time range,
+// comparing parsed timestamps: RFC 3339 strings with different fractional
+// precision do not sort as text. The original strings are kept.
+func Summarize(path string) (Summary, error) {
+	var summary Summary
+	var first, last time.Time
+	err := ReadSession(path, func(event dump.Event) error {
+		summary.Events++
+		timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+		if err != nil {
+			return nil
+		}
+		if first.IsZero() || timestamp.Before(first) {
+			first = timestamp
+			summary.FirstTimestamp = event.Timestamp
+		}
+		if last.IsZero() || timestamp.After(last) {
+			last = timestamp
+			summary.LastTimestamp = event.Timestamp
+		}
+		return nil
+	})
+	return summary, err
+}
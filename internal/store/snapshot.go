@@ -0,0 +1,112 @@
+package store
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type BackupResult struct {
+	Dir      string `json:"dir"`
+	Sessions int    `json:"sessions"`
+	// Payloads counts the full payload files copied alongside the logs.
+	Payloads int   `json:"payloads"`
+	Bytes    int64 `json:"bytes"`
+}
+
+// Snapshot copies the session log as of the last commit to w. Only the
+// committed prefix is copied, which always ends on a line boundary, so the
+// copy is consistent even while later batches are being appended; writers
+// are never blocked.
+func (s *Store) Snapshot(w io.Writer) (int64, error) {
+	if err := s.Flush(); err != nil && err != ErrClosed {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	size := s.size
+	s.mu.Unlock()
+
+	if size == 0 {
+		return 0, nil
+	}
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return io.CopyN(w, file, size)
+}
+
+// Backup copies every session log in dir into dest. The session current is
+// writing to, if any, is copied through Snapshot; finished sessions are
+// immutable and copied whole. Each file appears in dest only once complete.
+func Backup(dir string, dest string, current *Store) (BackupResult, error) {
+	result := BackupResult{Dir: dest}
+
+	// Flushing first makes a current session with nothing committed yet show
+	// up in the listing.
+	if current != nil {
+		if err := current.Flush(); err != nil && err != ErrClosed {
+			return result, err
+		}
+	}
+
+	sessions, err := ListSessions(dir)
+	if err != nil {
+		return result, err
+	}
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return result, err
+	}
+
+	for _, session := range sessions {
+		var written int64
+		err := writeAtomic(filepath.Join(dest, session.Name), func(w io.Writer) error {
+			if current != nil && session.Path == current.Path() {
+				n, err := current.Snapshot(w)
+				written = n
+				return err
+			}
+
+			src, err := os.Open(session.Path)
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+			n, err := io.Copy(w, src)
+			written = n
+			return err
+		})
+		if err != nil {
+			return result, err
+		}
+
+		result.Sessions++
+		result.Bytes += written
+	}
+	return result, nil
+}
+
+func writeAtomic(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_IsConsistentWhileAppending(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{BatchSize: 8, FlushInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 50; i++ {
+		s.Append(storeEvent(i))
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 50; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				s.Append(storeEvent(i))
+			}
+		}
+	}()
+
+	var buf bytes.Buffer
+	n, err := s.Snapshot(&buf)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	if n == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+		t.Fatalf("Snapshot() wrote %d bytes, want whole lines", n)
+	}
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) < 50 {
+		t.Fatalf("Snapshot() lines = %d, want at least the 50 events appended before it", len(lines))
+	}
+	for i, line := range lines {
+		if !json.Valid(line) {
+			t.Fatalf("Snapshot() line %d = %q, want valid JSON", i, line)
+		}
+	}
+}
+
+func TestBackup_CopiesFinishedAndCurrentSessions(t *testing.T) {
+	dir := t.TempDir()
+
+	finished, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	finished.Append(storeEvent(1))
+	finished.Close()
+
+	current, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer current.Close()
+	current.Append(storeEvent(2), storeEvent(3))
+
+	dest := filepath.Join(t.TempDir(), "backup")
+	result, err := Backup(dir, dest, current)
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	if result.Sessions != 2 {
+		t.Fatalf("Backup() sessions = %d, want 2", result.Sessions)
+	}
+
+	ids := readIDs(t, filepath.Join(dest, filepath.Base(current.Path())))
+	if len(ids) != 2 || ids[1] != "evt-3" {
+		t.Fatalf("backed up current session ids = %v, want [evt-2 evt-3]", ids)
+	}
+
+	entries, _ := os.ReadDir(dest)
+	if len(entries) != 2 {
+		t.Fatalf("backup dir has %d entries, want 2 and no temp files", len(entries))
+	}
+}
@@ -0,0 +1,364 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	DefaultBatchSize     = 512
+	DefaultFlushInterval = 50 * time.Millisecond
+
+	sessionExt = ".ndjson"
+)
+
+var ErrClosed = errors.New("store is closed")
+
+type Options struct {
+	// BatchSize commits as soon as this many events are pending.
+	BatchSize int `json:"batchSize"`
+	// FlushInterval bounds how long an event waits for its group commit.
+	FlushInterval time.Duration `json:"flushInterval"`
+	// OnCommit, if set, is called by the committer with the events of each
+	// successful group commit, before Flush callers waiting on them return.
+	OnCommit func([]dump.Event) `json:"-"`
+}
+
+type Stats struct {
+	Session   string `json:"session"`
+	Committed uint64 `json:"committed"`
+	Commits   uint64 `json:"commits"`
+	Pending   int    `json:"pending"`
+	LastBatch int    `json:"lastBatch"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Store persists events to an append-only NDJSON log, one file per session.
+// Appends are queued and written by a single committer in groups, each group
+// with one write and one fsync, so durability costs are paid per batch rather
+// than per event.
+type Store struct {
+	dir     string
+	path    string
+	file    *os.File
+	options Options
+
+	mu        sync.Mutex
+	committed *sync.Cond
+	pending   []dump.Event
+	queued    uint64
+	discarded uint64
+	attempts  uint64
+	size      int64
+	stats     Stats
+	lastErr   error
+	closed    bool
+	created   bool
+
+	kick chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Open reserves a new session log in dir. Nothing is created on disk until
+// the first commit, so opening is free and idle sessions leave no file.
+func Open(dir string, options Options) (*Store, error) {
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = DefaultFlushInterval
+	}
+
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + sessionExt
+	s := &Store{
+		dir:     dir,
+		path:    filepath.Join(dir, name),
+		options: options,
+		stats:   Stats{Session: name},
+		kick:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	s.committed = sync.NewCond(&s.mu)
+
+	s.wg.Add(1)
+	go s.run()
+	return s, nil
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append queues events for the next group commit and returns without
+// waiting for it. An event with the same ID as the last pending one, such
+// as a collapsed repeat, replaces it instead of being queued again.
+func (s *Store) Append(events ...dump.Event) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	for _, event := range events {
+		if n := len(s.pending); n > 0 && s.pending[n-1].ID == event.ID {
+			s.pending[n-1] = event
+			continue
+		}
+		s.pending = append(s.pending, event)
+		s.queued++
+	}
+	full := len(s.pending) >= s.options.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		s.signal()
+	}
+	return nil
+}
+
+// Flush blocks until every event appended so far is committed, or returns
+// the error of the commit that failed to write them. The events stay
+// pending and are retried by the next commit.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.queued
+	for s.stats.Committed+s.discarded < target && !s.closed {
+		attempt := s.attempts
+		s.signal()
+		for s.attempts == attempt && !s.closed {
+			s.committed.Wait()
+		}
+		if s.lastErr != nil {
+			return s.lastErr
+		}
+	}
+	if s.stats.Committed+s.discarded < target {
+		return ErrClosed
+	}
+	return nil
+}
+
+// Close commits whatever is pending and closes the session log.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.done)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	s.committed.Broadcast()
+	err := s.lastErr
+	s.mu.Unlock()
+
+	if s.file != nil {
+		if closeErr := s.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (s *Store) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Pending = len(s.pending)
+	return stats
+}
+
+func (s *Store) signal() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Store) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.options.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.kick:
+		case <-ticker.C:
+		case <-s.done:
+			s.commit()
+			return
+		}
+		s.commit()
+	}
+}
+
+// commit writes the pending events as one group. A batch that could not be
+// written, such as on a full disk, goes back in front of the events
+// appended since and is retried by the next commit; one that cannot be
+// encoded never will be, and is dropped.
+func (s *Store) commit() {
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	data, err := encodeBatch(batch)
+	retry := false
+	if err == nil {
+		err = s.write(data)
+		retry = err != nil
+	}
+	if err == nil && s.options.OnCommit != nil {
+		s.options.OnCommit(batch)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	switch {
+	case retry:
+		s.requeue(batch)
+		s.lastErr = err
+		s.stats.LastError = err.Error()
+	case err != nil:
+		s.discarded += uint64(len(batch))
+		s.lastErr = err
+		s.stats.LastError = err.Error()
+	default:
+		s.lastErr = nil
+		s.stats.LastError = ""
+		s.size += int64(len(data))
+		s.stats.Committed += uint64(len(batch))
+		s.stats.Commits++
+		s.stats.LastBatch = len(batch)
+	}
+	s.committed.Broadcast()
+}
+
+// requeue puts a failed batch back in front of the pending events. A
+// repeat appended since, under the ID of the batch's last event, replaces
+// it as Append would have.
+func (s *Store) requeue(batch []dump.Event) {
+	if len(s.pending) > 0 && s.pending[0].ID == batch[len(batch)-1].ID {
+		batch = batch[:len(batch)-1]
+		s.queued--
+	}
+	s.pending = append(batch, s.pending...)
+}
+
+func encodeBatch(batch []dump.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, event := range batch {
+		if err := encoder.Encode(event); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// write appends data to the session log and syncs it. A failed or short
+// write is cut back off, so the retry cannot leave a torn line in the
+// middle of the log; when that fails too, the log is reopened and cut on
+// the next write.
+func (s *Store) write(data []byte) error {
+	if s.file == nil {
+		if err := s.openLog(); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.file.Write(data)
+	if err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil && s.file.Truncate(s.size) != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	return err
+}
+
+func (s *Store) openLog() error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !s.created {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(s.path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	s.created = true
+	if err := file.Truncate(s.size); err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	return nil
+}
+
+// ReadSession replays a session log in commit order. A torn final line, left
+// by a crash in the middle of a commit, is skipped. A collapsed repeat that
+// missed the original's commit is logged again under the same ID right
+// after it; only the latest of such consecutive lines is replayed.
+func ReadSession(path string, fn func(dump.Event) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var held *dump.Event
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if held != nil {
+				return fn(*held)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var event dump.Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return err
+		}
+		if held != nil && held.ID != event.ID {
+			if err := fn(*held); err != nil {
+				return err
+			}
+		}
+		held = &event
+	}
+}
@@ -0,0 +1,289 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func storeEvent(id int) dump.Event {
+	return dump.Event{
+		SchemaVersion: dump.SchemaVersion,
+		ID:            fmt.Sprintf("evt-%d", id),
+		Payload:       []byte(`{"n":1}`),
+	}
+}
+
+func readIDs(t *testing.T, path string) []string {
+	t.Helper()
+
+	var ids []string
+	err := ReadSession(path, func(event dump.Event) error {
+		ids = append(ids, event.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadSession() error = %v", err)
+	}
+	return ids
+}
+
+func TestStore_GroupsAppendsIntoOneCommit(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{BatchSize: 100, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := s.Append(storeEvent(i)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	stats := s.Stats()
+	if stats.Commits != 1 || stats.Committed != 5 || stats.LastBatch != 5 {
+		t.Fatalf("Stats() = %+v, want one commit of 5 events", stats)
+	}
+
+	ids := readIDs(t, s.Path())
+	if len(ids) != 5 || ids[0] != "evt-0" || ids[4] != "evt-4" {
+		t.Fatalf("ReadSession() ids = %v, want evt-0..evt-4 in order", ids)
+	}
+}
+
+func TestStore_CommitsFullBatchesWithoutFlush(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{BatchSize: 3, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	s.Append(storeEvent(1), storeEvent(2), storeEvent(3))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Stats().Committed < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := s.Stats(); stats.Committed != 3 {
+		t.Fatalf("Stats().Committed = %d, want 3", stats.Committed)
+	}
+}
+
+func TestStore_CommitsAfterFlushInterval(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{BatchSize: 1000, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	s.Append(storeEvent(1))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Stats().Committed < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := s.Stats(); stats.Committed != 1 {
+		t.Fatalf("Stats().Committed = %d, want 1", stats.Committed)
+	}
+}
+
+func TestStore_CloseCommitsPendingAndRejectsAppends(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{BatchSize: 1000, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	s.Append(storeEvent(1), storeEvent(2))
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if ids := readIDs(t, s.Path()); len(ids) != 2 {
+		t.Fatalf("ReadSession() ids = %v, want 2 committed on close", ids)
+	}
+	if err := s.Append(storeEvent(3)); err != ErrClosed {
+		t.Fatalf("Append() after Close error = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestReadSession_SkipsTornFinalLine(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	s.Append(storeEvent(1))
+	s.Close()
+
+	file, err := os.OpenFile(s.Path(), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	file.WriteString(`{"schemaVersion":2,"id":"evt-`)
+	file.Close()
+
+	if ids := readIDs(t, s.Path()); len(ids) != 1 || ids[0] != "evt-1" {
+		t.Fatalf("ReadSession() ids = %v, want [evt-1]", ids)
+	}
+}
+
+func TestOpen_CreatesNoFileUntilFirstCommit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("Stat(dir) error = %v, want not exist before any commit", err)
+	}
+
+	s.Append(storeEvent(1))
+	s.Close()
+
+	sessions, err := ListSessions(dir)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Path != s.Path() || sessions[0].Bytes == 0 {
+		t.Fatalf("ListSessions() = %+v, want the committed session", sessions)
+	}
+}
+
+func TestStore_ReportsCommittedEvents(t *testing.T) {
+	var committed []string
+	s, err := Open(t.TempDir(), Options{OnCommit: func(batch []dump.Event) {
+		for _, event := range batch {
+			committed = append(committed, event.ID)
+		}
+	}})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	s.Append(storeEvent(1), storeEvent(2))
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if len(committed) != 2 || committed[0] != "evt-1" {
+		t.Fatalf("OnCommit() ids = %v, want evt-1 and evt-2", committed)
+	}
+}
+
+func TestSummarize_CountsEventsAndTimeRange(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	// Fractional seconds sort before the whole second as text.
+	for i, timestamp := range []string{"2026-03-02T12:00:01Z", "2026-03-02T12:00:00.5Z", "2026-03-02T12:00:00Z", "2026-03-02T12:00:05.25Z", "2026-03-02T12:00:05Z"} {
+		event := storeEvent(i)
+		event.Timestamp = timestamp
+		s.Append(event)
+	}
+	s.Close()
+
+	summary, err := Summarize(s.Path())
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	want := Summary{Events: 5, FirstTimestamp: "2026-03-02T12:00:00Z", LastTimestamp: "2026-03-02T12:00:05.25Z"}
+	if summary != want {
+		t.Fatalf("Summarize() = %+v, want %+v", summary, want)
+	}
+}
+
+func TestSessionPath_RejectsPathsOutsideDir(t *testing.T) {
+	for _, name := range []string{"", "../x.ndjson", "a/b.ndjson", "notes.txt"} {
+		if _, err := SessionPath("/data", name); err != ErrInvalidSession {
+			t.Fatalf("SessionPath(%q) error = %v, want %v", name, err, ErrInvalidSession)
+		}
+	}
+}
+
+func TestStore_RepeatsReplaceTheEventTheyCollapseInto(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{BatchSize: 100, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	repeat := func(count int) dump.Event {
+		event := storeEvent(1)
+		event.RepeatCount = count
+		return event
+	}
+
+	s.Append(storeEvent(0), storeEvent(1), repeat(2))
+	s.Flush()
+	// A repeat arriving after the commit is logged again.
+	s.Append(repeat(3))
+	s.Append(storeEvent(2))
+	s.Flush()
+
+	if stats := s.Stats(); stats.Committed != 4 {
+		t.Fatalf("Stats().Committed = %d, want 4 lines", stats.Committed)
+	}
+
+	var replayed []dump.Event
+	ReadSession(s.Path(), func(event dump.Event) error {
+		replayed = append(replayed, event)
+		return nil
+	})
+	if len(replayed) != 3 || replayed[1].ID != "evt-1" || replayed[1].RepeatCount != 3 {
+		t.Fatalf("ReadSession() = %+v, want evt-1 once with the latest count", replayed)
+	}
+}
+
+func TestStore_RetriesAFailedCommitWithoutTearingTheLog(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{BatchSize: 1000, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	s.Append(storeEvent(1))
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	// Half a line reached the disk before the write failed, and the handle
+	// can no longer be written or cut.
+	file, _ := os.OpenFile(s.Path(), os.O_APPEND|os.O_WRONLY, 0)
+	file.WriteString(`{"id":"evt-2","pay`)
+	file.Close()
+	readOnly, _ := os.Open(s.Path())
+	s.mu.Lock()
+	s.file.Close()
+	s.file = readOnly
+	s.mu.Unlock()
+
+	s.Append(storeEvent(2))
+	if err := s.Flush(); err == nil {
+		t.Fatal("Flush() error = nil, want the failed write")
+	}
+	if stats := s.Stats(); stats.LastError == "" || stats.Pending != 1 {
+		t.Fatalf("Stats() = %+v, want the error and the event still pending", stats)
+	}
+
+	s.Append(storeEvent(3))
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush() after recovery error = %v", err)
+	}
+	if stats := s.Stats(); stats.LastError != "" || stats.Committed != 3 {
+		t.Fatalf("Stats() = %+v, want all three committed and the error cleared", stats)
+	}
+	if ids := readIDs(t, s.Path()); strings.Join(ids, ",") != "evt-1,evt-2,evt-3" {
+		t.Fatalf("ReadSession() ids = %v, want evt-1 to evt-3 with no torn line", ids)
+	}
+}
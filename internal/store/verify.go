@@ -0,0 +1,229 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// Issue kinds Verify reports.
+const (
+	// IssueCorruptLine is a complete line of a session log that is not an
+	// event, which stops ReadSession from replaying the rest of the log.
+	IssueCorruptLine = "corrupt-line"
+	// IssueTornTail is a final line without its newline, left by a crash in
+	// the middle of a commit. ReadSession skips it.
+	IssueTornTail = "torn-tail"
+	// IssueStaleTemp is a temporary file left behind by an interrupted
+	// atomic write.
+	IssueStaleTemp = "stale-temp"
+)
+
+const (
+	// QuarantineDirName is the directory, inside the store, that repair
+	// moves the lines it removes from a session log to, one file per
+	// session, so nothing is lost for good.
+	QuarantineDirName = "quarantine"
+	// StaleAfter is how old a temporary file must be before it is taken
+	// for a leftover rather than a write in progress.
+	StaleAfter = 10 * time.Minute
+)
+
+// Issue is one problem found in the store. Line is 1-based and set for
+// problems with a line of a session log.
+type Issue struct {
+	Kind     string `json:"kind"`
+	Session  string `json:"session,omitempty"`
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+	Repaired bool   `json:"repaired"`
+}
+
+type VerifyOptions struct {
+	// Repair rewrites damaged session logs without their bad lines, which
+	// are appended to the quarantine, and removes stale temporary files.
+	Repair bool
+	// Active is the path of the session log being written. It is checked
+	// but never rewritten, and a line still being appended to it is not a
+	// torn tail.
+	Active string
+	// Event, if set, is called with every valid event of every session,
+	// so callers can check what refers to them.
+	Event func(dump.Event)
+	// Now is the time temporary files are aged against; zero means now.
+	Now time.Time
+}
+
+type VerifyResult struct {
+	Sessions    int     `json:"sessions"`
+	Events      int     `json:"events"`
+	Issues      []Issue `json:"issues"`
+	Quarantined int     `json:"quarantined"`
+}
+
+// Verify checks every session log in dir line by line, and looks for
+// temporary files left in it by interrupted writes. With Repair set it
+// fixes what it finds; a session it cannot repair is reported and kept as
+// it is.
+func Verify(dir string, options VerifyOptions) (VerifyResult, error) {
+	if options.Now.IsZero() {
+		options.Now = time.Now()
+	}
+	result := VerifyResult{Issues: []Issue{}}
+
+	sessions, err := ListSessions(dir)
+	if err != nil {
+		return result, err
+	}
+	for _, session := range sessions {
+		issues, bad, err := verifySession(session, session.Path == options.Active, options.Event, &result)
+		if err != nil {
+			return result, err
+		}
+		if options.Repair && len(bad) > 0 && session.Path != options.Active {
+			err := repairSession(dir, session, bad)
+			for i := range issues {
+				if err != nil {
+					issues[i].Message += "; repair failed: " + err.Error()
+				}
+				issues[i].Repaired = err == nil
+			}
+			if err == nil {
+				result.Quarantined += len(bad)
+			}
+		}
+		result.Sessions++
+		result.Issues = append(result.Issues, issues...)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return result, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || options.Now.Sub(info.ModTime()) < StaleAfter {
+			continue
+		}
+		issue := Issue{
+			Kind:    IssueStaleTemp,
+			Path:    filepath.Join(dir, entry.Name()),
+			Message: fmt.Sprintf("temporary file left at %s", info.ModTime().UTC().Format(time.RFC3339)),
+		}
+		if options.Repair {
+			issue.Repaired = os.Remove(issue.Path) == nil
+		}
+		result.Issues = append(result.Issues, issue)
+	}
+	return result, nil
+}
+
+// badLines holds the 1-based numbers of the lines repair removes from a
+// session log.
+type badLines map[int]bool
+
+func verifySession(session Session, active bool, visit func(dump.Event), result *VerifyResult) ([]Issue, badLines, error) {
+	file, err := os.Open(session.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var issues []Issue
+	bad := badLines{}
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for number := 1; ; number++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 && !active {
+				issues = append(issues, Issue{
+					Kind: IssueTornTail, Session: session.Name, Path: session.Path, Line: number,
+					Message: fmt.Sprintf("final line of %d bytes has no newline", len(line)),
+				})
+				bad[number] = true
+			}
+			return issues, bad, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var event dump.Event
+		if err := json.Unmarshal(line, &event); err != nil || event.ID == "" {
+			message := "line is not an event"
+			if err != nil {
+				message = err.Error()
+			}
+			issues = append(issues, Issue{Kind: IssueCorruptLine, Session: session.Name, Path: session.Path, Line: number, Message: message})
+			bad[number] = true
+			continue
+		}
+		result.Events++
+		if visit != nil {
+			visit(event)
+		}
+	}
+}
+
+// repairSession moves the bad lines of a session log to the quarantine and
+// replaces the log with the rest of its lines, as they were.
+func repairSession(dir string, session Session, bad badLines) error {
+	data, err := os.ReadFile(session.Path)
+	if err != nil {
+		return err
+	}
+
+	var kept, removed bytes.Buffer
+	for number, rest := 1, data; len(rest) > 0; number++ {
+		line := rest
+		if end := bytes.IndexByte(rest, '\n'); end >= 0 {
+			line = rest[:end+1]
+		}
+		rest = rest[len(line):]
+
+		if bad[number] {
+			removed.Write(bytes.TrimRight(line, "\n"))
+			removed.WriteByte('\n')
+			continue
+		}
+		kept.Write(line)
+	}
+
+	quarantine := filepath.Join(dir, QuarantineDirName)
+	if err := os.MkdirAll(quarantine, 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(quarantine, session.Name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(removed.Bytes()); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return writeAtomic(session.Path, func(w io.Writer) error {
+		_, err := w.Write(kept.Bytes())
+		return err
+	})
+}
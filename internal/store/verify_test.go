@@ -0,0 +1,90 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func writeSession(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func eventLine(t *testing.T, i int) string {
+	t.Helper()
+
+	line, err := json.Marshal(storeEvent(i))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return string(line) + "\n"
+}
+
+func TestVerify_ReportsCorruptLinesTornTailsAndStaleTemps(t *testing.T) {
+	dir := t.TempDir()
+	broken := writeSession(t, dir, "20260101T000000.000000000Z.ndjson", eventLine(t, 1)+"{not json\n"+eventLine(t, 2)+`{"id":"evt-3","payl`)
+	active := writeSession(t, dir, "20260102T000000.000000000Z.ndjson", eventLine(t, 4)+`{"id":"evt-5"`)
+	temp := writeSession(t, dir, "annotations.json.123.tmp", "{}")
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(temp, old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	var visited []string
+	result, err := Verify(dir, VerifyOptions{Active: active, Event: func(event dump.Event) { visited = append(visited, event.ID) }})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Sessions != 2 || result.Events != 3 || len(visited) != 3 {
+		t.Fatalf("Verify() = %+v, visited %v, want 2 sessions with 3 events", result, visited)
+	}
+	kinds := []string{}
+	for _, issue := range result.Issues {
+		kinds = append(kinds, issue.Kind)
+		if issue.Repaired {
+			t.Fatalf("Verify() issue %+v repaired without Repair", issue)
+		}
+	}
+	if len(kinds) != 3 || kinds[0] != IssueCorruptLine || result.Issues[0].Line != 2 || kinds[1] != IssueTornTail || kinds[2] != IssueStaleTemp {
+		t.Fatalf("Verify() issues = %+v, want corrupt line 2, torn tail, stale temp", result.Issues)
+	}
+
+	if err := ReadSession(broken, func(dump.Event) error { return nil }); err == nil {
+		t.Fatalf("ReadSession(broken) error = nil, want the corrupt line to stop it")
+	}
+}
+
+func TestVerify_RepairQuarantinesBadLines(t *testing.T) {
+	dir := t.TempDir()
+	broken := writeSession(t, dir, "20260101T000000.000000000Z.ndjson", eventLine(t, 1)+"{}\n"+eventLine(t, 2)+`{"id":"evt-3"`)
+
+	result, err := Verify(dir, VerifyOptions{Repair: true})
+	if err != nil {
+		t.Fatalf("Verify(repair) error = %v", err)
+	}
+	if result.Quarantined != 2 || len(result.Issues) != 2 || !result.Issues[0].Repaired || !result.Issues[1].Repaired {
+		t.Fatalf("Verify(repair) = %+v, want two repaired issues", result)
+	}
+
+	if ids := readIDs(t, broken); len(ids) != 2 {
+		t.Fatalf("ReadSession(repaired) = %v, want both good events", ids)
+	}
+	quarantined, err := os.ReadFile(filepath.Join(dir, QuarantineDirName, filepath.Base(broken)))
+	if err != nil || string(quarantined) != "{}\n{\"id\":\"evt-3\"\n" {
+		t.Fatalf("quarantine = %q, %v, want the removed lines", quarantined, err)
+	}
+
+	if again, err := Verify(dir, VerifyOptions{}); err != nil || len(again.Issues) != 0 {
+		t.Fatalf("Verify(after repair) = %+v, %v, want no issues", again, err)
+	}
+}
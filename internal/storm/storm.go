@@ -0,0 +1,149 @@
+// Package storm finds dump storms: bursts of hundreds of events from one
+// callsite within seconds, typically a dump() left inside a loop, so the
+// timeline can show each burst as a single group.
+package storm
+
+import (
+	"slices"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/query"
+	"phant/internal/signature"
+)
+
+const (
+	// DefaultMinEvents is how many events a burst needs to be a storm.
+	DefaultMinEvents = 100
+	// DefaultGap is the longest pause between two events of one callsite
+	// that still continues the same burst.
+	DefaultGap = time.Second
+
+	// SampleSize is how many of the first and last events a storm keeps.
+	SampleSize = 3
+	// MaxRepresentatives caps the payloads of distinct shapes a storm keeps.
+	MaxRepresentatives = 5
+)
+
+// Storm is one burst from a callsite. First and Last are its earliest and
+// latest events; Representatives holds the first event of each distinct
+// payload shape, so a loop dumping different kinds of values shows each
+// kind once.
+type Storm struct {
+	// ID is the ID of the storm's first event.
+	ID              string       `json:"id"`
+	ProjectRoot     string       `json:"projectRoot"`
+	Origin          string       `json:"origin"`
+	Count           int          `json:"count"`
+	StartedAt       string       `json:"startedAt"`
+	EndedAt         string       `json:"endedAt"`
+	RatePerSecond   float64      `json:"ratePerSecond"`
+	First           []dump.Event `json:"first"`
+	Last            []dump.Event `json:"last"`
+	Representatives []dump.Event `json:"representatives"`
+
+	events []dump.Event
+	start  time.Time
+	end    time.Time
+}
+
+// Events returns every event of the storm, oldest first.
+func (s Storm) Events() []dump.Event {
+	return s.events
+}
+
+type callsite struct {
+	project string
+	origin  string
+}
+
+// Detect finds storms among events, expected oldest first. Events of one
+// callsite (project and origin file:line) belong to the same burst while
+// each follows the previous within gap; bursts of at least minEvents are
+// storms. Events without an origin are never part of one. signatures
+// tells payload shapes apart for the representatives. Storms are returned
+// newest first.
+func Detect(events []dump.Event, minEvents int, gap time.Duration, signatures *signature.Cache) []Storm {
+	if minEvents <= 0 {
+		minEvents = DefaultMinEvents
+	}
+	if gap <= 0 {
+		gap = DefaultGap
+	}
+
+	var bursts []*Storm
+	open := map[callsite]*Storm{}
+	for _, event := range events {
+		origin := query.Origin(event)
+		if origin == "" {
+			continue
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+		if err != nil {
+			continue
+		}
+
+		key := callsite{project: event.ProjectRoot, origin: origin}
+		current := open[key]
+		if current == nil || timestamp.Sub(current.end) > gap {
+			current = &Storm{ID: event.ID, ProjectRoot: event.ProjectRoot, Origin: origin, start: timestamp, end: timestamp}
+			open[key] = current
+			bursts = append(bursts, current)
+		}
+		current.events = append(current.events, event)
+		current.start = minTime(current.start, timestamp)
+		current.end = maxTime(current.end, timestamp)
+	}
+
+	storms := []Storm{}
+	for i := len(bursts) - 1; i >= 0; i-- {
+		if burst := bursts[i]; len(burst.events) >= minEvents {
+			storms = append(storms, summarize(*burst, signatures))
+		}
+	}
+	return storms
+}
+
+func summarize(storm Storm, signatures *signature.Cache) Storm {
+	count := len(storm.events)
+	storm.Count = count
+	storm.StartedAt = storm.start.UTC().Format(time.RFC3339Nano)
+	storm.EndedAt = storm.end.UTC().Format(time.RFC3339Nano)
+	// A burst within one millisecond is rated as if it took a millisecond.
+	storm.RatePerSecond = float64(count) / max(storm.end.Sub(storm.start), time.Millisecond).Seconds()
+	storm.First = slices.Clone(storm.events[:min(SampleSize, count)])
+	storm.Last = slices.Clone(storm.events[max(count-SampleSize, 0):])
+
+	storm.Representatives = []dump.Event{}
+	shapes := map[string]bool{}
+	for _, event := range storm.events {
+		if len(storm.Representatives) == MaxRepresentatives {
+			break
+		}
+		hash := string(event.Payload)
+		if signatures != nil {
+			if sig, err := signatures.EventSignature(event); err == nil {
+				hash = sig.Hash
+			}
+		}
+		if !shapes[hash] {
+			shapes[hash] = true
+			storm.Representatives = append(storm.Representatives, event)
+		}
+	}
+	return storm
+}
+
+func minTime(a time.Time, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a time.Time, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,74 @@
+package storm
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/signature"
+)
+
+func loopEvents(prefix string, line int, start time.Time, count int, step time.Duration) []dump.Event {
+	events := make([]dump.Event, count)
+	for i := range events {
+		payload := json.RawMessage(fmt.Sprintf(`{"i":%d}`, i))
+		if i%10 == 9 {
+			payload = json.RawMessage(`"done"`)
+		}
+		events[i] = dump.Event{
+			ID:          fmt.Sprintf("%s%03d", prefix, i),
+			ProjectRoot: "/app",
+			Timestamp:   start.Add(time.Duration(i) * step).Format(time.RFC3339Nano),
+			Origin:      &dump.TraceFrame{File: "/app/Job.php", Line: line},
+			Payload:     payload,
+		}
+	}
+	return events
+}
+
+func TestDetect_GroupsBurstsFromOneCallsite(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	var events []dump.Event
+	events = append(events, loopEvents("a", 12, start, 200, 5*time.Millisecond)...)
+	// Another callsite dumping as often, but too few times to storm.
+	events = append(events, loopEvents("b", 40, start, 20, 5*time.Millisecond)...)
+	// The same callsite again after a pause is a second storm.
+	events = append(events, loopEvents("c", 12, start.Add(time.Minute), 150, time.Millisecond)...)
+
+	storms := Detect(events, 100, time.Second, signature.NewCache(0))
+	if len(storms) != 2 {
+		t.Fatalf("Detect() = %d storms, want 2", len(storms))
+	}
+	if storms[0].ID != "c000" || storms[0].Count != 150 {
+		t.Fatalf("Detect()[0] = %s with %d events, want the later storm c000 with 150 first", storms[0].ID, storms[0].Count)
+	}
+
+	storm := storms[1]
+	if storm.ID != "a000" || storm.Count != 200 || storm.Origin != "/app/Job.php:12" || len(storm.Events()) != 200 {
+		t.Fatalf("Detect()[1] = %s at %s with %d events, want a000 at /app/Job.php:12 with 200", storm.ID, storm.Origin, storm.Count)
+	}
+	if storm.StartedAt != "2026-03-01T10:00:00Z" || storm.EndedAt != "2026-03-01T10:00:00.995Z" {
+		t.Fatalf("Detect()[1] span = %s..%s, want 10:00:00..10:00:00.995", storm.StartedAt, storm.EndedAt)
+	}
+	if storm.RatePerSecond < 200 || storm.RatePerSecond > 202 {
+		t.Fatalf("Detect()[1] rate = %v, want about 201/s", storm.RatePerSecond)
+	}
+	if len(storm.First) != SampleSize || storm.First[0].ID != "a000" || storm.Last[SampleSize-1].ID != "a199" {
+		t.Fatalf("Detect()[1] samples = %v..%v, want a000..a199", storm.First, storm.Last)
+	}
+	if len(storm.Representatives) != 2 || storm.Representatives[1].ID != "a009" {
+		t.Fatalf("Detect()[1] representatives = %d, want one per payload shape", len(storm.Representatives))
+	}
+}
+
+func TestDetect_IgnoresEventsWithoutOrigin(t *testing.T) {
+	events := loopEvents("a", 12, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), 150, time.Millisecond)
+	for i := range events {
+		events[i].Origin = nil
+	}
+	if storms := Detect(events, 100, time.Second, nil); len(storms) != 0 {
+		t.Fatalf("Detect() = %d storms, want none without origins", len(storms))
+	}
+}
@@ -0,0 +1,267 @@
+package tail
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const DefaultPollInterval = 250 * time.Millisecond
+
+const maxPendingLineBytes = 4 * 1024 * 1024
+
+type Decoder func(line string) (*dump.Event, error)
+
+type Handler func(event dump.Event)
+
+type Follower struct {
+	path         string
+	decode       Decoder
+	handle       Handler
+	pollInterval time.Duration
+
+	file    *os.File
+	offset  int64
+	pending []byte
+
+	mu         sync.RWMutex
+	lastError  string
+	lastOffset int64
+	lines      uint64
+	rejected   uint64
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+	wg       sync.WaitGroup
+}
+
+type Status struct {
+	Path      string `json:"path"`
+	Offset    int64  `json:"offset"`
+	Lines     uint64 `json:"lines"`
+	Rejected  uint64 `json:"rejected"`
+	LastError string `json:"lastError"`
+}
+
+func NewFollower(path string, decode Decoder, handle Handler) *Follower {
+	if decode == nil {
+		decode = dump.DecodeNDJSONLine
+	}
+
+	return &Follower{
+		path:         path,
+		decode:       decode,
+		handle:       handle,
+		pollInterval: DefaultPollInterval,
+		stopped:      make(chan struct{}),
+	}
+}
+
+// Start opens the file positioned at its current end, so only lines appended
+// after the call are streamed.
+func (f *Follower) Start() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.offset = offset
+	f.lastOffset = offset
+	f.wg.Add(1)
+	go f.pollLoop()
+
+	return nil
+}
+
+func (f *Follower) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.stopped)
+		f.wg.Wait()
+
+		if f.file != nil {
+			f.file.Close()
+			f.file = nil
+		}
+	})
+}
+
+func (f *Follower) Path() string {
+	return f.path
+}
+
+func (f *Follower) Status() Status {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return Status{
+		Path:      f.path,
+		Offset:    f.lastOffset,
+		Lines:     f.lines,
+		Rejected:  f.rejected,
+		LastError: f.lastError,
+	}
+}
+
+func (f *Follower) pollLoop() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stopped:
+			return
+		case <-ticker.C:
+			f.recordPoll(f.poll())
+		}
+	}
+}
+
+func (f *Follower) poll() error {
+	if err := f.detectRotation(); err != nil {
+		return err
+	}
+	if f.file == nil {
+		return nil
+	}
+
+	info, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() < f.offset {
+		// Truncated in place (e.g. logrotate copytruncate): restart from the top.
+		f.offset = 0
+		f.pending = f.pending[:0]
+	}
+
+	if info.Size() == f.offset {
+		return nil
+	}
+
+	return f.readFrom(f.file)
+}
+
+// detectRotation reopens the path when it now points at a different file,
+// draining whatever was appended to the old file before switching over.
+func (f *Follower) detectRotation() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if f.file != nil {
+		current, statErr := f.file.Stat()
+		if statErr == nil && os.SameFile(info, current) {
+			return nil
+		}
+
+		if readErr := f.readFrom(f.file); readErr != nil {
+			return readErr
+		}
+		f.file.Close()
+		f.file = nil
+	}
+
+	next, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.file = next
+	f.offset = 0
+	f.pending = f.pending[:0]
+	return nil
+}
+
+func (f *Follower) readFrom(file *os.File) error {
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 64*1024)
+	for {
+		n, err := file.Read(chunk)
+		if n > 0 {
+			f.offset += int64(n)
+			f.consume(chunk[:n])
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (f *Follower) consume(data []byte) {
+	f.pending = append(f.pending, data...)
+
+	for {
+		idx := bytes.IndexByte(f.pending, '\n')
+		if idx < 0 {
+			break
+		}
+
+		line := string(f.pending[:idx])
+		f.pending = f.pending[idx+1:]
+		f.emit(line)
+	}
+
+	if len(f.pending) > maxPendingLineBytes {
+		f.pending = f.pending[:0]
+		f.mu.Lock()
+		f.rejected++
+		f.mu.Unlock()
+	}
+}
+
+func (f *Follower) emit(line string) {
+	event, err := f.decode(line)
+
+	f.mu.Lock()
+	if err != nil {
+		f.rejected++
+	} else if event != nil {
+		f.lines++
+	}
+	f.mu.Unlock()
+
+	if err != nil || event == nil {
+		return
+	}
+
+	if f.handle != nil {
+		f.handle(*event)
+	}
+}
+
+func (f *Follower) recordPoll(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastOffset = f.offset
+	if err == nil {
+		f.lastError = ""
+		return
+	}
+	f.lastError = err.Error()
+}
@@ -0,0 +1,162 @@
+package tail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+type eventRecorder struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (r *eventRecorder) handle(event dump.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = append(r.ids, event.ID)
+}
+
+func (r *eventRecorder) waitFor(t *testing.T, want int) []string {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		got := append([]string(nil), r.ids...)
+		r.mu.Unlock()
+		if len(got) >= want {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.Fatalf("received %d events, want %d (ids=%v)", len(r.ids), want, r.ids)
+	return nil
+}
+
+func startTestFollower(t *testing.T, path string, recorder *eventRecorder) *Follower {
+	t.Helper()
+
+	follower := NewFollower(path, nil, recorder.handle)
+	follower.pollInterval = 10 * time.Millisecond
+	if err := follower.Start(); err != nil {
+		t.Fatalf("follower.Start() error = %v", err)
+	}
+	t.Cleanup(follower.Stop)
+	return follower
+}
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatalf("os.OpenFile(%q) error = %v", path, err)
+	}
+	defer file.Close()
+
+	for _, line := range lines {
+		if _, err := file.WriteString(line); err != nil {
+			t.Fatalf("write line error = %v", err)
+		}
+	}
+}
+
+func TestFollower_StreamsOnlyAppendedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "phant.ndjson")
+	appendLines(t, path, validEventLine("old")+"\n")
+
+	recorder := &eventRecorder{}
+	startTestFollower(t, path, recorder)
+
+	appendLines(t, path, validEventLine("evt-1")+"\n", "not json\n", validEventLine("evt-2")+"\n")
+
+	got := recorder.waitFor(t, 2)
+	if got[0] != "evt-1" || got[1] != "evt-2" {
+		t.Fatalf("tailed IDs = %v, want [evt-1 evt-2]", got)
+	}
+}
+
+func TestFollower_BuffersPartialLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "phant.ndjson")
+	appendLines(t, path)
+
+	recorder := &eventRecorder{}
+	follower := startTestFollower(t, path, recorder)
+
+	line := validEventLine("evt-1")
+	appendLines(t, path, line[:20])
+	time.Sleep(50 * time.Millisecond)
+	appendLines(t, path, line[20:]+"\n")
+
+	got := recorder.waitFor(t, 1)
+	if got[0] != "evt-1" {
+		t.Fatalf("tailed IDs = %v, want [evt-1]", got)
+	}
+	if status := follower.Status(); status.Rejected != 0 {
+		t.Fatalf("follower.Status().Rejected = %d, want 0", status.Rejected)
+	}
+}
+
+func TestFollower_RestartsAfterTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "phant.ndjson")
+	appendLines(t, path)
+
+	recorder := &eventRecorder{}
+	startTestFollower(t, path, recorder)
+
+	appendLines(t, path, validEventLine("evt-1")+"\n", validEventLine("evt-2")+"\n")
+	recorder.waitFor(t, 2)
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatalf("os.Truncate(%q) error = %v", path, err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	appendLines(t, path, validEventLine("evt-3")+"\n")
+
+	got := recorder.waitFor(t, 3)
+	if got[2] != "evt-3" {
+		t.Fatalf("tailed IDs = %v, want evt-3 after truncation", got)
+	}
+}
+
+func TestFollower_FollowsRotatedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "phant.ndjson")
+	appendLines(t, path)
+
+	recorder := &eventRecorder{}
+	startTestFollower(t, path, recorder)
+
+	appendLines(t, path, validEventLine("evt-1")+"\n")
+	recorder.waitFor(t, 1)
+
+	if err := os.Rename(path, filepath.Join(dir, "phant.ndjson.1")); err != nil {
+		t.Fatalf("os.Rename() error = %v", err)
+	}
+	appendLines(t, path, validEventLine("evt-2")+"\n")
+
+	got := recorder.waitFor(t, 2)
+	if got[1] != "evt-2" {
+		t.Fatalf("tailed IDs = %v, want evt-2 from rotated file", got)
+	}
+}
+
+func TestManager_StopUnknownPathFails(t *testing.T) {
+	manager := NewManager(nil, nil)
+	if err := manager.Stop(filepath.Join(t.TempDir(), "missing.ndjson")); err == nil {
+		t.Fatalf("manager.Stop(unknown) error = nil, want error")
+	}
+}
+
+func validEventLine(id string) string {
+	return fmt.Sprintf(`{"schemaVersion":1,"id":"%s","timestamp":"2026-03-02T12:00:00Z","sourceType":"cli","projectRoot":"/tmp/app","phpSapi":"cli","requestId":null,"command":{"name":"artisan"},"isDd":false,"payloadFormat":"json","payload":{"ok":true},"trace":[],"host":{"hostname":"test-host","pid":1234}}`, id)
+}
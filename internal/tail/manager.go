@@ -0,0 +1,110 @@
+package tail
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+type Manager struct {
+	decode     Decoder
+	decoderFor func(path string) Decoder
+	handle     Handler
+
+	mu        sync.Mutex
+	followers map[string]*Follower
+}
+
+func NewManager(decode Decoder, handle Handler) *Manager {
+	return &Manager{
+		decode:    decode,
+		handle:    handle,
+		followers: make(map[string]*Follower),
+	}
+}
+
+// SetDecoderFor gives each followed file its own decoder, so events can
+// record the file they came from. It must be called before Follow.
+func (m *Manager) SetDecoderFor(decoderFor func(path string) Decoder) {
+	m.decoderFor = decoderFor
+}
+
+func (m *Manager) Follow(path string) error {
+	key, err := normalizePath(path)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.followers[key]; ok {
+		return nil
+	}
+
+	decode := m.decode
+	if m.decoderFor != nil {
+		decode = m.decoderFor(key)
+	}
+	follower := NewFollower(key, decode, m.handle)
+	if err := follower.Start(); err != nil {
+		return err
+	}
+
+	m.followers[key] = follower
+	return nil
+}
+
+func (m *Manager) Stop(path string) error {
+	key, err := normalizePath(path)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	follower, ok := m.followers[key]
+	delete(m.followers, key)
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("dump file is not being tailed: %s", key)
+	}
+
+	follower.Stop()
+	return nil
+}
+
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	followers := m.followers
+	m.followers = make(map[string]*Follower)
+	m.mu.Unlock()
+
+	for _, follower := range followers {
+		follower.Stop()
+	}
+}
+
+func (m *Manager) Statuses() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]Status, 0, len(m.followers))
+	for _, follower := range m.followers {
+		statuses = append(statuses, follower.Status())
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Path < statuses[j].Path
+	})
+	return statuses
+}
+
+func normalizePath(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("dump file path is required")
+	}
+	return filepath.Abs(path)
+}
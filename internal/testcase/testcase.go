@@ -0,0 +1,84 @@
+// Package testcase groups dumps emitted during Pest or PHPUnit runs by the
+// test that was running.
+package testcase
+
+import (
+	"phant/internal/dump"
+)
+
+// Case is one test execution: a test, with its data set, run by one
+// process. Failed is set when any of its events reported an exception or
+// error.
+type Case struct {
+	// ID is the ID of the case's first event.
+	ID          string       `json:"id"`
+	Framework   string       `json:"framework,omitempty"`
+	Class       string       `json:"class,omitempty"`
+	Name        string       `json:"name"`
+	Dataset     string       `json:"dataset,omitempty"`
+	File        string       `json:"file,omitempty"`
+	ProjectRoot string       `json:"projectRoot"`
+	PID         int          `json:"pid"`
+	StartedAt   string       `json:"startedAt"`
+	EndedAt     string       `json:"endedAt"`
+	Failed      bool         `json:"failed"`
+	Events      []dump.Event `json:"events"`
+}
+
+type key struct {
+	hostname string
+	pid      int
+	project  string
+	class    string
+	name     string
+	dataset  string
+}
+
+// Group collects events carrying test context, expected oldest first, into
+// cases in the order the tests started. Events without test context are
+// skipped.
+func Group(events []dump.Event) []Case {
+	var cases []*Case
+	byKey := map[key]*Case{}
+
+	for _, event := range events {
+		test := event.Test
+		if test == nil {
+			continue
+		}
+
+		k := key{
+			hostname: event.Host.Hostname,
+			pid:      event.Host.PID,
+			project:  event.ProjectRoot,
+			class:    test.Class,
+			name:     test.Name,
+			dataset:  test.Dataset,
+		}
+		current := byKey[k]
+		if current == nil {
+			current = &Case{
+				ID:          event.ID,
+				Framework:   test.Framework,
+				Class:       test.Class,
+				Name:        test.Name,
+				Dataset:     test.Dataset,
+				File:        test.File,
+				ProjectRoot: event.ProjectRoot,
+				PID:         event.Host.PID,
+				StartedAt:   event.Timestamp,
+			}
+			byKey[k] = current
+			cases = append(cases, current)
+		}
+		current.EndedAt = event.Timestamp
+		current.Failed = current.Failed || event.IsError
+		current.Events = append(current.Events, event)
+	}
+
+	grouped := make([]Case, len(cases))
+	for i, c := range cases {
+		grouped[i] = *c
+	}
+	return grouped
+}
@@ -0,0 +1,43 @@
+package testcase
+
+import (
+	"testing"
+
+	"phant/internal/dump"
+)
+
+func testEvent(id string, pid int, name string, dataset string) dump.Event {
+	return dump.Event{
+		ID: id, SourceType: "cli", ProjectRoot: "/app", Timestamp: "2026-03-01T10:00:00Z",
+		Host: dump.HostMeta{Hostname: "h", PID: pid},
+		Test: &dump.TestMeta{Framework: "pest", Class: "Tests\\Feature\\CheckoutTest", Name: name, Dataset: dataset},
+	}
+}
+
+func TestGroup_GroupsByTestDatasetAndProcess(t *testing.T) {
+	failing := testEvent("e3", 10, "it charges", "visa")
+	failing.IsError = true
+
+	events := []dump.Event{
+		testEvent("e1", 10, "it charges", "visa"),
+		{ID: "plain", SourceType: "cli", Host: dump.HostMeta{Hostname: "h", PID: 10}},
+		testEvent("e2", 10, "it charges", "amex"),
+		failing,
+		testEvent("e4", 11, "it charges", "visa"),
+	}
+
+	cases := Group(events)
+	if len(cases) != 3 {
+		t.Fatalf("Group() = %d cases, want 3", len(cases))
+	}
+	visa := cases[0]
+	if visa.ID != "e1" || visa.Dataset != "visa" || len(visa.Events) != 2 || !visa.Failed {
+		t.Fatalf("Group()[0] = %+v, want failed visa case with two events", visa)
+	}
+	if amex := cases[1]; amex.Dataset != "amex" || amex.Failed {
+		t.Fatalf("Group()[1] = %+v, want passing amex case", amex)
+	}
+	if other := cases[2]; other.PID != 11 || other.Failed {
+		t.Fatalf("Group()[2] = %+v, want separate case for the second process", other)
+	}
+}
@@ -0,0 +1,77 @@
+package testsupport
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/store"
+)
+
+// DefaultTimeout bounds every wait in this package. Pipelines under test
+// settle in milliseconds; the margin is for loaded CI machines.
+const DefaultTimeout = 5 * time.Second
+
+// Eventually polls condition until it holds, failing the test with what
+// after DefaultTimeout.
+func Eventually(tb testing.TB, what string, condition func() bool) {
+	tb.Helper()
+
+	deadline := time.Now().Add(DefaultTimeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			tb.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// WaitForEvents polls source until it returns at least want events.
+func WaitForEvents(tb testing.TB, source func() []dump.Event, want int) []dump.Event {
+	tb.Helper()
+
+	var events []dump.Event
+	Eventually(tb, "events", func() bool {
+		events = source()
+		return len(events) >= want
+	})
+	return events
+}
+
+// ReadStore returns every event committed to a store session log.
+func ReadStore(tb testing.TB, path string) []dump.Event {
+	tb.Helper()
+
+	var events []dump.Event
+	if err := store.ReadSession(path, func(event dump.Event) error {
+		events = append(events, event)
+		return nil
+	}); err != nil {
+		tb.Fatalf("store.ReadSession(%q) error = %v", path, err)
+	}
+	return events
+}
+
+// IDs returns the events' IDs, sorted. Ingest is sharded, so tests should
+// not depend on arrival order across requests.
+func IDs(events []dump.Event) []string {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// RequireIDs fails the test unless events are exactly the want IDs, in any
+// order.
+func RequireIDs(tb testing.TB, events []dump.Event, want ...string) {
+	tb.Helper()
+
+	want = slices.Clone(want)
+	slices.Sort(want)
+	if got := IDs(events); !slices.Equal(got, want) {
+		tb.Fatalf("event IDs = %v, want %v", got, want)
+	}
+}
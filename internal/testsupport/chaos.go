@@ -0,0 +1,92 @@
+package testsupport
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// Chaos describes a badly behaved network client. The zero value behaves
+// like Send.
+type Chaos struct {
+	// ChunkBytes splits writes into pieces of at most this many bytes, so
+	// lines arrive in several reads (partial writes).
+	ChunkBytes int
+	// Delay pauses between chunks, like a slow or congested client.
+	Delay time.Duration
+	// CutAfter closes the connection after this many bytes, usually in the
+	// middle of a line (abrupt disconnect, half-written line).
+	CutAfter int
+	// Connections spreads the events round-robin over this many concurrent
+	// connections, so their chunks interleave at the listener.
+	Connections int
+}
+
+// SendChaos delivers events as NDJSON the way chaos describes. It returns
+// the events whose lines were written completely; with CutAfter set, the
+// rest were lost or torn on purpose.
+func SendChaos(network string, address string, events []dump.Event, chaos Chaos) ([]dump.Event, error) {
+	connections := max(chaos.Connections, 1)
+	groups := make([][]dump.Event, connections)
+	for i, event := range events {
+		groups[i%connections] = append(groups[i%connections], event)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var delivered []dump.Event
+	var firstErr error
+	for _, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sent, err := sendChaos(network, address, group, chaos)
+			mu.Lock()
+			defer mu.Unlock()
+			delivered = append(delivered, sent...)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}()
+	}
+	wg.Wait()
+	return delivered, firstErr
+}
+
+func sendChaos(network string, address string, events []dump.Event, chaos Chaos) ([]dump.Event, error) {
+	conn, err := net.DialTimeout(network, address, 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var delivered []dump.Event
+	written := 0
+	for _, event := range events {
+		line := NDJSON([]dump.Event{event})
+		for len(line) > 0 {
+			n := len(line)
+			if chaos.ChunkBytes > 0 {
+				n = min(n, chaos.ChunkBytes)
+			}
+			if chaos.CutAfter > 0 {
+				n = min(n, chaos.CutAfter-written)
+				if n <= 0 {
+					return delivered, nil
+				}
+			}
+			if _, err := conn.Write(line[:n]); err != nil {
+				return delivered, err
+			}
+			written += n
+			line = line[n:]
+			if chaos.Delay > 0 {
+				time.Sleep(chaos.Delay)
+			}
+		}
+		delivered = append(delivered, event)
+	}
+	return delivered, nil
+}
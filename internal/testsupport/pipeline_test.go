@@ -0,0 +1,156 @@
+package testsupport_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"phant/internal/collector"
+	"phant/internal/dump"
+	"phant/internal/export"
+	"phant/internal/query"
+	"phant/internal/ray"
+	"phant/internal/store"
+	"phant/internal/tail"
+	"phant/internal/testsupport"
+)
+
+// pipeline wires the ingest paths to the collector and the collector to a
+// store, as the services layer does, without the desktop runtime.
+type pipeline struct {
+	socket    string
+	collector *collector.Server
+	store     *store.Store
+	ray       *ray.Server
+	follower  *tail.Follower
+	dumpFile  string
+}
+
+func startPipeline(t *testing.T) *pipeline {
+	t.Helper()
+
+	dir := t.TempDir()
+	p := &pipeline{socket: filepath.Join(dir, "collector.sock"), dumpFile: filepath.Join(dir, "dumps.ndjson")}
+
+	p.collector = collector.NewServer(p.socket, 100)
+	if err := p.collector.Start(); err != nil {
+		t.Fatalf("collector.Start() error = %v", err)
+	}
+	t.Cleanup(func() { p.collector.Stop() })
+
+	var err error
+	p.store, err = store.Open(filepath.Join(dir, "sessions"), store.Options{FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("store.Open() error = %v", err)
+	}
+	t.Cleanup(func() { p.store.Close() })
+
+	subID, feed := p.collector.Subscribe(100)
+	t.Cleanup(func() { p.collector.Unsubscribe(subID) })
+	go func() {
+		for event := range feed {
+			p.store.Append(event)
+		}
+	}()
+
+	p.ray = ray.NewServer("127.0.0.1:0", p.collector.Ingest, nil)
+	p.ray.SetSettleWindow(20 * time.Millisecond)
+	if err := p.ray.Start(); err != nil {
+		t.Fatalf("ray.Start() error = %v", err)
+	}
+	t.Cleanup(func() { p.ray.Stop() })
+
+	if err := os.WriteFile(p.dumpFile, nil, 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	p.follower = tail.NewFollower(p.dumpFile, nil, p.collector.Ingest)
+	if err := p.follower.Start(); err != nil {
+		t.Fatalf("follower.Start() error = %v", err)
+	}
+	t.Cleanup(p.follower.Stop)
+
+	return p
+}
+
+// committed waits until the store holds want events and returns them.
+func (p *pipeline) committed(t *testing.T, want int) []dump.Event {
+	t.Helper()
+
+	return testsupport.WaitForEvents(t, func() []dump.Event {
+		if err := p.store.Flush(); err != nil {
+			t.Fatalf("store.Flush() error = %v", err)
+		}
+		if p.store.Stats().Committed == 0 {
+			return nil
+		}
+		return testsupport.ReadStore(t, p.store.Path())
+	}, want)
+}
+
+func TestPipeline_ListenersToStoreQueryAndExport(t *testing.T) {
+	p := startPipeline(t)
+	producer := testsupport.NewProducer("/srv/app")
+
+	checkout := producer.HTTP("POST", "/checkout").
+		At("/srv/app/app/Cart.php", 12).Dump("cart", map[string]int{"items": 2}).
+		Log("warning", "payments", "card declined").
+		Exception("RuntimeException", "gateway timeout").
+		Events()
+	if err := testsupport.Send("unix", p.socket, checkout); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	migrate := producer.CLI("artisan", "migrate").Dump("batch", 3).Events()
+	if err := testsupport.AppendFile(p.dumpFile, migrate); err != nil {
+		t.Fatalf("AppendFile() error = %v", err)
+	}
+
+	fromRay := producer.CLI("worker").Dump("job", map[string]string{"queue": "mail"}).Events()
+	if err := testsupport.PostRay(p.ray.Address(), fromRay); err != nil {
+		t.Fatalf("PostRay() error = %v", err)
+	}
+
+	stored := p.committed(t, 5)
+	testsupport.RequireIDs(t, stored, "evt-1", "evt-2", "evt-3", "evt-4", "evt-5")
+
+	matcher, err := query.Filter{HTTPMethod: "POST", HTTPPathPrefix: "/checkout"}.Compile()
+	if err != nil {
+		t.Fatalf("Filter.Compile() error = %v", err)
+	}
+	var request []dump.Event
+	for _, event := range stored {
+		if matcher.Match(event) {
+			request = append(request, event)
+		}
+	}
+	testsupport.RequireIDs(t, request, "evt-1", "evt-2", "evt-3")
+
+	var archive bytes.Buffer
+	if _, err := export.Write(&archive, request, export.Timeline{}, testsupport.Epoch); err != nil {
+		t.Fatalf("export.Write() error = %v", err)
+	}
+	manifest, result, err := export.Read(&archive, dump.StreamOptions{})
+	if err != nil || len(result.Errors) > 0 {
+		t.Fatalf("export.Read() error = %v, line errors = %v", err, result.Errors)
+	}
+	if manifest.Events != 3 {
+		t.Fatalf("export manifest events = %d, want 3", manifest.Events)
+	}
+	testsupport.RequireIDs(t, result.Events, "evt-1", "evt-2", "evt-3")
+}
+
+func TestPipeline_CollectorRejectsInvalidLinesAndKeepsGoing(t *testing.T) {
+	p := startPipeline(t)
+	producer := testsupport.NewProducer("/srv/app")
+
+	events := producer.CLI("artisan").Dump("ok", true).DD("bye").Events()
+	events[0].Host.PID = 0
+	if err := testsupport.Send("unix", p.socket, events); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	testsupport.RequireIDs(t, p.committed(t, 1), "evt-2")
+	testsupport.RequireIDs(t, p.collector.Events(), "evt-2")
+}
@@ -0,0 +1,156 @@
+// Package testsupport drives phant's ingest paths the way a PHP application
+// would, for integration tests that run listener → decode → store → query →
+// export as one pipeline.
+package testsupport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// Epoch is the timestamp of a producer's first event. Each later event is
+// one millisecond after the previous one, so tests can assert on order and
+// time ranges without depending on the wall clock.
+var Epoch = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+// Producer is a fake PHP process. It builds schema-valid events the way the
+// prepend hook would; transports in this package deliver them.
+type Producer struct {
+	ProjectRoot string
+	Host        dump.HostMeta
+
+	mu       sync.Mutex
+	events   int
+	requests int
+}
+
+func NewProducer(projectRoot string) *Producer {
+	return &Producer{
+		ProjectRoot: projectRoot,
+		Host:        dump.HostMeta{Hostname: "test-host", PID: 4242},
+	}
+}
+
+// Script is a sequence of events emitted from one PHP execution: an HTTP
+// request or a CLI command. Events share the execution's metadata and the
+// callsite set with At.
+type Script struct {
+	producer *Producer
+	base     dump.Event
+	events   []dump.Event
+}
+
+// HTTP starts a script for a web request. All of its events share a
+// generated request ID.
+func (p *Producer) HTTP(method string, path string) *Script {
+	p.mu.Lock()
+	p.requests++
+	requestID := fmt.Sprintf("req-%d", p.requests)
+	p.mu.Unlock()
+
+	return p.script(dump.Event{
+		SourceType: "http",
+		PHPSAPI:    "fpm-fcgi",
+		RequestID:  &requestID,
+		HTTP:       &dump.HTTPMeta{Method: method, Scheme: "https", Host: "app.test", Path: path},
+	})
+}
+
+// CLI starts a script for a console command.
+func (p *Producer) CLI(command string, args ...string) *Script {
+	return p.script(dump.Event{
+		SourceType: "cli",
+		PHPSAPI:    "cli",
+		Command:    &dump.CommandMeta{Name: command, Args: args},
+	})
+}
+
+func (p *Producer) script(base dump.Event) *Script {
+	base.SchemaVersion = dump.SchemaVersion
+	base.OriginalSchemaVersion = dump.SchemaVersion
+	base.ProjectRoot = p.ProjectRoot
+	base.Host = p.Host
+	base.Trace = []dump.TraceFrame{{File: p.ProjectRoot + "/app/Http/Controller.php", Line: 1}}
+	return &Script{producer: p, base: base}
+}
+
+// At sets the callsite of the events added after it.
+func (s *Script) At(file string, line int) *Script {
+	s.base.Trace = []dump.TraceFrame{{File: file, Line: line}}
+	return s
+}
+
+// Dump adds a dump() call. payload is marshalled as JSON.
+func (s *Script) Dump(label string, payload any) *Script {
+	event := s.next()
+	event.Label = label
+	event.Payload = mustMarshal(payload)
+	return s.add(event)
+}
+
+// DD adds a dd() call, which ends the execution in PHP; the script keeps
+// going so tests can model whatever they need.
+func (s *Script) DD(payload any) *Script {
+	event := s.next()
+	event.IsDD = true
+	event.Payload = mustMarshal(payload)
+	return s.add(event)
+}
+
+// Log adds a PSR-3 log record.
+func (s *Script) Log(level string, channel string, message string) *Script {
+	event := s.next()
+	event.SourceType = "log"
+	event.Level = level
+	event.Log = &dump.LogMeta{Channel: channel, Message: message}
+	event.Payload = mustMarshal(map[string]any{"message": message})
+	return s.add(event)
+}
+
+// Exception adds a reported Throwable thrown at the current callsite.
+func (s *Script) Exception(class string, message string) *Script {
+	event := s.next()
+	event.IsError = true
+	event.Level = "error"
+	frame := event.Trace[0]
+	event.Exception = &dump.ExceptionMeta{Class: class, Message: message, File: frame.File, Line: frame.Line}
+	event.Payload = mustMarshal(message)
+	return s.add(event)
+}
+
+// Events returns the script's events in the order they were added.
+func (s *Script) Events() []dump.Event {
+	return append([]dump.Event(nil), s.events...)
+}
+
+func (s *Script) next() dump.Event {
+	p := s.producer
+	p.mu.Lock()
+	seq := p.events
+	p.events++
+	p.mu.Unlock()
+
+	event := s.base
+	event.ID = fmt.Sprintf("evt-%d", seq+1)
+	event.Timestamp = Epoch.Add(time.Duration(seq) * time.Millisecond).Format(time.RFC3339Nano)
+	event.PayloadFormat = dump.PayloadFormatJSON
+	event.Trace = append([]dump.TraceFrame(nil), s.base.Trace...)
+	return event
+}
+
+func (s *Script) add(event dump.Event) *Script {
+	s.events = append(s.events, event)
+	return s
+}
+
+func mustMarshal(value any) json.RawMessage {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Sprintf("testsupport: payload is not JSON: %v", err))
+	}
+	return data
+}
@@ -0,0 +1,90 @@
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"phant/internal/dump"
+	"phant/internal/ray"
+)
+
+// NDJSON encodes events the way the prepend hook writes them: one JSON
+// object per line.
+func NDJSON(events []dump.Event) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			panic(fmt.Sprintf("testsupport: encode event %s: %v", event.ID, err))
+		}
+	}
+	return buf.Bytes()
+}
+
+// Send writes events as NDJSON over a stream connection: "unix" for the
+// collector socket, "tcp" for socket-based listeners.
+func Send(network string, address string, events []dump.Event) error {
+	conn, err := net.DialTimeout(network, address, 2*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write(NDJSON(events))
+	return err
+}
+
+// AppendFile appends events to an NDJSON log, creating it if needed, as a
+// producer configured with a dump file would.
+func AppendFile(path string, events []dump.Event) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(NDJSON(events)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// PostRay sends each event to a Ray-compatible HTTP listener as a ray() call
+// with chained label and color modifiers. The event ID becomes the Ray UUID.
+// Ray carries no execution metadata, so only the payload, callsite, host,
+// label, and color survive.
+func PostRay(address string, events []dump.Event) error {
+	for _, event := range events {
+		origin := ray.Origin{Function: "ray", Hostname: event.Host.Hostname}
+		if len(event.Trace) > 0 {
+			origin.File = event.Trace[0].File
+			origin.Line = event.Trace[0].Line
+		}
+
+		payloads := []ray.Payload{{Type: "log", Content: mustMarshal(map[string]any{"values": []json.RawMessage{event.Payload}}), Origin: origin}}
+		if event.Label != "" {
+			payloads = append(payloads, ray.Payload{Type: "label", Content: mustMarshal(map[string]string{"label": event.Label}), Origin: origin})
+		}
+		if event.Color != "" {
+			payloads = append(payloads, ray.Payload{Type: "color", Content: mustMarshal(map[string]string{"color": event.Color}), Origin: origin})
+		}
+
+		for _, payload := range payloads {
+			body := mustMarshal(ray.Request{UUID: event.ID, Payloads: []ray.Payload{payload}, Meta: json.RawMessage(`{}`)})
+			response, err := http.Post("http://"+address+"/", "application/json", bytes.NewReader(body))
+			if err != nil {
+				return err
+			}
+			response.Body.Close()
+			if response.StatusCode >= 300 {
+				return fmt.Errorf("ray listener answered %s for %s", response.Status, event.ID)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Package trash keeps cleared events for an undo window before they are
+// deleted for good, so clearing the wrong project or time range can be
+// taken back.
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	DefaultWindow = 30 * time.Second
+	MaxWindow     = time.Hour
+)
+
+var (
+	ErrWindowRange = fmt.Errorf("undo window must be between 0 and %s", MaxWindow)
+	ErrNotHeld     = errors.New("cleared events are no longer held")
+)
+
+// Batch is the events one clear removed. ExpiresAt is when they are purged;
+// a batch that is no longer held cannot be restored.
+type Batch struct {
+	ID        string `json:"id"`
+	Count     int    `json:"count"`
+	ClearedAt string `json:"clearedAt"`
+	ExpiresAt string `json:"expiresAt"`
+
+	events  []dump.Event
+	expires time.Time
+}
+
+// Events returns the cleared events in the order they were buffered.
+func (b Batch) Events() []dump.Event {
+	return b.events
+}
+
+// Bin holds cleared batches until their undo window passes. A zero window
+// keeps nothing, so clearing is permanent at once.
+type Bin struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	window  time.Duration
+	batches []Batch
+	next    uint64
+}
+
+func New(window time.Duration) *Bin {
+	return &Bin{now: time.Now, window: window}
+}
+
+func (b *Bin) Window() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.window
+}
+
+// SetWindow changes the window for batches cleared from now on; batches
+// already held keep their expiry.
+func (b *Bin) SetWindow(window time.Duration) error {
+	if window < 0 || window > MaxWindow {
+		return ErrWindowRange
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.window = window
+	return nil
+}
+
+// Put holds events as a new batch and returns it. With a zero window the
+// batch is returned already expired and is not held.
+func (b *Bin) Put(events []dump.Event) Batch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.next++
+	batch := Batch{
+		ID:        "clear-" + strconv.FormatUint(b.next, 10),
+		Count:     len(events),
+		ClearedAt: now.UTC().Format(time.RFC3339Nano),
+		events:    events,
+		expires:   now.Add(b.window),
+	}
+	batch.ExpiresAt = batch.expires.UTC().Format(time.RFC3339Nano)
+	if b.window > 0 && len(events) > 0 {
+		b.batches = append(b.batches, batch)
+	}
+	return batch
+}
+
+// Take removes a held batch, to restore its events or to purge it before
+// its window passes.
+func (b *Bin) Take(id string) (Batch, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, batch := range b.batches {
+		if batch.ID == id {
+			b.batches = append(b.batches[:i], b.batches[i+1:]...)
+			return batch, nil
+		}
+	}
+	return Batch{}, ErrNotHeld
+}
+
+// List returns the held batches, oldest first.
+func (b *Bin) List() []Batch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Batch{}, b.batches...)
+}
+
+// Purge deletes the batches whose window has passed and returns them.
+func (b *Bin) Purge() []Batch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	var purged []Batch
+	kept := b.batches[:0]
+	for _, batch := range b.batches {
+		if now.Before(batch.expires) {
+			kept = append(kept, batch)
+		} else {
+			purged = append(purged, batch)
+		}
+	}
+	clear(b.batches[len(kept):])
+	b.batches = kept
+	return purged
+}
+
+// Empty deletes every held batch without waiting for its window.
+func (b *Bin) Empty() []Batch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	purged := b.batches
+	b.batches = nil
+	return purged
+}
@@ -0,0 +1,64 @@
+package trash
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+func TestBin_HoldsBatchesUntilTheirWindowPasses(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bin := New(time.Minute)
+	bin.now = func() time.Time { return now }
+
+	first := bin.Put([]dump.Event{{ID: "1"}, {ID: "2"}})
+	now = now.Add(30 * time.Second)
+	second := bin.Put([]dump.Event{{ID: "3"}})
+	if first.Count != 2 || first.ExpiresAt != "2024-01-01T00:01:00Z" {
+		t.Fatalf("Put() = %+v, want 2 events expiring at 00:01:00", first)
+	}
+
+	now = now.Add(30 * time.Second)
+	purged := bin.Purge()
+	if len(purged) != 1 || purged[0].ID != first.ID {
+		t.Fatalf("Purge() = %+v, want [%s]", purged, first.ID)
+	}
+	if _, err := bin.Take(first.ID); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("Take(purged) error = %v, want %v", err, ErrNotHeld)
+	}
+
+	taken, err := bin.Take(second.ID)
+	if err != nil || len(taken.Events()) != 1 || taken.Events()[0].ID != "3" {
+		t.Fatalf("Take() = %+v, %v, want the event 3", taken, err)
+	}
+	if held := bin.List(); len(held) != 0 {
+		t.Fatalf("List() = %+v, want none", held)
+	}
+}
+
+func TestBin_ZeroWindowHoldsNothing(t *testing.T) {
+	bin := New(0)
+
+	batch := bin.Put([]dump.Event{{ID: "1"}})
+	if batch.Count != 1 {
+		t.Fatalf("Put().Count = %d, want %d", batch.Count, 1)
+	}
+	if held := bin.List(); len(held) != 0 {
+		t.Fatalf("List() = %+v, want none", held)
+	}
+}
+
+func TestBin_SetWindowRejectsOutOfRange(t *testing.T) {
+	bin := New(DefaultWindow)
+
+	for _, window := range []time.Duration{-time.Second, MaxWindow + time.Second} {
+		if err := bin.SetWindow(window); !errors.Is(err, ErrWindowRange) {
+			t.Fatalf("SetWindow(%s) error = %v, want %v", window, err, ErrWindowRange)
+		}
+	}
+	if got := bin.Window(); got != DefaultWindow {
+		t.Fatalf("Window() = %s, want %s", got, DefaultWindow)
+	}
+}
@@ -0,0 +1,383 @@
+package vardumper
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"phant/internal/dump"
+)
+
+// Stub types and array classes from Symfony\Component\VarDumper\Cloner\Stub.
+const (
+	stubTypeRef      = 1
+	stubTypeString   = 2
+	stubTypeArray    = 3
+	stubTypeObject   = 4
+	stubTypeResource = 5
+	stubTypeScalar   = 6
+
+	stubArrayIndexed = 2
+)
+
+const maxConvertDepth = 64
+
+var ErrInvalidMessage = errors.New("not a var-dumper server message")
+
+// Decode converts one line sent by symfony/var-dumper's ServerDumper, a
+// base64-encoded serialize([$data, $context]), into a dump event. The
+// protocol carries no process metadata, so host is supplied by the caller.
+func Decode(line string, host dump.HostMeta) (dump.Event, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(line))
+	if err != nil {
+		return dump.Event{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	value, err := unserialize(raw)
+	if err != nil {
+		return dump.Event{}, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	message, ok := value.(*phpArray)
+	if !ok || len(message.Values) < 2 {
+		return dump.Event{}, ErrInvalidMessage
+	}
+	data, ok := message.Values[0].(*phpObject)
+	if !ok || !strings.HasSuffix(data.Class, `\Data`) {
+		return dump.Event{}, ErrInvalidMessage
+	}
+	context, ok := message.Values[1].(*phpArray)
+	if !ok {
+		return dump.Event{}, ErrInvalidMessage
+	}
+
+	payload, err := json.Marshal(convertData(data))
+	if err != nil {
+		return dump.Event{}, err
+	}
+
+	event := dump.Event{
+		SchemaVersion:         dump.SchemaVersion,
+		OriginalSchemaVersion: dump.SchemaVersion,
+		ID:                    newEventID(),
+		PayloadFormat:         dump.PayloadFormatJSON,
+		Payload:               payload,
+		Trace:                 []dump.TraceFrame{},
+		Host:                  host,
+	}
+	applyContext(&event, context)
+	return event, nil
+}
+
+func applyContext(event *dump.Event, context *phpArray) {
+	timestamp := time.Now()
+	if value, ok := context.get("timestamp"); ok {
+		if seconds, ok := number(value); ok {
+			whole, frac := math.Modf(seconds)
+			timestamp = time.Unix(int64(whole), int64(frac*1e9))
+		}
+	}
+	event.Timestamp = timestamp.UTC().Format(time.RFC3339Nano)
+
+	if source, ok := arrayField(context, "source"); ok {
+		file := stringField(source, "file")
+		if file != "" {
+			line, _ := source.get("line")
+			n, _ := number(line)
+			event.Trace = []dump.TraceFrame{{File: file, Line: int(n)}}
+		}
+		event.ProjectRoot = stringField(source, "project_dir")
+		if event.ProjectRoot == "" && file != "" {
+			event.ProjectRoot = filepath.Dir(file)
+		}
+	}
+
+	if request, ok := arrayField(context, "request"); ok {
+		event.SourceType = "http"
+		event.PHPSAPI = "fpm-fcgi"
+		event.HTTP = httpMeta(stringField(request, "method"), stringField(request, "uri"))
+		if identifier := stringField(request, "identifier"); identifier != "" {
+			event.RequestID = &identifier
+		}
+	} else {
+		event.SourceType = "cli"
+		event.PHPSAPI = "cli"
+		event.Command = &dump.CommandMeta{Name: "php"}
+		if cli, ok := arrayField(context, "cli"); ok {
+			if fields := strings.Fields(stringField(cli, "command_line")); len(fields) > 0 {
+				event.Command = &dump.CommandMeta{Name: fields[0], Args: fields[1:]}
+			}
+		}
+	}
+
+	if event.ProjectRoot == "" {
+		event.ProjectRoot = "/"
+	}
+	if event.Host.PID <= 0 {
+		event.Warnings = append(event.Warnings, dump.Warning{
+			Field:   "host.pid",
+			Message: "var-dumper server messages do not carry a process id",
+		})
+	}
+	if len(event.Trace) == 0 {
+		event.Warnings = append(event.Warnings, dump.Warning{
+			Field:   "trace",
+			Message: "trace is empty; the dump callsite is unknown",
+		})
+	}
+}
+
+func httpMeta(method string, uri string) *dump.HTTPMeta {
+	meta := &dump.HTTPMeta{Method: method, Host: "unknown"}
+	if meta.Method == "" {
+		meta.Method = "GET"
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		meta.Path = uri
+		return meta
+	}
+	meta.Scheme = parsed.Scheme
+	if parsed.Host != "" {
+		meta.Host = parsed.Host
+	}
+	meta.Path = parsed.Path
+	meta.Query = parsed.RawQuery
+	return meta
+}
+
+// convertData walks the flattened item tables of a cloned Data object back
+// into a JSON-friendly tree rooted at data[position][key].
+func convertData(data *phpObject) any {
+	tablesValue, _ := data.prop("data")
+	tables, ok := tablesValue.(*phpArray)
+	if !ok {
+		return nil
+	}
+
+	position, key := 0, "0"
+	if value, ok := data.prop("position"); ok {
+		if n, ok := number(value); ok {
+			position = int(n)
+		}
+	}
+	if value, ok := data.prop("key"); ok && value != nil {
+		key = fmt.Sprint(value)
+	}
+
+	c := &converter{tables: tables, visiting: map[int]bool{}}
+	root, ok := c.table(position)
+	if !ok {
+		return nil
+	}
+	item, _ := root.get(key)
+	return c.item(item, 0)
+}
+
+type converter struct {
+	tables   *phpArray
+	visiting map[int]bool
+}
+
+func (c *converter) table(position int) (*phpArray, bool) {
+	value, ok := c.tables.get(strconv.Itoa(position))
+	if !ok {
+		return nil, false
+	}
+	table, ok := value.(*phpArray)
+	return table, ok
+}
+
+func (c *converter) item(value any, depth int) any {
+	if depth > maxConvertDepth {
+		return "…"
+	}
+
+	switch v := value.(type) {
+	case *phpObject:
+		return c.stub(v, depth)
+	case *phpArray:
+		return c.members(v, false, depth)
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+	return value
+}
+
+// stub converts a Cloner\Stub. Stub::__sleep() only serializes properties
+// that differ from their defaults, so every lookup falls back to them.
+func (c *converter) stub(stub *phpObject, depth int) any {
+	if !strings.HasSuffix(stub.Class, "Stub") {
+		return orderedObject{{Key: "@class", Value: stub.Class}}
+	}
+
+	kind := intProp(stub, "type", stubTypeRef)
+	class, _ := stub.prop("class")
+	className, _ := class.(string)
+	value, _ := stub.prop("value")
+	cut := intProp(stub, "cut", 0)
+	position := intProp(stub, "position", 0)
+
+	switch kind {
+	case stubTypeString:
+		text := fmt.Sprint(value)
+		if cut > 0 {
+			text += "…"
+		}
+		return text
+	case stubTypeArray:
+		indexed := intProp(stub, "class", 0) == stubArrayIndexed
+		return withCut(c.children(position, indexed, depth), cut)
+	case stubTypeObject:
+		object := orderedObject{{Key: "@class", Value: className}}
+		if children, ok := c.children(position, false, depth).(orderedObject); ok {
+			object = append(object, children...)
+		}
+		return withCut(object, cut)
+	case stubTypeResource:
+		return orderedObject{{Key: "@resource", Value: className}}
+	case stubTypeRef, stubTypeScalar:
+		if position > 0 {
+			return c.children(position, false, depth)
+		}
+		return c.item(value, depth+1)
+	}
+	return c.item(value, depth+1)
+}
+
+func (c *converter) children(position int, indexed bool, depth int) any {
+	table, ok := c.table(position)
+	if position <= 0 || !ok || c.visiting[position] {
+		if indexed {
+			return []any{}
+		}
+		return orderedObject{}
+	}
+
+	c.visiting[position] = true
+	defer delete(c.visiting, position)
+	return c.members(table, indexed, depth)
+}
+
+func (c *converter) members(array *phpArray, indexed bool, depth int) any {
+	if indexed {
+		items := make([]any, 0, len(array.Values))
+		for _, value := range array.Values {
+			items = append(items, c.item(value, depth+1))
+		}
+		return items
+	}
+
+	object := make(orderedObject, 0, len(array.Values))
+	for i, key := range array.Keys {
+		name := fmt.Sprint(key)
+		if s, ok := key.(string); ok {
+			name = propertyName(s)
+		}
+		object = append(object, member{Key: name, Value: c.item(array.Values[i], depth+1)})
+	}
+	return object
+}
+
+func withCut(value any, cut int) any {
+	if cut <= 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case []any:
+		return append(v, fmt.Sprintf("… %d more", cut))
+	case orderedObject:
+		return append(v, member{Key: "…", Value: cut})
+	}
+	return value
+}
+
+type member struct {
+	Key   string
+	Value any
+}
+
+// orderedObject keeps PHP's key order, which a Go map would lose.
+type orderedObject []member
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func intProp(object *phpObject, name string, fallback int) int {
+	value, ok := object.prop(name)
+	if !ok {
+		return fallback
+	}
+	n, ok := number(value)
+	if !ok {
+		return fallback
+	}
+	return int(n)
+}
+
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func arrayField(array *phpArray, key string) (*phpArray, bool) {
+	value, ok := array.get(key)
+	if !ok {
+		return nil, false
+	}
+	field, ok := value.(*phpArray)
+	return field, ok
+}
+
+func stringField(array *phpArray, key string) string {
+	value, _ := array.get(key)
+	text, _ := value.(string)
+	return text
+}
+
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
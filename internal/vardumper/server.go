@@ -0,0 +1,208 @@
+package vardumper
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"phant/internal/capture"
+	"phant/internal/dump"
+	"phant/internal/netauth"
+	"phant/internal/netline"
+)
+
+// DefaultAddress matches the VAR_DUMPER_SERVER default used by
+// `bin/console server:dump`.
+const DefaultAddress = "127.0.0.1:9912"
+
+type Stats struct {
+	Address  string `json:"address"`
+	Received uint64 `json:"received"`
+	Rejected uint64 `json:"rejected"`
+	// Unauthorized counts connections closed for a missing or wrong
+	// handshake.
+	Unauthorized uint64 `json:"unauthorized"`
+}
+
+// Server accepts connections from symfony/var-dumper's ServerDumper and
+// hands every decoded dump to handle.
+type Server struct {
+	address string
+	handle  func(dump.Event)
+	host    dump.HostMeta
+	auth    *netauth.Guard
+	tls     *tls.Config
+	capture func() *capture.Recorder
+
+	received     atomic.Uint64
+	rejected     atomic.Uint64
+	unauthorized atomic.Uint64
+
+	listener net.Listener
+	stopOnce sync.Once
+	stopped  chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewServer(address string, handle func(dump.Event)) *Server {
+	if address == "" {
+		address = DefaultAddress
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "localhost"
+	}
+
+	return &Server{
+		address: address,
+		handle:  handle,
+		host:    dump.HostMeta{Hostname: hostname},
+		stopped: make(chan struct{}),
+	}
+}
+
+// SetAuth makes every connection open with the guard's handshake line. It
+// must be called before Start.
+func (s *Server) SetAuth(guard *netauth.Guard) {
+	s.auth = guard
+}
+
+// SetTLS makes the listener serve TLS with config. It must be called
+// before Start.
+func (s *Server) SetTLS(config *tls.Config) {
+	s.tls = config
+}
+
+// SetCapture records connections while recorder returns a recorder. It
+// must be called before Start.
+func (s *Server) SetCapture(recorder func() *capture.Recorder) {
+	s.capture = recorder
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+	if s.tls != nil {
+		listener = tls.NewListener(listener, s.tls)
+	}
+	if s.capture != nil {
+		listener = capture.Tap(listener, capture.ListenerVarDumper, s.capture)
+	}
+
+	s.listener = listener
+	s.wg.Add(1)
+	go s.acceptLoop()
+	return nil
+}
+
+func (s *Server) Stop() error {
+	var closeErr error
+
+	s.stopOnce.Do(func() {
+		if s.listener != nil {
+			closeErr = s.listener.Close()
+		}
+		close(s.stopped)
+		s.wg.Wait()
+	})
+
+	if errors.Is(closeErr, net.ErrClosed) {
+		return nil
+	}
+	return closeErr
+}
+
+// Address returns the bound address, which differs from the configured one
+// when listening on port 0.
+func (s *Server) Address() string {
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
+	return s.address
+}
+
+func (s *Server) Stats() Stats {
+	return Stats{
+		Address:      s.Address(),
+		Received:     s.received.Load(),
+		Rejected:     s.rejected.Load(),
+		Unauthorized: s.unauthorized.Load(),
+	}
+}
+
+// ServeConn handles conn as if the listener had accepted it, returning
+// once it ends. Replayed captures arrive this way.
+func (s *Server) ServeConn(conn net.Conn) {
+	s.wg.Add(1)
+	s.handleConn(conn)
+}
+
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.stopped:
+				return
+			default:
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				continue
+			}
+		}
+
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+// handleConn reads one message per line. ServerDumper keeps its socket open
+// across dumps and never reads, so nothing is written back.
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-s.stopped:
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// Base64 inflates dumps by a third, so lines may be longer than NDJSON.
+	handshake := s.auth.Expect(conn)
+	result := netline.Read(conn, netline.Options{MaxLineBytes: 16 * 1024 * 1024}, func(line []byte) {
+		if len(line) == 0 || !handshake.Admit(line) {
+			return
+		}
+
+		event, err := Decode(string(line), s.host)
+		if err != nil {
+			s.rejected.Add(1)
+			return
+		}
+
+		s.received.Add(1)
+		s.handle(event)
+	})
+	if handshake.Done() {
+		s.unauthorized.Add(1)
+		return
+	}
+	s.rejected.Add(uint64(result.Oversized))
+	if result.Torn {
+		s.rejected.Add(1)
+	}
+}
@@ -0,0 +1,294 @@
+package vardumper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const maxUnserializeDepth = 512
+
+// phpArray is an ordered PHP array; keys are int64 or string.
+type phpArray struct {
+	Keys   []any
+	Values []any
+}
+
+func (a *phpArray) get(key string) (any, bool) {
+	for i, k := range a.Keys {
+		if fmt.Sprint(k) == key {
+			return a.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+type phpObject struct {
+	Class string
+	Names []string
+	Props []any
+}
+
+// prop looks a property up by name, ignoring the visibility prefix PHP adds
+// to private ("\0Class\0name") and protected ("\0*\0name") properties.
+func (o *phpObject) prop(name string) (any, bool) {
+	for i, raw := range o.Names {
+		if propertyName(raw) == name {
+			return o.Props[i], true
+		}
+	}
+	return nil, false
+}
+
+func propertyName(raw string) string {
+	for i := len(raw) - 1; i >= 0; i-- {
+		if raw[i] == 0 {
+			return raw[i+1:]
+		}
+	}
+	return raw
+}
+
+type unserializer struct {
+	data  []byte
+	pos   int
+	slots []any
+	depth int
+}
+
+// unserialize decodes PHP's serialize() format into nil, bool, int64,
+// float64, string, *phpArray, and *phpObject values.
+func unserialize(data []byte) (any, error) {
+	u := &unserializer{data: data}
+	value, err := u.value(true)
+	if err != nil {
+		return nil, fmt.Errorf("unserialize at byte %d: %w", u.pos, err)
+	}
+	return value, nil
+}
+
+func (u *unserializer) value(track bool) (any, error) {
+	if u.pos+1 >= len(u.data) {
+		return nil, errors.New("unexpected end of input")
+	}
+
+	u.depth++
+	defer func() { u.depth-- }()
+	if u.depth > maxUnserializeDepth {
+		return nil, errors.New("nesting too deep")
+	}
+
+	kind := u.data[u.pos]
+	slot := -1
+	if track && kind != 'R' {
+		slot = len(u.slots)
+		u.slots = append(u.slots, nil)
+	}
+	store := func(value any) any {
+		if slot >= 0 {
+			u.slots[slot] = value
+		}
+		return value
+	}
+
+	if kind == 'N' {
+		if err := u.expect("N;"); err != nil {
+			return nil, err
+		}
+		return store(nil), nil
+	}
+
+	if err := u.expect(string(kind) + ":"); err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case 'b':
+		n, err := u.integer(';')
+		if err != nil {
+			return nil, err
+		}
+		return store(n != 0), nil
+	case 'i':
+		n, err := u.integer(';')
+		if err != nil {
+			return nil, err
+		}
+		return store(n), nil
+	case 'd':
+		token, err := u.until(';')
+		if err != nil {
+			return nil, err
+		}
+		var f float64
+		switch token {
+		case "INF":
+			f = math.Inf(1)
+		case "-INF":
+			f = math.Inf(-1)
+		case "NAN":
+			f = math.NaN()
+		default:
+			if f, err = strconv.ParseFloat(token, 64); err != nil {
+				return nil, err
+			}
+		}
+		return store(f), nil
+	case 's':
+		text, err := u.quoted()
+		if err != nil {
+			return nil, err
+		}
+		if err := u.expect(";"); err != nil {
+			return nil, err
+		}
+		return store(text), nil
+	case 'E':
+		text, err := u.quoted()
+		if err != nil {
+			return nil, err
+		}
+		if err := u.expect(";"); err != nil {
+			return nil, err
+		}
+		return store(text), nil
+	case 'r', 'R':
+		n, err := u.integer(';')
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 || int(n) > len(u.slots) {
+			return nil, fmt.Errorf("invalid reference %d", n)
+		}
+		return store(u.slots[n-1]), nil
+	case 'a':
+		count, err := u.integer(':')
+		if err != nil {
+			return nil, err
+		}
+		array := &phpArray{}
+		store(array)
+		if err := u.members(count, func(key any, value any) {
+			array.Keys = append(array.Keys, key)
+			array.Values = append(array.Values, value)
+		}); err != nil {
+			return nil, err
+		}
+		return array, nil
+	case 'O':
+		class, err := u.quoted()
+		if err != nil {
+			return nil, err
+		}
+		if err := u.expect(":"); err != nil {
+			return nil, err
+		}
+		count, err := u.integer(':')
+		if err != nil {
+			return nil, err
+		}
+		object := &phpObject{Class: class}
+		store(object)
+		if err := u.members(count, func(key any, value any) {
+			object.Names = append(object.Names, fmt.Sprint(key))
+			object.Props = append(object.Props, value)
+		}); err != nil {
+			return nil, err
+		}
+		return object, nil
+	case 'C':
+		class, err := u.quoted()
+		if err != nil {
+			return nil, err
+		}
+		if err := u.expect(":"); err != nil {
+			return nil, err
+		}
+		length, err := u.integer(':')
+		if err != nil {
+			return nil, err
+		}
+		if err := u.expect("{"); err != nil {
+			return nil, err
+		}
+		end := u.pos + int(length)
+		if length < 0 || end+1 > len(u.data) || u.data[end] != '}' {
+			return nil, errors.New("invalid custom serialized object")
+		}
+		u.pos = end + 1
+		return store(&phpObject{Class: class}), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %q", kind)
+}
+
+func (u *unserializer) members(count int64, add func(key any, value any)) error {
+	if err := u.expect("{"); err != nil {
+		return err
+	}
+	for i := int64(0); i < count; i++ {
+		key, err := u.value(false)
+		if err != nil {
+			return err
+		}
+		switch key.(type) {
+		case int64, string:
+		default:
+			return fmt.Errorf("invalid key type %T", key)
+		}
+
+		value, err := u.value(true)
+		if err != nil {
+			return err
+		}
+		add(key, value)
+	}
+	return u.expect("}")
+}
+
+func (u *unserializer) expect(token string) error {
+	if u.pos+len(token) > len(u.data) || string(u.data[u.pos:u.pos+len(token)]) != token {
+		return fmt.Errorf("expected %q", token)
+	}
+	u.pos += len(token)
+	return nil
+}
+
+func (u *unserializer) until(delimiter byte) (string, error) {
+	for i := u.pos; i < len(u.data); i++ {
+		if u.data[i] == delimiter {
+			token := string(u.data[u.pos:i])
+			u.pos = i + 1
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("missing %q", delimiter)
+}
+
+func (u *unserializer) integer(delimiter byte) (int64, error) {
+	token, err := u.until(delimiter)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(token, 10, 64)
+}
+
+// quoted reads a length-prefixed `N:"bytes"` string; the length counts bytes,
+// so the content may contain quotes, newlines, and NUL bytes.
+func (u *unserializer) quoted() (string, error) {
+	length, err := u.integer(':')
+	if err != nil {
+		return "", err
+	}
+	if err := u.expect(`"`); err != nil {
+		return "", err
+	}
+	end := u.pos + int(length)
+	if length < 0 || end+1 > len(u.data) || u.data[end] != '"' {
+		return "", errors.New("invalid string length")
+	}
+	text := string(u.data[u.pos:end])
+	u.pos = end + 1
+	return text, nil
+}
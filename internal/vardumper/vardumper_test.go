@@ -0,0 +1,196 @@
+package vardumper
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"phant/internal/dump"
+)
+
+const (
+	stubClass = `Symfony\Component\VarDumper\Cloner\Stub`
+	dataClass = `Symfony\Component\VarDumper\Cloner\Data`
+)
+
+func phpString(s string) string {
+	return fmt.Sprintf("s:%d:\"%s\";", len(s), s)
+}
+
+func phpArrayOf(pairs ...string) string {
+	return fmt.Sprintf("a:%d:{%s}", len(pairs)/2, strings.Join(pairs, ""))
+}
+
+func phpObjectOf(class string, pairs ...string) string {
+	return fmt.Sprintf("O:%d:\"%s\":%d:{%s}", len(class), class, len(pairs)/2, strings.Join(pairs, ""))
+}
+
+func stub(pairs ...string) string {
+	return phpObjectOf(stubClass, pairs...)
+}
+
+func private(name string) string {
+	return phpString("\x00" + dataClass + "\x00" + name)
+}
+
+// userMessage mirrors what ServerDumper sends for
+// dump(['id' => 42, 'name' => 'Ada', 'tags' => ['a', 'b'], 'owner' => $user]).
+func userMessage(context string) string {
+	tables := phpArrayOf(
+		"i:0;", phpArrayOf("i:0;", stub(phpString("type"), "i:3;", phpString("class"), "i:1;", phpString("value"), "i:4;", phpString("position"), "i:1;")),
+		"i:1;", phpArrayOf(
+			phpString("id"), "i:42;",
+			phpString("name"), phpString("Ada"),
+			phpString("tags"), stub(phpString("type"), "i:3;", phpString("class"), "i:2;", phpString("value"), "i:2;", phpString("position"), "i:2;"),
+			phpString("owner"), stub(phpString("type"), "i:4;", phpString("class"), phpString(`App\User`), phpString("position"), "i:3;", phpString("cut"), "i:1;"),
+		),
+		"i:2;", phpArrayOf("i:0;", phpString("a"), "i:1;", phpString("b")),
+		"i:3;", phpArrayOf(phpString("\x00*\x00email"), phpString("ada@example.test"), phpString("active"), "b:1;"),
+	)
+	data := phpObjectOf(dataClass, private("data"), tables, private("maxDepth"), "i:20;")
+	serialized := phpArrayOf("i:0;", data, "i:1;", context)
+	return base64.StdEncoding.EncodeToString([]byte(serialized))
+}
+
+func TestDecode_ConvertsDataAndHTTPContext(t *testing.T) {
+	context := phpArrayOf(
+		phpString("timestamp"), "d:1700000000.5;",
+		phpString("source"), phpArrayOf(
+			phpString("name"), phpString("UserController.php"),
+			phpString("file"), phpString("/srv/app/src/UserController.php"),
+			phpString("line"), "i:27;",
+			phpString("project_dir"), phpString("/srv/app"),
+		),
+		phpString("request"), phpArrayOf(
+			phpString("identifier"), phpString("5f1c2e"),
+			phpString("method"), phpString("POST"),
+			phpString("uri"), phpString("https://example.test/users?page=2"),
+		),
+	)
+
+	event, err := Decode(userMessage(context), dump.HostMeta{Hostname: "devbox"})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	wantPayload := `{"id":42,"name":"Ada","tags":["a","b"],"owner":{"@class":"App\\User","email":"ada@example.test","active":true,"…":1}}`
+	if string(event.Payload) != wantPayload {
+		t.Fatalf("Decode() payload = %s, want %s", event.Payload, wantPayload)
+	}
+	if event.PayloadFormat != dump.PayloadFormatJSON {
+		t.Fatalf("Decode() payloadFormat = %q, want json", event.PayloadFormat)
+	}
+	if event.Timestamp != "2023-11-14T22:13:20.5Z" {
+		t.Fatalf("Decode() timestamp = %q, want 2023-11-14T22:13:20.5Z", event.Timestamp)
+	}
+	if event.SourceType != "http" || event.HTTP == nil {
+		t.Fatalf("Decode() sourceType = %q, http = %v, want http metadata", event.SourceType, event.HTTP)
+	}
+	if event.HTTP.Method != "POST" || event.HTTP.Host != "example.test" || event.HTTP.Path != "/users" || event.HTTP.Query != "page=2" {
+		t.Fatalf("Decode() http = %+v, want POST example.test/users?page=2", *event.HTTP)
+	}
+	if event.RequestID == nil || *event.RequestID != "5f1c2e" {
+		t.Fatalf("Decode() requestId = %v, want 5f1c2e", event.RequestID)
+	}
+	if event.ProjectRoot != "/srv/app" {
+		t.Fatalf("Decode() projectRoot = %q, want /srv/app", event.ProjectRoot)
+	}
+	if len(event.Trace) != 1 || event.Trace[0].File != "/srv/app/src/UserController.php" || event.Trace[0].Line != 27 {
+		t.Fatalf("Decode() trace = %v, want the dump callsite", event.Trace)
+	}
+	if event.Host.Hostname != "devbox" || event.ID == "" {
+		t.Fatalf("Decode() host = %+v, id = %q, want caller host and generated id", event.Host, event.ID)
+	}
+}
+
+func TestDecode_CLIContext(t *testing.T) {
+	context := phpArrayOf(
+		phpString("cli"), phpArrayOf(
+			phpString("command_line"), phpString("bin/console app:sync --force"),
+			phpString("identifier"), phpString("ab12"),
+		),
+	)
+
+	event, err := Decode(userMessage(context), dump.HostMeta{Hostname: "devbox"})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if event.SourceType != "cli" || event.PHPSAPI != "cli" || event.RequestID != nil {
+		t.Fatalf("Decode() sourceType = %q, sapi = %q, requestId = %v, want cli without request", event.SourceType, event.PHPSAPI, event.RequestID)
+	}
+	if event.Command == nil || event.Command.Name != "bin/console" || strings.Join(event.Command.Args, " ") != "app:sync --force" {
+		t.Fatalf("Decode() command = %+v, want bin/console app:sync --force", event.Command)
+	}
+}
+
+func TestDecode_RejectsOtherPayloads(t *testing.T) {
+	tests := map[string]string{
+		"not base64":      "%%%",
+		"not serialized":  base64.StdEncoding.EncodeToString([]byte("hello")),
+		"not a data pair": base64.StdEncoding.EncodeToString([]byte(phpArrayOf("i:0;", "i:1;", "i:1;", "i:2;"))),
+	}
+
+	for name, line := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode(line, dump.HostMeta{}); err == nil {
+				t.Fatalf("Decode() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestUnserialize_ResolvesObjectReferences(t *testing.T) {
+	value, err := unserialize([]byte(`a:2:{i:0;O:8:"stdClass":1:{s:1:"a";s:3:"x;y";}i:1;r:2;}`))
+	if err != nil {
+		t.Fatalf("unserialize() error = %v", err)
+	}
+
+	array := value.(*phpArray)
+	if array.Values[0] != array.Values[1] {
+		t.Fatalf("unserialize() reference = %v, want the first object", array.Values[1])
+	}
+	if text, _ := array.Values[0].(*phpObject).prop("a"); text != "x;y" {
+		t.Fatalf("unserialize() property = %v, want x;y", text)
+	}
+}
+
+func TestServer_IngestsMessagesFromPersistentConnection(t *testing.T) {
+	events := make(chan dump.Event, 2)
+	server := NewServer("127.0.0.1:0", func(event dump.Event) { events <- event })
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.Address())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	writer := bufio.NewWriter(conn)
+	message := userMessage(phpArrayOf())
+	fmt.Fprintf(writer, "%s\n%s\n%s\n", message, "garbage", message)
+	writer.Flush()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-events:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d events, want 2", i)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for server.Stats().Rejected != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stats := server.Stats(); stats.Received != 2 || stats.Rejected != 1 {
+		t.Fatalf("Stats() = %+v, want 2 received and 1 rejected", stats)
+	}
+}
@@ -0,0 +1,220 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"phant/internal/dump"
+	"phant/internal/retention"
+)
+
+const maxNameLength = 100
+
+var ErrEmptyRoot = errors.New("project root is required")
+
+// Settings are the parts of a project the user controls. Editor and path
+// mappings live with the other project overrides in config.Projects.
+type Settings struct {
+	// Name is shown instead of the root; empty falls back to the root's
+	// last path segment.
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	// Archived projects are hidden from the project list but still collect
+	// events.
+	Archived  bool             `json:"archived"`
+	Retention retention.Policy `json:"retention"`
+}
+
+// Project is a project root seen in incoming events or configured by the
+// user, with its settings and what has been observed of it.
+type Project struct {
+	Root string `json:"root"`
+	Settings
+	FirstSeen string `json:"firstSeen"`
+	LastSeen  string `json:"lastSeen"`
+	Events    int    `json:"events"`
+}
+
+func (s Settings) Validate() error {
+	if len(s.Name) > maxNameLength {
+		return fmt.Errorf("project name must be at most %d characters", maxNameLength)
+	}
+	if s.Color != "" && !dump.ValidColor(s.Color) {
+		return errors.New("project color must be a named color or #rgb/#rrggbb hex value")
+	}
+	return s.Retention.Validate()
+}
+
+func (s Settings) isZero() bool {
+	return s.Name == "" && s.Color == "" && !s.Archived && s.Retention.IsZero()
+}
+
+// Workspace is the registry of projects. Projects register themselves the
+// first time an event carries their root; settings are kept for roots that
+// have not been seen yet so an imported configuration applies on arrival.
+type Workspace struct {
+	mu       sync.Mutex
+	projects map[string]*Project
+}
+
+func New() *Workspace {
+	return &Workspace{projects: make(map[string]*Project)}
+}
+
+// Observe counts an event towards its project, registering the project if
+// needed. It reports whether the project is new.
+func (w *Workspace) Observe(event dump.Event) bool {
+	if event.ProjectRoot == "" {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	project, known := w.projects[event.ProjectRoot]
+	if !known {
+		project = w.register(event.ProjectRoot)
+	}
+	if project.FirstSeen == "" {
+		project.FirstSeen = event.Timestamp
+	}
+	project.LastSeen = event.Timestamp
+	project.Events++
+	return !known
+}
+
+// Projects lists registered projects by display name, then root.
+func (w *Workspace) Projects(includeArchived bool) []Project {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	projects := make([]Project, 0, len(w.projects))
+	for _, project := range w.projects {
+		if project.Archived && !includeArchived {
+			continue
+		}
+		projects = append(projects, w.view(project))
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		a, b := strings.ToLower(projects[i].Name), strings.ToLower(projects[j].Name)
+		if a != b {
+			return a < b
+		}
+		return projects[i].Root < projects[j].Root
+	})
+	return projects
+}
+
+func (w *Workspace) Project(root string) (Project, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	project, ok := w.projects[root]
+	if !ok {
+		return Project{}, false
+	}
+	return w.view(project), true
+}
+
+// Update replaces a project's settings, registering the root if it has not
+// been seen yet.
+func (w *Workspace) Update(root string, settings Settings) (Project, error) {
+	if root == "" {
+		return Project{}, ErrEmptyRoot
+	}
+	if err := settings.Validate(); err != nil {
+		return Project{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	project, ok := w.projects[root]
+	if !ok {
+		project = w.register(root)
+	}
+	project.Settings = settings
+	return w.view(project), nil
+}
+
+// Settings returns the settings worth persisting, keyed by root.
+func (w *Workspace) Settings() map[string]Settings {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	settings := make(map[string]Settings)
+	for root, project := range w.projects {
+		if !project.Settings.isZero() {
+			settings[root] = project.Settings
+		}
+	}
+	return settings
+}
+
+// Load applies persisted settings. Nothing is changed if any entry is
+// invalid.
+func (w *Workspace) Load(settings map[string]Settings) error {
+	for root, entry := range settings {
+		if root == "" {
+			return ErrEmptyRoot
+		}
+		if err := entry.Validate(); err != nil {
+			return fmt.Errorf("project %s: %w", root, err)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for root, entry := range settings {
+		project, ok := w.projects[root]
+		if !ok {
+			project = w.register(root)
+		}
+		project.Settings = entry
+	}
+	return nil
+}
+
+// RetentionPolicies returns the projects that set their own retention.
+func (w *Workspace) RetentionPolicies() map[string]retention.Policy {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	policies := make(map[string]retention.Policy)
+	for root, project := range w.projects {
+		if !project.Retention.IsZero() {
+			policies[root] = project.Retention
+		}
+	}
+	return policies
+}
+
+func (w *Workspace) register(root string) *Project {
+	project := &Project{Root: root}
+	w.projects[root] = project
+	return project
+}
+
+func (w *Workspace) view(project *Project) Project {
+	view := *project
+	if view.Name == "" {
+		view.Name = DefaultName(project.Root)
+	}
+	return view
+}
+
+// DefaultName is the last segment of root, which is usually the checkout
+// directory. Windows separators are handled so roots reported by producers
+// on other platforms still get a readable name.
+func DefaultName(root string) string {
+	trimmed := strings.TrimRight(strings.ReplaceAll(root, "\\", "/"), "/")
+	if trimmed == "" {
+		return root
+	}
+	return path.Base(trimmed)
+}
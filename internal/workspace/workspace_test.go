@@ -0,0 +1,90 @@
+package workspace
+
+import (
+	"testing"
+
+	"phant/internal/dump"
+	"phant/internal/retention"
+)
+
+func TestWorkspace_ObserveRegistersAndCountsProjects(t *testing.T) {
+	workspace := New()
+	events := []dump.Event{
+		{ProjectRoot: "/srv/shop", Timestamp: "2026-03-02T12:00:00Z"},
+		{ProjectRoot: "/srv/shop", Timestamp: "2026-03-02T12:00:05Z"},
+		{ProjectRoot: `C:\work\Api\`, Timestamp: "2026-03-02T12:00:06Z"},
+		{Timestamp: "2026-03-02T12:00:07Z"},
+	}
+
+	var added int
+	for _, event := range events {
+		if workspace.Observe(event) {
+			added++
+		}
+	}
+
+	projects := workspace.Projects(false)
+	if added != 2 || len(projects) != 2 {
+		t.Fatalf("Observe() added %d, Projects() = %+v, want two projects", added, projects)
+	}
+	if projects[0].Name != "Api" || projects[1].Name != "shop" {
+		t.Fatalf("Projects() names = %q, %q, want Api and shop", projects[0].Name, projects[1].Name)
+	}
+	shop := projects[1]
+	if shop.Events != 2 || shop.FirstSeen != "2026-03-02T12:00:00Z" || shop.LastSeen != "2026-03-02T12:00:05Z" {
+		t.Fatalf("Projects() shop = %+v, want two events with first and last seen", shop)
+	}
+}
+
+func TestWorkspace_UpdateAppliesSettingsAndHidesArchived(t *testing.T) {
+	workspace := New()
+	workspace.Observe(dump.Event{ProjectRoot: "/srv/shop"})
+
+	if _, err := workspace.Update("/srv/shop", Settings{Color: "magenta"}); err == nil {
+		t.Fatalf("Update(bad color) error = nil, want error")
+	}
+	if _, err := workspace.Update("", Settings{}); err == nil {
+		t.Fatalf("Update(empty root) error = nil, want error")
+	}
+
+	project, err := workspace.Update("/srv/shop", Settings{Name: "Shop", Color: "#0af", Archived: true, Retention: retention.Policy{MaxEvents: 10}})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if project.Name != "Shop" || project.Color != "#0af" {
+		t.Fatalf("Update() = %+v, want name and color set", project)
+	}
+
+	if got := workspace.Projects(false); len(got) != 0 {
+		t.Fatalf("Projects(false) = %+v, want archived project hidden", got)
+	}
+	if got := workspace.Projects(true); len(got) != 1 {
+		t.Fatalf("Projects(true) = %+v, want archived project listed", got)
+	}
+	if policies := workspace.RetentionPolicies(); policies["/srv/shop"].MaxEvents != 10 {
+		t.Fatalf("RetentionPolicies() = %+v, want the project's policy", policies)
+	}
+}
+
+func TestWorkspace_LoadKeepsSettingsForUnseenRoots(t *testing.T) {
+	workspace := New()
+	if err := workspace.Load(map[string]Settings{"/srv/a": {Retention: retention.Policy{MaxEvents: -1}}}); err == nil {
+		t.Fatalf("Load(invalid) error = nil, want error")
+	}
+	if len(workspace.Projects(true)) != 0 {
+		t.Fatalf("Load(invalid) registered projects, want none")
+	}
+
+	if err := workspace.Load(map[string]Settings{"/srv/a": {Name: "Alpha"}}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	workspace.Observe(dump.Event{ProjectRoot: "/srv/a", Timestamp: "2026-03-02T12:00:00Z"})
+
+	project, ok := workspace.Project("/srv/a")
+	if !ok || project.Name != "Alpha" || project.Events != 1 {
+		t.Fatalf("Project() = %+v %v, want loaded name and one event", project, ok)
+	}
+	if settings := workspace.Settings(); len(settings) != 1 || settings["/srv/a"].Name != "Alpha" {
+		t.Fatalf("Settings() = %+v, want only the configured project", settings)
+	}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"io"
+	"os"
+	"os/signal"
 	"phant/internal/services"
+	"syscall"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
@@ -10,7 +15,24 @@ import (
 //go:embed frontend/dist
 var assets embed.FS
 
+//go:embed build/appicon.png
+var trayIcon []byte
+
 func main() {
+	if len(os.Args) > 1 {
+		commands := map[string]func(context.Context, []string, io.Writer, io.Writer) int{
+			"tail":         runTail,
+			"soak":         runSoak,
+			"schema-check": runSchemaCheck,
+		}
+		if command, ok := commands[os.Args[1]]; ok {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			code := command(ctx, os.Args[2:], os.Stdout, os.Stderr)
+			stop()
+			os.Exit(code)
+		}
+	}
+
 	appServices := services.NewAppServices()
 
 	app := application.New(application.Options{
@@ -20,6 +42,9 @@ func main() {
 			application.NewService(appServices.Dump),
 			application.NewService(appServices.Setup),
 			application.NewService(appServices.PHP),
+			application.NewService(appServices.Config),
+			application.NewService(appServices.Notifications),
+			application.NewServiceWithOptions(appServices.Binary, application.ServiceOptions{Route: services.BinaryRoute}),
 		},
 		Assets: application.AssetOptions{
 			Handler: application.AssetFileServerFS(assets),
@@ -27,12 +52,14 @@ func main() {
 	})
 	services.AttachApplication(appServices.Lifecycle, app)
 
-	app.Window.NewWithOptions(application.WebviewWindowOptions{
+	window := app.Window.NewWithOptions(application.WebviewWindowOptions{
 		Title:            "Phant",
 		Width:            1024,
 		Height:           768,
+		Hidden:           services.StartsHidden(appServices.Lifecycle),
 		BackgroundColour: application.NewRGBA(27, 38, 54, 255),
 	})
+	services.AttachTray(appServices.Lifecycle, window, trayIcon)
 
 	err := app.Run()
 
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"phant/internal/cli"
+	"phant/internal/schemacheck"
+)
+
+// runSchemaCheck implements `phant schema-check`. It exits 1 when events
+// that pass today would be rejected under the requested version.
+func runSchemaCheck(_ context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	options, err := cli.ParseSchemaCheckArgs(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+
+	report, err := schemacheck.ValidateCorpusAgainst(options.Version, options.Path)
+	if err != nil {
+		fmt.Fprintf(stderr, "phant schema-check: %v\n", err)
+		return 1
+	}
+
+	if options.JSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		fmt.Fprintf(stdout, "schemaVersion %d: %d events in %d files: %d pass, %d break, %d already invalid, %d fixed\n",
+			report.Version, report.Lines, len(report.Files), report.Passing, report.Breaking, report.AlreadyInvalid, report.Fixed)
+		for _, issue := range report.Issues {
+			message := issue.Message
+			if issue.Field != "" {
+				message = issue.Field + ": " + message
+			}
+			fmt.Fprintf(stdout, "%6d  %s\n", issue.Count, message)
+		}
+		for _, sample := range report.Samples {
+			fmt.Fprintf(stdout, "%s:%d %s: %s\n", sample.File, sample.Line, sample.EventID, sample.Issues[0].Message)
+		}
+	}
+
+	if report.Breaking > 0 {
+		return 1
+	}
+	return 0
+}
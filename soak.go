@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"phant/internal/cli"
+	"phant/internal/services"
+	"phant/internal/soak"
+)
+
+// runSoak implements `phant soak`: the headless pipeline, fed synthetic
+// events through its own socket, with sessions and payloads kept in a
+// temporary directory so the user's history is untouched.
+func runSoak(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	options, err := cli.ParseSoakArgs(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+
+	dir, err := os.MkdirTemp("", "phant-soak-")
+	if err != nil {
+		fmt.Fprintf(stderr, "phant soak: %v\n", err)
+		return 1
+	}
+	if options.KeepData {
+		fmt.Fprintf(stderr, "phant soak: keeping data in %s\n", dir)
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	socketPath := filepath.Join(dir, "collector.sock")
+	appServices := services.NewAppServicesWithOptions(services.Options{
+		SocketPath: socketPath,
+		PayloadDir: filepath.Join(dir, "payloads"),
+		StoreDir:   filepath.Join(dir, "sessions"),
+	})
+	if err := appServices.StartHeadless(); err != nil {
+		fmt.Fprintf(stderr, "phant soak: %v\n", err)
+		return 1
+	}
+	defer appServices.StopHeadless()
+
+	fmt.Fprintf(stderr, "phant soak: %d events/s for %s after %s warmup, sampling every %s\n",
+		options.Soak.Rate, options.Soak.Duration, options.Soak.Warmup, options.Soak.SampleInterval)
+	report, err := soak.Run(ctx, socketPath, options.Soak, func(sample soak.Sample) {
+		fmt.Fprintf(stderr, "phant soak: %8s sent=%d heap=%.1fMiB goroutines=%d\n",
+			sample.Elapsed.Round(time.Second), sample.Sent, float64(sample.HeapBytes)/(1<<20), sample.Goroutines)
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "phant soak: %v\n", err)
+		return 1
+	}
+
+	if len(report.Findings) == 0 {
+		fmt.Fprintf(stdout, "no leaks detected: %d events, %d samples\n", report.Sent, len(report.Samples))
+		return 0
+	}
+	for _, finding := range report.Findings {
+		fmt.Fprintf(stdout, "possible leak: %s\n", finding)
+	}
+	return 1
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"phant/internal/cli"
+	"phant/internal/services"
+)
+
+const tailBuffer = 4096
+
+// runTail implements `phant tail`: the same ingest pipeline as the desktop
+// app, printing events instead of showing a window.
+func runTail(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	options, err := cli.ParseTailArgs(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+
+	appServices := services.NewAppServicesWithOptions(services.Options{SocketPath: options.Socket})
+	if err := appServices.StartHeadless(); err != nil {
+		fmt.Fprintf(stderr, "phant tail: %v\n", err)
+		return 1
+	}
+	defer appServices.StopHeadless()
+
+	listeners := []struct {
+		name    string
+		address string
+		set     func(string) error
+	}{
+		{"ray", options.Listen, func(address string) error { _, err := appServices.Dump.SetRayAddress(address); return err }},
+		{"var-dumper", options.VarDumper, func(address string) error { _, err := appServices.Dump.SetVarDumperAddress(address); return err }},
+		{"monolog", options.Monolog, func(address string) error { _, err := appServices.Dump.SetLogAddress(address); return err }},
+	}
+	for _, listener := range listeners {
+		if listener.address == "" {
+			continue
+		}
+		if err := listener.set(listener.address); err != nil {
+			fmt.Fprintf(stderr, "phant tail: %s listener: %v\n", listener.name, err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "phant tail: %s listening on %s\n", listener.name, listener.address)
+	}
+	fmt.Fprintf(stderr, "phant tail: collector socket %s\n", appServices.Dump.GetCollectorStatus().SocketPath)
+
+	events, cancel, err := appServices.Events(tailBuffer)
+	if err != nil {
+		fmt.Fprintf(stderr, "phant tail: %v\n", err)
+		return 1
+	}
+	defer cancel()
+
+	printer := cli.NewPrinter(stdout, options.Format)
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case event, ok := <-events:
+			if !ok {
+				return 0
+			}
+			if err := printer.Print(event); err != nil {
+				fmt.Fprintf(stderr, "phant tail: %v\n", err)
+				return 1
+			}
+		}
+	}
+}